@@ -0,0 +1,108 @@
+//go:build js && wasm
+
+// Command cmdfuscator-wasm exposes the obfuscator to JavaScript, for browser
+// front ends that want the Go implementation and its embedded profiles in
+// place of, or next to, the TypeScript one. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o cmdfuscator.wasm ./cmd/cmdfuscator-wasm
+//
+// and load it with the wasm_exec.js shipped in $(go env GOROOT)/lib/wasm.
+// It defines a global cmdfuscator object:
+//
+//	const {output, error} = cmdfuscator.obfuscate("certutil -urlcache -f https://x", "",
+//		{seed: 1, modifiers: ["RandomCase"], target: "cmd"});
+//
+// exe picks the profile by executable name or alias; "" detects it from the
+// command. Every option is optional. error is null on success, and a message
+// otherwise, with output "".
+package main
+
+import (
+	"context"
+	"fmt"
+	"syscall/js"
+
+	"cmdFuscator"
+	"cmdFuscator/engine/modifiers"
+)
+
+func main() {
+	js.Global().Set("cmdfuscator", js.ValueOf(map[string]any{
+		"obfuscate": js.FuncOf(obfuscate),
+		"modifiers": js.FuncOf(modifierNames),
+	}))
+	select {} // keep the functions callable
+}
+
+// obfuscate implements cmdfuscator.obfuscate(command, exe, options).
+func obfuscate(_ js.Value, args []js.Value) any {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return result("", fmt.Errorf("obfuscate: want (command, exe, options)"))
+	}
+	opts, err := options(args[1:])
+	if err != nil {
+		return result("", err)
+	}
+	out, err := cmdfuscator.Obfuscate(context.Background(), args[0].String(), opts...)
+	return result(out, err)
+}
+
+// options converts the exe and options arguments.
+func options(args []js.Value) ([]cmdfuscator.Option, error) {
+	var opts []cmdfuscator.Option
+	if len(args) > 0 && args[0].Type() == js.TypeString && args[0].String() != "" {
+		opts = append(opts, cmdfuscator.WithExecutable(args[0].String()))
+	}
+	if len(args) < 2 || args[1].IsUndefined() || args[1].IsNull() {
+		return opts, nil
+	}
+	o := args[1]
+	if o.Type() != js.TypeObject {
+		return nil, fmt.Errorf("obfuscate: options must be an object")
+	}
+	switch seed := o.Get("seed"); seed.Type() {
+	case js.TypeUndefined, js.TypeNull:
+	case js.TypeNumber:
+		opts = append(opts, cmdfuscator.WithSeed(int64(seed.Float())))
+	default:
+		return nil, fmt.Errorf("obfuscate: options.seed must be a number")
+	}
+	if mods := o.Get("modifiers"); !mods.IsUndefined() && !mods.IsNull() {
+		if !js.Global().Get("Array").Call("isArray", mods).Bool() {
+			return nil, fmt.Errorf("obfuscate: options.modifiers must be an array of names")
+		}
+		names := make([]string, mods.Length())
+		for i := range names {
+			if mods.Index(i).Type() != js.TypeString {
+				return nil, fmt.Errorf("obfuscate: options.modifiers[%d] is not a string", i)
+			}
+			names[i] = mods.Index(i).String()
+		}
+		opts = append(opts, cmdfuscator.WithModifiers(names...))
+	}
+	switch target := o.Get("target"); target.Type() {
+	case js.TypeUndefined, js.TypeNull:
+	case js.TypeString:
+		opts = append(opts, cmdfuscator.WithTarget(target.String()))
+	default:
+		return nil, fmt.Errorf("obfuscate: options.target must be a string")
+	}
+	return opts, nil
+}
+
+func result(output string, err error) any {
+	if err != nil {
+		return map[string]any{"output": "", "error": err.Error()}
+	}
+	return map[string]any{"output": output, "error": nil}
+}
+
+// modifierNames implements cmdfuscator.modifiers(), the registered modifier
+// names in registration order.
+func modifierNames(js.Value, []js.Value) any {
+	var names []any
+	for _, m := range modifiers.All() {
+		names = append(names, m.Name())
+	}
+	return names
+}
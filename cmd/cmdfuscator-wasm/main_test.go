@@ -0,0 +1,51 @@
+//go:build js && wasm
+
+// Run with: GOOS=js GOARCH=wasm go test -exec="$(go env GOROOT)/lib/wasm/go_js_wasm_exec" ./cmd/cmdfuscator-wasm
+package main
+
+import (
+	"strings"
+	"syscall/js"
+	"testing"
+)
+
+func TestObfuscate(t *testing.T) {
+	call := func(args ...any) (output string, err js.Value) {
+		vals := make([]js.Value, len(args))
+		for i, a := range args {
+			vals[i] = js.ValueOf(a)
+		}
+		res := js.ValueOf(obfuscate(js.Undefined(), vals))
+		return res.Get("output").String(), res.Get("error")
+	}
+
+	out, err := call("tool -abc", "bash", map[string]any{"seed": 1, "modifiers": []any{"RandomCase"}, "target": "none"})
+	if !err.IsNull() {
+		t.Fatalf("error = %v", err)
+	}
+	if !strings.EqualFold(out, "tool -abc") {
+		t.Errorf("output = %q", out)
+	}
+	again, _ := call("tool -abc", "bash", map[string]any{"seed": 1, "modifiers": []any{"RandomCase"}, "target": "none"})
+	if again != out {
+		t.Errorf("same seed gave %q, then %q", out, again)
+	}
+
+	tests := []struct {
+		args []any
+		want string
+	}{
+		{nil, "want (command, exe, options)"},
+		{[]any{"tool -f"}, "no profile matches"},
+		{[]any{"tool -f", "bash", "RandomCase"}, "options must be an object"},
+		{[]any{"tool -f", "bash", map[string]any{"seed": "1"}}, "options.seed"},
+		{[]any{"tool -f", "bash", map[string]any{"modifiers": "RandomCase"}}, "array of names"},
+		{[]any{"tool -f", "bash", map[string]any{"modifiers": []any{1}}}, "modifiers[0]"},
+		{[]any{"tool -f", "bash", map[string]any{"target": "tcsh"}}, "tcsh"},
+	}
+	for _, tt := range tests {
+		if _, err := call(tt.args...); err.IsNull() || !strings.Contains(err.String(), tt.want) {
+			t.Errorf("obfuscate(%v): error = %v, want one containing %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+// ─── bench ────────────────────────────────────────────────────────────────────
+
+// bench implements `cmdfuscator bench`: it times every registered modifier,
+// then the whole pipeline, over a synthetic corpus: each profile's example
+// command and a command passing every argument the profile defines. Modifiers run on the tokens directly, so their rows leave out
+// tokenizing and rendering; the pipeline row includes both.
+//
+// The config file's modifiers and probabilities are ignored, so numbers from
+// different machines compare; its profile directories still apply.
+func (a *app) bench(args []string) int {
+	fset := a.flags("bench", "[flags]")
+	a.profileFlags(fset)
+	exe := fset.String("exe", "", "only use the example command of this executable's profiles")
+	mods := fset.String("modifiers", "", "comma-separated modifiers to time (default: all registered)")
+	benchtime := fset.Duration("benchtime", time.Second, "run each measurement for about `D`")
+	asJSON := fset.Bool("json", false, "print JSON instead of a table")
+	if code, ok := parse(fset, args); !ok {
+		return code
+	}
+	if fset.NArg() > 0 {
+		a.errorf("bench: unexpected argument %q", fset.Arg(0))
+		return exitUsage
+	}
+	if *benchtime <= 0 {
+		a.errorf("bench: --benchtime must be positive")
+		return exitUsage
+	}
+	var enabled map[string]bool
+	if *mods != "" {
+		var err error
+		if enabled, _, err = parseModifiers(*mods); err != nil {
+			a.errorf("bench: %v", err)
+			return exitUsage
+		}
+	}
+
+	profiles, err := a.loadProfiles()
+	if err != nil {
+		a.errorf("bench: %v", err)
+		return exitError
+	}
+	if *exe != "" {
+		index, _ := loader.BuildIndex(profiles)
+		pf, ok := index.Lookup(*exe)
+		if !ok {
+			a.errorf("bench: no profile for %q", *exe)
+			return exitError
+		}
+		profiles = []*models.ProfileFile{pf}
+	}
+	corpus := benchCorpus(profiles)
+	if len(corpus) == 0 {
+		a.errorf("bench: no profile has an example command or arguments")
+		return exitError
+	}
+
+	var rep benchReport
+	for _, mod := range modifiers.All() {
+		if enabled == nil || enabled[mod.Name()] {
+			rep.Modifiers = append(rep.Modifiers, benchModifier(mod, corpus, *benchtime))
+		}
+	}
+	rep.Pipeline = benchPipeline(corpus, enabled, *benchtime)
+
+	if *asJSON {
+		return a.printJSON(rep)
+	}
+	writeBench(a.stdout, rep)
+	return exitOK
+}
+
+// benchCase is one command of the corpus.
+type benchCase struct {
+	profile *models.ProfileFile
+	command string
+	tokens  []models.Token
+}
+
+// benchCorpus returns, for every profile in order, its example command and
+// one made up of all its arguments.
+func benchCorpus(profiles []*models.ProfileFile) []benchCase {
+	var corpus []benchCase
+	for _, pf := range profiles {
+		for _, p := range pf.Profiles {
+			// The engine runs a file's first profile, so the pipeline row
+			// gets a file holding just this one.
+			single := *pf
+			single.Profiles = []models.Profile{p}
+			if len(p.Parameters.Command) > 0 {
+				tokens := make([]models.Token, len(p.Parameters.Command))
+				for i, el := range p.Parameters.Command {
+					tokens[i] = el.ToToken()
+				}
+				corpus = append(corpus, benchCase{profile: &single, command: engine.Render(tokens), tokens: tokens})
+			}
+			if len(p.Parameters.Arguments) > 0 {
+				command := allArguments(pf.Name, p)
+				if tokens, err := engine.Tokenize(command, p); err == nil {
+					corpus = append(corpus, benchCase{profile: &single, command: command, tokens: tokens})
+				}
+			}
+		}
+	}
+	return corpus
+}
+
+// allArguments returns a command line running exe with every argument p
+// defines, each followed by placeholder paths and URLs for its values.
+func allArguments(exe string, p models.Profile) string {
+	words := []string{exe}
+	n := 0
+	for _, def := range p.Parameters.Arguments {
+		if len(def.Flags) == 0 {
+			continue
+		}
+		words = append(words, def.Flags[0])
+		for range def.ValueCount {
+			n++
+			switch {
+			case n%2 == 0:
+				words = append(words, fmt.Sprintf("https://example.com/payload%d.bin", n))
+			case p.Platform == "windows":
+				words = append(words, fmt.Sprintf(`C:\Users\Public\file%d.txt`, n))
+			default:
+				words = append(words, fmt.Sprintf("/tmp/file%d.txt", n))
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// benchResult is one row of the report. The per-op figures are per command.
+type benchResult struct {
+	Name           string  `json:"name"`
+	Commands       int     `json:"commands"` // corpus commands measured
+	Ops            int     `json:"ops"`
+	NsPerOp        int64   `json:"nsPerOp"`
+	BytesPerOp     int64   `json:"bytesPerOp"`
+	AllocsPerOp    int64   `json:"allocsPerOp"`
+	VariantsPerSec float64 `json:"variantsPerSec,omitempty"`
+	Note           string  `json:"note,omitempty"` // why the row is empty or partial
+}
+
+type benchReport struct {
+	Modifiers []benchResult `json:"modifiers"`
+	Pipeline  benchResult   `json:"pipeline"`
+}
+
+// benchModifier times mod over the corpus commands whose profile configures
+// it. A command it fails on is left out and counted in the row's note.
+func benchModifier(mod modifiers.Modifier, corpus []benchCase, d time.Duration) benchResult {
+	res := benchResult{Name: mod.Name()}
+	mc := modifiers.Context{Rand: rand.New(rand.NewSource(1))}
+	type input struct {
+		tokens   []models.Token
+		cfg      []byte
+		args     []models.ArgumentDefinition
+		exe      string
+		platform string
+		paths    []string
+	}
+	var inputs []input
+	failed := 0
+	var firstErr error
+	for _, c := range corpus {
+		cfg, ok := engine.ConfigFor(c.profile.Profiles[0], mod.Name())
+		if !ok {
+			continue
+		}
+		mc.Arguments = c.profile.Profiles[0].Parameters.Arguments
+		mc.Executable = c.profile.Name
+		mc.Platform, mc.Paths = c.profile.Profiles[0].Platform, c.profile.Profiles[0].Paths
+		if _, err := engine.ApplyModifier(mc, mod, c.tokens, cfg); err != nil {
+			if errors.Is(err, modifiers.ErrNotImplemented) {
+				res.Note = "not implemented"
+				return res
+			}
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", c.profile.Name, err)
+			}
+			continue
+		}
+		inputs = append(inputs, input{c.tokens, cfg, mc.Arguments, mc.Executable, mc.Platform, mc.Paths})
+	}
+	if failed > 0 {
+		res.Note = fmt.Sprintf("failed on %s, first %v", plural(failed, "command"), firstErr)
+	}
+	if len(inputs) == 0 {
+		if res.Note == "" {
+			res.Note = "no profile configures it"
+		}
+		return res
+	}
+	res.Commands = len(inputs)
+	m := measure(d, func(i int) {
+		in := inputs[i%len(inputs)]
+		mc.Arguments, mc.Executable = in.args, in.exe
+		mc.Platform, mc.Paths = in.platform, in.paths
+		engine.ApplyModifier(mc, mod, in.tokens, in.cfg)
+	})
+	m.fill(&res)
+	return res
+}
+
+// benchPipeline times Obfuscate over the whole corpus, with enabled, or each
+// profile's own modifiers when enabled is nil.
+func benchPipeline(corpus []benchCase, enabled map[string]bool, d time.Duration) benchResult {
+	res := benchResult{Name: "pipeline", Commands: len(corpus)}
+	eng := engine.New()
+	sets := make([]map[string]bool, len(corpus))
+	for i, c := range corpus {
+		sets[i] = enabled
+		if sets[i] == nil {
+			sets[i] = engine.DefaultEnabled(c.profile)
+		}
+	}
+	m := measure(d, func(i int) {
+		i %= len(corpus)
+		eng.Obfuscate(corpus[i].command, corpus[i].profile, sets[i])
+	})
+	m.fill(&res)
+	res.VariantsPerSec = float64(m.n) / m.elapsed.Seconds()
+	return res
+}
+
+// ─── Measuring ────────────────────────────────────────────────────────────────
+
+type measurement struct {
+	n       int
+	elapsed time.Duration
+	bytes   uint64
+	allocs  uint64
+}
+
+// measure calls op(0), op(1), … until a run takes at least d, growing the
+// number of calls between runs the way testing.B does, and returns the last
+// run's figures.
+func measure(d time.Duration, op func(i int)) measurement {
+	n := 1
+	for {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := range n {
+			op(i)
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		m := measurement{n: n, elapsed: elapsed, bytes: after.TotalAlloc - before.TotalAlloc, allocs: after.Mallocs - before.Mallocs}
+		if elapsed >= d || n >= 1e9 {
+			return m
+		}
+		// Aim 20% past d, growing at most 100x at a time.
+		next := n * 100
+		if elapsed > 0 {
+			next = min(next, int(int64(n)*int64(d)*6/5/int64(elapsed)))
+		}
+		n = max(next, n+1)
+	}
+}
+
+func (m measurement) fill(res *benchResult) {
+	res.Ops = m.n
+	res.NsPerOp = m.elapsed.Nanoseconds() / int64(m.n)
+	res.BytesPerOp = int64(m.bytes / uint64(m.n))
+	res.AllocsPerOp = int64(m.allocs / uint64(m.n))
+}
+
+// ─── Output ───────────────────────────────────────────────────────────────────
+
+func writeBench(w io.Writer, rep benchReport) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODIFIER\tCOMMANDS\tNS/OP\tB/OP\tALLOCS/OP\tNOTE")
+	for _, r := range slices.Concat(rep.Modifiers, []benchResult{rep.Pipeline}) {
+		if r.Ops == 0 {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t%s\n", r.Name, r.Note)
+			continue
+		}
+		note := r.Note
+		if r.VariantsPerSec > 0 {
+			note = fmt.Sprintf("%.0f variants/s", r.VariantsPerSec)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\n", r.Name, r.Commands, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp, note)
+	}
+	tw.Flush()
+}
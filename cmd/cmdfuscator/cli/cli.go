@@ -0,0 +1,272 @@
+// Package cli implements the non-interactive cmdfuscator subcommands, so the
+// tool can be used from scripts and pipelines as well as through the TUI.
+//
+//	cmdfuscator obfuscate --exe certutil --modifiers RandomCase "certutil -urlcache -f https://x"
+//
+// Run is the entry point; main calls it whenever arguments are given.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"cmdFuscator/cmd/cmdfuscator/config"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/loader"
+	"cmdFuscator/loader/remote"
+	"cmdFuscator/models"
+)
+
+// Exit codes returned by Run.
+const (
+	exitOK    = 0
+	exitError = 1 // the command ran and failed
+	exitUsage = 2 // bad flags or arguments
+)
+
+// app carries what every subcommand needs from the process.
+type app struct {
+	modelFS fs.FS
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	cfg     *config.Config
+
+	// remote loads the upstream profile set rather than the embedded one
+	// (see baseProfiles): the config file's profiles setting, or --remote.
+	remote bool
+
+	// ctx is done once the process is interrupted, which stops long batches,
+	// searches and corpus generations at the next modifier.
+	ctx context.Context
+}
+
+// command is one subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, args []string) int
+}
+
+// commands lists the subcommands in the order usage prints them.
+var commands = []command{
+	{"obfuscate", "obfuscate a command line and print the result", (*app).obfuscate},
+	{"deobfuscate", "print the normalized form of obfuscated command lines", (*app).deobfuscate},
+	{"profiles", "list the bundled executables or describe one", (*app).profiles},
+	{"validate", "check profile directories against the schema and linter", (*app).validate},
+	{"corpus", "write a labelled JSONL dataset of variants of every profile's example", (*app).corpus},
+	{"bench", "time every modifier and the whole pipeline over the profiles' examples", (*app).bench},
+	{"completion", "print a completion script for bash, zsh, fish or powershell", (*app).completion},
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit
+// code. modelFS is the embedded profile data, as passed to tui.New; profiles
+// in the configured overlay directories, loader.DefaultUserDir() by default,
+// are overlaid on it.
+func Run(modelFS fs.FS, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	a := &app{modelFS: modelFS, stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		a.usage(a.stderr)
+		return exitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		a.usage(a.stdout)
+		return exitOK
+	}
+	var run func(*app, []string) int
+	if args[0] == completeCommand {
+		// The completion scripts' callback, not listed in usage; it cannot be
+		// in commands, which it lists.
+		run = (*app).complete
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			run = c.run
+		}
+	}
+	if run == nil {
+		a.errorf("unknown command %q", args[0])
+		a.usage(a.stderr)
+		return exitUsage
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		a.errorf("%v", err)
+		return exitError
+	}
+	a.cfg = cfg
+	a.remote = cfg.Profiles == "remote"
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	a.ctx = ctx
+	return run(a, args[1:])
+}
+
+func (a *app) usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: cmdfuscator                      start the interactive TUI")
+	fmt.Fprintln(w, "       cmdfuscator <command> [flags] ...")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "cmdfuscator <command> -h" for the command's flags.`)
+}
+
+// errorf prints an error message to stderr.
+func (a *app) errorf(format string, args ...any) {
+	fmt.Fprintf(a.stderr, "cmdfuscator: "+format+"\n", args...)
+}
+
+// warnf prints a warning to stderr; the command carries on.
+func (a *app) warnf(format string, args ...any) {
+	fmt.Fprintf(a.stderr, "cmdfuscator: warning: "+format+"\n", args...)
+}
+
+// flags returns a FlagSet for a subcommand that prints its errors and usage
+// to stderr. Parse errors are returned, not fatal.
+func (a *app) flags(name, synopsis string) *flag.FlagSet {
+	fset := flag.NewFlagSet(name, flag.ContinueOnError)
+	fset.SetOutput(a.stderr)
+	fset.Usage = func() {
+		fmt.Fprintf(a.stderr, "usage: cmdfuscator %s %s\n\nflags:\n", name, synopsis)
+		fset.PrintDefaults()
+	}
+	return fset
+}
+
+// parse parses args into fset. ok is false when the command should stop, with
+// code as its exit code: exitOK for -h, exitUsage for bad flags.
+func parse(fset *flag.FlagSet, args []string) (code int, ok bool) {
+	switch err := fset.Parse(args); {
+	case err == flag.ErrHelp:
+		return exitOK, false
+	case err != nil:
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// parseInterspersed is parse for commands whose positional arguments are
+// names rather than a command line, so flags may also follow them, as in
+// `profiles show certutil --json`. It returns the positional arguments.
+func parseInterspersed(fset *flag.FlagSet, args []string) (pos []string, code int, ok bool) {
+	for {
+		if code, ok := parse(fset, args); !ok {
+			return nil, code, false
+		}
+		if fset.NArg() == 0 {
+			return pos, exitOK, true
+		}
+		pos = append(pos, fset.Arg(0))
+		args = fset.Args()[1:]
+	}
+}
+
+// ─── Profiles ─────────────────────────────────────────────────────────────────
+
+// profileFlags adds the flags of the commands that load profiles to fset:
+// --remote, and --manifest and --public-key, which override the config
+// file's manifest and public_key.
+func (a *app) profileFlags(fset *flag.FlagSet) {
+	fset.BoolVar(&a.remote, "remote", a.remote, "use ArgFuscator.net's current profiles, synced daily into the user cache, with the built-in ones as fallback (default: the config file's profiles setting)")
+	fset.StringVar(&a.cfg.Manifest, "manifest", a.cfg.Manifest, "only load overlay profiles listed, with their SHA-256, in the manifest `NAME` in each overlay directory (default: the config file's; SHA256SUMS with --public-key)")
+	fset.StringVar(&a.cfg.PublicKey, "public-key", a.cfg.PublicKey, "require each overlay manifest to be signed by the minisign public `KEY`, given as the key or its file (default: the config file's)")
+}
+
+// loadProfiles loads the base profiles with the user overlays on top,
+// falling back to the base set alone if the overlay cannot be read or fails
+// verification, as the TUI does. Rejected files are reported as a warning.
+func (a *app) loadProfiles() ([]*models.ProfileFile, error) {
+	v, err := a.cfg.Verifier()
+	if err != nil {
+		return nil, err
+	}
+	base, err := a.baseProfiles()
+	if err != nil {
+		return nil, err
+	}
+	profiles, rep, err := loader.LoadWithOverlayVerified(base, v, a.cfg.Dirs()...)
+	if err != nil {
+		a.warnf("overlay error, using base profiles only: %v", err)
+		profiles, rep, err = loader.LoadFSReport(base)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !rep.OK() {
+		a.warnf("%s", rep.Summary())
+	}
+	return profiles, nil
+}
+
+// baseProfiles returns the profile set the overlays are laid over: the
+// embedded one, or with a.remote the upstream one, refreshed when the cache
+// is a day old. When upstream cannot be reached the stale cache or, without
+// one, the embedded set is used, with a warning.
+func (a *app) baseProfiles() (fs.FS, error) {
+	sub, err := fs.Sub(a.modelFS, "models")
+	if err != nil {
+		return nil, fmt.Errorf("profiles: %w", err)
+	}
+	if !a.remote {
+		return sub, nil
+	}
+	fsys, rep, err := remote.New(remote.DefaultCacheDir(), remote.WithFallback(sub)).FS(a.ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case rep.Source == remote.SourceFallback:
+		a.warnf("%v; using the built-in profiles", rep.SyncErr)
+	case rep.SyncErr != nil:
+		a.warnf("%v; using the cached profiles of %s", rep.SyncErr, rep.Fetched.Format(time.DateOnly))
+	}
+	return fsys, nil
+}
+
+// ─── Modifiers ────────────────────────────────────────────────────────────────
+
+// parseModifiers parses a comma-separated list of modifier names, as given
+// to --modifiers, into an enabled set, keeping the names' order. Every name
+// must be registered.
+func parseModifiers(list string) (map[string]bool, []string, error) {
+	enabled := make(map[string]bool)
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || enabled[name] {
+			continue
+		}
+		if _, ok := modifiers.Get(name); !ok {
+			return nil, nil, fmt.Errorf("unknown modifier %q (known: %s)", name, strings.Join(modifierNames(), ", "))
+		}
+		enabled[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("no modifiers in %q", list)
+	}
+	return enabled, names, nil
+}
+
+// modifierNames returns the registered modifier names, sorted.
+func modifierNames() []string {
+	all := modifiers.All()
+	names := make([]string, len(all))
+	for i, m := range all {
+		names[i] = m.Name()
+	}
+	sort.Strings(names)
+	return names
+}
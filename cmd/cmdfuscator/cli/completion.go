@@ -0,0 +1,321 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/export"
+)
+
+// ─── completion ───────────────────────────────────────────────────────────────
+
+// completion implements `cmdfuscator completion SHELL`, which prints a
+// completion script for SHELL. The scripts call back into the hidden
+// __complete command, so executable and modifier names come from the profiles
+// actually installed, overlays included.
+func (a *app) completion(args []string) int {
+	fset := a.flags("completion", "bash|zsh|fish|powershell")
+	if code, ok := parse(fset, args); !ok {
+		return code
+	}
+	if fset.NArg() != 1 {
+		a.errorf("completion: want exactly one shell: %s", strings.Join(shellNames(), ", "))
+		fset.Usage()
+		return exitUsage
+	}
+	script, ok := completionScripts[fset.Arg(0)]
+	if !ok {
+		a.errorf("completion: unknown shell %q (known: %s)", fset.Arg(0), strings.Join(shellNames(), ", "))
+		return exitUsage
+	}
+	fmt.Fprint(a.stdout, script)
+	return exitOK
+}
+
+func shellNames() []string {
+	return []string{"bash", "zsh", "fish", "powershell"}
+}
+
+// completeCommand is the hidden command the completion scripts run.
+const completeCommand = "__complete"
+
+// complete implements `cmdfuscator __complete WORD... :CURRENT`: it prints,
+// one per line, the candidates for CURRENT, the word being completed, given
+// the WORDs before it on the command line. CURRENT is prefixed with a colon
+// so that an empty word survives shells that drop empty arguments. bash
+// splits "--exe=cert" into "--exe", "=", "cert"; lone "=" words are dropped,
+// which leaves it the value alone to complete. No output tells the scripts to
+// fall back to completing file names.
+func (a *app) complete(args []string) int {
+	if len(args) == 0 || !strings.HasPrefix(args[len(args)-1], ":") {
+		a.errorf("%s: want WORD... :CURRENT", completeCommand)
+		return exitUsage
+	}
+	cur := strings.TrimPrefix(args[len(args)-1], ":")
+	var words []string
+	for _, w := range args[:len(args)-1] {
+		if w != "=" {
+			words = append(words, w)
+		}
+	}
+	// Completion runs on every Tab; warnings about the profiles would
+	// scribble over the user's command line.
+	quiet := *a
+	quiet.stderr = io.Discard
+	for _, c := range quiet.candidates(words, cur) {
+		fmt.Fprintln(a.stdout, c)
+	}
+	return exitOK
+}
+
+// valueKind says what a flag's value is, for completion.
+type valueKind int
+
+const (
+	noValue       valueKind = iota // a boolean flag
+	anyValue                       // a value with nothing to suggest
+	fileValue                      // a file name, left to the shell
+	profileValue                   // an executable name or alias
+	modifierList                   // a comma-separated list of modifier names
+	targetValue                    // a render target
+	platformValue                  // a profile platform
+	formatValue                    // an --out-format
+	queryValue                     // a --query-format
+	reportValue                    // a --report-format
+)
+
+// interspersed lists the commands whose flags may follow their positional
+// arguments (see parseInterspersed); for the others, everything after the
+// first positional argument is the command line being worked on.
+var interspersed = []string{"profiles list", "profiles show", "validate"}
+
+// completionFlags lists each command's flags. TestCompletionFlags checks it
+// against the commands' own flag sets.
+var completionFlags = map[string]map[string]valueKind{
+	"obfuscate": withProfileFlags(map[string]valueKind{
+		"exe": profileValue, "modifiers": modifierList, "target": targetValue, "stdin": noValue,
+		"seed": anyValue, "count": anyValue, "pipeline": fileValue, "explain": noValue,
+		"sigma": fileValue, "patterns": fileValue, "navigator": fileValue, "caldera": fileValue,
+		"out-format": formatValue, "verify": noValue, "evade": noValue, "budget": anyValue,
+		"query": fileValue, "query-format": queryValue, "report": fileValue, "report-format": reportValue,
+	}),
+	"deobfuscate":   withProfileFlags(map[string]valueKind{"keep-case": noValue, "json": noValue}),
+	"validate":      {"strict": noValue},
+	"profiles list": withProfileFlags(map[string]valueKind{"platform": platformValue, "json": noValue}),
+	"profiles show": withProfileFlags(map[string]valueKind{"json": noValue, "lolbas": fileValue}),
+	"corpus": withProfileFlags(map[string]valueKind{
+		"exe": profileValue, "platform": platformValue, "count": anyValue, "seed": anyValue,
+		"modifiers": modifierList, "min-modifiers": anyValue, "out": fileValue,
+	}),
+	"bench":      withProfileFlags(map[string]valueKind{"exe": profileValue, "modifiers": modifierList, "benchtime": anyValue, "json": noValue}),
+	"completion": {},
+}
+
+// withProfileFlags adds the flags of profileFlags to flags and returns it.
+func withProfileFlags(flags map[string]valueKind) map[string]valueKind {
+	flags["remote"] = noValue
+	flags["manifest"] = anyValue
+	flags["public-key"] = fileValue
+	return flags
+}
+
+// candidates returns the completions of cur after words, filtered by prefix.
+func (a *app) candidates(words []string, cur string) []string {
+	if len(words) == 0 {
+		names := []string{"help"}
+		for _, c := range commands {
+			names = append(names, c.name)
+		}
+		return matching(names, cur)
+	}
+	cmd, rest := words[0], words[1:]
+	if cmd == "profiles" {
+		if len(rest) == 0 {
+			return matching([]string{"list", "show"}, cur)
+		}
+		cmd, rest = cmd+" "+rest[0], rest[1:]
+	}
+	flags, ok := completionFlags[cmd]
+	if !ok || (!slices.Contains(interspersed, cmd) && positionals(rest, flags) > 0) {
+		return nil
+	}
+
+	// A flag's value: "--exe cert" or "--exe=cert".
+	if name, val, ok := strings.Cut(cur, "="); ok && strings.HasPrefix(name, "-") {
+		kind := flags[strings.TrimLeft(name, "-")]
+		var out []string
+		for _, v := range a.values(kind, val) {
+			out = append(out, name+"="+v)
+		}
+		return out
+	}
+	if len(rest) > 0 {
+		if kind, ok := flags[strings.TrimLeft(rest[len(rest)-1], "-")]; ok && isFlag(rest[len(rest)-1]) && kind != noValue {
+			return a.values(kind, cur)
+		}
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		var names []string
+		for name := range flags {
+			names = append(names, "--"+name)
+		}
+		slices.Sort(names)
+		return matching(names, cur)
+	}
+	if positionals(rest, flags) > 0 {
+		return nil
+	}
+	switch cmd {
+	case "obfuscate", "profiles show":
+		return a.values(profileValue, cur)
+	case "completion":
+		return matching(shellNames(), cur)
+	}
+	return nil
+}
+
+// values returns the candidates for a flag value of the given kind.
+func (a *app) values(kind valueKind, cur string) []string {
+	switch kind {
+	case profileValue:
+		profiles, err := a.loadProfiles()
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for _, pf := range profiles {
+			names = append(names, pf.Name)
+			names = append(names, pf.Aliases()...)
+		}
+		slices.Sort(names)
+		return matching(slices.Compact(names), cur)
+	case modifierList:
+		// Complete the last name of the list, leaving the others alone.
+		done, last := "", cur
+		if i := strings.LastIndex(cur, ","); i >= 0 {
+			done, last = cur[:i+1], cur[i+1:]
+		}
+		given := strings.Split(done, ",")
+		var out []string
+		for _, name := range matching(modifierNames(), last) {
+			if !slices.Contains(given, name) {
+				out = append(out, done+name)
+			}
+		}
+		return out
+	case targetValue:
+		var names []string
+		for _, t := range []engine.RenderTarget{engine.TargetAuto, engine.TargetCmd, engine.TargetPowerShell, engine.TargetBash, engine.TargetNone} {
+			names = append(names, t.String())
+		}
+		return matching(names, cur)
+	case platformValue:
+		return matching([]string{"windows", "linux", "macos"}, cur)
+	case formatValue:
+		names := []string{"text"}
+		for _, f := range []export.ScriptFormat{export.FormatBat, export.FormatPS1, export.FormatPS1UTF16, export.FormatSh} {
+			names = append(names, f.String())
+		}
+		return matching(names, cur)
+	case queryValue:
+		return matching([]string{export.QuerySPL.String(), export.QueryElastic.String()}, cur)
+	case reportValue:
+		return matching([]string{export.ReportCSV.String(), export.ReportMarkdown.String()}, cur)
+	}
+	return nil
+}
+
+// positionals counts the positional arguments in words.
+func positionals(words []string, flags map[string]valueKind) int {
+	n := 0
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if !isFlag(w) {
+			n++
+			continue
+		}
+		if kind, ok := flags[strings.TrimLeft(w, "-")]; ok && kind != noValue && !strings.Contains(w, "=") {
+			i++ // skip the flag's value
+		}
+	}
+	return n
+}
+
+func isFlag(w string) bool {
+	return strings.HasPrefix(w, "-") && w != "-"
+}
+
+// matching returns the entries of names that start with prefix, ignoring
+// case, since names such as OptionCharSubstitution are easy to mistype.
+func matching(names []string, prefix string) []string {
+	var out []string
+	for _, name := range names {
+		if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// ─── Scripts ──────────────────────────────────────────────────────────────────
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for cmdfuscator.
+# Load it with: source <(cmdfuscator completion bash)
+_cmdfuscator() {
+    local IFS=$'\n'
+    local cur=${COMP_WORDS[COMP_CWORD]}
+    [[ $cur == "=" ]] && cur=""
+    COMPREPLY=($(cmdfuscator __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" ":$cur" 2>/dev/null))
+}
+complete -o default -F _cmdfuscator cmdfuscator
+`,
+	"zsh": `#compdef cmdfuscator
+# zsh completion for cmdfuscator.
+# Load it with: source <(cmdfuscator completion zsh)
+_cmdfuscator() {
+    local -a candidates
+    candidates=("${(@f)$(cmdfuscator __complete "${(@)words[2,CURRENT-1]}" ":${words[CURRENT]}" 2>/dev/null)}")
+    if (( ${#candidates[@]} )) && [[ -n ${candidates[1]} ]]; then
+        compadd -Q -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+if [[ $funcstack[1] == _cmdfuscator ]]; then
+    _cmdfuscator "$@"
+else
+    compdef _cmdfuscator cmdfuscator
+fi
+`,
+	"fish": `# fish completion for cmdfuscator.
+# Load it with: cmdfuscator completion fish | source
+function __cmdfuscator_complete
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l out (cmdfuscator __complete $words ":"(commandline -ct) 2>/dev/null)
+    if test (count $out) -eq 0
+        __fish_complete_path (commandline -ct)
+    else
+        printf '%s\n' $out
+    end
+end
+complete -c cmdfuscator -f -a '(__cmdfuscator_complete)'
+`,
+	"powershell": `# PowerShell completion for cmdfuscator.
+# Load it with: cmdfuscator completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName cmdfuscator, cmdfuscator.exe -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 |
+        Where-Object { $_.Extent.EndOffset -lt $cursorPosition } |
+        ForEach-Object { $_.ToString() })
+    cmdfuscator __complete @words ":$wordToComplete" 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
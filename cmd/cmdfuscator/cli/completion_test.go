@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestCompletionFlags checks completionFlags against the flags each command
+// prints for -h: the same names, and values for exactly the non-boolean ones.
+func TestCompletionFlags(t *testing.T) {
+	flagLine := regexp.MustCompile(`(?m)^  -([a-z-]+)( \S+)?$`)
+	for cmd, want := range completionFlags {
+		t.Run(cmd, func(t *testing.T) {
+			code, _, stderr := run(t, "", append(strings.Fields(cmd), "-h")...)
+			if code != exitOK {
+				t.Fatalf("-h: exit code = %d, stderr %q", code, stderr)
+			}
+			got := make(map[string]bool)
+			for _, m := range flagLine.FindAllStringSubmatch(stderr, -1) {
+				got[m[1]] = m[2] != ""
+			}
+			for name, takesValue := range got {
+				kind, ok := want[name]
+				switch {
+				case !ok:
+					t.Errorf("--%s is missing from completionFlags", name)
+				case takesValue != (kind != noValue):
+					t.Errorf("--%s: takes a value = %v, but completionFlags says kind %d", name, takesValue, kind)
+				}
+			}
+			for name := range want {
+				if _, ok := got[name]; !ok {
+					t.Errorf("completionFlags lists --%s, which %s does not have", name, cmd)
+				}
+			}
+		})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"commands", []string{":"}, []string{"help", "obfuscate", "deobfuscate", "profiles", "validate", "corpus", "bench", "completion"}},
+		{"command prefix", []string{":de"}, []string{"deobfuscate"}},
+		{"profiles subcommands", []string{"profiles", ":"}, []string{"list", "show"}},
+		{"flags", []string{"deobfuscate", ":-"}, []string{"--json", "--keep-case", "--manifest", "--public-key", "--remote"}},
+		{"exe value", []string{"obfuscate", "--exe", ":certu"}, []string{"certutil"}},
+		{"exe value with =", []string{"obfuscate", ":--exe=CERTU"}, []string{"--exe=certutil"}},
+		{"bash exe value with =", []string{"obfuscate", "--exe", "=", ":certu"}, []string{"certutil"}},
+		{"modifier list", []string{"obfuscate", "--modifiers", ":RandomCase,Opt"}, []string{"RandomCase,OptionCharSubstitution"}},
+		{"modifier list skips given", []string{"obfuscate", "--modifiers", ":RandomCase,Random"}, nil},
+		{"target", []string{"obfuscate", "--target", ":p"}, []string{"powershell"}},
+		{"out-format", []string{"obfuscate", "--out-format", ":ps"}, []string{"ps1", "ps1-utf16"}},
+		{"query-format", []string{"obfuscate", "--query-format", ":"}, []string{"spl", "elastic"}},
+		{"platform", []string{"profiles", "list", "--platform", ":w"}, []string{"windows"}},
+		{"profile name", []string{"profiles", "show", ":certu"}, []string{"certutil"}},
+		{"obfuscate executable", []string{"obfuscate", "--stdin=false", ":certu"}, []string{"certutil"}},
+		{"command line being written", []string{"obfuscate", "certutil", ":-"}, nil},
+		{"file value left to the shell", []string{"obfuscate", "--pipeline", ":"}, nil},
+		{"interspersed flags", []string{"profiles", "show", "certutil", ":--j"}, []string{"--json"}},
+		{"shells", []string{"completion", ":"}, []string{"bash", "zsh", "fish", "powershell"}},
+		{"unknown command", []string{"nope", ":"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(t, "", append([]string{completeCommand}, tt.args...)...)
+			if code != exitOK {
+				t.Fatalf("exit code = %d, stderr %q", code, stderr)
+			}
+			if stderr != "" {
+				t.Errorf("stderr = %q, want nothing", stderr)
+			}
+			if got := strings.Fields(stdout); !slices.Equal(got, tt.want) {
+				t.Errorf("candidates = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	for _, shell := range shellNames() {
+		code, stdout, stderr := run(t, "", "completion", shell)
+		if code != exitOK {
+			t.Errorf("%s: exit code = %d, stderr %q", shell, code, stderr)
+		}
+		if !strings.Contains(stdout, "cmdfuscator "+completeCommand) {
+			t.Errorf("%s: script does not call %s:\n%s", shell, completeCommand, stdout)
+		}
+	}
+	for _, args := range [][]string{{"completion"}, {"completion", "tcsh"}} {
+		if code, _, _ := run(t, "", args...); code != exitUsage {
+			t.Errorf("%q: exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+	if code, stdout, _ := run(t, "", "help"); code != exitOK || strings.Contains(stdout, completeCommand) {
+		t.Errorf("usage lists %s:\n%s", completeCommand, stdout)
+	}
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"cmdFuscator/corpus"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+// ─── corpus ───────────────────────────────────────────────────────────────────
+
+// corpus implements `cmdfuscator corpus`: it writes a labelled JSON Lines
+// dataset of variants of every profile's example command, each made with a
+// random subset of the profile's modifiers (see package corpus).
+func (a *app) corpus(args []string) int {
+	fset := a.flags("corpus", "[flags]")
+	a.profileFlags(fset)
+	exe := fset.String("exe", "", "only use the profiles of this executable")
+	platform := fset.String("platform", "", "only use profiles for this platform (windows, linux, macos)")
+	count := fset.Int("count", 10, "generate `K` distinct variants per example command")
+	seed := fset.Int64("seed", 0, "seed the random choices, so the same profiles and flags always give the same dataset")
+	mods := fset.String("modifiers", "", "comma-separated modifiers to draw from (default: all each profile configures)")
+	minMods := fset.Int("min-modifiers", 1, "enable at least `N` modifiers per variant")
+	out := fset.String("out", "", "write the dataset to `FILE` instead of stdout")
+	if code, ok := parse(fset, args); !ok {
+		return code
+	}
+	switch {
+	case fset.NArg() > 0:
+		a.errorf("corpus: unexpected argument %q", fset.Arg(0))
+		return exitUsage
+	case *count < 1:
+		a.errorf("corpus: --count must be at least 1")
+		return exitUsage
+	case *minMods < 1:
+		a.errorf("corpus: --min-modifiers must be at least 1")
+		return exitUsage
+	}
+	opts := corpus.Options{Variants: *count, Seed: *seed, MinModifiers: *minMods, Engine: a.cfg.EngineOptions()}
+	if *mods != "" {
+		var err error
+		if _, opts.Modifiers, err = parseModifiers(*mods); err != nil {
+			a.errorf("corpus: %v", err)
+			return exitUsage
+		}
+	}
+
+	profiles, err := a.loadProfiles()
+	if err != nil {
+		a.errorf("corpus: %v", err)
+		return exitError
+	}
+	if *exe != "" {
+		index, _ := loader.BuildIndex(profiles)
+		pf, ok := index.Lookup(*exe)
+		if !ok {
+			a.errorf("corpus: no profile for %q", *exe)
+			return exitError
+		}
+		profiles = []*models.ProfileFile{pf}
+	}
+	if *platform != "" {
+		profiles = onPlatform(profiles, *platform)
+	}
+	if len(profiles) == 0 {
+		a.errorf("corpus: no profile for platform %q", *platform)
+		return exitError
+	}
+
+	var w io.Writer = a.stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			a.errorf("corpus: %v", err)
+			return exitError
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	st, err := corpus.Generate(a.ctx, profiles, opts, corpus.JSONL(bw))
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		a.errorf("corpus: %v", err)
+		return exitError
+	}
+	if len(st.Short) > 0 {
+		a.warnf("fewer than %d distinct variants for %s", *count, strings.Join(st.Short, ", "))
+	}
+	if st.Templates == 0 {
+		a.warnf("no profile has an example command")
+	}
+	return exitOK
+}
+
+// onPlatform returns copies of the files in profiles holding only their
+// profiles for platform, dropping files left with none.
+func onPlatform(profiles []*models.ProfileFile, platform string) []*models.ProfileFile {
+	var out []*models.ProfileFile
+	for _, pf := range profiles {
+		kept := *pf
+		kept.Profiles = slices.DeleteFunc(slices.Clone(pf.Profiles), func(p models.Profile) bool {
+			return !strings.EqualFold(p.Platform, platform)
+		})
+		if len(kept.Profiles) > 0 {
+			out = append(out, &kept)
+		}
+	}
+	return out
+}
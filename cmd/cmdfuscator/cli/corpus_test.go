@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCorpus(t *testing.T) {
+	code, stdout, stderr := run(t, "", "corpus", "--count", "4", "--seed", "9")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	perExe := make(map[string]int)
+	for i, line := range strings.Split(strings.TrimSuffix(stdout, "\n"), "\n") {
+		var rec struct {
+			Executable string   `json:"executable"`
+			Original   string   `json:"original"`
+			Obfuscated string   `json:"obfuscated"`
+			Enabled    []string `json:"enabled"`
+			Modifiers  []string `json:"modifiers"`
+			Seed       int64    `json:"seed"`
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %d: %v\n%s", i+1, err, line)
+		}
+		if rec.Original == "" || rec.Obfuscated == rec.Original || len(rec.Enabled) == 0 || rec.Seed == 0 {
+			t.Errorf("line %d = %s", i+1, line)
+		}
+		perExe[rec.Executable]++
+	}
+	for _, exe := range []string{"bash", "certutil", "powershell"} {
+		if perExe[exe] != 4 {
+			t.Errorf("%s: %d records, want 4", exe, perExe[exe])
+		}
+	}
+
+	_, again, _ := run(t, "", "corpus", "--count", "4", "--seed", "9")
+	if again != stdout {
+		t.Error("the same --seed gave a different dataset")
+	}
+}
+
+func TestCorpusFilters(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "corpus.jsonl")
+	code, stdout, stderr := run(t, "", "corpus", "--platform", "windows", "--count", "2", "--modifiers", "RandomCase", "--out", out)
+	if code != exitOK || stdout != "" {
+		t.Fatalf("exit code = %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 4 {
+		t.Errorf("%d records, want 2 each for certutil and powershell:\n%s", n, data)
+	}
+	if strings.Contains(string(data), `"executable":"bash"`) || strings.Contains(string(data), "CharacterInsertion") {
+		t.Errorf("records outside the filters:\n%s", data)
+	}
+
+	code, stdout, _ = run(t, "", "corpus", "--exe", "pwsh", "--count", "1")
+	if code != exitOK || strings.Count(stdout, "\n") != 1 || !strings.Contains(stdout, `"executable":"powershell"`) {
+		t.Errorf("--exe pwsh: exit %d, stdout %q", code, stdout)
+	}
+}
+
+func TestCorpusShort(t *testing.T) {
+	code, _, stderr := run(t, "", "corpus", "--exe", "bash", "--modifiers", "RandomCase", "--count", "100", "--seed", "1")
+	if code != exitOK || !strings.Contains(stderr, "warning: fewer than 100 distinct variants for bash/linux") {
+		t.Errorf("exit code = %d, stderr %q; want a warning for bash", code, stderr)
+	}
+}
+
+func TestCorpusUsage(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"corpus", "extra"}, exitUsage},
+		{[]string{"corpus", "--count", "0"}, exitUsage},
+		{[]string{"corpus", "--min-modifiers", "0"}, exitUsage},
+		{[]string{"corpus", "--modifiers", "RandomCas"}, exitUsage},
+		{[]string{"corpus", "--exe", "nosuchtool"}, exitError},
+		{[]string{"corpus", "--platform", "plan9"}, exitError},
+		{[]string{"corpus", "--out", filepath.Join(t.TempDir(), "missing", "x.jsonl")}, exitError},
+	}
+	for _, tt := range tests {
+		if code, _, stderr := run(t, "", tt.args...); code != tt.want {
+			t.Errorf("%v: exit code = %d, want %d; stderr %q", tt.args, code, tt.want, stderr)
+		}
+	}
+}
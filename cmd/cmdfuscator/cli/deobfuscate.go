@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cmdFuscator/deobfuscate"
+)
+
+// ─── deobfuscate ──────────────────────────────────────────────────────────────
+
+// deobfuscate implements `cmdfuscator deobfuscate [flags] [COMMAND...]`. It
+// prints the normalized form of COMMAND or, without one, of every stdin line,
+// one output line per input line.
+func (a *app) deobfuscate(args []string) int {
+	fset := a.flags("deobfuscate", "[flags] [COMMAND...]")
+	a.profileFlags(fset)
+	keepCase := fset.Bool("keep-case", false, "do not lowercase the output")
+	asJSON := fset.Bool("json", false, `print one JSON object per line: {"input", "output", "steps"}`)
+	if code, ok := parse(fset, args); !ok {
+		return code
+	}
+
+	// The bundled profiles' character pools may hold visible letters that
+	// only they identify as inserted.
+	var opts []deobfuscate.Option
+	if profiles, err := a.loadProfiles(); err != nil {
+		a.warnf("%v; inserted letters will not be recognised", err)
+	} else {
+		opts = append(opts, deobfuscate.Strip(deobfuscate.InsertedChars(profiles)...))
+	}
+	if *keepCase {
+		opts = append(opts, deobfuscate.KeepCase())
+	}
+
+	w := bufio.NewWriter(a.stdout)
+	emit := func(line string) error {
+		res := deobfuscate.Normalize(line, opts...)
+		if *asJSON {
+			steps := res.Steps
+			if steps == nil {
+				steps = []deobfuscate.Step{}
+			}
+			out, _ := json.Marshal(struct {
+				Input  string             `json:"input"`
+				Output string             `json:"output"`
+				Steps  []deobfuscate.Step `json:"steps"`
+			}{line, res.Output, steps})
+			fmt.Fprintf(w, "%s\n", out)
+		} else {
+			fmt.Fprintln(w, res.Output)
+		}
+		return w.Flush()
+	}
+
+	if input := strings.Join(fset.Args(), " "); input != "" {
+		if err := emit(input); err != nil {
+			a.errorf("deobfuscate: %v", err)
+			return exitError
+		}
+		return exitOK
+	}
+	sc := bufio.NewScanner(a.stdin)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLine)
+	for sc.Scan() {
+		if err := emit(strings.TrimRight(sc.Text(), "\r")); err != nil {
+			a.errorf("deobfuscate: %v", err)
+			return exitError
+		}
+	}
+	if err := sc.Err(); err != nil {
+		a.errorf("deobfuscate: stdin: %v", err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDeobfuscateArgs(t *testing.T) {
+	code, stdout, stderr := run(t, "", "deobfuscate", `C^e^r"tUtil"`, "–URL‍cache", "-fͿ")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if stdout != "certutil -urlcache -f\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+}
+
+func TestDeobfuscateStdin(t *testing.T) {
+	input := "CertUtil -URLcache\n\nbash -c id\r\n"
+	code, stdout, _ := run(t, input, "deobfuscate", "--keep-case")
+	if code != exitOK {
+		t.Fatalf("exit code = %d", code)
+	}
+	if want := "CertUtil -URLcache\n\nbash -c id\n"; stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+}
+
+func TestDeobfuscateRoundTrip(t *testing.T) {
+	const input = "certutil -urlcache -split -f https://example.com/a output.ext"
+	_, variants, stderr := run(t, "", "obfuscate", "--seed", "7", "--count", "10", input)
+	if variants == "" {
+		t.Fatalf("no variants: %s", stderr)
+	}
+	_, stdout, _ := run(t, variants, "deobfuscate")
+	for i, line := range strings.Split(strings.TrimSuffix(stdout, "\n"), "\n") {
+		if line != input {
+			t.Errorf("variant %d normalized to %q, want %q", i, line, input)
+		}
+	}
+}
+
+func TestDeobfuscateJSON(t *testing.T) {
+	_, stdout, _ := run(t, "a\nCERT^UTIL\n", "deobfuscate", "--json")
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %q", len(lines), stdout)
+	}
+	type record struct {
+		Input  string   `json:"input"`
+		Output string   `json:"output"`
+		Steps  []string `json:"steps"`
+	}
+	var got []record
+	for _, l := range lines {
+		var v record
+		if err := json.Unmarshal([]byte(l), &v); err != nil {
+			t.Fatalf("%q: %v", l, err)
+		}
+		got = append(got, v)
+	}
+	if got[0].Output != "a" || got[0].Steps == nil || len(got[0].Steps) != 0 {
+		t.Errorf("line 1 = %+v", got[0])
+	}
+	if got[1].Input != "CERT^UTIL" || got[1].Output != "certutil" || strings.Join(got[1].Steps, ",") != "carets,case" {
+		t.Errorf("line 2 = %+v", got[1])
+	}
+}
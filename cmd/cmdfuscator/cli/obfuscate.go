@@ -0,0 +1,647 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"cmdFuscator/detect"
+	"cmdFuscator/engine"
+	"cmdFuscator/export"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+	"cmdFuscator/navigator"
+)
+
+// ─── obfuscate ────────────────────────────────────────────────────────────────
+
+// maxLine is the longest stdin line --stdin accepts.
+const maxLine = 1 << 20
+
+// triesPerVariant bounds the attempts --count makes per requested variant
+// before giving up on finding more distinct ones.
+const triesPerVariant = 20
+
+// obfuscate implements `cmdfuscator obfuscate [flags] COMMAND...`. The
+// command's words are joined with spaces, so it may be given quoted or not.
+// With --stdin, commands are read one per line instead.
+func (a *app) obfuscate(args []string) (code int) {
+	opt, code, ok := a.parseObfuscateFlags(args)
+	if !ok {
+		return code
+	}
+	o, err := a.newObfuscator(opt)
+	if err != nil {
+		a.errorf("obfuscate: %v", err)
+		return exitError
+	}
+	if opt.layer != "" {
+		defer o.writeLayer(opt.layer, &code)
+	}
+	if opt.caldera != "" {
+		defer o.writeAbilities(opt.caldera, &code)
+	}
+	if opt.query != "" {
+		defer o.writeQuery(opt.query, opt.queryFormat, &code)
+	}
+	if opt.report != "" {
+		defer o.writeReport(opt.report, opt.reportFormat, &code)
+	}
+	if opt.stdin {
+		return o.stream()
+	}
+	return o.print(opt)
+}
+
+// obfuscateOptions holds the flags of an obfuscate run, checked and with
+// their formats parsed.
+type obfuscateOptions struct {
+	input    string // COMMAND's words, joined; empty with --stdin
+	exe      string
+	mods     string
+	enabled  map[string]bool // --modifiers, parsed; nil without
+	explicit []string
+	pipeline string
+	target   engine.RenderTarget
+	script   export.ScriptFormat // --out-format; 0 for text
+	stdin    bool
+	seed     *int64 // --seed; nil unless given
+	count    int
+	explain  bool
+	layer    string
+	caldera  string
+	rules    string
+	sigs     string
+	evade    bool
+	budget   int
+	verify   bool
+
+	query        string
+	queryFormat  export.QueryFormat
+	report       string
+	reportFormat export.ReportFormat
+}
+
+// parseObfuscateFlags parses and checks the flags of obfuscate. ok is false
+// when the command should stop, with code as its exit code, as for parse.
+func (a *app) parseObfuscateFlags(args []string) (opt *obfuscateOptions, code int, ok bool) {
+	opt = &obfuscateOptions{}
+	var target, outFormat, queryFormat, reportFormat string
+	var seed int64
+	fset := a.flags("obfuscate", "[flags] COMMAND... | --stdin")
+	a.profileFlags(fset)
+	fset.StringVar(&opt.exe, "exe", "", "profile to use, by executable name or alias (default: detected from each command)")
+	fset.StringVar(&opt.mods, "modifiers", "", "comma-separated modifiers to apply (default: the config file's, or all the profile configures)")
+	fset.StringVar(&target, "target", a.cfg.RenderTarget().String(), "shell to render for: auto, cmd, powershell, bash or none")
+	fset.BoolVar(&opt.stdin, "stdin", false, "read commands from stdin, one per line, and write one result line per input line")
+	fset.Int64Var(&seed, "seed", 0, "seed the random choices, so the same input and flags always give the same output")
+	fset.IntVar(&opt.count, "count", 1, "print `N` distinct variants of COMMAND, one per line")
+	fset.StringVar(&opt.pipeline, "pipeline", "", "run the modifiers listed in `FILE`, in its order and with its config overrides")
+	fset.BoolVar(&opt.explain, "explain", false, "also show on stderr what each modifier changed, with invisible characters escaped")
+	fset.StringVar(&opt.layer, "navigator", "", "write an ATT&CK Navigator layer of the techniques the run exercised to `FILE`")
+	fset.StringVar(&outFormat, "out-format", "text", "print the variants as `FORMAT`: text, one per line, or a script: bat, ps1, ps1-utf16 or sh")
+	fset.StringVar(&opt.caldera, "caldera", "", "write the variants to `FILE` as Caldera abilities, one per variant, run by the --target shell's executor")
+	fset.StringVar(&opt.query, "query", "", "write a detection query matching what every variant has in common to `FILE`, in the --query-format language")
+	fset.StringVar(&queryFormat, "query-format", "spl", "write --query as `FORMAT`: spl, a Splunk search, or elastic, an Elasticsearch query body")
+	fset.StringVar(&opt.report, "report", "", "write every variant, its modifiers, score and --sigma/--patterns hits to `FILE` as a --report-format table")
+	fset.StringVar(&reportFormat, "report-format", "", "write --report as `FORMAT`: csv or markdown (default: from the file extension, else csv)")
+	fset.StringVar(&opt.rules, "sigma", "", "check every variant against the Sigma process_creation rules in `PATH`, a file or directory, and report on stderr which evade")
+	fset.StringVar(&opt.sigs, "patterns", "", "check every variant against the signatures in `FILE`, one substring or re:regexp per line, and report on stderr which still fire")
+	fset.BoolVar(&opt.evade, "evade", false, "search for one variant that evades every --sigma rule and --patterns signature, varying seeds and modifier subsets")
+	fset.IntVar(&opt.budget, "budget", engine.DefaultSearchAttempts, "with --evade, give up after `N` attempts")
+	fset.BoolVar(&opt.verify, "verify", false, "parse every variant as the shell it was rendered for and report on stderr which no longer run the original's argument vectors (bash, and cmd on Windows) or no longer parse (powershell, with pwsh installed)")
+	if code, ok := parse(fset, args); !ok {
+		return nil, code, false
+	}
+	targeted := false
+	fset.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			opt.seed = &seed
+		case "target":
+			targeted = true
+		}
+	})
+
+	opt.input = strings.TrimSpace(strings.Join(fset.Args(), " "))
+	switch {
+	case opt.stdin && opt.input != "":
+		a.errorf("obfuscate: --stdin takes no COMMAND")
+		return nil, exitUsage, false
+	case !opt.stdin && opt.input == "":
+		a.errorf("obfuscate: no command given")
+		fset.Usage()
+		return nil, exitUsage, false
+	case opt.count < 1:
+		a.errorf("obfuscate: --count must be at least 1")
+		return nil, exitUsage, false
+	case opt.stdin && opt.count != 1:
+		a.errorf("obfuscate: --count cannot be combined with --stdin")
+		return nil, exitUsage, false
+	case opt.pipeline != "" && opt.mods != "":
+		a.errorf("obfuscate: --modifiers cannot be combined with --pipeline")
+		return nil, exitUsage, false
+	case opt.evade && opt.rules == "" && opt.sigs == "":
+		a.errorf("obfuscate: --evade needs --sigma or --patterns to evade")
+		return nil, exitUsage, false
+	case opt.evade && (opt.stdin || opt.count != 1 || opt.mods != ""):
+		a.errorf("obfuscate: --evade cannot be combined with --stdin, --count or --modifiers")
+		return nil, exitUsage, false
+	case opt.query != "" && opt.stdin:
+		a.errorf("obfuscate: --query cannot be combined with --stdin")
+		return nil, exitUsage, false
+	}
+	var err error
+	if opt.mods != "" {
+		if opt.enabled, opt.explicit, err = parseModifiers(opt.mods); err != nil {
+			a.errorf("obfuscate: %v", err)
+			return nil, exitUsage, false
+		}
+	}
+	if opt.queryFormat, err = export.ParseQueryFormat(queryFormat); err != nil {
+		a.errorf("obfuscate: --query-format: %v", err)
+		return nil, exitUsage, false
+	}
+	opt.reportFormat = export.ReportCSV
+	if reportFormat != "" {
+		if opt.reportFormat, err = export.ParseReportFormat(reportFormat); err != nil {
+			a.errorf("obfuscate: --report-format: %v", err)
+			return nil, exitUsage, false
+		}
+	} else if f, err := export.ParseReportFormat(filepath.Ext(opt.report)); err == nil {
+		opt.reportFormat = f
+	}
+	if opt.target, err = engine.ParseRenderTarget(target); err != nil {
+		a.errorf("obfuscate: %v", err)
+		return nil, exitUsage, false
+	}
+	if outFormat != "text" {
+		if opt.script, err = export.ParseScriptFormat(outFormat); err != nil {
+			a.errorf("obfuscate: --out-format: %v", err)
+			return nil, exitUsage, false
+		}
+		switch {
+		case opt.stdin:
+			a.errorf("obfuscate: --out-format cannot be combined with --stdin")
+			return nil, exitUsage, false
+		case targeted && opt.target != opt.script.Target():
+			a.errorf("obfuscate: --target %s does not match --out-format %s, which runs %s", opt.target, opt.script, opt.script.Target())
+			return nil, exitUsage, false
+		}
+		opt.target = opt.script.Target()
+	}
+	return opt, exitOK, true
+}
+
+// newObfuscator sets up the run opt describes: the modifiers, profiles,
+// detectors and engine, and what collects the variants for the files
+// obfuscate writes once it is over.
+func (a *app) newObfuscator(opt *obfuscateOptions) (*obfuscator, error) {
+	o := &obfuscator{app: a, exe: opt.exe, explain: opt.explain, warned: make(map[string]bool)}
+	switch {
+	case opt.seed != nil:
+		o.seeds = rand.New(rand.NewSource(*opt.seed))
+	case a.cfg.Seed != nil:
+		o.seeds = rand.New(rand.NewSource(*a.cfg.Seed))
+	}
+	var steps []engine.PipelineStep
+	var err error
+	switch {
+	case opt.pipeline != "":
+		if steps, err = readPipeline(opt.pipeline); err != nil {
+			return nil, fmt.Errorf("pipeline: %w", err)
+		}
+		o.explicit, o.supplied = pipelineNames(steps)
+		o.enabled = make(map[string]bool, len(o.explicit))
+		for _, name := range o.explicit {
+			o.enabled[name] = true
+		}
+	case opt.enabled != nil:
+		o.enabled, o.explicit = opt.enabled, opt.explicit
+	case len(a.cfg.Modifiers) > 0:
+		o.enabled = make(map[string]bool, len(a.cfg.Modifiers))
+		for _, name := range a.cfg.Modifiers {
+			o.enabled[name] = true
+		}
+	}
+
+	if o.profiles, err = a.loadProfiles(); err != nil {
+		return nil, err
+	}
+	o.index, _ = loader.BuildIndex(o.profiles)
+	if opt.rules != "" {
+		d, err := a.loadSigma(opt.rules)
+		if err != nil {
+			return nil, err
+		}
+		o.detections = append(o.detections, d)
+	}
+	if opt.sigs != "" {
+		d, err := a.loadPatterns(opt.sigs)
+		if err != nil {
+			return nil, err
+		}
+		o.detections = append(o.detections, d)
+	}
+	opts := append(a.cfg.EngineOptions(), engine.WithRenderTarget(opt.target), engine.WithTrace(o.explain))
+	if steps != nil {
+		opts = append(opts, engine.WithPipeline(steps...))
+	}
+	if opt.verify {
+		var vopts []engine.Option
+		o.verifier, vopts = newVerifier(o, opt.target)
+		opts = append(opts, vopts...)
+	}
+	o.eng = engine.New(opts...)
+
+	if opt.layer != "" {
+		o.coverage = &navigator.Coverage{}
+	}
+	if opt.caldera != "" {
+		o.abilities, o.perExe = []export.Ability{}, make(map[string]int)
+	}
+	if opt.query != "" {
+		o.queried = []export.Variant{}
+	}
+	if opt.report != "" {
+		o.reported = []export.ReportRow{}
+	}
+	return o, nil
+}
+
+// print obfuscates opt's COMMAND, prints the variants found and reports on
+// stderr how they fare against the detectors and --verify.
+func (o *obfuscator) print(opt *obfuscateOptions) int {
+	var outs []string
+	var err error
+	if opt.evade {
+		outs, err = o.evade(opt.input, opt.budget)
+	} else {
+		outs, err = o.variants(opt.input, opt.count)
+	}
+	if err != nil {
+		o.errorf("obfuscate: %v", err)
+		return exitError
+	}
+	if opt.script != 0 {
+		o.stdout.Write(export.Script(opt.script, outs))
+	} else {
+		for _, out := range outs {
+			fmt.Fprintln(o.stdout, out)
+		}
+	}
+	if len(outs) < opt.count {
+		o.warnf("found only %s of %d", plural(len(outs), "distinct variant"), opt.count)
+	}
+	pf, _ := o.selectProfile(opt.input)
+	for _, d := range o.detections {
+		d.baseline(pf, opt.input)
+		for i, out := range outs {
+			d.check(pf, fmt.Sprintf("variant %d", i+1), out)
+		}
+		d.summary("variant")
+	}
+	if o.verifier != nil {
+		for i, out := range outs {
+			o.verifier.check(pf, fmt.Sprintf("variant %d", i+1), opt.input, out)
+		}
+		o.verifier.summary("variant")
+	}
+	return exitOK
+}
+
+// evade searches for a variant of input that evades every detector loaded,
+// reporting on stderr how long that took.
+func (o *obfuscator) evade(input string, budget int) ([]string, error) {
+	pf, err := o.selectProfile(input)
+	if err != nil {
+		return nil, err
+	}
+	var ruleset []detect.Detector
+	for _, d := range o.detections {
+		ruleset = append(ruleset, d.detectors...)
+	}
+	res, err := o.eng.SearchEvading(o.ctx, input, pf, ruleset, engine.SearchBudget{Attempts: budget, Seed: o.nextSeed()})
+	if err != nil {
+		return nil, err
+	}
+	o.reportResult(res.Result)
+	if !res.Found {
+		return nil, fmt.Errorf("no variant evades every detector in %s; the closest still fires %s",
+			plural(res.Stats.Attempts, "attempt"), detectorNames(res.Hits))
+	}
+	applied := "no modifier"
+	if len(res.Result.Applied) > 0 {
+		applied = strings.Join(res.Result.Applied, ", ")
+	}
+	fmt.Fprintf(o.stderr, "evade: found after %s (%d distinct) with %s\n",
+		plural(res.Stats.Attempts, "attempt"), res.Stats.Distinct, applied)
+	o.record(pf, input, res.Result)
+	if o.explain {
+		explain(o.stderr, input, pf, res.Result)
+	}
+	return []string{res.Result.Output}, nil
+}
+
+// record hands a variant printed to --verify, --navigator, --caldera,
+// --query and --report.
+func (o *obfuscator) record(pf *models.ProfileFile, input string, res engine.ObfuscateResult) {
+	if o.verifier != nil {
+		o.verifier.note(res)
+	}
+	if o.coverage != nil {
+		o.coverage.Record(pf, res.Applied)
+	}
+	v := export.Variant{Profile: pf, Original: input, Command: res.Output, Target: res.Target, Modifiers: res.Applied}
+	if o.queried != nil {
+		o.queried = append(o.queried, v)
+	}
+	if o.reported != nil {
+		row := export.ReportRow{Input: input, Output: res.Output, Modifiers: res.Applied, Score: res.Score.Value}
+		if len(o.detections) > 0 {
+			row.Hits = []string{}
+			ev := detect.EventFor(pf, res.Output)
+			for _, d := range o.detections {
+				for _, hit := range detect.Hits(d.detectors, ev) {
+					row.Hits = append(row.Hits, hit.Name())
+				}
+			}
+		}
+		o.reported = append(o.reported, row)
+	}
+	if o.abilities != nil {
+		a, err := export.CalderaAbility(fmt.Sprintf("%s variant %d", pf.Name, o.perExe[pf.Name]+1), v)
+		if err != nil {
+			o.warnOnce("caldera: %v; left out", err)
+			return
+		}
+		o.abilities = append(o.abilities, a)
+		o.perExe[pf.Name]++
+	}
+}
+
+// writeAbilities writes the --caldera abilities to path once the run is
+// over, setting *code to exitError if it cannot.
+func (o *obfuscator) writeAbilities(path string, code *int) {
+	var buf bytes.Buffer
+	err := export.WriteCaldera(&buf, o.abilities)
+	if err == nil {
+		err = os.WriteFile(path, buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		o.errorf("obfuscate: caldera: %v", err)
+		*code = exitError
+	}
+}
+
+// writeQuery writes the --query detection query of the variants printed to
+// path once the run is over, setting *code to exitError if it cannot.
+func (o *obfuscator) writeQuery(path string, f export.QueryFormat, code *int) {
+	if len(o.queried) == 0 {
+		return
+	}
+	q, err := export.DetectionQuery(f, o.queried)
+	if err == nil {
+		err = os.WriteFile(path, []byte(q), 0o644)
+	}
+	if err != nil {
+		o.errorf("obfuscate: query: %v", err)
+		*code = exitError
+	}
+}
+
+// writeReport writes the --report table of the variants printed to path
+// once the run is over, setting *code to exitError if it cannot.
+func (o *obfuscator) writeReport(path string, f export.ReportFormat, code *int) {
+	var buf bytes.Buffer
+	err := export.WriteReport(&buf, f, o.reported)
+	if err == nil {
+		err = os.WriteFile(path, buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		o.errorf("obfuscate: report: %v", err)
+		*code = exitError
+	}
+}
+
+// writeLayer writes the --navigator layer to path once the run is over,
+// setting *code to exitError if it cannot. Executables whose profiles name no
+// ATT&CK technique are left out of the layer, with a warning.
+func (o *obfuscator) writeLayer(path string, code *int) {
+	if untagged := o.coverage.Untagged(); len(untagged) > 0 {
+		o.warnf("navigator: no ATT&CK technique tagged for %s; left out of the layer", strings.Join(untagged, ", "))
+	}
+	data, err := json.MarshalIndent(o.coverage.Layer("cmdFuscator coverage"), "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0o644)
+	}
+	if err != nil {
+		o.errorf("obfuscate: navigator: %v", err)
+		*code = exitError
+	}
+}
+
+// obfuscator holds the settings of one obfuscate run.
+type obfuscator struct {
+	*app
+	eng      *engine.Engine
+	profiles []*models.ProfileFile
+	index    *loader.Index
+	exe      string
+	enabled  map[string]bool     // --modifiers, --pipeline or the config's; nil: each profile's own
+	explicit []string            // modifiers named with --modifiers or --pipeline
+	supplied map[string]bool     // --pipeline modifiers given a config, so needing none
+	explain  bool                // --explain: trace every variant printed
+	verifier *verifier           // --verify: nil unless given
+	coverage *navigator.Coverage // --navigator: nil unless given
+
+	// detections holds the --sigma rules and --patterns signatures, in that
+	// order, each reported on separately.
+	detections []*detection
+
+	// abilities collects the --caldera abilities, one per variant; nil
+	// without --caldera. perExe numbers them per executable.
+	abilities []export.Ability
+	perExe    map[string]int
+
+	// queried collects the variants --query builds its query from; nil
+	// without --query.
+	queried []export.Variant
+
+	// reported collects the --report rows, one per variant; nil without
+	// --report.
+	reported []export.ReportRow
+
+	// seeds, when set by --seed or the config file, hands out the seed of every run in order,
+	// which makes the whole output reproducible.
+	seeds *rand.Rand
+
+	// warned records warnings already printed, so a stream of commands for
+	// the same profile repeats none of them.
+	warned map[string]bool
+}
+
+// stream obfuscates stdin line by line. Every input line yields exactly one
+// output line, written as soon as it is ready: blank lines stay blank, and a
+// line that cannot be obfuscated is copied through unchanged with a warning
+// naming its line number. The exit code is exitError if any line failed.
+func (o *obfuscator) stream() int {
+	sc := bufio.NewScanner(o.stdin)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLine)
+	w := bufio.NewWriter(o.stdout)
+	code := exitOK
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		out, ok := line, false
+		if strings.TrimSpace(line) != "" {
+			var err error
+			if out, err = o.one(strings.TrimSpace(line)); err != nil {
+				o.warnf("line %d: %v", n, err)
+				out, code = line, exitError
+			} else {
+				ok = true
+			}
+		}
+		fmt.Fprintln(w, out)
+		if err := w.Flush(); err != nil {
+			o.errorf("obfuscate: %v", err)
+			return exitError
+		}
+		if ok {
+			pf, _ := o.selectProfile(strings.TrimSpace(line))
+			for _, d := range o.detections {
+				d.check(pf, fmt.Sprintf("line %d", n), out)
+			}
+		}
+		if ok && o.verifier != nil {
+			pf, _ := o.selectProfile(strings.TrimSpace(line))
+			o.verifier.check(pf, fmt.Sprintf("line %d", n), strings.TrimSpace(line), out)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		o.errorf("obfuscate: stdin: %v", err)
+		return exitError
+	}
+	for _, d := range o.detections {
+		d.summary("line")
+	}
+	if o.verifier != nil {
+		o.verifier.summary("line")
+	}
+	return code
+}
+
+// one obfuscates a single command.
+func (o *obfuscator) one(input string) (string, error) {
+	outs, err := o.variants(input, 1)
+	if err != nil {
+		return "", err
+	}
+	return outs[0], nil
+}
+
+// variants obfuscates input until it has n distinct results, in the order
+// they were produced, or until it has made triesPerVariant attempts per
+// variant; with few enabled modifiers a short command may not have n
+// variants. Every attempt draws its own seed.
+func (o *obfuscator) variants(input string, n int) ([]string, error) {
+	pf, err := o.selectProfile(input)
+	if err != nil {
+		return nil, err
+	}
+	enabled := o.enabled
+	if enabled == nil {
+		enabled = engine.DefaultEnabled(pf)
+	} else if len(pf.Profiles) > 0 {
+		for _, name := range o.explicit {
+			if _, ok := engine.ConfigFor(pf.Profiles[0], name); !ok && !o.supplied[name] {
+				o.warnOnce("profile %s does not configure %s; skipped", pf.Name, name)
+			}
+		}
+	}
+
+	var outs []string
+	seen := make(map[string]bool, n)
+	for tries := 0; len(outs) < n && tries < n*triesPerVariant; {
+		items := make([]engine.BatchItem, n-len(outs))
+		for i := range items {
+			items[i] = engine.BatchItem{Command: input, Profile: pf, Enabled: enabled, Seed: o.nextSeed()}
+		}
+		tries += len(items)
+		for _, r := range o.eng.ObfuscateBatch(o.ctx, items) {
+			if r.Err != nil {
+				return nil, r.Err
+			}
+			o.reportResult(r.Result)
+			if !seen[r.Result.Output] {
+				seen[r.Result.Output] = true
+				outs = append(outs, r.Result.Output)
+				o.record(pf, input, r.Result)
+				if o.explain {
+					explain(o.stderr, input, pf, r.Result)
+				}
+			}
+		}
+	}
+	return outs, nil
+}
+
+// nextSeed returns the seed for the next run: drawn from seeds when set, or
+// zero, which lets the engine pick a fresh one.
+func (o *obfuscator) nextSeed() int64 {
+	if o.seeds == nil {
+		return 0
+	}
+	if s := o.seeds.Int63(); s != 0 {
+		return s
+	}
+	return 1
+}
+
+// selectProfile returns the profile named by --exe, or the one whose
+// executable starts command when --exe is not given.
+func (o *obfuscator) selectProfile(command string) (*models.ProfileFile, error) {
+	if o.exe != "" {
+		if pf, ok := o.index.Lookup(o.exe); ok {
+			return pf, nil
+		}
+		return nil, fmt.Errorf("no profile for %q", o.exe)
+	}
+	if pf, ok := engine.DetectProfile(command, o.profiles); ok {
+		return pf, nil
+	}
+	return nil, fmt.Errorf("no profile matches %q; pass --exe", strings.Fields(command)[0])
+}
+
+// reportResult warns about modifiers that failed and, among those the user
+// asked for by name, ones that are not implemented yet.
+func (o *obfuscator) reportResult(res engine.ObfuscateResult) {
+	for _, name := range res.Skipped {
+		if slices.Contains(o.explicit, name) {
+			o.warnOnce("%s is not implemented yet; skipped", name)
+		}
+	}
+	names := make([]string, 0, len(res.Errors))
+	for name := range res.Errors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		o.warnOnce("%s: %v", name, res.Errors[name])
+	}
+}
+
+func (o *obfuscator) warnOnce(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if !o.warned[msg] {
+		o.warned[msg] = true
+		o.warnf("%s", msg)
+	}
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+)
+
+// ─── Pipeline files ───────────────────────────────────────────────────────────
+
+// pipelineFile is the format --pipeline reads: an ordered modifier list, each
+// entry either a bare name or a name with config overrides, laid over the
+// profile's config for that modifier key by key. YAML, or JSON, which YAML
+// accepts too.
+//
+//	description: quiet certutil variants
+//	modifiers:
+//	  - name: CharacterInsertion
+//	    config:
+//	      Probability: 0.2
+//	      Characters: ["\u200d"]
+//	  - RandomCase
+type pipelineFile struct {
+	Description string         `yaml:"description"`
+	Modifiers   []pipelineStep `yaml:"modifiers"`
+}
+
+type pipelineStep struct {
+	Name   string
+	Config map[string]any
+}
+
+// UnmarshalYAML accepts a bare modifier name or a mapping with name and
+// config keys; none other.
+func (s *pipelineStep) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&s.Name)
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: want a modifier name or a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		var err error
+		switch key.Value {
+		case "name":
+			err = val.Decode(&s.Name)
+		case "config":
+			err = val.Decode(&s.Config)
+		default:
+			err = fmt.Errorf("line %d: unknown key %q (want name or config)", key.Line, key.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPipeline reads and checks a pipeline file, returning its steps in
+// order.
+func readPipeline(path string) ([]engine.PipelineStep, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pf pipelineFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&pf); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(pf.Modifiers) == 0 {
+		return nil, fmt.Errorf("%s: no modifiers", path)
+	}
+
+	steps := make([]engine.PipelineStep, len(pf.Modifiers))
+	for i, s := range pf.Modifiers {
+		step, err := s.step()
+		if err != nil {
+			return nil, fmt.Errorf("%s: modifiers[%d]: %w", path, i, err)
+		}
+		steps[i] = step
+	}
+	return steps, nil
+}
+
+// step checks s and converts it for the engine.
+func (s pipelineStep) step() (engine.PipelineStep, error) {
+	step := engine.PipelineStep{Modifier: strings.TrimSpace(s.Name)}
+	if step.Modifier == "" {
+		return step, errors.New("no modifier name")
+	}
+	if _, ok := modifiers.Get(step.Modifier); !ok {
+		return step, fmt.Errorf("unknown modifier %q (known: %s)", step.Modifier, strings.Join(modifierNames(), ", "))
+	}
+	if s.Config == nil {
+		return step, nil
+	}
+	raw, err := json.Marshal(s.Config)
+	if err != nil {
+		return step, fmt.Errorf("%s: config: %w", step.Modifier, err)
+	}
+	base, err := modifiers.ParseConfig(raw)
+	if err != nil {
+		return step, fmt.Errorf("%s: config: %w", step.Modifier, err)
+	}
+	if base.Probability != "" {
+		if _, err := modifiers.ParseProbability(string(base.Probability)); err != nil {
+			return step, fmt.Errorf("%s: config: Probability: %w", step.Modifier, err)
+		}
+	}
+	step.Config = raw
+	return step, nil
+}
+
+// pipelineNames returns the modifiers steps name, each once, in order, and
+// which of them are given a config.
+func pipelineNames(steps []engine.PipelineStep) (names []string, configured map[string]bool) {
+	configured = make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, s := range steps {
+		if !seen[s.Modifier] {
+			seen[s.Modifier] = true
+			names = append(names, s.Modifier)
+		}
+		if s.Config != nil {
+			configured[s.Modifier] = true
+		}
+	}
+	return names, configured
+}
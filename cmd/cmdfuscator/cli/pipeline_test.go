@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePipeline(t *testing.T, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "pipeline.yaml")
+	if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestObfuscatePipeline(t *testing.T) {
+	p := writePipeline(t, `
+description: case, then inserted characters
+modifiers:
+  - name: RandomCase
+    config: {Probability: 0}
+  - name: CharacterInsertion
+    config:
+      Probability: 1
+      Characters: ["\u200d"]
+      Offset: "1"
+`)
+	code, stdout, stderr := run(t, "", "obfuscate", "--pipeline", p, "--explain", "certutil -urlcache -f")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if got, want := strings.TrimSuffix(stdout, "\n"), "certutil -\u200durlcache -\u200df"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	// The pipeline's order, not the registry's, and only its modifiers.
+	rc, ci := strings.Index(stderr, "RandomCase: no change"), strings.Index(stderr, "CharacterInsertion:")
+	if rc < 0 || ci < rc {
+		t.Errorf("explanation does not show an unchanged RandomCase then CharacterInsertion:\n%s", stderr)
+	}
+	if strings.Contains(stderr, "QuoteInsertion") {
+		t.Errorf("explanation mentions a modifier the pipeline does not list:\n%s", stderr)
+	}
+}
+
+func TestObfuscatePipelineBareNames(t *testing.T) {
+	p := writePipeline(t, "modifiers: [RandomCase, Sed]\n")
+	code, stdout, stderr := run(t, "", "obfuscate", "--pipeline", p, "certutil -urlcache -f")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	if !strings.EqualFold(strings.TrimSuffix(stdout, "\n"), "certutil -urlcache -f") {
+		t.Errorf("output %q is not the input with its case changed", stdout)
+	}
+	if !strings.Contains(stderr, "Sed is not implemented yet; skipped") {
+		t.Errorf("stderr = %q, want a warning about Sed", stderr)
+	}
+}
+
+func TestObfuscatePipelineErrors(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"empty", "", "no modifiers"},
+		{"unknown modifier", "modifiers: [RandomCas]", `modifiers[0]: unknown modifier "RandomCas"`},
+		{"unknown key", "modifier: [RandomCase]", "field modifier not found"},
+		{"unknown step key", "modifiers:\n  - name: RandomCase\n    probability: 1", `unknown key "probability"`},
+		{"no name", "modifiers:\n  - config: {Probability: 1}", "modifiers[0]: no modifier name"},
+		{"bad probability", "modifiers:\n  - name: RandomCase\n    config: {Probability: 2}", "config: Probability: probability 2 must be between 0 and 1"},
+		{"NaN probability", "modifiers:\n  - name: RandomCase\n    config: {Probability: \"NaN\"}", "config: Probability: probability NaN must be between 0 and 1"},
+		{"bad config", "modifiers:\n  - name: RandomCase\n    config: {AppliesTo: argument}", "RandomCase: config:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := writePipeline(t, tt.body)
+			code, _, stderr := run(t, "", "obfuscate", "--pipeline", p, "certutil -f")
+			if code != exitError {
+				t.Errorf("exit code = %d, want %d", code, exitError)
+			}
+			if !strings.Contains(stderr, p+": ") || !strings.Contains(stderr, tt.want) {
+				t.Errorf("stderr = %q, want it to name %s and contain %q", stderr, p, tt.want)
+			}
+		})
+	}
+}
+
+func TestObfuscatePipelineUsage(t *testing.T) {
+	p := writePipeline(t, "modifiers: [RandomCase]")
+	code, _, stderr := run(t, "", "obfuscate", "--pipeline", p, "--modifiers", "RandomCase", "certutil -f")
+	if code != exitUsage || !strings.Contains(stderr, "cannot be combined") {
+		t.Errorf("exit code = %d, stderr %q", code, stderr)
+	}
+	if code, _, _ := run(t, "", "obfuscate", "--pipeline", filepath.Join(t.TempDir(), "absent.yaml"), "certutil -f"); code != exitError {
+		t.Errorf("missing pipeline file: exit code = %d, want %d", code, exitError)
+	}
+}
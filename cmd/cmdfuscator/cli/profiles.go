@@ -0,0 +1,350 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/loader"
+	"cmdFuscator/lolbas"
+	"cmdFuscator/models"
+)
+
+// ─── profiles ─────────────────────────────────────────────────────────────────
+
+// profiles implements `cmdfuscator profiles list|show`.
+func (a *app) profiles(args []string) int {
+	if len(args) == 0 {
+		a.errorf("profiles: want list or show")
+		a.profilesUsage()
+		return exitUsage
+	}
+	switch args[0] {
+	case "list":
+		return a.profilesList(args[1:])
+	case "show":
+		return a.profilesShow(args[1:])
+	case "help", "-h", "-help", "--help":
+		a.profilesUsage()
+		return exitOK
+	}
+	a.errorf("profiles: unknown subcommand %q", args[0])
+	a.profilesUsage()
+	return exitUsage
+}
+
+func (a *app) profilesUsage() {
+	fmt.Fprintln(a.stderr, "usage: cmdfuscator profiles list [--platform NAME] [--json] [--remote]")
+	fmt.Fprintln(a.stderr, "       cmdfuscator profiles show [--json] [--lolbas SOURCE] [--remote] NAME")
+}
+
+func (a *app) profilesList(args []string) int {
+	fset := a.flags("profiles list", "[flags]")
+	a.profileFlags(fset)
+	platform := fset.String("platform", "", "only list executables with a profile for this platform (windows, linux, macos)")
+	asJSON := fset.Bool("json", false, "print JSON instead of a table")
+	pos, code, ok := parseInterspersed(fset, args)
+	if !ok {
+		return code
+	}
+	if len(pos) > 0 {
+		a.errorf("profiles list: unexpected argument %q", pos[0])
+		return exitUsage
+	}
+
+	profiles, err := a.loadProfiles()
+	if err != nil {
+		a.errorf("profiles: %v", err)
+		return exitError
+	}
+	var infos []profileInfo
+	for _, pf := range profiles {
+		info := describe(pf, false)
+		if *platform == "" || slices.ContainsFunc(info.Platforms, func(p string) bool { return strings.EqualFold(p, *platform) }) {
+			infos = append(infos, info)
+		}
+	}
+	slices.SortFunc(infos, func(x, y profileInfo) int { return strings.Compare(x.Name, y.Name) })
+
+	if *asJSON {
+		if infos == nil {
+			infos = []profileInfo{}
+		}
+		return a.printJSON(infos)
+	}
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tPLATFORMS\tMODIFIERS\tDESCRIPTION")
+	for _, info := range infos {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", info.Name, strings.Join(info.Platforms, ","),
+			strings.Join(info.Modifiers, ","), info.Description)
+	}
+	if err := tw.Flush(); err != nil {
+		a.errorf("profiles: %v", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func (a *app) profilesShow(args []string) int {
+	fset := a.flags("profiles show", "[flags] NAME")
+	a.profileFlags(fset)
+	asJSON := fset.Bool("json", false, "print JSON instead of text")
+	source := fset.String("lolbas", a.cfg.LOLBAS, "read LOLBAS metadata from `SOURCE`: embedded, remote (the project's API), a URL or a file (default: the config file's, else embedded)")
+	pos, code, ok := parseInterspersed(fset, args)
+	if !ok {
+		return code
+	}
+	if len(pos) != 1 {
+		a.errorf("profiles show: want exactly one executable name")
+		fset.Usage()
+		return exitUsage
+	}
+
+	profiles, err := a.loadProfiles()
+	if err != nil {
+		a.errorf("profiles: %v", err)
+		return exitError
+	}
+	ix, _ := loader.BuildIndex(profiles)
+	pf, ok := ix.Lookup(pos[0])
+	if !ok {
+		a.errorf("profiles show: no profile for %q", pos[0])
+		return exitError
+	}
+	info := describe(pf, true)
+	if cat, err := lolbas.Open(a.ctx, *source); err != nil {
+		a.warnf("%v; shown without LOLBAS metadata", err)
+	} else if e, ok := cat.ForProfile(pf); ok {
+		info.LOLBAS = describeLOLBAS(e)
+	}
+	if *asJSON {
+		return a.printJSON(info)
+	}
+	writeProfile(a.stdout, info)
+	return exitOK
+}
+
+// ─── Descriptions ─────────────────────────────────────────────────────────────
+
+// profileInfo is what `profiles list` and `profiles show` print for one
+// profile file, and the shape of their JSON output.
+type profileInfo struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Platforms   []string `json:"platforms"`
+	Description string   `json:"description,omitempty"`
+	Attack      []string `json:"attack,omitempty"`
+	// Modifiers is every modifier any of the file's profiles configures, in
+	// first-seen order.
+	Modifiers []string `json:"modifiers"`
+	// Profiles and LOLBAS are only filled in for `profiles show`.
+	Profiles []variantInfo `json:"profiles,omitempty"`
+	LOLBAS   *lolbasInfo   `json:"lolbas,omitempty"`
+}
+
+// lolbasInfo is the LOLBAS project's entry for the executable.
+type lolbasInfo struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Functions   []string           `json:"functions"`
+	Attack      []string           `json:"attack"`
+	Paths       []string           `json:"paths"`
+	Detections  []lolbas.Detection `json:"detections"`
+}
+
+// variantInfo describes one entry of a file's profiles.
+type variantInfo struct {
+	Platform               string                      `json:"platform"`
+	OperatingSystem        string                      `json:"operatingSystem,omitempty"`
+	OperatingSystemVersion string                      `json:"operatingSystemVersion,omitempty"`
+	ExecutableVersion      string                      `json:"executableVersion,omitempty"`
+	Example                string                      `json:"example,omitempty"`
+	Modifiers              []modifierInfo              `json:"modifiers"`
+	Arguments              []models.ArgumentDefinition `json:"arguments"`
+}
+
+// modifierInfo is one configured modifier. Known is false for modifiers
+// this build does not register, which the engine ignores.
+type modifierInfo struct {
+	Name        string   `json:"name"`
+	AppliesTo   []string `json:"appliesTo"`
+	Probability string   `json:"probability"`
+	Known       bool     `json:"known"`
+}
+
+func describe(pf *models.ProfileFile, full bool) profileInfo {
+	info := profileInfo{
+		Name:        pf.Name,
+		Aliases:     pf.Aliases(),
+		Description: pf.Description(),
+		Attack:      pf.Techniques(),
+		Platforms:   []string{},
+		Modifiers:   []string{},
+	}
+	for _, p := range pf.Profiles {
+		if plat := strings.ToLower(p.Platform); plat != "" && !slices.Contains(info.Platforms, plat) {
+			info.Platforms = append(info.Platforms, plat)
+		}
+		for _, name := range p.Parameters.ModifierNames() {
+			if !slices.Contains(info.Modifiers, name) {
+				info.Modifiers = append(info.Modifiers, name)
+			}
+		}
+		if full {
+			info.Profiles = append(info.Profiles, describeVariant(p))
+		}
+	}
+	return info
+}
+
+func describeLOLBAS(e *lolbas.Entry) *lolbasInfo {
+	info := &lolbasInfo{
+		Name:        e.Name,
+		Description: e.Description,
+		Functions:   e.Functions(),
+		Attack:      e.Techniques(),
+		Paths:       []string{},
+		Detections:  e.Detection,
+	}
+	for _, p := range e.FullPath {
+		info.Paths = append(info.Paths, p.Path)
+	}
+	if info.Functions == nil {
+		info.Functions = []string{}
+	}
+	if info.Attack == nil {
+		info.Attack = []string{}
+	}
+	if info.Detections == nil {
+		info.Detections = []lolbas.Detection{}
+	}
+	return info
+}
+
+func describeVariant(p models.Profile) variantInfo {
+	v := variantInfo{
+		Platform:               p.Platform,
+		OperatingSystem:        p.OperatingSystem,
+		OperatingSystemVersion: p.OperatingSystemVersion,
+		ExecutableVersion:      p.ExecutableVersion,
+		Modifiers:              []modifierInfo{},
+		Arguments:              p.Parameters.Arguments,
+	}
+	if v.Arguments == nil {
+		v.Arguments = []models.ArgumentDefinition{}
+	}
+	if len(p.Parameters.Command) > 0 {
+		tokens := make([]models.Token, len(p.Parameters.Command))
+		for i, el := range p.Parameters.Command {
+			tokens[i] = el.ToToken()
+		}
+		v.Example = engine.Render(tokens)
+	}
+	for _, name := range p.Parameters.ModifierNames() {
+		mi := modifierInfo{Name: name, AppliesTo: []string{}}
+		if base, err := modifiers.ParseConfig(p.Parameters.Modifiers[name]); err == nil {
+			if base.AppliesTo != nil {
+				mi.AppliesTo = base.AppliesTo
+			}
+			mi.Probability = string(base.Probability)
+		}
+		_, mi.Known = modifiers.Get(name)
+		v.Modifiers = append(v.Modifiers, mi)
+	}
+	return v
+}
+
+func writeProfile(w io.Writer, info profileInfo) {
+	fmt.Fprintln(w, info.Name)
+	if len(info.Aliases) > 0 {
+		fmt.Fprintf(w, "  aliases:     %s\n", strings.Join(info.Aliases, ", "))
+	}
+	if info.Description != "" {
+		fmt.Fprintf(w, "  description: %s\n", info.Description)
+	}
+	if len(info.Attack) > 0 {
+		fmt.Fprintf(w, "  attack:      %s\n", strings.Join(info.Attack, ", "))
+	}
+	if l := info.LOLBAS; l != nil {
+		fmt.Fprintf(w, "\nlolbas: %s", l.Name)
+		if l.Description != "" {
+			fmt.Fprintf(w, ", %s", l.Description)
+		}
+		fmt.Fprintln(w)
+		if len(l.Functions) > 0 {
+			fmt.Fprintf(w, "  functions: %s\n", strings.Join(l.Functions, ", "))
+		}
+		if len(l.Attack) > 0 {
+			fmt.Fprintf(w, "  attack:    %s\n", strings.Join(l.Attack, ", "))
+		}
+		if len(l.Paths) > 0 {
+			fmt.Fprintf(w, "  paths:     %s\n", strings.Join(l.Paths, ", "))
+		}
+		if len(l.Detections) > 0 {
+			fmt.Fprintln(w, "  detections:")
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			for _, d := range l.Detections {
+				fmt.Fprintf(tw, "    %s\t%s\n", d.Kind, d.Value)
+			}
+			tw.Flush()
+		}
+	}
+	for i, v := range info.Profiles {
+		fmt.Fprintf(w, "\nprofile %d: %s", i, v.Platform)
+		if os := strings.TrimSpace(v.OperatingSystem + " " + v.OperatingSystemVersion); os != "" {
+			fmt.Fprintf(w, " (%s)", os)
+		}
+		if v.ExecutableVersion != "" {
+			fmt.Fprintf(w, ", version %s", v.ExecutableVersion)
+		}
+		fmt.Fprintln(w)
+		if v.Example != "" {
+			fmt.Fprintf(w, "  example: %s\n", v.Example)
+		}
+
+		fmt.Fprintln(w, "  modifiers:")
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, m := range v.Modifiers {
+			note := ""
+			if !m.Known {
+				note = "\t(unknown modifier)"
+			}
+			fmt.Fprintf(tw, "    %s\tp=%s\t%s%s\n", m.Name, m.Probability, strings.Join(m.AppliesTo, ","), note)
+		}
+		tw.Flush()
+
+		if len(v.Arguments) == 0 {
+			fmt.Fprintln(w, "  arguments: none defined")
+			continue
+		}
+		fmt.Fprintln(w, "  arguments:")
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, arg := range v.Arguments {
+			fmt.Fprintf(tw, "    %s\t%s\n", strings.Join(arg.Flags, ", "), plural(arg.ValueCount, "value"))
+		}
+		tw.Flush()
+	}
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// printJSON writes v to stdout as indented JSON.
+func (a *app) printJSON(v any) int {
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		a.errorf("%v", err)
+		return exitError
+	}
+	return exitOK
+}
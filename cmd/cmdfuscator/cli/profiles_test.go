@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProfilesList(t *testing.T) {
+	code, stdout, stderr := run(t, "", "profiles", "list")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if !strings.HasPrefix(lines[0], "NAME") {
+		t.Errorf("no header: %q", lines[0])
+	}
+	var names []string
+	for _, l := range lines[1:] {
+		names = append(names, strings.Fields(l)[0])
+	}
+	if got := strings.Join(names, " "); got != "bash certutil powershell" {
+		t.Errorf("names = %s, want bash certutil powershell", got)
+	}
+}
+
+func TestProfilesListJSONPlatform(t *testing.T) {
+	tests := []struct {
+		platform string
+		want     []string
+	}{
+		{"windows", []string{"certutil", "powershell"}},
+		{"Linux", []string{"bash"}},
+		{"plan9", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			code, stdout, stderr := run(t, "", "profiles", "list", "--platform", tt.platform, "--json")
+			if code != exitOK {
+				t.Fatalf("exit code = %d, stderr %q", code, stderr)
+			}
+			var infos []profileInfo
+			if err := json.Unmarshal([]byte(stdout), &infos); err != nil {
+				t.Fatalf("output is not JSON: %v\n%s", err, stdout)
+			}
+			if infos == nil {
+				t.Fatal("want a JSON array, got null")
+			}
+			var got []string
+			for _, info := range infos {
+				got = append(got, info.Name)
+				if len(info.Modifiers) == 0 || info.Profiles != nil {
+					t.Errorf("%s: modifiers %v, profiles %v", info.Name, info.Modifiers, info.Profiles)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("names = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfilesShow(t *testing.T) {
+	// Aliases and executable file names resolve too.
+	for _, name := range []string{"powershell", "pwsh", "PowerShell.exe"} {
+		code, stdout, stderr := run(t, "", "profiles", "show", name)
+		if code != exitOK {
+			t.Fatalf("%s: exit code = %d, stderr %q", name, code, stderr)
+		}
+		for _, want := range []string{"powershell\n", "aliases:     pwsh", "profile 0: windows", "example: powershell", "Shorthands", "arguments:"} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("%s: output lacks %q:\n%s", name, want, stdout)
+			}
+		}
+	}
+}
+
+func TestProfilesShowJSON(t *testing.T) {
+	// Flags may follow the name.
+	code, stdout, stderr := run(t, "", "profiles", "show", "certutil", "--json")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	var info profileInfo
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout)
+	}
+	if info.Name != "certutil" || len(info.Profiles) != 1 {
+		t.Fatalf("info = %+v", info)
+	}
+	v := info.Profiles[0]
+	if v.Platform != "windows" || len(v.Modifiers) != len(info.Modifiers) || v.Arguments == nil {
+		t.Errorf("profile = %+v", v)
+	}
+	for _, m := range v.Modifiers {
+		if !m.Known || m.Probability == "" || len(m.AppliesTo) == 0 {
+			t.Errorf("modifier = %+v", m)
+		}
+	}
+	if l := info.LOLBAS; l == nil || l.Name != "Certutil.exe" || !slices.Contains(l.Functions, "Download") || len(l.Detections) == 0 {
+		t.Errorf("lolbas = %+v, want the embedded Certutil.exe entry", l)
+	}
+}
+
+func TestProfilesShowLOLBAS(t *testing.T) {
+	code, stdout, _ := run(t, "", "profiles", "show", "certutil")
+	if code != exitOK {
+		t.Fatalf("exit code = %d", code)
+	}
+	for _, want := range []string{"lolbas: Certutil.exe, ", "  functions: Download, ", "  attack:    T1105", "    Sigma    https://"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output lacks %q:\n%s", want, stdout)
+		}
+	}
+	// LOLBAS covers Windows binaries only.
+	if _, stdout, _ := run(t, "", "profiles", "show", "bash"); strings.Contains(stdout, "lolbas:") {
+		t.Errorf("bash shows LOLBAS metadata:\n%s", stdout)
+	}
+
+	path := filepath.Join(t.TempDir(), "lolbas.json")
+	listing := `[{"Name": "Certutil.exe", "Description": "From a file", "Commands": [{"Category": "Execute", "MitreID": "T1218"}]}]`
+	if err := os.WriteFile(path, []byte(listing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stdout, _ := run(t, "", "profiles", "show", "--lolbas", path, "certutil"); !strings.Contains(stdout, "lolbas: Certutil.exe, From a file\n  functions: Execute\n") {
+		t.Errorf("--lolbas %s: output\n%s", path, stdout)
+	}
+	code, stdout, stderr := run(t, "", "profiles", "show", "--lolbas", filepath.Join(t.TempDir(), "nope.json"), "certutil")
+	if code != exitOK || strings.Contains(stdout, "lolbas:") || !strings.Contains(stderr, "shown without LOLBAS metadata") {
+		t.Errorf("missing --lolbas file: exit code %d, stderr %q; want a warning and the profile alone", code, stderr)
+	}
+}
+
+func TestProfilesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{"no subcommand", nil, exitUsage, "want list or show"},
+		{"unknown subcommand", []string{"delete"}, exitUsage, `unknown subcommand "delete"`},
+		{"show without name", []string{"show"}, exitUsage, "want exactly one executable name"},
+		{"show unknown", []string{"show", "notepad"}, exitError, `no profile for "notepad"`},
+		{"list with argument", []string{"list", "bash"}, exitUsage, `unexpected argument "bash"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := run(t, "", append([]string{"profiles"}, tt.args...)...)
+			if code != tt.wantCode || !strings.Contains(stderr, tt.wantErr) {
+				t.Errorf("exit code = %d, stderr %q; want %d and %q", code, stderr, tt.wantCode, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"cmdFuscator/loader"
+	"cmdFuscator/loader/lint"
+	"cmdFuscator/models"
+)
+
+// ─── validate ─────────────────────────────────────────────────────────────────
+
+// validate implements `cmdfuscator validate [flags] DIR...`: the schema check
+// of loader.Validate and the semantic checks of lint.Lint over each
+// directory, one problem per line on stdout. The exit code is exitError when
+// any error was found (or any warning, with --strict), so profile authors can
+// gate changes in CI.
+func (a *app) validate(args []string) int {
+	fset := a.flags("validate", "[flags] DIR...")
+	strict := fset.Bool("strict", false, "fail on warnings too")
+	dirs, code, ok := parseInterspersed(fset, args)
+	if !ok {
+		return code
+	}
+	if len(dirs) == 0 {
+		a.errorf("validate: no directory given")
+		fset.Usage()
+		return exitUsage
+	}
+
+	var total validateCounts
+	for _, dir := range dirs {
+		counts, err := a.validateDir(dir)
+		if err != nil {
+			a.errorf("validate: %v", err)
+			return exitError
+		}
+		total.add(counts)
+	}
+
+	fmt.Fprintf(a.stderr, "cmdfuscator: validate: %s, %s in %s\n",
+		plural(total.errors, "error"), plural(total.warnings, "warning"), plural(total.files, "file"))
+	if total.errors > 0 || (*strict && total.warnings > 0) {
+		return exitError
+	}
+	return exitOK
+}
+
+type validateCounts struct{ files, errors, warnings int }
+
+func (c *validateCounts) add(o validateCounts) {
+	c.files += o.files
+	c.errors += o.errors
+	c.warnings += o.warnings
+}
+
+// validateDir checks one directory and prints its problems. File names are
+// printed joined with dir, so they can be opened from the output as is.
+func (a *app) validateDir(dir string) (validateCounts, error) {
+	var counts validateCounts
+	info, err := os.Stat(dir)
+	if err != nil {
+		return counts, err
+	}
+	if !info.IsDir() {
+		return counts, fmt.Errorf("%s is not a directory", dir)
+	}
+	fsys := os.DirFS(dir)
+	join := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+
+	issues, err := loader.Validate(fsys)
+	if err != nil {
+		return counts, err
+	}
+	// Schema issues by file, as JSON Pointers, so lint diagnostics about the
+	// same value are not printed twice.
+	flagged := make(map[string][]string)
+	for _, is := range issues {
+		flagged[is.File] = append(flagged[is.File], is.Path)
+		is.File = join(is.File)
+		fmt.Fprintf(a.stdout, "%s: error: %s\n", is.File+location(is), is.Message)
+		counts.errors++
+	}
+
+	profiles, rep, err := loader.LoadFSReport(fsys)
+	if err != nil && len(rep.Skipped) == 0 {
+		return counts, err
+	}
+	counts.files = len(rep.Loaded) + len(rep.Skipped)
+	for _, fe := range rep.Skipped {
+		// A file the schema already explained needs no second line.
+		if len(flagged[fe.File]) == 0 {
+			fmt.Fprintf(a.stdout, "%s: error: %v\n", join(fe.File), fe.Err)
+			counts.errors++
+		}
+	}
+	for _, fe := range rep.Warnings {
+		fmt.Fprintf(a.stdout, "%s: warning: %v\n", join(fe.File), fe.Err)
+		counts.warnings++
+	}
+
+	// profiles and rep.Loaded are parallel: LoadFSReport does not filter.
+	for i, pf := range profiles {
+		file := rep.Loaded[i]
+		for _, d := range lint.Lint([]*models.ProfileFile{pf}) {
+			if covered(flagged[file], d) {
+				continue
+			}
+			d.File = join(file)
+			fmt.Fprintln(a.stdout, d)
+			if d.Severity == lint.Error {
+				counts.errors++
+			} else {
+				counts.warnings++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// location formats an Issue's position and JSON Pointer as ":line:col: path".
+func location(is loader.Issue) string {
+	loc := fmt.Sprintf(":%d:%d", is.Line, is.Col)
+	if is.Path != "" {
+		loc += ": " + is.Path
+	}
+	return loc
+}
+
+var indexRe = regexp.MustCompile(`\[(\d+)\]`)
+
+// covered reports whether a schema issue was raised at d's location or
+// inside it.
+func covered(pointers []string, d lint.Diagnostic) bool {
+	ptr := fmt.Sprintf("/profiles/%d", d.Profile)
+	if d.Path != "" {
+		ptr += "/" + strings.ReplaceAll(indexRe.ReplaceAllString(d.Path, ".$1"), ".", "/")
+	}
+	for _, p := range pointers {
+		if p == ptr || strings.HasPrefix(p, ptr+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// profileDir writes files (path → contents) into a new temporary directory.
+func profileDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const validProfile = `{"versions":{"format":"2.0"},"profiles":[{"platform":"linux","parameters":{
+	"command":[{"command":"tool"}],"arguments":[{"flags":["-f"],"valueCount":1}],
+	"modifiers":{"RandomCase":{"AppliesTo":["argument"],"Probability":"0.5"}}}}]}`
+
+func TestValidateEmbedded(t *testing.T) {
+	code, stdout, stderr := run(t, "", "validate", filepath.Join("..", "..", "..", "data", "models"))
+	if code != exitOK || stdout != "" {
+		t.Errorf("exit code = %d, stdout:\n%s", code, stdout)
+	}
+	if !strings.Contains(stderr, "0 errors, 0 warnings in 3 files") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		strict   bool
+		wantCode int
+		// want lists substrings of the stdout lines, in order.
+		want    []string
+		summary string
+	}{
+		{
+			name:     "clean",
+			files:    map[string]string{"tool.json": validProfile},
+			wantCode: exitOK,
+			summary:  "0 errors, 0 warnings in 1 file",
+		},
+		{
+			name: "schema error is not repeated by the linter",
+			files: map[string]string{"tool.json": strings.Replace(validProfile,
+				`"RandomCase"`, `"RandomCsae"`, 1)},
+			wantCode: exitError,
+			want:     []string{`tool.json:3:15: /profiles/0/parameters/modifiers/RandomCsae: error: unknown modifier "RandomCsae"`},
+			summary:  "1 error, 0 warnings in 1 file",
+		},
+		{
+			name: "lint only",
+			files: map[string]string{"tool.json": strings.Replace(validProfile,
+				`"valueCount":1}]`, `"valueCount":1},{"flags":["-f"],"valueCount":0}]`, 1)},
+			wantCode: exitError,
+			want:     []string{`tool.json: profiles[0].parameters.arguments[1].flags: error: flag "-f" is also defined`},
+			summary:  "1 error, 0 warnings in 1 file",
+		},
+		{
+			name: "warnings pass",
+			files: map[string]string{"tool.json": strings.Replace(validProfile,
+				`"Probability":"0.5"`, `"Probability":"0"`, 1)},
+			wantCode: exitOK,
+			want:     []string{`warning: probability 0 means the modifier never fires`},
+			summary:  "0 errors, 1 warning in 1 file",
+		},
+		{
+			name: "warnings fail with strict",
+			files: map[string]string{"tool.json": strings.Replace(validProfile,
+				`"Probability":"0.5"`, `"Probability":"0"`, 1)},
+			strict:   true,
+			wantCode: exitError,
+			want:     []string{`warning: probability 0`},
+			summary:  "0 errors, 1 warning in 1 file",
+		},
+		{
+			name: "broken file in a subdirectory",
+			files: map[string]string{
+				"tool.json":       validProfile,
+				"sub/broken.yaml": "versions: [",
+			},
+			wantCode: exitError,
+			want:     []string{filepath.Join("sub", "broken.yaml") + ":1:"},
+			summary:  "in 2 files",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := profileDir(t, tt.files)
+			args := []string{"validate"}
+			if tt.strict {
+				args = append(args, "--strict")
+			}
+			code, stdout, stderr := run(t, "", append(args, dir)...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			var lines []string
+			if out := strings.TrimSuffix(stdout, "\n"); out != "" {
+				lines = strings.Split(out, "\n")
+			}
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(tt.want), stdout)
+			}
+			for i, w := range tt.want {
+				if !strings.HasPrefix(lines[i], dir) || !strings.Contains(lines[i], w) {
+					t.Errorf("line %d = %q, want it under %s and containing %q", i, lines[i], dir, w)
+				}
+			}
+			if !strings.Contains(stderr, tt.summary) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.summary)
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	file := filepath.Join(profileDir(t, map[string]string{"tool.json": validProfile}), "tool.json")
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{"no directory", nil, exitUsage, "no directory given"},
+		{"missing", []string{filepath.Join(t.TempDir(), "nope")}, exitError, "no such file or directory"},
+		{"not a directory", []string{file}, exitError, "is not a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := run(t, "", append([]string{"validate"}, tt.args...)...)
+			if code != tt.wantCode || !strings.Contains(stderr, tt.wantErr) {
+				t.Errorf("exit code = %d, stderr %q; want %d and %q", code, stderr, tt.wantCode, tt.wantErr)
+			}
+		})
+	}
+}
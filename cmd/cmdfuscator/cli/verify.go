@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/models"
+	"cmdFuscator/verify"
+)
+
+// ─── --verify ─────────────────────────────────────────────────────────────────
+
+// verifier parses an obfuscate run's variants as the shell they were rendered
+// for would, and reports on stderr the ones that no longer run what the
+// original command did. Bash variants, and on Windows cmd ones, are
+// compared with the original here; PowerShell ones are parsed by pwsh, as the
+// engine's validator, and only checked for syntax errors.
+type verifier struct {
+	*obfuscator
+	target engine.RenderTarget // the --target given, before TargetAuto is resolved
+	pwsh   *verify.Pwsh        // nil when pwsh is not installed
+
+	// warnings holds the validator's findings for each variant recorded.
+	warnings map[string][]error
+
+	checked, changed int
+}
+
+// newVerifier returns the verifier of o, and the engine options it needs.
+func newVerifier(o *obfuscator, target engine.RenderTarget) (*verifier, []engine.Option) {
+	v := &verifier{obfuscator: o, target: target, warnings: make(map[string][]error)}
+	pwsh, err := verify.LookPwsh()
+	if err != nil {
+		return v, nil
+	}
+	v.pwsh = pwsh
+	return v, []engine.Option{engine.WithValidators(pwsh)}
+}
+
+// note keeps what the engine's validator found wrong with res.
+func (v *verifier) note(res engine.ObfuscateResult) {
+	v.warnings[res.Output] = res.Warnings
+}
+
+// check reports whether variant, called label, runs what input does.
+// Variants rendered for a shell verify has no parser for are skipped, with a
+// warning.
+func (v *verifier) check(pf *models.ProfileFile, label, input, variant string) {
+	target := v.target
+	if target == engine.TargetAuto && len(pf.Profiles) > 0 {
+		target = engine.TargetFor(pf.Profiles[0])
+	}
+	var err error
+	switch target {
+	case engine.TargetBash:
+		err = verify.Bash(input, variant)
+	case engine.TargetCmd:
+		if err = verify.Cmd(input, variant); errors.Is(err, verify.ErrNoCmd) {
+			v.warnOnce("%v; %s skipped", err, pf.Name)
+			return
+		}
+	case engine.TargetPowerShell:
+		if v.pwsh == nil {
+			v.warnOnce("verify: pwsh not found; %s skipped", pf.Name)
+			return
+		}
+		err = errors.Join(v.warnings[variant]...)
+	default:
+		v.warnOnce("verify: cannot check commands rendered for %s; %s skipped", target, pf.Name)
+		return
+	}
+	var m *verify.Mismatch
+	switch {
+	case errors.As(err, &m):
+		v.checked++
+		v.changed++
+		fmt.Fprintf(v.stderr, "verify: %s changes what runs: %s\n", label, m.Reason)
+	case err != nil:
+		v.warnOnce("%v; not checked", err)
+	default:
+		v.checked++
+	}
+}
+
+// summary reports how many of the commands checked, counted as what, run
+// something other than their original.
+func (v *verifier) summary(what string) {
+	fmt.Fprintf(v.stderr, "verify: %d of %s change what runs\n", v.changed, plural(v.checked, what))
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestObfuscateVerify(t *testing.T) {
+	// RandomCase flips the -c of bash -c, which bash reads as another option.
+	code, stdout, stderr := run(t, "", "obfuscate", "--seed", "3", "--count", "2", "--modifiers", "RandomCase",
+		"--verify", "bash -c id")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	variants := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	changed := 0
+	for i, v := range variants {
+		if strings.Contains(v, "-C") {
+			changed++
+			if !strings.Contains(stderr, fmt.Sprintf(`variant %d changes what runs: word 2 of command 1 is "-C", the original "-c"`, i+1)) {
+				t.Errorf("variant %d %q not flagged; stderr:\n%s", i+1, v, stderr)
+			}
+		}
+	}
+	if len(variants) != 2 || changed != 1 {
+		t.Fatalf("variants %q: want bash -c id and bash -C id", variants)
+	}
+	if want := "verify: 1 of 2 variants change what runs\n"; !strings.HasSuffix(stderr, want) {
+		t.Errorf("stderr = %q, want it to end %q", stderr, want)
+	}
+}
+
+func TestObfuscateVerifyStdin(t *testing.T) {
+	code, stdout, stderr := run(t, "bash -c 'id'\ncertutil -f x\n", "obfuscate", "--stdin", "--verify", "--modifiers", "QuoteInsertion")
+	if code != exitOK || strings.Count(stdout, "\n") != 2 {
+		t.Fatalf("exit code = %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+	wants := []string{"verify: 0 of 2 lines change what runs\n"}
+	if runtime.GOOS != "windows" {
+		wants = []string{"warning: verify: cmd.exe checks need Windows; certutil skipped\n", "verify: 0 of 1 line change what runs\n"}
+	}
+	for _, want := range wants {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr = %q, want %q", stderr, want)
+		}
+	}
+}
+
+func TestObfuscateVerifyPwsh(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake pwsh is a shell script")
+	}
+	dir := t.TempDir()
+	fake := "#!/bin/sh\necho \"1:5: Unexpected token '-C' in expression or statement.\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pwsh"), []byte(fake), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	code, _, stderr := run(t, "", "obfuscate", "--target", "powershell", "--count", "2", "--verify", "powershell -c Get-Process")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr)
+	}
+	for _, want := range []string{
+		"verify: variant 2 changes what runs: PowerShell cannot parse it: 1:5: Unexpected token '-C' in expression or statement.\n",
+		"verify: 2 of 2 variants change what runs\n",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr = %q, want %q", stderr, want)
+		}
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	_, _, stderr = run(t, "", "obfuscate", "--target", "powershell", "--verify", "powershell -c Get-Process")
+	if !strings.Contains(stderr, "warning: verify: pwsh not found; powershell skipped\n") {
+		t.Errorf("without pwsh: stderr = %q", stderr)
+	}
+}
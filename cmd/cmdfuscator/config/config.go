@@ -0,0 +1,421 @@
+// Package config reads the cmdfuscator settings file, config.toml or
+// config.yaml in <user config dir>/cmdfuscator (e.g. ~/.config/cmdfuscator on
+// Linux). Both the CLI and the TUI load it at startup and use it for their
+// defaults; CLI flags still win over it.
+//
+//	modifiers    = ["RandomCase", "CharacterInsertion"]
+//	profiles     = "remote"
+//	profile_dirs = ["~/work/profiles"]
+//	manifest     = "SHA256SUMS"
+//	public_key   = "~/.config/cmdfuscator/minisign.pub"
+//	target       = "powershell"
+//	seed         = 42
+//	lolbas       = "remote"
+//	history      = true
+//	theme        = "light"
+//	sigma        = "~/sigma/rules/windows/process_creation"
+//
+//	[probabilities]
+//	RandomCase = 0.3
+//
+//	[colors]
+//	accent = "#005F87"
+//
+//	[keys]
+//	up   = ["ctrl+p", "up"]
+//	down = ["ctrl+n", "down"]
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+// File names Load looks for, in Dir.
+const (
+	TOMLName = "config.toml"
+	YAMLName = "config.yaml"
+)
+
+// Config is the contents of the settings file. The zero value is the
+// behaviour without one.
+type Config struct {
+	// Modifiers are the modifiers enabled when a profile is selected, by
+	// name. Empty enables every modifier the profile configures.
+	Modifiers []string `toml:"modifiers" yaml:"modifiers"`
+	// Probabilities overrides, by modifier name, the Probability every
+	// profile configures for that modifier. Values are in [0, 1].
+	Probabilities map[string]float64 `toml:"probabilities" yaml:"probabilities"`
+	// Profiles is the profile set the overlays are laid over: empty or
+	// "embedded" for the built-in one, or "remote" for ArgFuscator.net's,
+	// synced into remote.DefaultCacheDir() once a day, with the built-in
+	// set as fallback when upstream and the cache both fail.
+	Profiles string `toml:"profiles" yaml:"profiles"`
+	// ProfileDirs are the overlay directories laid over the profile set,
+	// later ones winning. Empty means loader.DefaultUserDir().
+	// "~/" expands to the home directory, and relative paths are relative
+	// to the settings file.
+	ProfileDirs []string `toml:"profile_dirs" yaml:"profile_dirs"`
+	// Manifest and PublicKey, when either is set, require every overlay
+	// directory to hold a SHA-256 manifest listing its profile files (see
+	// loader.Verified): Manifest names it, loader.DefaultManifest when
+	// empty, and PublicKey, a minisign public key or a file holding one,
+	// requires it to be signed in Manifest+".minisig". A relative key file
+	// is relative to the settings file.
+	Manifest  string `toml:"manifest" yaml:"manifest"`
+	PublicKey string `toml:"public_key" yaml:"public_key"`
+	// Target is the shell output is rendered for, as accepted by
+	// engine.ParseRenderTarget. Empty means auto.
+	Target string `toml:"target" yaml:"target"`
+	// Seed, when set, seeds every run, so the same input always gives the
+	// same output. Unset, each run draws a fresh seed.
+	Seed *int64 `toml:"seed" yaml:"seed"`
+	// LOLBAS is where LOLBAS metadata on executables comes from, as accepted
+	// by lolbas.Open: empty or "embedded" for the built-in snapshot,
+	// "remote" or a URL for the project's API, or a file. A relative file
+	// is relative to the settings file.
+	LOLBAS string `toml:"lolbas" yaml:"lolbas"`
+	// History keeps the TUI's obfuscation history across sessions, in
+	// HistoryFile. Off, the history lasts for the session.
+	History bool `toml:"history" yaml:"history"`
+	// Theme is the TUI's palette: "dark", the default, "light" or "mono".
+	// The TUI is mono whatever it says when NO_COLOR is set.
+	Theme string `toml:"theme" yaml:"theme"`
+	// Colors overrides colours of the theme, by the names in ColorNames,
+	// as "#RGB", "#RRGGBB" or an ANSI colour number from 0 to 255.
+	Colors map[string]string `toml:"colors" yaml:"colors"`
+	// Sigma and Patterns are detection rules the TUI checks every variant
+	// against, as obfuscate's --sigma and --patterns do: Sigma
+	// process_creation rules in a file or directory tree, and a signature
+	// file, one substring or re:regexp per line. Relative paths are relative
+	// to the settings file. The CLI only uses its flags.
+	Sigma    string `toml:"sigma" yaml:"sigma"`
+	Patterns string `toml:"patterns" yaml:"patterns"`
+	// Keys rebinds TUI actions, by action name, to keys as Bubble Tea names
+	// them ("ctrl+p", "alt+enter", "x"). The TUI checks them, and reports
+	// unknown actions and conflicting keys and keeps its defaults.
+	Keys map[string][]string `toml:"keys" yaml:"keys"`
+
+	// File is the file the settings were read from; empty for the zero value.
+	File string `toml:"-" yaml:"-"`
+
+	target engine.RenderTarget
+}
+
+// ProfileSources are the values Profiles accepts besides "".
+var ProfileSources = []string{"embedded", "remote"}
+
+// Themes are the values Theme accepts besides "".
+var Themes = []string{"dark", "light", "mono"}
+
+// ColorNames are the keys Colors accepts: what the TUI draws in each colour.
+var ColorNames = []string{
+	"accent",    // title, selection, checked boxes
+	"highlight", // section labels, changed characters
+	"muted",     // subtitles, status bar, inactive tabs
+	"dim",       // hints and placeholders
+	"text",      // plain text
+	"border",    // panel borders
+	"focus",     // the focused panel's border
+	"error",     // errors
+	"key",       // key names, invisible-character markers
+}
+
+// Dir returns the directory Load reads from, or "" when the platform has no
+// user config directory.
+func Dir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cmdfuscator")
+}
+
+// HistoryFile returns the file the TUI keeps its history in when History is
+// set: history.jsonl in $XDG_DATA_HOME/cmdfuscator, which defaults to
+// ~/.local/share/cmdfuscator, or in %LocalAppData%\cmdfuscator on Windows.
+// It returns "" when there is no such directory.
+func HistoryFile() string {
+	return stateFile("history.jsonl")
+}
+
+// PinsFile returns the file the TUI keeps the executables pinned to the top
+// of its sidebar in: pins.json, next to HistoryFile. It returns "" when
+// there is no such directory.
+func PinsFile() string {
+	return stateFile("pins.json")
+}
+
+// InputsFile returns the file the TUI keeps the commands applied to each
+// executable in, for recalling them with the arrow keys: inputs.json, next
+// to HistoryFile. It returns "" when there is no such directory.
+func InputsFile() string {
+	return stateFile("inputs.json")
+}
+
+// stateFile returns the path of the TUI state file name, in the directory
+// HistoryFile describes, or "" when there is none.
+func stateFile(name string) string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" && runtime.GOOS == "windows" {
+		dir = os.Getenv("LocalAppData")
+	}
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "cmdfuscator", name)
+}
+
+// Load reads TOMLName or YAMLName from Dir. A missing file is not an error:
+// Load returns the zero Config. Having both files is, since it would be
+// unclear which one is in effect.
+func Load() (*Config, error) {
+	dir := Dir()
+	if dir == "" {
+		return &Config{}, nil
+	}
+	var found []string
+	for _, name := range []string{TOMLName, YAMLName} {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			found = append(found, p)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return &Config{}, nil
+	case 1:
+		return LoadFile(found[0])
+	}
+	return nil, fmt.Errorf("config: both %s and %s exist in %s; keep one", TOMLName, YAMLName, dir)
+}
+
+// LoadFile reads the settings in path, a .toml, .yaml or .yml file. Unknown
+// keys, unknown modifiers, out-of-range values and a public key that does
+// not read are errors.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	cfg := &Config{File: path}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		var md toml.MetaData
+		if md, err = toml.Decode(string(data), cfg); err == nil {
+			if extra := md.Undecoded(); len(extra) > 0 {
+				err = fmt.Errorf("unknown key %q", extra[0].String())
+			}
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(cfg); errors.Is(err, io.EOF) {
+			err = nil // an empty file
+		}
+	default:
+		err = fmt.Errorf("unsupported file type %q", ext)
+	}
+	if err == nil {
+		err = cfg.check()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// check validates c and resolves its directories and target.
+func (c *Config) check() error {
+	for _, name := range c.Modifiers {
+		if _, ok := modifiers.Get(name); !ok {
+			return fmt.Errorf("modifiers: unknown modifier %q", name)
+		}
+	}
+	for name, p := range c.Probabilities {
+		if _, ok := modifiers.Get(name); !ok {
+			return fmt.Errorf("probabilities: unknown modifier %q", name)
+		}
+		if p < 0 || p > 1 {
+			return fmt.Errorf("probabilities: %s = %v is outside [0, 1]", name, p)
+		}
+	}
+	t, err := engine.ParseRenderTarget(c.Target)
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	c.target = t
+	if c.Profiles != "" && !slices.Contains(ProfileSources, c.Profiles) {
+		return fmt.Errorf("profiles: unknown profile set %q; want one of %s", c.Profiles, strings.Join(ProfileSources, ", "))
+	}
+	for i, dir := range c.ProfileDirs {
+		if c.ProfileDirs[i], err = c.resolve(dir); err != nil {
+			return fmt.Errorf("profile_dirs: %w", err)
+		}
+	}
+	if c.Manifest != "" && !fs.ValidPath(c.Manifest) {
+		return fmt.Errorf("manifest: %q is not a slash-separated path within the overlay directories", c.Manifest)
+	}
+	if c.PublicKey != "" {
+		if _, err := loader.ParsePublicKey(c.PublicKey); err != nil {
+			if c.PublicKey, err = c.resolve(c.PublicKey); err != nil {
+				return fmt.Errorf("public_key: %w", err)
+			}
+		}
+		if _, err := c.Verifier(); err != nil {
+			return err
+		}
+	}
+	if c.Theme != "" && !slices.Contains(Themes, c.Theme) {
+		return fmt.Errorf("theme: unknown theme %q; want one of %s", c.Theme, strings.Join(Themes, ", "))
+	}
+	for name, color := range c.Colors {
+		if !slices.Contains(ColorNames, name) {
+			return fmt.Errorf("colors: unknown colour %q", name)
+		}
+		if !validColor(color) {
+			return fmt.Errorf("colors: %s = %q is not #RGB, #RRGGBB or 0-255", name, color)
+		}
+	}
+	if c.Sigma != "" {
+		if c.Sigma, err = c.resolve(c.Sigma); err != nil {
+			return fmt.Errorf("sigma: %w", err)
+		}
+	}
+	if c.Patterns != "" {
+		if c.Patterns, err = c.resolve(c.Patterns); err != nil {
+			return fmt.Errorf("patterns: %w", err)
+		}
+	}
+	switch src := c.LOLBAS; {
+	case src == "", src == "embedded", src == "remote", strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"):
+	default:
+		if c.LOLBAS, err = c.resolve(src); err != nil {
+			return fmt.Errorf("lolbas: %w", err)
+		}
+	}
+	return nil
+}
+
+// validColor reports whether s is a hex colour or an ANSI colour number.
+func validColor(s string) bool {
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		if len(hex) != 3 && len(hex) != 6 {
+			return false
+		}
+		_, err := strconv.ParseUint(hex, 16, 32)
+		return err == nil
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// resolve expands a leading "~/" in dir, a directory or file, and makes it
+// absolute relative to the settings file.
+func (c *Config) resolve(dir string) (string, error) {
+	if dir == "" {
+		return "", errors.New("empty directory")
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, dir[1:])
+	}
+	if !filepath.IsAbs(dir) && c.File != "" {
+		dir = filepath.Join(filepath.Dir(c.File), dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+// ─── Defaults ─────────────────────────────────────────────────────────────────
+
+// Dirs returns the overlay directories to load profiles from.
+func (c *Config) Dirs() []string {
+	if len(c.ProfileDirs) > 0 {
+		return c.ProfileDirs
+	}
+	return []string{loader.DefaultUserDir()}
+}
+
+// Verifier returns what overlay directories must pass before they are
+// loaded, or nil when neither Manifest nor PublicKey is set. A PublicKey
+// that is not a key is read as a file.
+func (c *Config) Verifier() (*loader.Verifier, error) {
+	if c.Manifest == "" && c.PublicKey == "" {
+		return nil, nil
+	}
+	v := &loader.Verifier{Manifest: c.Manifest}
+	if c.PublicKey == "" {
+		return v, nil
+	}
+	pk, err := loader.ParsePublicKey(c.PublicKey)
+	if err != nil {
+		data, rerr := os.ReadFile(c.PublicKey)
+		if rerr != nil {
+			return nil, fmt.Errorf("public_key: %w", rerr)
+		}
+		if pk, err = loader.ParsePublicKey(string(data)); err != nil {
+			return nil, fmt.Errorf("public_key: %s: %w", c.PublicKey, err)
+		}
+	}
+	v.PublicKey = pk
+	return v, nil
+}
+
+// Enabled returns the modifiers to enable for pf: engine.DefaultEnabled
+// narrowed to Modifiers when that is set.
+func (c *Config) Enabled(pf *models.ProfileFile) map[string]bool {
+	enabled := engine.DefaultEnabled(pf)
+	if len(c.Modifiers) == 0 {
+		return enabled
+	}
+	for name := range enabled {
+		enabled[name] = false
+	}
+	for _, name := range c.Modifiers {
+		if _, ok := enabled[name]; ok {
+			enabled[name] = true
+		}
+	}
+	return enabled
+}
+
+// RenderTarget returns the parsed Target.
+func (c *Config) RenderTarget() engine.RenderTarget {
+	return c.target
+}
+
+// EngineOptions returns the engine options the settings imply: the render
+// target and the probability overrides.
+func (c *Config) EngineOptions() []engine.Option {
+	opts := []engine.Option{engine.WithRenderTarget(c.target)}
+	if len(c.Probabilities) > 0 {
+		opts = append(opts, engine.WithProbabilities(c.Probabilities))
+	}
+	return opts
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/models"
+)
+
+// useDir points Dir at a fresh temporary directory and returns it.
+func useDir(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := Dir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func write(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoad_Missing(t *testing.T) {
+	useDir(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.File != "" || cfg.Seed != nil || len(cfg.Modifiers) > 0 {
+		t.Errorf("Load without a file = %+v, want the zero Config", cfg)
+	}
+	if got := cfg.Dirs(); len(got) != 1 || !strings.HasSuffix(got[0], filepath.Join("cmdfuscator", "models")) {
+		t.Errorf("Dirs() = %v, want the default overlay directory", got)
+	}
+}
+
+func TestLoad_Formats(t *testing.T) {
+	tests := []struct {
+		name, body string
+	}{
+		{TOMLName, `
+modifiers    = ["RandomCase", "CharacterInsertion"]
+profiles     = "remote"
+profile_dirs = ["profiles", "~/more"]
+target       = "powershell"
+seed         = 42
+lolbas       = "lolbas.json"
+history      = true
+theme        = "light"
+sigma        = "rules"
+patterns     = "~/sigs.txt"
+
+[probabilities]
+RandomCase = 0.3
+
+[colors]
+accent = "#005F87"
+
+[keys]
+apply = ["ctrl+j", "enter"]
+`},
+		{YAMLName, `
+modifiers: [RandomCase, CharacterInsertion]
+profiles: remote
+profile_dirs: [profiles, ~/more]
+target: powershell
+seed: 42
+lolbas: lolbas.json
+history: true
+theme: light
+sigma: rules
+patterns: ~/sigs.txt
+probabilities:
+  RandomCase: 0.3
+colors:
+  accent: "#005F87"
+keys:
+  apply: [ctrl+j, enter]
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := useDir(t)
+			write(t, dir, tt.name, tt.body)
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got := strings.Join(cfg.Modifiers, ","); got != "RandomCase,CharacterInsertion" {
+				t.Errorf("Modifiers = %s", got)
+			}
+			if cfg.Profiles != "remote" {
+				t.Errorf("Profiles = %q, want remote", cfg.Profiles)
+			}
+			if cfg.Probabilities["RandomCase"] != 0.3 {
+				t.Errorf("Probabilities = %v", cfg.Probabilities)
+			}
+			if cfg.RenderTarget() != engine.TargetPowerShell {
+				t.Errorf("RenderTarget() = %v, want powershell", cfg.RenderTarget())
+			}
+			if cfg.Seed == nil || *cfg.Seed != 42 {
+				t.Errorf("Seed = %v, want 42", cfg.Seed)
+			}
+			if want := filepath.Join(dir, "lolbas.json"); cfg.LOLBAS != want {
+				t.Errorf("LOLBAS = %q, want %q", cfg.LOLBAS, want)
+			}
+			if !cfg.History {
+				t.Error("History = false, want true")
+			}
+			if cfg.Theme != "light" || cfg.Colors["accent"] != "#005F87" {
+				t.Errorf("Theme = %q, Colors = %v", cfg.Theme, cfg.Colors)
+			}
+			if got := strings.Join(cfg.Keys["apply"], ","); got != "ctrl+j,enter" {
+				t.Errorf("Keys = %v", cfg.Keys)
+			}
+			home, _ := os.UserHomeDir()
+			if want := filepath.Join(dir, "rules"); cfg.Sigma != want {
+				t.Errorf("Sigma = %q, want %q", cfg.Sigma, want)
+			}
+			if want := filepath.Join(home, "sigs.txt"); cfg.Patterns != want {
+				t.Errorf("Patterns = %q, want %q", cfg.Patterns, want)
+			}
+			want := []string{filepath.Join(dir, "profiles"), filepath.Join(home, "more")}
+			if got := cfg.Dirs(); strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("Dirs() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoad_BothFiles(t *testing.T) {
+	dir := useDir(t)
+	write(t, dir, TOMLName, "")
+	write(t, dir, YAMLName, "")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "keep one") {
+		t.Errorf("Load with both files: err = %v", err)
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"config.toml", `modifers = ["RandomCase"]`, `unknown key "modifers"`},
+		{"config.yaml", `modifers: [RandomCase]`, "field modifers not found"},
+		{"config.toml", `modifiers = ["Nope"]`, `modifiers: unknown modifier "Nope"`},
+		{"config.toml", "[probabilities]\nRandomCase = 1.5", "RandomCase = 1.5 is outside [0, 1]"},
+		{"config.toml", "[probabilities]\nNope = 0.5", `probabilities: unknown modifier "Nope"`},
+		{"config.toml", `target = "fish"`, `target: unknown render target "fish"`},
+		{"config.toml", `profiles = "github"`, `profiles: unknown profile set "github"`},
+		{"config.toml", `profile_dirs = [""]`, "profile_dirs: empty directory"},
+		{"config.toml", `manifest = "../SHA256SUMS"`, "manifest: \"../SHA256SUMS\" is not a slash-separated path"},
+		{"config.toml", `seed = "random"`, "seed"},
+		{"config.toml", `theme = "solarized"`, `theme: unknown theme "solarized"`},
+		{"config.toml", "[colors]\nbackground = \"#000\"", `colors: unknown colour "background"`},
+		{"config.toml", "[colors]\naccent = \"green\"", `accent = "green" is not #RGB`},
+		{"config.toml", "[colors]\naccent = \"#12345\"", `accent = "#12345" is not #RGB`},
+		{"config.toml", "[colors]\nerror = \"256\"", `error = "256" is not #RGB`},
+		{"config.json", `{}`, `unsupported file type ".json"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			p := write(t, t.TempDir(), tt.name, tt.body)
+			_, err := LoadFile(p)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), p) {
+				t.Errorf("LoadFile: err = %v, want one naming %s and containing %q", err, p, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_Empty(t *testing.T) {
+	for _, name := range []string{"config.toml", "config.yaml"} {
+		cfg, err := LoadFile(write(t, t.TempDir(), name, ""))
+		if err != nil || cfg.RenderTarget() != engine.TargetAuto {
+			t.Errorf("%s: cfg = %+v, err = %v; want defaults", name, cfg, err)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	pf := &models.ProfileFile{Profiles: []models.Profile{{Parameters: models.ProfileParameters{
+		Modifiers: map[string]json.RawMessage{"RandomCase": nil, "CharacterInsertion": nil},
+	}}}}
+
+	all := (&Config{}).Enabled(pf)
+	if !all["RandomCase"] || !all["CharacterInsertion"] {
+		t.Errorf("Enabled without Modifiers = %v, want every configured modifier", all)
+	}
+
+	some := (&Config{Modifiers: []string{"RandomCase", "Sed"}}).Enabled(pf)
+	if !some["RandomCase"] || some["CharacterInsertion"] || some["Sed"] {
+		t.Errorf("Enabled = %v, want only RandomCase", some)
+	}
+	if _, ok := some["CharacterInsertion"]; !ok {
+		t.Error("CharacterInsertion missing; configured modifiers should be listed, switched off")
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	pk, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := base64.StdEncoding.EncodeToString(append([]byte("Ed12345678"), pk...))
+	dir := t.TempDir()
+	write(t, dir, "minisign.pub", "untrusted comment: minisign public key\n"+key+"\n")
+
+	tests := []struct {
+		name, body   string
+		manifest     string
+		verify, sign bool
+	}{
+		{"unset", "", "", false, false},
+		{"manifest", `manifest = "sums.txt"`, "sums.txt", true, false},
+		{"inline key", `public_key = "` + key + `"`, "", true, true},
+		{"key file", `public_key = "minisign.pub"`, "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadFile(write(t, dir, "config.toml", tt.body))
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			v, err := cfg.Verifier()
+			if err != nil {
+				t.Fatalf("Verifier: %v", err)
+			}
+			if (v != nil) != tt.verify {
+				t.Fatalf("Verifier() = %+v, want one: %v", v, tt.verify)
+			}
+			if v != nil && (v.Manifest != tt.manifest || (v.PublicKey != nil) != tt.sign) {
+				t.Errorf("Verifier() = %+v, want manifest %q, key %v", v, tt.manifest, tt.sign)
+			}
+		})
+	}
+
+	p := write(t, dir, "config.toml", `public_key = "missing.pub"`)
+	if _, err := LoadFile(p); err == nil || !strings.Contains(err.Error(), filepath.Join(dir, "missing.pub")) {
+		t.Errorf("missing key file: err = %v, want one naming it", err)
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	if got, want := HistoryFile(), filepath.Join(data, "cmdfuscator", "history.jsonl"); got != want {
+		t.Errorf("HistoryFile() = %q, want %q", got, want)
+	}
+	home := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("HOME", home)
+	t.Setenv("LocalAppData", "")
+	if got, want := HistoryFile(), filepath.Join(home, ".local", "share", "cmdfuscator", "history.jsonl"); got != want {
+		t.Errorf("HistoryFile() without XDG_DATA_HOME = %q, want %q", got, want)
+	}
+}
+
+func TestPinsFile(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	if got, want := PinsFile(), filepath.Join(data, "cmdfuscator", "pins.json"); got != want {
+		t.Errorf("PinsFile() = %q, want %q", got, want)
+	}
+	if got, want := filepath.Dir(PinsFile()), filepath.Dir(HistoryFile()); got != want {
+		t.Errorf("PinsFile() is in %q, want it next to HistoryFile() in %q", got, want)
+	}
+}
+
+func TestInputsFile(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	if got, want := InputsFile(), filepath.Join(data, "cmdfuscator", "inputs.json"); got != want {
+		t.Errorf("InputsFile() = %q, want %q", got, want)
+	}
+}
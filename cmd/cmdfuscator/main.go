@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"cmdFuscator/cmd/cmdfuscator/cli"
 	"cmdFuscator/cmd/cmdfuscator/tui"
 	"cmdFuscator/data"
 
@@ -11,12 +12,17 @@ import (
 )
 
 func main() {
+	// With arguments, run a non-interactive subcommand instead of the TUI.
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(data.ModelFS, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	model := tui.New(data.ModelFS)
 
 	p := tea.NewProgram(
 		model,
 		tea.WithAltScreen(),       // full-screen TUI
-		tea.WithMouseCellMotion(), // enable mouse scrolling in viewport
+		tea.WithMouseCellMotion(), // clicks, and wheel scrolling in the output viewport
 	)
 
 	if _, err := p.Run(); err != nil {
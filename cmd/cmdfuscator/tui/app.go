@@ -27,16 +27,23 @@
 package tui
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/fs"
-	"os/exec"
-	"runtime"
+	"math/rand"
+	"os"
 	"sort"
 	"strings"
-	"unicode"
+	"time"
 
+	"cmdFuscator/cmd/cmdfuscator/config"
+	"cmdFuscator/detect"
 	"cmdFuscator/engine"
+	"cmdFuscator/export"
 	"cmdFuscator/loader"
+	"cmdFuscator/loader/remote"
+	"cmdFuscator/lolbas"
 	"cmdFuscator/models"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -55,6 +62,7 @@ const (
 	panelInput
 	panelOptions
 	panelOutput
+	panelHistory
 	panelCount
 )
 
@@ -97,35 +105,123 @@ type Model struct {
 	osFilter    osFilter
 	searchInput textinput.Model
 	searching   bool
-	allExes     []*models.ProfileFile // all loaded profiles
-	filtered    []*models.ProfileFile // after OS filter + search
-	exeCursor   int
-	exeOffset   int // scroll offset for sidebar list
-
-	// command input
-	cmdInput textinput.Model
+	allExes     []*models.ProfileFile         // all loaded profiles
+	filtered    []*models.ProfileFile         // after OS filter + search
+	rows        []sidebarRow                  // filtered as drawn; see buildRows
+	collapsed   map[string]bool               // platforms whose groups are folded
+	exeCursor   int                           // row of rows
+	exeOffset   int                           // scroll offset for sidebar list
+	matches     map[*models.ProfileFile][]int // name runes the search matched
+	pins        map[string]bool               // lower-cased names pinned to the top
+	pinsFile    string                        // where pins are saved, "" for nowhere
+	flashName   string                        // the executable a paste selected, for a while
+	flashGen    int
+
+	// command input; recalls holds the commands applied to each executable,
+	// by lower-cased name, and recallBack how many of them back the input
+	// shows, 0 for recallDraft, what was typed
+	cmdInput    textinput.Model
+	recalls     map[string][]string
+	recallsFile string
+	recallBack  int
+	recallDraft string
 
 	// selected profile
 	selected *models.ProfileFile
 
 	// options panel – modifier toggles
-	modifiers []engine.ModifierInfo
-	modCursor int
+	modifiers  []engine.ModifierInfo
+	modCursor  int
+	freezeURLs bool // keep URL tokens out of every modifier's reach
+
+	// previews holds, by modifier name, the current command with only that
+	// modifier applied, and previewBase the command with none.
+	previews    map[string]modifierPreview
+	previewBase string
+
+	// editor is the open modifier config form; nil when closed.
+	editor *configEditor
+	// edits holds the configs saved from the editor this session, by profile
+	// name and then modifier name, laid over the profile's.
+	edits map[string]map[string]json.RawMessage
 
 	// output
-	output     string
-	rawOutput  string
-	outputView viewport.Model
-	copyMsg    string
+	variants     *carousel // variants of the command last applied; nil before
+	output       string
+	outputTarget engine.RenderTarget // the shell output was rendered for
+	rawOutput    string
+	warnings     []string        // why the output may not run; see resultWarnings
+	detection    *detectionCheck // the output against detectors; nil without them
+	outputView   viewport.Model
+	copyMsg      string
+	inspecting   bool // the output panel shows the token table
+	inspectRow   int  // the token table's cursor
+	splitting    bool // the output panel shows the original and the output side by side
+	escaped      bool // the output panel shows the output escaped; see escapeRune
+
+	// frozen holds the tokens of frozenInput frozen from the token
+	// inspector, by where they were read from
+	frozen      map[tokenSpan]bool
+	frozenInput string
+
+	// live mode applies on every change, liveGen changes later; liveDue
+	// asks the Update making one to schedule the apply.
+	live    bool
+	liveGen int
+	liveDue bool
+
+	// history, oldest first; historyFile is where it is saved, "" for none
+	history     []historyEntry
+	histCursor  int // row of the panel, which lists the newest first
+	histOffset  int
+	historyFile string
+	historyErr  error // why the last entry was not saved, until shown
 
 	// engine
 	eng *engine.Engine
+	cfg *config.Config // settings file; the zero Config when there is none
+	// seeds draws the fresh seeds of carousels, batches and rerolls; see
+	// sessionSeeds.
+	seeds *rand.Rand
 
 	// status / error
 	statusMsg string
 	lastErr   error
+
+	// catalog decodes the lazily loaded profiles in allExes on selection.
+	catalog *loader.Catalog
+
+	// reloads delivers profile sets re-read after the overlay directory
+	// changes; nil when the directory is not being watched.
+	reloads <-chan loader.Reload
+	watcher *loader.Watcher
+
+	// base is the profile set the overlays are laid over, the built-in one
+	// or, with the settings file's profiles = "remote", the upstream one;
+	// opened are the directories added with the open prompt.
+	base      fs.FS
+	verifier  *loader.Verifier // what overlays must pass; nil for none
+	opened    []string
+	opening   bool // the open prompt has the keyboard
+	openInput textinput.Model
+
+	// lolbas holds the LOLBAS metadata shown for the selected executable;
+	// nil until a remote catalog has been fetched, or when it failed.
+	lolbas *lolbas.Catalog
+
+	// detectors are the settings file's Sigma rules and signatures.
+	detectors []detect.Detector
+
+	// batch is the batch screen, kept once opened so it reopens as it was
+	// left; batching, that it is shown and has the keyboard.
+	batch    *batchScreen
+	batching bool
 }
 
+// remoteProfilesTimeout bounds refreshing the upstream profiles at startup,
+// which the TUI waits for; past it the stale cache or built-in set is used.
+const remoteProfilesTimeout = 10 * time.Second
+
 // New creates a Model and loads profiles from the provided fs.FS.
 // Pass the embedded model FS from main.go.
 func New(modelFS fs.FS) Model {
@@ -148,42 +244,193 @@ func New(modelFS fs.FS) Model {
 		cmdInput:    ci,
 		searchInput: si,
 		outputView:  ov,
-		eng:         engine.New(),
 		focused:     panelSidebar,
 	}
 
+	// The settings file supplies default modifiers, probabilities, overlay
+	// directories, render target and seed. A broken one is reported and
+	// ignored rather than keeping the TUI from starting.
+	var status string
+	cfg, err := config.Load()
+	if err != nil {
+		status = fmt.Sprintf("config error, using defaults: %v", err)
+		cfg = &config.Config{}
+	}
+	m.cfg = cfg
+	m.seeds = sessionSeeds(cfg)
+	setTheme(themeFor(cfg))
+	if err := keys.remap(cfg.Keys); err != nil && status == "" {
+		status = fmt.Sprintf("keys: %v; using the default keys", err)
+	}
+	m.eng = engine.New(m.engineOptions()...)
+
+	// With history on, earlier sessions' entries are listed too.
+	if cfg.History {
+		m.historyFile = config.HistoryFile()
+		if m.history, err = loadHistory(m.historyFile); err != nil && status == "" {
+			status = fmt.Sprintf("history unavailable: %v", err)
+		}
+	}
+
+	// Pinned executables and applied commands are kept across sessions
+	// whatever History says.
+	m.pinsFile = config.PinsFile()
+	if m.pins, err = loadPins(m.pinsFile); err != nil && status == "" {
+		status = fmt.Sprintf("pins unavailable: %v", err)
+	}
+	m.recallsFile = config.InputsFile()
+	if m.recalls, err = loadRecalls(m.recallsFile); err != nil && status == "" {
+		status = fmt.Sprintf("input recall unavailable: %v", err)
+	}
+
+	// The settings file's Sigma rules and signatures check every variant.
+	if m.detectors, err = loadDetectors(cfg); err != nil && status == "" {
+		status = fmt.Sprintf("detection off: %v", err)
+	}
+
+	// A local LOLBAS catalog is read now; a remote one is fetched by Init.
+	if !remoteLOLBAS(cfg.LOLBAS) {
+		if m.lolbas, err = lolbas.Open(context.Background(), cfg.LOLBAS); err != nil && status == "" {
+			status = fmt.Sprintf("LOLBAS metadata unavailable: %v", err)
+		}
+	}
+
 	// Load profiles from the embedded FS (sub-dir is "models" within the FS)
 	sub, err := fs.Sub(modelFS, "models")
 	if err != nil {
 		m.statusMsg = fmt.Sprintf("load error: %v", err)
 		return m
 	}
+	m.base = sub
+
+	// The upstream set, when configured, replaces the embedded one. It is
+	// only fetched when the cache is a day old; the embedded set stays the
+	// fallback.
+	if cfg.Profiles == "remote" {
+		ctx, cancel := context.WithTimeout(context.Background(), remoteProfilesTimeout)
+		base, rep, err := remote.New(remote.DefaultCacheDir(), remote.WithFallback(sub)).FS(ctx)
+		cancel()
+		if err == nil {
+			m.base = base
+		}
+		switch {
+		case status != "":
+		case err != nil:
+			status = fmt.Sprintf("upstream profiles unavailable, using built-in ones: %v", err)
+		case rep.Source == remote.SourceFallback:
+			status = fmt.Sprintf("upstream profiles unavailable, using built-in ones: %v", rep.SyncErr)
+		case rep.SyncErr != nil:
+			status = fmt.Sprintf("using the profiles cached %s: %v", rep.Fetched.Format(time.DateOnly), rep.SyncErr)
+		}
+	}
 
-	profiles, err := loader.LoadFS(sub)
+	// User profiles in the overlay directories replace or extend the base
+	// set. Only headers are read here; a profile is decoded when first selected.
+	// With a manifest or public key configured they must pass it first.
+	m.verifier, _ = cfg.Verifier() // checked by config.Load
+	userDirs := m.cfg.Dirs()
+	cat, rep, err := loader.LoadLazyWithOverlayVerified(m.base, m.verifier, userDirs...)
+	if err != nil {
+		if status == "" {
+			status = fmt.Sprintf("overlay error, using base profiles only: %v", err)
+		}
+		cat, rep, err = loader.LoadLazy(m.base)
+	}
 	if err != nil {
 		m.statusMsg = fmt.Sprintf("load error: %v", err)
 		return m
 	}
+	m.catalog = cat
 
-	// Sort alphabetically for a stable list
-	sort.Slice(profiles, func(i, j int) bool {
-		return strings.ToLower(profiles[i].Name) < strings.ToLower(profiles[j].Name)
-	})
-
+	profiles := cat.Profiles()
+	sortProfiles(profiles)
 	m.allExes = profiles
 	m.applyFilter()
+	m.selectFirst()
 
-	if len(m.filtered) > 0 {
-		m.selectExe(0)
+	// Tell the user which files were rejected rather than just showing fewer
+	// executables. Set after selectExe, which clears the status line.
+	if status == "" {
+		status = rep.Summary()
+	}
+	if m.statusMsg == "" {
+		m.statusMsg = status
 	}
 
+	// Watch the overlay directories so profile edits show up without a
+	// restart.
+	m.watch(userDirs)
+
 	return m
 }
 
+// existingDirs returns the entries of dirs that are existing directories.
+func existingDirs(dirs []string) []string {
+	var out []string
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			out = append(out, dir)
+		}
+	}
+	return out
+}
+
+// sortProfiles sorts alphabetically for a stable list.
+func sortProfiles(profiles []*models.ProfileFile) {
+	sort.Slice(profiles, func(i, j int) bool {
+		return strings.ToLower(profiles[i].Name) < strings.ToLower(profiles[j].Name)
+	})
+}
+
 // ─── Bubbletea interface ──────────────────────────────────────────────────────
 
 func (m Model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, waitReload(m.reloads), fetchLOLBAS(m.cfg))
+}
+
+// lolbasMsg carries the outcome of fetching a remote LOLBAS catalog.
+type lolbasMsg struct {
+	catalog *lolbas.Catalog
+	err     error
+}
+
+// remoteLOLBAS reports whether source, as lolbas.Open takes it, is fetched
+// over the network.
+func remoteLOLBAS(source string) bool {
+	return source == "remote" || strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// fetchLOLBAS fetches the configured LOLBAS catalog when it is remote, so a
+// slow network does not hold up startup.
+func fetchLOLBAS(cfg *config.Config) tea.Cmd {
+	if cfg == nil || !remoteLOLBAS(cfg.LOLBAS) {
+		return nil
+	}
+	return func() tea.Msg {
+		c, err := lolbas.Open(context.Background(), cfg.LOLBAS)
+		return lolbasMsg{c, err}
+	}
+}
+
+// reloadMsg carries a profile set re-read by the overlay watcher, and the
+// channel it came from.
+type reloadMsg struct {
+	loader.Reload
+	from <-chan loader.Reload
+}
+
+// waitReload blocks until the watcher delivers the next reload.
+func waitReload(ch <-chan loader.Reload) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		r, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return reloadMsg{r, ch}
+	}
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -196,7 +443,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		return m.handleKey(msg)
+		return withLive(m.handleKey(msg))
+
+	case tea.MouseMsg:
+		return withLive(m.handleMouse(msg))
+
+	case liveMsg:
+		m.applyLive(msg)
+		return m, nil
+
+	case flashMsg:
+		m.endFlash(msg)
+		return m, nil
+
+	case batchMsg:
+		return m, m.batchProgress(msg)
+
+	case reloadMsg:
+		if msg.from != m.reloads {
+			return m, nil // from a watcher replaced since
+		}
+		m.applyReload(msg.Reload)
+		return m, waitReload(m.reloads)
+
+	case lolbasMsg:
+		if msg.err != nil {
+			m.statusMsg = errorStyle.Render("LOLBAS metadata unavailable: " + msg.err.Error())
+		}
+		m.lolbas = msg.catalog
+		return m, nil
 	}
 
 	// Propagate to focused widget
@@ -208,10 +483,15 @@ func (m Model) View() string {
 		return "Loading…"
 	}
 
-	sidebar := m.viewSidebar()
-	main := m.viewMain()
-
-	body := lipgloss.JoinHorizontal(lipgloss.Top, sidebar, main)
+	var body string
+	switch {
+	case m.batching:
+		body = m.viewBatch()
+	case m.narrow():
+		body = lipgloss.JoinVertical(lipgloss.Left, m.viewSelector(), m.viewMain())
+	default:
+		body = lipgloss.JoinHorizontal(lipgloss.Top, m.viewSidebar(), m.viewMain())
+	}
 	statusBar := renderStatusBar(m.width)
 
 	return lipgloss.JoinVertical(lipgloss.Left,
@@ -228,11 +508,21 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if keyStr := msg.String(); keyStr == "ctrl+c" {
 		return m, tea.Quit
 	}
-	if !m.searching && msg.String() == "q" {
+	if !m.searching && m.editor == nil && !m.opening && !m.batching && key.Matches(msg, keys.Quit) {
 		return m, tea.Quit
 	}
 
-	// Searching mode captures all input for the search box
+	// The batch screen, the config editor, the open prompt and searching
+	// mode capture all input for themselves
+	if m.batching {
+		return m.handleBatchKey(msg)
+	}
+	if m.opening {
+		return m.handleOpenKey(msg)
+	}
+	if m.editor != nil {
+		return m.handleEditorKey(msg)
+	}
 	if m.searching {
 		return m.handleSearchKey(msg)
 	}
@@ -246,6 +536,12 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.focused = (m.focused + panelCount - 1) % panelCount
 		m.syncFocusToWidget()
 
+	case key.Matches(msg, keys.Pin) && m.focused == panelSidebar:
+		m.togglePin()
+
+	case key.Matches(msg, keys.Fold) && m.focused == panelSidebar:
+		m.toggleGroup()
+
 	case key.Matches(msg, keys.Search) && m.focused == panelSidebar:
 		m.searching = true
 		m.searchInput.Focus()
@@ -256,6 +552,18 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, keys.Right) && m.focused == panelSidebar:
 		m.setOSFilter((m.osFilter + 1) % osFilter(len(osLabels)))
 
+	case key.Matches(msg, keys.MoveUp) && m.focused == panelOptions:
+		m.moveModifier(-1)
+
+	case key.Matches(msg, keys.MoveDown) && m.focused == panelOptions:
+		m.moveModifier(1)
+
+	case key.Matches(msg, keys.RecallPrev) && m.focused == panelInput:
+		m.recall(1)
+
+	case key.Matches(msg, keys.RecallNext) && m.focused == panelInput:
+		m.recall(-1)
+
 	case key.Matches(msg, keys.Up):
 		m.handleUp()
 
@@ -265,26 +573,75 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, keys.Toggle) && m.focused == panelOptions:
 		m.toggleModifier()
 
+	case key.Matches(msg, keys.Freeze) && m.focused == panelOptions:
+		m.toggleFreezeURLs()
+
+	case key.Matches(msg, keys.FreezeToken) && m.focused == panelOutput && m.inspecting:
+		m.toggleFreezeToken()
+
+	case key.Matches(msg, keys.Edit) && m.focused == panelOptions:
+		m.openEditor()
+
+	case key.Matches(msg, keys.Apply) && m.focused == panelHistory:
+		m.reapplyHistory()
+
 	case key.Matches(msg, keys.Apply):
 		m.applyObfuscation()
 
+	case key.Matches(msg, keys.NextVariant) && m.focused != panelInput:
+		m.nextVariant()
+
+	case key.Matches(msg, keys.PrevVariant) && m.focused != panelInput:
+		m.prevVariant()
+
+	case key.Matches(msg, keys.Reroll) && m.focused != panelInput:
+		m.reroll()
+
+	case key.Matches(msg, keys.Replay) && m.focused != panelInput:
+		m.replay()
+
+	case key.Matches(msg, keys.Inspect) && m.focused != panelInput:
+		m.toggleInspector()
+
+	case key.Matches(msg, keys.Escaped) && m.focused != panelInput:
+		m.toggleEscaped()
+
+	case key.Matches(msg, keys.Split) && m.focused != panelInput:
+		m.toggleSplit()
+
+	case key.Matches(msg, keys.Open) && m.focused != panelInput:
+		m.openPrompt()
+
+	case key.Matches(msg, keys.Live) && m.focused != panelInput:
+		m.toggleLive()
+
+	case key.Matches(msg, keys.Batch) && m.focused != panelInput:
+		return m, m.openBatch()
+
+	case key.Matches(msg, keys.Copy) && m.focused == panelHistory:
+		m.copyHistory()
+
 	case key.Matches(msg, keys.Copy):
 		m.copyOutput()
 
+	case key.Matches(msg, keys.Export):
+		m.exportOutput()
+
 	case key.Matches(msg, keys.Reset):
+		m.variants = nil
 		m.output = ""
 		m.rawOutput = ""
+		m.warnings = nil
 		m.outputView.SetContent("")
 		m.outputView.GotoTop()
 		m.copyMsg = ""
 		m.lastErr = nil
 		m.statusMsg = ""
+		m.recalcSizes()
 
 	default:
 		if m.focused == panelInput {
-			var cmd tea.Cmd
-			m.cmdInput, cmd = m.cmdInput.Update(msg)
-			return m, cmd
+			return m.updateCmdInput(msg)
 		}
 	}
 
@@ -292,16 +649,12 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "esc", "enter":
+	switch {
+	case key.Matches(msg, keys.Escape, keys.Apply):
 		m.searching = false
 		m.searchInput.Blur()
 		m.applyFilter()
-		if len(m.filtered) > 0 {
-			m.exeCursor = 0
-			m.exeOffset = 0
-			m.selectExe(0)
-		}
+		m.selectFirst()
 		return m, nil
 	default:
 		var cmd tea.Cmd
@@ -328,14 +681,23 @@ func (m *Model) handleUp() {
 			m.modCursor--
 		}
 	case panelOutput:
-		m.outputView.LineUp(1)
+		if m.inspecting {
+			m.moveInspectRow(-1)
+		} else {
+			m.outputView.LineUp(1)
+		}
+	case panelHistory:
+		if m.histCursor > 0 {
+			m.histCursor--
+			m.histOffset = min(m.histOffset, m.histCursor)
+		}
 	}
 }
 
 func (m *Model) handleDown() {
 	switch m.focused {
 	case panelSidebar:
-		if m.exeCursor < len(m.filtered)-1 {
+		if m.exeCursor < len(m.rows)-1 {
 			m.exeCursor++
 			visibleRows := m.sidebarListHeight()
 			if m.exeCursor >= m.exeOffset+visibleRows {
@@ -348,24 +710,70 @@ func (m *Model) handleDown() {
 			m.modCursor++
 		}
 	case panelOutput:
-		m.outputView.LineDown(1)
+		if m.inspecting {
+			m.moveInspectRow(1)
+		} else {
+			m.outputView.LineDown(1)
+		}
+	case panelHistory:
+		if m.histCursor < len(m.history)-1 {
+			m.histCursor++
+			if m.histCursor >= m.histOffset+historyRows {
+				m.histOffset++
+			}
+		}
 	}
 }
 
 func (m *Model) toggleModifier() {
 	if m.modCursor >= 0 && m.modCursor < len(m.modifiers) {
 		m.modifiers[m.modCursor].Enabled = !m.modifiers[m.modCursor].Enabled
+		m.changed()
 	}
 }
 
+// toggleFreezeURLs switches URL freezing on or off by swapping in an engine
+// built with or without engine.WithFrozenTypes.
+func (m *Model) toggleFreezeURLs() {
+	m.freezeURLs = !m.freezeURLs
+	m.eng = engine.New(m.engineOptions()...)
+	m.refreshPreviews()
+	m.changed()
+	if m.freezeURLs {
+		m.statusMsg = "URLs frozen"
+	} else {
+		m.statusMsg = "URLs unfrozen"
+	}
+}
+
+// engineOptions returns the options the engine is built with: the settings
+// file's, plus URL freezing when it is on and the selected profile's edited
+// configs. Tracing is always on for the output panel's highlighting, and
+// modifiers run in the options panel's order.
+func (m *Model) engineOptions() []engine.Option {
+	return m.engineOptionsFor(m.selected)
+}
+
+// engineOptionsFor is engineOptions with the edited configs of pf instead,
+// or none when pf is nil.
+func (m *Model) engineOptionsFor(pf *models.ProfileFile) []engine.Option {
+	opts := append(m.cfg.EngineOptions(), engine.WithTrace(true), engine.WithValidators(engine.QuoteBalance),
+		engine.WithOrder(m.modifierOrder()...))
+	if m.freezeURLs {
+		opts = append(opts, engine.WithFrozenTypes(models.TokenTypeURL))
+	}
+	if pf != nil && len(m.edits[pf.Name]) > 0 {
+		opts = append(opts, engine.WithConfigOverrides(m.edits[pf.Name]))
+	}
+	return opts
+}
+
 // escapeInvisible renders non-printing Unicode codepoints (excluding \n and \t)
 // as highlighted [U+XXXX] markers so they are visible in the raw pane.
 func escapeInvisible(s string) string {
 	var b strings.Builder
 	for _, r := range s {
-		if r == '\n' || r == '\t' {
-			b.WriteRune(r)
-		} else if !unicode.IsPrint(r) || unicode.Is(unicode.Cf, r) {
+		if invisible(r) {
 			b.WriteString(rawEscapeStyle.Render(fmt.Sprintf("[U+%04X]", r)))
 		} else {
 			b.WriteRune(r)
@@ -375,6 +783,19 @@ func escapeInvisible(s string) string {
 }
 
 func (m *Model) applyObfuscation() {
+	m.applyWithSeed(0)
+}
+
+// applyWithSeed applies the command with the first variant's run seed set to
+// seed, or with zero drawn the way `cmdfuscator obfuscate` seeds its first
+// run.
+func (m *Model) applyWithSeed(seed int64) {
+	m.run(seed, false)
+}
+
+// run is applyWithSeed for live mode too, whose variants are not recorded
+// in the history.
+func (m *Model) run(seed int64, live bool) {
 	if m.selected == nil {
 		m.statusMsg = "select an executable first"
 		return
@@ -391,20 +812,47 @@ func (m *Model) applyObfuscation() {
 		enabled[mod.Name] = mod.Enabled
 	}
 
-	result, err := m.eng.Obfuscate(cmd, m.selected, enabled)
+	c := m.newCarousel(cmd, enabled)
+	c.live = live
+	var err error
+	if seed == 0 {
+		err = m.generate(c)
+	} else {
+		err = m.generateSeed(c, seed)
+	}
 	if err != nil {
 		m.lastErr = err
 		m.statusMsg = "error: " + err.Error()
 		return
 	}
+	m.variants = c
+	m.showVariant(c.ring[0])
+	if !live {
+		m.rememberInput(cmd)
+	}
+}
 
+// showVariant puts v in the output panel and a summary of it, starting with
+// its run seed, in the status line.
+func (m *Model) showVariant(v variant) {
+	result := v.result
 	m.output = result.Output
+	m.outputTarget = result.Target
 	m.rawOutput = escapeInvisible(result.Output)
-	m.outputView.SetContent(result.Output)
+	var enabled map[string]bool
+	if m.variants != nil {
+		enabled = m.variants.enabled
+	}
+	m.warnings = m.resultWarnings(result, enabled)
+	if m.variants != nil {
+		m.detection = m.checkDetection(m.variants.command, result)
+	}
+	m.recalcSizes() // the warning strip and detection line take rows from the viewport
+	m.setOutputContent()
 	m.outputView.GotoTop()
 
 	// Build status summary
-	parts := []string{}
+	parts := []string{fmt.Sprintf("seed: %d", v.seed), fmt.Sprintf("score: %d", result.Score.Value)}
 	if len(result.Applied) > 0 {
 		parts = append(parts, "applied: "+strings.Join(result.Applied, ", "))
 	}
@@ -416,6 +864,10 @@ func (m *Model) applyObfuscation() {
 			parts = append(parts, errorStyle.Render(name+": "+e.Error()))
 		}
 	}
+	if m.historyErr != nil {
+		parts = append(parts, errorStyle.Render("history not saved: "+m.historyErr.Error()))
+		m.historyErr = nil
+	}
 	m.statusMsg = strings.Join(parts, "  |  ")
 	m.lastErr = nil
 }
@@ -425,50 +877,146 @@ func (m *Model) copyOutput() {
 		return
 	}
 
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("pbcopy")
-	case "linux":
-		cmd = exec.Command("xclip", "-selection", "clipboard")
-	default:
-		m.copyMsg = "(copy not supported on this OS)"
+	via, err := copyToClipboard(m.output)
+	if err != nil {
+		m.copyMsg = errorStyle.Render("copy failed: " + err.Error())
 		return
 	}
-
-	cmd.Stdin = strings.NewReader(m.output)
-	if err := cmd.Run(); err != nil {
-		m.copyMsg = errorStyle.Render("copy failed: " + err.Error())
+	if via == viaTerminal {
+		m.copyMsg = copyStyle.Render("SENT TO TERMINAL (OSC 52)")
 		return
 	}
 	m.copyMsg = copyStyle.Render("COPIED!")
 }
 
+// exportOutput writes the output to a script for the shell it was rendered
+// for, named after the executable, in the working directory.
+func (m *Model) exportOutput() {
+	if m.output == "" {
+		return
+	}
+	format, ok := export.FormatFor(m.outputTarget)
+	if !ok {
+		m.statusMsg = errorStyle.Render("export: no script format for target " + m.outputTarget.String())
+		return
+	}
+	name := "cmdfuscator-" + m.selected.Name + format.Ext()
+	perm := os.FileMode(0o644)
+	if format == export.FormatSh {
+		perm = 0o755
+	}
+	if err := os.WriteFile(name, export.Script(format, []string{m.output}), perm); err != nil {
+		m.statusMsg = errorStyle.Render("export failed: " + err.Error())
+		return
+	}
+	m.statusMsg = "exported to " + name
+}
+
 // ─── Profile selection ────────────────────────────────────────────────────────
 
+// selectExe selects the executable on sidebar row idx; a group header
+// selects nothing.
 func (m *Model) selectExe(idx int) {
-	if idx < 0 || idx >= len(m.filtered) {
+	if idx < 0 || idx >= len(m.rows) || m.rows[idx].pf == nil {
+		return
+	}
+	pf, err := m.fullProfile(m.rows[idx].pf)
+	if err != nil {
+		m.statusMsg = errorStyle.Render(err.Error())
 		return
 	}
-	m.selected = m.filtered[idx]
+	m.selected = pf
 
 	// Populate command input with the template from the first profile
+	m.recallBack = 0
 	if len(m.selected.Profiles) > 0 {
 		m.cmdInput.SetValue(buildTemplateCommand(m.selected.Profiles[0]))
 	}
 
-	// Reset modifiers to defaults for this profile
-	enabled := engine.DefaultEnabled(m.selected)
-	m.modifiers = engine.ModifierSummary(enabled)
+	// Edited configs are kept per profile
+	if len(m.edits) > 0 {
+		m.eng = engine.New(m.engineOptions()...)
+	}
+
+	// Reset modifiers to defaults for this profile, keeping their order
+	enabled := m.cfg.Enabled(m.selected)
+	m.modifiers = keepOrder(engine.ModifierSummary(enabled), m.modifiers)
 	m.modCursor = 0
+	m.refreshPreviews()
+	m.variants = nil
 	m.output = ""
 	m.rawOutput = ""
+	m.warnings = nil
 	m.outputView.SetContent("")
 	m.outputView.GotoTop()
 	m.copyMsg = ""
 	m.statusMsg = ""
 }
 
+// applyReload swaps in a reloaded profile set. The selected executable, the
+// typed command and modifier toggles survive when the executable still exists,
+// so a profile author sees the edited profile take effect on the same input.
+func (m *Model) applyReload(r loader.Reload) {
+	if r.Err != nil {
+		m.statusMsg = errorStyle.Render("reload failed, keeping current profiles: " + r.Err.Error())
+		return
+	}
+
+	sortProfiles(r.Profiles)
+	m.allExes = r.Profiles
+	m.applyFilter()
+
+	idx := -1
+	if m.selected != nil {
+		idx = m.findExe(m.selected.Name)
+	}
+	if idx >= 0 {
+		m.reselect(idx)
+	} else {
+		m.selected = nil
+		m.selectFirst()
+	}
+
+	m.statusMsg = "profiles reloaded"
+	if sum := r.Report.Summary(); sum != "" {
+		m.statusMsg += "  |  " + sum
+	}
+}
+
+// reselect points the selection at the reloaded copy of the current
+// executable, keeping the command input and carrying modifier toggles over
+// by name.
+func (m *Model) reselect(idx int) {
+	toggles := make(map[string]bool, len(m.modifiers))
+	for _, mod := range m.modifiers {
+		toggles[mod.Name] = mod.Enabled
+	}
+	m.exeCursor = idx
+	m.exeOffset = min(m.exeOffset, idx)
+	m.selected = m.rows[idx].pf
+	m.modifiers = keepOrder(engine.ModifierSummary(m.cfg.Enabled(m.selected)), m.modifiers)
+	for i, mod := range m.modifiers {
+		if on, ok := toggles[mod.Name]; ok {
+			m.modifiers[i].Enabled = on
+		}
+	}
+	m.modCursor = min(m.modCursor, max(len(m.modifiers)-1, 0))
+	m.refreshPreviews()
+
+	if m.output != "" {
+		m.applyObfuscation()
+	}
+}
+
+// fullProfile returns the fully decoded form of a sidebar entry, which is a
+// header-only stub until first selected.
+func (m *Model) fullProfile(pf *models.ProfileFile) (*models.ProfileFile, error) {
+	if m.catalog == nil {
+		return pf, nil
+	}
+	return m.catalog.Full(pf)
+}
+
 // buildTemplateCommand converts the profile's command template into a string.
 func buildTemplateCommand(p models.Profile) string {
 	parts := make([]string, 0, len(p.Parameters.Command))
@@ -480,11 +1028,16 @@ func buildTemplateCommand(p models.Profile) string {
 
 // ─── Filtering ────────────────────────────────────────────────────────────────
 
+// applyFilter narrows allExes to the OS filter and the search query. With a
+// query, executables whose name or an alias fuzzy-matches it are kept, best
+// match first.
 func (m *Model) applyFilter() {
-	query := strings.ToLower(strings.TrimSpace(m.searchInput.Value()))
+	query := strings.TrimSpace(m.searchInput.Value())
 	platform := osPlatforms[m.osFilter] // empty string for osAll
 
 	var out []*models.ProfileFile
+	scores := make(map[*models.ProfileFile]int)
+	m.matches = make(map[*models.ProfileFile][]int)
 	for _, pf := range m.allExes {
 		// Platform filter
 		if platform != "" {
@@ -499,27 +1052,52 @@ func (m *Model) applyFilter() {
 				continue
 			}
 		}
-		// Search filter
-		if query != "" && !strings.Contains(strings.ToLower(pf.Name), query) {
-			continue
+		// Search filter (name or any alias)
+		if query != "" {
+			score, positions, ok := matchProfile(pf, query)
+			if !ok {
+				continue
+			}
+			scores[pf], m.matches[pf] = score, positions
 		}
 		out = append(out, pf)
 	}
+	// Pinned executables come first; equal scores keep sortProfiles' order.
+	sort.SliceStable(out, func(i, j int) bool {
+		if pi, pj := m.pinned(out[i]), m.pinned(out[j]); pi != pj {
+			return pi
+		}
+		return scores[out[i]] > scores[out[j]]
+	})
 	m.filtered = out
+	m.buildRows()
 }
 
 func (m *Model) setOSFilter(f osFilter) {
 	m.osFilter = f
 	m.applyFilter()
-	m.exeCursor = 0
-	m.exeOffset = 0
-	if len(m.filtered) > 0 {
-		m.selectExe(0)
-	}
+	m.selectFirst()
 }
 
 // ─── Widget sync ──────────────────────────────────────────────────────────────
 
+// updateCmdInput passes msg to the command input, refreshing the modifier
+// previews when it changes the command.
+func (m Model) updateCmdInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	prev := m.cmdInput.Value()
+	var cmd tea.Cmd
+	m.cmdInput, cmd = m.cmdInput.Update(msg)
+	if m.cmdInput.Value() != prev {
+		m.recallBack = 0
+		if k, ok := msg.(tea.KeyMsg); ok && k.Paste {
+			cmd = tea.Batch(cmd, m.detectPasted())
+		}
+		m.refreshPreviews()
+		m.changed()
+	}
+	return m, cmd
+}
+
 func (m *Model) syncFocusToWidget() {
 	if m.focused == panelInput {
 		m.cmdInput.Focus()
@@ -531,9 +1109,7 @@ func (m *Model) syncFocusToWidget() {
 func (m *Model) updateFocusedWidget(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch m.focused {
 	case panelInput:
-		var cmd tea.Cmd
-		m.cmdInput, cmd = m.cmdInput.Update(msg)
-		return m, cmd
+		return m.updateCmdInput(msg)
 	case panelOutput:
 		var cmd tea.Cmd
 		m.outputView, cmd = m.outputView.Update(msg)
@@ -569,7 +1145,7 @@ func (m *Model) bodyHeight() int {
 
 // mainWidth is the pixel-column width of the right-hand main area.
 func (m *Model) mainWidth() int {
-	w := m.width - sidebarWidth
+	w := m.width - m.sidebarCols()
 	if w < 20 {
 		return 20
 	}
@@ -582,8 +1158,12 @@ func (m *Model) panelContentWidth() int {
 	return m.mainWidth() - panelBorderH
 }
 
-// optModifierRows returns how many two-column rows the modifier grid occupies.
+// optModifierRows returns how many two-column rows the modifier grid occupies,
+// or how many lines the config editor does while it is open.
 func (m *Model) optModifierRows() int {
+	if m.editor != nil {
+		return m.editor.rows()
+	}
 	rows := (len(m.modifiers) + 1) / 2
 	if rows < 1 {
 		return 1
@@ -592,22 +1172,29 @@ func (m *Model) optModifierRows() int {
 }
 
 // outputViewHeight calculates the viewport height so the three panels plus
-// gaps and status bar fill bodyHeight exactly.
+// gaps and status bar fill bodyHeight exactly, less the selector line of a
+// narrow terminal.
 //
 // Accounting (lines):
-//   cmdBox  = sectionLabel(1) + input(1) + panelBorderV(2)              = 4
-//   gap                                                                   = 1
-//   optBox  = sectionLabel(1) + optRows  + panelBorderV(2)
-//   gap                                                                   = 1
-//   outBox  = sectionLabel(1) + viewH + divider(1) + rawLabel(1)
-//             + rawFixedH(3) + panelBorderV(2)                           = 8 + viewH
-//   gap                                                                   = 1
-//   status                                                                = 1
 //
-// Total fixed = 4+1+(1+optRows+2)+1+(1+1+1+rawFixedH+2)+1+1 = 19 + optRows
+//	cmdBox  = sectionLabel(1) + input(1) + panelBorderV(2)              = 4
+//	gap                                                                   = 1
+//	optBox  = sectionLabel(1) + optRows  + panelBorderV(2)
+//	gap                                                                   = 1
+//	outBox  = sectionLabel(1) + warnings + viewH + divider(1) + rawLabel(1)
+//	          + rawFixedH(3) + detection + panelBorderV(2)
+//	                                            = 8 + warnings + detection + viewH
+//	gap                                                                   = 1
+//	histBox = sectionLabel(1) + historyRows(4) + panelBorderV(2)         = 7
+//	gap                                                                   = 1
+//	status                                                                = 1
+//
+//	Total fixed = 4+1+(1+optRows+2)+1+(1+1+1+rawFixedH+2)+1+7+1+1
+//	            = 27 + optRows + warnings + detection
 func (m *Model) outputViewHeight() int {
-	fixed := 4 + 1 + (1+m.optModifierRows()+panelBorderV) + 1 + (1+1+1+rawFixedH+panelBorderV) + 1 + 1
-	h := m.bodyHeight() - fixed
+	fixed := 4 + 1 + (1 + m.optModifierRows() + panelBorderV) + 1 + (1 + m.warningRows() + 1 + 1 + rawFixedH + m.detectionRows() + panelBorderV) + 1 +
+		(1 + historyRows + panelBorderV) + 1 + 1
+	h := m.bodyHeight() - m.selectorRows() - fixed
 	if h < 2 {
 		return 2
 	}
@@ -631,9 +1218,18 @@ func (m *Model) recalcSizes() {
 	if pw < 4 {
 		pw = 4
 	}
-	m.cmdInput.Width = pw - 2
+	// The prompt and the cursor, a column past the text, take room from
+	// the text, and setting the cursor again scrolls it to the new width.
+	m.cmdInput.Width = pw - 2 - lipgloss.Width(m.cmdInput.Prompt) - 1
+	m.cmdInput.SetCursor(m.cmdInput.Position())
 	m.outputView.Width = pw - 2
 	m.outputView.Height = m.outputViewHeight()
+	if m.inspecting || m.splitting {
+		m.setOutputContent() // the table and columns are laid out for the width
+	}
+	if m.batch != nil {
+		m.resizeBatch()
+	}
 }
 
 // ─── Views ────────────────────────────────────────────────────────────────────
@@ -641,7 +1237,7 @@ func (m *Model) recalcSizes() {
 func (m Model) viewHeader() string {
 	title := titleStyle.Render("cmdFuscator")
 	sub := subtitleStyle.Render(" TUI port of ArgFuscator.net  •  security research tool")
-	return title + sub
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(title + sub)
 }
 
 func (m Model) viewSidebar() string {
@@ -665,20 +1261,33 @@ func (m Model) viewSidebar() string {
 	listH := m.sidebarListHeight()
 	listLines := make([]string, 0, listH)
 	end := m.exeOffset + listH
-	if end > len(m.filtered) {
-		end = len(m.filtered)
+	if end > len(m.rows) {
+		end = len(m.rows)
 	}
 	maxNameLen := sidebarWidth - panelBorderH - 2 // 2 for "> " or "  " prefix
 	for i := m.exeOffset; i < end; i++ {
-		name := m.filtered[i].Name
-		if len(name) > maxNameLen {
-			name = name[:maxNameLen-1] + "…"
+		pf := m.rows[i].pf
+		if pf == nil {
+			listLines = append(listLines, m.viewGroupHeader(m.rows[i], i == m.exeCursor))
+			continue
 		}
+		name, mark := pf.Name, ""
+		nameLen := maxNameLen
+		if m.pinned(pf) {
+			mark = pinStyle.Render(pinMark)
+			nameLen -= len(pinMark)
+		}
+		if len(name) > nameLen {
+			name = name[:nameLen-1] + "…"
+		}
+		style, prefix := normalStyle, "  "
 		if i == m.exeCursor {
-			listLines = append(listLines, selectedStyle.Render("> "+name))
-		} else {
-			listLines = append(listLines, normalStyle.Render("  "+name))
+			style, prefix = selectedStyle, "> "
 		}
+		if strings.EqualFold(pf.Name, m.flashName) {
+			style = flashStyle
+		}
+		listLines = append(listLines, style.Render(prefix)+highlightMatch(name, m.matches[pf], style)+mark)
 	}
 	if len(m.filtered) == 0 {
 		listLines = append(listLines, dimStyle.Render("  (no results)"))
@@ -718,12 +1327,19 @@ func (m Model) viewMain() string {
 
 	// ── Modifier options ──────────────────────────────────────────────────
 	optFocused := m.focused == panelOptions
-	optHeader := lipgloss.NewStyle().MaxWidth(pw).Render(
-		sectionStyle.Render("Modifiers") + "  " + dimStyle.Render("[Enter] Apply  [r] Reset"),
+	optHeader := lipgloss.NewStyle().MaxWidth(pw - 2).Render(
+		sectionStyle.Render("Modifiers") + "  " + dimStyle.Render("[Enter] Apply  [e] Edit  [r] Reset") + liveLabel(m.live),
 	)
+	optBody := m.renderModifierGrid(pw - 2)
+	if m.editor != nil {
+		optHeader = lipgloss.NewStyle().MaxWidth(pw - 2).Render(
+			sectionStyle.Render("Edit "+m.editor.modifier) + "  " + dimStyle.Render("[Enter] Save  [Esc] Cancel  [^R] Profile default"),
+		)
+		optBody = m.editor.view(pw)
+	}
 	optInner := lipgloss.JoinVertical(lipgloss.Left,
 		optHeader,
-		renderModifierGrid(m.modifiers, m.modCursor, pw),
+		optBody,
 	)
 	optBox := panelStyle(optFocused).Width(pw).Render(optInner)
 
@@ -731,33 +1347,81 @@ func (m Model) viewMain() string {
 	outFocused := m.focused == panelOutput
 	var outViewStr string
 	if m.output == "" {
-		outViewStr = dimStyle.Render("(press Enter to apply obfuscation)")
+		// Padded to the viewport's height, so the panels below stay where
+		// panelAt expects them.
+		outViewStr = lipgloss.NewStyle().Height(m.outputView.Height).Render(
+			dimStyle.Render("(press Enter to apply obfuscation)"))
 	} else {
 		outViewStr = m.outputView.View()
 	}
-	divider := dimStyle.Render(strings.Repeat("─", pw))
+	divider := dimStyle.Render(strings.Repeat("─", pw-2))
 	rawLabel := dimStyle.Render("raw")
 	var rawStr string
 	if m.rawOutput == "" {
 		rawStr = dimStyle.Render("(no output)")
 	} else {
-		rawStr = lipgloss.NewStyle().Width(pw).MaxHeight(rawFixedH).Render(m.rawOutput)
-	}
-	outInner := lipgloss.JoinVertical(lipgloss.Left,
-		sectionStyle.Render("Output")+"  "+m.copyMsg,
-		outViewStr,
-		divider,
-		rawLabel,
-		rawStr,
-	)
+		rawStr = lipgloss.NewStyle().Width(pw - 2).MaxHeight(rawFixedH).Render(m.rawOutput)
+	}
+	rawStr = lipgloss.NewStyle().Height(rawFixedH).Render(rawStr)
+	outHeader := sectionStyle.Render("Output") + "  "
+	if m.variants != nil && m.output != "" {
+		outHeader += dimStyle.Render(m.variants.label()+"  [n/p]") + "  "
+	}
+	switch {
+	case m.inspecting:
+		outHeader += dimStyle.Render("tokens  [t]") + "  "
+	case m.splitting && m.escaped:
+		outHeader += dimStyle.Render("split  [s]  •  escaped  [x]") + "  "
+	case m.splitting:
+		outHeader += dimStyle.Render("split  [s]") + "  "
+	case m.escaped:
+		outHeader += dimStyle.Render("escaped  [x]") + "  "
+	}
+	outRows := []string{lipgloss.NewStyle().MaxWidth(pw - 2).Render(outHeader + m.copyMsg)}
+	if m.warningRows() > 0 {
+		outRows = append(outRows, m.viewWarnings(pw-2))
+	}
+	outRows = append(outRows, outViewStr, divider, rawLabel, rawStr)
+	if m.detectionRows() > 0 {
+		outRows = append(outRows, m.viewDetection(pw-2))
+	}
+	outInner := lipgloss.JoinVertical(lipgloss.Left, outRows...)
 	outBox := panelStyle(outFocused).Width(pw).Render(outInner)
 
+	// ── History ───────────────────────────────────────────────────────────
+	histInner := lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.NewStyle().MaxWidth(pw-2).Render(
+			sectionStyle.Render("History")+"  "+dimStyle.Render("[Enter] Re-apply  [c] Copy"),
+		),
+		m.viewHistory(pw),
+	)
+	histBox := panelStyle(m.focused == panelHistory).Width(pw).Render(histInner)
+
 	// ── Status message ────────────────────────────────────────────────────
 	statusStr := ""
-	if m.statusMsg != "" {
+	if m.opening {
+		statusStr = m.openInput.View()
+	} else if m.statusMsg != "" {
 		statusStr = m.statusMsg
 	} else if m.selected != nil {
-		statusStr = dimStyle.Render(fmt.Sprintf("%s  •  %d profile(s)", m.selected.Name, len(m.selected.Profiles)))
+		detail := fmt.Sprintf("%s  •  %d profile(s)", m.selected.Name, len(m.selected.Profiles))
+		if ids := m.selected.Techniques(); len(ids) > 0 {
+			detail += "  •  ATT&CK " + strings.Join(ids, ", ")
+		}
+		// LOLBAS goes before the description, which the status line
+		// truncates first.
+		if m.lolbas != nil {
+			if e, ok := m.lolbas.ForProfile(m.selected); ok {
+				detail += "  •  LOLBAS " + strings.Join(e.Functions(), ", ")
+				if ids := e.Techniques(); len(ids) > 0 {
+					detail += " (" + strings.Join(ids, ", ") + ")"
+				}
+			}
+		}
+		if desc := m.selected.Description(); desc != "" {
+			detail += "  •  " + desc
+		}
+		statusStr = dimStyle.Render(detail)
 	}
 	status := lipgloss.NewStyle().MaxWidth(mw).Render(statusStr)
 
@@ -768,14 +1432,18 @@ func (m Model) viewMain() string {
 		"",
 		outBox,
 		"",
+		histBox,
+		"",
 		status,
 	)
 
 	return lipgloss.NewStyle().Width(mw).Render(mainContent)
 }
 
-// renderModifierGrid lays out modifier checkboxes in two columns.
-func renderModifierGrid(mods []engine.ModifierInfo, cursor, width int) string {
+// renderModifierGrid lays out modifier checkboxes in two columns, marking
+// those with an edited config and following each with its preview.
+func (m Model) renderModifierGrid(width int) string {
+	mods, cursor, edited := m.modifiers, m.modCursor, m.edits[m.selectedName()]
 	if len(mods) == 0 {
 		return dimStyle.Render("(no modifiers for this profile)")
 	}
@@ -784,10 +1452,10 @@ func renderModifierGrid(mods []engine.ModifierInfo, cursor, width int) string {
 	var lines []string
 
 	for i := 0; i < len(mods); i += 2 {
-		left := renderModifierItem(mods[i], i == cursor, colW)
+		left := m.renderModifierItem(mods[i], i == cursor, edited[mods[i].Name] != nil, colW)
 		right := ""
 		if i+1 < len(mods) {
-			right = renderModifierItem(mods[i+1], i+1 == cursor, colW)
+			right = m.renderModifierItem(mods[i+1], i+1 == cursor, edited[mods[i+1].Name] != nil, colW)
 		}
 		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, left, right))
 	}
@@ -795,18 +1463,27 @@ func renderModifierGrid(mods []engine.ModifierInfo, cursor, width int) string {
 	return strings.Join(lines, "\n")
 }
 
-func renderModifierItem(info engine.ModifierInfo, selected bool, width int) string {
+func (m Model) renderModifierItem(info engine.ModifierInfo, selected, edited bool, width int) string {
 	checkbox := uncheckedStyle.Render("[ ]")
 	label := dimStyle.Render(info.Name)
 	if info.Enabled {
 		checkbox = checkedStyle.Render("[✓]")
 		label = normalStyle.Render(info.Name)
 	}
+	if edited {
+		label += copyStyle.Render("*")
+	}
 	item := checkbox + " " + label
 	if selected {
 		item = selectedStyle.Render("> ") + item
 	} else {
 		item = "  " + item
 	}
-	return lipgloss.NewStyle().Width(width).Render(item)
+	// One column is left free before the next item.
+	avail := width - lipgloss.Width(item) - 3
+	if preview := m.previewText(info.Name, avail); preview != "" && lipgloss.Width(preview) <= avail {
+		item += "  " + preview
+	}
+	// Cut rather than wrapped when a narrow terminal leaves too little room.
+	return lipgloss.NewStyle().Width(width).Render(lipgloss.NewStyle().MaxWidth(width - 1).Render(item))
 }
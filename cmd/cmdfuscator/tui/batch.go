@@ -0,0 +1,423 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+
+	"cmdFuscator/detect"
+	"cmdFuscator/engine"
+	"cmdFuscator/export"
+	"cmdFuscator/models"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Batch mode ───────────────────────────────────────────────────────────────
+
+const (
+	maxBatchLines = 1000                // commands the list holds
+	batchChunk    = 8                   // commands run between progress updates
+	batchBarWidth = 24                  // columns of the progress bar
+	batchName     = "cmdfuscator-batch" // the exported files, before their extension
+)
+
+// batchRow is one command of a batch and, once run, its variant.
+type batchRow struct {
+	line    int // in the command list, from 1
+	command string
+	profile *models.ProfileFile
+	seed    int64
+	result  engine.ObfuscateResult
+	err     error    // no profile for the command, or the run failed
+	hits    []string // the detectors the variant fires; nil without detectors
+}
+
+// batchScreen replaces the sidebar and panels while a list of commands is
+// obfuscated with the options panel's modifiers, each command on the
+// profile of the executable it starts with.
+type batchScreen struct {
+	input   textarea.Model // the commands, one per line
+	results viewport.Model
+	rows    []batchRow
+	enabled map[string]bool // the modifiers of the last run
+	done    int             // rows run so far
+
+	// The running batch, on an engine of its own; cancel is nil when none is.
+	ctx    context.Context
+	cancel context.CancelFunc
+	eng    *engine.Engine
+	gen    int // changes with every run, so a stopped one's results are dropped
+
+	loading bool // the load prompt has the keyboard
+	path    textinput.Model
+	status  string
+}
+
+// batchMsg carries the results of the rows idx of run gen, and how many rows
+// the batch has run with them.
+type batchMsg struct {
+	gen     int
+	idx     []int
+	results []engine.BatchResult
+	done    int
+}
+
+// openBatch shows the batch screen, as it was left when it was last open.
+func (m *Model) openBatch() tea.Cmd {
+	if m.batch == nil {
+		in := textarea.New()
+		in.Placeholder = "paste commands here, one per line, or load a file with ^O"
+		in.CharLimit = 0
+		in.MaxHeight = maxBatchLines
+		m.batch = &batchScreen{input: in, results: viewport.New(0, 0)}
+	}
+	m.batching = true
+	m.recalcSizes()
+	return m.batch.input.Focus()
+}
+
+func (m Model) handleBatchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := m.batch
+	if b.loading {
+		switch {
+		case key.Matches(msg, keys.Escape):
+			b.loading = false
+		case key.Matches(msg, keys.Apply):
+			b.loading = false
+			m.loadBatch(b.path.Value())
+		default:
+			var cmd tea.Cmd
+			b.path, cmd = b.path.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+	switch s := msg.String(); {
+	case key.Matches(msg, keys.Escape) && b.cancel != nil:
+		b.stop()
+	case key.Matches(msg, keys.Escape):
+		m.batching = false
+		b.input.Blur()
+	case s == "ctrl+r":
+		return m, m.runBatch()
+	case s == "ctrl+o":
+		b.path = textinput.New()
+		b.path.Prompt = "load commands from: "
+		b.path.Placeholder = "~/work/commands.txt"
+		b.path.CharLimit = 1024
+		b.path.Width = max(m.width-panelBorderH-lipgloss.Width(b.path.Prompt)-1, 8)
+		b.loading = true
+		return m, b.path.Focus()
+	case s == "ctrl+s":
+		m.exportBatch()
+	case s == "pgup" || s == "pgdown":
+		var cmd tea.Cmd
+		b.results, cmd = b.results.Update(msg)
+		return m, cmd
+	default:
+		var cmd tea.Cmd
+		b.input, cmd = b.input.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// loadBatch replaces the command list with the lines of the file at path.
+func (m *Model) loadBatch(path string) {
+	b := m.batch
+	path = expandHome(strings.TrimSpace(path))
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		b.status = errorStyle.Render("load: " + err.Error())
+		return
+	}
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+	b.status = fmt.Sprintf("loaded %d lines from %s", len(lines), path)
+	if len(lines) > maxBatchLines {
+		lines = lines[:maxBatchLines]
+		b.status = fmt.Sprintf("loaded the first %d lines of %s", maxBatchLines, path)
+	}
+	b.input.SetValue(strings.Join(lines, "\n"))
+}
+
+// runBatch starts obfuscating every command of the list with the modifiers
+// enabled in the options panel. Each command runs on the profile of the
+// executable it starts with; the config editor's edits, which belong to the
+// selected executable, are left out. Seeds are drawn the way the variant
+// carousel draws them, so the settings file's seed reproduces the batch.
+func (m *Model) runBatch() tea.Cmd {
+	b := m.batch
+	if b.cancel != nil {
+		b.status = "a batch is running: Esc stops it"
+		return nil
+	}
+	enabled := make(map[string]bool)
+	for _, mod := range m.modifiers {
+		enabled[mod.Name] = mod.Enabled
+	}
+	base := m.seeds.Int63()
+	if m.cfg.Seed != nil {
+		base = *m.cfg.Seed
+	}
+	seeds := rand.New(rand.NewSource(base))
+
+	b.rows = nil
+	for i, line := range strings.Split(b.input.Value(), "\n") {
+		cmd := strings.TrimSpace(line)
+		if cmd == "" {
+			continue
+		}
+		row := batchRow{line: i + 1, command: cmd, seed: max(seeds.Int63(), 1)}
+		if pf, ok := engine.DetectProfile(cmd, m.allExes); !ok {
+			row.err = fmt.Errorf("no profile matches %q", strings.Fields(cmd)[0])
+		} else {
+			row.profile, row.err = m.fullProfile(pf)
+		}
+		b.rows = append(b.rows, row)
+	}
+	if len(b.rows) == 0 {
+		b.status = "no commands to run"
+		return nil
+	}
+
+	b.enabled, b.done = enabled, 0
+	b.gen++
+	b.eng = engine.New(m.engineOptionsFor(nil)...)
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	b.status = fmt.Sprintf("running %d commands", len(b.rows))
+	m.refreshBatch()
+	b.results.GotoTop()
+	return b.step()
+}
+
+// step runs the next chunk of rows that have a profile, in the background.
+func (b *batchScreen) step() tea.Cmd {
+	end := min(b.done+batchChunk, len(b.rows))
+	var idx []int
+	var items []engine.BatchItem
+	for i := b.done; i < end; i++ {
+		if r := b.rows[i]; r.err == nil {
+			idx = append(idx, i)
+			items = append(items, engine.BatchItem{Command: r.command, Profile: r.profile, Enabled: b.enabled, Seed: r.seed})
+		}
+	}
+	ctx, eng, gen := b.ctx, b.eng, b.gen
+	return func() tea.Msg {
+		return batchMsg{gen: gen, idx: idx, results: eng.ObfuscateBatch(ctx, items), done: end}
+	}
+}
+
+// batchProgress records a chunk's results and starts the next one.
+func (m *Model) batchProgress(msg batchMsg) tea.Cmd {
+	b := m.batch
+	if b == nil || msg.gen != b.gen {
+		return nil // from a batch stopped since
+	}
+	for k, r := range msg.results {
+		row := &b.rows[msg.idx[k]]
+		row.result, row.err = r.Result, r.Err
+		if row.err == nil && len(m.detectors) > 0 {
+			row.hits = []string{}
+			for _, d := range detect.Hits(m.detectors, detect.EventFor(row.profile, r.Result.Output)) {
+				row.hits = append(row.hits, d.Name())
+			}
+		}
+	}
+	b.done = msg.done
+	if b.done < len(b.rows) {
+		m.refreshBatch()
+		return b.step()
+	}
+	b.cancel()
+	b.cancel = nil
+	failed := 0
+	for _, r := range b.rows {
+		if r.err != nil {
+			failed++
+		}
+	}
+	b.status = fmt.Sprintf("ran %d commands, %d failed: ^S exports the results", len(b.rows), failed)
+	m.refreshBatch()
+	return nil
+}
+
+// stop cancels the running batch, keeping the rows it has run.
+func (b *batchScreen) stop() {
+	b.cancel()
+	b.cancel = nil
+	b.gen++
+	b.status = fmt.Sprintf("stopped after %d of %d commands", b.done, len(b.rows))
+}
+
+// exportBatch writes the variants of the last run to a CSV report, with the
+// detectors each fires when the settings file names some, and to one script
+// per shell they were rendered for, in the working directory.
+func (m *Model) exportBatch() {
+	b := m.batch
+	var report []export.ReportRow
+	scripts := make(map[engine.RenderTarget][]string)
+	var targets []engine.RenderTarget
+	for _, r := range b.rows[:b.done] {
+		if r.err != nil {
+			continue
+		}
+		report = append(report, export.ReportRow{Input: r.command, Output: r.result.Output, Modifiers: r.result.Applied, Score: r.result.Score.Value, Hits: r.hits})
+		if _, ok := scripts[r.result.Target]; !ok {
+			targets = append(targets, r.result.Target)
+		}
+		scripts[r.result.Target] = append(scripts[r.result.Target], r.result.Output)
+	}
+	if len(report) == 0 {
+		b.status = "run the batch first: ^R"
+		return
+	}
+
+	var buf bytes.Buffer
+	err := export.WriteReport(&buf, export.ReportCSV, report)
+	if err == nil {
+		err = os.WriteFile(batchName+".csv", buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		b.status = errorStyle.Render("export failed: " + err.Error())
+		return
+	}
+	names := []string{batchName + ".csv"}
+	for _, target := range targets {
+		format, ok := export.FormatFor(target)
+		if !ok {
+			continue // in the report only
+		}
+		name := batchName + format.Ext()
+		perm := os.FileMode(0o644)
+		if format == export.FormatSh {
+			perm = 0o755
+		}
+		if err := os.WriteFile(name, export.Script(format, scripts[target]), perm); err != nil {
+			b.status = errorStyle.Render("export failed: " + err.Error())
+			return
+		}
+		names = append(names, name)
+	}
+	b.status = fmt.Sprintf("exported %d results to %s", len(report), strings.Join(names, ", "))
+}
+
+// Lines of the batch screen other than the command list and the results:
+// the header, the modifiers, the divider, the progress line and the status.
+const batchFixedRows = 5
+
+// resizeBatch lays the batch screen out for the terminal: the command list
+// takes a third of the rows left, the results the rest.
+func (m *Model) resizeBatch() {
+	b := m.batch
+	w := m.width - panelBorderH
+	rows := max(m.bodyHeight()-panelBorderV-batchFixedRows, 4)
+	b.input.SetWidth(w)
+	b.input.SetHeight(max(rows/3, 2))
+	b.results.Width = w
+	b.results.Height = rows - b.input.Height()
+	m.refreshBatch()
+}
+
+// refreshBatch fills the results viewport with a line per row: the score and
+// output of those run, what went wrong with those that failed and the
+// command of those still to run.
+func (m *Model) refreshBatch() {
+	b := m.batch
+	width := b.results.Width
+	lines := make([]string, len(b.rows))
+	for i, r := range b.rows {
+		num := dimStyle.Render(fmt.Sprintf("%4d  ", r.line))
+		var text string
+		switch {
+		case r.err != nil:
+			text = errorStyle.Render("✗ " + r.command + ": " + strings.TrimPrefix(r.err.Error(), "engine: "))
+		case i >= b.done:
+			text = dimStyle.Render("· " + r.command)
+		default:
+			text = checkedStyle.Render("✓ ") + dimStyle.Render(fmt.Sprintf("%-12s score %3d  ", r.profile.Name, r.result.Score.Value)) + visible(r.result.Output)
+			if r.hits != nil {
+				text += "  " + batchHits(r.hits, len(m.detectors))
+			}
+		}
+		lines[i] = lipgloss.NewStyle().MaxWidth(width).Render(num + text)
+	}
+	b.results.SetContent(strings.Join(lines, "\n"))
+}
+
+// batchHits says how many of total detectors a variant fires.
+func batchHits(hits []string, total int) string {
+	if len(hits) == 0 {
+		return checkedStyle.Render(fmt.Sprintf("evades all %d", total))
+	}
+	return errorStyle.Render(fmt.Sprintf("fires %d of %d", len(hits), total))
+}
+
+// batchModifiers names the modifiers a run would use, in the order they run.
+func (m *Model) batchModifiers() string {
+	var names []string
+	for _, mod := range m.modifiers {
+		if mod.Enabled {
+			names = append(names, mod.Name)
+		}
+	}
+	if len(names) == 0 {
+		return "no modifiers enabled: enable some in the Modifiers panel first"
+	}
+	return "modifiers: " + strings.Join(names, ", ") + "  (from the Modifiers panel)"
+}
+
+// viewProgress renders the progress bar and the counts of the last run.
+func (b *batchScreen) viewProgress() string {
+	if len(b.rows) == 0 {
+		return dimStyle.Render("(^R runs every command in the list)")
+	}
+	filled := b.done * batchBarWidth / len(b.rows)
+	bar := checkedStyle.Render(strings.Repeat("█", filled)) + dimStyle.Render(strings.Repeat("░", batchBarWidth-filled))
+	failed := 0
+	for _, r := range b.rows[:b.done] {
+		if r.err != nil {
+			failed++
+		}
+	}
+	counts := fmt.Sprintf("  %d/%d  •  %d ok  •  %d failed", b.done, len(b.rows), b.done-failed, failed)
+	if b.cancel != nil {
+		counts += "  •  running…"
+	}
+	return bar + dimStyle.Render(counts)
+}
+
+// viewBatch renders the batch screen in place of the sidebar and panels.
+func (m Model) viewBatch() string {
+	b := m.batch
+	w := m.width - panelBorderH
+	header := lipgloss.NewStyle().MaxWidth(w).Render(
+		sectionStyle.Render("Batch") + "  " + dimStyle.Render("[^R] Run  [^O] Load file  [^S] Export  [PgUp/PgDn] Scroll  [Esc] Stop/Close"),
+	)
+	status := b.status
+	if b.loading {
+		status = b.path.View()
+	}
+	inner := lipgloss.JoinVertical(lipgloss.Left,
+		header,
+		lipgloss.NewStyle().MaxWidth(w).Render(dimStyle.Render(m.batchModifiers())),
+		b.input.View(),
+		dimStyle.Render(strings.Repeat("─", w)),
+		lipgloss.NewStyle().MaxWidth(w).Render(b.viewProgress()),
+		lipgloss.NewStyle().Height(b.results.Height).Render(b.results.View()),
+		lipgloss.NewStyle().MaxWidth(w).Render(status),
+	)
+	// The width set takes in the padding, but not the border.
+	return panelStyle(true).Width(w + 2).Height(m.bodyHeight() - panelBorderV).Render(inner)
+}
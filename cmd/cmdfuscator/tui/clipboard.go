@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+)
+
+// ─── Clipboard ────────────────────────────────────────────────────────────────
+
+// viaTerminal is what copyToClipboard reports when it copied with OSC 52.
+const viaTerminal = "terminal"
+
+// clipboardTool is a command that copies its stdin to the system clipboard.
+type clipboardTool struct {
+	name string
+	args []string
+	// utf16 feeds the tool UTF-16LE with a byte order mark: clip.exe reads
+	// anything else in the console code page, which garbles the invisible
+	// and non-ASCII characters modifiers insert.
+	utf16 bool
+}
+
+// psSetClipboard copies PowerShell's stdin, read as UTF-8, to the clipboard.
+const psSetClipboard = "[Console]::InputEncoding = [Text.Encoding]::UTF8; Set-Clipboard -Value ([Console]::In.ReadToEnd())"
+
+// clipboardTools returns the tools to try on this system, in order.
+func clipboardTools() []clipboardTool {
+	clip := clipboardTool{name: "clip.exe", utf16: true}
+	powershell := clipboardTool{name: "powershell.exe", args: []string{"-NoProfile", "-NonInteractive", "-Command", psSetClipboard}}
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardTool{{name: "pbcopy"}}
+	case "windows":
+		return []clipboardTool{clip, powershell}
+	}
+	var tools []clipboardTool
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, clipboardTool{name: "wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		tools = append(tools,
+			clipboardTool{name: "xclip", args: []string{"-selection", "clipboard"}},
+			clipboardTool{name: "xsel", args: []string{"--clipboard", "--input"}})
+	}
+	if os.Getenv("WSL_DISTRO_NAME") != "" { // the Windows clipboard, from WSL
+		tools = append(tools, clip, powershell)
+	}
+	return tools
+}
+
+// copyToClipboard copies text with the first clipboard tool that is installed
+// and succeeds, and returns its name. Over SSH, or when no tool works, it
+// asks the terminal to copy instead with an OSC 52 escape sequence, which
+// reaches the clipboard of the machine the user sits at, and returns
+// viaTerminal. Terminals do not acknowledge OSC 52, and some ignore it.
+func copyToClipboard(text string) (string, error) {
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		for _, tool := range clipboardTools() {
+			if _, err := exec.LookPath(tool.name); err != nil {
+				continue
+			}
+			if runTool(tool, text) == nil {
+				return tool.name, nil
+			}
+		}
+	}
+	if _, err := io.WriteString(os.Stdout, osc52(text, os.Getenv("TMUX") != "")); err != nil {
+		return "", errors.New("no clipboard tool, and the terminal could not be reached: " + err.Error())
+	}
+	return viaTerminal, nil
+}
+
+func runTool(tool clipboardTool, text string) error {
+	cmd := exec.Command(tool.name, tool.args...)
+	if tool.utf16 {
+		cmd.Stdin = bytes.NewReader(utf16LE(text))
+	} else {
+		cmd.Stdin = strings.NewReader(text)
+	}
+	return cmd.Run()
+}
+
+// utf16LE returns s as UTF-16LE with a byte order mark.
+func utf16LE(s string) []byte {
+	units := utf16.Encode([]rune("\ufeff" + s))
+	out := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
+
+// osc52 returns the escape sequence that sets the terminal's clipboard to
+// text. Inside tmux it is wrapped in a passthrough sequence, which tmux
+// forwards to the outer terminal when allow-passthrough is on.
+func osc52(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"cmdFuscator/cmd/cmdfuscator/config"
+	"cmdFuscator/detect"
+	"cmdFuscator/detect/patterns"
+	"cmdFuscator/engine"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Detection ────────────────────────────────────────────────────────────────
+
+// detectionCheck is the current variant checked against the detectors.
+type detectionCheck struct {
+	score int
+	hits  []string // the detectors the output still fires
+	base  int      // how many the input fires
+}
+
+// loadDetectors loads the Sigma rules and signatures the settings file names.
+// Sigma files that hold no usable rule are skipped, as obfuscate --sigma
+// skips them; a path with nothing usable at all is an error.
+func loadDetectors(cfg *config.Config) ([]detect.Detector, error) {
+	var ds []detect.Detector
+	if cfg.Sigma != "" {
+		rules, _, err := detect.LoadPath(cfg.Sigma)
+		if err != nil {
+			return nil, fmt.Errorf("sigma: %w", err)
+		}
+		if len(rules) == 0 {
+			return nil, fmt.Errorf("sigma: no process_creation rules in %s", cfg.Sigma)
+		}
+		for _, r := range rules {
+			ds = append(ds, r)
+		}
+	}
+	if cfg.Patterns != "" {
+		sigs, err := patterns.Load(cfg.Patterns)
+		if err != nil {
+			return nil, err
+		}
+		if len(sigs) == 0 {
+			return nil, fmt.Errorf("patterns: no signatures in %s", cfg.Patterns)
+		}
+		for _, s := range sigs {
+			ds = append(ds, s)
+		}
+	}
+	return ds, nil
+}
+
+// checkDetection checks res, obfuscated from input, and input itself against
+// the detectors, or returns nil when there are none.
+func (m *Model) checkDetection(input string, res engine.ObfuscateResult) *detectionCheck {
+	if len(m.detectors) == 0 || m.selected == nil {
+		return nil
+	}
+	c := &detectionCheck{
+		score: res.Score.Value,
+		base:  len(detect.Hits(m.detectors, detect.EventFor(m.selected, input))),
+	}
+	for _, d := range detect.Hits(m.detectors, detect.EventFor(m.selected, res.Output)) {
+		c.hits = append(c.hits, d.Name())
+	}
+	return c
+}
+
+// detectionRows is how many lines the detection line under the output takes.
+func (m *Model) detectionRows() int {
+	if m.detection == nil || m.output == "" {
+		return 0
+	}
+	return 1
+}
+
+// viewDetection renders the detection line in width columns: evading every
+// detector in the accent colour, firing fewer than the input in the
+// highlight colour, and as many or more in the error colour.
+func (m Model) viewDetection(width int) string {
+	c := m.detection
+	total := len(m.detectors)
+	var text string
+	style := errorStyle
+	switch {
+	case len(c.hits) == 0:
+		text = fmt.Sprintf("evades all %d", total)
+		style = checkedStyle
+	default:
+		text = fmt.Sprintf("fires %d of %d", len(c.hits), total)
+		if len(c.hits) < c.base {
+			style = warningStyle
+		}
+	}
+	text += fmt.Sprintf(" (the input fires %d)", c.base)
+	if len(c.hits) > 0 {
+		text += ": " + strings.Join(c.hits, ", ")
+	}
+	line := sectionStyle.Render("detection") + "  " + dimStyle.Render(fmt.Sprintf("score %d  •  ", c.score)) + style.Render(text)
+	return lipgloss.NewStyle().MaxWidth(width).Render(line)
+}
@@ -0,0 +1,392 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Modifier config editor ───────────────────────────────────────────────────
+
+// openEditor opens the config form for the modifier under the cursor, filled
+// with its edited config or else the profile's.
+func (m *Model) openEditor() {
+	if m.selected == nil || len(m.selected.Profiles) == 0 || m.modCursor >= len(m.modifiers) {
+		return
+	}
+	name := m.modifiers[m.modCursor].Name
+	cfg, ok := m.edits[m.selected.Name][name]
+	if !ok {
+		cfg, _ = engine.ConfigFor(m.selected.Profiles[0], name)
+	}
+	ed, err := newConfigEditor(name, cfg)
+	if err != nil {
+		m.statusMsg = errorStyle.Render(err.Error())
+		return
+	}
+	m.editor = ed
+	m.recalcSizes()
+}
+
+func (m Model) handleEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	e := m.editor
+	switch s := msg.String(); {
+	case key.Matches(msg, keys.Escape):
+		m.closeEditor("")
+	case key.Matches(msg, keys.Apply):
+		m.saveEditor()
+	case s == "ctrl+r":
+		m.setEdit(e.modifier, nil)
+		m.closeEditor(e.modifier + ": profile config restored")
+	case s == "up" || s == "shift+tab":
+		e.move(e.cursor - 1)
+	case s == "down" || s == "tab":
+		e.move(e.cursor + 1)
+	default:
+		if len(e.fields) == 0 {
+			return m, nil
+		}
+		f := &e.fields[e.cursor]
+		e.err = ""
+		if f.kind == fieldBool {
+			if msg.String() == " " {
+				f.on = !f.on
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		f.input, cmd = f.input.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// saveEditor saves the form as the modifier's config for this session and
+// enables the modifier, or reports what is wrong with it and stays open.
+// A config equal to the profile's drops the edit.
+func (m *Model) saveEditor() {
+	name := m.editor.modifier
+	cfg, err := m.editor.config()
+	if err != nil {
+		m.editor.err = err.Error()
+		return
+	}
+	if base, ok := engine.ConfigFor(m.selected.Profiles[0], name); ok && sameConfig(cfg, base) {
+		cfg = nil
+	}
+	m.setEdit(name, cfg)
+	for i := range m.modifiers {
+		if m.modifiers[i].Name == name {
+			m.modifiers[i].Enabled = true
+		}
+	}
+	m.closeEditor(name + ": config edited for this session")
+	if m.output != "" {
+		m.applyObfuscation()
+	}
+}
+
+func (m *Model) closeEditor(status string) {
+	m.editor = nil
+	if status != "" {
+		m.statusMsg = status
+	}
+	m.recalcSizes()
+}
+
+// setEdit records cfg as the selected profile's config for modifier, or drops
+// the edit when cfg is nil, and rebuilds the engine with it.
+func (m *Model) setEdit(modifier string, cfg json.RawMessage) {
+	profile := m.selected.Name
+	if cfg == nil {
+		delete(m.edits[profile], modifier)
+	} else {
+		if m.edits == nil {
+			m.edits = make(map[string]map[string]json.RawMessage)
+		}
+		if m.edits[profile] == nil {
+			m.edits[profile] = make(map[string]json.RawMessage)
+		}
+		m.edits[profile][modifier] = cfg
+	}
+	m.eng = engine.New(m.engineOptions()...)
+	m.refreshPreviews()
+}
+
+// selectedName is the selected executable's name, or "" when there is none.
+func (m *Model) selectedName() string {
+	if m.selected == nil {
+		return ""
+	}
+	return m.selected.Name
+}
+
+// fieldKind is how a config field is shown and edited.
+type fieldKind int
+
+const (
+	fieldText fieldKind = iota // a string of printable characters, edited as is
+	fieldBool                  // a bool, toggled with Space
+	fieldList                  // an array of strings, edited as quoted items
+	fieldJSON                  // anything else, edited as JSON
+)
+
+// configField is one top-level key of the config being edited.
+type configField struct {
+	key   string
+	kind  fieldKind
+	on    bool            // a fieldBool's value
+	input textinput.Model // every other kind's text
+}
+
+// configEditor is the form the options panel shows in place of the modifier
+// grid while a modifier's config is being edited.
+type configEditor struct {
+	modifier string
+	fields   []configField
+	cursor   int
+	err      string
+}
+
+// defaultConfig is where the editor starts for a modifier the profile does
+// not configure.
+var defaultConfig = json.RawMessage(`{"AppliesTo":["argument"],"Probability":"0.5"}`)
+
+// newConfigEditor returns a form holding cfg, a JSON object, with Probability
+// first, AppliesTo last and the modifier's own keys in between.
+func newConfigEditor(modifier string, cfg json.RawMessage) (*configEditor, error) {
+	if len(cfg) == 0 {
+		cfg = defaultConfig
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(cfg, &values); err != nil || values == nil {
+		return nil, fmt.Errorf("%s: config is not a JSON object", modifier)
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	rank := func(k string) int {
+		switch {
+		case strings.EqualFold(k, "Probability"):
+			return 0
+		case strings.EqualFold(k, "AppliesTo"):
+			return 2
+		}
+		return 1
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if d := rank(a) - rank(b); d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+
+	e := &configEditor{modifier: modifier}
+	for _, k := range keys {
+		e.fields = append(e.fields, newField(k, values[k]))
+	}
+	if len(e.fields) > 0 {
+		e.fields[0].input.Focus()
+	}
+	return e, nil
+}
+
+func newField(key string, raw json.RawMessage) configField {
+	f := configField{key: key, kind: fieldJSON, input: textinput.New()}
+	f.input.Prompt = ""
+	f.input.CharLimit = 1024
+	var s string
+	var list []string
+	switch {
+	case json.Unmarshal(raw, &f.on) == nil:
+		f.kind = fieldBool
+	case json.Unmarshal(raw, &s) == nil && printable(s):
+		f.kind = fieldText
+		f.input.SetValue(s)
+	case json.Unmarshal(raw, &list) == nil && list != nil:
+		f.kind = fieldList
+		quoted := make([]string, len(list))
+		for i, item := range list {
+			quoted[i] = quoteJSON(item)
+		}
+		f.input.SetValue(strings.Join(quoted, ", "))
+	case json.Unmarshal(raw, &s) == nil:
+		f.input.SetValue(quoteJSON(s))
+	default:
+		f.input.SetValue(string(raw))
+	}
+	return f
+}
+
+// printable reports whether s can be edited as is: it holds no control,
+// format or other invisible characters.
+func printable(s string) bool {
+	for _, r := range s {
+		if !unicode.IsPrint(r) || unicode.Is(unicode.Cf, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteJSON returns s as a JSON string with every invisible or non-ASCII
+// character written as a \u escape, so that pools of zero-width and
+// look-alike characters can be read and typed.
+func quoteJSON(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		default:
+			for _, u := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%04x`, u)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// move puts the cursor on field i, wrapping around.
+func (e *configEditor) move(i int) {
+	if len(e.fields) == 0 {
+		return
+	}
+	e.fields[e.cursor].input.Blur()
+	e.cursor = (i + len(e.fields)) % len(e.fields)
+	e.fields[e.cursor].input.Focus()
+}
+
+// config returns the form's values as a JSON object, checked the way the
+// engine and the modifier will read it.
+func (e *configEditor) config() (json.RawMessage, error) {
+	values := make(map[string]json.RawMessage, len(e.fields))
+	for _, f := range e.fields {
+		text := f.input.Value()
+		var v any
+		var err error
+		switch f.kind {
+		case fieldBool:
+			v = f.on
+		case fieldText:
+			v = text
+		case fieldList:
+			var list []string
+			err = json.Unmarshal([]byte("["+text+"]"), &list)
+			v = list
+		case fieldJSON:
+			err = json.Unmarshal([]byte(text), &v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.key, err)
+		}
+		values[f.key], _ = json.Marshal(v)
+	}
+	cfg, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := modifiers.ParseConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := modifiers.ParseProbability(string(base.Probability)); err != nil {
+		return nil, err
+	}
+	if mod, ok := modifiers.Get(e.modifier); ok {
+		if cp, ok := mod.(modifiers.ConfigParser); ok {
+			if _, err := cp.ParseConfig(nil, cfg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// sameConfig reports whether a and b hold the same JSON value.
+func sameConfig(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// rows is the number of lines view renders.
+func (e *configEditor) rows() int {
+	return max(len(e.fields), 1) + 1
+}
+
+// view renders the form in width columns: one field per line, then the
+// last error or a hint.
+func (e *configEditor) view(width int) string {
+	labelW := 0
+	for _, f := range e.fields {
+		labelW = max(labelW, lipgloss.Width(f.key))
+	}
+	var lines []string
+	for i, f := range e.fields {
+		prefix := "  "
+		if i == e.cursor {
+			prefix = selectedStyle.Render("> ")
+		}
+		label := normalStyle.Render(fmt.Sprintf("%-*s", labelW, f.key)) + "  "
+		var value string
+		switch f.kind {
+		case fieldBool:
+			value = uncheckedStyle.Render("[ ]")
+			if f.on {
+				value = checkedStyle.Render("[✓]")
+			}
+		default:
+			in := f.input
+			in.Width = max(width-labelW-6, 8)
+			value = in.View()
+		}
+		lines = append(lines, prefix+label+value)
+	}
+	if len(e.fields) == 0 {
+		lines = append(lines, dimStyle.Render("  (empty config)"))
+	}
+	if e.err != "" {
+		lines = append(lines, errorStyle.Render(e.err))
+	} else {
+		lines = append(lines, dimStyle.Render(fieldHint(e.fields, e.cursor)))
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(strings.Join(lines, "\n"))
+}
+
+// fieldHint describes how the field under the cursor is edited.
+func fieldHint(fields []configField, cursor int) string {
+	if cursor >= len(fields) {
+		return ""
+	}
+	switch fields[cursor].kind {
+	case fieldBool:
+		return "Space toggles"
+	case fieldList:
+		return `quoted items, comma separated: "a", "\u200d"`
+	case fieldJSON:
+		return "a JSON value"
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+
+	"cmdFuscator/models"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Fuzzy search ─────────────────────────────────────────────────────────────
+
+// Scores of a fuzzy match, per query character matched.
+const (
+	fuzzyMatch       = 1  // every matched character
+	fuzzyStart       = 8  // the first character of the name
+	fuzzyBoundary    = 6  // after a separator or at an upper-case letter
+	fuzzyConsecutive = 5  // right after the previous matched character
+	fuzzyGap         = -1 // after skipping characters
+	fuzzyAlias       = -2 // the match is on an alias, not the name
+)
+
+// fuzzyScore matches the characters of query, in order and ignoring case,
+// against target and returns the best score of that and the rune indexes of
+// target it matched, or ok false when target does not hold them all.
+// Matches that start words and run together score higher.
+func fuzzyScore(target, query string) (score int, positions []int, ok bool) {
+	t, q := []rune(target), []rune(strings.ToLower(query))
+	if len(q) == 0 || len(q) > len(t) {
+		return 0, nil, len(q) == 0
+	}
+	lower := []rune(strings.ToLower(target))
+	if len(lower) != len(t) {
+		lower = t // a rune whose case changes length; match it as is
+	}
+
+	const none = -1 << 30
+	// best[i][j] is the best score of q[:i+1] with q[i] matched at t[j], and
+	// from[i][j] where q[i-1] was matched then.
+	best := make([][]int, len(q))
+	from := make([][]int, len(q))
+	for i := range q {
+		best[i] = make([]int, len(t))
+		from[i] = make([]int, len(t))
+		// run is the best best[i-1][k] for k < j-1, at runAt.
+		run, runAt := none, -1
+		for j := range t {
+			best[i][j] = none
+			if i > 0 && j >= 2 && best[i-1][j-2] > run {
+				run, runAt = best[i-1][j-2], j-2
+			}
+			if lower[j] != q[i] {
+				continue
+			}
+			s := fuzzyMatch + boundaryBonus(t, j)
+			switch {
+			case i == 0:
+				best[i][j] = s
+				if j > 0 {
+					best[i][j] += fuzzyGap
+				}
+			case j > 0 && best[i-1][j-1] != none && best[i-1][j-1]+fuzzyConsecutive >= run+fuzzyGap:
+				best[i][j], from[i][j] = best[i-1][j-1]+s+fuzzyConsecutive, j-1
+			case run != none:
+				best[i][j], from[i][j] = run+s+fuzzyGap, runAt
+			}
+		}
+	}
+
+	last := len(q) - 1
+	end := -1
+	for j := range t {
+		if best[last][j] != none && (end < 0 || best[last][j] > best[last][end]) {
+			end = j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	positions = make([]int, len(q))
+	for i, j := last, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+	return best[last][end], positions, true
+}
+
+// boundaryBonus scores t[j] starting a word.
+func boundaryBonus(t []rune, j int) int {
+	switch {
+	case j == 0:
+		return fuzzyStart
+	case strings.ContainsRune("-_. /\\", t[j-1]):
+		return fuzzyBoundary
+	case unicode.IsUpper(t[j]) && unicode.IsLower(t[j-1]):
+		return fuzzyBoundary
+	}
+	return 0
+}
+
+// matchProfile fuzzy-matches query against pf's name and aliases and returns
+// the best score, with the positions it matched in the name; nil when the
+// best match is on an alias.
+func matchProfile(pf *models.ProfileFile, query string) (score int, positions []int, ok bool) {
+	score, positions, ok = fuzzyScore(pf.Name, query)
+	for _, alias := range pf.Aliases() {
+		if s, _, found := fuzzyScore(alias, query); found && (!ok || s+fuzzyAlias > score) {
+			score, positions, ok = s+fuzzyAlias, nil, true
+		}
+	}
+	return score, positions, ok
+}
+
+// highlightMatch renders name in style with the runes at positions, those a
+// search matched, in matchStyle as well.
+func highlightMatch(name string, positions []int, style lipgloss.Style) string {
+	if len(positions) == 0 {
+		return style.Render(name)
+	}
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+	var b strings.Builder
+	runes := []rune(name)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && matched[j] == matched[i] {
+			j++
+		}
+		if matched[i] {
+			b.WriteString(matchStyle.Inherit(style).Render(string(runes[i:j])))
+		} else {
+			b.WriteString(style.Render(string(runes[i:j])))
+		}
+		i = j
+	}
+	return b.String()
+}
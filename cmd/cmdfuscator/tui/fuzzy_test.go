@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+// ─── fuzzyScore ───────────────────────────────────────────────────────────────
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		name          string
+		target, query string
+		ok            bool
+		positions     []int
+	}{
+		{"start and later letter", "certutil", "cu", true, []int{0, 4}},
+		{"ignores case", "certutil", "CU", true, []int{0, 4}},
+		{"prefers word starts", "cert-util", "cu", true, []int{0, 5}},
+		{"prefers upper-case starts", "CertUtil", "util", true, []int{4, 5, 6, 7}},
+		{"prefers a run", "xcuxcu", "cu", true, []int{1, 2}},
+		{"skips letters", "powershell", "pwsh", true, []int{0, 2, 5, 6}},
+		{"empty query", "certutil", "", true, nil},
+		{"letter missing", "certutil", "z", false, nil},
+		{"out of order", "msiexec", "mt", false, nil},
+		{"query longer than target", "sh", "bash", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, positions, ok := fuzzyScore(tt.target, tt.query)
+			if ok != tt.ok {
+				t.Fatalf("fuzzyScore(%q, %q) ok = %v, want %v", tt.target, tt.query, ok, tt.ok)
+			}
+			if !slices.Equal(positions, tt.positions) {
+				t.Errorf("fuzzyScore(%q, %q) positions = %v, want %v", tt.target, tt.query, positions, tt.positions)
+			}
+		})
+	}
+}
+
+func TestFuzzyScore_Ranking(t *testing.T) {
+	tests := []struct {
+		query, better, worse string
+	}{
+		{"cu", "certutil", "secureboot"}, // the name's start over mid-word
+		{"cu", "CertUtil", "certutil"},   // an upper-case word start
+		{"cu", "cert-util", "certutil"},  // a word after a separator
+		{"pwsh", "pwsh", "powershell"},   // a run over skipped letters
+	}
+	for _, tt := range tests {
+		b, _, okB := fuzzyScore(tt.better, tt.query)
+		w, _, okW := fuzzyScore(tt.worse, tt.query)
+		if !okB || !okW {
+			t.Fatalf("%q should match both %q and %q", tt.query, tt.better, tt.worse)
+		}
+		if b <= w {
+			t.Errorf("%q: %q scores %d, want above %q's %d", tt.query, tt.better, b, tt.worse, w)
+		}
+	}
+}
+
+func TestMatchProfile_Alias(t *testing.T) {
+	pwsh := &models.ProfileFile{Name: "pwsh"}
+	powershell := &models.ProfileFile{Name: "powershell", Profiles: []models.Profile{{Alias: []string{"pwsh"}}}}
+
+	name, positions, ok := matchProfile(pwsh, "pwsh")
+	if !ok || !slices.Equal(positions, []int{0, 1, 2, 3}) {
+		t.Fatalf("name match: positions %v, ok %v", positions, ok)
+	}
+	alias, positions, ok := matchProfile(powershell, "pwsh")
+	if !ok || positions != nil {
+		t.Fatalf("alias match: positions %v, ok %v; want no positions in the name", positions, ok)
+	}
+	if alias >= name {
+		t.Errorf("alias match scores %d, want below the name match's %d", alias, name)
+	}
+	if want := name + fuzzyAlias; alias != want {
+		t.Errorf("alias match scores %d, want %d", alias, want)
+	}
+}
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"cmdFuscator/models"
+)
+
+// ─── Platform groups ──────────────────────────────────────────────────────────
+
+// sidebarRow is one line of the sidebar's executable list: an executable,
+// or with the All tab the header of a platform's group.
+type sidebarRow struct {
+	pf       *models.ProfileFile // nil on a header
+	platform string              // the group the row is in; "" without groups
+	count    int                 // a header's executables
+}
+
+// groupOther is the group of executables with no profile for a known
+// platform.
+const groupOther = "other"
+
+// groupNames are the platforms' headers, in the order of the OS tabs.
+var groupNames = []struct{ platform, name string }{
+	{"windows", "Windows"},
+	{"linux", "Linux"},
+	{"macos", "macOS"},
+	{groupOther, "Other"},
+}
+
+// platformsOf lists the groups pf is listed in: one per platform it has a
+// profile for.
+func platformsOf(pf *models.ProfileFile) []string {
+	var out []string
+	for _, g := range groupNames {
+		if slices.ContainsFunc(pf.Profiles, func(p models.Profile) bool { return strings.EqualFold(p.Platform, g.platform) }) {
+			out = append(out, g.platform)
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, groupOther)
+	}
+	return out
+}
+
+// buildRows lays out filtered for the sidebar. The All tab groups it under
+// a header per platform, an executable with profiles for several under each
+// of theirs, and leaves out the executables of folded groups, unless a
+// search is on, which shows every match. Other tabs list it as it is.
+func (m *Model) buildRows() {
+	m.rows = nil
+	if m.osFilter != osAll {
+		for _, pf := range m.filtered {
+			m.rows = append(m.rows, sidebarRow{pf: pf})
+		}
+		return
+	}
+	for _, g := range groupNames {
+		var members []*models.ProfileFile
+		for _, pf := range m.filtered {
+			if slices.Contains(platformsOf(pf), g.platform) {
+				members = append(members, pf)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		m.rows = append(m.rows, sidebarRow{platform: g.platform, count: len(members)})
+		if m.folded(g.platform) {
+			continue
+		}
+		for _, pf := range members {
+			m.rows = append(m.rows, sidebarRow{pf: pf, platform: g.platform})
+		}
+	}
+}
+
+// toggleGroup folds the group of the row under the sidebar cursor, leaving
+// the cursor on its header, or unfolds it.
+func (m *Model) toggleGroup() {
+	if m.exeCursor < 0 || m.exeCursor >= len(m.rows) || m.rows[m.exeCursor].platform == "" {
+		return
+	}
+	platform := m.rows[m.exeCursor].platform
+	if m.collapsed == nil {
+		m.collapsed = make(map[string]bool)
+	}
+	m.collapsed[platform] = !m.collapsed[platform]
+	m.buildRows()
+	for i, r := range m.rows {
+		if r.pf == nil && r.platform == platform {
+			m.showRow(i)
+			m.statusMsg = fmt.Sprintf("%s: %d executable(s)", groupName(platform), r.count)
+			if m.collapsed[platform] {
+				m.statusMsg += ", folded"
+			}
+			return
+		}
+	}
+}
+
+// groupName is the header of platform's group.
+func groupName(platform string) string {
+	for _, g := range groupNames {
+		if g.platform == platform {
+			return g.name
+		}
+	}
+	return platform
+}
+
+// findExe returns the sidebar row of the executable called name, unfolding
+// its group when it is folded, or -1.
+func (m *Model) findExe(name string) int {
+	i := slices.IndexFunc(m.filtered, func(pf *models.ProfileFile) bool { return strings.EqualFold(pf.Name, name) })
+	if i < 0 {
+		return -1
+	}
+	pf := m.filtered[i]
+	if m.osFilter == osAll && slices.ContainsFunc(platformsOf(pf), m.folded) {
+		for _, p := range platformsOf(pf) {
+			delete(m.collapsed, p)
+		}
+		m.buildRows()
+	}
+	return slices.IndexFunc(m.rows, func(r sidebarRow) bool { return r.pf == pf })
+}
+
+// showRow moves the sidebar cursor to row i, scrolling it into view.
+func (m *Model) showRow(i int) {
+	m.exeCursor = i
+	m.exeOffset = min(m.exeOffset, i)
+	if listH := m.sidebarListHeight(); i >= m.exeOffset+listH {
+		m.exeOffset = i - listH + 1
+	}
+}
+
+// selectFirst moves the sidebar cursor to the first executable in the list
+// and selects it.
+func (m *Model) selectFirst() {
+	m.exeCursor, m.exeOffset = 0, 0
+	for i, r := range m.rows {
+		if r.pf != nil {
+			m.showRow(i)
+			m.selectExe(i)
+			return
+		}
+	}
+}
+
+// folded reports whether platform's group hides its executables.
+func (m *Model) folded(platform string) bool {
+	return m.collapsed[platform] && strings.TrimSpace(m.searchInput.Value()) == ""
+}
+
+// viewGroupHeader renders the header r, with the cursor on it or not.
+func (m Model) viewGroupHeader(r sidebarRow, cursor bool) string {
+	arrow := "▾ "
+	if m.folded(r.platform) {
+		arrow = "▸ "
+	}
+	style := sectionStyle
+	if cursor {
+		style = selectedStyle
+	}
+	return style.Render(arrow+groupName(r.platform)) + dimStyle.Render(fmt.Sprintf(" (%d)", r.count))
+}
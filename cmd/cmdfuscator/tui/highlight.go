@@ -0,0 +1,174 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"cmdFuscator/engine"
+)
+
+// ─── Diff highlighting ────────────────────────────────────────────────────────
+
+// maxDiffCells bounds the rune-by-rune comparison of one token with its
+// original; a longer pair is highlighted whole when it differs.
+const maxDiffCells = 1 << 16
+
+// invisibleNames names the invisible characters modifiers commonly insert.
+var invisibleNames = map[rune]string{
+	0x00A0: "NBSP",
+	0x00AD: "SHY",
+	0x034F: "CGJ",
+	0x061C: "ALM",
+	0x180E: "MVS",
+	0x200B: "ZWSP",
+	0x200C: "ZWNJ",
+	0x200D: "ZWJ",
+	0x200E: "LRM",
+	0x200F: "RLM",
+	0x202A: "LRE",
+	0x202B: "RLE",
+	0x202C: "PDF",
+	0x202D: "LRO",
+	0x202E: "RLO",
+	0x2060: "WJ",
+	0x2066: "LRI",
+	0x2067: "RLI",
+	0x2068: "FSI",
+	0x2069: "PDI",
+	0xFEFF: "BOM",
+}
+
+// invisible reports whether r is a character escapeInvisible marks.
+func invisible(r rune) bool {
+	return r != '\n' && r != '\t' && (!unicode.IsPrint(r) || unicode.Is(unicode.Cf, r))
+}
+
+// placeholder is what the output panel shows for the invisible character r.
+func placeholder(r rune) string {
+	if name, ok := invisibleNames[r]; ok {
+		return "⟨" + name + "⟩"
+	}
+	return fmt.Sprintf("⟨U+%04X⟩", r)
+}
+
+// escapeRune is how the escaped output shows r, as in a Go string literal,
+// or "" for a rune shown as itself. Backslashes are doubled so that a path
+// such as C:\temp does not read as a tab.
+func escapeRune(r rune) string {
+	switch {
+	case r == '\\':
+		return `\\`
+	case r == '\t':
+		return `\t`
+	case r == '\n':
+		return `\n`
+	case r == '\r':
+		return `\r`
+	case r < 0x20 || r == 0x7F:
+		return fmt.Sprintf(`\x%02x`, r)
+	case !invisible(r):
+		return ""
+	case r > 0xFFFF:
+		return fmt.Sprintf(`\U%08x`, r)
+	}
+	return fmt.Sprintf(`\u%04x`, r)
+}
+
+// placeholderRune is how the output panel shows r when it is not escaped:
+// invisible characters as their placeholder, others as themselves ("").
+func placeholderRune(r rune) string {
+	if invisible(r) {
+		return placeholder(r)
+	}
+	return ""
+}
+
+// highlightOutput renders res.Output with the characters the modifiers
+// changed or inserted highlighted, found by comparing each of the engine's
+// segments with the input token it descends from, and with invisible
+// characters shown as placeholders, or every character escapeRune escapes
+// escaped when escaped is set.
+func highlightOutput(res engine.ObfuscateResult, escaped bool) string {
+	show := placeholderRune
+	if escaped {
+		show = escapeRune
+	}
+	if res.Segments == nil {
+		out := []rune(res.Output)
+		return highlightRunes(out, make([]bool, len(out)), show)
+	}
+	var b strings.Builder
+	for i, seg := range res.Segments {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		out := []rune(res.Output[seg.Start:seg.End])
+		b.WriteString(highlightRunes(out, changedRunes(out, []rune(seg.Original)), show))
+	}
+	return b.String()
+}
+
+// changedRunes marks the runes of out that are not part of a longest common
+// subsequence with original: those a modifier inserted or changed.
+func changedRunes(out, original []rune) []bool {
+	changed := make([]bool, len(out))
+	if len(original) == 0 || len(out)*len(original) > maxDiffCells {
+		eq := string(out) == string(original)
+		for i := range changed {
+			changed[i] = !eq
+		}
+		return changed
+	}
+	// lcs[i][j] is the length of the LCS of out[i:] and original[j:].
+	lcs := make([][]int, len(out)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(original)+1)
+	}
+	for i := len(out) - 1; i >= 0; i-- {
+		for j := len(original) - 1; j >= 0; j-- {
+			if out[i] == original[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(out) {
+		switch {
+		case j < len(original) && out[i] == original[j]:
+			i, j = i+1, j+1
+		case j < len(original) && lcs[i][j+1] > lcs[i+1][j]:
+			j++
+		default:
+			changed[i] = true
+			i++
+		}
+	}
+	return changed
+}
+
+// highlightRunes renders runes, styling each run of changed runes shown as
+// themselves and every rune show gives another form to.
+func highlightRunes(runes []rune, changed []bool, show func(rune) string) string {
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		if s := show(runes[i]); s != "" {
+			b.WriteString(rawEscapeStyle.Render(s))
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && changed[j] == changed[i] && show(runes[j]) == "" {
+			j++
+		}
+		if changed[i] {
+			b.WriteString(changedStyle.Render(string(runes[i:j])))
+		} else {
+			b.WriteString(string(runes[i:j]))
+		}
+		i = j
+	}
+	return b.String()
+}
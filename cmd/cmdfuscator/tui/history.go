@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── History ──────────────────────────────────────────────────────────────────
+
+// maxHistory is how many entries the history panel keeps, and loads from the
+// history file.
+const maxHistory = 200
+
+// historyRows is how many entries the history panel shows at once.
+const historyRows = 4
+
+// historyEntry is one obfuscation, as the history file stores it: one JSON
+// object per line.
+type historyEntry struct {
+	Time      time.Time `json:"time"`
+	Profile   string    `json:"profile"`
+	Input     string    `json:"input"`
+	Modifiers []string  `json:"modifiers"`
+	Seed      int64     `json:"seed"`
+	Output    string    `json:"output"`
+}
+
+// loadHistory returns the last maxHistory entries of the history file at
+// path, oldest first. A missing file is an empty history; lines that do not
+// decode are skipped.
+func loadHistory(path string) ([]historyEntry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []historyEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var e historyEntry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries[max(len(entries)-maxHistory, 0):], sc.Err()
+}
+
+// appendHistory appends e to the history file at path, creating it and its
+// directory as needed.
+func appendHistory(path string, e historyEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// recordHistory adds a generated variant to the history, and to the history
+// file when the settings file asks for one.
+func (m *Model) recordHistory(c *carousel, v variant) {
+	var mods []string
+	for _, mod := range m.modifiers {
+		if c.enabled[mod.Name] {
+			mods = append(mods, mod.Name)
+		}
+	}
+	e := historyEntry{
+		Time:      time.Now(),
+		Profile:   m.selected.Name,
+		Input:     c.command,
+		Modifiers: mods,
+		Seed:      v.seed,
+		Output:    v.result.Output,
+	}
+	m.history = append(m.history, e)
+	if len(m.history) > maxHistory {
+		m.history = m.history[1:]
+	}
+	m.histCursor, m.histOffset = 0, 0
+	if m.historyFile != "" {
+		if err := appendHistory(m.historyFile, e); err != nil {
+			m.historyErr = err
+			m.historyFile = "" // report it once
+		}
+	}
+}
+
+// historyAt returns the entry on row i of the panel, which lists the newest
+// first.
+func (m *Model) historyAt(i int) (historyEntry, bool) {
+	if i < 0 || i >= len(m.history) {
+		return historyEntry{}, false
+	}
+	return m.history[len(m.history)-1-i], true
+}
+
+// reapplyHistory selects the entry's executable, command and modifiers and
+// runs it again with its seed. Configs edited since may change the output.
+func (m *Model) reapplyHistory() {
+	e, ok := m.historyAt(m.histCursor)
+	if !ok {
+		return
+	}
+	if m.selected == nil || !strings.EqualFold(m.selected.Name, e.Profile) {
+		if !m.jumpToExe(e.Profile) {
+			m.statusMsg = errorStyle.Render(e.Profile + " is no longer loaded")
+			return
+		}
+	}
+	m.cmdInput.SetValue(e.Input)
+	enabled := make(map[string]bool, len(m.modifiers))
+	for i := range m.modifiers {
+		m.modifiers[i].Enabled = slices.Contains(e.Modifiers, m.modifiers[i].Name)
+		enabled[m.modifiers[i].Name] = m.modifiers[i].Enabled
+	}
+	m.refreshPreviews()
+
+	c := m.newCarousel(e.Input, enabled)
+	if err := m.generateSeed(c, e.Seed); err != nil {
+		m.statusMsg = "error: " + err.Error()
+		return
+	}
+	m.variants = c
+	m.showVariant(c.ring[0])
+}
+
+// jumpToExe moves the sidebar cursor to the executable called name and
+// selects it, clearing the search and the OS tab when they hide it. It
+// returns false when no executable is called name.
+func (m *Model) jumpToExe(name string) bool {
+	idx := m.findExe(name)
+	if idx < 0 {
+		m.searchInput.SetValue("")
+		m.setOSFilter(osAll)
+		idx = m.findExe(name)
+	}
+	if idx < 0 {
+		return false
+	}
+	m.showRow(idx)
+	m.selectExe(idx)
+	return true
+}
+
+// copyHistory copies the output of the entry under the cursor.
+func (m *Model) copyHistory() {
+	e, ok := m.historyAt(m.histCursor)
+	if !ok {
+		return
+	}
+	if _, err := copyToClipboard(e.Output); err != nil {
+		m.statusMsg = errorStyle.Render("copy failed: " + err.Error())
+		return
+	}
+	m.statusMsg = "copied history entry"
+}
+
+// viewHistory renders the history rows for a panel width columns wide, which
+// leaves width-2 inside its padding, newest first.
+func (m Model) viewHistory(width int) string {
+	if len(m.history) == 0 {
+		lines := []string{dimStyle.Render("(nothing applied yet)")}
+		for len(lines) < historyRows {
+			lines = append(lines, "")
+		}
+		return strings.Join(lines, "\n")
+	}
+	var lines []string
+	for i := m.histOffset; i < m.histOffset+historyRows; i++ {
+		e, ok := m.historyAt(i)
+		if !ok {
+			lines = append(lines, "")
+			continue
+		}
+		row := fmt.Sprintf("%s  %-12s %s", e.Time.Format("15:04"), e.Profile, visible(e.Output))
+		if i == m.histCursor {
+			row = selectedStyle.Render("> ") + row
+		} else {
+			row = "  " + row
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(width-2).Render(row))
+	}
+	return strings.Join(lines, "\n")
+}
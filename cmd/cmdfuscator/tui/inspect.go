@@ -0,0 +1,283 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/models"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Token inspector ──────────────────────────────────────────────────────────
+
+// tokenSpan is where a token was read from in the input command.
+type tokenSpan struct{ start, end int }
+
+// tokenRow is one line of the token inspector.
+type tokenRow struct {
+	index     string
+	typ       models.TokenType
+	original  string
+	current   string
+	modifiers []string
+	span      tokenSpan // of the input token; zero for an inserted one
+}
+
+// toggleInspector switches the output panel between the highlighted output
+// and the token table.
+func (m *Model) toggleInspector() {
+	m.inspecting = !m.inspecting
+	m.inspectRow = 0
+	m.splitting = false
+	m.setOutputContent()
+	m.outputView.GotoTop()
+	switch {
+	case !m.inspecting:
+		m.statusMsg = "token inspector off"
+	case m.variants == nil:
+		m.statusMsg = "token inspector on: apply to see the tokens"
+	default:
+		m.statusMsg = "token inspector on: ↑↓ pick a token, f freezes it"
+	}
+}
+
+// moveInspectRow moves the token inspector's cursor by delta rows, scrolling
+// the output panel to keep the row in view.
+func (m *Model) moveInspectRow(delta int) {
+	if m.variants == nil {
+		return
+	}
+	rows := tokenRows(m.variants.ring[m.variants.cur].result)
+	m.inspectRow = max(min(m.inspectRow+delta, len(rows)-1), 0)
+	m.setOutputContent()
+	line := m.inspectRow + 1 // below the header
+	switch {
+	case line-1 < m.outputView.YOffset:
+		m.outputView.SetYOffset(line - 1)
+	case line >= m.outputView.YOffset+m.outputView.Height:
+		m.outputView.SetYOffset(line - m.outputView.Height + 1)
+	}
+}
+
+// toggleFreezeToken freezes the input token of the inspector's row, or
+// thaws it, and applies the command again with the variant's seed. Frozen
+// tokens are handed to the engine as by ObfuscateTokens (see frozenTokens)
+// and kept while the command stays the same.
+func (m *Model) toggleFreezeToken() {
+	if m.variants == nil {
+		m.statusMsg = "apply first to freeze its tokens"
+		return
+	}
+	if strings.TrimSpace(m.cmdInput.Value()) != m.variants.command {
+		m.statusMsg = "apply the edited command before freezing its tokens"
+		return
+	}
+	v := m.variants.ring[m.variants.cur]
+	rows := tokenRows(v.result)
+	if m.inspectRow >= len(rows) {
+		return
+	}
+	r := rows[m.inspectRow]
+	if r.span == (tokenSpan{}) {
+		m.statusMsg = "an inserted token has no input token to freeze"
+		return
+	}
+	if m.frozenInput != m.variants.command {
+		m.frozen, m.frozenInput = nil, m.variants.command
+	}
+	if m.frozen == nil {
+		m.frozen = make(map[tokenSpan]bool)
+	}
+	frozen := !m.frozen[r.span]
+	if frozen {
+		m.frozen[r.span] = true
+	} else {
+		delete(m.frozen, r.span)
+	}
+
+	before := m.variants
+	m.applyWithSeed(v.seed)
+	if m.variants == before {
+		return // the apply failed and said why
+	}
+	if frozen {
+		m.statusMsg = fmt.Sprintf("froze %s", visible(r.original))
+	} else {
+		m.statusMsg = fmt.Sprintf("thawed %s", visible(r.original))
+	}
+}
+
+// frozenTokens returns command tokenized by the selected profile with the
+// tokens frozen from the inspector marked Frozen, or nil when none of
+// command's are.
+func (m *Model) frozenTokens(command string) ([]models.Token, error) {
+	if len(m.frozen) == 0 || command != m.frozenInput {
+		return nil, nil
+	}
+	tokens, err := engine.Tokenize(command, m.selected.Profiles[0])
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range tokens {
+		if m.frozen[tokenSpan{t.Start, t.End}] {
+			tokens[i].Frozen = true
+		}
+	}
+	return tokens, nil
+}
+
+// toggleEscaped switches the output panel between the output with
+// placeholders for invisible characters and the output escaped as in a Go
+// string, which also tells tabs, control characters and backslashes apart
+// and can be checked against a payload character by character.
+func (m *Model) toggleEscaped() {
+	m.escaped = !m.escaped
+	m.setOutputContent()
+	if m.escaped {
+		m.statusMsg = `escaped output on: \u200c, \t, \\ and so on`
+	} else {
+		m.statusMsg = "escaped output off"
+	}
+}
+
+// setOutputContent fills the output viewport with the current variant,
+// highlighted, as a token table or split, for its current width.
+func (m *Model) setOutputContent() {
+	if m.variants == nil {
+		return
+	}
+	res := m.variants.ring[m.variants.cur].result
+	switch {
+	case m.inspecting:
+		m.outputView.SetContent(tokenTable(res, m.outputView.Width, m.inspectRow))
+	case m.splitting:
+		m.outputView.SetContent(splitView(res, m.outputView.Width, m.escaped))
+	default:
+		m.outputView.SetContent(highlightOutput(res, m.escaped))
+	}
+}
+
+// tokenRows lists the final tokens of res in output order, each with the
+// input token it descends from and the modifiers that changed it, followed
+// by the input tokens no final token descends from.
+func tokenRows(res engine.ObfuscateResult) []tokenRow {
+	touched := make(map[tokenSpan][]string)
+	var inserted []string // modifiers that added tokens
+	for _, step := range res.Trace {
+		if step.Err != nil {
+			continue
+		}
+		before := make(map[tokenSpan]models.Token, len(step.Before))
+		unspanned := 0
+		for _, t := range step.Before {
+			if t.HasSpan() {
+				before[tokenSpan{t.Start, t.End}] = t
+			} else {
+				unspanned++
+			}
+		}
+		for _, t := range step.After {
+			if !t.HasSpan() {
+				unspanned--
+				continue
+			}
+			s := tokenSpan{t.Start, t.End}
+			if b, ok := before[s]; ok && b != t {
+				touched[s] = append(touched[s], step.Modifier)
+			}
+		}
+		if unspanned < 0 {
+			inserted = append(inserted, step.Modifier)
+		}
+	}
+
+	rows := make([]tokenRow, 0, len(res.Segments))
+	kept := make(map[tokenSpan]bool, len(res.Segments))
+	for i, seg := range res.Segments {
+		r := tokenRow{
+			index:    fmt.Sprint(i),
+			typ:      seg.Token.Type,
+			original: seg.Original,
+			current:  res.Output[seg.Start:seg.End],
+		}
+		switch {
+		case seg.Inserted():
+			r.modifiers = inserted
+		default:
+			s := tokenSpan{seg.Token.Start, seg.Token.End}
+			kept[s] = true
+			r.modifiers = touched[s]
+			r.span = s
+		}
+		if seg.Token.Frozen {
+			r.modifiers = append([]string{"(frozen)"}, r.modifiers...)
+		}
+		rows = append(rows, r)
+	}
+	if len(res.Trace) > 0 {
+		for _, t := range res.Trace[0].Before {
+			if t.HasSpan() && !kept[tokenSpan{t.Start, t.End}] {
+				s := tokenSpan{t.Start, t.End}
+				rows = append(rows, tokenRow{index: "-", typ: t.Type, original: t.Value, modifiers: touched[s], span: s})
+			}
+		}
+	}
+	return rows
+}
+
+// tokenTable renders the rows of res as a table width columns wide, with a
+// cursor at row cursor. Inserted tokens have no original and dropped ones no
+// current value.
+func tokenTable(res engine.ObfuscateResult, width, cursor int) string {
+	rows := tokenRows(res)
+	if len(rows) == 0 {
+		return dimStyle.Render("(no tokens)")
+	}
+	const curW, idxW, typeW, gap = 2, 3, 9, "  "
+	valW := max((width-curW-idxW-typeW-4*len(gap))*3/10, 4)
+	modW := max(width-curW-idxW-typeW-2*valW-4*len(gap), 4)
+
+	lines := []string{sectionStyle.Render(strings.Repeat(" ", curW) + strings.Join([]string{
+		cell("#", idxW), cell("type", typeW), cell("original", valW), cell("current", valW), cell("modifiers", modW),
+	}, gap))}
+	for i, r := range rows {
+		original := cell(visible(r.original), valW)
+		if r.original == "" {
+			original = dimStyle.Render(cell("(inserted)", valW))
+		}
+		current := cell(visible(r.current), valW)
+		switch {
+		case r.index == "-":
+			current = dimStyle.Render(cell("(dropped)", valW))
+		case r.current != r.original:
+			current = changedStyle.Render(current)
+		}
+		mods := dimStyle.Render(cell("-", modW))
+		if len(r.modifiers) > 0 {
+			mods = cell(strings.Join(r.modifiers, ", "), modW)
+		}
+		marker := "  "
+		if i == cursor {
+			marker = selectedStyle.Render("> ")
+		}
+		lines = append(lines, marker+strings.Join([]string{
+			cell(r.index, idxW), cell(string(r.typ), typeW), original, current, mods,
+		}, gap))
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(strings.Join(lines, "\n"))
+}
+
+// cell pads s to width columns, or cuts it to fit with an ellipsis.
+func cell(s string, width int) string {
+	if w := lipgloss.Width(s); w <= width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	out := string(runes) + "…"
+	return out + strings.Repeat(" ", max(width-lipgloss.Width(out), 0))
+}
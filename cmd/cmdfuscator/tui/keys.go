@@ -1,26 +1,57 @@
 package tui
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
 
 // keyMap defines all key bindings used by the TUI.
 type keyMap struct {
-	NextPanel  key.Binding
-	PrevPanel  key.Binding
-	Up         key.Binding
-	Down       key.Binding
-	Left       key.Binding
-	Right      key.Binding
-	Toggle     key.Binding
-	Apply      key.Binding
-	Copy       key.Binding
-	Reset      key.Binding
-	Search     key.Binding
-	Escape     key.Binding
-	Quit       key.Binding
+	NextPanel   key.Binding
+	PrevPanel   key.Binding
+	Up          key.Binding
+	Down        key.Binding
+	RecallPrev  key.Binding
+	RecallNext  key.Binding
+	Left        key.Binding
+	Right       key.Binding
+	Pin         key.Binding
+	Fold        key.Binding
+	Toggle      key.Binding
+	MoveUp      key.Binding
+	MoveDown    key.Binding
+	Freeze      key.Binding
+	FreezeToken key.Binding
+	Edit        key.Binding
+	Apply       key.Binding
+	NextVariant key.Binding
+	PrevVariant key.Binding
+	Reroll      key.Binding
+	Replay      key.Binding
+	Inspect     key.Binding
+	Escaped     key.Binding
+	Split       key.Binding
+	Open        key.Binding
+	Live        key.Binding
+	Batch       key.Binding
+	Copy        key.Binding
+	Export      key.Binding
+	Reset       key.Binding
+	Search      key.Binding
+	Escape      key.Binding
+	Quit        key.Binding
 }
 
-// keys is the global keyMap used throughout the TUI.
-var keys = keyMap{
+// keys is the global keyMap used throughout the TUI, defaultKeys with the
+// settings file's [keys] laid over it.
+var keys = defaultKeys
+
+// defaultKeys are the built-in bindings.
+var defaultKeys = keyMap{
 	NextPanel: key.NewBinding(
 		key.WithKeys("tab"),
 		key.WithHelp("Tab", "next panel"),
@@ -37,6 +68,14 @@ var keys = keyMap{
 		key.WithKeys("right", "l"),
 		key.WithHelp("→/l", "next OS filter"),
 	),
+	Pin: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "pin executable"),
+	),
+	Fold: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("Space", "fold platform group"),
+	),
 	Up: key.NewBinding(
 		key.WithKeys("up", "k"),
 		key.WithHelp("↑/k", "up"),
@@ -45,18 +84,90 @@ var keys = keyMap{
 		key.WithKeys("down", "j"),
 		key.WithHelp("↓/j", "down"),
 	),
+	RecallPrev: key.NewBinding(
+		key.WithKeys("up"),
+		key.WithHelp("↑", "recall previous command"),
+	),
+	RecallNext: key.NewBinding(
+		key.WithKeys("down"),
+		key.WithHelp("↓", "recall next command"),
+	),
 	Toggle: key.NewBinding(
 		key.WithKeys(" "),
 		key.WithHelp("Space", "toggle modifier"),
 	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("shift+up"),
+		key.WithHelp("Shift+↑", "run modifier earlier"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("shift+down"),
+		key.WithHelp("Shift+↓", "run modifier later"),
+	),
+	Freeze: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "freeze URLs"),
+	),
+	FreezeToken: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "freeze token"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit modifier config"),
+	),
 	Apply: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("Enter", "apply obfuscation"),
 	),
+	NextVariant: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next variant"),
+	),
+	PrevVariant: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "previous variant"),
+	),
+	Reroll: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "re-roll with a new seed"),
+	),
+	Replay: key.NewBinding(
+		key.WithKeys("G"),
+		key.WithHelp("G", "re-apply with the same seed"),
+	),
+	Inspect: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "toggle token inspector"),
+	),
+	Escaped: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "toggle escaped output"),
+	),
+	Split: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "toggle split view"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open a profile directory"),
+	),
+	Live: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "toggle live mode"),
+	),
+	Batch: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "batch mode"),
+	),
 	Copy: key.NewBinding(
 		key.WithKeys("c"),
 		key.WithHelp("c", "copy output"),
 	),
+	Export: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export output as a script"),
+	),
 	Reset: key.NewBinding(
 		key.WithKeys("r"),
 		key.WithHelp("r", "reset output"),
@@ -74,3 +185,127 @@ var keys = keyMap{
 		key.WithHelp("q", "quit"),
 	),
 }
+
+// ─── Remapping ────────────────────────────────────────────────────────────────
+
+// actions maps the names the settings file's [keys] table uses to the
+// bindings they set.
+func (k *keyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"next_panel":   &k.NextPanel,
+		"prev_panel":   &k.PrevPanel,
+		"up":           &k.Up,
+		"down":         &k.Down,
+		"recall_prev":  &k.RecallPrev,
+		"recall_next":  &k.RecallNext,
+		"left":         &k.Left,
+		"right":        &k.Right,
+		"pin":          &k.Pin,
+		"fold":         &k.Fold,
+		"toggle":       &k.Toggle,
+		"move_up":      &k.MoveUp,
+		"move_down":    &k.MoveDown,
+		"freeze":       &k.Freeze,
+		"freeze_token": &k.FreezeToken,
+		"edit":         &k.Edit,
+		"apply":        &k.Apply,
+		"next_variant": &k.NextVariant,
+		"prev_variant": &k.PrevVariant,
+		"reroll":       &k.Reroll,
+		"replay":       &k.Replay,
+		"inspect":      &k.Inspect,
+		"escaped":      &k.Escaped,
+		"split":        &k.Split,
+		"open":         &k.Open,
+		"live":         &k.Live,
+		"batch":        &k.Batch,
+		"copy":         &k.Copy,
+		"export":       &k.Export,
+		"reset":        &k.Reset,
+		"search":       &k.Search,
+		"escape":       &k.Escape,
+		"quit":         &k.Quit,
+	}
+}
+
+// actionPanel names the panel of the actions bound in one panel only. There
+// they win over a global action with the same key, the way Edit's "e" wins
+// over Export's in the options panel; elsewhere the key is free.
+var actionPanel = map[string]string{
+	"recall_prev":  "input",
+	"recall_next":  "input",
+	"left":         "sidebar",
+	"right":        "sidebar",
+	"pin":          "sidebar",
+	"fold":         "sidebar",
+	"search":       "sidebar",
+	"toggle":       "options",
+	"move_up":      "options",
+	"move_down":    "options",
+	"freeze":       "options",
+	"edit":         "options",
+	"freeze_token": "output",
+}
+
+// remap binds the actions in remaps, by name, to their keys, keeping the
+// help text. It fails on an unknown action, an empty key list, Ctrl+C on
+// anything but quit, since it always quits, and a key bound to two actions
+// that are active at the same time, and then leaves k as it was.
+func (k *keyMap) remap(remaps map[string][]string) error {
+	next := *k
+	actions := next.actions()
+	for _, name := range slices.Sorted(maps.Keys(remaps)) {
+		ks := remaps[name]
+		b, ok := actions[name]
+		if !ok {
+			return fmt.Errorf("unknown action %q", name)
+		}
+		if len(ks) == 0 || slices.Contains(ks, "") {
+			return fmt.Errorf("%s: empty key", name)
+		}
+		if name != "quit" && slices.Contains(ks, "ctrl+c") {
+			return fmt.Errorf("%s: ctrl+c always quits", name)
+		}
+		b.SetKeys(ks...)
+		b.SetHelp(strings.Join(ks, "/"), b.Help().Desc)
+	}
+
+	bound := make(map[string]string) // panel and key → action
+	for _, name := range slices.Sorted(maps.Keys(actions)) {
+		for _, s := range actions[name].Keys() {
+			slot := actionPanel[name] + " " + s
+			if other, ok := bound[slot]; ok {
+				return fmt.Errorf("%q is bound to both %s and %s", s, other, name)
+			}
+			bound[slot] = name
+		}
+	}
+	*k = next
+	return nil
+}
+
+// remapped reports whether any of the named actions is bound to other keys
+// than its default ones.
+func remapped(names ...string) bool {
+	current, defaults := keys.actions(), defaultKeys.actions()
+	for _, name := range names {
+		if !slices.Equal(current[name].Keys(), defaults[name].Keys()) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyLabel is label, the status bar's name for the keys of the named actions,
+// unless the settings file rebound one of them; then it is their help keys.
+func keyLabel(label string, names ...string) string {
+	if !remapped(names...) {
+		return label
+	}
+	actions := keys.actions()
+	helps := make([]string, len(names))
+	for i, name := range names {
+		helps[i] = actions[name].Help().Key
+	}
+	return strings.Join(helps, " ")
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Live mode ────────────────────────────────────────────────────────────────
+
+// liveDelay is how long live mode waits after the last change to the command
+// or the modifiers before applying, so typing runs the engine once.
+const liveDelay = 250 * time.Millisecond
+
+// liveMsg asks for a live apply; gen tells a stale one from the latest.
+type liveMsg struct{ gen int }
+
+// toggleLive switches live mode, which applies the command whenever it or
+// the modifiers change, on or off.
+func (m *Model) toggleLive() {
+	m.live = !m.live
+	if m.live {
+		m.statusMsg = "live mode on: changes apply as you make them"
+		m.changed()
+	} else {
+		m.statusMsg = "live mode off"
+	}
+}
+
+// changed notes that the command or the modifiers changed. In live mode the
+// Update handling the change schedules an apply with withLive.
+func (m *Model) changed() {
+	if m.live {
+		m.liveGen++
+		m.liveDue = true
+	}
+}
+
+// withLive adds the delayed apply a change in live mode asks for to the
+// result of an Update.
+func withLive(model tea.Model, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	m, ok := model.(Model)
+	if !ok || !m.liveDue {
+		return model, cmd
+	}
+	m.liveDue = false
+	gen := m.liveGen
+	return m, tea.Batch(cmd, tea.Tick(liveDelay, func(time.Time) tea.Msg { return liveMsg{gen} }))
+}
+
+// applyLive applies the command for live mode. There is nothing to report
+// while the command is still empty, and its variants stay out of the history.
+func (m *Model) applyLive(msg liveMsg) {
+	if !m.live || msg.gen != m.liveGen {
+		return
+	}
+	if m.selected == nil || strings.TrimSpace(m.cmdInput.Value()) == "" {
+		return
+	}
+	m.run(0, true)
+}
+
+// liveLabel is the options panel header's note that live mode is on.
+func liveLabel(live bool) string {
+	if !live {
+		return ""
+	}
+	return "  " + copyStyle.Render("LIVE")
+}
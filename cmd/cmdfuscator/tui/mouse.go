@@ -0,0 +1,134 @@
+package tui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// ─── Mouse ────────────────────────────────────────────────────────────────────
+
+// Where things are drawn, in lines from the top of the body and columns from
+// the left of a panel, as View lays them out.
+const (
+	headerLines  = 1 // viewHeader
+	panelInsetX  = 2 // border and padding left of a panel's content
+	sidebarTabsY = 1 // the OS filter tabs, below the sidebar's border
+	sidebarListY = 4 // the first executable, after the tabs, search and gap
+	osTabWidth   = 5 // "[All]", "[Win]", …
+)
+
+// handleMouse focuses the panel clicked in and acts on what was clicked:
+// an OS tab, the search bar, an executable, a modifier or a history entry.
+// A click on a narrow terminal's selector line focuses the sidebar.
+// Other mouse events, the wheel included, go to the focused widget. On the
+// batch screen they all go to the results.
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.batching {
+		var cmd tea.Cmd
+		m.batch.results, cmd = m.batch.results.Update(msg)
+		return m, cmd
+	}
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return m.updateFocusedWidget(msg)
+	}
+	if m.searching {
+		m.searching = false
+		m.searchInput.Blur()
+	}
+	m.opening = false
+	x, y := msg.X, msg.Y-headerLines
+	if y < 0 || y >= m.bodyHeight() {
+		return m, nil
+	}
+	if x < m.sidebarCols() {
+		m.focus(panelSidebar)
+		m.clickSidebar(x-panelInsetX, y)
+		return m, nil
+	}
+	if y < m.selectorRows() {
+		m.focus(panelSidebar)
+		return m, nil
+	}
+	p, row := m.panelAt(y - m.selectorRows())
+	if p == panelCount {
+		return m, nil
+	}
+	m.focus(p)
+	col := x - m.sidebarCols() - panelInsetX
+	switch p {
+	case panelOptions:
+		m.clickModifier(col, row)
+	case panelHistory:
+		if i := m.histOffset + row; row >= 0 && i < len(m.history) {
+			m.histCursor = i
+		}
+	}
+	return m, nil
+}
+
+// focus moves the focus to p.
+func (m *Model) focus(p panel) {
+	m.focused = p
+	m.syncFocusToWidget()
+}
+
+// panelAt returns the panel of the main area drawn on body line y, with the
+// row of its content y falls on: 0 for the first line below its section
+// label, negative on the label or the top border. It returns panelCount on
+// the gaps and the status line.
+func (m *Model) panelAt(y int) (panel, int) {
+	heights := []struct {
+		p panel
+		h int // content lines below the section label
+	}{
+		{panelInput, 1},
+		{panelOptions, m.optModifierRows()},
+		{panelOutput, m.warningRows() + m.outputViewHeight() + 2 + rawFixedH + m.detectionRows()},
+		{panelHistory, historyRows},
+	}
+	top := 0
+	for _, b := range heights {
+		if y >= top && y < top+b.h+3 {
+			return b.p, y - top - 2
+		}
+		top += b.h + 3 + 1 // label, borders and the gap below
+	}
+	return panelCount, 0
+}
+
+// clickSidebar selects the OS tab or executable at column x of the sidebar's
+// content on body line y, folds or unfolds the group of a header, or starts
+// a search on the search bar.
+func (m *Model) clickSidebar(x, y int) {
+	switch {
+	case y == sidebarTabsY:
+		if i := x / osTabWidth; x >= 0 && i < len(osLabels) {
+			m.setOSFilter(osFilter(i))
+		}
+	case y == sidebarTabsY+1:
+		m.searching = true
+		m.searchInput.Focus()
+	case y >= sidebarListY:
+		if i := m.exeOffset + y - sidebarListY; i < len(m.rows) && y-sidebarListY < m.sidebarListHeight() {
+			m.exeCursor = i
+			if m.rows[i].pf == nil {
+				m.toggleGroup()
+			} else {
+				m.selectExe(i)
+			}
+		}
+	}
+}
+
+// clickModifier moves the cursor to the modifier drawn at column x of grid
+// row row and toggles it. The config editor ignores clicks.
+func (m *Model) clickModifier(x, row int) {
+	if m.editor != nil || row < 0 || x < 0 {
+		return
+	}
+	i := row * 2
+	if x >= (m.panelContentWidth()-2)/2 {
+		i++
+	}
+	if i < len(m.modifiers) {
+		m.modCursor = i
+		m.toggleModifier()
+	}
+}
@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Narrow terminals ─────────────────────────────────────────────────────────
+
+// narrowWidth is the terminal width below which the sidebar gives way to a
+// selector line above the panels, which then take the full width.
+const narrowWidth = 80
+
+// narrow reports whether the terminal is too narrow for the sidebar.
+func (m *Model) narrow() bool {
+	return m.width < narrowWidth
+}
+
+// sidebarCols is how many columns the sidebar takes: none in a narrow
+// terminal.
+func (m *Model) sidebarCols() int {
+	if m.narrow() {
+		return 0
+	}
+	return sidebarWidth
+}
+
+// selectorRows is how many lines the selector line takes: one in a narrow
+// terminal.
+func (m *Model) selectorRows() int {
+	if m.narrow() {
+		return 1
+	}
+	return 0
+}
+
+// viewSelector renders the sidebar as one line for a narrow terminal: the
+// OS tab, the row under the cursor and its place in the list, and the
+// search bar while a search is on. The sidebar's keys work on it as they do
+// on the sidebar.
+func (m Model) viewSelector() string {
+	focused := m.focused == panelSidebar
+	tabStyle := inactiveTabStyle
+	if focused {
+		tabStyle = activeTabStyle
+	}
+	line := tabStyle.Render("["+osLabels[m.osFilter]+"]") + " "
+
+	switch {
+	case len(m.rows) == 0:
+		line += dimStyle.Render("(no results)")
+	case m.rows[m.exeCursor].pf == nil:
+		line += m.viewGroupHeader(m.rows[m.exeCursor], focused)
+	default:
+		pf := m.rows[m.exeCursor].pf
+		style := normalStyle
+		if focused {
+			style = selectedStyle
+		}
+		line += highlightMatch(pf.Name, m.matches[pf], style)
+		if m.pinned(pf) {
+			line += pinStyle.Render(pinMark)
+		}
+	}
+	if len(m.rows) > 0 {
+		line += dimStyle.Render(fmt.Sprintf("  %d/%d", m.exeCursor+1, len(m.rows)))
+	}
+
+	switch {
+	case m.searching || strings.TrimSpace(m.searchInput.Value()) != "":
+		line += "  " + dimStyle.Render("/") + " " + m.searchInput.View()
+	case focused:
+		line += dimStyle.Render(fmt.Sprintf("  %s pick  %s OS  %s search",
+			keyLabel("↑↓", "up", "down"), keyLabel("←→", "left", "right"), keyLabel("/", "search")))
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+}
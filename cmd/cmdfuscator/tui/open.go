@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"cmdFuscator/loader"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Opening profile directories ──────────────────────────────────────────────
+
+// openPrompt starts the prompt for a profile directory to open.
+func (m *Model) openPrompt() {
+	in := textinput.New()
+	in.Prompt = "open profile dir: "
+	in.Placeholder = "~/work/profiles"
+	in.CharLimit = 1024
+	in.Width = max(m.mainWidth()-lipgloss.Width(in.Prompt)-1, 8)
+	in.Focus()
+	m.openInput = in
+	m.opening = true
+}
+
+func (m Model) handleOpenKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Escape):
+		m.opening = false
+	case key.Matches(msg, keys.Apply):
+		m.opening = false
+		return m, m.openProfileDir(m.openInput.Value())
+	default:
+		var cmd tea.Cmd
+		m.openInput, cmd = m.openInput.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// profileDirs returns the overlay directories in effect: the settings
+// file's, then those opened, later ones winning.
+func (m *Model) profileDirs() []string {
+	return append(slices.Clone(m.cfg.Dirs()), m.opened...)
+}
+
+// openProfileDir lays the profiles in dir over the current set, as the last
+// overlay directory, and watches it along with the others. The command it
+// returns waits for the new watcher's first reload.
+func (m *Model) openProfileDir(dir string) tea.Cmd {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil
+	}
+	dir, err := filepath.Abs(expandHome(dir))
+	if err != nil {
+		m.statusMsg = errorStyle.Render("open: " + err.Error())
+		return nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		m.statusMsg = errorStyle.Render("open: " + dir + " is not a directory")
+		return nil
+	}
+	if slices.Contains(m.profileDirs(), dir) {
+		m.statusMsg = dir + " is already loaded"
+		return nil
+	}
+
+	dirs := append(m.profileDirs(), dir)
+	profiles, rep, err := loader.LoadWithOverlayVerified(m.base, m.verifier, dirs...)
+	if err != nil {
+		m.statusMsg = errorStyle.Render("open: " + err.Error())
+		return nil
+	}
+	m.opened = append(m.opened, dir)
+	before := len(m.allExes)
+	m.applyReload(loader.Reload{Profiles: profiles, Report: rep})
+	m.statusMsg = fmt.Sprintf("opened %s: %d executables (%+d)", dir, len(m.allExes), len(m.allExes)-before)
+	if sum := rep.Summary(); sum != "" {
+		m.statusMsg += "  |  " + sum
+	}
+	m.watch(dirs)
+	return waitReload(m.reloads)
+}
+
+// expandHome replaces a leading ~ in path with the home directory.
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// watch replaces the overlay watcher with one on those of dirs that exist.
+// A missing directory simply means there is nothing to watch there.
+func (m *Model) watch(dirs []string) {
+	if m.watcher != nil {
+		m.watcher.Close()
+		m.watcher, m.reloads = nil, nil
+	}
+	if dirs = existingDirs(dirs); len(dirs) > 0 {
+		if w, err := loader.WatchVerified(m.base, m.verifier, dirs...); err == nil {
+			m.watcher, m.reloads = w, w.Subscribe()
+		}
+	}
+}
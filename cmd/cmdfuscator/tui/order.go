@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"cmdFuscator/engine"
+)
+
+// ─── Modifier order ───────────────────────────────────────────────────────────
+
+// moveModifier moves the modifier under the cursor delta places through the
+// options panel, whose order is the order the modifiers run in, and takes
+// the cursor along.
+func (m *Model) moveModifier(delta int) {
+	i, j := m.modCursor, m.modCursor+delta
+	if i < 0 || i >= len(m.modifiers) || j < 0 || j >= len(m.modifiers) {
+		return
+	}
+	m.modifiers[i], m.modifiers[j] = m.modifiers[j], m.modifiers[i]
+	m.modCursor = j
+	m.eng = engine.New(m.engineOptions()...)
+	m.statusMsg = fmt.Sprintf("%s now runs %d of %d", m.modifiers[j].Name, j+1, len(m.modifiers))
+	m.changed()
+}
+
+// modifierOrder names the options panel's modifiers in the order they run.
+func (m *Model) modifierOrder() []string {
+	names := make([]string, len(m.modifiers))
+	for i, mod := range m.modifiers {
+		names[i] = mod.Name
+	}
+	return names
+}
+
+// keepOrder sorts mods, fresh from engine.ModifierSummary, into the order of
+// prev, the options panel's modifiers before, so that selecting another
+// executable keeps the order the user chose. Modifiers not in prev go last.
+func keepOrder(mods, prev []engine.ModifierInfo) []engine.ModifierInfo {
+	rank := make(map[string]int, len(prev))
+	for i, mod := range prev {
+		rank[mod.Name] = i
+	}
+	slices.SortStableFunc(mods, func(a, b engine.ModifierInfo) int {
+		ra, aok := rank[a.Name]
+		rb, bok := rank[b.Name]
+		switch {
+		case aok && bok:
+			return ra - rb
+		case aok:
+			return -1
+		case bok:
+			return 1
+		}
+		return 0
+	})
+	return mods
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	"cmdFuscator/engine"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Paste detection ──────────────────────────────────────────────────────────
+
+// flashDelay is how long the sidebar highlights the executable a pasted
+// command selected.
+const flashDelay = 1500 * time.Millisecond
+
+// flashMsg ends a flash; gen tells a stale one from the latest.
+type flashMsg struct{ gen int }
+
+// detectPasted selects the executable the command just pasted into the
+// input starts with, keeping the command, and flashes it in the sidebar. It
+// does nothing when that executable is already selected or has no profile.
+func (m *Model) detectPasted() tea.Cmd {
+	cmd := m.cmdInput.Value()
+	pf, ok := engine.DetectProfile(cmd, m.allExes)
+	if !ok || m.selected != nil && strings.EqualFold(pf.Name, m.selected.Name) {
+		return nil
+	}
+	if !m.jumpToExe(pf.Name) {
+		return nil
+	}
+	m.cmdInput.SetValue(cmd)
+	m.cmdInput.CursorEnd()
+	m.statusMsg = "selected " + pf.Name + " for the pasted command"
+
+	m.flashGen++
+	m.flashName = pf.Name
+	gen := m.flashGen
+	return tea.Tick(flashDelay, func(time.Time) tea.Msg { return flashMsg{gen} })
+}
+
+// endFlash stops highlighting the flashed executable, unless a later paste
+// flashed one again.
+func (m *Model) endFlash(msg flashMsg) {
+	if msg.gen == m.flashGen {
+		m.flashName = ""
+	}
+}
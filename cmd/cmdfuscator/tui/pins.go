@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"cmdFuscator/models"
+)
+
+// ─── Pinned executables ───────────────────────────────────────────────────────
+
+// pinMark follows the name of a pinned executable in the sidebar.
+const pinMark = " *"
+
+// loadPins returns the executables pinned in the pins file at path, by
+// lower-cased name. A missing file pins none.
+func loadPins(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return map[string]bool{}, err
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return map[string]bool{}, err
+	}
+	pins := make(map[string]bool, len(names))
+	for _, name := range names {
+		pins[strings.ToLower(name)] = true
+	}
+	return pins, nil
+}
+
+// savePins writes pins to the pins file at path as a sorted JSON list,
+// creating its directory as needed.
+func savePins(path string, pins map[string]bool) error {
+	data, err := json.Marshal(slices.Sorted(maps.Keys(pins)))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// pinned reports whether pf is pinned to the top of the sidebar.
+func (m *Model) pinned(pf *models.ProfileFile) bool {
+	return m.pins[strings.ToLower(pf.Name)]
+}
+
+// togglePin pins the executable under the sidebar cursor, or unpins it, and
+// saves the pins. The cursor follows it to its new place in the list.
+func (m *Model) togglePin() {
+	if m.exeCursor < 0 || m.exeCursor >= len(m.rows) || m.rows[m.exeCursor].pf == nil {
+		return
+	}
+	pf := m.rows[m.exeCursor].pf
+	name := strings.ToLower(pf.Name)
+	if m.pins[name] {
+		delete(m.pins, name)
+		m.statusMsg = "unpinned " + pf.Name
+	} else {
+		m.pins[name] = true
+		m.statusMsg = "pinned " + pf.Name
+	}
+	if m.pinsFile != "" {
+		if err := savePins(m.pinsFile, m.pins); err != nil {
+			m.statusMsg += errorStyle.Render("; not saved: " + err.Error())
+		}
+	}
+
+	m.applyFilter()
+	if i := m.findExe(pf.Name); i >= 0 {
+		m.showRow(i)
+	}
+}
@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Modifier previews ────────────────────────────────────────────────────────
+
+// previewSeed seeds every preview run, so a preview changes only with the
+// command, the profile or the modifier's config, not on every keystroke.
+const previewSeed = 1
+
+// minPreviewWidth is the narrowest preview worth showing next to a checkbox.
+const minPreviewWidth = 6
+
+// modifierPreview is the command with one modifier applied on its own.
+type modifierPreview struct {
+	output string
+	err    error // modifiers.ErrNotImplemented for a stub
+}
+
+// refreshPreviews reruns every modifier of the options panel alone on the
+// current command, all with previewSeed, plus a run of none for the
+// previews to be compared with.
+func (m *Model) refreshPreviews() {
+	m.previews, m.previewBase = nil, ""
+	cmd := strings.TrimSpace(m.cmdInput.Value())
+	if m.selected == nil || cmd == "" || len(m.modifiers) == 0 {
+		return
+	}
+	items := make([]engine.BatchItem, 0, len(m.modifiers)+1)
+	items = append(items, engine.BatchItem{Command: cmd, Profile: m.selected, Enabled: map[string]bool{}, Seed: previewSeed})
+	for _, mod := range m.modifiers {
+		items = append(items, engine.BatchItem{Command: cmd, Profile: m.selected, Enabled: map[string]bool{mod.Name: true}, Seed: previewSeed})
+	}
+	results := m.eng.ObfuscateBatch(context.Background(), items)
+	if results[0].Err != nil {
+		return // the command does not tokenize; applying says why
+	}
+	m.previewBase = results[0].Result.Output
+	m.previews = make(map[string]modifierPreview, len(m.modifiers))
+	for i, mod := range m.modifiers {
+		r := results[i+1]
+		p := modifierPreview{output: r.Result.Output}
+		switch {
+		case r.Err != nil:
+			p.err = r.Err
+		case r.Result.Errors[mod.Name] != nil:
+			p.err = r.Result.Errors[mod.Name]
+		case slices.Contains(r.Result.Skipped, mod.Name):
+			p.err = modifiers.ErrNotImplemented
+		case !slices.Contains(r.Result.Applied, mod.Name):
+			continue // the profile does not configure it
+		}
+		m.previews[mod.Name] = p
+	}
+}
+
+// previewText renders the preview of modifier in at most width columns, or
+// returns "" when there is none to show.
+func (m Model) previewText(modifier string, width int) string {
+	p, ok := m.previews[modifier]
+	switch {
+	case !ok || width < minPreviewWidth:
+		return ""
+	case errors.Is(p.err, modifiers.ErrNotImplemented):
+		return dimStyle.Render("(stub)")
+	case p.err != nil:
+		return errorStyle.Render("(error)")
+	case p.output == m.previewBase:
+		return dimStyle.Render("(no change)")
+	}
+	return dimStyle.Render(previewWindow(visible(p.output), visible(m.previewBase), width))
+}
+
+// visible replaces the characters escapeInvisible marks with a middle dot,
+// which is one column wide.
+func visible(s string) string {
+	return strings.Map(func(r rune) rune {
+		if invisible(r) {
+			return '·'
+		}
+		return r
+	}, s)
+}
+
+// previewWindow returns at most width columns of out. When the first rune
+// that differs from base would fall near or past the right edge, the window
+// starts a little before it instead, so the change is in view. Cut ends are
+// marked with an ellipsis.
+func previewWindow(out, base string, width int) string {
+	o, b := []rune(out), []rune(base)
+	diff := 0
+	for diff < len(o) && diff < len(b) && o[diff] == b[diff] {
+		diff++
+	}
+	var s strings.Builder
+	start := 0
+	if diff > width-minPreviewWidth {
+		start = diff - width/3
+		s.WriteString("…")
+	}
+	for i := start; i < len(o); i++ {
+		// Keep a column for the ellipsis unless this is the last rune.
+		if w := lipgloss.Width(s.String() + string(o[i])); w > width || w == width && i < len(o)-1 {
+			s.WriteString("…")
+			break
+		}
+		s.WriteRune(o[i])
+	}
+	return s.String()
+}
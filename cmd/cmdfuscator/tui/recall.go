@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ─── Input recall ─────────────────────────────────────────────────────────────
+
+// maxRecall is how many applied commands are kept per executable.
+const maxRecall = 50
+
+// loadRecalls returns the commands applied to each executable, by
+// lower-cased name and oldest first, from the inputs file at path. A missing
+// file has none.
+func loadRecalls(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string][]string{}, nil
+	}
+	if err != nil {
+		return map[string][]string{}, err
+	}
+	recalls := map[string][]string{}
+	if err := json.Unmarshal(data, &recalls); err != nil {
+		return map[string][]string{}, err
+	}
+	return recalls, nil
+}
+
+// saveRecalls writes recalls to the inputs file at path, creating its
+// directory as needed.
+func saveRecalls(path string, recalls map[string][]string) error {
+	data, err := json.MarshalIndent(recalls, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// rememberInput records cmd as the newest command applied to the selected
+// executable, moving it there if it was applied before, and saves it.
+func (m *Model) rememberInput(cmd string) {
+	m.recallBack = 0
+	if m.selected == nil {
+		return
+	}
+	name := strings.ToLower(m.selected.Name)
+	list := slices.DeleteFunc(m.recalls[name], func(s string) bool { return s == cmd })
+	list = append(list, cmd)
+	m.recalls[name] = list[max(len(list)-maxRecall, 0):]
+	if m.recallsFile != "" {
+		if err := saveRecalls(m.recallsFile, m.recalls); err != nil {
+			m.statusMsg += "  |  " + errorStyle.Render("inputs not saved: "+err.Error())
+			m.recallsFile = "" // report it once
+		}
+	}
+}
+
+// recall steps back, by a delta of 1, or forward, by -1, through the
+// commands applied to the selected executable, as a shell's history does.
+// Stepping forward past the newest brings back what was being typed.
+func (m *Model) recall(delta int) {
+	if m.selected == nil {
+		return
+	}
+	list := m.recalls[strings.ToLower(m.selected.Name)]
+	back := m.recallBack + delta
+	if back < 0 || back > len(list) {
+		return
+	}
+	if m.recallBack == 0 {
+		m.recallDraft = m.cmdInput.Value()
+	}
+	m.recallBack = back
+	if back == 0 {
+		m.cmdInput.SetValue(m.recallDraft)
+	} else {
+		m.cmdInput.SetValue(list[len(list)-back])
+	}
+	m.cmdInput.CursorEnd()
+	m.refreshPreviews()
+	m.changed()
+}
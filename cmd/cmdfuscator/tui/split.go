@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"strings"
+
+	"cmdFuscator/engine"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Split view ───────────────────────────────────────────────────────────────
+
+// splitGutter separates the original and obfuscated columns.
+const splitGutter = " │ "
+
+// toggleSplit switches the output panel between the output on one line and
+// the original and obfuscated commands side by side. The token inspector
+// and the split view take turns.
+func (m *Model) toggleSplit() {
+	m.splitting = !m.splitting
+	m.inspecting = false
+	m.setOutputContent()
+	m.outputView.GotoTop()
+	switch {
+	case !m.splitting:
+		m.statusMsg = "split view off"
+	case m.variants == nil:
+		m.statusMsg = "split view on: apply to compare the commands"
+	default:
+		m.statusMsg = "split view on"
+	}
+}
+
+// splitView renders the tokens of res as two columns in width columns: the
+// input token each descends from on the left, and what it became on the
+// right, with the changed characters highlighted. A token too long for its
+// column wraps onto the rows below, with the other column's token kept
+// level with its first row. Escaped draws both as escapeRune does.
+func splitView(res engine.ObfuscateResult, width int, escaped bool) string {
+	rows := tokenRows(res)
+	if len(rows) == 0 {
+		return dimStyle.Render("(no tokens)")
+	}
+	show := placeholderRune
+	if escaped {
+		show = escapeRune
+	}
+	colW := max((width-lipgloss.Width(splitGutter))/2, 4)
+	gutter := dimStyle.Render(splitGutter)
+
+	lines := []string{sectionStyle.Render(cell("original", colW)) + gutter + sectionStyle.Render("obfuscated")}
+	for _, r := range rows {
+		var left, right []string
+		if r.original == "" {
+			left = []string{dimStyle.Render("(inserted)")}
+		} else {
+			orig := []rune(r.original)
+			left = wrapHighlighted(orig, make([]bool, len(orig)), colW, show)
+		}
+		if r.index == "-" {
+			right = []string{dimStyle.Render("(dropped)")}
+		} else {
+			cur := []rune(r.current)
+			right = wrapHighlighted(cur, changedRunes(cur, []rune(r.original)), colW, show)
+		}
+		for i := range max(len(left), len(right)) {
+			var l, rt string
+			if i < len(left) {
+				l = left[i]
+			}
+			if i < len(right) {
+				rt = right[i]
+			}
+			lines = append(lines, cell(l, colW)+gutter+rt)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapHighlighted renders runes as highlightRunes does, cut into lines of at
+// most width columns.
+func wrapHighlighted(runes []rune, changed []bool, width int, show func(rune) string) []string {
+	var lines []string
+	start, w := 0, 0
+	for i, r := range runes {
+		s := show(r)
+		if s == "" {
+			s = string(r)
+		}
+		rw := lipgloss.Width(s)
+		if w+rw > width && i > start {
+			lines = append(lines, highlightRunes(runes[start:i], changed[start:i], show))
+			start, w = i, 0
+		}
+		w += rw
+	}
+	return append(lines, highlightRunes(runes[start:], changed[start:], show))
+}
@@ -1,30 +1,127 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"os"
 
-// ─── Colour palette ───────────────────────────────────────────────────────────
+	"cmdFuscator/cmd/cmdfuscator/config"
 
-var (
-	clrGreen    = lipgloss.Color("#00FF88")
-	clrGold     = lipgloss.Color("#FFD700")
-	clrGray     = lipgloss.Color("#888888")
-	clrDimGray  = lipgloss.Color("#444444")
-	clrWhite    = lipgloss.Color("#DDDDDD")
-	clrBorder   = lipgloss.Color("#334455")
-	clrFocusBdr = lipgloss.Color("#00FF88")
-	clrRed      = lipgloss.Color("#FF4455")
-	clrCyan     = lipgloss.Color("#00CCFF")
+	"github.com/charmbracelet/lipgloss"
 )
 
+// ─── Colour palette ───────────────────────────────────────────────────────────
+
+// palette is a theme's colours, by what they are drawn on; the names match
+// config.ColorNames.
+type palette struct {
+	accent    lipgloss.TerminalColor
+	highlight lipgloss.TerminalColor
+	muted     lipgloss.TerminalColor
+	dim       lipgloss.TerminalColor
+	text      lipgloss.TerminalColor
+	border    lipgloss.TerminalColor
+	focus     lipgloss.TerminalColor
+	error     lipgloss.TerminalColor
+	key       lipgloss.TerminalColor
+	// mono draws no colour at all: the focused panel gets a thick border
+	// and dim text is faint instead.
+	mono bool
+}
+
+var themes = map[string]palette{
+	"dark": {
+		accent:    lipgloss.Color("#00FF88"),
+		highlight: lipgloss.Color("#FFD700"),
+		muted:     lipgloss.Color("#888888"),
+		dim:       lipgloss.Color("#444444"),
+		text:      lipgloss.Color("#DDDDDD"),
+		border:    lipgloss.Color("#334455"),
+		focus:     lipgloss.Color("#00FF88"),
+		error:     lipgloss.Color("#FF4455"),
+		key:       lipgloss.Color("#00CCFF"),
+	},
+	"light": {
+		accent:    lipgloss.Color("#007A3D"),
+		highlight: lipgloss.Color("#9A6700"),
+		muted:     lipgloss.Color("#666666"),
+		dim:       lipgloss.Color("#999999"),
+		text:      lipgloss.Color("#222222"),
+		border:    lipgloss.Color("#AABBCC"),
+		focus:     lipgloss.Color("#007A3D"),
+		error:     lipgloss.Color("#CC0022"),
+		key:       lipgloss.Color("#0064B4"),
+	},
+	"mono": {
+		accent:    lipgloss.NoColor{},
+		highlight: lipgloss.NoColor{},
+		muted:     lipgloss.NoColor{},
+		dim:       lipgloss.NoColor{},
+		text:      lipgloss.NoColor{},
+		border:    lipgloss.NoColor{},
+		focus:     lipgloss.NoColor{},
+		error:     lipgloss.NoColor{},
+		key:       lipgloss.NoColor{},
+		mono:      true,
+	},
+}
+
+// pal is the palette in use.
+var pal palette
+
+func init() {
+	setTheme(themes["dark"])
+}
+
+// themeFor returns the palette cfg asks for: mono when NO_COLOR is set,
+// otherwise its theme with its colours laid over it.
+func themeFor(cfg *config.Config) palette {
+	if os.Getenv("NO_COLOR") != "" {
+		return themes["mono"]
+	}
+	p, ok := themes[cfg.Theme]
+	if !ok {
+		p = themes["dark"]
+	}
+	if p.mono {
+		return p
+	}
+	for name, c := range cfg.Colors {
+		color := lipgloss.Color(c)
+		switch name {
+		case "accent":
+			p.accent = color
+		case "highlight":
+			p.highlight = color
+		case "muted":
+			p.muted = color
+		case "dim":
+			p.dim = color
+		case "text":
+			p.text = color
+		case "border":
+			p.border = color
+		case "focus":
+			p.focus = color
+		case "error":
+			p.error = color
+		case "key":
+			p.key = color
+		}
+	}
+	return p
+}
+
 // ─── Panel borders ────────────────────────────────────────────────────────────
 
 func panelStyle(focused bool) lipgloss.Style {
-	bdr := clrBorder
+	bdr, border := pal.border, lipgloss.RoundedBorder()
 	if focused {
-		bdr = clrFocusBdr
+		bdr = pal.focus
+		if pal.mono {
+			border = lipgloss.ThickBorder()
+		}
 	}
 	return lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
+		Border(border).
 		BorderForeground(bdr).
 		Padding(0, 1)
 }
@@ -32,81 +129,156 @@ func panelStyle(focused bool) lipgloss.Style {
 // ─── Text styles ──────────────────────────────────────────────────────────────
 
 var (
+	titleStyle       lipgloss.Style
+	subtitleStyle    lipgloss.Style
+	sectionStyle     lipgloss.Style
+	selectedStyle    lipgloss.Style
+	normalStyle      lipgloss.Style
+	dimStyle         lipgloss.Style
+	checkedStyle     lipgloss.Style
+	uncheckedStyle   lipgloss.Style
+	activeTabStyle   lipgloss.Style
+	inactiveTabStyle lipgloss.Style
+	keyStyle         lipgloss.Style
+	statusBarStyle   lipgloss.Style
+	copyStyle        lipgloss.Style
+	errorStyle       lipgloss.Style
+	notImplStyle     lipgloss.Style
+	rawEscapeStyle   lipgloss.Style
+	// changedStyle marks output characters a modifier changed or inserted.
+	changedStyle lipgloss.Style
+	// matchStyle marks the characters of a name the sidebar search matched.
+	matchStyle lipgloss.Style
+	// flashStyle briefly marks the executable a pasted command selected.
+	flashStyle lipgloss.Style
+	// pinStyle marks pinned executables in the sidebar.
+	pinStyle lipgloss.Style
+	// warningStyle is the strip of reasons the output may not run.
+	warningStyle lipgloss.Style
+)
+
+// setTheme makes p the palette in use and rebuilds the text styles with it.
+func setTheme(p palette) {
+	pal = p
+
 	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(clrGreen)
+		Bold(true).
+		Foreground(p.accent)
 
 	subtitleStyle = lipgloss.NewStyle().
-			Foreground(clrGray).
-			Italic(true)
+		Foreground(p.muted).
+		Italic(true)
 
 	sectionStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(clrGold)
+		Bold(true).
+		Foreground(p.highlight)
 
 	selectedStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(clrGreen)
+		Bold(true).
+		Foreground(p.accent)
 
 	normalStyle = lipgloss.NewStyle().
-			Foreground(clrWhite)
+		Foreground(p.text)
 
 	dimStyle = lipgloss.NewStyle().
-			Foreground(clrDimGray)
+		Foreground(p.dim).
+		Faint(p.mono)
 
 	checkedStyle = lipgloss.NewStyle().
-			Foreground(clrGreen)
+		Foreground(p.accent)
 
 	uncheckedStyle = lipgloss.NewStyle().
-			Foreground(clrDimGray)
+		Foreground(p.dim).
+		Faint(p.mono)
 
 	activeTabStyle = lipgloss.NewStyle().
-			Bold(true).
-			Underline(true).
-			Foreground(clrGreen)
+		Bold(true).
+		Underline(true).
+		Foreground(p.accent)
 
 	inactiveTabStyle = lipgloss.NewStyle().
-				Foreground(clrGray)
+		Foreground(p.muted)
 
 	keyStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(clrCyan)
+		Bold(true).
+		Foreground(p.key)
 
 	statusBarStyle = lipgloss.NewStyle().
-			Foreground(clrGray)
+		Foreground(p.muted)
 
 	copyStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(clrGold)
+		Bold(true).
+		Foreground(p.highlight)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(clrRed)
+		Foreground(p.error).
+		Bold(p.mono)
 
 	notImplStyle = lipgloss.NewStyle().
-			Foreground(clrGold).
-			Italic(true)
+		Foreground(p.highlight).
+		Italic(true)
 
 	rawEscapeStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(clrCyan)
-)
+		Bold(true).
+		Foreground(p.key)
 
-// statusBar renders the bottom help line.
+	changedStyle = lipgloss.NewStyle().
+		Foreground(p.highlight).
+		Underline(true)
+
+	matchStyle = lipgloss.NewStyle().
+		Bold(true).
+		Underline(p.mono).
+		Foreground(p.highlight)
+
+	flashStyle = lipgloss.NewStyle().
+		Bold(true).
+		Reverse(true).
+		Foreground(p.accent)
+
+	pinStyle = lipgloss.NewStyle().
+		Foreground(p.accent)
+
+	warningStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(p.highlight)
+}
+
+// statusBar renders the bottom help line. A terminal too narrow for the
+// sidebar gets the brief keys only.
 func renderStatusBar(width int) string {
-	keys := []struct{ key, action string }{
-		{"Tab", "Focus"},
-		{"↑↓", "Navigate"},
-		{"←→", "OS Filter"},
-		{"Space", "Toggle"},
-		{"Enter", "Apply"},
-		{"c", "Copy"},
-		{"r", "Reset"},
-		{"/", "Search"},
-		{"q", "Quit"},
+	keys := []struct {
+		key, action string
+		brief       bool
+	}{
+		{keyLabel("Tab", "next_panel"), "Focus", true},
+		{keyLabel("↑↓", "up", "down"), "Navigate", true},
+		{keyLabel("←→", "left", "right"), "OS Filter", false},
+		{keyLabel("*", "pin"), "Pin", false},
+		{keyLabel("Space", "toggle", "fold"), "Toggle/Fold", false},
+		{keyLabel("⇧↑↓", "move_up", "move_down"), "Reorder", false},
+		{keyLabel("f", "freeze", "freeze_token"), "Freeze", false},
+		{keyLabel("Enter", "apply"), "Apply", true},
+		{keyLabel("a", "live"), "Live", false},
+		{keyLabel("n/p", "next_variant", "prev_variant"), "Variants", false},
+		{keyLabel("g/G", "reroll", "replay"), "Re-roll/Replay", false},
+		{keyLabel("t", "inspect"), "Tokens", false},
+		{keyLabel("x", "escaped"), "Escaped", false},
+		{keyLabel("s", "split"), "Split", false},
+		{keyLabel("o", "open"), "Open dir", false},
+		{keyLabel("b", "batch"), "Batch", false},
+		{keyLabel("c", "copy"), "Copy", true},
+		{keyLabel("e", "edit", "export"), "Edit/Export", false},
+		{keyLabel("r", "reset"), "Reset", false},
+		{keyLabel("/", "search"), "Search", false},
+		{keyLabel("q", "quit"), "Quit", true},
 	}
 
 	var parts []string
 	for _, k := range keys {
+		if width < narrowWidth && !k.brief {
+			continue
+		}
 		parts = append(parts, keyStyle.Render(k.key)+" "+statusBarStyle.Render(k.action))
 	}
 
@@ -118,8 +290,10 @@ func renderStatusBar(width int) string {
 		bar += p
 	}
 
+	// One line, however narrow the terminal: the layout counts on it.
 	return lipgloss.NewStyle().
 		Width(width).
-		Foreground(clrGray).
+		MaxHeight(1).
+		Foreground(pal.muted).
 		Render(bar)
 }
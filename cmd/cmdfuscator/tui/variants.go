@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"cmdFuscator/cmd/cmdfuscator/config"
+	"cmdFuscator/engine"
+)
+
+// ─── Variant carousel ─────────────────────────────────────────────────────────
+
+// maxVariants is how many variants the carousel keeps; generating one more
+// drops the oldest.
+const maxVariants = 16
+
+// variant is one obfuscation of the applied command.
+type variant struct {
+	result engine.ObfuscateResult
+	seed   int64
+}
+
+// carousel holds the variants of the command last applied. n generates or
+// steps forward, p steps back.
+type carousel struct {
+	command string
+	enabled map[string]bool
+	seeds   *rand.Rand // the run seed of every variant, in order
+	drawn   int        // variants generated, including those dropped
+	ring    []variant
+	cur     int
+	live    bool // applied by live mode; its variants stay out of the history
+}
+
+// sessionSeeds returns the source a session draws its fresh seeds from,
+// seeded once: from the settings file's seed when it sets one, so a session
+// draws the same seeds as the last one with it, and from the clock
+// otherwise. The seed is offset by one so rerolls do not draw the run seeds
+// of the seed's own variants (see newCarousel).
+func sessionSeeds(cfg *config.Config) *rand.Rand {
+	seed := time.Now().UnixNano()
+	if cfg.Seed != nil {
+		seed = *cfg.Seed + 1
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// newCarousel starts the carousel of command. With the settings file's seed,
+// run seeds are drawn the way `cmdfuscator obfuscate --seed N --count n`
+// draws them, so the carousel steps through the same outputs.
+func (m *Model) newCarousel(command string, enabled map[string]bool) *carousel {
+	base := m.seeds.Int63()
+	if m.cfg.Seed != nil {
+		base = *m.cfg.Seed
+	}
+	return &carousel{command: command, enabled: enabled, seeds: rand.New(rand.NewSource(base))}
+}
+
+// generate runs the engine once more and makes the new variant current.
+func (m *Model) generate(c *carousel) error {
+	seed := c.seeds.Int63()
+	if seed == 0 {
+		seed = 1 // zero asks ObfuscateBatch for a fresh seed
+	}
+	return m.generateSeed(c, seed)
+}
+
+// generateSeed is generate with the run seed given, and records the variant
+// in the history.
+func (m *Model) generateSeed(c *carousel, seed int64) error {
+	tokens, err := m.frozenTokens(c.command)
+	if err != nil {
+		return err
+	}
+	item := engine.BatchItem{Command: c.command, Tokens: tokens, Profile: m.selected, Enabled: c.enabled, Seed: seed}
+	r := m.eng.ObfuscateBatch(context.Background(), []engine.BatchItem{item})[0]
+	if r.Err != nil {
+		return r.Err
+	}
+	c.drawn++
+	v := variant{result: r.Result, seed: seed}
+	if !c.live {
+		m.recordHistory(c, v)
+	}
+	c.ring = append(c.ring, v)
+	if len(c.ring) > maxVariants {
+		c.ring = c.ring[1:]
+	}
+	c.cur = len(c.ring) - 1
+	return nil
+}
+
+// nextVariant steps forward through the carousel, generating a new variant
+// past the last one.
+func (m *Model) nextVariant() {
+	c := m.variants
+	if c == nil {
+		m.statusMsg = "apply first to get variants"
+		return
+	}
+	if c.cur < len(c.ring)-1 {
+		c.cur++
+	} else if err := m.generate(c); err != nil {
+		m.statusMsg = "error: " + err.Error()
+		return
+	}
+	m.showVariant(c.ring[c.cur])
+}
+
+// prevVariant steps back through the carousel.
+func (m *Model) prevVariant() {
+	c := m.variants
+	if c == nil || c.cur == 0 {
+		return
+	}
+	c.cur--
+	m.showVariant(c.ring[c.cur])
+}
+
+// label describes the current variant for the output panel header: its
+// number, counting every variant generated, and its run seed.
+func (c *carousel) label() string {
+	n := c.drawn - len(c.ring) + c.cur + 1
+	return fmt.Sprintf("variant %d/%d  •  seed %d", n, c.drawn, c.ring[c.cur].seed)
+}
+
+// reroll applies the command again with a fresh seed, even when the settings
+// file sets one.
+func (m *Model) reroll() {
+	seed := m.seeds.Int63()
+	if seed == 0 {
+		seed = 1
+	}
+	m.applyWithSeed(seed)
+}
+
+// replay applies the command again with the current variant's seed, so the
+// same input and modifiers give the same output again.
+func (m *Model) replay() {
+	if m.variants == nil {
+		m.statusMsg = "apply first to replay its seed"
+		return
+	}
+	m.applyWithSeed(m.variants.ring[m.variants.cur].seed)
+}
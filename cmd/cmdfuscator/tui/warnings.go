@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/models"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Execution warnings ───────────────────────────────────────────────────────
+
+// maxWarningRows is how many lines the warning strip above the output takes
+// at most; the last says how many more there are.
+const maxWarningRows = 2
+
+// resultWarnings lists what makes res likely to fail to run, or to run
+// something else: the engine validators' findings, URLs that no longer
+// parse, a render target that is not the selected profile's shell, and
+// enabled modifiers the profile does not configure.
+func (m *Model) resultWarnings(res engine.ObfuscateResult, enabled map[string]bool) []string {
+	var out []string
+	for _, err := range res.Warnings {
+		out = append(out, strings.TrimPrefix(err.Error(), "engine: "))
+	}
+	for _, seg := range res.Segments {
+		if seg.Token.Type != models.TokenTypeURL || seg.Original == "" {
+			continue
+		}
+		if problem := urlProblem(unquote(res.Output[seg.Start:seg.End], res.Target)); problem != "" {
+			out = append(out, fmt.Sprintf("URL %s %s", visible(res.Output[seg.Start:seg.End]), problem))
+		}
+	}
+	if m.selected == nil || len(m.selected.Profiles) == 0 {
+		return out
+	}
+	profile := m.selected.Profiles[0]
+	if want := engine.TargetFor(profile); want != engine.TargetNone && res.Target != want {
+		out = append(out, fmt.Sprintf("output is quoted for %s, but %s runs on %s (%s)", res.Target, m.selected.Name, profile.Platform, want))
+	}
+	var unconfigured []string
+	for _, mod := range m.modifiers {
+		if _, ok := engine.ConfigFor(profile, mod.Name); enabled[mod.Name] && !ok {
+			unconfigured = append(unconfigured, mod.Name)
+		}
+	}
+	if len(unconfigured) > 0 {
+		out = append(out, fmt.Sprintf("skipped, not in the %s %s profile: %s", profile.Platform, m.selected.Name, strings.Join(unconfigured, ", ")))
+	}
+	return out
+}
+
+// urlProblem says what keeps u from being the URL it was, or returns "".
+func urlProblem(u string) string {
+	parsed, err := url.Parse(u)
+	switch {
+	case err != nil:
+		return "no longer parses"
+	case parsed.Scheme == "" || parsed.Host == "":
+		return "has lost its scheme or host"
+	case strings.IndexFunc(parsed.Host, invisible) >= 0:
+		return "has an invisible character in its host"
+	}
+	return ""
+}
+
+// unquote strips the quotes and escapes target's shell would remove from
+// word, roughly: enough to see the URL a modifier left behind.
+func unquote(word string, target engine.RenderTarget) string {
+	esc := map[engine.RenderTarget]rune{engine.TargetCmd: '^', engine.TargetPowerShell: '`', engine.TargetBash: '\\'}[target]
+	var b strings.Builder
+	runes := []rune(word)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == esc && esc != 0 && i+1 < len(runes):
+			i++
+			b.WriteRune(runes[i])
+		case r == '"' || r == '\'':
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// warningRows is how many lines the warning strip takes.
+func (m *Model) warningRows() int {
+	if m.output == "" {
+		return 0
+	}
+	return min(len(m.warnings), maxWarningRows)
+}
+
+// viewWarnings renders the warning strip in width columns.
+func (m Model) viewWarnings(width int) string {
+	rows := m.warnings[:m.warningRows()]
+	if len(m.warnings) > maxWarningRows {
+		rows = slices.Clone(rows)
+		rows[len(rows)-1] = fmt.Sprintf("%s  (+%d more)", rows[len(rows)-1], len(m.warnings)-len(rows))
+	}
+	lines := make([]string, len(rows))
+	for i, w := range rows {
+		lines[i] = lipgloss.NewStyle().MaxWidth(width).Render(warningStyle.Render("⚠ " + w))
+	}
+	return strings.Join(lines, "\n")
+}
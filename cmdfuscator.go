@@ -0,0 +1,220 @@
+package cmdfuscator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"cmdFuscator/data"
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+// ─── Options ──────────────────────────────────────────────────────────────────
+
+// Option configures Obfuscate and Variants.
+type Option func(*settings)
+
+type settings struct {
+	seed      *int64
+	modifiers []string
+	exe       string
+	target    string
+	dirs      []string
+	logger    *slog.Logger
+}
+
+// WithSeed makes the output reproducible: the same command, options and seed
+// always give the same result, and the same one `cmdfuscator obfuscate --seed`
+// prints. Without it every call draws a fresh seed.
+func WithSeed(seed int64) Option {
+	return func(s *settings) { s.seed = &seed }
+}
+
+// WithModifiers applies only the named modifiers, e.g. "RandomCase". Those
+// the profile does not configure are skipped. The default is every modifier
+// the profile configures.
+func WithModifiers(names ...string) Option {
+	return func(s *settings) { s.modifiers = names }
+}
+
+// WithExecutable picks the profile by executable name or alias instead of
+// detecting it from the command's first word.
+func WithExecutable(name string) Option {
+	return func(s *settings) { s.exe = name }
+}
+
+// WithTarget renders the output for a shell: "auto" (the default, from the
+// profile's platform), "cmd", "powershell", "bash" or "none".
+func WithTarget(shell string) Option {
+	return func(s *settings) { s.target = shell }
+}
+
+// WithProfileDirs lays the profiles in dirs over the bundled ones, later
+// directories winning, as the CLI's profile_dirs setting does.
+func WithProfileDirs(dirs ...string) Option {
+	return func(s *settings) { s.dirs = dirs }
+}
+
+// WithLogger sends the engine's structured debug records, one per modifier
+// stage, to l. The default logs nothing.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *settings) { s.logger = l }
+}
+
+// ─── Obfuscation ──────────────────────────────────────────────────────────────
+
+// Obfuscate returns an obfuscated variant of command using the bundled
+// profiles:
+//
+//	out, err := cmdfuscator.Obfuscate(ctx, "certutil -urlcache -f https://x",
+//		cmdfuscator.WithSeed(1), cmdfuscator.WithModifiers("RandomCase"))
+//
+// Modifiers that are not implemented yet are skipped; any other modifier
+// error is returned.
+func Obfuscate(ctx context.Context, command string, opts ...Option) (string, error) {
+	outs, err := Variants(ctx, command, 1, opts...)
+	if err != nil {
+		return "", err
+	}
+	return outs[0], nil
+}
+
+// triesPerVariant bounds the attempts Variants makes per requested variant.
+const triesPerVariant = 20
+
+// Variants returns up to n distinct obfuscated variants of command, in the
+// order they were produced. It returns fewer when the modifiers cannot
+// produce n, as with few of them and a short command.
+func Variants(ctx context.Context, command string, n int, opts ...Option) ([]string, error) {
+	var s settings
+	for _, opt := range opts {
+		opt(&s)
+	}
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return nil, errors.New("cmdfuscator: empty command")
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("cmdfuscator: %d variants requested", n)
+	}
+	rt, err := engine.ParseRenderTarget(s.target)
+	if s.target == "" {
+		rt, err = engine.TargetAuto, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cmdfuscator: %w", err)
+	}
+	pf, err := s.profile(command)
+	if err != nil {
+		return nil, err
+	}
+	enabled := engine.DefaultEnabled(pf)
+	if s.modifiers != nil {
+		enabled = make(map[string]bool, len(s.modifiers))
+		for _, name := range s.modifiers {
+			if _, ok := modifiers.Get(name); !ok {
+				return nil, fmt.Errorf("cmdfuscator: unknown modifier %q", name)
+			}
+			enabled[name] = true
+		}
+	}
+
+	// Seeds are drawn the way the CLI draws them, so a seed gives the same
+	// variants here as with `cmdfuscator obfuscate --seed N --count n`.
+	var seeds *rand.Rand
+	if s.seed != nil {
+		seeds = rand.New(rand.NewSource(*s.seed))
+	}
+	eng := engine.New(engine.WithRenderTarget(rt), engine.WithErrorPolicy(engine.FailFast), engine.WithLogger(s.logger))
+	var outs []string
+	seen := make(map[string]bool, n)
+	for tries := 0; len(outs) < n && tries < n*triesPerVariant; {
+		items := make([]engine.BatchItem, n-len(outs))
+		for i := range items {
+			items[i] = engine.BatchItem{Command: command, Profile: pf, Enabled: enabled, Seed: nextSeed(seeds)}
+		}
+		tries += len(items)
+		for _, r := range eng.ObfuscateBatch(ctx, items) {
+			if r.Err != nil {
+				return nil, fmt.Errorf("cmdfuscator: %w", r.Err)
+			}
+			if !seen[r.Result.Output] {
+				seen[r.Result.Output] = true
+				outs = append(outs, r.Result.Output)
+			}
+		}
+	}
+	return outs, nil
+}
+
+// nextSeed returns the next seed from seeds, or zero, which asks the engine
+// for a fresh one, when seeds is nil.
+func nextSeed(seeds *rand.Rand) int64 {
+	if seeds == nil {
+		return 0
+	}
+	if s := seeds.Int63(); s != 0 {
+		return s
+	}
+	return 1
+}
+
+// ─── Profiles ─────────────────────────────────────────────────────────────────
+
+var (
+	bundledOnce  sync.Once
+	bundled      []*models.ProfileFile
+	bundledIndex *loader.Index
+	bundledErr   error
+)
+
+// profile returns the profile to obfuscate command with. The bundled
+// profiles are loaded once; overlays are read on every call, so edits to
+// them are picked up.
+func (s *settings) profile(command string) (*models.ProfileFile, error) {
+	bundledOnce.Do(func() {
+		if bundled, bundledErr = loadProfiles(); bundledErr == nil {
+			bundledIndex, _ = loader.BuildIndex(bundled)
+		}
+	})
+	profiles, index, err := bundled, bundledIndex, bundledErr
+	if err == nil && len(s.dirs) > 0 {
+		if profiles, err = loadProfiles(s.dirs...); err == nil {
+			index, _ = loader.BuildIndex(profiles)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cmdfuscator: profiles: %w", err)
+	}
+
+	if s.exe != "" {
+		if pf, ok := index.Lookup(s.exe); ok {
+			return pf, nil
+		}
+		return nil, fmt.Errorf("cmdfuscator: no profile for %q", s.exe)
+	}
+	if pf, ok := engine.DetectProfile(command, profiles); ok {
+		return pf, nil
+	}
+	return nil, fmt.Errorf("cmdfuscator: no profile matches %q; use WithExecutable", strings.Fields(command)[0])
+}
+
+// loadProfiles loads the bundled profiles with dirs laid over them.
+func loadProfiles(dirs ...string) ([]*models.ProfileFile, error) {
+	sub, err := fs.Sub(data.ModelFS, "models")
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return loader.LoadFS(sub)
+	}
+	return loader.LoadWithOverlay(sub, dirs...)
+}
@@ -0,0 +1,111 @@
+package cmdfuscator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/cmd/cmdfuscator/cli"
+	"cmdFuscator/data"
+)
+
+const command = "certutil -urlcache -split -f https://example.com/a.txt a.txt"
+
+func TestObfuscate(t *testing.T) {
+	ctx := context.Background()
+	out, err := Obfuscate(ctx, command, WithSeed(1), WithModifiers("RandomCase"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.EqualFold(out, command) {
+		t.Errorf("Obfuscate = %q, want %q with its case changed", out, command)
+	}
+	again, err := Obfuscate(ctx, command, WithSeed(1), WithModifiers("RandomCase"))
+	if err != nil || again != out {
+		t.Errorf("second run with the same seed = %q, %v; want %q", again, err, out)
+	}
+}
+
+// TestVariantsMatchCLI checks that a seed gives the same variants as
+// `cmdfuscator obfuscate --seed`.
+func TestVariantsMatchCLI(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	var stdout, stderr bytes.Buffer
+	args := []string{"obfuscate", "--seed", "7", "--count", "3", "--target", "cmd", command}
+	if code := cli.Run(data.ModelFS, args, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("cli exit code = %d, stderr %q", code, stderr.String())
+	}
+	want := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+
+	got, err := Variants(context.Background(), command, 3, WithSeed(7), WithTarget("cmd"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Variants = %q, want the CLI's %q", got, want)
+	}
+}
+
+func TestObfuscateExecutable(t *testing.T) {
+	// "tool" matches no profile by itself.
+	if _, err := Obfuscate(context.Background(), "tool -f"); err == nil || !strings.Contains(err.Error(), "WithExecutable") {
+		t.Errorf("undetected profile: err = %v, want a hint to use WithExecutable", err)
+	}
+	out, err := Obfuscate(context.Background(), "tool -f", WithExecutable("bash"), WithModifiers("RandomCase"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.EqualFold(out, "tool -f") {
+		t.Errorf("Obfuscate = %q", out)
+	}
+}
+
+func TestObfuscateProfileDirs(t *testing.T) {
+	dir := t.TempDir()
+	profile := `{"versions": {"argfuscator": "2.0", "format": "2.0"}, "profiles": [{"platform": "linux",
+		"parameters": {"command": [], "arguments": [], "modifiers": {"RandomCase": {"AppliesTo": ["argument"], "Probability": "1.0"}}}}]}`
+	if err := os.WriteFile(filepath.Join(dir, "mytool.json"), []byte(profile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := Obfuscate(context.Background(), "mytool -abc", WithProfileDirs(dir))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "mytool -ABC" {
+		t.Errorf("Obfuscate = %q, want %q", out, "mytool -ABC")
+	}
+	if _, err := Obfuscate(context.Background(), "mytool -abc"); err == nil {
+		t.Error("the overlay profile is still used without WithProfileDirs")
+	}
+}
+
+func TestObfuscateErrors(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		cmd  string
+		opts []Option
+		want string
+	}{
+		{"empty", context.Background(), "  ", nil, "empty command"},
+		{"unknown modifier", context.Background(), command, []Option{WithModifiers("RandomCas")}, `unknown modifier "RandomCas"`},
+		{"unknown executable", context.Background(), command, []Option{WithExecutable("nosuchtool")}, `no profile for "nosuchtool"`},
+		{"bad target", context.Background(), command, []Option{WithTarget("tcsh")}, "tcsh"},
+		{"cancelled", cancelled, command, nil, context.Canceled.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Obfuscate(tt.ctx, tt.cmd, tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package compat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	_ "cmdFuscator/engine/modifiers/all"
+	"cmdFuscator/models"
+)
+
+// ─── Fixtures ─────────────────────────────────────────────────────────────────
+
+type fixtureFile struct {
+	Generator string    `json:"generator"`
+	Modifier  string    `json:"modifier"`
+	Cases     []fixture `json:"cases"`
+}
+
+type fixture struct {
+	Name    string                    `json:"name"`
+	Config  json.RawMessage           `json:"config"`
+	Input   []models.CommandElement   `json:"input"`
+	Outputs [][]models.CommandElement `json:"outputs"`
+}
+
+// config is the part of a fixture's config the relations look at.
+type config struct {
+	models.BaseModifierConfig
+	Characters []string `json:"Characters"`
+}
+
+func tokens(els []models.CommandElement) []models.Token {
+	out := make([]models.Token, len(els))
+	for i, el := range els {
+		out[i] = el.ToToken()
+	}
+	return out
+}
+
+func loadFixtures(t *testing.T) []fixtureFile {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no fixtures in testdata/")
+	}
+	var files []fixtureFile
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f fixtureFile
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&f); err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		if f.Modifier == "" || len(f.Cases) == 0 {
+			t.Fatalf("%s: want a modifier and at least one case", p)
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// ─── Relations ────────────────────────────────────────────────────────────────
+
+// relation checks that out is something the modifier may turn in into, token
+// by token. same() has already checked that the two have the same shape and
+// that tokens of other types are unchanged.
+type relation func(cfg config, in, out models.Token) error
+
+var relations = map[string]relation{
+	"RandomCase": func(_ config, in, out models.Token) error {
+		if !strings.EqualFold(in.Value, out.Value) {
+			return errors.New("differs by more than case")
+		}
+		return nil
+	},
+	"CharacterInsertion": func(cfg config, in, out models.Token) error {
+		if strip(out.Value, cfg.Characters) != in.Value {
+			return fmt.Errorf("differs by more than inserted characters from %q", cfg.Characters)
+		}
+		return nil
+	},
+}
+
+func strip(s string, chars []string) string {
+	for _, c := range chars {
+		if c != "" {
+			s = strings.ReplaceAll(s, c, "")
+		}
+	}
+	return s
+}
+
+// check returns an error unless out has in's shape, changes only tokens of
+// the types cfg applies to, and changes those as rel allows. changed
+// collects the types of the tokens out changed.
+func check(rel relation, cfg config, in, out []models.Token, changed map[models.TokenType]bool) error {
+	if len(out) != len(in) {
+		return fmt.Errorf("%d tokens, want %d", len(out), len(in))
+	}
+	for i := range in {
+		if out[i].Type != in[i].Type {
+			return fmt.Errorf("token %d is %s, want %s", i, out[i].Type, in[i].Type)
+		}
+		if out[i].Value == in[i].Value {
+			continue
+		}
+		if !modifiers.Applies(cfg.BaseModifierConfig, in[i]) {
+			return fmt.Errorf("token %d (%s) changed, but AppliesTo is %v", i, in[i].Type, cfg.AppliesTo)
+		}
+		if err := rel(cfg, in[i], out[i]); err != nil {
+			return fmt.Errorf("token %d: %q → %q %w", i, in[i].Value, out[i].Value, err)
+		}
+		changed[in[i].Type] = true
+	}
+	return nil
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func TestCompat(t *testing.T) {
+	for _, f := range loadFixtures(t) {
+		t.Run(f.Modifier, func(t *testing.T) {
+			mod, ok := modifiers.Get(f.Modifier)
+			if !ok {
+				t.Fatalf("fixtures for unregistered modifier %q", f.Modifier)
+			}
+			rel, ok := relations[f.Modifier]
+			if !ok {
+				t.Fatalf("no relation for %s; add one to relations", f.Modifier)
+			}
+			for _, c := range f.Cases {
+				t.Run(c.Name, func(t *testing.T) {
+					var cfg config
+					if err := json.Unmarshal(c.Config, &cfg); err != nil {
+						t.Fatalf("config: %v", err)
+					}
+					in := tokens(c.Input)
+
+					// The fixtures themselves, so a bad relation or a
+					// mistranscribed output is caught before it hides a
+					// modifier's.
+					upstream := make(map[models.TokenType]bool)
+					for i, els := range c.Outputs {
+						if err := check(rel, cfg, in, tokens(els), upstream); err != nil {
+							t.Fatalf("%s output %d: %v", f.Generator, i, err)
+						}
+					}
+
+					ours := make(map[models.TokenType]bool)
+					runs := max(len(c.Outputs), 20)
+					for seed := range runs {
+						mc := modifiers.Context{Rand: rand.New(rand.NewSource(int64(seed) + 1))}
+						out, err := modifiers.ApplyWith(mc, mod, in, c.Config)
+						if errors.Is(err, modifiers.ErrNotImplemented) {
+							t.Skipf("%s is not implemented yet", f.Modifier)
+						}
+						if err != nil {
+							t.Fatalf("seed %d: %v", seed+1, err)
+						}
+						if err := check(rel, cfg, in, out, ours); err != nil {
+							t.Errorf("seed %d: %v", seed+1, err)
+						}
+					}
+					for typ := range upstream {
+						if !ours[typ] {
+							t.Errorf("the fixture changes %s tokens; %d runs here never did", typ, runs)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestFixturesMatchCases checks that testdata holds one fixture file per
+// modifier in gen/cases.json, with its cases, so none is edited by hand or
+// left behind when a case changes.
+func TestFixturesMatchCases(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("gen", "cases.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []fixtureFile
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("cases.json: %v", err)
+	}
+	files := make(map[string]fixtureFile)
+	for _, f := range loadFixtures(t) {
+		files[f.Modifier] = f
+	}
+	for _, e := range entries {
+		f, ok := files[e.Modifier]
+		if !ok {
+			t.Errorf("%s: in cases.json but not in testdata; run go generate ./compat", e.Modifier)
+			continue
+		}
+		delete(files, e.Modifier)
+		if len(f.Cases) != len(e.Cases) {
+			t.Errorf("%s: %d cases in testdata, %d in cases.json", e.Modifier, len(f.Cases), len(e.Cases))
+			continue
+		}
+		for i, c := range e.Cases {
+			got := f.Cases[i]
+			if got.Name != c.Name || !sameJSON(got.Config, c.Config) || !slices.Equal(got.Input, c.Input) {
+				t.Errorf("%s: case %q differs from cases.json; run go generate ./compat", e.Modifier, c.Name)
+			}
+		}
+	}
+	for name := range files {
+		t.Errorf("%s: in testdata but not in cases.json", name)
+	}
+}
+
+// sameJSON reports whether a and b encode the same value.
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	ea, _ := json.Marshal(va)
+	eb, _ := json.Marshal(vb)
+	return string(ea) == string(eb)
+}
+
+func TestRelationsHaveFixtures(t *testing.T) {
+	have := make(map[string]bool)
+	for _, f := range loadFixtures(t) {
+		have[f.Modifier] = true
+	}
+	var missing []string
+	for name := range relations {
+		if !have[name] {
+			missing = append(missing, name)
+		}
+	}
+	slices.Sort(missing)
+	if len(missing) > 0 {
+		t.Errorf("relations without fixtures: %v", missing)
+	}
+}
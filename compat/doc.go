@@ -0,0 +1,34 @@
+// Package compat holds the compatibility tests that keep the Go modifiers in
+// line with ArgFuscator.net's TypeScript ones. It has no API; the package
+// exists for its tests.
+//
+// Each file in testdata/ holds fixtures for one modifier: the inputs and
+// configs of gen/cases.json and the outputs the upstream modifier produced
+// for them over a number of seeds. gen runs the upstream modifiers at a
+// pinned commit, which the fixtures' "generator" field records, so moving
+// the pin and regenerating shows where upstream's behaviour drifted. The two
+// implementations draw from different random sources, so outputs are not
+// compared byte for byte. Instead every output, upstream's and ours, must
+// stand in the modifier's relation to its input, e.g. "equal ignoring case"
+// for RandomCase, and must keep the token list's shape. Ours must also, over
+// as many seeds, change every token type upstream changed. Modifiers that
+// are still stubs have no cases until they are implemented.
+//
+// The fixture format reuses the profile format's command elements:
+//
+//	{
+//	  "generator": "ArgFuscator.net <commit>, seeds 1-5",
+//	  "modifier": "RandomCase",
+//	  "cases": [{
+//	    "name": "flags and url",
+//	    "config": {"AppliesTo": ["argument"], "Probability": "1.0"},
+//	    "input": [{"command": "certutil"}, {"argument": "-urlcache"}],
+//	    "outputs": [[{"command": "certutil"}, {"argument": "-URLCACHE"}]]
+//	  }]
+//	}
+//
+// Fixtures written before gen say "handwritten" there instead; gen
+// replaces them on its first run.
+package compat
+
+//go:generate go run ./gen -dir . -upstream ${ARGFUSCATOR}
@@ -0,0 +1,71 @@
+// harness.cjs runs ArgFuscator.net's modifiers, compiled to CommonJS by
+// gen, over the cases it is sent on stdin and writes their outputs to
+// stdout. Its input is
+//
+//   {"root": "<compiled src/>", "seeds": 5, "modifiers": [<cases.json entries>]}
+//
+// and its output the same entries with "outputs" added to every case, one
+// token list per seed. Math.random is replaced by a seeded PRNG before each
+// run, so a commit, the cases and the seeds give the same outputs again.
+//
+// The upstream API used here is the one of the pinned commit, and the place
+// to look when the pin moves and a run fails: src/Modifiers/<Name>.js
+// exports a class <Name> whose constructor takes the input tokens, the token
+// types to touch, the probability and the modifier's own options, in that
+// order, and whose GenerateOutput() rewrites the tokens it was given;
+// src/Models/Token.js exports Token, built from a string and a type and read
+// back with GetStringContent().
+"use strict";
+
+const path = require("path");
+
+// mulberry32 returns a PRNG seeded with seed, in [0, 1) like Math.random.
+function mulberry32(seed) {
+  return function () {
+    seed = (seed + 0x6d2b79f5) | 0;
+    let t = Math.imul(seed ^ (seed >>> 15), 1 | seed);
+    t = (t + Math.imul(t ^ (t >>> 7), 61 | t)) ^ t;
+    return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+  };
+}
+
+// Elements are {"<type>": "<value>"}, as in the profile format.
+function element(tok) {
+  return { [tok.type]: tok.value };
+}
+
+function run(root, seeds, entry) {
+  const Mod = require(path.join(root, "Modifiers", entry.modifier + ".js"))[entry.modifier];
+  const { Token } = require(path.join(root, "Models", "Token.js"));
+  if (typeof Mod !== "function" || typeof Token !== "function") {
+    throw new Error(`${entry.modifier}: the upstream modules do not export ${entry.modifier} and Token`);
+  }
+
+  for (const c of entry.cases) {
+    const { AppliesTo, Probability, ...options } = c.config;
+    c.outputs = [];
+    for (let seed = 1; seed <= seeds; seed++) {
+      Math.random = mulberry32(seed);
+      const input = c.input.map((el) => {
+        const [type, value] = Object.entries(el)[0];
+        return { type, token: new Token(value, type) };
+      });
+      const mod = new Mod(input.map((t) => t.token), AppliesTo, Number(Probability), options);
+      if (typeof mod.GenerateOutput !== "function") {
+        throw new Error(`${entry.modifier}: no GenerateOutput()`);
+      }
+      mod.GenerateOutput();
+      c.outputs.push(input.map((t) => element({ type: t.type, value: t.token.GetStringContent() })));
+    }
+  }
+  return entry;
+}
+
+let stdin = "";
+process.stdin.setEncoding("utf8");
+process.stdin.on("data", (chunk) => (stdin += chunk));
+process.stdin.on("end", () => {
+  const req = JSON.parse(stdin);
+  const out = req.modifiers.map((entry) => run(req.root, req.seeds, entry));
+  process.stdout.write(JSON.stringify(out));
+});
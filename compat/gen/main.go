@@ -0,0 +1,332 @@
+// Command gen regenerates the fixtures in compat/testdata/ by running
+// ArgFuscator.net's TypeScript modifiers, at a pinned commit, over the cases
+// in cases.json:
+//
+//	go run ./compat/gen -upstream ~/src/ArgFuscator.net [-commit <sha>] [-seeds 5]
+//
+// The upstream checkout must be clean and at the pinned commit: the one the
+// fixtures' "generator" fields record, or -commit to move the pin. gen
+// compiles the checkout's src/ with tsc (fetched with npx), runs
+// harness.cjs over it under node with a seeded PRNG and writes one fixture
+// file per modifier in cases.json, recording the commit and the seeds.
+// A modifier goes in cases.json once it is implemented here; stubs have no
+// fixtures.
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+//go:embed harness.cjs
+var harness []byte
+
+// generatorPrefix starts the "generator" field of generated fixtures; the
+// commit follows it.
+const generatorPrefix = "ArgFuscator.net "
+
+func main() {
+	upstream := flag.String("upstream", "", "ArgFuscator.net checkout to run")
+	commit := flag.String("commit", "", "upstream commit to pin (default: the one the fixtures record)")
+	seeds := flag.Int("seeds", 5, "outputs per case, from seeds 1 to n")
+	dir := flag.String("dir", "compat", "directory holding gen/cases.json and testdata/")
+	flag.Parse()
+
+	if err := run(*upstream, *commit, *seeds, *dir); err != nil {
+		fmt.Fprintf(os.Stderr, "gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// ─── Cases and fixtures ───────────────────────────────────────────────────────
+
+// entry is one modifier's cases, as in cases.json and, with outputs, as the
+// harness returns them.
+type entry struct {
+	Modifier string     `json:"modifier"`
+	Cases    []caseSpec `json:"cases"`
+}
+
+type caseSpec struct {
+	Name    string              `json:"name"`
+	Config  json.RawMessage     `json:"config"`
+	Input   []json.RawMessage   `json:"input"`
+	Outputs [][]json.RawMessage `json:"outputs,omitempty"`
+}
+
+func readCases(dir string) ([]entry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "gen", "cases.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("cases.json: %w", err)
+	}
+	return entries, nil
+}
+
+// pinnedCommit returns the upstream commit the generated fixtures in
+// testdata record, or "" when none is generated yet. Fixtures recording two
+// commits are an error.
+func pinnedCommit(testdata string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(testdata, "*.json"))
+	if err != nil {
+		return "", err
+	}
+	commit := ""
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return "", err
+		}
+		var f struct {
+			Generator string `json:"generator"`
+		}
+		if err := json.Unmarshal(data, &f); err != nil {
+			return "", fmt.Errorf("%s: %w", p, err)
+		}
+		c, ok := strings.CutPrefix(f.Generator, generatorPrefix)
+		if !ok {
+			continue
+		}
+		c, _, _ = strings.Cut(c, ",")
+		if commit != "" && c != commit {
+			return "", fmt.Errorf("fixtures record both %s and %s", commit, c)
+		}
+		commit = c
+	}
+	return commit, nil
+}
+
+// fixture renders e as a fixture file generated at commit over seeds seeds,
+// one token list to a line and every character that does not print escaped.
+func fixture(e entry, commit string, seeds int) ([]byte, error) {
+	var b bytes.Buffer
+	line := func(indent int, format string, args ...any) {
+		b.WriteString(strings.Repeat("  ", indent))
+		fmt.Fprintf(&b, format, args...)
+		b.WriteByte('\n')
+	}
+	enc := func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return escape(string(data)), nil
+	}
+
+	gen, err := enc(fmt.Sprintf("%s%s, seeds 1-%d", generatorPrefix, commit, seeds))
+	if err != nil {
+		return nil, err
+	}
+	mod, err := enc(e.Modifier)
+	if err != nil {
+		return nil, err
+	}
+	line(0, "{")
+	line(1, `"generator": %s,`, gen)
+	line(1, `"modifier": %s,`, mod)
+	line(1, `"cases": [`)
+	for i, c := range e.Cases {
+		if len(c.Outputs) == 0 {
+			return nil, fmt.Errorf("%s: case %q has no outputs", e.Modifier, c.Name)
+		}
+		name, err := enc(c.Name)
+		if err != nil {
+			return nil, err
+		}
+		config, err := enc(c.Config)
+		if err != nil {
+			return nil, err
+		}
+		input, err := enc(c.Input)
+		if err != nil {
+			return nil, err
+		}
+		line(2, "{")
+		line(3, `"name": %s,`, name)
+		line(3, `"config": %s,`, config)
+		line(3, `"input": %s,`, input)
+		line(3, `"outputs": [`)
+		for j, out := range c.Outputs {
+			s, err := enc(out)
+			if err != nil {
+				return nil, err
+			}
+			line(4, "%s%s", s, comma(j, len(c.Outputs)))
+		}
+		line(3, "]")
+		line(2, "}%s", comma(i, len(e.Cases)))
+	}
+	line(1, "]")
+	line(0, "}")
+	return b.Bytes(), nil
+}
+
+func comma(i, n int) string {
+	if i < n-1 {
+		return ","
+	}
+	return ""
+}
+
+// escape writes the characters of s, JSON text, that do not print as \u
+// escapes, so the fixtures show which invisible character went where.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r > 0xffff && !unicode.IsGraphic(r):
+			r1, r2 := (r-0x10000)>>10+0xd800, (r-0x10000)&0x3ff+0xdc00
+			fmt.Fprintf(&b, `\u%04x\u%04x`, r1, r2)
+		case r > 0x7e && (!unicode.IsGraphic(r) || unicode.Is(unicode.Cf, r)):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ─── Upstream ─────────────────────────────────────────────────────────────────
+
+func run(upstream, commit string, seeds int, dir string) error {
+	if upstream == "" {
+		return errors.New("-upstream is required: a clone of https://github.com/wietze/ArgFuscator.net")
+	}
+	if seeds < 1 {
+		return fmt.Errorf("-seeds %d: want at least 1", seeds)
+	}
+	entries, err := readCases(dir)
+	if err != nil {
+		return err
+	}
+	testdata := filepath.Join(dir, "testdata")
+	if commit == "" {
+		if commit, err = pinnedCommit(testdata); err != nil {
+			return err
+		}
+		if commit == "" {
+			return errors.New("no fixture records an upstream commit yet: pin one with -commit")
+		}
+	}
+	if commit, err = checkout(upstream, commit); err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "compat-gen-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	root, err := compile(upstream, tmp, entries)
+	if err != nil {
+		return err
+	}
+	if entries, err = runHarness(tmp, root, seeds, entries); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		data, err := fixture(e, commit, seeds)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(testdata, e.Modifier+".json"), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkout returns the full hash of commit, or an error unless the checkout
+// at dir is clean and at commit, so the fixtures say exactly what produced
+// them.
+func checkout(dir, commit string) (string, error) {
+	head, err := output(exec.Command("git", "-C", dir, "rev-parse", "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(head, commit) {
+		return "", fmt.Errorf("%s is at %s, want %s: run git -C %s checkout %s", dir, head, commit, dir, commit)
+	}
+	status, err := output(exec.Command("git", "-C", dir, "status", "--porcelain"))
+	if err != nil {
+		return "", err
+	}
+	if status != "" {
+		return "", fmt.Errorf("%s has local changes", dir)
+	}
+	return head, nil
+}
+
+// compile compiles the modifiers of entries, and what they import, from the
+// checkout's src/ into tmp as CommonJS and returns where src/ ended up. Type
+// errors are reported but do not stop the run: tsc still emits, and a
+// modifier that does not load fails in the harness.
+func compile(upstream, tmp string, entries []entry) (string, error) {
+	src := filepath.Join(upstream, "src")
+	out := filepath.Join(tmp, "src")
+	args := []string{"--yes", "-p", "typescript@5", "tsc",
+		"--module", "commonjs", "--target", "es2020", "--skipLibCheck",
+		"--rootDir", src, "--outDir", out}
+	for _, e := range entries {
+		args = append(args, filepath.Join(src, "Modifiers", e.Modifier+".ts"))
+	}
+	cmd := exec.Command("npx", args...)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) {
+			return "", fmt.Errorf("tsc: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "gen: tsc reported errors; running what it emitted")
+	}
+	return out, nil
+}
+
+// runHarness runs harness.cjs under node over entries and returns them with
+// their outputs.
+func runHarness(tmp, root string, seeds int, entries []entry) ([]entry, error) {
+	script := filepath.Join(tmp, "harness.cjs")
+	if err := os.WriteFile(script, harness, 0o644); err != nil {
+		return nil, err
+	}
+	req, err := json.Marshal(map[string]any{"root": root, "seeds": seeds, "modifiers": entries})
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("node", script)
+	cmd.Stdin = bytes.NewReader(req)
+	out, err := output(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var got []entry
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		return nil, fmt.Errorf("harness output: %w", err)
+	}
+	return got, nil
+}
+
+// output runs cmd and returns its trimmed stdout, with its stderr in the
+// error when it fails.
+func output(cmd *exec.Cmd) (string, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %s", strings.Join(cmd.Args[:min(len(cmd.Args), 2)], " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeUpstream writes CommonJS modules shaped like the compiled upstream
+// ones to a temporary src/: a Token and a RandomCase that upper-cases the
+// tokens of its types when Math.random() comes in under the probability.
+func fakeUpstream(t *testing.T) string {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "src")
+	files := map[string]string{
+		"Models/Token.js": `class Token {
+  constructor(value, type) { this.value = value; this.Type = type; }
+  GetStringContent() { return this.value; }
+}
+exports.Token = Token;`,
+		"Modifiers/RandomCase.js": `class RandomCase {
+  constructor(tokens, appliesTo, probability) { this.tokens = tokens; this.appliesTo = appliesTo; this.p = probability; }
+  GenerateOutput() {
+    for (const t of this.tokens) {
+      if (this.appliesTo.includes(t.Type) && Math.random() < this.p) t.value = t.value.toUpperCase();
+    }
+  }
+}
+exports.RandomCase = RandomCase;`,
+	}
+	for name, src := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+var randomCase = entry{
+	Modifier: "RandomCase",
+	Cases: []caseSpec{{
+		Name:   "arguments",
+		Config: json.RawMessage(`{"AppliesTo":["argument"],"Probability":"0.5"}`),
+		Input:  []json.RawMessage{json.RawMessage(`{"command":"certutil"}`), json.RawMessage(`{"argument":"-f"}`)},
+	}},
+}
+
+// ─── harness ──────────────────────────────────────────────────────────────────
+
+func TestRunHarness(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not installed")
+	}
+	root := fakeUpstream(t)
+	run := func() []entry {
+		got, err := runHarness(t.TempDir(), root, 8, []entry{randomCase})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	got := run()
+	outs := got[0].Cases[0].Outputs
+	if len(outs) != 8 {
+		t.Fatalf("%d outputs, want one per seed", len(outs))
+	}
+	seen := map[string]bool{}
+	for _, out := range outs {
+		if string(out[0]) != `{"command":"certutil"}` {
+			t.Errorf("command rewritten: %s", out[0])
+		}
+		seen[string(out[1])] = true
+	}
+	if !seen[`{"argument":"-f"}`] || !seen[`{"argument":"-F"}`] {
+		t.Errorf("outputs %v, want both cases over 8 seeds", seen)
+	}
+
+	again := run()[0].Cases[0].Outputs
+	for i := range outs {
+		if string(again[i][1]) != string(outs[i][1]) {
+			t.Errorf("seed %d: %s, then %s", i+1, outs[i][1], again[i][1])
+		}
+	}
+}
+
+// ─── fixtures ─────────────────────────────────────────────────────────────────
+
+func TestFixture(t *testing.T) {
+	e := entry{
+		Modifier: "CharacterInsertion",
+		Cases: []caseSpec{{
+			Name:    "soft hyphen",
+			Config:  json.RawMessage(`{"AppliesTo":["argument"],"Probability":"1.0"}`),
+			Input:   []json.RawMessage{json.RawMessage(`{"argument":"-f"}`)},
+			Outputs: [][]json.RawMessage{{json.RawMessage(`{"argument":"-` + "\u00ad" + `f"}`)}},
+		}},
+	}
+	data, err := fixture(e, "0123abc", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"-\u00adf"`) {
+		t.Errorf("invisible character not escaped:\n%s", data)
+	}
+
+	var f struct {
+		Generator string `json:"generator"`
+		Modifier  string `json:"modifier"`
+		Cases     []struct {
+			Outputs [][]map[string]string `json:"outputs"`
+		} `json:"cases"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatalf("%v:\n%s", err, data)
+	}
+	if f.Generator != "ArgFuscator.net 0123abc, seeds 1-5" || f.Modifier != e.Modifier {
+		t.Errorf("generator %q, modifier %q", f.Generator, f.Modifier)
+	}
+	if got := f.Cases[0].Outputs[0][0]["argument"]; got != "-\u00adf" {
+		t.Errorf("output %q", got)
+	}
+
+	e.Cases[0].Outputs = nil
+	if _, err := fixture(e, "0123abc", 5); err == nil {
+		t.Error("a case without outputs should be an error")
+	}
+}
+
+func TestPinnedCommit(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, generator string) {
+		data := `{"generator": "` + generator + `", "modifier": "X", "cases": []}`
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("A.json", "handwritten")
+	if got, err := pinnedCommit(dir); err != nil || got != "" {
+		t.Errorf("handwritten only: %q, %v", got, err)
+	}
+	write("B.json", "ArgFuscator.net 0123abc, seeds 1-5")
+	if got, err := pinnedCommit(dir); err != nil || got != "0123abc" {
+		t.Errorf("got %q, %v, want 0123abc", got, err)
+	}
+	write("C.json", "ArgFuscator.net 4567def, seeds 1-5")
+	if _, err := pinnedCommit(dir); err == nil {
+		t.Error("two commits should be an error")
+	}
+}
@@ -0,0 +1,252 @@
+// Package corpus generates labelled datasets of obfuscated command lines, for
+// training and evaluating detection models.
+//
+// For every profile, Generate obfuscates the profile's template command (its
+// example) several times, each time with a randomly drawn subset of the
+// modifiers the profile configures, and hands back one Record per distinct
+// variant: the original, the obfuscated command, the modifiers that changed
+// it and the seed that reproduces it. JSONL writes records as JSON Lines.
+package corpus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	randv2 "math/rand/v2"
+	"slices"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── Records ──────────────────────────────────────────────────────────────────
+
+// Record is one labelled example of the dataset.
+type Record struct {
+	Executable string   `json:"executable"`
+	Platform   string   `json:"platform"`
+	Attack     []string `json:"attack,omitempty"` // the profile's ATT&CK technique IDs
+	Original   string   `json:"original"`
+	Obfuscated string   `json:"obfuscated"`
+
+	// Enabled is the subset of modifiers drawn for the variant, and
+	// Modifiers those among them that changed the command, in the order they
+	// ran. A modifier may be drawn and change nothing, e.g. when its
+	// probability roll fails on every token.
+	Enabled   []string `json:"enabled"`
+	Modifiers []string `json:"modifiers"`
+
+	// Seed reproduces the variant: engine.BatchItem{Seed: Seed} with the
+	// same command, profile and Enabled yields Obfuscated again.
+	Seed int64 `json:"seed"`
+}
+
+// JSONL returns an emit function for Generate that writes each record to w
+// as one line of JSON.
+func JSONL(w io.Writer) func(Record) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return func(r Record) error {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("corpus: %w", err)
+		}
+		return nil
+	}
+}
+
+// ─── Generation ───────────────────────────────────────────────────────────────
+
+// triesPerVariant bounds the attempts Generate makes per requested variant
+// before settling for fewer distinct ones.
+const triesPerVariant = 20
+
+// Options configures Generate.
+type Options struct {
+	// Variants is the number of distinct variants wanted per template
+	// command. It must be positive.
+	Variants int
+
+	// Seed, when non-zero, fixes every random choice, so the same
+	// profiles and options give the same dataset.
+	Seed int64
+
+	// MinModifiers is the smallest subset of modifiers drawn per variant;
+	// values below 1 mean 1. Profiles configuring fewer use all of theirs.
+	MinModifiers int
+
+	// Modifiers, when non-empty, limits the draw to these modifiers.
+	Modifiers []string
+
+	// Engine holds extra options for the engine that obfuscates, such as
+	// engine.WithWorkers or engine.WithRenderTarget.
+	Engine []engine.Option
+}
+
+// Stats summarizes a Generate run.
+type Stats struct {
+	Templates int // template commands obfuscated
+	Records   int
+
+	// Short lists, by executable and platform, the templates that yielded
+	// fewer distinct variants than Options.Variants, e.g. ones whose
+	// profile configures only unimplemented modifiers.
+	Short []string
+}
+
+// Generate obfuscates the template command of every profile in profiles and
+// calls emit with each distinct variant, in profile order. Variants equal to
+// their original are dropped. Generate stops at the first error emit or the
+// engine returns, or when ctx is done.
+func Generate(ctx context.Context, profiles []*models.ProfileFile, opts Options, emit func(Record) error) (Stats, error) {
+	var st Stats
+	if opts.Variants < 1 {
+		return st, errors.New("corpus: Variants must be at least 1")
+	}
+	for _, name := range opts.Modifiers {
+		if _, ok := modifiers.Get(name); !ok {
+			return st, fmt.Errorf("corpus: unknown modifier %q", name)
+		}
+	}
+	var rng *rand.Rand
+	if opts.Seed != 0 {
+		rng = rand.New(rand.NewSource(opts.Seed))
+	} else {
+		rng = rand.New(rand.NewSource(randv2.Int64()))
+	}
+	g := &generator{
+		eng:  engine.New(append(slices.Clone(opts.Engine), engine.WithTrace(true))...),
+		rng:  rng,
+		opts: opts,
+	}
+	for _, pf := range profiles {
+		for _, p := range pf.Profiles {
+			if len(p.Parameters.Command) == 0 {
+				continue
+			}
+			st.Templates++
+			n, err := g.template(ctx, pf, p, emit)
+			st.Records += n
+			if err != nil {
+				return st, err
+			}
+			if n < opts.Variants {
+				st.Short = append(st.Short, pf.Name+"/"+p.Platform)
+			}
+		}
+	}
+	return st, nil
+}
+
+type generator struct {
+	eng  *engine.Engine
+	rng  *rand.Rand
+	opts Options
+}
+
+// template emits up to Variants distinct variants of p's example command and
+// returns how many it emitted.
+func (g *generator) template(ctx context.Context, pf *models.ProfileFile, p models.Profile, emit func(Record) error) (int, error) {
+	// The engine runs a file's first profile, so it gets a file holding
+	// just this one.
+	single := *pf
+	single.Profiles = []models.Profile{p}
+	tokens := make([]models.Token, len(p.Parameters.Command))
+	for i, el := range p.Parameters.Command {
+		tokens[i] = el.ToToken()
+	}
+	original := engine.Render(tokens)
+	pool := g.pool(p)
+	if len(pool) == 0 {
+		return 0, nil
+	}
+
+	seen := map[string]bool{original: true}
+	n := 0
+	for tries := 0; n < g.opts.Variants && tries < g.opts.Variants*triesPerVariant; {
+		items := make([]engine.BatchItem, g.opts.Variants-n)
+		subsets := make([][]string, len(items))
+		for i := range items {
+			subsets[i] = g.subset(pool)
+			enabled := make(map[string]bool, len(subsets[i]))
+			for _, name := range subsets[i] {
+				enabled[name] = true
+			}
+			items[i] = engine.BatchItem{Command: original, Profile: &single, Enabled: enabled, Seed: g.seed()}
+		}
+		tries += len(items)
+		for i, r := range g.eng.ObfuscateBatch(ctx, items) {
+			if r.Err != nil {
+				return n, fmt.Errorf("corpus: %s: %w", pf.Name, r.Err)
+			}
+			if seen[r.Result.Output] {
+				continue
+			}
+			seen[r.Result.Output] = true
+			rec := Record{
+				Executable: pf.Name,
+				Platform:   p.Platform,
+				Attack:     pf.Techniques(),
+				Original:   original,
+				Obfuscated: r.Result.Output,
+				Enabled:    subsets[i],
+				Modifiers:  []string{},
+				Seed:       r.Seed,
+			}
+			for _, step := range r.Result.Trace {
+				if step.Changed() && !slices.Contains(rec.Modifiers, step.Modifier) {
+					rec.Modifiers = append(rec.Modifiers, step.Modifier)
+				}
+			}
+			if err := emit(rec); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// pool returns the modifiers p configures, restricted to Options.Modifiers,
+// in registry order so that a seeded run draws the same subsets every time.
+func (g *generator) pool(p models.Profile) []string {
+	var pool []string
+	for _, mod := range modifiers.All() {
+		name := mod.Name()
+		if _, ok := engine.ConfigFor(p, name); !ok {
+			continue
+		}
+		if len(g.opts.Modifiers) > 0 && !slices.Contains(g.opts.Modifiers, name) {
+			continue
+		}
+		pool = append(pool, name)
+	}
+	return pool
+}
+
+// subset draws a random subset of pool of at least MinModifiers modifiers,
+// in pool order.
+func (g *generator) subset(pool []string) []string {
+	k := min(max(g.opts.MinModifiers, 1), len(pool))
+	// Every size from k up is equally likely, then every subset of it.
+	size := k + g.rng.Intn(len(pool)-k+1)
+	picked := g.rng.Perm(len(pool))[:size]
+	slices.Sort(picked)
+	out := make([]string, size)
+	for i, j := range picked {
+		out[i] = pool[j]
+	}
+	return out
+}
+
+// seed returns the engine seed of the next variant; never zero, which would
+// ask the engine to draw its own.
+func (g *generator) seed() int64 {
+	if s := g.rng.Int63(); s != 0 {
+		return s
+	}
+	return 1
+}
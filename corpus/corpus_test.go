@@ -0,0 +1,159 @@
+package corpus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/data"
+	"cmdFuscator/engine"
+	_ "cmdFuscator/engine/modifiers/all"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+func bundled(t *testing.T) []*models.ProfileFile {
+	t.Helper()
+	profiles, err := loader.LoadFS(data.ModelFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return profiles
+}
+
+func collect(t *testing.T, profiles []*models.ProfileFile, opts Options) ([]Record, Stats) {
+	t.Helper()
+	var recs []Record
+	st, err := Generate(context.Background(), profiles, opts, func(r Record) error {
+		recs = append(recs, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	return recs, st
+}
+
+func TestGenerate(t *testing.T) {
+	profiles := bundled(t)
+	recs, st := collect(t, profiles, Options{Variants: 5, Seed: 1})
+	if st.Records != len(recs) || st.Templates == 0 {
+		t.Errorf("Stats = %+v for %d records", st, len(recs))
+	}
+	index := loader.IndexByName(profiles)
+	seen := make(map[string]bool)
+	perExe := make(map[string]int)
+	for _, r := range recs {
+		perExe[r.Executable]++
+		if r.Obfuscated == r.Original || seen[r.Obfuscated] {
+			t.Errorf("%s: variant %q is the original or a repeat", r.Executable, r.Obfuscated)
+		}
+		seen[r.Obfuscated] = true
+		if len(r.Enabled) == 0 {
+			t.Errorf("%s: no modifiers enabled", r.Executable)
+		}
+		for _, name := range r.Modifiers {
+			if !slices.Contains(r.Enabled, name) {
+				t.Errorf("%s: %s changed the command but was not enabled (%v)", r.Executable, name, r.Enabled)
+			}
+		}
+
+		// The record's seed and subset reproduce its variant.
+		pf := index[r.Executable]
+		enabled := make(map[string]bool)
+		for _, name := range r.Enabled {
+			enabled[name] = true
+		}
+		res := engine.New().ObfuscateBatch(context.Background(), []engine.BatchItem{{Command: r.Original, Profile: pf, Enabled: enabled, Seed: r.Seed}})
+		if res[0].Err != nil || res[0].Result.Output != r.Obfuscated {
+			t.Errorf("%s seed %d: reproduced %q, %v; want %q", r.Executable, r.Seed, res[0].Result.Output, res[0].Err, r.Obfuscated)
+		}
+	}
+	for _, pf := range profiles {
+		if perExe[pf.Name] != 5 {
+			t.Errorf("%s: %d records, want 5", pf.Name, perExe[pf.Name])
+		}
+	}
+
+	again, _ := collect(t, profiles, Options{Variants: 5, Seed: 1})
+	if !slices.EqualFunc(recs, again, func(a, b Record) bool { return a.Obfuscated == b.Obfuscated && a.Seed == b.Seed }) {
+		t.Error("the same seed gave a different dataset")
+	}
+}
+
+func TestGenerateModifiers(t *testing.T) {
+	allowed := []string{"RandomCase", "CharacterInsertion"}
+	recs, _ := collect(t, bundled(t), Options{Variants: 3, Seed: 2, Modifiers: allowed, MinModifiers: 2})
+	for _, r := range recs {
+		// Profiles configuring only one of the two get just that one.
+		want := []string{"CharacterInsertion", "RandomCase"}
+		if r.Executable == "powershell" {
+			want = []string{"RandomCase"}
+		}
+		if !slices.Equal(r.Enabled, want) {
+			t.Errorf("%s: enabled %v, want %v", r.Executable, r.Enabled, want)
+		}
+	}
+}
+
+func TestGenerateShort(t *testing.T) {
+	// bash -c id has few RandomCase variants.
+	pf := loader.IndexByName(bundled(t))["bash"]
+	recs, st := collect(t, []*models.ProfileFile{pf}, Options{Variants: 50, Seed: 3, Modifiers: []string{"RandomCase"}})
+	if len(recs) >= 50 || !slices.Equal(st.Short, []string{"bash/linux"}) {
+		t.Errorf("%d records, Short = %v; want fewer than 50 and bash/linux", len(recs), st.Short)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	profiles := bundled(t)
+	emit := func(Record) error { return nil }
+	if _, err := Generate(context.Background(), profiles, Options{}, emit); err == nil {
+		t.Error("Variants 0: want an error")
+	}
+	if _, err := Generate(context.Background(), profiles, Options{Variants: 1, Modifiers: []string{"Nope"}}, emit); err == nil || !strings.Contains(err.Error(), `"Nope"`) {
+		t.Errorf("unknown modifier: err = %v", err)
+	}
+	stop := errors.New("stop")
+	n := 0
+	st, err := Generate(context.Background(), profiles, Options{Variants: 5, Seed: 1}, func(Record) error {
+		if n++; n == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || st.Records != 2 {
+		t.Errorf("emit error: %d records, err %v; want 2 and stop", st.Records, err)
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	emit := JSONL(&buf)
+	recs := []Record{
+		{Executable: "certutil", Original: "certutil -f https://x/?a=1&b=2", Obfuscated: "c<e>rtutil", Enabled: []string{"RandomCase"}, Modifiers: []string{}, Seed: 7},
+		{Executable: "bash", Original: "bash -c id", Obfuscated: "bash -c i\u200dd", Enabled: []string{"CharacterInsertion"}, Modifiers: []string{"CharacterInsertion"}, Seed: 8},
+	}
+	for _, r := range recs {
+		if err := emit(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "&b=2") || !strings.Contains(lines[0], "c<e>rtutil") {
+		t.Errorf("line 1 = %s, want the commands unescaped", lines[0])
+	}
+	for i, line := range lines {
+		var got Record
+		if err := json.Unmarshal([]byte(line), &got); err != nil || got.Obfuscated != recs[i].Obfuscated || got.Seed != recs[i].Seed {
+			t.Errorf("line %d = %s, %v", i+1, line, err)
+		}
+	}
+}
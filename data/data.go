@@ -1,13 +1,15 @@
 // Package data embeds the bundled ArgFuscator-compatible JSON profile files.
 // Import this package and pass ModelFS to loader.LoadFS or tui.New.
 //
-// To add more profiles, place *.json files in data/models/ and rebuild.
+// To add more profiles, place *.json or *.yaml files in data/models/ (or a subdirectory
+// such as data/models/windows/) and rebuild.
 package data
 
 import "embed"
 
 // ModelFS contains all JSON profile files from data/models/.
-// The directory tree within the FS is preserved: files are at "models/<name>.json".
+// The directory tree within the FS is preserved: files are at "models/<name>.json"
+// or "models/<dir>/<name>.json".
 //
-//go:embed models/*.json
+//go:embed models
 var ModelFS embed.FS
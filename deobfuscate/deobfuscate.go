@@ -0,0 +1,301 @@
+// Package deobfuscate reduces an obfuscated command line to a normalized form,
+// undoing the techniques the engine's modifiers apply, so that variants of the
+// same command compare equal and can be matched against detection rules.
+//
+// Normalization is lossy by design: it folds case and drops characters that
+// can change meaning in rare, legitimate commands. Keep the original for
+// evidence and use the normalized form for triage and matching.
+package deobfuscate
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"cmdFuscator/engine/modifiers/charinsert"
+	"cmdFuscator/models"
+)
+
+// Step names one normalization. Result.Steps lists the ones that changed the
+// command, in the order Normalize applies them.
+type Step string
+
+const (
+	// StepInvisible removes format, unassigned and private-use code points
+	// such as U+200D ZERO WIDTH JOINER, and the characters passed to Strip
+	// (CharacterInsertion).
+	StepInvisible Step = "invisible"
+	// StepCompat folds compatibility characters, e.g. superscript "ᵃ" to
+	// "a" and fullwidth letters to ASCII (Sed, Regex).
+	StepCompat Step = "compat"
+	// StepDashes maps dash lookalikes at the start of a word, such as "–"
+	// and "−", to "-" (OptionCharSubstitution).
+	StepDashes Step = "dashes"
+	// StepCarets removes cmd.exe caret escapes outside quotes: c^e^r^t.
+	StepCarets Step = "carets"
+	// StepQuotes removes quotes that do not protect whitespace, so
+	// ce"rt"util becomes certutil (QuoteInsertion).
+	StepQuotes Step = "quotes"
+	// StepPaths collapses repeated separators and resolves "." and ".."
+	// segments in paths (FilePathTransformer).
+	StepPaths Step = "paths"
+	// StepCase lowercases everything but URLs (RandomCase).
+	StepCase Step = "case"
+)
+
+// Result is the outcome of Normalize.
+type Result struct {
+	Output string
+	Steps  []Step
+}
+
+// Option configures Normalize.
+type Option func(*config)
+
+type config struct {
+	keepCase bool
+	strip    map[rune]bool
+}
+
+// KeepCase leaves letter case alone, for case-sensitive targets such as
+// POSIX shells where RandomCase output would not run anyway.
+func KeepCase() Option {
+	return func(c *config) { c.keepCase = true }
+}
+
+// Strip also removes every character in chars, in either case. Character
+// pools may contain visible letters, such as U+037F GREEK CAPITAL LETTER YOT
+// in the certutil profile, which StepInvisible cannot tell from text; pass
+// InsertedChars of the profiles in use to remove them too.
+func Strip(chars ...string) Option {
+	return func(c *config) {
+		if c.strip == nil {
+			c.strip = make(map[rune]bool)
+		}
+		for _, s := range chars {
+			for _, r := range s {
+				// RandomCase may have changed an inserted letter's case.
+				for f := r; ; {
+					c.strip[f] = true
+					if f = unicode.SimpleFold(f); f == r {
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// InsertedChars returns the CharacterInsertion pools of every profile in
+// profiles, for Strip.
+func InsertedChars(profiles []*models.ProfileFile) []string {
+	var out []string
+	for _, pf := range profiles {
+		for _, p := range pf.Profiles {
+			raw, ok := p.Parameters.Modifiers["CharacterInsertion"]
+			if !ok {
+				continue
+			}
+			var cfg charinsert.Config
+			if json.Unmarshal(raw, &cfg) == nil {
+				out = append(out, cfg.Characters...)
+			}
+		}
+	}
+	return out
+}
+
+// Normalize returns the normalized form of command. Surrounding whitespace
+// is trimmed and runs of whitespace between words become single spaces.
+func Normalize(command string, opts ...Option) Result {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var res Result
+	apply := func(step Step, fn func(string) string) {
+		if out := fn(command); out != command {
+			command = out
+			res.Steps = append(res.Steps, step)
+		}
+	}
+
+	apply(StepInvisible, func(s string) string { return stripInvisible(s, cfg.strip) })
+	apply(StepCompat, norm.NFKC.String)
+	words := splitWords(strings.TrimSpace(command))
+	command = strings.Join(words, " ")
+
+	apply(StepDashes, mapWords(normalizeDash))
+	apply(StepCarets, mapWords(stripCarets))
+	apply(StepQuotes, mapWords(unquoteWord))
+	apply(StepPaths, mapWords(cleanPath))
+	if !cfg.keepCase {
+		apply(StepCase, mapWords(foldCase))
+	}
+	res.Output = command
+	return res
+}
+
+// ─── Steps ────────────────────────────────────────────────────────────────────
+
+func stripInvisible(s string, extra map[rune]bool) string {
+	return strings.Map(func(r rune) rune {
+		if extra[r] || unicode.In(r, unicode.Cf, unicode.Co) || !unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.P, unicode.S, unicode.Z, unicode.Cc) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// dashes are the lookalikes OptionCharSubstitution swaps for "-".
+const dashes = "‐‑‒–—―−﹘﹣－"
+
+func normalizeDash(w string) string {
+	lead, rest := splitQuote(w)
+	if r, size := utf8.DecodeRuneInString(rest); size > 0 && strings.ContainsRune(dashes, r) {
+		return lead + "-" + rest[size:]
+	}
+	return w
+}
+
+// unquoteWord removes the quotes from a word unless they protect whitespace,
+// in which case the word keeps a single pair around its whole content.
+func unquoteWord(w string) string {
+	if !strings.ContainsAny(w, `"'`) {
+		return w
+	}
+	var b strings.Builder
+	var quote rune
+	spaced := false
+	for _, r := range w {
+		switch {
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case r == quote:
+			quote = 0
+		default:
+			if quote != 0 && unicode.IsSpace(r) {
+				spaced = true
+			}
+			b.WriteRune(r)
+		}
+	}
+	if spaced {
+		return `"` + b.String() + `"`
+	}
+	return b.String()
+}
+
+// stripCarets removes the carets cmd.exe would consume: those outside double
+// quotes, each escaping the character after it.
+func stripCarets(w string) string {
+	if !strings.Contains(w, "^") {
+		return w
+	}
+	var b strings.Builder
+	quoted, escaped := false, false
+	for _, r := range w {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '"':
+			quoted = !quoted
+		case r == '^' && !quoted:
+			escaped = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cleanPath cleans words that look like paths, keeping their separator:
+// backslash when the word has any, slash otherwise. URLs are left alone.
+func cleanPath(w string) string {
+	if strings.Contains(w, "://") || !strings.ContainsAny(w, `/\`) || isSwitch(w) {
+		return w
+	}
+	sep := "/"
+	if strings.Contains(w, `\`) {
+		sep = `\`
+	}
+	slashed := strings.ReplaceAll(w, `\`, "/")
+	prefix := ""
+	if len(slashed) >= 2 && slashed[1] == ':' {
+		prefix, slashed = slashed[:2], slashed[2:]
+	}
+	cleaned := path.Clean(slashed)
+	if strings.HasSuffix(slashed, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	// "./tool" runs tool from the current directory, not from PATH.
+	if strings.HasPrefix(slashed, "./") && !strings.HasPrefix(cleaned, ".") {
+		cleaned = "./" + cleaned
+	}
+	return prefix + strings.ReplaceAll(cleaned, "/", sep)
+}
+
+// isSwitch reports whether w is a Windows-style switch such as /f, which
+// must not be mistaken for a path.
+func isSwitch(w string) bool {
+	return strings.HasPrefix(w, "/") && !strings.Contains(w[1:], "/") && !strings.Contains(w, `\`)
+}
+
+func foldCase(w string) string {
+	if strings.Contains(w, "://") {
+		return w
+	}
+	return strings.ToLower(w)
+}
+
+// ─── Words ────────────────────────────────────────────────────────────────────
+
+// splitWords splits s on whitespace outside quotes.
+func splitWords(s string) []string {
+	var words []string
+	var b strings.Builder
+	var quote rune
+	flush := func() {
+		if b.Len() > 0 {
+			words = append(words, b.String())
+			b.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case quote == 0 && unicode.IsSpace(r):
+			flush()
+			continue
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case r == quote:
+			quote = 0
+		}
+		b.WriteRune(r)
+	}
+	flush()
+	return words
+}
+
+func mapWords(fn func(string) string) func(string) string {
+	return func(s string) string {
+		words := splitWords(s)
+		for i, w := range words {
+			words[i] = fn(w)
+		}
+		return strings.Join(words, " ")
+	}
+}
+
+// splitQuote splits a leading quote off w.
+func splitQuote(w string) (lead, rest string) {
+	if strings.HasPrefix(w, `"`) || strings.HasPrefix(w, "'") {
+		return w[:1], w[1:]
+	}
+	return "", w
+}
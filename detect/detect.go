@@ -0,0 +1,73 @@
+// Package detect tests obfuscated command lines against detection logic, so
+// a run can report which variants a SOC would still catch and which evade.
+//
+// An Event is the process-creation record a variant would produce, and a
+// Detector is anything that decides whether an event raises an alert. Sigma
+// process_creation rules (see Rule and LoadRules) are Detectors.
+package detect
+
+import (
+	"path"
+	"strings"
+
+	"cmdFuscator/models"
+)
+
+// ─── Events ───────────────────────────────────────────────────────────────────
+
+// Event is a process-creation event as Sigma rules see it: field names such
+// as CommandLine, Image and OriginalFileName mapped to values. Fields are
+// looked up case-insensitively.
+type Event map[string]string
+
+// Get returns the value of field, and whether the event has it.
+func (ev Event) Get(field string) (string, bool) {
+	if v, ok := ev[field]; ok {
+		return v, true
+	}
+	for k, v := range ev {
+		if strings.EqualFold(k, field) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// EventFor returns the event running commandLine would log on a host where
+// pf's executable is installed. Obfuscation changes the command line only,
+// so Image is the executable's usual location whatever the command line
+// spells, and on Windows OriginalFileName is set from the PE header's name,
+// which Sigma rules match to catch renamed binaries.
+func EventFor(pf *models.ProfileFile, commandLine string) Event {
+	ev := Event{"CommandLine": commandLine}
+	windows := len(pf.Profiles) > 0 && strings.EqualFold(pf.Profiles[0].Platform, "windows")
+	if windows {
+		ev["Image"] = `C:\Windows\System32\` + pf.Name + ".exe"
+		ev["OriginalFileName"] = pf.Name + ".exe"
+	} else {
+		ev["Image"] = path.Join("/usr/bin", pf.Name)
+	}
+	return ev
+}
+
+// ─── Detectors ────────────────────────────────────────────────────────────────
+
+// Detector decides whether an event raises an alert.
+type Detector interface {
+	// Name identifies the detector in reports, e.g. a rule's title.
+	Name() string
+	// Match reports whether ev raises the detector's alert.
+	Match(ev Event) bool
+}
+
+// Hits returns the detectors in ds that match ev, in order. A variant evades
+// ds when Hits returns none.
+func Hits[D Detector](ds []D, ev Event) []D {
+	var out []D
+	for _, d := range ds {
+		if d.Match(ev) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
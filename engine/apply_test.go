@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/data"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+// inPlace is a careless modifier: it rewrites and extends its input in place,
+// then fails with err when set.
+type inPlace struct{ err error }
+
+func (m inPlace) Name() string        { return "inPlace" }
+func (m inPlace) Description() string { return "rewrites its input in place" }
+func (m inPlace) Apply(tokens []models.Token, _ json.RawMessage) ([]models.Token, error) {
+	for i := range tokens {
+		tokens[i].Value = "X"
+	}
+	return append(tokens[:len(tokens):len(tokens)], models.Token{Value: "Y"}), m.err
+}
+
+func TestApplyModifier_Isolates(t *testing.T) {
+	in := []models.Token{{Type: models.TokenTypeCommand, Value: "tool"}, {Type: models.TokenTypeArgument, Value: "-a"}}
+	want := slices.Clone(in)
+
+	out, err := ApplyModifier(modifiers.Context{}, inPlace{}, in, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(in, want) {
+		t.Errorf("input = %+v after Apply, want it untouched", in)
+	}
+	if len(out) != 3 || out[0].Value != "X" {
+		t.Errorf("output = %+v, want the modifier's result", out)
+	}
+
+	fail := errors.New("broken")
+	out, err = ApplyModifier(modifiers.Context{}, inPlace{err: fail}, in, nil)
+	if !errors.Is(err, fail) {
+		t.Fatalf("err = %v, want %v", err, fail)
+	}
+	if !slices.Equal(in, want) || !slices.Equal(out, want) {
+		t.Errorf("on error: input %+v, output %+v; want both untouched", in, out)
+	}
+}
+
+// TestPipeline_NeverMutates runs every bundled profile's modifiers and checks
+// that none of them reaches back into the caller's tokens, which the first
+// trace step still holds.
+func TestPipeline_NeverMutates(t *testing.T) {
+	sub, err := fs.Sub(data.ModelFS, "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := loader.LoadFS(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := New(WithTrace(true), WithSeed(1))
+	for _, pf := range profiles {
+		var words []string
+		for _, el := range pf.Profiles[0].Parameters.Command {
+			words = append(words, el.StringValue())
+		}
+		tokens, err := Tokenize(strings.Join(words, " "), pf.Profiles[0])
+		if err != nil {
+			t.Fatalf("%s: tokenize: %v", pf.Name, err)
+		}
+		input := slices.Clone(tokens)
+
+		res, err := eng.ObfuscateTokens(tokens, pf, DefaultEnabled(pf))
+		if err != nil {
+			t.Fatalf("%s: %v", pf.Name, err)
+		}
+		if !slices.Equal(tokens, input) {
+			t.Errorf("%s: caller's tokens changed to %q", pf.Name, Render(tokens))
+		}
+		if len(res.Trace) > 0 && !slices.Equal(res.Trace[0].Before, input) {
+			t.Errorf("%s: first step saw %q, want the input", pf.Name, Render(res.Trace[0].Before))
+		}
+	}
+}
+
+// countingParser appends its config string to every token and records each
+// parse of its config. A config of `"bad"` fails to parse.
+type countingParser struct {
+	parses    int
+	artifacts []*modifiers.Artifacts // handed to each ParseConfig call
+}
+
+func (m *countingParser) Name() string        { return "countingParser" }
+func (m *countingParser) Description() string { return "" }
+func (m *countingParser) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return m.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+func (m *countingParser) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := m.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return m.ApplyParsed(c, tokens, parsed)
+}
+func (m *countingParser) ParseConfig(a *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	m.parses++
+	m.artifacts = append(m.artifacts, a)
+	var suffix string
+	if err := json.Unmarshal(cfg, &suffix); err != nil || suffix == "bad" {
+		return nil, errors.New("bad config")
+	}
+	return suffix, nil
+}
+func (m *countingParser) ApplyParsed(_ modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	for i := range tokens {
+		tokens[i].Value += parsed.(string)
+	}
+	return tokens, nil
+}
+
+func TestConfigCache(t *testing.T) {
+	e := New()
+	m := &countingParser{}
+	in := []models.Token{{Type: models.TokenTypeCommand, Value: "tool"}}
+	for _, cfg := range []string{`"!"`, `"!"`, `"?"`, `"!"`} {
+		out, err := e.apply(modifiers.Context{}, m, in, json.RawMessage(cfg))
+		if err != nil {
+			t.Fatalf("apply(%s): %v", cfg, err)
+		}
+		if want := "tool" + cfg[1:2]; out[0].Value != want {
+			t.Errorf("apply(%s) = %q, want %q", cfg, out[0].Value, want)
+		}
+	}
+	if in[0].Value != "tool" {
+		t.Errorf("input changed to %q", in[0].Value)
+	}
+	if m.parses != 2 {
+		t.Errorf("configs parsed %d times, want once each", m.parses)
+	}
+
+	for range 2 {
+		if _, err := e.apply(modifiers.Context{}, m, in, json.RawMessage(`"bad"`)); err == nil {
+			t.Error(`apply("bad"): want an error`)
+		}
+	}
+	if m.parses != 4 {
+		t.Errorf("configs parsed %d times, want a broken one parsed on every run", m.parses)
+	}
+	if _, err := New().apply(modifiers.Context{}, m, in, json.RawMessage(`"!"`)); err != nil || m.parses != 5 {
+		t.Errorf("a new engine parsed %d configs (err %v), want its own cache", m.parses, err)
+	}
+	if a := m.artifacts; a[0] == nil || a[1] != a[0] || a[2] != a[0] || a[4] == a[0] {
+		t.Errorf("ParseConfig got artifacts %p, want one cache per engine", a)
+	}
+}
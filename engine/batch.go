@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sync"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// BatchItem is one unit of work for ObfuscateBatch.
+type BatchItem struct {
+	Command string
+	Profile *models.ProfileFile
+	Enabled map[string]bool
+
+	// Tokens, when non-nil, is obfuscated as by ObfuscateTokens instead of
+	// tokenizing Command, typically so some tokens can be marked Frozen.
+	// Command is then only the input the result is scored against, and is
+	// rendered from Tokens when empty.
+	Tokens []models.Token
+
+	// Seed fixes the random source for this item. Zero draws a fresh seed
+	// from the engine's sequence (see WithSeed); the chosen value is reported
+	// back in BatchResult.Seed so any item can be reproduced later.
+	Seed int64
+}
+
+// BatchResult is the outcome of one BatchItem. Results are returned in the
+// same order as the items they belong to.
+type BatchResult struct {
+	Index  int   // position of the item in the input slice
+	Seed   int64 // seed the item was obfuscated with
+	Result ObfuscateResult
+	Err    error
+}
+
+// ObfuscateBatch obfuscates every item concurrently over a pool of workers
+// (see WithWorkers). Each worker owns its RNG, so workers share no random
+// state, and an item's output depends only on its seed — not on which worker
+// picked it up. Items without a Seed are given theirs in item order before
+// any work starts.
+//
+// When ctx is cancelled, items that have not started yet are returned with
+// Err set to ctx.Err(), and items in flight stop before their next modifier
+// with an Err wrapping it.
+func (e *Engine) ObfuscateBatch(ctx context.Context, items []BatchItem) []BatchResult {
+	results := make([]BatchResult, len(items))
+	if len(items) == 0 {
+		return results
+	}
+
+	workers := e.workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	seeds := make([]int64, len(items))
+	for i, item := range items {
+		if seeds[i] = item.Seed; seeds[i] == 0 {
+			seeds[i] = e.seeds.next()
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// One source per worker, reseeded for every item.
+			itemRand := newRand(0)
+			for i := range jobs {
+				results[i] = e.runItem(ctx, itemRand, i, seeds[i], items[i])
+			}
+		}()
+	}
+
+	for i := range items {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(items); j++ {
+				results[j] = BatchResult{Index: j, Seed: seeds[j], Err: ctx.Err()}
+			}
+			close(jobs)
+			wg.Wait()
+			return results
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// runItem obfuscates a single batch item on the calling worker.
+func (e *Engine) runItem(ctx context.Context, itemRand *rand.Rand, i int, seed int64, item BatchItem) BatchResult {
+	out := BatchResult{Index: i, Seed: seed}
+
+	if err := ctx.Err(); err != nil {
+		out.Err = err
+		return out
+	}
+
+	command := item.Command
+	if item.Tokens != nil && command == "" {
+		command = Render(item.Tokens)
+	}
+	itemRand.Seed(seed)
+	out.Result, out.Err = e.run(ctx, modifiers.Context{Rand: itemRand}, command, item.Tokens, item.Profile, item.Enabled)
+	return out
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+// randomCaseFile is a minimal profile file whose only modifier is RandomCase,
+// which makes output depend entirely on the random source.
+func randomCaseFile() *models.ProfileFile {
+	return &models.ProfileFile{
+		Name: "tool",
+		Profiles: []models.Profile{{
+			Platform: "linux",
+			Parameters: models.ProfileParameters{
+				Modifiers: map[string]json.RawMessage{
+					"RandomCase": json.RawMessage(`{"AppliesTo":["argument","value"],"Probability":"0.5"}`),
+				},
+			},
+		}},
+	}
+}
+
+var randomCaseOnly = map[string]bool{"RandomCase": true}
+
+func TestObfuscateBatch_OrderAndCount(t *testing.T) {
+	pf := randomCaseFile()
+	items := make([]BatchItem, 50)
+	for i := range items {
+		items[i] = BatchItem{Command: fmt.Sprintf("tool --item-%d", i), Profile: pf, Enabled: randomCaseOnly}
+	}
+
+	results := New(WithWorkers(4)).ObfuscateBatch(context.Background(), items)
+	if len(results) != len(items) {
+		t.Fatalf("got %d results, want %d", len(results), len(items))
+	}
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("results[%d].Index = %d", i, r.Index)
+		}
+		if r.Err != nil {
+			t.Errorf("results[%d].Err = %v", i, r.Err)
+		}
+		if r.Seed == 0 {
+			t.Errorf("results[%d].Seed was not reported", i)
+		}
+	}
+}
+
+// An item's output must depend only on its seed, regardless of worker count
+// or scheduling.
+func TestObfuscateBatch_SeedIsReproducible(t *testing.T) {
+	pf := randomCaseFile()
+	items := make([]BatchItem, 20)
+	for i := range items {
+		items[i] = BatchItem{
+			Command: "tool --some-long-argument-name another-long-value",
+			Profile: pf,
+			Enabled: randomCaseOnly,
+			Seed:    int64(i + 1),
+		}
+	}
+
+	first := New(WithWorkers(1)).ObfuscateBatch(context.Background(), items)
+	second := New(WithWorkers(8)).ObfuscateBatch(context.Background(), items)
+
+	distinct := map[string]bool{}
+	for i := range items {
+		if first[i].Result.Output != second[i].Result.Output {
+			t.Errorf("item %d: seed %d gave %q and %q", i, items[i].Seed,
+				first[i].Result.Output, second[i].Result.Output)
+		}
+		distinct[first[i].Result.Output] = true
+	}
+	if len(distinct) < 2 {
+		t.Error("different seeds should produce different variants")
+	}
+
+	// A reported seed fed back in reproduces the variant.
+	drawn := New().ObfuscateBatch(context.Background(), []BatchItem{{Command: items[0].Command, Profile: pf, Enabled: randomCaseOnly}})
+	replay := New().ObfuscateBatch(context.Background(), []BatchItem{{Command: items[0].Command, Profile: pf, Enabled: randomCaseOnly, Seed: drawn[0].Seed}})
+	if drawn[0].Result.Output != replay[0].Result.Output {
+		t.Errorf("replaying seed %d: got %q, want %q", drawn[0].Seed, replay[0].Result.Output, drawn[0].Result.Output)
+	}
+}
+
+// Under WithSeed, items without a Seed draw theirs in item order, so a whole
+// batch is reproducible whatever the worker count.
+func TestObfuscateBatch_WithSeed(t *testing.T) {
+	pf := randomCaseFile()
+	items := make([]BatchItem, 20)
+	for i := range items {
+		items[i] = BatchItem{Command: "tool --some-long-argument-name another-long-value", Profile: pf, Enabled: randomCaseOnly}
+	}
+
+	first := New(WithSeed(42), WithWorkers(1)).ObfuscateBatch(context.Background(), items)
+	second := New(WithSeed(42), WithWorkers(8)).ObfuscateBatch(context.Background(), items)
+	other := New(WithSeed(43)).ObfuscateBatch(context.Background(), items)
+	changed := false
+	for i := range items {
+		if first[i].Seed != second[i].Seed || first[i].Result.Output != second[i].Result.Output {
+			t.Errorf("item %d: got seed %d %q and seed %d %q", i,
+				first[i].Seed, first[i].Result.Output, second[i].Seed, second[i].Result.Output)
+		}
+		changed = changed || first[i].Seed != other[i].Seed
+	}
+	if !changed {
+		t.Error("a different engine seed should draw different item seeds")
+	}
+}
+
+func TestObfuscateBatch_PerItemErrors(t *testing.T) {
+	items := []BatchItem{
+		{Command: "tool -x", Profile: randomCaseFile()},
+		{Command: "tool -x", Profile: nil},
+	}
+	results := New().ObfuscateBatch(context.Background(), items)
+	if results[0].Err != nil {
+		t.Errorf("item 0: unexpected error %v", results[0].Err)
+	}
+	if results[1].Err == nil {
+		t.Error("item 1: expected an error for a nil profile")
+	}
+}
+
+func TestObfuscateBatch_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items := []BatchItem{{Command: "tool -x", Profile: randomCaseFile()}, {Command: "tool -y", Profile: randomCaseFile()}}
+	for _, r := range New().ObfuscateBatch(ctx, items) {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v, want context.Canceled", r.Index, r.Err)
+		}
+	}
+}
+
+func TestObfuscateBatch_Empty(t *testing.T) {
+	if got := New().ObfuscateBatch(context.Background(), nil); len(got) != 0 {
+		t.Errorf("got %d results for no items", len(got))
+	}
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"strings"
+
+	"cmdFuscator/models"
+)
+
+// executableExts are the file extensions stripped when matching an executable
+// against profile names, so "certutil.exe" finds the "certutil" profile.
+var executableExts = []string{".exe", ".com", ".bat", ".cmd", ".ps1", ".sh"}
+
+// DetectProfile returns the ProfileFile whose name or alias matches the
+// executable at the start of command. The executable is matched
+// case-insensitively on its basename with any directory and executable
+// extension removed, so `C:\Windows\System32\certutil.exe -urlcache` resolves
+// to "certutil" and `pwsh -c ...` to the powershell profile. Names take
+// precedence over aliases.
+func DetectProfile(command string, profiles []*models.ProfileFile) (*models.ProfileFile, bool) {
+	words := splitWords(command)
+	if len(words) == 0 {
+		return nil, false
+	}
+	exe := ExecutableName(words[0])
+	if exe == "" {
+		return nil, false
+	}
+
+	for _, pf := range profiles {
+		if ExecutableName(pf.Name) == exe {
+			return pf, true
+		}
+	}
+	for _, pf := range profiles {
+		for _, alias := range pf.Aliases() {
+			if ExecutableName(alias) == exe {
+				return pf, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ExecutableName normalises an executable reference to the form used for
+// profile lookups: quotes and directories removed, a trailing executable
+// extension stripped, lowercased.
+func ExecutableName(word string) string {
+	name := strings.ToLower(unquote(word))
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, ext := range executableExts {
+		if base, ok := strings.CutSuffix(name, ext); ok && base != "" {
+			return base
+		}
+	}
+	return name
+}
@@ -1,18 +1,23 @@
 // Package engine orchestrates the obfuscation pipeline:
 //
 //  1. Parse  – turn a raw command string into a typed []models.Token
-//  2. Modify – apply each enabled Modifier in registration order
+//  2. Modify – apply each enabled Modifier in registration order (or that set
+//     with WithOrder), or the steps set with WithPipeline in theirs
 //  3. Render – join the modified tokens back into an output string
 //
-// The Parse and Render steps are stubbed; implement them here once you are
-// comfortable with the modifier implementations.
+// Tokenize lives in tokenize.go and Render (with its shell-specific
+// RenderTarget dialects) in render.go.
 package engine
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strconv"
 	"strings"
+	"time"
 
 	"cmdFuscator/engine/modifiers"
 	"cmdFuscator/models"
@@ -23,67 +28,189 @@ import (
 
 // Engine is the top-level obfuscation coordinator. Create one with New() and
 // reuse it across calls — it is safe for concurrent use once constructed.
-type Engine struct{}
+type Engine struct {
+	target    RenderTarget
+	workers   int
+	stats     bool
+	trace     bool
+	policy    ErrorPolicy
+	strict    bool
+	freeze    []models.TokenType
+	probs     map[string]float64
+	overrides map[string]json.RawMessage
+	pipeline  []PipelineStep
+	order     []string
+	seed      int64
+
+	validators []Validator
+	logger     *slog.Logger
+
+	// seeds seeds the random source of every run not given one, starting
+	// from seed.
+	seeds engineSeeds
+
+	// configs caches the parsed configs of modifiers.ConfigParser modifiers.
+	configs configCache
+}
 
 // New returns a ready-to-use Engine. All modifiers registered via
 // modifiers.Register() (typically via init() in each modifier file) are
-// available automatically.
-func New() *Engine {
-	return &Engine{}
+// available automatically. Options customise rendering and other behaviour.
+func New(opts ...Option) *Engine {
+	e := &Engine{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.seeds.reset(e.seed)
+	return e
 }
 
 // ObfuscateResult holds both the output command and a per-modifier summary
 // so the TUI can show which techniques were actually applied.
 type ObfuscateResult struct {
 	Output  string
-	Applied []string // names of modifiers that ran without error
-	Skipped []string // names of modifiers that returned ErrNotImplemented
+	Target  RenderTarget // shell dialect Output was rendered for
+	Applied []string     // names of modifiers that ran without error
+	Skipped []string     // names of modifiers that returned ErrNotImplemented
 	Errors  map[string]error
+	Stats   *Stats      // timing and touch counts; nil unless WithStats(true)
+	Trace   []TraceStep // one step per modifier dispatched; nil unless WithTrace(true)
+	Score   Score       // how far Output has moved from the input command
+
+	// Segments maps Output back to the input, one segment per final token;
+	// nil unless WithTrace(true).
+	Segments []Segment
+
+	// Warnings holds what the WithValidators validators found wrong with
+	// Output, one error per failed check; nil when all passed.
+	Warnings []error
 }
 
 // Obfuscate runs the full pipeline against command using the first profile in pf
 // that matches the host platform (or the first profile if none match).
 //
 // enabled is a set of modifier names the user has toggled on in the TUI;
-// modifiers absent from the map, or mapped to false, are skipped.
+// modifiers absent from the map, or mapped to false, are skipped. An engine
+// built with WithPipeline ignores enabled and runs the pipeline's steps.
 func (e *Engine) Obfuscate(command string, pf *models.ProfileFile, enabled map[string]bool) (ObfuscateResult, error) {
+	return e.ObfuscateContext(context.Background(), command, pf, enabled)
+}
+
+// ObfuscateContext is Obfuscate with a context. The pipeline checks ctx
+// before every modifier and, once it is done, stops with an error wrapping
+// ctx.Err(); a modifier that is already running is left to finish.
+func (e *Engine) ObfuscateContext(ctx context.Context, command string, pf *models.ProfileFile, enabled map[string]bool) (ObfuscateResult, error) {
+	return e.run(ctx, e.modContext(), command, nil, pf, enabled)
+}
+
+// ObfuscateTokens is Obfuscate for callers that tokenized the command
+// themselves, typically to mark some tokens Frozen first. The tokens are used
+// as given, apart from the types frozen with WithFrozenTypes.
+func (e *Engine) ObfuscateTokens(tokens []models.Token, pf *models.ProfileFile, enabled map[string]bool) (ObfuscateResult, error) {
+	return e.ObfuscateTokensContext(context.Background(), tokens, pf, enabled)
+}
+
+// ObfuscateTokensContext is ObfuscateTokens with a context, checked as in
+// ObfuscateContext.
+func (e *Engine) ObfuscateTokensContext(ctx context.Context, tokens []models.Token, pf *models.ProfileFile, enabled map[string]bool) (ObfuscateResult, error) {
+	return e.run(ctx, e.modContext(), Render(tokens), tokens, pf, enabled)
+}
+
+// modContext returns the modifier context of a run not given a seed: a fresh
+// source seeded from the engine's sequence, so concurrent runs share no
+// state.
+func (e *Engine) modContext() modifiers.Context {
+	return modifiers.Context{Rand: newRand(e.seeds.next())}
+}
+
+// run is the pipeline shared by Obfuscate and ObfuscateBatch; mc supplies the
+// random source handed to each modifier. command is tokenized unless tokens is
+// non-nil, in which case command is only the input the result is scored against.
+// ctx is checked before every modifier.
+func (e *Engine) run(ctx context.Context, mc modifiers.Context, command string, tokens []models.Token, pf *models.ProfileFile, enabled map[string]bool) (ObfuscateResult, error) {
 	if pf == nil || len(pf.Profiles) == 0 {
 		return ObfuscateResult{}, errors.New("engine: no profiles available")
 	}
 
 	profile := pickProfile(pf)
+	mc.Arguments = profile.Parameters.Arguments
+	mc.Executable = pf.Name
+	mc.Platform, mc.Paths = profile.Platform, profile.Paths
+
+	var stats *Stats
+	start := time.Now()
+	if e.stats {
+		stats = &Stats{}
+	}
 
 	// ── Step 1: Tokenize ─────────────────────────────────────────────────────
-	// TODO: implement Tokenize in tokenize.go.
-	// It should use profile.Parameters.Arguments to identify flags and their
-	// value counts, then classify each whitespace-separated token.
-	tokens, err := Tokenize(command, profile)
-	if err != nil {
-		return ObfuscateResult{}, fmt.Errorf("engine: tokenize: %w", err)
+	if tokens == nil {
+		var err error
+		if tokens, err = Tokenize(command, profile); err != nil {
+			return ObfuscateResult{}, fmt.Errorf("engine: tokenize: %w", err)
+		}
+	}
+	if len(e.freeze) > 0 {
+		tokens = FreezeTypes(tokens, e.freeze...)
+	}
+	if stats != nil {
+		stats.Tokenize = time.Since(start)
+		stats.Tokens = len(tokens)
 	}
 
 	// ── Step 2: Apply modifiers ───────────────────────────────────────────────
-	result := ObfuscateResult{Errors: make(map[string]error)}
+	result := ObfuscateResult{Errors: make(map[string]error), Stats: stats}
+	original := tokens
 
-	for _, mod := range modifiers.All() {
-		if !enabled[mod.Name()] {
-			continue
+	for _, st := range e.stages(enabled) {
+		mod := st.mod
+		if err := ctx.Err(); err != nil {
+			return ObfuscateResult{}, fmt.Errorf("engine: stopped before %s: %w", mod.Name(), err)
 		}
-
 		rawCfg, hasCfg := profile.Parameters.Modifiers[mod.Name()]
-		if !hasCfg {
+		if !hasCfg && st.override == nil {
 			// Profile does not define this modifier; silently skip.
 			continue
 		}
+		if p, ok := e.probs[mod.Name()]; ok && hasCfg {
+			rawCfg = withProbability(rawCfg, p)
+		}
+		if st.override != nil {
+			rawCfg = overlayConfig(rawCfg, st.override)
+		}
 
-		modified, err := mod.Apply(tokens, rawCfg)
+		// Frozen tokens are withheld from the modifier and spliced back after.
+		visible, frozen := splitFrozen(tokens)
+		modStart := time.Now()
+		modified, err := e.apply(mc, mod, visible, rawCfg)
+		if err == nil {
+			modified = mergeFrozen(modified, frozen)
+		}
+		e.logModifier(ctx, pf, mod.Name(), time.Since(modStart), tokens, modified, err)
+		if stats != nil {
+			ms := ModifierStats{Name: mod.Name(), Duration: time.Since(modStart)}
+			if err == nil {
+				ms.TokensTouched = tokensTouched(tokens, modified)
+			}
+			stats.Modifiers = append(stats.Modifiers, ms)
+		}
+		if e.trace {
+			step := TraceStep{Modifier: mod.Name(), Before: tokens, After: tokens, Err: err}
+			if err == nil {
+				step.After = modified
+			}
+			result.Trace = append(result.Trace, step)
+		}
 		if err != nil {
-			if errors.Is(err, modifiers.ErrNotImplemented) {
+			if errors.Is(err, modifiers.ErrNotImplemented) && !e.strict {
 				result.Skipped = append(result.Skipped, mod.Name())
-			} else {
-				result.Errors[mod.Name()] = err
+				continue
+			}
+			if e.policy == FailFast {
+				return ObfuscateResult{}, fmt.Errorf("engine: modifier %s: %w", mod.Name(), err)
 			}
 			// Leave tokens unchanged and continue with remaining modifiers.
+			result.Errors[mod.Name()] = err
 			continue
 		}
 
@@ -92,60 +219,51 @@ func (e *Engine) Obfuscate(command string, pf *models.ProfileFile, enabled map[s
 	}
 
 	// ── Step 3: Render ────────────────────────────────────────────────────────
-	// TODO: implement Render in render.go.
-	// It should reconstruct quoting and spacing correctly rather than just
-	// joining with spaces.
-	result.Output = Render(tokens)
+	result.Target = e.target
+	if result.Target == TargetAuto {
+		result.Target = TargetFor(profile)
+	}
+	renderStart := time.Now()
+	parts := renderParts(tokens, result.Target)
+	result.Output = strings.Join(parts, " ")
+	if e.trace {
+		result.Segments = segments(parts, tokens, original, result.Target)
+	}
+	if stats != nil {
+		stats.Render = time.Since(renderStart)
+		stats.Total = time.Since(start)
+	}
+	result.Score = ComputeScore(command, result.Output, original, tokens)
+	e.validate(&result)
+	e.logRun(ctx, pf, &result, time.Since(start))
 
 	return result, nil
 }
 
-// ─── Stubs (implement these) ──────────────────────────────────────────────────
+// ─── Error policy ─────────────────────────────────────────────────────────────
 
-// Tokenize parses a raw command string into a slice of typed tokens.
-//
-// TODO: Implement this function.
-//
-// Guidance:
-//   - Split on whitespace (but respect quoted strings).
-//   - The first token is always TokenTypeCommand.
-//   - Use profile.Parameters.Arguments to identify known flags; the token
-//     immediately following a flag with ValueCount > 0 is TokenTypeValue.
-//   - Tokens that look like file paths (contain / or \) → TokenTypePath.
-//   - Tokens that start with http:// or https:// → TokenTypeURL.
-//   - Everything else → TokenTypeArgument or TokenTypeValue depending on context.
-func Tokenize(command string, profile models.Profile) ([]models.Token, error) {
-	// Minimal fallback: split on whitespace, label first token as command,
-	// rest as argument. Replace this with a proper implementation.
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return nil, errors.New("tokenize: empty command")
-	}
+// ErrorPolicy controls what the pipeline does when a modifier returns an error.
+type ErrorPolicy int
 
-	tokens := make([]models.Token, len(parts))
-	tokens[0] = models.Token{Type: models.TokenTypeCommand, Value: parts[0]}
-	for i, p := range parts[1:] {
-		tokens[i+1] = models.Token{Type: models.TokenTypeArgument, Value: p}
-	}
+const (
+	// BestEffort records the error in ObfuscateResult.Errors, leaves the
+	// tokens as they were, and continues with the remaining modifiers.
+	BestEffort ErrorPolicy = iota
 
-	return tokens, nil
-}
+	// FailFast aborts the run and returns the first modifier error, wrapped
+	// with the modifier's name.
+	FailFast
+)
 
-// Render joins a token slice back into a command string.
-//
-// TODO: Implement this function.
-//
-// Guidance:
-//   - Insert spaces between tokens (matching the original spacing where possible).
-//   - Re-apply quoting when a token value contains spaces.
-//   - This is the inverse of Tokenize; the round-trip should be lossless for
-//     unmodified tokens.
-func Render(tokens []models.Token) string {
-	parts := make([]string, len(tokens))
-	for i, t := range tokens {
-		parts[i] = t.Value
+// String returns the policy's name.
+func (p ErrorPolicy) String() string {
+	switch p {
+	case BestEffort:
+		return "best-effort"
+	case FailFast:
+		return "fail-fast"
 	}
-	return strings.Join(parts, " ")
+	return fmt.Sprintf("ErrorPolicy(%d)", int(p))
 }
 
 // ─── Helpers ──────────────────────────────────────────────────────────────────
@@ -156,6 +274,21 @@ func pickProfile(pf *models.ProfileFile) models.Profile {
 	return pf.Profiles[0]
 }
 
+// withProbability returns raw with its Probability replaced by p. A config
+// that is not a JSON object is returned unchanged, for the modifier to reject.
+func withProbability(raw json.RawMessage, p float64) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return raw
+	}
+	fields["Probability"], _ = json.Marshal(strconv.FormatFloat(p, 'f', -1, 64))
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
 // ModifierSummary returns a []ModifierInfo describing all registered modifiers
 // and whether each one is enabled, for use by the TUI options panel.
 func ModifierSummary(enabled map[string]bool) []ModifierInfo {
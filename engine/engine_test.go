@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// policyFile defines one working modifier, one with a broken config and one
+// that is not implemented yet.
+func policyFile() *models.ProfileFile {
+	return &models.ProfileFile{
+		Name: "tool",
+		Profiles: []models.Profile{{
+			Platform: "linux",
+			Parameters: models.ProfileParameters{
+				Modifiers: map[string]json.RawMessage{
+					"RandomCase":         json.RawMessage(`{"AppliesTo":["argument"],"Probability":"1.0"}`),
+					"CharacterInsertion": json.RawMessage(`{"AppliesTo":["argument"],"Probability":"1.0","Characters":[]}`),
+					"Sed":                json.RawMessage(`{"AppliesTo":["argument"],"Probability":"1.0"}`),
+				},
+			},
+		}},
+	}
+}
+
+var policyEnabled = map[string]bool{"RandomCase": true, "CharacterInsertion": true, "Sed": true}
+
+func TestErrorPolicy_BestEffort(t *testing.T) {
+	res, err := New().Obfuscate("tool -a", policyFile(), policyEnabled)
+	if err != nil {
+		t.Fatalf("best effort should not fail: %v", err)
+	}
+	if res.Output != "tool -A" {
+		t.Errorf("Output = %q, want %q", res.Output, "tool -A")
+	}
+	if _, ok := res.Errors["CharacterInsertion"]; !ok {
+		t.Errorf("Errors = %v, want CharacterInsertion entry", res.Errors)
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0] != "Sed" {
+		t.Errorf("Skipped = %v, want [Sed]", res.Skipped)
+	}
+}
+
+func TestErrorPolicy_FailFast(t *testing.T) {
+	_, err := New(WithErrorPolicy(FailFast)).Obfuscate("tool -a", policyFile(), policyEnabled)
+	if err == nil {
+		t.Fatal("fail fast should return the CharacterInsertion error")
+	}
+
+	// Unimplemented modifiers are still only skipped without WithStrict.
+	enabled := map[string]bool{"RandomCase": true, "Sed": true}
+	res, err := New(WithErrorPolicy(FailFast)).Obfuscate("tool -a", policyFile(), enabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Skipped) != 1 {
+		t.Errorf("Skipped = %v, want [Sed]", res.Skipped)
+	}
+}
+
+func TestErrorPolicy_Strict(t *testing.T) {
+	enabled := map[string]bool{"RandomCase": true, "Sed": true}
+
+	res, err := New(WithStrict(true)).Obfuscate("tool -a", policyFile(), enabled)
+	if err != nil {
+		t.Fatalf("strict best effort should not fail: %v", err)
+	}
+	if len(res.Skipped) != 0 || !errors.Is(res.Errors["Sed"], modifiers.ErrNotImplemented) {
+		t.Errorf("Skipped = %v, Errors = %v; want Sed in Errors", res.Skipped, res.Errors)
+	}
+
+	_, err = New(WithStrict(true), WithErrorPolicy(FailFast)).Obfuscate("tool -a", policyFile(), enabled)
+	if !errors.Is(err, modifiers.ErrNotImplemented) {
+		t.Errorf("strict fail fast: err = %v, want ErrNotImplemented", err)
+	}
+}
+
+func TestWithProbabilities(t *testing.T) {
+	enabled := map[string]bool{"RandomCase": true}
+	res, err := New(WithProbabilities(map[string]float64{"RandomCase": 0})).Obfuscate("tool -abc", policyFile(), enabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output != "tool -abc" {
+		t.Errorf("Output = %q, want the input unchanged at probability 0", res.Output)
+	}
+
+	// Other modifiers keep their profile's probability.
+	res, err = New(WithProbabilities(map[string]float64{"Sed": 0})).Obfuscate("tool -abc", policyFile(), enabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output != "tool -ABC" {
+		t.Errorf("Output = %q, want %q", res.Output, "tool -ABC")
+	}
+}
+
+func TestWithProbability(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`{"AppliesTo":["argument"],"Probability":"1.0"}`, `{"AppliesTo":["argument"],"Probability":"0.25"}`},
+		{`{"AppliesTo":["argument"]}`, `{"AppliesTo":["argument"],"Probability":"0.25"}`},
+		{`[1]`, `[1]`},
+		{`null`, `null`},
+	}
+	for _, tt := range tests {
+		if got := string(withProbability(json.RawMessage(tt.raw), 0.25)); got != tt.want {
+			t.Errorf("withProbability(%s) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestWithSeed(t *testing.T) {
+	const cmd = "tool --some-long-argument-name another-long-value"
+	outputs := func(seed int64) []string {
+		e := New(WithSeed(seed))
+		var out []string
+		for range 5 {
+			res, err := e.Obfuscate(cmd, randomCaseFile(), randomCaseOnly)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			out = append(out, res.Output)
+		}
+		return out
+	}
+	first, second := outputs(7), outputs(7)
+	if !slices.Equal(first, second) {
+		t.Errorf("seed 7 gave %q, then %q", first, second)
+	}
+	if slices.Equal(first, outputs(8)) {
+		t.Error("seeds 7 and 8 gave the same runs")
+	}
+	if first[0] == first[1] {
+		t.Errorf("successive runs of one engine repeat %q", first[0])
+	}
+}
+
+// stopsAfter is a context that reports itself cancelled from its n+1th Err
+// call on, so a test can stop the pipeline between two given modifiers.
+type stopsAfter struct {
+	context.Context
+	n int
+}
+
+func (c *stopsAfter) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestObfuscateContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := New().ObfuscateContext(ctx, "tool -abc", policyFile(), policyEnabled); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: err = %v, want context.Canceled", err)
+	}
+
+	// CharacterInsertion runs (and fails), then the pipeline stops before
+	// RandomCase.
+	_, err := New().ObfuscateContext(&stopsAfter{context.Background(), 1}, "tool -abc", policyFile(), policyEnabled)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "before RandomCase") {
+		t.Errorf("stopped mid-run: err = %v, want context.Canceled before RandomCase", err)
+	}
+
+	if _, err := New().ObfuscateScriptContext(ctx, "tool -a; tool -b", []*models.ProfileFile{policyFile()}, policyEnabled); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled script: err = %v, want context.Canceled", err)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if _, err := New(WithLogger(logger)).Obfuscate("tool -abc", policyFile(), policyEnabled); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`level=DEBUG msg="engine: modifier applied" profile=tool modifier=RandomCase`,
+		`level=WARN msg="engine: modifier failed" profile=tool modifier=CharacterInsertion`,
+		`level=DEBUG msg="engine: modifier not implemented" profile=tool modifier=Sed`,
+		`level=DEBUG msg="engine: obfuscated" profile=tool applied=1 skipped=1 errors=1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log lacks %s:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	logger = slog.New(slog.NewTextHandler(&buf, nil))
+	New(WithLogger(logger)).Obfuscate("tool -abc", policyFile(), policyEnabled)
+	if n := strings.Count(buf.String(), "\n"); n != 1 || !strings.Contains(buf.String(), "modifier=CharacterInsertion") {
+		t.Errorf("at info level, want only the CharacterInsertion warning:\n%s", buf.String())
+	}
+}
+
+// TestPowerShellConcat_Executable checks that the engine hands modifiers the
+// profile file's name as the executable, and that cmd.exe rendering keeps
+// the expression PowerShellConcat writes as it is.
+func TestPowerShellConcat_Executable(t *testing.T) {
+	pf := func(name string) *models.ProfileFile {
+		return &models.ProfileFile{
+			Name: name,
+			Profiles: []models.Profile{{
+				Platform: "windows",
+				Parameters: models.ProfileParameters{
+					Arguments: []models.ArgumentDefinition{{Flags: []string{"-Command"}, ValueCount: 1}},
+					Modifiers: map[string]json.RawMessage{
+						"PowerShellConcat": json.RawMessage(`{"AppliesTo":["value"],"Probability":"1.0","Forms":["concat"],"Chunks":"2"}`),
+					},
+				},
+			}},
+		}
+	}
+	enabled := map[string]bool{"PowerShellConcat": true}
+
+	res, err := New(WithSeed(1)).Obfuscate("powershell -Command whoami", pf("powershell"), enabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script, ok := strings.CutPrefix(res.Output, `powershell -Command "&('`)
+	if !ok || !strings.HasSuffix(script, `')"`) || strings.ReplaceAll(strings.TrimSuffix(script, `')"`), "'+'", "") != "whoami" {
+		t.Errorf("Output = %q, want whoami rewritten as a concatenation", res.Output)
+	}
+
+	res, err = New(WithSeed(1)).Obfuscate("powershell -Command whoami", pf("tool"), enabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "powershell -Command whoami"; res.Output != want {
+		t.Errorf("Output = %q, want %q: the profile is not PowerShell's", res.Output, want)
+	}
+}
+
+func TestExecutableForm_ProfilePaths(t *testing.T) {
+	pf := &models.ProfileFile{
+		Name: "certutil",
+		Profiles: []models.Profile{{
+			Platform: "windows",
+			Paths:    []string{`C:\Windows\System32\certutil.exe`},
+			Parameters: models.ProfileParameters{
+				Modifiers: map[string]json.RawMessage{
+					"ExecutableForm": json.RawMessage(`{"AppliesTo":["command"],"Probability":"1.0","AbsolutePath":"1"}`),
+				},
+			},
+		}},
+	}
+	res, err := New(WithSeed(1)).Obfuscate("certutil -urlcache", pf, map[string]bool{"ExecutableForm": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `C:\Windows\System32\certutil.exe -urlcache`; res.Output != want {
+		t.Errorf("Output = %q, want %q", res.Output, want)
+	}
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"slices"
+
+	"cmdFuscator/models"
+)
+
+// FreezeTypes returns a copy of tokens with every token of one of the given
+// types marked Frozen. Tokens already frozen stay frozen.
+func FreezeTypes(tokens []models.Token, types ...models.TokenType) []models.Token {
+	out := slices.Clone(tokens)
+	for i := range out {
+		if slices.Contains(types, out[i].Type) {
+			out[i].Frozen = true
+		}
+	}
+	return out
+}
+
+// frozenToken is a token held back from a modifier. It goes back in front of
+// next, the unfrozen token that followed it, found by its span, so that
+// tokens a modifier inserts (see modifiers.Inserter) do not move it. When
+// next has no span it goes back after the first at unfrozen tokens instead,
+// and when no unfrozen token followed, at the end.
+type frozenToken struct {
+	at      int
+	next    models.Token
+	hasNext bool
+	tok     models.Token
+}
+
+// before reports whether f goes back in front of t, the i-th unfrozen token
+// of a modifier's output.
+func (f frozenToken) before(i int, t models.Token) bool {
+	switch {
+	case !f.hasNext:
+		return false
+	case f.next.HasSpan():
+		return t.Start == f.next.Start && t.End == f.next.End
+	}
+	return f.at <= i
+}
+
+// splitFrozen separates frozen tokens from the ones a modifier may see. When
+// nothing is frozen it returns tokens itself and a nil frozen list.
+func splitFrozen(tokens []models.Token) (visible []models.Token, frozen []frozenToken) {
+	if !slices.ContainsFunc(tokens, func(t models.Token) bool { return t.Frozen }) {
+		return tokens, nil
+	}
+	visible = make([]models.Token, 0, len(tokens))
+	pending := 0 // frozen tokens still waiting for the unfrozen one after them
+	for _, t := range tokens {
+		if t.Frozen {
+			frozen = append(frozen, frozenToken{at: len(visible), tok: t})
+			pending++
+			continue
+		}
+		for ; pending > 0; pending-- {
+			frozen[len(frozen)-pending].next, frozen[len(frozen)-pending].hasNext = t, true
+		}
+		visible = append(visible, t)
+	}
+	return visible, frozen
+}
+
+// mergeFrozen splices frozen tokens back into a modifier's output at their
+// original positions relative to the unfrozen tokens. If the modifier removed
+// the token a frozen one preceded, it goes at the end, so frozen tokens are
+// never lost.
+func mergeFrozen(visible []models.Token, frozen []frozenToken) []models.Token {
+	if len(frozen) == 0 {
+		return visible
+	}
+	out := make([]models.Token, 0, len(visible)+len(frozen))
+	next := 0
+	for i, t := range visible {
+		for next < len(frozen) && frozen[next].before(i, t) {
+			out = append(out, frozen[next].tok)
+			next++
+		}
+		out = append(out, t)
+	}
+	for ; next < len(frozen); next++ {
+		out = append(out, frozen[next].tok)
+	}
+	return out
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+// freezeFile upper-cases every argument and URL.
+func freezeFile() *models.ProfileFile {
+	return &models.ProfileFile{
+		Name: "tool",
+		Profiles: []models.Profile{{
+			Platform: "linux",
+			Parameters: models.ProfileParameters{
+				Modifiers: map[string]json.RawMessage{
+					"RandomCase": json.RawMessage(`{"AppliesTo":["argument","url"],"Probability":"1.0"}`),
+				},
+			},
+		}},
+	}
+}
+
+var freezeEnabled = map[string]bool{"RandomCase": true}
+
+func TestWithFrozenTypes(t *testing.T) {
+	res, err := New(WithFrozenTypes(models.TokenTypeURL)).Obfuscate("tool -f https://x/a", freezeFile(), freezeEnabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "tool -F https://x/a"; res.Output != want {
+		t.Errorf("Output = %q, want %q", res.Output, want)
+	}
+}
+
+func TestObfuscateTokens_FrozenFlag(t *testing.T) {
+	tokens := []models.Token{
+		tok(models.TokenTypeCommand, "tool"),
+		tok(models.TokenTypeArgument, "-a"),
+		{Type: models.TokenTypeArgument, Value: "-b", Frozen: true},
+		tok(models.TokenTypeURL, "https://x"),
+	}
+	res, err := New().ObfuscateTokens(tokens, freezeFile(), freezeEnabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "tool -A -b HTTPS://X"; res.Output != want {
+		t.Errorf("Output = %q, want %q", res.Output, want)
+	}
+}
+
+func TestObfuscateBatch_Tokens(t *testing.T) {
+	tokens := []models.Token{
+		tok(models.TokenTypeCommand, "tool"),
+		{Type: models.TokenTypeArgument, Value: "-a", Frozen: true},
+		tok(models.TokenTypeArgument, "-b"),
+	}
+	item := BatchItem{Tokens: tokens, Profile: freezeFile(), Enabled: freezeEnabled, Seed: 1}
+	r := New().ObfuscateBatch(context.Background(), []BatchItem{item})[0]
+	if r.Err != nil {
+		t.Fatalf("unexpected error: %v", r.Err)
+	}
+	if want := "tool -a -B"; r.Result.Output != want {
+		t.Errorf("Output = %q, want %q", r.Result.Output, want)
+	}
+}
+
+// TestDuplicateFlags_Frozen checks that the engine hands DuplicateFlags the
+// profile's argument definitions and puts frozen tokens back around the
+// copies it inserts.
+func TestDuplicateFlags_Frozen(t *testing.T) {
+	pf := &models.ProfileFile{
+		Name: "tool",
+		Profiles: []models.Profile{{
+			Platform: "linux",
+			Parameters: models.ProfileParameters{
+				Arguments: []models.ArgumentDefinition{{Flags: []string{"-f"}, Repeatable: true}},
+				Modifiers: map[string]json.RawMessage{
+					"DuplicateFlags": json.RawMessage(`{"AppliesTo":["argument"],"Probability":"1.0"}`),
+				},
+			},
+		}},
+	}
+	for seed := range int64(20) {
+		e := New(WithFrozenTypes(models.TokenTypeURL), WithTrace(true), WithSeed(seed))
+		res, err := e.Obfuscate("tool -f https://x/a -f", pf, map[string]bool{"DuplicateFlags": true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out := res.Trace[0].After
+		i := slices.IndexFunc(out, func(tok models.Token) bool { return tok.Frozen })
+		if len(out) != 6 || i < 0 || i+1 >= len(out) || !out[i+1].HasSpan() || out[i+1].Start != 20 {
+			t.Fatalf("seed %d: tokens %+v, want 6 with the URL before the second -f", seed, out)
+		}
+	}
+}
+
+func TestMergeFrozen(t *testing.T) {
+	a, b, c := tok(models.TokenTypeValue, "a"), tok(models.TokenTypeValue, "b"), tok(models.TokenTypeValue, "c")
+	f := models.Token{Type: models.TokenTypeURL, Value: "F", Frozen: true}
+	// sa and sb have spans, which frozen tokens are put back by.
+	sa := models.Token{Type: models.TokenTypeValue, Value: "a", End: 1}
+	sb := models.Token{Type: models.TokenTypeValue, Value: "b", Start: 4, End: 5}
+
+	cases := []struct {
+		name   string
+		tokens []models.Token
+		apply  func([]models.Token) []models.Token
+		want   string
+	}{
+		{"unchanged", []models.Token{a, f, b}, func(v []models.Token) []models.Token { return v }, "a F b"},
+		{"leading", []models.Token{f, a, b}, func(v []models.Token) []models.Token { return v }, "F a b"},
+		{"trailing", []models.Token{a, b, f}, func(v []models.Token) []models.Token { return v }, "a b F"},
+		{"append", []models.Token{a, f, b}, func(v []models.Token) []models.Token {
+			return append(v, c)
+		}, "a F b c"},
+		{"removed all", []models.Token{a, f, b}, func(v []models.Token) []models.Token { return nil }, "F"},
+		{"inserted before", []models.Token{sa, f, sb}, func(v []models.Token) []models.Token {
+			return []models.Token{v[0], c, v[1]}
+		}, "a c F b"},
+		{"moved", []models.Token{sa, f, sb}, func(v []models.Token) []models.Token {
+			return []models.Token{v[1], v[0]}
+		}, "F b a"},
+		{"appended after trailing", []models.Token{a, b, f}, func(v []models.Token) []models.Token {
+			return append(v, c)
+		}, "a b c F"},
+	}
+	for _, tc := range cases {
+		visible, frozen := splitFrozen(tc.tokens)
+		for _, v := range visible {
+			if v.Frozen {
+				t.Fatalf("%s: frozen token leaked into visible set", tc.name)
+			}
+		}
+		if got := Render(mergeFrozen(tc.apply(visible), frozen)); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── Logging ──────────────────────────────────────────────────────────────────
+
+// debugging reports whether a run under ctx should build its debug records,
+// so an engine without a logger, or one above debug level, pays nothing.
+func (e *Engine) debugging(ctx context.Context) bool {
+	return e.logger != nil && e.logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// logModifier records one modifier stage: at debug level when it ran or is
+// not implemented, at warn level when it failed. before and after are the
+// stage's tokens; after is ignored when err is set.
+func (e *Engine) logModifier(ctx context.Context, pf *models.ProfileFile, name string, d time.Duration, before, after []models.Token, err error) {
+	if e.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.String("profile", pf.Name), slog.String("modifier", name), slog.Duration("duration", d)}
+	switch {
+	case err == nil:
+		if e.debugging(ctx) {
+			attrs = append(attrs, slog.Int("touched", tokensTouched(before, after)))
+			e.logger.LogAttrs(ctx, slog.LevelDebug, "engine: modifier applied", attrs...)
+		}
+	case errors.Is(err, modifiers.ErrNotImplemented) && !e.strict:
+		e.logger.LogAttrs(ctx, slog.LevelDebug, "engine: modifier not implemented", attrs...)
+	default:
+		e.logger.LogAttrs(ctx, slog.LevelWarn, "engine: modifier failed", append(attrs, slog.Any("err", err))...)
+	}
+}
+
+// logRun records a finished run at debug level.
+func (e *Engine) logRun(ctx context.Context, pf *models.ProfileFile, res *ObfuscateResult, d time.Duration) {
+	if !e.debugging(ctx) {
+		return
+	}
+	e.logger.LogAttrs(ctx, slog.LevelDebug, "engine: obfuscated",
+		slog.String("profile", pf.Name),
+		slog.Int("applied", len(res.Applied)),
+		slog.Int("skipped", len(res.Skipped)),
+		slog.Int("errors", len(res.Errors)),
+		slog.Int("score", res.Score.Value),
+		slog.Duration("duration", d),
+	)
+}
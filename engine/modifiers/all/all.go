@@ -6,14 +6,24 @@
 package all
 
 import (
+	_ "cmdFuscator/engine/modifiers/bidi"
 	_ "cmdFuscator/engine/modifiers/charinsert"
+	_ "cmdFuscator/engine/modifiers/diacritic"
+	_ "cmdFuscator/engine/modifiers/dupflag"
+	_ "cmdFuscator/engine/modifiers/exeform"
 	_ "cmdFuscator/engine/modifiers/filepath"
+	_ "cmdFuscator/engine/modifiers/nummangle"
 	_ "cmdFuscator/engine/modifiers/optionchar"
+	_ "cmdFuscator/engine/modifiers/psconcat"
 	_ "cmdFuscator/engine/modifiers/quoteinsert"
+	_ "cmdFuscator/engine/modifiers/quotesplit"
 	_ "cmdFuscator/engine/modifiers/randomcase"
 	_ "cmdFuscator/engine/modifiers/regex"
 	_ "cmdFuscator/engine/modifiers/reorderargs"
+	_ "cmdFuscator/engine/modifiers/script"
 	_ "cmdFuscator/engine/modifiers/sed"
 	_ "cmdFuscator/engine/modifiers/shorthands"
+	_ "cmdFuscator/engine/modifiers/substring"
+	_ "cmdFuscator/engine/modifiers/urlmangle"
 	_ "cmdFuscator/engine/modifiers/urltransform"
 )
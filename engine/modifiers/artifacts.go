@@ -0,0 +1,65 @@
+package modifiers
+
+import (
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// ─── Artifacts ────────────────────────────────────────────────────────────────
+
+// maxArtifacts bounds an Artifacts cache; it is cleared when it fills up.
+const maxArtifacts = 4096
+
+// Artifacts caches values that modifiers derive from their configs and that
+// are costly to rebuild: compiled regexes, sed substitution maps, shorthand
+// prefix tries. The engine keeps one per Engine and hands it to
+// ConfigParser.ParseConfig, so an artifact is built once, then shared by
+// every profile, config variant and modifier that asks for the same one.
+//
+// Entries are keyed by a kind, which names what is built, and the source it
+// is built from, so an artifact never goes stale: the same source always
+// builds the same value. Cached values are shared by concurrent runs and
+// must not be modified. A nil *Artifacts is valid and caches nothing.
+type Artifacts struct {
+	entries sync.Map // artifactKey → any
+	size    atomic.Int64
+}
+
+type artifactKey struct {
+	kind, source string
+}
+
+// Artifact returns the artifact of the given kind built from source, calling
+// build on first use. Errors are not cached. kind must identify build: two
+// callers that share a kind must build the same value from the same source,
+// so kinds are best prefixed with the modifier's name unless, like "regexp",
+// they are meant to be shared.
+func Artifact[T any](a *Artifacts, kind, source string, build func(source string) (T, error)) (T, error) {
+	if a == nil {
+		return build(source)
+	}
+	key := artifactKey{kind, source}
+	if v, ok := a.entries.Load(key); ok {
+		return v.(T), nil
+	}
+	v, err := build(source)
+	if err != nil {
+		return v, err
+	}
+	if a.size.Add(1) > maxArtifacts {
+		a.entries.Clear()
+		a.size.Store(1)
+	}
+	if prev, loaded := a.entries.LoadOrStore(key, v); loaded {
+		a.size.Add(-1) // another run built it first
+		return prev.(T), nil
+	}
+	return v, nil
+}
+
+// Regexp returns expr compiled, shared by every modifier that compiles the
+// same expression. A *regexp.Regexp is safe for concurrent use.
+func (a *Artifacts) Regexp(expr string) (*regexp.Regexp, error) {
+	return Artifact(a, "regexp", expr, regexp.Compile)
+}
@@ -0,0 +1,62 @@
+package modifiers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestArtifact(t *testing.T) {
+	builds := 0
+	upper := func(s string) (string, error) {
+		builds++
+		if s == "" {
+			return "", errors.New("empty")
+		}
+		return strings.ToUpper(s), nil
+	}
+
+	a := &Artifacts{}
+	for range 3 {
+		if v, err := Artifact(a, "upper", "abc", upper); err != nil || v != "ABC" {
+			t.Fatalf("Artifact = %q, %v", v, err)
+		}
+	}
+	if builds != 1 {
+		t.Errorf("built %d times, want once", builds)
+	}
+	Artifact(a, "upper", "def", upper)
+	Artifact(a, "other", "abc", upper)
+	if builds != 3 {
+		t.Errorf("built %d times, want once more per source and per kind", builds)
+	}
+	for range 2 {
+		if _, err := Artifact(a, "upper", "", upper); err == nil {
+			t.Error("Artifact of a failing build: want an error")
+		}
+	}
+	if builds != 5 {
+		t.Errorf("built %d times, want failures rebuilt", builds)
+	}
+
+	var none *Artifacts
+	Artifact(none, "upper", "abc", upper)
+	Artifact(none, "upper", "abc", upper)
+	if builds != 7 {
+		t.Errorf("built %d times, want a nil cache to build every time", builds)
+	}
+}
+
+func TestArtifactsRegexp(t *testing.T) {
+	a := &Artifacts{}
+	re, err := a.Regexp(`a+b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := a.Regexp(`a+b`); again != re {
+		t.Error("Regexp compiled the same expression twice")
+	}
+	if _, err := a.Regexp(`(`); err == nil {
+		t.Error("Regexp(`(`): want an error")
+	}
+}
@@ -0,0 +1,212 @@
+// Package bidi implements the BidiInsertion obfuscation modifier.
+//
+// Technique: insert a Unicode bidirectional control character, such as
+// U+202E RIGHT-TO-LEFT OVERRIDE, at a configured position within each
+// eligible token. The characters take no room on screen but reorder what
+// follows them, so a command shown in a log viewer, a terminal or a review
+// tool reads differently from what runs. Nothing else in the token changes,
+// so the reordering is of what it already holds: at "extension",
+// "report.pdf", U+202E, ".exe" displays as "report.pdfexe.", which no
+// longer ends in .exe. (The classic spoof, "report", U+202E, "fdp.exe"
+// displaying as "reportexe.pdf", takes a file named that way.) Signatures
+// matching the plain string miss it too.
+//
+// Only the bidi controls are accepted, and of those only the ones the
+// profile's Characters list allows.
+//
+// Not part of ArgFuscator.
+// Applies to token types: path, value
+package bidi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&BidiInsertion{})
+}
+
+// BidiInsertion inserts bidirectional control characters into token values.
+type BidiInsertion struct{}
+
+func (b *BidiInsertion) Name() string { return "BidiInsertion" }
+func (b *BidiInsertion) Description() string {
+	return "Insert RTLO and other bidi control characters into tokens"
+}
+
+// Config holds BidiInsertion-specific config fields.
+type Config struct {
+	models.BaseModifierConfig
+	// Characters is the whitelist of bidi controls to sample from, one per
+	// entry, e.g. "\u202e" in JSON. Anything else is rejected.
+	Characters []string `json:"Characters"`
+	// Positions lists where a character may go; each insertion picks one.
+	// An entry is "start", "end", "extension" (before the last dot of the
+	// token's last path element, or the end without one), "random" (any
+	// offset), or a string integer counting characters from the start.
+	// Empty means "random".
+	Positions []string `json:"Positions,omitempty"`
+	// Terminate closes an embedding, override or isolate at the end of the
+	// token with U+202C or U+2069, so that it reorders the token alone
+	// rather than the rest of the command line.
+	Terminate bool `json:"Terminate,omitempty"`
+}
+
+// The bidi controls, by the names the Unicode standard gives them.
+const (
+	ALM = '\u061c' // ARABIC LETTER MARK
+	LRM = '\u200e' // LEFT-TO-RIGHT MARK
+	RLM = '\u200f' // RIGHT-TO-LEFT MARK
+	LRE = '\u202a' // LEFT-TO-RIGHT EMBEDDING
+	RLE = '\u202b' // RIGHT-TO-LEFT EMBEDDING
+	PDF = '\u202c' // POP DIRECTIONAL FORMATTING
+	LRO = '\u202d' // LEFT-TO-RIGHT OVERRIDE
+	RLO = '\u202e' // RIGHT-TO-LEFT OVERRIDE
+	LRI = '\u2066' // LEFT-TO-RIGHT ISOLATE
+	RLI = '\u2067' // RIGHT-TO-LEFT ISOLATE
+	FSI = '\u2068' // FIRST STRONG ISOLATE
+	PDI = '\u2069' // POP DIRECTIONAL ISOLATE
+)
+
+// closers maps the controls that open a run to the one that closes it.
+var closers = map[rune]rune{
+	LRE: PDF, RLE: PDF, LRO: PDF, RLO: PDF,
+	LRI: PDI, RLI: PDI, FSI: PDI,
+}
+
+// IsControl reports whether r is a bidi control character.
+func IsControl(r rune) bool {
+	switch r {
+	case ALM, LRM, RLM, PDF, PDI:
+		return true
+	}
+	_, ok := closers[r]
+	return ok
+}
+
+// Apply implements modifiers.Modifier.
+func (b *BidiInsertion) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return b.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from mc.
+func (b *BidiInsertion) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := b.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return b.ApplyParsed(mc, tokens, parsed)
+}
+
+// position is a parsed Positions entry: one of the named positions, or an
+// offset when name is "".
+type position struct {
+	name   string
+	offset int
+}
+
+// parsedConfig is a Config with its characters and positions parsed.
+type parsedConfig struct {
+	base      models.BaseModifierConfig
+	chars     []rune
+	positions []position
+	terminate bool
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (b *BidiInsertion) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	if len(cfgM.Characters) == 0 {
+		return nil, fmt.Errorf("characters list must not be empty")
+	}
+
+	p := &parsedConfig{base: cfgM.BaseModifierConfig, terminate: cfgM.Terminate}
+	for i, s := range cfgM.Characters {
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 || size != len(s) || !IsControl(r) {
+			return nil, fmt.Errorf("characters[%d]: %+q is not a bidi control character", i, s)
+		}
+		p.chars = append(p.chars, r)
+	}
+	for i, s := range cfgM.Positions {
+		switch s {
+		case "start", "end", "extension", "random":
+			p.positions = append(p.positions, position{name: s})
+		default:
+			offset, err := strconv.Atoi(s)
+			if err != nil || offset < 0 {
+				return nil, fmt.Errorf("positions[%d]: %q is not start, end, extension, random or a non-negative integer", i, s)
+			}
+			p.positions = append(p.positions, position{offset: offset})
+		}
+	}
+	if len(p.positions) == 0 {
+		p.positions = []position{{name: "random"}}
+	}
+	return p, nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+func (b *BidiInsertion) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	return modifiers.ForEachEligible(tokens, cfgM.base, mc, func(_ int, t models.Token) models.Token {
+		runes := []rune(t.Value)
+		pos := cfgM.positions[mc.Intn(len(cfgM.positions))].in(mc, runes)
+		r := cfgM.chars[mc.Intn(len(cfgM.chars))]
+
+		out := make([]rune, 0, len(runes)+2)
+		out = append(out, runes[:pos]...)
+		out = append(out, r)
+		out = append(out, runes[pos:]...)
+		if closer, ok := closers[r]; ok && cfgM.terminate {
+			out = append(out, closer)
+		}
+		t.Value = string(out)
+		return t
+	})
+}
+
+// in returns the rune offset p stands for in runes.
+func (p position) in(mc modifiers.Context, runes []rune) int {
+	switch p.name {
+	case "start":
+		return 0
+	case "end":
+		return len(runes)
+	case "extension":
+		for i := len(runes) - 1; i >= 0 && runes[i] != '/' && runes[i] != '\\'; i-- {
+			if runes[i] == '.' {
+				return i
+			}
+		}
+		return len(runes)
+	case "random":
+		return mc.Intn(len(runes) + 1)
+	}
+	return min(p.offset, len(runes))
+}
+
+// Lint implements modifiers.Linter: Characters must hold bidi controls.
+func (b *BidiInsertion) Lint(cfg json.RawMessage, _ string, _ *models.Profile) []modifiers.Finding {
+	var c Config
+	if json.Unmarshal(cfg, &c) != nil {
+		return nil
+	}
+	out := modifiers.LintPool("Characters", c.Characters, `add at least one bidi control, e.g. "\u202e" (right-to-left override)`)
+	for i, s := range c.Characters {
+		if r := []rune(s); s != "" && (len(r) != 1 || !IsControl(r[0])) {
+			out = append(out, modifiers.Finding{Path: fmt.Sprintf("Characters[%d]", i), Error: true,
+				Message: fmt.Sprintf("%+q is not a bidi control character", s)})
+		}
+	}
+	return out
+}
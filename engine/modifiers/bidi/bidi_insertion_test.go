@@ -0,0 +1,198 @@
+package bidi
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(characters, positions []string, terminate bool) json.RawMessage {
+	c := Config{
+		BaseModifierConfig: models.BaseModifierConfig{
+			AppliesTo:   []string{"path", "value"},
+			Probability: "1.0",
+		},
+		Characters: characters,
+		Positions:  positions,
+		Terminate:  terminate,
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("cfg helper: " + err.Error())
+	}
+	return b
+}
+
+func seeded() modifiers.Context {
+	return modifiers.Context{Rand: rand.New(rand.NewSource(1))}
+}
+
+var input = []models.Token{
+	{Type: models.TokenTypeCommand, Value: "cmd.exe"},
+	{Type: models.TokenTypeArgument, Value: "/c"},
+	{Type: models.TokenTypePath, Value: `C:\Temp\report.pdf.exe`},
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&BidiInsertion{}).Name(); name != "BidiInsertion" {
+		t.Errorf("Name() = %q, want %q", name, "BidiInsertion")
+	}
+	if _, ok := modifiers.Get("BidiInsertion"); !ok {
+		t.Error("BidiInsertion is not registered")
+	}
+}
+
+// ─── insertion ────────────────────────────────────────────────────────────────
+
+func TestApply_Positions(t *testing.T) {
+	const rlo = "\u202e"
+	tests := []struct {
+		name      string
+		positions []string
+		terminate bool
+		want      string
+	}{
+		{"start", []string{"start"}, false, rlo + `C:\Temp\report.pdf.exe`},
+		{"end", []string{"end"}, false, `C:\Temp\report.pdf.exe` + rlo},
+		{"extension", []string{"extension"}, false, `C:\Temp\report.pdf` + rlo + ".exe"},
+		{"offset", []string{"3"}, false, `C:\` + rlo + `Temp\report.pdf.exe`},
+		{"offset past the end", []string{"99"}, false, `C:\Temp\report.pdf.exe` + rlo},
+		{"terminated", []string{"extension"}, true, `C:\Temp\report.pdf` + rlo + ".exe\u202c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := (&BidiInsertion{}).ApplyContext(seeded(), input, cfg([]string{rlo}, tt.positions, tt.terminate))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out[2].Value != tt.want {
+				t.Errorf("path = %+q, want %+q", out[2].Value, tt.want)
+			}
+			if out[0] != input[0] || out[1] != input[1] {
+				t.Errorf("ineligible tokens changed: %v", out[:2])
+			}
+		})
+	}
+}
+
+// display is how a viewer lays out s when its only bidi controls are RLO
+// overrides, each closed by PDF or the end of s: the run after each RLO is
+// shown reversed, and the controls themselves not at all.
+func display(s string) string {
+	var b strings.Builder
+	for {
+		before, after, ok := strings.Cut(s, string(RLO))
+		b.WriteString(before)
+		if !ok {
+			return b.String()
+		}
+		run, rest, _ := strings.Cut(after, string(PDF))
+		r := []rune(run)
+		slices.Reverse(r)
+		b.WriteString(string(r))
+		s = rest
+	}
+}
+
+func TestApply_ExtensionDisplay(t *testing.T) {
+	tests := []struct {
+		terminate bool
+		want      string // the token, then " -q", as displayed
+	}{
+		{false, "report.pdfq- exe."}, // the override runs on into the next word
+		{true, "report.pdfexe. -q"},
+	}
+	for _, tt := range tests {
+		tokens := []models.Token{{Type: models.TokenTypePath, Value: "report.pdf.exe"}}
+		out, err := (&BidiInsertion{}).ApplyContext(seeded(), tokens, cfg([]string{string(RLO)}, []string{"extension"}, tt.terminate))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := display(out[0].Value + " -q"); got != tt.want {
+			t.Errorf("terminate %v: %+q displays as %q, want %q", tt.terminate, out[0].Value, got, tt.want)
+		}
+	}
+}
+
+func TestApply_ExtensionWithoutDot(t *testing.T) {
+	tokens := []models.Token{{Type: models.TokenTypePath, Value: `C:\my.dir\payload`}}
+	out, err := (&BidiInsertion{}).ApplyContext(seeded(), tokens, cfg([]string{"\u202e"}, []string{"extension"}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `C:\my.dir\payload` + "\u202e"; out[0].Value != want {
+		t.Errorf("path = %+q, want %+q", out[0].Value, want)
+	}
+}
+
+func TestApply_Whitelist(t *testing.T) {
+	allowed := []string{"\u2067", "\u200f"}
+	for seed := range int64(50) {
+		mc := modifiers.Context{Rand: rand.New(rand.NewSource(seed))}
+		out, err := (&BidiInsertion{}).ApplyContext(mc, input, cfg(allowed, nil, true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := out[2].Value
+		switch {
+		case strings.Contains(got, "\u2067"):
+			if !strings.HasSuffix(got, "\u2069") {
+				t.Errorf("seed %d: isolate not closed: %+q", seed, got)
+			}
+		case strings.Contains(got, "\u200f"):
+			if len([]rune(got)) != len([]rune(input[2].Value))+1 {
+				t.Errorf("seed %d: a mark was closed: %+q", seed, got)
+			}
+		default:
+			t.Errorf("seed %d: inserted outside the whitelist: %+q", seed, got)
+		}
+	}
+}
+
+// ─── config errors ────────────────────────────────────────────────────────────
+
+func TestParseConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  json.RawMessage
+		want string
+	}{
+		{"invalid JSON", json.RawMessage(`not json`), "unmarshal config"},
+		{"no characters", cfg(nil, nil, false), "must not be empty"},
+		{"not a bidi control", cfg([]string{"\u200b"}, nil, false), `characters[0]: "\u200b" is not a bidi control`},
+		{"two characters in one entry", cfg([]string{"\u202e\u202c"}, nil, false), "characters[0]"},
+		{"empty entry", cfg([]string{"\u202e", ""}, nil, false), "characters[1]"},
+		{"unknown position", cfg([]string{"\u202e"}, []string{"middle"}, false), `positions[0]: "middle" is not`},
+		{"negative offset", cfg([]string{"\u202e"}, []string{"end", "-1"}, false), "positions[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&BidiInsertion{}).ParseConfig(nil, tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseConfig error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsControl(t *testing.T) {
+	for _, r := range []rune{ALM, LRM, RLM, LRE, RLE, PDF, LRO, RLO, LRI, RLI, FSI, PDI} {
+		if !IsControl(r) {
+			t.Errorf("IsControl(%U) = false", r)
+		}
+	}
+	for _, r := range []rune{'a', '\u200b', '\u200d', '\u2060', '\ufeff'} {
+		if IsControl(r) {
+			t.Errorf("IsControl(%U) = true", r)
+		}
+	}
+}
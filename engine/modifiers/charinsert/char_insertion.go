@@ -12,8 +12,6 @@ package charinsert
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
-	"slices"
 	"strconv"
 
 	"cmdFuscator/engine/modifiers"
@@ -55,50 +53,66 @@ type Config struct {
 //     (clamp Offset to len(runes) if the token is shorter).
 //  4. Return updated tokens.
 func (c *CharacterInsertion) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
-	out := make([]models.Token, len(tokens)) // the eventual return value
-	copy(out, tokens)                        // make a copy of the input tokens for no op situations
+	return c.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from mc.
+func (c *CharacterInsertion) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := c.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return c.ApplyParsed(mc, tokens, parsed)
+}
 
+// parsedConfig is a Config with its offset parsed.
+type parsedConfig struct {
+	Config
+	offset int
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (c *CharacterInsertion) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
 	cfgM := &Config{}
 	if err := json.Unmarshal(cfg, cfgM); err != nil {
-		return tokens, fmt.Errorf("unmarshal config: %w", err)
+		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
 
 	// ensure characters is non-empty
 	if len(cfgM.Characters) == 0 {
-		return tokens, fmt.Errorf("characters list must not be empty")
-	}
-
-	probability, err := strconv.ParseFloat(cfgM.Probability, 64)
-	if err != nil {
-		return tokens, fmt.Errorf("parse probability: %w", err)
-	} else if probability < 0 || probability > 1 {
-		return tokens, fmt.Errorf("probability must be between 0 and 1")
+		return nil, fmt.Errorf("characters list must not be empty")
 	}
 
 	offset, err := strconv.Atoi(cfgM.Offset)
 	if err != nil {
-		return tokens, fmt.Errorf("parse offset: %w", err)
+		return nil, fmt.Errorf("parse offset: %w", err)
 	}
+	return &parsedConfig{Config: *cfgM, offset: offset}, nil
+}
 
-	for t := range tokens {
-		if !slices.Contains(cfgM.AppliesTo, string(tokens[t].Type)) {
-			continue // only apply to tokens of the specified types from config
-		}
-		if rand.Float64() > probability {
-			continue // skip if probability doesn't fire
-		}
-
+// ApplyParsed implements modifiers.ConfigParser.
+func (c *CharacterInsertion) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	return modifiers.ForEachEligible(tokens, cfgM.BaseModifierConfig, mc, func(_ int, t models.Token) models.Token {
 		// ensure the offset is within the bounds of the token
-		runes := []rune(tokens[t].Value)
-		pos := offset
+		runes := []rune(t.Value)
+		pos := cfgM.offset
 		if pos >= len(runes) {
 			pos = len(runes)
 		}
 
-		rdmChar := cfgM.Characters[rand.Intn(len(cfgM.Characters))]
+		rdmChar := cfgM.Characters[mc.Intn(len(cfgM.Characters))]
 		result := append(runes[:pos:pos], append([]rune(rdmChar), runes[pos:]...)...)
-		out[t].Value = string(result)
-	}
+		t.Value = string(result)
+		return t
+	})
+}
 
-	return out, nil
+// Lint implements modifiers.Linter: Characters must not be empty.
+func (c *CharacterInsertion) Lint(cfg json.RawMessage, _ string, _ *models.Profile) []modifiers.Finding {
+	var cfgM Config
+	if json.Unmarshal(cfg, &cfgM) != nil {
+		return nil
+	}
+	return modifiers.LintPool("Characters", cfgM.Characters, `add at least one character, e.g. "\u00ad" (soft hyphen)`)
 }
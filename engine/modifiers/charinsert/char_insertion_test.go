@@ -19,7 +19,7 @@ func cfg(appliesTo []string, probability string, characters []string, offset str
 	c := Config{
 		BaseModifierConfig: models.BaseModifierConfig{
 			AppliesTo:   appliesTo,
-			Probability: probability,
+			Probability: models.ProbabilityValue(probability),
 		},
 		Characters: characters,
 		Offset:     offset,
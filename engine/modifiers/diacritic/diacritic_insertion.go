@@ -0,0 +1,157 @@
+// Package diacritic implements the DiacriticInsertion obfuscation modifier.
+//
+// Technique: append a Unicode combining mark, such as U+0307 COMBINING DOT
+// ABOVE, to some of the letters of each eligible token. The marks draw over
+// the letter they follow, so the token looks nearly the same, but it no
+// longer matches the plain string. It suits targets that strip combining
+// marks or decompose and drop them before use; one that composes them keeps
+// the accented letter (e with U+0301 becomes é).
+//
+// Only nonspacing combining marks are accepted, and of those only the ones
+// the profile's Characters list allows. A letter gets at most one mark, and
+// none if a mark already follows it.
+//
+// Not part of ArgFuscator.
+// Applies to token types: argument, value, path
+package diacritic
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&DiacriticInsertion{})
+}
+
+// DiacriticInsertion appends combining marks to letters in token values.
+type DiacriticInsertion struct{}
+
+func (d *DiacriticInsertion) Name() string { return "DiacriticInsertion" }
+func (d *DiacriticInsertion) Description() string {
+	return "Append combining diacritics to letters in tokens"
+}
+
+// Config holds DiacriticInsertion-specific config fields.
+type Config struct {
+	models.BaseModifierConfig
+	// Characters is the whitelist of combining marks to sample from, one per
+	// entry, e.g. "\u0307" in JSON. Anything else is rejected.
+	Characters []string `json:"Characters"`
+	// Density is the chance, between 0 and 1, that each letter of a token
+	// the roll fires for gets a mark. At least one letter always does.
+	// Empty means "0.2".
+	Density string `json:"Density,omitempty"`
+}
+
+// IsMark reports whether r is a combining mark DiacriticInsertion accepts.
+func IsMark(r rune) bool {
+	return unicode.Is(unicode.Mn, r)
+}
+
+// Apply implements modifiers.Modifier.
+func (d *DiacriticInsertion) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return d.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from mc.
+func (d *DiacriticInsertion) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := d.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return d.ApplyParsed(mc, tokens, parsed)
+}
+
+// parsedConfig is a Config with its characters and density parsed.
+type parsedConfig struct {
+	base    models.BaseModifierConfig
+	marks   []rune
+	density float64
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (d *DiacriticInsertion) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	if len(cfgM.Characters) == 0 {
+		return nil, fmt.Errorf("characters list must not be empty")
+	}
+
+	p := &parsedConfig{base: cfgM.BaseModifierConfig, density: 0.2}
+	for i, s := range cfgM.Characters {
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 || size != len(s) || !IsMark(r) {
+			return nil, fmt.Errorf("characters[%d]: %+q is not a combining mark", i, s)
+		}
+		p.marks = append(p.marks, r)
+	}
+	if cfgM.Density != "" {
+		density, err := modifiers.ParseProbability(cfgM.Density)
+		if err != nil {
+			return nil, fmt.Errorf("density: %w", err)
+		}
+		p.density = density
+	}
+	return p, nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+func (d *DiacriticInsertion) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	return modifiers.ForEachEligible(tokens, cfgM.base, mc, func(_ int, t models.Token) models.Token {
+		runes := []rune(t.Value)
+		var letters []int
+		for i, r := range runes {
+			if unicode.IsLetter(r) && (i+1 == len(runes) || !unicode.Is(unicode.M, runes[i+1])) {
+				letters = append(letters, i)
+			}
+		}
+		if len(letters) == 0 {
+			return t
+		}
+
+		marked := make(map[int]bool)
+		for _, i := range letters {
+			if mc.Float64() < cfgM.density {
+				marked[i] = true
+			}
+		}
+		if len(marked) == 0 {
+			marked[letters[mc.Intn(len(letters))]] = true
+		}
+
+		out := make([]rune, 0, len(runes)+len(marked))
+		for i, r := range runes {
+			out = append(out, r)
+			if marked[i] {
+				out = append(out, cfgM.marks[mc.Intn(len(cfgM.marks))])
+			}
+		}
+		t.Value = string(out)
+		return t
+	})
+}
+
+// Lint implements modifiers.Linter: Characters must hold combining marks.
+func (d *DiacriticInsertion) Lint(cfg json.RawMessage, _ string, _ *models.Profile) []modifiers.Finding {
+	var c Config
+	if json.Unmarshal(cfg, &c) != nil {
+		return nil
+	}
+	out := modifiers.LintPool("Characters", c.Characters, `add at least one combining mark, e.g. "\u0307" (combining dot above)`)
+	for i, s := range c.Characters {
+		if r := []rune(s); s != "" && (len(r) != 1 || !IsMark(r[0])) {
+			out = append(out, modifiers.Finding{Path: fmt.Sprintf("Characters[%d]", i), Error: true,
+				Message: fmt.Sprintf("%+q is not a combining mark", s)})
+		}
+	}
+	return out
+}
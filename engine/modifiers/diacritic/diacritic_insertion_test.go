@@ -0,0 +1,130 @@
+package diacritic
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(characters []string, density string) json.RawMessage {
+	c := Config{
+		BaseModifierConfig: models.BaseModifierConfig{
+			AppliesTo:   []string{"argument"},
+			Probability: "1.0",
+		},
+		Characters: characters,
+		Density:    density,
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("cfg helper: " + err.Error())
+	}
+	return b
+}
+
+func insert(t *testing.T, seed int64, value string, c json.RawMessage) string {
+	t.Helper()
+	tokens := []models.Token{{Type: models.TokenTypeArgument, Value: value}}
+	out, err := (&DiacriticInsertion{}).ApplyContext(modifiers.Context{Rand: rand.New(rand.NewSource(seed))}, tokens, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out[0].Value
+}
+
+// strip removes combining marks from s.
+func strip(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.M, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func countMarks(s string) int {
+	return len([]rune(s)) - len([]rune(strip(s)))
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&DiacriticInsertion{}).Name(); name != "DiacriticInsertion" {
+		t.Errorf("Name() = %q, want %q", name, "DiacriticInsertion")
+	}
+	if _, ok := modifiers.Get("DiacriticInsertion"); !ok {
+		t.Error("DiacriticInsertion is not registered")
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  json.RawMessage
+	}{
+		{"empty pool", cfg(nil, "")},
+		{"not a mark", cfg([]string{"\u0301", "e"}, "")},
+		{"enclosing mark", cfg([]string{"\u20dd"}, "")},
+		{"two marks in one entry", cfg([]string{"\u0301\u0302"}, "")},
+		{"density above 1", cfg([]string{"\u0301"}, "2")},
+		{"density not a number", cfg([]string{"\u0301"}, "dense")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (&DiacriticInsertion{}).ParseConfig(nil, tt.cfg); err == nil {
+				t.Error("ParseConfig should return an error")
+			}
+		})
+	}
+}
+
+// ─── insertion ────────────────────────────────────────────────────────────────
+
+func TestApply_MarksLetters(t *testing.T) {
+	marks := []string{"\u0307", "\u0323"}
+	for seed := range int64(50) {
+		got := insert(t, seed, "-urlcache", cfg(marks, "0.3"))
+		if strip(got) != "-urlcache" || countMarks(got) == 0 {
+			t.Fatalf("seed %d: got %+q, want -urlcache with marks added", seed, got)
+		}
+		runes := []rune(got)
+		for i, r := range runes {
+			if unicode.Is(unicode.M, r) && (r != '\u0307' && r != '\u0323' || i == 0 || !unicode.IsLetter(runes[i-1])) {
+				t.Errorf("seed %d: %+q at %d does not follow a letter or is not from the pool", seed, r, i)
+			}
+		}
+	}
+}
+
+func TestApply_Density(t *testing.T) {
+	const value = "certutil"
+	if got := insert(t, 1, value, cfg([]string{"\u0301"}, "1")); countMarks(got) != len(value) {
+		t.Errorf("density 1: got %+q, want a mark on every letter", got)
+	}
+	for seed := range int64(20) {
+		if got := insert(t, seed, value, cfg([]string{"\u0301"}, "0")); countMarks(got) != 1 {
+			t.Errorf("seed %d: density 0: got %+q, want exactly one mark", seed, got)
+		}
+	}
+}
+
+func TestApply_NoStacking(t *testing.T) {
+	// Only the e is free: the a already carries a mark.
+	got := insert(t, 1, "-a\u0301e", cfg([]string{"\u0307"}, "1"))
+	if want := "-a\u0301e\u0307"; got != want {
+		t.Errorf("got %+q, want %+q", got, want)
+	}
+}
+
+func TestApply_NoLetters(t *testing.T) {
+	if got := insert(t, 1, "--/42", cfg([]string{"\u0307"}, "1")); got != "--/42" {
+		t.Errorf("got %+q, want the value unchanged", got)
+	}
+}
@@ -0,0 +1,130 @@
+// Package dupflag implements the DuplicateFlags obfuscation modifier.
+//
+// Technique: repeat a flag the command already has, with the values it
+// takes, later on the command line. Tools that accept a flag more than once
+// run as before, but detections matching the exact argv, or counting its
+// arguments, no longer do.
+//
+// Only flags whose argument definition in the profile is marked repeatable
+// are repeated, since many tools reject a second copy or treat it as a
+// different request. The copy goes in right after the flag's own values or
+// after any later argument group, but never right after a flag the profile
+// does not define, which may take the next token as its value. It keeps the
+// flag's spelling.
+//
+// Not part of ArgFuscator.
+// Applies to token types: argument (and its associated value tokens)
+package dupflag
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&DuplicateFlags{})
+}
+
+// DuplicateFlags repeats flags the profile marks repeatable.
+type DuplicateFlags struct{}
+
+func (d *DuplicateFlags) Name() string        { return "DuplicateFlags" }
+func (d *DuplicateFlags) Description() string { return "Repeat flags the tool accepts more than once" }
+
+// InsertsTokens implements modifiers.Inserter.
+func (d *DuplicateFlags) InsertsTokens() bool { return true }
+
+// Config holds DuplicateFlags-specific config fields.
+type Config struct {
+	models.BaseModifierConfig
+}
+
+// Apply implements modifiers.Modifier. Without a Context it has no argument
+// definitions, so it changes nothing.
+func (d *DuplicateFlags) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return d.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from
+// mc and the profile's argument definitions from mc.Arguments.
+func (d *DuplicateFlags) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := d.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return d.ApplyParsed(mc, tokens, parsed)
+}
+
+// parsedConfig is a Config with its probability parsed.
+type parsedConfig struct {
+	base        models.BaseModifierConfig
+	probability float64
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (d *DuplicateFlags) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	probability, err := modifiers.ParseProbability(string(cfgM.Probability))
+	if err != nil {
+		return nil, err
+	}
+	return &parsedConfig{base: cfgM.BaseModifierConfig, probability: probability}, nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+//
+// Each group of a repeatable flag and its values rolls the probability once;
+// when it fires, a copy without spans goes after one of the groups it may
+// follow, picked at random. The tokens given are never moved or changed.
+func (d *DuplicateFlags) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	stream := models.TokenStream(tokens)
+	groups := stream.PairsWithValues(mc.Arguments)
+
+	copies := make(map[int][]models.Token) // by the group they go after
+	for i, g := range groups {
+		if g.Def == nil || !g.Def.Repeatable || !modifiers.Applies(cfgM.base, tokens[g.Start]) {
+			continue
+		}
+		if mc.Float64() >= cfgM.probability {
+			continue
+		}
+		after := []int{i}
+		for j := i + 1; j < len(groups); j++ {
+			if groups[j].Def != nil || tokens[groups[j].Start].Type != models.TokenTypeArgument {
+				after = append(after, j)
+			}
+		}
+		at := after[mc.Intn(len(after))]
+		for _, t := range tokens[g.Start:g.End] {
+			t.Start, t.End = 0, 0
+			copies[at] = append(copies[at], t)
+		}
+	}
+	if len(copies) == 0 {
+		return tokens, nil
+	}
+
+	out := []models.Token{tokens[0]}
+	for i, g := range groups {
+		out = append(out, tokens[g.Start:g.End]...)
+		out = append(out, copies[i]...)
+	}
+	return out, nil
+}
+
+// Lint implements modifiers.Linter: the profile needs a repeatable flag.
+func (d *DuplicateFlags) Lint(_ json.RawMessage, _ string, p *models.Profile) []modifiers.Finding {
+	if slices.ContainsFunc(p.Parameters.Arguments, func(a models.ArgumentDefinition) bool { return a.Repeatable }) {
+		return nil
+	}
+	return []modifiers.Finding{{Hint: `mark the flags the tool accepts twice with "repeatable": true`,
+		Message: "no argument is repeatable, so the modifier never fires"}}
+}
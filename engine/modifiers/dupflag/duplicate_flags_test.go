@@ -0,0 +1,145 @@
+package dupflag
+
+import (
+	"encoding/json"
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(probability string) json.RawMessage {
+	return json.RawMessage(`{"AppliesTo": ["argument"], "Probability": "` + probability + `"}`)
+}
+
+// args defines -f and -o FILE as repeatable and -split as not.
+var args = []models.ArgumentDefinition{
+	{Flags: []string{"-f", "/f"}, Repeatable: true},
+	{Flags: []string{"-o"}, ValueCount: 1, Repeatable: true},
+	{Flags: []string{"-split"}},
+}
+
+// line tokenizes a command the way the tokenizer would, with spans: words
+// starting with "-" are arguments, the rest values.
+func line(words ...string) []models.Token {
+	out := make([]models.Token, len(words))
+	pos := 0
+	for i, w := range words {
+		typ := models.TokenTypeValue
+		switch {
+		case i == 0:
+			typ = models.TokenTypeCommand
+		case w[0] == '-':
+			typ = models.TokenTypeArgument
+		}
+		out[i] = models.Token{Type: typ, Value: w, Start: pos, End: pos + len(w)}
+		pos += len(w) + 1
+	}
+	return out
+}
+
+func values(tokens []models.Token) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.Value
+	}
+	return out
+}
+
+func run(t *testing.T, seed int64, tokens []models.Token, probability string) []models.Token {
+	t.Helper()
+	mc := modifiers.Context{Rand: rand.New(rand.NewSource(seed)), Arguments: args}
+	out, err := (&DuplicateFlags{}).ApplyContext(mc, tokens, cfg(probability))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&DuplicateFlags{}).Name(); name != "DuplicateFlags" {
+		t.Errorf("Name() = %q, want %q", name, "DuplicateFlags")
+	}
+	if m, ok := modifiers.Get("DuplicateFlags"); !ok || !modifiers.Inserts(m) {
+		t.Error("DuplicateFlags is not registered as an Inserter")
+	}
+}
+
+// ─── duplication ──────────────────────────────────────────────────────────────
+
+func TestApply_RepeatsRepeatableFlags(t *testing.T) {
+	in := line("tool", "-f", "-o", "out.txt", "-split", "in.txt")
+	for seed := range int64(50) {
+		out := run(t, seed, in, "1.0")
+		if !slices.Equal(spanned(out), in) {
+			t.Fatalf("seed %d: the original tokens changed: %q", seed, values(out))
+		}
+		var added []string
+		for _, tok := range out {
+			if !tok.HasSpan() {
+				added = append(added, tok.Value)
+			}
+		}
+		if slices.Sort(added); !slices.Equal(added, []string{"-f", "-o", "out.txt"}) {
+			t.Fatalf("seed %d: added %q, want -f and -o out.txt", seed, added)
+		}
+		if i := slices.IndexFunc(out, func(tok models.Token) bool { return tok.Value == "-o" && !tok.HasSpan() }); out[i+1].Value != "out.txt" {
+			t.Errorf("seed %d: the copy of -o lost its value: %q", seed, values(out))
+		}
+	}
+}
+
+func TestApply_Placement(t *testing.T) {
+	// The copy of -f may follow -f itself, -split or in.txt, but not -x,
+	// whose value in.txt could be.
+	in := line("tool", "-f", "-split", "-x", "in.txt")
+	seen := map[string]bool{}
+	for seed := range int64(100) {
+		out := run(t, seed, in, "1.0")
+		i := slices.IndexFunc(out, func(tok models.Token) bool { return !tok.HasSpan() })
+		seen[out[i-1].Value] = true
+	}
+	want := map[string]bool{"-f": true, "-split": true, "in.txt": true}
+	if !maps.Equal(seen, want) {
+		t.Errorf("copies followed %v, want each of %v", seen, want)
+	}
+}
+
+func TestApply_NothingToRepeat(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokens      []models.Token
+		probability string
+	}{
+		{"not repeatable", line("tool", "-split", "a"), "1.0"},
+		{"unknown flag", line("tool", "-q"), "1.0"},
+		{"probability 0", line("tool", "-f"), "0"},
+		{"command only", line("tool"), "1.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out := run(t, 1, tt.tokens, tt.probability); !slices.Equal(out, tt.tokens) {
+				t.Errorf("got %q, want the input back", values(out))
+			}
+		})
+	}
+}
+
+func TestApply_NoArguments(t *testing.T) {
+	in := line("tool", "-f")
+	out, err := (&DuplicateFlags{}).Apply(in, cfg("1.0"))
+	if err != nil || !slices.Equal(out, in) {
+		t.Errorf("Apply = %q, %v, want the input back: there are no definitions", values(out), err)
+	}
+}
+
+func spanned(tokens []models.Token) []models.Token {
+	return slices.DeleteFunc(slices.Clone(tokens), func(t models.Token) bool { return !t.HasSpan() })
+}
@@ -0,0 +1,60 @@
+package modifiers
+
+import (
+	"slices"
+
+	"cmdFuscator/models"
+)
+
+// ─── Eligibility helpers ──────────────────────────────────────────────────────
+
+// Applies reports whether cfg.AppliesTo lists the type of t.
+func Applies(cfg models.BaseModifierConfig, t models.Token) bool {
+	return slices.Contains(cfg.AppliesTo, string(t.Type))
+}
+
+// ForEachEligible is the common loop of per-token modifiers. For every token
+// whose type is listed in cfg.AppliesTo it rolls cfg.Probability once with c,
+// and when the roll fires calls fn with the token's index and value; fn returns
+// the replacement token.
+//
+// tokens itself is never written to. It is copied on the first replacement that
+// differs from the original, so a run that changes nothing returns tokens as is.
+// An unparseable or out-of-range probability is returned as an error before fn
+// is called.
+func ForEachEligible(tokens []models.Token, cfg models.BaseModifierConfig, c Context, fn func(i int, t models.Token) models.Token) ([]models.Token, error) {
+	p, err := ParseProbability(string(cfg.Probability))
+	if err != nil {
+		return tokens, err
+	}
+
+	return ForEachApplicable(tokens, cfg, func(i int, t models.Token) models.Token {
+		if c.Float64() >= p {
+			return t
+		}
+		return fn(i, t)
+	}), nil
+}
+
+// ForEachApplicable is ForEachEligible without the probability roll, for
+// modifiers that decide at a finer grain than the token (RandomCase rolls per
+// character). fn is called for every token whose type is in cfg.AppliesTo.
+func ForEachApplicable(tokens []models.Token, cfg models.BaseModifierConfig, fn func(i int, t models.Token) models.Token) []models.Token {
+	out := tokens
+	copied := false
+	for i, t := range tokens {
+		if !Applies(cfg, t) {
+			continue
+		}
+		nt := fn(i, t)
+		if nt == t {
+			continue
+		}
+		if !copied {
+			out = slices.Clone(tokens)
+			copied = true
+		}
+		out[i] = nt
+	}
+	return out
+}
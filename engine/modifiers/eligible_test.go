@@ -0,0 +1,86 @@
+package modifiers
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func base(probability string, appliesTo ...string) models.BaseModifierConfig {
+	return models.BaseModifierConfig{AppliesTo: appliesTo, Probability: models.ProbabilityValue(probability)}
+}
+
+func upper(_ int, t models.Token) models.Token {
+	t.Value = strings.ToUpper(t.Value)
+	return t
+}
+
+var sample = []models.Token{
+	{Type: models.TokenTypeCommand, Value: "tool"},
+	{Type: models.TokenTypeArgument, Value: "-a"},
+	{Type: models.TokenTypeValue, Value: "v"},
+	{Type: models.TokenTypeArgument, Value: "-b"},
+}
+
+func TestForEachEligible_FiltersByType(t *testing.T) {
+	got, err := ForEachEligible(sample, base("1.0", "argument"), Context{}, upper)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"tool", "-A", "v", "-B"}
+	for i := range want {
+		if got[i].Value != want[i] {
+			t.Errorf("token[%d] = %q, want %q", i, got[i].Value, want[i])
+		}
+	}
+	if sample[1].Value != "-a" {
+		t.Error("ForEachEligible modified its input")
+	}
+}
+
+func TestForEachEligible_ProbabilityEdges(t *testing.T) {
+	calls := 0
+	count := func(_ int, t models.Token) models.Token { calls++; return t }
+
+	c := Context{Rand: rand.New(rand.NewSource(1))}
+	for range 100 {
+		if _, err := ForEachEligible(sample, base("0.0", "argument"), c, count); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("probability 0: fn called %d times", calls)
+	}
+
+	if _, err := ForEachEligible(sample, base("1", "argument", "value"), c, count); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("probability 1: fn called %d times, want 3", calls)
+	}
+}
+
+func TestForEachEligible_InvalidProbability(t *testing.T) {
+	for _, p := range []string{"", "x", "-0.1", "1.5"} {
+		if _, err := ForEachEligible(sample, base(p, "argument"), Context{}, upper); err == nil {
+			t.Errorf("probability %q should be rejected", p)
+		}
+	}
+}
+
+func TestForEachApplicable_CopyOnWrite(t *testing.T) {
+	same := ForEachApplicable(sample, base("", "argument"), func(_ int, t models.Token) models.Token { return t })
+	if &same[0] != &sample[0] {
+		t.Error("unchanged run should return the input slice")
+	}
+
+	changed := ForEachApplicable(sample, base("", "value"), upper)
+	if &changed[0] == &sample[0] {
+		t.Error("changed run should return a copy")
+	}
+	if changed[2].Value != "V" || sample[2].Value != "v" {
+		t.Errorf("changed = %q, input = %q", changed[2].Value, sample[2].Value)
+	}
+}
@@ -0,0 +1,236 @@
+// Package exeform implements the ExecutableForm obfuscation modifier.
+//
+// Technique: change how the command names the executable without changing
+// which one runs, in up to three ways, each with its own probability:
+//
+// Example:  certutil  →  certutil.exe  or  C:\Windows\System32\certutil.exe  or  C:\Windows\System32\.\certutil.exe
+//
+//   - Extension appends .exe to a name without an extension, or strips it
+//     from one that has it. Windows only: elsewhere the name is the file's.
+//   - AbsolutePath replaces a bare name with one of the profile's "paths"
+//     whose file name matches it, so an alias is never swapped for the
+//     profile's executable.
+//   - DotPrefix inserts a redundant . segment after one of the path's
+//     separators (/usr/bin/./bash). A bare name is first replaced as by
+//     AbsolutePath, and left alone when no path matches it: .\certutil on
+//     its own would look in the working directory instead of the PATH.
+//
+// Names holding quotes or expansions are left alone, and so are
+// drive-relative ones like C:certutil.
+//
+// Not part of ArgFuscator.
+// Applies to token types: command
+package exeform
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&ExecutableForm{})
+}
+
+// ExecutableForm rewrites the command token's form.
+type ExecutableForm struct{}
+
+func (e *ExecutableForm) Name() string { return "ExecutableForm" }
+func (e *ExecutableForm) Description() string {
+	return "Add or strip .exe, spell out the command's absolute path, or insert ./ into it"
+}
+
+// Config holds ExecutableForm-specific config fields. Each field is the
+// chance, between 0 and 1, that its form is applied to a command the roll
+// fires for; empty means "0". At least one must be set.
+type Config struct {
+	models.BaseModifierConfig
+	DotPrefix    string `json:"DotPrefix,omitempty"`
+	Extension    string `json:"Extension,omitempty"`
+	AbsolutePath string `json:"AbsolutePath,omitempty"`
+}
+
+// Apply implements modifiers.Modifier. Without a Context it knows neither
+// the platform nor the profile's paths, so only DotPrefix fires, on commands
+// already holding a directory.
+func (e *ExecutableForm) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return e.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from
+// mc and the platform and paths from mc.Platform and mc.Paths.
+func (e *ExecutableForm) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := e.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return e.ApplyParsed(mc, tokens, parsed)
+}
+
+// parsedConfig is a Config with its probabilities parsed.
+type parsedConfig struct {
+	base                               models.BaseModifierConfig
+	dotPrefix, extension, absolutePath float64
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (e *ExecutableForm) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	p := &parsedConfig{base: cfgM.BaseModifierConfig}
+	for _, f := range []struct {
+		name string
+		in   string
+		out  *float64
+	}{
+		{"dot prefix", cfgM.DotPrefix, &p.dotPrefix},
+		{"extension", cfgM.Extension, &p.extension},
+		{"absolute path", cfgM.AbsolutePath, &p.absolutePath},
+	} {
+		if f.in == "" {
+			continue
+		}
+		v, err := modifiers.ParseProbability(f.in)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.out = v
+	}
+	if p.dotPrefix == 0 && p.extension == 0 && p.absolutePath == 0 {
+		return nil, fmt.Errorf("no form is enabled: set DotPrefix, Extension or AbsolutePath")
+	}
+	return p, nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+func (e *ExecutableForm) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	windows := strings.EqualFold(mc.Platform, "windows")
+	return modifiers.ForEachEligible(tokens, cfgM.base, mc, func(_ int, t models.Token) models.Token {
+		v := t.Value
+		if t.Type != models.TokenTypeCommand || v == "" || strings.ContainsAny(v, "\"'`^%!$") {
+			return t
+		}
+
+		// Roll every form, so that one firing does not shift the others' draws.
+		absolute := mc.Float64() < cfgM.absolutePath
+		dot := mc.Float64() < cfgM.dotPrefix
+		ext := mc.Float64() < cfgM.extension
+
+		switch {
+		case strings.ContainsAny(v, `/\`):
+			// Already a path, which AbsolutePath leaves as it is.
+		case strings.ContainsRune(v, ':'):
+			return t // drive-relative: no separator to insert . after
+		case absolute || dot:
+			paths := Matching(mc.Paths, v)
+			if len(paths) == 0 {
+				dot = false
+				break
+			}
+			v = paths[mc.Intn(len(paths))]
+		}
+		if dot {
+			v = dotSegment(mc, v)
+		}
+		if ext && windows {
+			v = toggleExe(v)
+		}
+		t.Value = v
+		return t
+	})
+}
+
+// ─── Names ────────────────────────────────────────────────────────────────────
+
+// Matching returns the paths whose file name is name's, ignoring case and a
+// trailing .exe on either.
+func Matching(paths []string, name string) []string {
+	var out []string
+	for _, p := range paths {
+		if stem(p) == stem(name) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// stem returns the file name of p, which may use either separator, in lower
+// case and without .exe.
+func stem(p string) string {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(p, `\`, "/")))
+	return strings.TrimSuffix(name, ".exe")
+}
+
+// dotSegment inserts a . segment after a separator of v picked at random,
+// written with that separator: C:\Windows\.\certutil.exe. A v without one
+// is returned as is.
+func dotSegment(mc modifiers.Context, v string) string {
+	var seps []int
+	for i := 0; i < len(v); i++ {
+		if v[i] == '/' || v[i] == '\\' {
+			seps = append(seps, i)
+		}
+	}
+	if len(seps) == 0 {
+		return v
+	}
+	i := seps[mc.Intn(len(seps))]
+	return v[:i+1] + "." + v[i:]
+}
+
+// toggleExe strips .exe, in any case, from v, or appends it when v's file
+// name has no extension. A name with another extension, like run.bat, is
+// returned as is.
+func toggleExe(v string) string {
+	base := path.Base(strings.ReplaceAll(v, `\`, "/"))
+	ext := path.Ext(base)
+	switch {
+	case strings.EqualFold(ext, ".exe") && len(base) > len(ext):
+		return v[:len(v)-len(ext)]
+	case ext == "":
+		return v + ".exe"
+	}
+	return v
+}
+
+// Lint implements modifiers.Linter: the forms need something to work with,
+// a path the profile lists for the executable or, for Extension, Windows.
+func (e *ExecutableForm) Lint(cfg json.RawMessage, file string, p *models.Profile) []modifiers.Finding {
+	var c Config
+	if json.Unmarshal(cfg, &c) != nil {
+		return nil
+	}
+	listed := slices.ContainsFunc(append([]string{file}, p.Alias...), func(name string) bool {
+		return len(Matching(p.Paths, name)) > 0
+	})
+	hint := fmt.Sprintf(`list where %s is installed in "paths"`, file)
+	var out []modifiers.Finding
+	if enabled(c.AbsolutePath) && !listed {
+		out = append(out, modifiers.Finding{Path: "AbsolutePath", Hint: hint,
+			Message: fmt.Sprintf("no path in the profile names %q, so the form never fires", file)})
+	}
+	if enabled(c.DotPrefix) && !listed {
+		out = append(out, modifiers.Finding{Path: "DotPrefix", Hint: hint,
+			Message: fmt.Sprintf("no path in the profile names %q, so the form only fires on commands naming a directory", file)})
+	}
+	if enabled(c.Extension) && !strings.EqualFold(p.Platform, "windows") {
+		out = append(out, modifiers.Finding{Path: "Extension",
+			Message: fmt.Sprintf("%q executables have no .exe, so the form never fires", p.Platform)})
+	}
+	return out
+}
+
+// enabled reports whether probability parses to more than zero.
+func enabled(probability string) bool {
+	v, err := modifiers.ParseProbability(probability)
+	return err == nil && v > 0
+}
@@ -0,0 +1,182 @@
+package exeform
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(dot, ext, absolute string) json.RawMessage {
+	c := Config{
+		BaseModifierConfig: models.BaseModifierConfig{
+			AppliesTo:   []string{"command"},
+			Probability: "1.0",
+		},
+		DotPrefix:    dot,
+		Extension:    ext,
+		AbsolutePath: absolute,
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("cfg helper: " + err.Error())
+	}
+	return b
+}
+
+var paths = []string{`C:\Windows\System32\certutil.exe`, `C:\Windows\SysWOW64\certutil.exe`, "/usr/bin/bash"}
+
+func rewrite(t *testing.T, seed int64, platform, command string, c json.RawMessage) string {
+	t.Helper()
+	tokens := []models.Token{
+		{Type: models.TokenTypeCommand, Value: command},
+		{Type: models.TokenTypeArgument, Value: "-urlcache"},
+	}
+	mc := modifiers.Context{Rand: rand.New(rand.NewSource(seed)), Platform: platform, Paths: paths}
+	out, err := (&ExecutableForm{}).ApplyContext(mc, tokens, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out[1].Value != "-urlcache" {
+		t.Fatalf("argument rewritten to %q", out[1].Value)
+	}
+	return out[0].Value
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&ExecutableForm{}).Name(); name != "ExecutableForm" {
+		t.Errorf("Name() = %q, want %q", name, "ExecutableForm")
+	}
+	if _, ok := modifiers.Get("ExecutableForm"); !ok {
+		t.Error("ExecutableForm is not registered")
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  json.RawMessage
+	}{
+		{"no form", cfg("", "", "")},
+		{"all zero", cfg("0", "0.0", "")},
+		{"dot prefix above 1", cfg("2", "", "")},
+		{"extension not a number", cfg("", "often", "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (&ExecutableForm{}).ParseConfig(nil, tt.cfg); err == nil {
+				t.Error("ParseConfig should return an error")
+			}
+		})
+	}
+}
+
+// ─── forms ────────────────────────────────────────────────────────────────────
+
+func TestApply_Forms(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform string
+		command  string
+		cfg      json.RawMessage
+		want     string
+	}{
+		{"append .exe", "windows", "certutil", cfg("", "1", ""), "certutil.exe"},
+		{"strip .exe", "windows", "CertUtil.EXE", cfg("", "1", ""), "CertUtil"},
+		{"no extension off windows", "linux", "bash", cfg("", "1", ""), "bash"},
+		{"other extension kept", "windows", "run.bat", cfg("", "1", ""), "run.bat"},
+		{"directory keeps its path", "windows", `C:\Tools\certutil`, cfg("", "", "1"), `C:\Tools\certutil`},
+		{"directory still toggles extension", "windows", `C:\Tools\certutil`, cfg("", "1", ""), `C:\Tools\certutil.exe`},
+		{"drive-relative", "windows", "C:certutil", cfg("1", "1", "1"), "C:certutil"},
+		{"no matching path", "windows", "bitsadmin", cfg("", "", "1"), "bitsadmin"},
+		{"no path to insert . into", "windows", "bitsadmin", cfg("1", "", ""), "bitsadmin"},
+		{"expansion", "windows", "%SystemRoot%\\certutil", cfg("1", "1", ""), "%SystemRoot%\\certutil"},
+		{"quoted", "windows", `"certutil"`, cfg("1", "1", ""), `"certutil"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewrite(t, 1, tt.platform, tt.command, tt.cfg); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply_AbsolutePath(t *testing.T) {
+	windows := paths[:2]
+	seen := map[string]bool{}
+	for seed := range int64(30) {
+		got := rewrite(t, seed, "windows", "certutil.exe", cfg("", "", "1"))
+		if !slices.Contains(windows, got) {
+			t.Fatalf("seed %d: got %q, want one of %q", seed, got, windows)
+		}
+		seen[got] = true
+	}
+	if len(seen) != len(windows) {
+		t.Errorf("only %v were picked", seen)
+	}
+}
+
+func TestApply_DotSegment(t *testing.T) {
+	tests := []struct {
+		platform, command string
+		want              []string
+	}{
+		{"linux", "bash", []string{"/./usr/bin/bash", "/usr/./bin/bash", "/usr/bin/./bash"}},
+		{"windows", `C:\Tools\certutil.exe`, []string{`C:\.\Tools\certutil.exe`, `C:\Tools\.\certutil.exe`}},
+	}
+	for _, tt := range tests {
+		seen := map[string]bool{}
+		for seed := range int64(50) {
+			got := rewrite(t, seed, tt.platform, tt.command, cfg("1", "", ""))
+			if !slices.Contains(tt.want, got) {
+				t.Fatalf("seed %d: got %q, want one of %q", seed, got, tt.want)
+			}
+			seen[got] = true
+		}
+		if len(seen) != len(tt.want) {
+			t.Errorf("%s: only %v were picked", tt.command, seen)
+		}
+	}
+
+	// A bare name gets a profile path, as with AbsolutePath.
+	for seed := range int64(20) {
+		got := rewrite(t, seed, "windows", "certutil", cfg("1", "", ""))
+		if clean := strings.Replace(got, `\.\`, `\`, 1); clean == got || !slices.Contains(paths, clean) {
+			t.Errorf("seed %d: %q is not a profile path with . inserted", seed, got)
+		}
+	}
+}
+
+func TestApply_Probabilities(t *testing.T) {
+	type form struct{ dot, exe bool }
+	counts := map[form]int{}
+	const runs = 400
+	for seed := range int64(runs) {
+		got := rewrite(t, seed, "windows", `C:\Tools\certutil`, cfg("0.5", "0.5", ""))
+		counts[form{strings.Contains(got, `\.\`), strings.HasSuffix(got, ".exe")}]++
+	}
+	for _, f := range []form{{false, false}, {true, false}, {false, true}, {true, true}} {
+		if n := counts[f]; n < runs/8 {
+			t.Errorf("%+v came up %d times in %d, want about a quarter", f, n, runs)
+		}
+	}
+}
+
+func TestMatching(t *testing.T) {
+	all := []string{`C:\Windows\System32\certutil.exe`, "/usr/bin/bash", `C:\Tools\CERTUTIL`}
+	if got := Matching(all, "CertUtil.exe"); !slices.Equal(got, []string{all[0], all[2]}) {
+		t.Errorf("Matching(certutil) = %q", got)
+	}
+	if got := Matching(all, "sh"); got != nil {
+		t.Errorf("Matching(sh) = %q, want none", got)
+	}
+}
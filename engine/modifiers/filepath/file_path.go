@@ -51,5 +51,10 @@ type Config struct {
 //     d. If Config.ExtraSlashes: double one or more separator characters.
 //  4. Return updated tokens.
 func (f *FilePathTransformer) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return f.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from c.
+func (f *FilePathTransformer) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
 	return tokens, modifiers.ErrNotImplemented
 }
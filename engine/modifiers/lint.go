@@ -0,0 +1,52 @@
+package modifiers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"cmdFuscator/models"
+)
+
+// ─── Linting ──────────────────────────────────────────────────────────────────
+
+// Linter is implemented by modifiers that can tell configs which parse but
+// are probably mistakes, given the profile they are in: a pool with nothing
+// to draw from, a setting the profile's platform has no use for. The lint
+// package calls Lint for every modifier a profile configures, once its
+// AppliesTo and Probability parse, and reports the findings under the
+// modifier's config.
+type Linter interface {
+	Modifier
+	// Lint returns the problems with cfg, the modifier's config in profile p
+	// of the file named file (e.g. "certutil"), or nil.
+	Lint(cfg json.RawMessage, file string, p *models.Profile) []Finding
+}
+
+// Finding is one problem a Linter found.
+type Finding struct {
+	// Path locates the setting within the modifier's config, e.g.
+	// "Characters[2]"; empty for the config as a whole.
+	Path string
+	// Error marks a setting the modifier fails on or silently ignores;
+	// otherwise the finding is a warning.
+	Error   bool
+	Message string
+	// Hint suggests a fix; it may be empty.
+	Hint string
+}
+
+// LintPool returns the findings for pool, the strings a modifier draws from,
+// at path: an error with hint when it is empty, and a warning for each empty
+// entry.
+func LintPool(path string, pool []string, hint string) []Finding {
+	if len(pool) == 0 {
+		return []Finding{{Path: path, Error: true, Message: "pool is empty, so the modifier has nothing to insert", Hint: hint}}
+	}
+	var out []Finding
+	for i, s := range pool {
+		if s == "" {
+			out = append(out, Finding{Path: fmt.Sprintf("%s[%d]", path, i), Message: "empty string in pool", Hint: "remove the entry"})
+		}
+	}
+	return out
+}
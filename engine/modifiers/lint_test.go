@@ -0,0 +1,16 @@
+package modifiers
+
+import "testing"
+
+func TestLintPool(t *testing.T) {
+	if got := LintPool("Characters", nil, "add one"); len(got) != 1 || !got[0].Error || got[0].Path != "Characters" || got[0].Hint != "add one" {
+		t.Errorf("empty pool: %+v, want one error with the hint", got)
+	}
+	got := LintPool("Characters", []string{"a", "", "b"}, "add one")
+	if len(got) != 1 || got[0].Error || got[0].Path != "Characters[1]" {
+		t.Errorf("empty entry: %+v, want a warning at Characters[1]", got)
+	}
+	if got := LintPool("Characters", []string{"a"}, "add one"); got != nil {
+		t.Errorf("clean pool: %+v", got)
+	}
+}
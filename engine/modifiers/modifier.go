@@ -3,13 +3,20 @@
 //
 // To add a new modifier:
 //  1. Create a new file (e.g. my_technique.go) in this package.
-//  2. Define a struct that implements the Modifier interface.
+//  2. Define a struct that implements the Modifier interface and, if it draws
+//     any randomness, ContextModifier: take every random choice from the
+//     Context, never from the package-global math/rand functions, so that
+//     seeded runs are reproducible and concurrent runs share no lock.
 //  3. Call Register(New<MyTechnique>()) in an init() function in that file.
+//  4. Optionally implement Linter, so `cmdfuscator validate` catches configs
+//     the modifier would do nothing with.
 package modifiers
 
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	randv2 "math/rand/v2"
 
 	"cmdFuscator/models"
 )
@@ -34,9 +41,120 @@ type Modifier interface {
 	// cfg is the raw JSON config for this modifier from the profile; unmarshal
 	// it into a modifier-specific struct that embeds models.BaseModifierConfig.
 	// Return the (possibly modified) token slice and any error.
+	//
+	// Apply must not write to tokens; ForEachEligible copies on write for
+	// it. The engine calls it through engine.ApplyModifier, which passes a
+	// copy and discards the result on error, so a modifier that breaks this
+	// cannot corrupt a run, but the modtest invariants report it.
 	Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error)
 }
 
+// ─── Run context ──────────────────────────────────────────────────────────────
+
+// Context carries per-run state that the engine hands to modifiers.
+// The zero value is ready to use.
+type Context struct {
+	// Rand is the random source for this run; the engine gives every run its
+	// own (see engine.WithSeed). Nil falls back to math/rand/v2's unseeded
+	// top-level functions, which take no lock.
+	Rand *rand.Rand
+
+	// Arguments are the argument definitions of the profile being run, for
+	// modifiers that need to know the executable's flags. Nil outside a
+	// profile.
+	Arguments []models.ArgumentDefinition
+
+	// Executable is the name of the profile file being run, which is the
+	// executable's, e.g. "certutil" or "powershell", for modifiers whose
+	// technique only one program understands. Empty outside a profile.
+	Executable string
+
+	// Platform is the profile's platform, "windows", "linux" or "macos", and
+	// Paths the absolute paths it lists for the executable, for modifiers
+	// that rewrite the command itself. Empty outside a profile.
+	Platform string
+	Paths    []string
+}
+
+// Float64 returns a pseudo-random number in [0.0, 1.0) from c.Rand.
+func (c Context) Float64() float64 {
+	if c.Rand == nil {
+		return randv2.Float64()
+	}
+	return c.Rand.Float64()
+}
+
+// Intn returns a pseudo-random number in [0, n) from c.Rand. It panics if n <= 0.
+func (c Context) Intn(n int) int {
+	if c.Rand == nil {
+		return randv2.IntN(n)
+	}
+	return c.Rand.Intn(n)
+}
+
+// Shuffle pseudo-randomizes the order of n elements with c.Rand; swap swaps
+// the elements with indexes i and j. It panics if n < 0.
+func (c Context) Shuffle(n int, swap func(i, j int)) {
+	if c.Rand == nil {
+		randv2.Shuffle(n, swap)
+		return
+	}
+	c.Rand.Shuffle(n, swap)
+}
+
+// ContextModifier is implemented by modifiers that draw randomness (and any
+// other per-run state) from a Context instead of package globals. The engine
+// prefers ApplyContext over Apply when a modifier implements it, which is what
+// makes seeded and per-worker runs reproducible.
+type ContextModifier interface {
+	Modifier
+	ApplyContext(c Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error)
+}
+
+// ConfigParser is implemented by modifiers whose config can be parsed once
+// and reused across runs. The engine caches what ParseConfig returns for each
+// modifier and profile config, and calls ApplyParsed with it instead of
+// ApplyContext, so batch runs do not unmarshal the same JSON for every
+// command.
+type ConfigParser interface {
+	ContextModifier
+	// ParseConfig unmarshals and validates cfg, building anything derived
+	// from it through a. Errors are the ones Apply would return for the same
+	// config. a is nil when there is no cache, as when Apply parses for itself.
+	ParseConfig(a *Artifacts, cfg json.RawMessage) (any, error)
+	// ApplyParsed is ApplyContext with a config ParseConfig returned. The
+	// config is shared by concurrent runs, so it must not be modified.
+	ApplyParsed(c Context, tokens []models.Token, parsed any) ([]models.Token, error)
+}
+
+// Inserter is implemented by modifiers that may return more tokens than they
+// were given. Every other modifier returns one token per input token, in
+// order, as the modtest invariants check. An Inserter keeps the tokens it was
+// given in their order and with their spans, and leaves the span of every
+// token it adds unset (End == 0), which is how the engine puts frozen tokens
+// back in place and how traces tell added tokens from rewritten ones.
+type Inserter interface {
+	Modifier
+	// InsertsTokens reports whether the modifier may add tokens.
+	InsertsTokens() bool
+}
+
+// Inserts reports whether m may add tokens.
+func Inserts(m Modifier) bool {
+	in, ok := m.(Inserter)
+	return ok && in.InsertsTokens()
+}
+
+// ApplyWith runs m against tokens using c when m implements ContextModifier,
+// and plain Apply otherwise. It hands tokens over as is; callers that keep
+// using them should go through engine.ApplyModifier instead.
+func ApplyWith(c Context, m Modifier, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	if cm, ok := m.(ContextModifier); ok {
+		return cm.ApplyContext(c, tokens, cfg)
+	}
+	return m.Apply(tokens, cfg)
+}
+
 // ─── Registry ─────────────────────────────────────────────────────────────────
 
 // registry holds all modifiers indexed by Name().
@@ -0,0 +1,53 @@
+package modifiers
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Modifiers take their randomness from the Context: the package-global
+// math/rand functions share one locked source and ignore the engine's seed.
+// Only the types and constructors are allowed, for sources of a modifier's own.
+func TestNoGlobalRand(t *testing.T) {
+	allowed := map[string]bool{"Rand": true, "Source": true, "Source64": true, "New": true, "NewSource": true, "NewPCG": true, "NewChaCha8": true, "NewZipf": true}
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") || path == "modifier.go" {
+			return err
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		rands := map[string]bool{}
+		for _, imp := range f.Imports {
+			if p, _ := strconv.Unquote(imp.Path.Value); p == "math/rand" || p == "math/rand/v2" {
+				name := filepath.Base(strings.TrimSuffix(p, "/v2"))
+				if imp.Name != nil {
+					name = imp.Name.Name
+				}
+				rands[name] = true
+			}
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if id, ok := sel.X.(*ast.Ident); ok && rands[id.Name] && !allowed[sel.Sel.Name] {
+				t.Errorf("%s: %s.%s uses the global source; draw from modifiers.Context instead", fset.Position(sel.Pos()), id.Name, sel.Sel.Name)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
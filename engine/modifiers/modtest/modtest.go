@@ -0,0 +1,265 @@
+// Package modtest checks the invariants every modifier keeps, whatever its
+// technique, against generated token lists. A modifier gets them by being
+// registered: the package's own test runs Check over modifiers.All(), with
+// configs taken from the bundled profiles. Call Check from a modifier's test
+// to run them against a config of its own.
+//
+// For every generated case Check asserts that the modifier
+//
+//   - leaves its input untouched,
+//   - returns as many tokens as it was given, each of its original type and
+//     with its original span (a modifiers.Inserter may add tokens, without a
+//     span, between them),
+//   - leaves tokens whose type is not in AppliesTo alone,
+//   - changes nothing at probability 0,
+//   - gives the same output for the same seed, and
+//   - when it implements modifiers.ConfigParser, gives that output too from
+//     ApplyParsed, run twice on one parsed config.
+//
+// A modifier that is still a stub is skipped.
+package modtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// Options relaxes or tunes Check for one modifier.
+type Options struct {
+	// Config is the modifier's config for the generated cases, such as a
+	// bundled profile's. Check overrides its AppliesTo and Probability.
+	Config json.RawMessage
+	// Reorders marks modifiers that move tokens (ReorderArgs): tokens are then
+	// compared as a multiset rather than position by position.
+	Reorders bool
+	// Arguments are handed to the modifier in its Context, as the engine
+	// hands over a profile's.
+	Arguments []models.ArgumentDefinition
+	// Executable is handed to the modifier in its Context, as the engine
+	// hands over a profile file's name.
+	Executable string
+	// Platform and Paths are handed to the modifier in its Context, as the
+	// engine hands over a profile's.
+	Platform string
+	Paths    []string
+	// Cases is the number of generated cases; zero means 200.
+	Cases int
+	// Seed fixes the generator; zero means 1.
+	Seed int64
+}
+
+// Types lists every token type, in the order Check draws from.
+var Types = []models.TokenType{
+	models.TokenTypeCommand,
+	models.TokenTypeArgument,
+	models.TokenTypeValue,
+	models.TokenTypePath,
+	models.TokenTypeURL,
+	models.TokenTypeEnvVar,
+	models.TokenTypeExpansion,
+}
+
+// words are the raw material of generated values: typical command-line
+// words, plus quotes, carets, ZWJ (U+200D) and non-ASCII text.
+var words = []string{
+	"certutil", "-urlcache", "/f", "--output", "https://example.com/a?b=1",
+	`C:\Windows\Temp\a.exe`, "/tmp/x y", "%TEMP%", "$(id)", `"quoted value"`,
+	"it's", "^&|", "\u200dzw", "déjà", "ИМЯ", "", "x", "443",
+}
+
+// Check runs m against generated token lists and reports every case that
+// breaks an invariant.
+func Check(t *testing.T, m modifiers.Modifier, opts Options) {
+	t.Helper()
+	n := opts.Cases
+	if n == 0 {
+		n = 200
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = 1
+	}
+	gen := rand.New(rand.NewSource(seed))
+	for i := range n {
+		c := generate(gen)
+		cfg, err := withBase(opts.Config, c.appliesTo, c.probability)
+		if err != nil {
+			t.Fatalf("%s: config: %v", m.Name(), err)
+		}
+		runSeed := gen.Int63()
+		out, err := apply(m, opts.context(runSeed), c.tokens, cfg)
+		if errors.Is(err, modifiers.ErrNotImplemented) {
+			t.Skipf("%s is not implemented", m.Name())
+		}
+		name := fmt.Sprintf("%s case %d (AppliesTo %q, probability %s)", m.Name(), i, c.appliesTo, c.probability)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		for _, problem := range check(c, out, opts.Reorders, modifiers.Inserts(m)) {
+			t.Errorf("%s: %s\n in  %q\n out %q", name, problem, values(c.tokens), values(out))
+		}
+		if again, _ := apply(m, opts.context(runSeed), c.tokens, cfg); !slices.Equal(again, out) {
+			t.Errorf("%s: seed %d gave %q, then %q", name, runSeed, values(out), values(again))
+		}
+		if cp, ok := m.(modifiers.ConfigParser); ok {
+			parsed, err := cp.ParseConfig(&modifiers.Artifacts{}, cfg)
+			if err != nil {
+				t.Errorf("%s: ParseConfig: %v", name, err)
+				continue
+			}
+			for range 2 {
+				if got, err := cp.ApplyParsed(opts.context(runSeed), slices.Clone(c.tokens), parsed); err != nil || !slices.Equal(got, out) {
+					t.Errorf("%s: ApplyParsed gave %q (err %v), want %q", name, values(got), err, values(out))
+				}
+			}
+		}
+	}
+}
+
+// ─── Cases ────────────────────────────────────────────────────────────────────
+
+type testCase struct {
+	tokens      []models.Token
+	appliesTo   []string
+	probability string
+}
+
+// generate returns a command token followed by up to eight tokens of random
+// types and values, with spans as the tokenizer sets them, and a random
+// AppliesTo and probability.
+func generate(gen *rand.Rand) testCase {
+	var c testCase
+	pos := 0
+	for i := range 1 + gen.Intn(9) {
+		typ := models.TokenTypeCommand
+		if i > 0 {
+			typ = Types[1+gen.Intn(len(Types)-1)]
+		}
+		value := words[gen.Intn(len(words))]
+		if gen.Intn(2) == 0 {
+			value += words[gen.Intn(len(words))]
+		}
+		tok := models.Token{Type: typ, Value: value}
+		if value != "" {
+			tok.Start, tok.End = pos, pos+len(value)
+		}
+		pos += len(value) + 1
+		c.tokens = append(c.tokens, tok)
+	}
+	for _, typ := range Types {
+		if gen.Intn(2) == 0 {
+			c.appliesTo = append(c.appliesTo, string(typ))
+		}
+	}
+	c.probability = []string{"0", "0.5", "1.0"}[gen.Intn(3)]
+	return c
+}
+
+// withBase returns base with AppliesTo and Probability set.
+func withBase(base json.RawMessage, appliesTo []string, probability string) (json.RawMessage, error) {
+	cfg := map[string]any{}
+	if len(base) > 0 {
+		if err := json.Unmarshal(base, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	for key := range cfg {
+		if strings.EqualFold(key, "AppliesTo") || strings.EqualFold(key, "Probability") {
+			delete(cfg, key)
+		}
+	}
+	cfg["AppliesTo"] = appliesTo
+	cfg["Probability"] = probability
+	return json.Marshal(cfg)
+}
+
+// context returns the Context of a run: a source seeded with seed, and the
+// profile details opts hands over.
+func (opts Options) context(seed int64) modifiers.Context {
+	return modifiers.Context{
+		Rand:       rand.New(rand.NewSource(seed)),
+		Arguments:  opts.Arguments,
+		Executable: opts.Executable,
+		Platform:   opts.Platform,
+		Paths:      opts.Paths,
+	}
+}
+
+// apply runs m on tokens in mc, and reports it as an error when m writes to
+// tokens.
+func apply(m modifiers.Modifier, mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	in := slices.Clone(tokens)
+	out, err := modifiers.ApplyWith(mc, m, in, cfg)
+	if !slices.Equal(in, tokens) {
+		return out, fmt.Errorf("input changed to %q", values(in))
+	}
+	return out, err
+}
+
+// ─── Invariants ───────────────────────────────────────────────────────────────
+
+// check returns a description of every invariant out breaks. With inserts,
+// out may hold more tokens than c, and the invariants are checked for the
+// ones with a span.
+func check(c testCase, out []models.Token, reorders, inserts bool) []string {
+	if len(out) < len(c.tokens) || !inserts && len(out) != len(c.tokens) {
+		return []string{fmt.Sprintf("%d tokens became %d", len(c.tokens), len(out))}
+	}
+	var problems []string
+	if c.probability == "0" && !slices.Equal(out, c.tokens) {
+		problems = append(problems, "probability 0 changed the tokens")
+	}
+	if inserts {
+		if c.tokens, out = spanned(c.tokens), spanned(out); len(out) != len(c.tokens) {
+			return append(problems, fmt.Sprintf("%d tokens with a span became %d", len(c.tokens), len(out)))
+		}
+	}
+	if reorders {
+		if !slices.Equal(sortedTypes(c.tokens), sortedTypes(out)) {
+			problems = append(problems, "token types changed")
+		}
+		return problems
+	}
+	for i, in := range c.tokens {
+		switch got := out[i]; {
+		case got.Type != in.Type:
+			problems = append(problems, fmt.Sprintf("token %d changed type from %s to %s", i, in.Type, got.Type))
+		case got.Start != in.Start || got.End != in.End:
+			problems = append(problems, fmt.Sprintf("token %d lost its span [%d:%d]", i, in.Start, in.End))
+		case got != in && !slices.Contains(c.appliesTo, string(in.Type)):
+			problems = append(problems, fmt.Sprintf("token %d is a %s, which AppliesTo leaves out, but changed", i, in.Type))
+		}
+	}
+	return problems
+}
+
+// spanned returns the tokens with a span, which an Inserter keeps in order.
+func spanned(tokens []models.Token) []models.Token {
+	return slices.DeleteFunc(slices.Clone(tokens), func(t models.Token) bool { return !t.HasSpan() })
+}
+
+func sortedTypes(tokens []models.Token) []models.TokenType {
+	out := make([]models.TokenType, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.Type
+	}
+	slices.Sort(out)
+	return out
+}
+
+func values(tokens []models.Token) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.Value
+	}
+	return out
+}
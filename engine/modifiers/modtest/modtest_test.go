@@ -0,0 +1,107 @@
+package modtest
+
+import (
+	"encoding/json"
+	"io/fs"
+	"testing"
+
+	"cmdFuscator/data"
+	"cmdFuscator/engine/modifiers"
+	_ "cmdFuscator/engine/modifiers/all"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+// fallback holds the options of modifiers no bundled profile configures, or
+// that need more than a config.
+var fallback = map[string]Options{
+	"BidiInsertion": {Config: json.RawMessage(`{"Characters": ["\u202e", "\u2067", "\u200f"], "Positions": ["start", "extension"], "Terminate": true}`)},
+	"Script": {Config: json.RawMessage(`{"Script": [
+		"text := import(\"text\")",
+		"for t in tokens { if t.eligible && roll() { t.value = text.to_upper(t.value) + string(randint(10)) } }"
+	]}`)},
+	// -urlcache stands in for -Command, which the generated words lack.
+	"PowerShellConcat": {Executable: "powershell", Arguments: []models.ArgumentDefinition{
+		{Flags: []string{"-Command", "-urlcache"}, ValueCount: 1},
+	}},
+	"ReorderArgs":        {Reorders: true},
+	"DiacriticInsertion": {Config: json.RawMessage(`{"Characters": ["\u0307", "\u0323"], "Density": "0.3"}`)},
+	"DuplicateFlags": {Arguments: []models.ArgumentDefinition{
+		{Flags: []string{"-urlcache", "/f"}, Repeatable: true},
+		{Flags: []string{"--output"}, ValueCount: 1, Repeatable: true},
+	}},
+	"NumericMangling": {Arguments: []models.ArgumentDefinition{
+		{Flags: []string{"--output"}, ValueCount: 1, NumberForms: []string{"zeros", "hex", "octal"}},
+	}},
+	"ExecutableForm": {
+		Config:   json.RawMessage(`{"DotPrefix": "0.5", "Extension": "0.5", "AbsolutePath": "0.5"}`),
+		Platform: "windows",
+		Paths:    []string{`C:\Windows\System32\certutil.exe`},
+	},
+	"SubstringExpansion": {Config: json.RawMessage(`{"Table": {"e": ["COMSPEC:-1", "ProgramFiles:14"], "t": ["COMSPEC:14"]}, "IgnoreCase": true}`)},
+	"UrlMangling":        {Config: json.RawMessage(`{"MixedCaseScheme": true, "TrailingDot": true, "Punycode": true}`)},
+}
+
+func TestAllModifiers(t *testing.T) {
+	sub, err := fs.Sub(data.ModelFS, "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := loader.LoadFS(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range modifiers.All() {
+		t.Run(m.Name(), func(t *testing.T) {
+			opts := fallback[m.Name()]
+			if opts.Config == nil {
+				opts.Config = bundledConfig(profiles, m.Name())
+			}
+			Check(t, m, opts)
+		})
+	}
+}
+
+// bundledConfig returns the first bundled config of the modifier called name.
+func bundledConfig(profiles []*models.ProfileFile, name string) json.RawMessage {
+	for _, pf := range profiles {
+		for _, p := range pf.Profiles {
+			if raw, ok := p.Parameters.Modifiers[name]; ok {
+				return raw
+			}
+		}
+	}
+	return nil
+}
+
+// breaksAppliesTo changes every token, whatever AppliesTo says.
+type breaksAppliesTo struct{}
+
+func (breaksAppliesTo) Name() string        { return "breaksAppliesTo" }
+func (breaksAppliesTo) Description() string { return "" }
+func (breaksAppliesTo) Apply(tokens []models.Token, _ json.RawMessage) ([]models.Token, error) {
+	out := make([]models.Token, len(tokens))
+	for i, tok := range tokens {
+		tok.Value += "!"
+		out[i] = tok
+	}
+	return out, nil
+}
+
+func TestCheckCatches(t *testing.T) {
+	c := testCase{
+		tokens:      []models.Token{{Type: models.TokenTypeCommand, Value: "tool", End: 4}, {Type: models.TokenTypeArgument, Value: "-a", Start: 5, End: 7}},
+		appliesTo:   []string{"argument"},
+		probability: "0",
+	}
+	out, err := apply(breaksAppliesTo{}, Options{}.context(1), c.tokens, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := check(c, out, false, false); len(problems) != 2 {
+		t.Errorf("check = %q, want probability 0 and AppliesTo reported", problems)
+	}
+	if problems := check(c, out[:1], false, false); len(problems) != 1 {
+		t.Errorf("check = %q, want the token count reported", problems)
+	}
+}
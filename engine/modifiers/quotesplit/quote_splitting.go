@@ -0,0 +1,215 @@
+// Package quotesplit implements the QuoteSplitting obfuscation modifier.
+//
+// Technique: cut a token's value into chunks and quote each one, so the shell
+// concatenates them back into the same word. Unlike QuoteInsertion, which
+// adds empty pairs, the quotes wrap the token's own content:
+//
+// Example:  https://evil.com  →  "htt"'ps://e'"vil.com"  or  "htt"ps://e"vil.com"
+//
+// Two chunks quoted with the same character never touch: Windows programs
+// parsing their command line the way the C runtime does, and PowerShell, read
+// "" inside a quoted string as an escaped quote rather than two strings. With
+// both quotes configured the chunks alternate between them; with one, every
+// other chunk is left bare.
+//
+// Values holding quotes, escapes or expansions (" ' ` \ $ % !) are left
+// alone, since each shell treats those differently inside quotes and out, and
+// so are values holding whitespace: cmd.exe rendering wraps it in quotes of
+// its own, which would touch the chunks'.
+//
+// Not part of ArgFuscator.
+// Applies to token types: argument, value, path, url
+package quotesplit
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&QuoteSplitting{})
+}
+
+// QuoteSplitting splits token values into adjacent quoted chunks.
+type QuoteSplitting struct{}
+
+func (q *QuoteSplitting) Name() string        { return "QuoteSplitting" }
+func (q *QuoteSplitting) Description() string { return "Split tokens into adjacent quoted chunks" }
+
+// Config holds QuoteSplitting-specific config fields.
+type Config struct {
+	models.BaseModifierConfig
+	// Quotes is the pool of quote characters to wrap chunks in: `"`, `'` or
+	// both. Empty means `"`, the only one cmd.exe knows.
+	Quotes []string `json:"Quotes,omitempty"`
+	// Chunks is a string integer: how many chunks to cut a value into. It is
+	// capped at the value's length. Empty means "2" unless ChunkSize is set.
+	Chunks string `json:"Chunks,omitempty"`
+	// ChunkSize is a string integer: the most characters a chunk may hold.
+	// Values too long for Chunks chunks of that size get more of them.
+	ChunkSize string `json:"ChunkSize,omitempty"`
+}
+
+// unsafe are the characters whose meaning depends on quoting in at least one
+// of the shells the engine renders for.
+const unsafe = "\"'`\\$%!"
+
+// Apply implements modifiers.Modifier.
+func (q *QuoteSplitting) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return q.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from mc.
+func (q *QuoteSplitting) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := q.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return q.ApplyParsed(mc, tokens, parsed)
+}
+
+// parsedConfig is a Config with its quotes and sizes parsed. size is 0 when
+// chunks are not capped.
+type parsedConfig struct {
+	base   models.BaseModifierConfig
+	quotes []rune
+	chunks int
+	size   int
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (q *QuoteSplitting) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	p := &parsedConfig{base: cfgM.BaseModifierConfig}
+	for i, s := range cfgM.Quotes {
+		if s != `"` && s != `'` {
+			return nil, fmt.Errorf("quotes[%d]: %q is not \" or '", i, s)
+		}
+		if r := rune(s[0]); !strings.ContainsRune(string(p.quotes), r) {
+			p.quotes = append(p.quotes, r)
+		}
+	}
+	if len(p.quotes) == 0 {
+		p.quotes = []rune{'"'}
+	}
+
+	var err error
+	if p.chunks, err = parseCount("chunks", cfgM.Chunks); err != nil {
+		return nil, err
+	}
+	if p.size, err = parseCount("chunk size", cfgM.ChunkSize); err != nil {
+		return nil, err
+	}
+	if p.chunks == 0 && p.size == 0 {
+		p.chunks = 2
+	}
+	return p, nil
+}
+
+// parseCount parses a positive string integer, or "" as 0.
+func parseCount(name, s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("parse %s: %q is not a positive integer", name, s)
+	}
+	return n, nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+func (q *QuoteSplitting) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	return modifiers.ForEachEligible(tokens, cfgM.base, mc, func(_ int, t models.Token) models.Token {
+		if strings.ContainsAny(t.Value, unsafe) || strings.ContainsFunc(t.Value, unicode.IsSpace) {
+			return t
+		}
+		runes := []rune(t.Value)
+		sizes := cfgM.cut(mc, len(runes))
+		if len(sizes) < 2 {
+			return t
+		}
+
+		var b strings.Builder
+		var prev rune
+		bare := len(cfgM.quotes) == 1 && mc.Intn(2) == 0
+		for _, n := range sizes {
+			chunk := string(runes[:n])
+			runes = runes[n:]
+			if bare {
+				b.WriteString(chunk)
+				prev = 0
+			} else {
+				quote := cfgM.pick(mc, prev)
+				b.WriteRune(quote)
+				b.WriteString(chunk)
+				b.WriteRune(quote)
+				prev = quote
+			}
+			if len(cfgM.quotes) == 1 {
+				bare = !bare
+			}
+		}
+		t.Value = b.String()
+		return t
+	})
+}
+
+// cut returns the sizes of the chunks to cut a value of n characters into,
+// each at least 1 and no more than p.size, at random.
+func (p *parsedConfig) cut(mc modifiers.Context, n int) []int {
+	k := p.chunks
+	limit := n
+	if p.size > 0 {
+		k = max(k, (n+p.size-1)/p.size)
+		limit = p.size
+	}
+	k = min(k, n)
+
+	sizes := make([]int, k)
+	for i := range sizes {
+		sizes[i] = 1
+	}
+	open := make([]int, 0, k) // the chunks still below the limit
+	for rest := n - k; rest > 0; rest-- {
+		open = open[:0]
+		for i, s := range sizes {
+			if s < limit {
+				open = append(open, i)
+			}
+		}
+		sizes[open[mc.Intn(len(open))]]++
+	}
+	return sizes
+}
+
+// pick returns a quote from the pool other than prev, or the only one.
+func (p *parsedConfig) pick(mc modifiers.Context, prev rune) rune {
+	for {
+		if r := p.quotes[mc.Intn(len(p.quotes))]; r != prev || len(p.quotes) == 1 {
+			return r
+		}
+	}
+}
+
+// Lint implements modifiers.Linter: cmd.exe does not strip single quotes.
+func (q *QuoteSplitting) Lint(cfg json.RawMessage, _ string, p *models.Profile) []modifiers.Finding {
+	var c Config
+	if !strings.EqualFold(p.Platform, "windows") || json.Unmarshal(cfg, &c) != nil || !slices.Contains(c.Quotes, "'") {
+		return nil
+	}
+	return []modifiers.Finding{{Path: "Quotes", Hint: `use only "\""`,
+		Message: "cmd.exe passes ' through to the program, so its chunks keep their quotes"}}
+}
@@ -0,0 +1,188 @@
+package quotesplit
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(quotes []string, chunks, chunkSize string) json.RawMessage {
+	c := Config{
+		BaseModifierConfig: models.BaseModifierConfig{
+			AppliesTo:   []string{"value"},
+			Probability: "1.0",
+		},
+		Quotes:    quotes,
+		Chunks:    chunks,
+		ChunkSize: chunkSize,
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("cfg helper: " + err.Error())
+	}
+	return b
+}
+
+func split(t *testing.T, seed int64, value string, c json.RawMessage) string {
+	t.Helper()
+	tokens := []models.Token{{Type: models.TokenTypeValue, Value: value}}
+	out, err := (&QuoteSplitting{}).ApplyContext(modifiers.Context{Rand: rand.New(rand.NewSource(seed))}, tokens, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out[0].Value
+}
+
+// chunk is a piece of a split value and the quote around it, 0 for none.
+type chunk struct {
+	quote rune
+	text  string
+}
+
+// chunks parses s back into its chunks; it fails t on an unclosed quote.
+func chunks(t *testing.T, s string) []chunk {
+	t.Helper()
+	var out []chunk
+	for s != "" {
+		if q := rune(s[0]); q == '"' || q == '\'' {
+			end := strings.IndexRune(s[1:], q)
+			if end < 0 {
+				t.Fatalf("unclosed %c in %s", q, s)
+			}
+			out = append(out, chunk{q, s[1 : end+1]})
+			s = s[end+2:]
+			continue
+		}
+		end := strings.IndexAny(s, `"'`)
+		if end < 0 {
+			end = len(s)
+		}
+		out = append(out, chunk{0, s[:end]})
+		s = s[end:]
+	}
+	return out
+}
+
+func joined(cs []chunk) string {
+	var b strings.Builder
+	for _, c := range cs {
+		b.WriteString(c.text)
+	}
+	return b.String()
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&QuoteSplitting{}).Name(); name != "QuoteSplitting" {
+		t.Errorf("Name() = %q, want %q", name, "QuoteSplitting")
+	}
+	if _, ok := modifiers.Get("QuoteSplitting"); !ok {
+		t.Error("QuoteSplitting is not registered")
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  json.RawMessage
+	}{
+		{"backtick quote", cfg([]string{"`"}, "", "")},
+		{"two quotes in one entry", cfg([]string{`""`}, "", "")},
+		{"zero chunks", cfg(nil, "0", "")},
+		{"non-numeric chunks", cfg(nil, "many", "")},
+		{"negative chunk size", cfg(nil, "", "-1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (&QuoteSplitting{}).ParseConfig(nil, tt.cfg); err == nil {
+				t.Error("ParseConfig should return an error")
+			}
+		})
+	}
+}
+
+// ─── splitting ────────────────────────────────────────────────────────────────
+
+func TestApply_Chunks(t *testing.T) {
+	const url = "https://evil.com"
+	for seed := range int64(50) {
+		got := chunks(t, split(t, seed, url, cfg([]string{`"`, `'`}, "3", "")))
+		if len(got) != 3 || joined(got) != url {
+			t.Fatalf("seed %d: got %v, want %s in 3 chunks", seed, got, url)
+		}
+		for i, c := range got {
+			if c.quote == 0 || c.text == "" {
+				t.Errorf("seed %d: chunk %d is %v, want quoted and non-empty", seed, i, c)
+			}
+			if i > 0 && got[i-1].quote == c.quote {
+				t.Errorf("seed %d: chunks %d and %d share %c", seed, i-1, i, c.quote)
+			}
+		}
+	}
+}
+
+func TestApply_ChunkSize(t *testing.T) {
+	const url = "https://evil.com" // 16 characters
+	for seed := range int64(50) {
+		got := chunks(t, split(t, seed, url, cfg([]string{`"`, `'`}, "2", "3")))
+		if len(got) != 6 || joined(got) != url {
+			t.Fatalf("seed %d: got %v, want %s in 6 chunks", seed, got, url)
+		}
+		for i, c := range got {
+			if len(c.text) > 3 {
+				t.Errorf("seed %d: chunk %d is %q, longer than 3", seed, i, c.text)
+			}
+		}
+	}
+}
+
+func TestApply_OneQuoteAlternatesWithBare(t *testing.T) {
+	const url = "https://evil.com"
+	for seed := range int64(50) {
+		got := chunks(t, split(t, seed, url, cfg(nil, "4", "")))
+		if len(got) != 4 || joined(got) != url {
+			t.Fatalf("seed %d: got %v, want %s in 4 chunks", seed, got, url)
+		}
+		for i, c := range got {
+			if c.quote == '\'' {
+				t.Errorf("seed %d: chunk %d is single-quoted, want only %c", seed, i, '"')
+			}
+			if i > 0 && (got[i-1].quote == 0) == (c.quote == 0) {
+				t.Errorf("seed %d: chunks %d and %d are both quoted or both bare: %v", seed, i-1, i, got)
+			}
+		}
+	}
+}
+
+func TestApply_LeavesAlone(t *testing.T) {
+	for _, value := range []string{
+		"a",
+		`say "hi"`,
+		"it's",
+		"$HOME/x",
+		"%TEMP%",
+		`C:\Temp`,
+		"`whoami`",
+		"hi!",
+		"hello big world",
+		"tab\tstop",
+	} {
+		if got := split(t, 1, value, cfg([]string{`"`, `'`}, "2", "")); got != value {
+			t.Errorf("%s became %s, want it left alone", value, got)
+		}
+	}
+}
+
+func TestApply_ShortValue(t *testing.T) {
+	got := chunks(t, split(t, 1, "ab", cfg([]string{`"`, `'`}, "5", "")))
+	if len(got) != 2 || joined(got) != "ab" {
+		t.Errorf("got %v, want ab in 2 chunks", got)
+	}
+}
@@ -10,9 +10,6 @@ package randomcase
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
-	"slices"
-	"strconv"
 	"unicode"
 
 	"cmdFuscator/engine/modifiers"
@@ -41,10 +38,10 @@ type Config struct {
 //
 // Steps:
 //  1. Unmarshal cfg into a Config struct.
-//  2. Parse Config.Probability with strconv.ParseFloat.
+//  2. Parse Config.Probability with modifiers.ParseProbability.
 //  3. For each token whose Type is in Config.AppliesTo:
 //     a. Iterate over each rune in token.Value.
-//     b. Call rand.Float64(); if < probability, flip the rune's case
+//     b. Call c.Float64(); if < probability, flip the rune's case
 //     (use unicode.ToUpper / unicode.ToLower as appropriate).
 //     c. Rebuild token.Value from the modified runes.
 //  4. Return the updated token slice.
@@ -52,37 +49,55 @@ type Config struct {
 // Hint: unicode.IsUpper(r) / unicode.IsLower(r) tell you the current case.
 // Hint: use a strings.Builder or []rune for efficient string construction.
 func (r *RandomCase) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
-	out := make([]models.Token, len(tokens)) // the eventual return value
-	copy(out, tokens)                        // make a copy of the input tokens for no op situations
+	return r.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from c.
+func (r *RandomCase) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := r.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return r.ApplyParsed(c, tokens, parsed)
+}
 
+// parsedConfig is a Config with its probability parsed.
+type parsedConfig struct {
+	Config
+	probability float64
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (r *RandomCase) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
 	cfgM := &Config{}
 	if err := json.Unmarshal(cfg, cfgM); err != nil {
-		return tokens, fmt.Errorf("unmarshal config: %w", err)
+		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
 
-	probability, err := strconv.ParseFloat(cfgM.Probability, 64)
+	probability, err := modifiers.ParseProbability(string(cfgM.Probability))
 	if err != nil {
-		return tokens, fmt.Errorf("parse probability: %w", err)
-	} else if probability < 0 || probability > 1 {
-		return tokens, fmt.Errorf("probability must be between 0 and 1")
+		return nil, err
 	}
+	return &parsedConfig{Config: *cfgM, probability: probability}, nil
+}
 
-	for idx := range tokens {
-		if !slices.Contains(cfgM.AppliesTo, string(tokens[idx].Type)) {
-			continue // only apply to tokens of the specified types from config
-		}
-		runes := []rune(tokens[idx].Value)
+// ApplyParsed implements modifiers.ConfigParser. Probability is rolled per
+// character, so the token-level roll of modifiers.ForEachEligible does not
+// apply here.
+func (r *RandomCase) ApplyParsed(c modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	return modifiers.ForEachApplicable(tokens, cfgM.BaseModifierConfig, func(_ int, t models.Token) models.Token {
+		runes := []rune(t.Value)
 		for charIdx, r := range runes {
-			if rand.Float64() < probability { // flip this character's case with given probability
+			if c.Float64() < cfgM.probability { // flip this character's case with given probability
 				if unicode.IsUpper(r) {
-					runes[charIdx] = unicode.ToLower(runes[charIdx])
+					runes[charIdx] = unicode.ToLower(r)
 				} else {
-					runes[charIdx] = unicode.ToUpper(runes[charIdx])
+					runes[charIdx] = unicode.ToUpper(r)
 				}
 			}
 		}
-		out[idx].Value = string(runes) // rebuild the token with the modified runes as a string
-	}
-
-	return out, nil
+		t.Value = string(runes) // rebuild the token with the modified runes as a string
+		return t
+	}), nil
 }
@@ -16,7 +16,7 @@ func cfg(appliesTo []string, probability string) json.RawMessage {
 	c := Config{
 		BaseModifierConfig: models.BaseModifierConfig{
 			AppliesTo:   appliesTo,
-			Probability: probability,
+			Probability: models.ProbabilityValue(probability),
 		},
 	}
 	b, err := json.Marshal(c)
@@ -68,10 +68,20 @@ func TestApply_InvalidProbability(t *testing.T) {
 	}
 }
 
+func TestApply_ProbabilityOutOfRange(t *testing.T) {
+	m := &RandomCase{}
+	for _, p := range []string{"-0.5", "1.5"} {
+		_, err := m.Apply([]models.Token{tok(models.TokenTypeArgument, "-urlcache")}, cfg([]string{"argument"}, p))
+		if err == nil {
+			t.Errorf("Apply with probability %s should return an error", p)
+		}
+	}
+}
+
 // ─── probability = 0.0 (never fires) ─────────────────────────────────────────
 
-// probability "0.0" is a valid config meaning "never modify". The validation
-// guard uses <= 0 which incorrectly rejects it — this test will expose that.
+// probability "0.0" is a valid config meaning "never modify"; a guard written
+// as <= 0 would wrongly reject it.
 func TestApply_ProbabilityZero_NeverModifies(t *testing.T) {
 	m := &RandomCase{}
 	input := []models.Token{
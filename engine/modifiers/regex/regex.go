@@ -45,14 +45,23 @@ type Config struct {
 // Steps:
 //  1. Unmarshal cfg into a Config struct.
 //  2. Parse Probability.
-//  3. Compile each Rule.Pattern with regexp.MustCompile (or Compile + handle error).
+//  3. Compile each Rule.Pattern with Artifacts.Regexp, which returns an error
+//     rather than panicking and compiles each pattern once per engine.
 //  4. For each eligible token:
 //     a. Roll probability; skip if not triggered.
 //     b. Apply each compiled regex in order using regexp.Regexp.ReplaceAllString.
 //  5. Return updated tokens.
 //
+// Hint: implement modifiers.ConfigParser, doing steps 1–3 in ParseConfig with
+// the *modifiers.Artifacts it is given, so the engine compiles nothing per run.
+//
 // Note: since the Regex modifier config schema is partially inferred, you may
 // need to adjust the Config struct after inspecting real profile files that use it.
 func (r *Regex) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return r.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from c.
+func (r *Regex) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
 	return tokens, modifiers.ErrNotImplemented
 }
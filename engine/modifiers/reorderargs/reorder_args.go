@@ -36,18 +36,23 @@ type Config struct {
 //
 // Steps:
 //  1. Unmarshal cfg into a Config struct.
-//  2. Parse Probability; if rand.Float64() >= probability, return unchanged.
+//  2. Parse Probability; if c.Float64() >= probability, return unchanged.
 //  3. Separate the command token (index 0) from the argument tokens.
-//  4. Group argument tokens into (flag, value…) pairs using the ValueCount
-//     information from the profile's ArgumentDefinitions.
-//     (You may need to pass ArgumentDefinitions through the engine; consider
-//     adding them to the Config or using a wrapper struct.)
-//  5. Shuffle the pairs with rand.Shuffle.
-//  6. Flatten back to a token slice: [command] + [shuffled pairs…].
+//  4. Group argument tokens into (flag, value…) pairs with
+//     models.TokenStream.PairsWithValues, which uses the ValueCount
+//     information from the profile's ArgumentDefinitions, which the engine
+//     hands over in c.Arguments.
+//  5. Shuffle the pairs with c.Shuffle.
+//  6. Flatten back to a token slice with TokenStream.Flatten.
 //  7. Return updated tokens.
 //
 // Edge case: tokens that are not recognised flags should be treated as
 // standalone argument groups (no associated value tokens).
 func (r *ReorderArgs) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return r.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from c.
+func (r *ReorderArgs) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
 	return tokens, modifiers.ErrNotImplemented
 }
@@ -0,0 +1,275 @@
+// Package script implements the Script modifier, which runs a short Tengo
+// script (https://github.com/d5/tengo) from the profile config over the
+// token list. Profile authors use it for one-off, executable-specific tricks
+// that do not deserve a modifier of their own.
+//
+// The script sees these variables:
+//
+//	tokens       array of {type, value, eligible, start, end} maps; eligible
+//	             is true for tokens whose type is in AppliesTo
+//	probability  the config's Probability, as a float
+//	random()     a float in [0, 1) from the run's random source
+//	randint(n)   an int in [0, n) from the same source
+//	roll()       true with probability `probability`
+//
+// and leaves its result in tokens, either by editing the maps in place or by
+// assigning a new array. A token needs a type and a value; start and end may
+// be dropped from inserted ones. Only the text, math and enum standard
+// modules can be imported, and a run is stopped after RunTimeout.
+//
+// Not part of ArgFuscator.
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/d5/tengo/v2"
+	"github.com/d5/tengo/v2/stdlib"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&Script{})
+}
+
+// Script runs a profile-supplied Tengo script over the tokens.
+type Script struct{}
+
+func (s *Script) Name() string        { return "Script" }
+func (s *Script) Description() string { return "Run the profile's own Tengo script over the tokens" }
+
+// InsertsTokens implements modifiers.Inserter: a script may add tokens.
+func (s *Script) InsertsTokens() bool { return true }
+
+// Config holds the Script-specific config fields.
+type Config struct {
+	models.BaseModifierConfig
+	// Script is the Tengo source, as one string or as an array of lines,
+	// which reads better in JSON.
+	Script Source `json:"Script"`
+}
+
+// Source is script source that unmarshals from a string or an array of
+// lines.
+type Source string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Source) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = Source(strings.Join(lines, "\n"))
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return errors.New("Script must be a string or an array of lines")
+	}
+	*s = Source(str)
+	return nil
+}
+
+// RunTimeout bounds one run of a script.
+const RunTimeout = time.Second
+
+// maxAllocs bounds the objects one run of a script may allocate.
+const maxAllocs = 1 << 20
+
+// Apply implements modifiers.Modifier.
+func (s *Script) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return s.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from mc.
+func (s *Script) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := s.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return s.ApplyParsed(mc, tokens, parsed)
+}
+
+// parsedConfig is a Config with its probability parsed and its script
+// compiled.
+type parsedConfig struct {
+	base        models.BaseModifierConfig
+	probability float64
+	compiled    *tengo.Compiled
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (s *Script) ParseConfig(a *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	probability, err := modifiers.ParseProbability(string(cfgM.Probability))
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := modifiers.Artifact(a, "Script", string(cfgM.Script), compile)
+	if err != nil {
+		return nil, err
+	}
+	return &parsedConfig{base: cfgM.BaseModifierConfig, probability: probability, compiled: compiled}, nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+func (s *Script) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	probability := cfgM.probability
+	run := cfgM.compiled.Clone()
+	in := make([]any, len(tokens))
+	for i, t := range tokens {
+		in[i] = map[string]any{
+			"type":     string(t.Type),
+			"value":    t.Value,
+			"eligible": modifiers.Applies(cfgM.base, t),
+			"start":    t.Start,
+			"end":      t.End,
+		}
+	}
+	vars := map[string]any{
+		"tokens":      in,
+		"probability": probability,
+		"random":      &tengo.UserFunction{Name: "random", Value: stdlib.FuncARF(mc.Float64)},
+		"randint":     &tengo.UserFunction{Name: "randint", Value: randint(mc)},
+		"roll":        &tengo.UserFunction{Name: "roll", Value: stdlib.FuncARB(func() bool { return mc.Float64() < probability })},
+	}
+	for name, v := range vars {
+		if err := run.Set(name, v); err != nil {
+			return tokens, fmt.Errorf("script: %s: %w", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), RunTimeout)
+	defer cancel()
+	if err := run.RunContext(ctx); err != nil {
+		return tokens, fmt.Errorf("script: %w", err)
+	}
+	out, err := fromScript(run.Get("tokens").Value())
+	if err != nil {
+		return tokens, fmt.Errorf("script: %w", err)
+	}
+	if slices.Equal(out, tokens) {
+		return tokens, nil
+	}
+	return out, nil
+}
+
+// randint returns the script's randint(n) function.
+func randint(mc modifiers.Context) tengo.CallableFunc {
+	return func(args ...tengo.Object) (tengo.Object, error) {
+		if len(args) != 1 {
+			return nil, tengo.ErrWrongNumArguments
+		}
+		n, ok := tengo.ToInt(args[0])
+		if !ok {
+			return nil, tengo.ErrInvalidArgumentType{Name: "n", Expected: "int", Found: args[0].TypeName()}
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("randint: n must be positive, got %d", n)
+		}
+		return &tengo.Int{Value: int64(mc.Intn(n))}, nil
+	}
+}
+
+// fromScript converts the script's tokens variable back into tokens.
+func fromScript(v any) ([]models.Token, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("tokens is %s, want an array", typeName(v))
+	}
+	out := make([]models.Token, len(list))
+	for i, el := range list {
+		m, ok := el.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("tokens[%d] is %s, want a map", i, typeName(el))
+		}
+		typ, _ := m["type"].(string)
+		if !slices.Contains(tokenTypes, models.TokenType(typ)) {
+			return nil, fmt.Errorf("tokens[%d]: unknown type %q", i, typ)
+		}
+		value, ok := m["value"].(string)
+		if !ok {
+			return nil, fmt.Errorf("tokens[%d]: value is %s, want a string", i, typeName(m["value"]))
+		}
+		t := models.Token{Type: models.TokenType(typ), Value: value}
+		start, okStart := m["start"].(int64)
+		end, okEnd := m["end"].(int64)
+		if okStart && okEnd {
+			t.Start, t.End = int(start), int(end)
+		}
+		out[i] = t
+	}
+	return out, nil
+}
+
+func typeName(v any) string {
+	if v == nil {
+		return "undefined"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// tokenTypes are the types a script may give a token.
+var tokenTypes = []models.TokenType{
+	models.TokenTypeCommand,
+	models.TokenTypeArgument,
+	models.TokenTypeValue,
+	models.TokenTypePath,
+	models.TokenTypeURL,
+	models.TokenTypeEnvVar,
+	models.TokenTypeExpansion,
+}
+
+// ─── Compiling ────────────────────────────────────────────────────────────────
+
+// Compile reports whether src compiles as a Script modifier script, for
+// linters that want to catch errors before a run does.
+func Compile(src string) error {
+	_, err := compile(src)
+	return err
+}
+
+// compile compiles src, cached by ParseConfig as a "Script" artifact since a
+// profile's script runs once per obfuscation. Runs use clones, so a
+// compiled script is never mutated.
+func compile(src string) (*tengo.Compiled, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, errors.New("script: no Script in config")
+	}
+	s := tengo.NewScript([]byte(src))
+	s.SetImports(stdlib.GetModuleMap("text", "math", "enum"))
+	s.SetMaxAllocs(maxAllocs)
+	for _, name := range []string{"tokens", "probability", "random", "randint", "roll"} {
+		if err := s.Add(name, nil); err != nil {
+			return nil, fmt.Errorf("script: %w", err)
+		}
+	}
+	c, err := s.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("script: compile: %w", err)
+	}
+	return c, nil
+}
+
+// Lint implements modifiers.Linter: Script must compile.
+func (s *Script) Lint(cfg json.RawMessage, _ string, _ *models.Profile) []modifiers.Finding {
+	var c Config
+	err := json.Unmarshal(cfg, &c)
+	if err == nil {
+		err = Compile(string(c.Script))
+	}
+	if err != nil {
+		return []modifiers.Finding{{Path: "Script", Error: true, Message: err.Error()}}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package script
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(probability string, lines ...string) json.RawMessage {
+	c := Config{
+		BaseModifierConfig: models.BaseModifierConfig{
+			AppliesTo:   []string{"argument"},
+			Probability: models.ProbabilityValue(probability),
+		},
+		Script: Source(strings.Join(lines, "\n")),
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("cfg helper: " + err.Error())
+	}
+	return b
+}
+
+func input() []models.Token {
+	return []models.Token{
+		{Type: models.TokenTypeCommand, Value: "certutil", Start: 0, End: 8},
+		{Type: models.TokenTypeArgument, Value: "-urlcache", Start: 9, End: 18},
+		{Type: models.TokenTypeURL, Value: "https://x", Start: 19, End: 28},
+	}
+}
+
+func seeded() modifiers.Context {
+	return modifiers.Context{Rand: rand.New(rand.NewSource(1))}
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&Script{}).Name(); name != "Script" {
+		t.Errorf("Name() = %q, want %q", name, "Script")
+	}
+	if _, ok := modifiers.Get("Script"); !ok {
+		t.Error("Script is not registered")
+	}
+}
+
+// ─── scripts ──────────────────────────────────────────────────────────────────
+
+func TestApply_EditInPlace(t *testing.T) {
+	c := cfg("1.0",
+		`text := import("text")`,
+		`for t in tokens {`,
+		`	if t.eligible && roll() { t.value = text.to_upper(t.value) }`,
+		`}`,
+	)
+	in := input()
+	out, err := (&Script{}).ApplyContext(seeded(), in, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := input()
+	want[1].Value = "-URLCACHE"
+	if !slices.Equal(out, want) {
+		t.Errorf("Apply = %+v, want %+v", out, want)
+	}
+	if in[1].Value != "-urlcache" {
+		t.Error("Apply modified its input")
+	}
+}
+
+func TestApply_NewArray(t *testing.T) {
+	// The script inserts a token; it has no source offsets.
+	c := cfg("1.0",
+		`out := []`,
+		`for t in tokens {`,
+		`	out = append(out, t)`,
+		`	if t.type == "command" { out = append(out, {type: "argument", value: "-f"}) }`,
+		`}`,
+		`tokens = out`,
+	)
+	out, err := (&Script{}).Apply(input(), c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []models.Token{input()[0], {Type: models.TokenTypeArgument, Value: "-f"}, input()[1], input()[2]}
+	if !slices.Equal(out, want) {
+		t.Errorf("Apply = %+v, want %+v", out, want)
+	}
+}
+
+func TestApply_LinesAndRandomness(t *testing.T) {
+	raw := json.RawMessage(`{"AppliesTo":["argument"],"Probability":"0.5","Script":[
+		"for t in tokens { t.value = t.value + string(randint(10)) + (random() < 1 ? \"\" : \"!\") }"
+	]}`)
+	first, err := (&Script{}).ApplyContext(seeded(), input(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := (&Script{}).ApplyContext(seeded(), input(), raw)
+	if !slices.Equal(first, second) {
+		t.Errorf("the same seed gave %+v, then %+v", first, second)
+	}
+	if first[0].Value == "certutil" || strings.Contains(first[0].Value, "!") {
+		t.Errorf("tokens[0] = %q, want a digit appended", first[0].Value)
+	}
+}
+
+func TestApply_Unchanged(t *testing.T) {
+	in := input()
+	out, err := (&Script{}).Apply(in, cfg("1.0", `x := len(tokens)`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if &out[0] != &in[0] {
+		t.Error("a script that changes nothing should return its input as is")
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  json.RawMessage
+		want string
+	}{
+		{"invalid JSON", json.RawMessage(`not json`), "unmarshal config"},
+		{"bad Script type", json.RawMessage(`{"Probability":"1.0","Script":3}`), "string or an array of lines"},
+		{"missing probability", cfg("", `x := 1`), "parse probability"},
+		{"no script", cfg("1.0"), "no Script"},
+		{"compile error", cfg("1.0", `tokens = [`), "compile"},
+		{"runtime error", cfg("1.0", `x := 1 / 0`), "divide by zero"},
+		{"tokens not an array", cfg("1.0", `tokens = "x"`), "want an array"},
+		{"token not a map", cfg("1.0", `tokens = [1]`), "tokens[0] is int64, want a map"},
+		{"unknown type", cfg("1.0", `tokens = [{type: "flag", value: "-f"}]`), `tokens[0]: unknown type "flag"`},
+		{"value not a string", cfg("1.0", `tokens = [{type: "argument"}]`), "tokens[0]: value is undefined"},
+		{"randint", cfg("1.0", `x := randint(0)`), "n must be positive"},
+		{"forbidden module", cfg("1.0", `os := import("os")`), "module 'os' not found"},
+		{"endless loop", cfg("1.0", `for {}`), "context deadline exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := input()
+			out, err := (&Script{}).Apply(in, tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want one containing %q", err, tt.want)
+			}
+			if !slices.Equal(out, in) {
+				t.Errorf("on error Apply returned %+v, want its input", out)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	if err := Compile(`tokens = tokens`); err != nil {
+		t.Errorf("Compile: %v", err)
+	}
+	if err := Compile(`tokens = (`); err == nil {
+		t.Error("Compile of a syntax error succeeded")
+	}
+}
+
+func TestParseConfig_Artifacts(t *testing.T) {
+	a := &modifiers.Artifacts{}
+	parse := func(a *modifiers.Artifacts) *parsedConfig {
+		t.Helper()
+		p, err := (&Script{}).ParseConfig(a, cfg("1.0", `tokens = tokens`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.(*parsedConfig)
+	}
+	if parse(a).compiled != parse(a).compiled {
+		t.Error("the same script compiled twice with one Artifacts")
+	}
+	if parse(nil).compiled == parse(nil).compiled {
+		t.Error("a nil Artifacts cached the compiled script")
+	}
+}
@@ -55,7 +55,16 @@ type Config struct {
 //     b. If triggered and the character has a substitution, apply it.
 //  6. Return updated tokens.
 //
+// Hint: implement modifiers.ConfigParser and build the map of steps 3–4 in
+// ParseConfig with modifiers.Artifact(a, "sed", SedStatements, parse), so
+// profiles sharing the same statements share one map.
+//
 // Example rule: "s/a/ᵃ/i" → replace 'a' or 'A' with 'ᵃ'
 func (s *Sed) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return s.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from c.
+func (s *Sed) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
 	return tokens, modifiers.ErrNotImplemented
 }
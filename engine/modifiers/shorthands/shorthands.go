@@ -37,9 +37,8 @@ type Config struct {
 // Steps:
 //  1. Unmarshal cfg into a Config struct.
 //  2. Parse Probability.
-//  3. Build an index of all known flags from the profile's Arguments list
-//     (you will need to pass the profile through or pre-process it; consider
-//     whether the profile should be injected via a constructor or method).
+//  3. Build an index of all known flags from the profile's Arguments list,
+//     which the engine hands over in c.Arguments.
 //  4. For each eligible argument token:
 //     a. Strip the leading option char (-, /, --).
 //     b. Find all known flags that start with the same prefix.
@@ -48,8 +47,13 @@ type Config struct {
 //        shortest unambiguous prefix (re-adding the original option char).
 //  5. Return updated tokens.
 //
-// Design note: you may need to refactor the Apply signature or store the
-// ArgumentDefinition list on the struct to make the profile data available here.
+// Design note: build the prefix trie of step 3 once per flag list with
+// modifiers.Artifact (kind "shorthands") rather than on every call.
 func (s *Shorthands) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return s.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from c.
+func (s *Shorthands) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
 	return tokens, modifiers.ErrNotImplemented
 }
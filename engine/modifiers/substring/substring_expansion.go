@@ -0,0 +1,221 @@
+// Package substring implements the SubstringExpansion obfuscation modifier.
+//
+// Technique: replace characters of a token with cmd.exe substring expansions
+// of environment variables every Windows machine has, which cmd.exe expands
+// before it runs the command:
+//
+// Example:  certutil  →  c%COMSPEC:~-3,1%rtut%ProgramFiles:~12,1%l
+//
+// This is how Daniel Bohannon's Dosfuscation builds command names out of
+// %ProgramFiles%, %COMSPEC% and the like. What those variables hold depends
+// on the machine, so the modifier does not guess: the profile's Table maps
+// each character to the variables and offsets that yield it on the target.
+// Negative offsets count from the end, as cmd.exe's do, which keeps them
+// right when only the leading directory differs.
+//
+// It only works where cmd.exe parses the command line. Tokens holding % or !
+// are left alone, since an inserted % could pair with one already there, and
+// so are characters escaped with ^.
+//
+// Not part of ArgFuscator.
+// Applies to token types: command, argument, value, path, url
+package substring
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&SubstringExpansion{})
+}
+
+// SubstringExpansion replaces characters with substrings of environment
+// variables.
+type SubstringExpansion struct{}
+
+func (s *SubstringExpansion) Name() string { return "SubstringExpansion" }
+func (s *SubstringExpansion) Description() string {
+	return "Replace characters with %VAR:~n,1% substrings of environment variables"
+}
+
+// Config holds SubstringExpansion-specific config fields.
+type Config struct {
+	models.BaseModifierConfig
+	// Table maps a character to the substrings that yield it, each written
+	// "VAR:OFFSET", e.g. {"e": ["COMSPEC:-1", "ProgramFiles:14"]}.
+	// Whitespace, quotes and cmd.exe metacharacters cannot be keys, since
+	// cmd.exe parses what an expansion yields.
+	Table map[string][]string `json:"Table"`
+	// Density is the chance, between 0 and 1, that each character in the
+	// table is replaced in a token the roll fires for. At least one always
+	// is. Empty means "0.3".
+	Density string `json:"Density,omitempty"`
+	// IgnoreCase lets a letter be replaced with either case's entries, for
+	// targets that do not tell them apart, such as Windows paths and most
+	// flags.
+	IgnoreCase bool `json:"IgnoreCase,omitempty"`
+}
+
+// reserved are the characters a Table key cannot be: cmd.exe reads them as
+// syntax even when an expansion yields them.
+const reserved = "\"&|<>^%!()"
+
+// Apply implements modifiers.Modifier.
+func (s *SubstringExpansion) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return s.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from mc.
+func (s *SubstringExpansion) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := s.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return s.ApplyParsed(mc, tokens, parsed)
+}
+
+// parsedConfig is a Config with its table and density parsed.
+type parsedConfig struct {
+	base    models.BaseModifierConfig
+	table   map[rune][]string // expansions, written out, by the character they yield
+	density float64
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (s *SubstringExpansion) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	if len(cfgM.Table) == 0 {
+		return nil, fmt.Errorf("table must not be empty")
+	}
+
+	p := &parsedConfig{
+		base:    cfgM.BaseModifierConfig,
+		table:   make(map[rune][]string, len(cfgM.Table)),
+		density: 0.3,
+	}
+	keys := make([]string, 0, len(cfgM.Table))
+	for k := range cfgM.Table {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys) // same errors and entry order every time
+	for _, k := range keys {
+		r, size := utf8.DecodeRuneInString(k)
+		if size == 0 || size != len(k) || unicode.IsSpace(r) || strings.ContainsRune(reserved, r) {
+			return nil, fmt.Errorf("table: %+q cannot be replaced, use a single character other than whitespace and %s", k, reserved)
+		}
+		yields := []rune{r}
+		if other := otherCase(r); cfgM.IgnoreCase && other != r {
+			yields = append(yields, other)
+		}
+		for i, e := range cfgM.Table[k] {
+			exp, err := expansion(e)
+			if err != nil {
+				return nil, fmt.Errorf("table[%+q][%d]: %w", k, i, err)
+			}
+			for _, y := range yields {
+				p.table[y] = append(p.table[y], exp)
+			}
+		}
+	}
+	if cfgM.Density != "" {
+		density, err := modifiers.ParseProbability(cfgM.Density)
+		if err != nil {
+			return nil, fmt.Errorf("density: %w", err)
+		}
+		p.density = density
+	}
+	return p, nil
+}
+
+// otherCase returns r in the other case, or r if it has none.
+func otherCase(r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToLower(r)
+	}
+	return unicode.ToUpper(r)
+}
+
+// expansion parses a Table entry, "VAR:OFFSET", into the cmd.exe expansion
+// it stands for.
+func expansion(entry string) (string, error) {
+	i := strings.LastIndexByte(entry, ':')
+	if i < 0 {
+		return "", fmt.Errorf("%q is not VAR:OFFSET", entry)
+	}
+	name, offset := entry[:i], entry[i+1:]
+	if name == "" || strings.ContainsAny(name, "%!:=~^\"") || strings.ContainsFunc(name, unicode.IsSpace) {
+		return "", fmt.Errorf("%q is not a variable name", name)
+	}
+	if _, err := strconv.Atoi(offset); err != nil {
+		return "", fmt.Errorf("%q in %q is not an offset", offset, entry)
+	}
+	return "%" + name + ":~" + offset + ",1%", nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+func (s *SubstringExpansion) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*parsedConfig)
+	return modifiers.ForEachEligible(tokens, cfgM.base, mc, func(_ int, t models.Token) models.Token {
+		if strings.ContainsAny(t.Value, "%!") {
+			return t
+		}
+		runes := []rune(t.Value)
+		var found []int
+		for i, r := range runes {
+			if (i == 0 || runes[i-1] != '^') && len(cfgM.table[r]) > 0 {
+				found = append(found, i)
+			}
+		}
+		if len(found) == 0 {
+			return t
+		}
+
+		replaced := make(map[int]bool)
+		for _, i := range found {
+			if mc.Float64() < cfgM.density {
+				replaced[i] = true
+			}
+		}
+		if len(replaced) == 0 {
+			replaced[found[mc.Intn(len(found))]] = true
+		}
+
+		var b strings.Builder
+		for i, r := range runes {
+			if !replaced[i] {
+				b.WriteRune(r)
+				continue
+			}
+			entries := cfgM.table[r]
+			b.WriteString(entries[mc.Intn(len(entries))])
+		}
+		t.Value = b.String()
+		return t
+	})
+}
+
+// Lint implements modifiers.Linter: the table must be one cmd.exe can use,
+// in a Windows profile.
+func (s *SubstringExpansion) Lint(cfg json.RawMessage, _ string, p *models.Profile) []modifiers.Finding {
+	var out []modifiers.Finding
+	if _, err := s.ParseConfig(nil, cfg); err != nil {
+		out = append(out, modifiers.Finding{Error: true, Message: err.Error()})
+	}
+	if !strings.EqualFold(p.Platform, "windows") {
+		out = append(out, modifiers.Finding{Hint: "remove it, or move it to a Windows profile",
+			Message: fmt.Sprintf("%q is not cmd.exe's platform, so the substrings are never expanded", p.Platform)})
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package substring
+
+import (
+	"encoding/json"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(table map[string][]string, density string, ignoreCase bool) json.RawMessage {
+	c := Config{
+		BaseModifierConfig: models.BaseModifierConfig{
+			AppliesTo:   []string{"command", "argument"},
+			Probability: "1.0",
+		},
+		Table:      table,
+		Density:    density,
+		IgnoreCase: ignoreCase,
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("cfg helper: " + err.Error())
+	}
+	return b
+}
+
+// env is what the variables in table hold on a stock Windows install.
+var env = map[string]string{
+	"ProgramFiles": `C:\Program Files`,
+	"COMSPEC":      `C:\Windows\system32\cmd.exe`,
+}
+
+var table = map[string][]string{
+	"e": {"COMSPEC:-1", "COMSPEC:-3", "ProgramFiles:14"},
+	"i": {"ProgramFiles:12"},
+	"l": {"ProgramFiles:13"},
+	"r": {"ProgramFiles:4"},
+	"c": {"COMSPEC:-7"},
+	"F": {"ProgramFiles:11"},
+}
+
+func expand(t *testing.T, seed int64, value string, c json.RawMessage) string {
+	t.Helper()
+	tokens := []models.Token{{Type: models.TokenTypeArgument, Value: value}}
+	out, err := (&SubstringExpansion{}).ApplyContext(modifiers.Context{Rand: rand.New(rand.NewSource(seed))}, tokens, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out[0].Value
+}
+
+var substringExpr = regexp.MustCompile(`%([^%:]+):~(-?\d+),1%`)
+
+// cmdExpand expands the substrings in s the way cmd.exe would with env.
+func cmdExpand(t *testing.T, s string) string {
+	t.Helper()
+	return substringExpr.ReplaceAllStringFunc(s, func(m string) string {
+		sub := substringExpr.FindStringSubmatch(m)
+		v, ok := env[sub[1]]
+		if !ok {
+			t.Fatalf("%s expands an unknown variable", m)
+		}
+		i, _ := strconv.Atoi(sub[2])
+		if i < 0 {
+			i += len(v)
+		}
+		return v[i : i+1]
+	})
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&SubstringExpansion{}).Name(); name != "SubstringExpansion" {
+		t.Errorf("Name() = %q, want %q", name, "SubstringExpansion")
+	}
+	if _, ok := modifiers.Get("SubstringExpansion"); !ok {
+		t.Error("SubstringExpansion is not registered")
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  json.RawMessage
+	}{
+		{"empty table", cfg(nil, "", false)},
+		{"reserved key", cfg(map[string][]string{"%": {"COMSPEC:-1"}}, "", false)},
+		{"whitespace key", cfg(map[string][]string{" ": {"ProgramFiles:10"}}, "", false)},
+		{"two characters", cfg(map[string][]string{"ex": {"COMSPEC:-2"}}, "", false)},
+		{"no offset", cfg(map[string][]string{"e": {"COMSPEC"}}, "", false)},
+		{"bad offset", cfg(map[string][]string{"e": {"COMSPEC:last"}}, "", false)},
+		{"no variable", cfg(map[string][]string{"e": {":-1"}}, "", false)},
+		{"percent in variable", cfg(map[string][]string{"e": {"%COMSPEC%:-1"}}, "", false)},
+		{"density above 1", cfg(table, "2", false)},
+		{"density not a number", cfg(table, "most", false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (&SubstringExpansion{}).ParseConfig(nil, tt.cfg); err == nil {
+				t.Error("ParseConfig should return an error")
+			}
+		})
+	}
+}
+
+// ─── expansion ────────────────────────────────────────────────────────────────
+
+func TestApply_RoundTrip(t *testing.T) {
+	for seed := range int64(50) {
+		got := expand(t, seed, "certutil", cfg(table, "0.5", false))
+		if !strings.Contains(got, ",1%") {
+			t.Fatalf("seed %d: got %q, want at least one substring", seed, got)
+		}
+		if back := cmdExpand(t, got); back != "certutil" {
+			t.Errorf("seed %d: %q expands to %q, want certutil", seed, got, back)
+		}
+	}
+}
+
+func TestApply_Density(t *testing.T) {
+	const value = "-urlcache"
+	// r, l, both c and the e are in the table.
+	got := expand(t, 1, value, cfg(table, "1", false))
+	if strings.Count(got, ",1%") != 5 || cmdExpand(t, got) != value {
+		t.Errorf("density 1: got %q, want every character in the table replaced", got)
+	}
+	for seed := range int64(20) {
+		if got := expand(t, seed, value, cfg(table, "0", false)); strings.Count(got, ",1%") != 1 {
+			t.Errorf("seed %d: density 0: got %q, want exactly one substring", seed, got)
+		}
+	}
+}
+
+func TestApply_IgnoreCase(t *testing.T) {
+	c := cfg(map[string][]string{"F": {"ProgramFiles:11"}}, "1", false)
+	if got := expand(t, 1, "/f", c); got != "/f" {
+		t.Errorf("case-sensitive: got %q, want /f unchanged", got)
+	}
+	c = cfg(map[string][]string{"F": {"ProgramFiles:11"}}, "1", true)
+	if got := expand(t, 1, "/f", c); got != "/%ProgramFiles:~11,1%" {
+		t.Errorf("ignoring case: got %q, want the f replaced", got)
+	}
+}
+
+func TestApply_LeavesAlone(t *testing.T) {
+	for _, value := range []string{
+		"%TEMP%\\file", // an existing expansion
+		"!cmdcmdline!", // delayed expansion
+		"^e",           // escaped
+		"--/42:0",      // nothing in the table
+	} {
+		if got := expand(t, 1, value, cfg(table, "1", false)); got != value {
+			t.Errorf("got %q, want %q unchanged", got, value)
+		}
+	}
+}
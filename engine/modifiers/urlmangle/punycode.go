@@ -0,0 +1,168 @@
+package urlmangle
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ─── Punycode (RFC 3492) ──────────────────────────────────────────────────────
+
+// acePrefix marks a host label written in Punycode.
+const acePrefix = "xn--"
+
+const (
+	base        = 36
+	tmin        = 1
+	tmax        = 26
+	skew        = 38
+	damp        = 700
+	initialBias = 72
+	initialN    = 128
+)
+
+var errPunycode = errors.New("invalid punycode")
+
+// adapt is the bias adaptation function of RFC 3492 section 6.1.
+func adapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= damp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((base-tmin)*tmax)/2 {
+		delta /= base - tmin
+		k += base
+	}
+	return k + (base-tmin+1)*delta/(delta+skew)
+}
+
+// threshold is t(k) of RFC 3492 section 6.2 and 6.3.
+func threshold(k, bias int) int {
+	switch {
+	case k <= bias:
+		return tmin
+	case k >= bias+tmax:
+		return tmax
+	}
+	return k - bias
+}
+
+func encodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func decodeDigit(c byte) (int, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c-'0') + 26, true
+	case 'a' <= c && c <= 'z':
+		return int(c - 'a'), true
+	case 'A' <= c && c <= 'Z':
+		return int(c - 'A'), true
+	}
+	return 0, false
+}
+
+// encode returns the Punycode form of label, without the ACE prefix.
+func encode(label string) string {
+	runes := []rune(label)
+	var out strings.Builder
+	for _, r := range runes {
+		if r < initialN {
+			out.WriteByte(byte(r))
+		}
+	}
+	basic := out.Len()
+	handled := basic
+	if basic > 0 {
+		out.WriteByte('-')
+	}
+
+	n, delta, bias := initialN, 0, initialBias
+	for handled < len(runes) {
+		m := int(utf8.MaxRune)
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		delta += (m - n) * (handled + 1)
+		n = m
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+				continue
+			}
+			if int(r) > n {
+				continue
+			}
+			q := delta
+			for k := base; ; k += base {
+				t := threshold(k, bias)
+				if q < t {
+					break
+				}
+				out.WriteByte(encodeDigit(t + (q-t)%(base-t)))
+				q = (q - t) / (base - t)
+			}
+			out.WriteByte(encodeDigit(q))
+			bias = adapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return out.String()
+}
+
+// decode returns the label whose Punycode form, without the ACE prefix, is s.
+func decode(s string) (string, error) {
+	var out []rune
+	rest := s
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		for j := 0; j < i; j++ {
+			if s[j] >= initialN {
+				return "", errPunycode
+			}
+			out = append(out, rune(s[j]))
+		}
+		rest = s[i+1:]
+	}
+
+	n, i, bias := initialN, 0, initialBias
+	for pos := 0; pos < len(rest); {
+		oldi, w := i, 1
+		for k := base; ; k += base {
+			if pos >= len(rest) {
+				return "", errPunycode
+			}
+			digit, ok := decodeDigit(rest[pos])
+			pos++
+			if !ok || digit > (utf8.MaxRune-i)/w {
+				return "", errPunycode
+			}
+			i += digit * w
+			t := threshold(k, bias)
+			if digit < t {
+				break
+			}
+			w *= base - t
+		}
+		bias = adapt(i-oldi, len(out)+1, oldi == 0)
+		n += i / (len(out) + 1)
+		i %= len(out) + 1
+		if n > utf8.MaxRune || !utf8.ValidRune(rune(n)) {
+			return "", errPunycode
+		}
+		out = append(out[:i], append([]rune{rune(n)}, out[i:]...)...)
+		i++
+	}
+	return string(out), nil
+}
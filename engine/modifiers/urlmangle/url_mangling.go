@@ -0,0 +1,230 @@
+// Package urlmangle implements the UrlMangling obfuscation modifier.
+//
+// Technique: rewrite the scheme and host of URL tokens into forms that
+// resolve to the same resource but no longer match the plain string:
+//   - MixedCaseScheme: randomize the case of the scheme (hTtPs://), which
+//     RFC 3986 makes case-insensitive
+//   - TrailingDot:     write the host as a fully qualified name with its root
+//     dot (example.com.)
+//   - Punycode:        switch an internationalized host, such as a lookalike
+//     with Cyrillic letters, between its Unicode and its xn-- form
+//
+// Each is enabled on its own; a token the roll fires for gets every enabled
+// one that applies to it. The rest of the URL is left as written.
+//
+// Not part of ArgFuscator; UrlTransformer covers IP encoding.
+// Applies to token types: url
+package urlmangle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+func init() {
+	modifiers.Register(&UrlMangling{})
+}
+
+// UrlMangling rewrites the scheme and host of URL tokens.
+type UrlMangling struct{}
+
+func (u *UrlMangling) Name() string { return "UrlMangling" }
+func (u *UrlMangling) Description() string {
+	return "Mix the scheme's case, add a trailing dot or switch IDN hosts to punycode"
+}
+
+// Config holds UrlMangling-specific config fields.
+type Config struct {
+	models.BaseModifierConfig
+	MixedCaseScheme bool `json:"MixedCaseScheme"`
+	TrailingDot     bool `json:"TrailingDot"`
+	Punycode        bool `json:"Punycode"`
+}
+
+// Apply implements modifiers.Modifier.
+func (u *UrlMangling) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return u.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from mc.
+func (u *UrlMangling) ApplyContext(mc modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	parsed, err := u.ParseConfig(nil, cfg)
+	if err != nil {
+		return tokens, err
+	}
+	return u.ApplyParsed(mc, tokens, parsed)
+}
+
+// ParseConfig implements modifiers.ConfigParser.
+func (u *UrlMangling) ParseConfig(_ *modifiers.Artifacts, cfg json.RawMessage) (any, error) {
+	cfgM := &Config{}
+	if err := json.Unmarshal(cfg, cfgM); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	if !cfgM.MixedCaseScheme && !cfgM.TrailingDot && !cfgM.Punycode {
+		return nil, fmt.Errorf("enable at least one of MixedCaseScheme, TrailingDot and Punycode")
+	}
+	return cfgM, nil
+}
+
+// ApplyParsed implements modifiers.ConfigParser.
+func (u *UrlMangling) ApplyParsed(mc modifiers.Context, tokens []models.Token, parsed any) ([]models.Token, error) {
+	cfgM := parsed.(*Config)
+	return modifiers.ForEachEligible(tokens, cfgM.BaseModifierConfig, mc, func(_ int, t models.Token) models.Token {
+		p, ok := split(t.Value)
+		if !ok {
+			return t
+		}
+		if cfgM.MixedCaseScheme {
+			p.scheme = mixCase(mc, p.scheme)
+		}
+		if cfgM.Punycode {
+			p.host = switchIDN(p.host)
+		}
+		if cfgM.TrailingDot && isDomain(p.host) && !strings.HasSuffix(p.host, ".") {
+			p.host += "."
+		}
+		t.Value = p.String()
+		return t
+	})
+}
+
+// ─── URL parts ────────────────────────────────────────────────────────────────
+
+// parts is a URL cut around its scheme and host, keeping everything else as
+// written: net/url would re-escape a Unicode host and normalize the rest.
+type parts struct {
+	scheme   string // without "://"
+	userinfo string // with its "@", if any
+	host     string // without brackets or port
+	rest     string // the port, path, query and fragment
+}
+
+func (p parts) String() string {
+	return p.scheme + "://" + p.userinfo + p.host + p.rest
+}
+
+// split cuts s into its parts. It reports false for anything but an
+// absolute URL with a host, such as a bare domain or a file path.
+func split(s string) (parts, bool) {
+	scheme, after, ok := strings.Cut(s, "://")
+	if !ok || !validScheme(scheme) {
+		return parts{}, false
+	}
+	end := strings.IndexAny(after, "/?#")
+	if end < 0 {
+		end = len(after)
+	}
+	authority, rest := after[:end], after[end:]
+
+	p := parts{scheme: scheme, rest: rest}
+	if i := strings.LastIndexByte(authority, '@'); i >= 0 {
+		p.userinfo, authority = authority[:i+1], authority[i+1:]
+	}
+	if strings.HasPrefix(authority, "[") {
+		return parts{}, false // an IPv6 literal has nothing to mangle
+	}
+	if i := strings.LastIndexByte(authority, ':'); i >= 0 {
+		authority, p.rest = authority[:i], authority[i:]+p.rest
+	}
+	if authority == "" {
+		return parts{}, false
+	}
+	p.host = authority
+	return p, true
+}
+
+// validScheme reports whether s is a scheme as RFC 3986 section 3.1 spells
+// one: a letter followed by letters, digits, "+", "-" and ".".
+func validScheme(s string) bool {
+	for i, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && ('0' <= r && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isDomain reports whether host is a domain name rather than an IP address,
+// which a trailing dot would break. Hosts of digits and dots, or starting
+// with 0x, are taken as IPs in one of the forms UrlTransformer writes.
+func isDomain(host string) bool {
+	if _, err := netip.ParseAddr(strings.TrimSuffix(host, ".")); err == nil {
+		return false
+	}
+	return strings.ContainsFunc(host, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }) &&
+		!strings.HasPrefix(strings.ToLower(host), "0x")
+}
+
+// ─── Tricks ───────────────────────────────────────────────────────────────────
+
+// mixCase flips the case of each letter of scheme with even odds, and of one
+// letter more if none flipped, so the result always differs.
+func mixCase(mc modifiers.Context, scheme string) string {
+	runes := []rune(scheme)
+	var letters []int
+	changed := false
+	for i, r := range runes {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters = append(letters, i)
+		if mc.Float64() < 0.5 {
+			runes[i] = flip(r)
+			changed = true
+		}
+	}
+	if !changed && len(letters) > 0 {
+		i := letters[mc.Intn(len(letters))]
+		runes[i] = flip(runes[i])
+	}
+	return string(runes)
+}
+
+func flip(r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToLower(r)
+	}
+	return unicode.ToUpper(r)
+}
+
+// switchIDN writes a host with Unicode labels in its xn-- form, and one with
+// xn-- labels only in Unicode. Other hosts, and labels that do not decode,
+// are returned as they are.
+func switchIDN(host string) string {
+	labels := strings.Split(host, ".")
+	toACE := slices.ContainsFunc(labels, nonASCII)
+	for i, l := range labels {
+		switch {
+		case toACE && nonASCII(l):
+			labels[i] = acePrefix + encode(norm.NFC.String(strings.ToLower(l)))
+		case !toACE && isACE(l):
+			if d, err := decode(strings.ToLower(l[len(acePrefix):])); err == nil && d != "" {
+				labels[i] = d
+			}
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// nonASCII reports whether label has a character outside ASCII.
+func nonASCII(label string) bool {
+	return strings.ContainsFunc(label, func(r rune) bool { return r >= initialN })
+}
+
+// isACE reports whether label is written in Punycode.
+func isACE(label string) bool {
+	return len(label) > len(acePrefix) && strings.EqualFold(label[:len(acePrefix)], acePrefix)
+}
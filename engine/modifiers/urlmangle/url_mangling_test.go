@@ -0,0 +1,140 @@
+package urlmangle
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func cfg(mixedCase, trailingDot, punycode bool) json.RawMessage {
+	c := Config{
+		BaseModifierConfig: models.BaseModifierConfig{
+			AppliesTo:   []string{"url"},
+			Probability: "1.0",
+		},
+		MixedCaseScheme: mixedCase,
+		TrailingDot:     trailingDot,
+		Punycode:        punycode,
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic("cfg helper: " + err.Error())
+	}
+	return b
+}
+
+func seeded() modifiers.Context {
+	return modifiers.Context{Rand: rand.New(rand.NewSource(1))}
+}
+
+func mangle(t *testing.T, url string, c json.RawMessage) string {
+	t.Helper()
+	tokens := []models.Token{{Type: models.TokenTypeURL, Value: url}}
+	out, err := (&UrlMangling{}).ApplyContext(seeded(), tokens, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out[0].Value
+}
+
+// ─── modifier interface ───────────────────────────────────────────────────────
+
+func TestName(t *testing.T) {
+	if name := (&UrlMangling{}).Name(); name != "UrlMangling" {
+		t.Errorf("Name() = %q, want %q", name, "UrlMangling")
+	}
+	if _, ok := modifiers.Get("UrlMangling"); !ok {
+		t.Error("UrlMangling is not registered")
+	}
+}
+
+func TestParseConfig_NothingEnabled(t *testing.T) {
+	if _, err := (&UrlMangling{}).ParseConfig(nil, cfg(false, false, false)); err == nil {
+		t.Error("ParseConfig with every trick off should return an error")
+	}
+}
+
+// ─── tricks ───────────────────────────────────────────────────────────────────
+
+func TestApply_MixedCaseScheme(t *testing.T) {
+	for _, url := range []string{"https://example.com/a", "http://x", "ftp://host:21/f"} {
+		got := mangle(t, url, cfg(true, false, false))
+		scheme, rest, _ := strings.Cut(url, "://")
+		gotScheme, gotRest, _ := strings.Cut(got, "://")
+		if gotScheme == scheme || !strings.EqualFold(gotScheme, scheme) || gotRest != rest {
+			t.Errorf("%s became %s, want only the scheme's case changed", url, got)
+		}
+	}
+}
+
+func TestApply_TrailingDot(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://example.com/a?b=1", "https://example.com./a?b=1"},
+		{"https://example.com", "https://example.com."},
+		{"https://user:pw@example.com:8443/x", "https://user:pw@example.com.:8443/x"},
+		{"https://example.com./a", "https://example.com./a"},
+		{"https://localhost#top", "https://localhost.#top"},
+		{"http://127.0.0.1/a", "http://127.0.0.1/a"},
+		{"http://2130706433/a", "http://2130706433/a"},
+		{"http://0x7f000001/a", "http://0x7f000001/a"},
+		{"http://[::1]:80/a", "http://[::1]:80/a"},
+		{"example.com/a", "example.com/a"},
+		{`C:\Temp\a.exe`, `C:\Temp\a.exe`},
+		{"file:///etc/passwd", "file:///etc/passwd"},
+	}
+	for _, tt := range tests {
+		if got := mangle(t, tt.in, cfg(false, true, false)); got != tt.want {
+			t.Errorf("%s became %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApply_Punycode(t *testing.T) {
+	tests := []struct{ in, want string }{
+		// "аpple.com" with CYRILLIC SMALL LETTER A.
+		{"https://\u0430pple.com/login", "https://xn--pple-43d.com/login"},
+		{"https://xn--pple-43d.com/login", "https://\u0430pple.com/login"},
+		{"https://B\u00fccher.example:8080/", "https://xn--bcher-kva.example:8080/"},
+		{"http://XN--MNCHEN-3YA.de", "http://m\u00fcnchen.de"},
+		{"https://example.com/\u00fc", "https://example.com/\u00fc"},
+		{"https://xn--!!.com/", "https://xn--!!.com/"},
+	}
+	for _, tt := range tests {
+		if got := mangle(t, tt.in, cfg(false, false, true)); got != tt.want {
+			t.Errorf("%s became %+q, want %+q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApply_AllTricks(t *testing.T) {
+	got := mangle(t, "https://\u043f\u0440\u0438\u043c\u0435\u0440.com/a", cfg(true, true, true))
+	scheme, rest, _ := strings.Cut(got, "://")
+	if !strings.EqualFold(scheme, "https") || scheme == "https" || rest != "xn--e1afmkfd.com./a" {
+		t.Errorf("got %s, want a mixed-case https://xn--e1afmkfd.com./a", got)
+	}
+}
+
+// ─── punycode ─────────────────────────────────────────────────────────────────
+
+func TestPunycodeRoundTrip(t *testing.T) {
+	tests := []struct{ label, ace string }{
+		{"b\u00fccher", "bcher-kva"},
+		{"\u043f\u0440\u0438\u043c\u0435\u0440", "e1afmkfd"},
+		{"m\u00fcnchen", "mnchen-3ya"},
+		{"\u4f8b\u3048", "r8jz45g"},
+	}
+	for _, tt := range tests {
+		if got := encode(tt.label); got != tt.ace {
+			t.Errorf("encode(%+q) = %q, want %q", tt.label, got, tt.ace)
+		}
+		if got, err := decode(tt.ace); err != nil || got != tt.label {
+			t.Errorf("decode(%q) = %+q, %v, want %+q", tt.ace, got, err, tt.label)
+		}
+	}
+}
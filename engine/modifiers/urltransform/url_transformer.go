@@ -53,5 +53,10 @@ type Config struct {
 //     e. Reconstruct the URL string and update the token.
 //  4. Return updated tokens.
 func (u *UrlTransformer) Apply(tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
+	return u.ApplyContext(modifiers.Context{}, tokens, cfg)
+}
+
+// ApplyContext implements modifiers.ContextModifier, drawing randomness from c.
+func (u *UrlTransformer) ApplyContext(c modifiers.Context, tokens []models.Token, cfg json.RawMessage) ([]models.Token, error) {
 	return tokens, modifiers.ErrNotImplemented
 }
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"cmdFuscator/models"
+)
+
+// Option configures an Engine at construction time. Pass any number of
+// options to New; later options override earlier ones.
+type Option func(*Engine)
+
+// WithRenderTarget fixes the shell dialect used to render output. The default,
+// TargetAuto, picks a dialect from each profile's platform via TargetFor.
+func WithRenderTarget(t RenderTarget) Option {
+	return func(e *Engine) { e.target = t }
+}
+
+// WithWorkers sets how many goroutines ObfuscateBatch fans work out over.
+// Values below 1 select runtime.GOMAXPROCS(0), which is also the default.
+func WithWorkers(n int) Option {
+	return func(e *Engine) { e.workers = n }
+}
+
+// WithStats enables collection of per-modifier timing and token-touch counts
+// in ObfuscateResult.Stats. Collection is off by default.
+func WithStats(enabled bool) Option {
+	return func(e *Engine) { e.stats = enabled }
+}
+
+// WithTrace records, in ObfuscateResult.Trace, the tokens going into and
+// coming out of every modifier, so callers can show what each technique did.
+// Tracing is off by default.
+func WithTrace(enabled bool) Option {
+	return func(e *Engine) { e.trace = enabled }
+}
+
+// WithProbabilities overrides, by modifier name, the Probability of every
+// profile's config for that modifier with a value in [0, 1]. Modifiers not in
+// probs keep their profile's value.
+func WithProbabilities(probs map[string]float64) Option {
+	return func(e *Engine) { e.probs = probs }
+}
+
+// WithConfigOverrides lays configs, by modifier name, over every profile's
+// config for that modifier, key by key as a PipelineStep's Config is, so an
+// override can change just a Probability or a character pool. A modifier the
+// profile does not configure runs with its override alone, when enabled.
+// Overrides win over WithProbabilities; pipeline step configs win over them.
+func WithConfigOverrides(configs map[string]json.RawMessage) Option {
+	return func(e *Engine) { e.overrides = configs }
+}
+
+// WithPipeline replaces the modifier selection and order: every Obfuscate
+// runs steps in the order given, each with its Config laid over the profile's
+// (see PipelineStep), and the enabled set passed to Obfuscate is ignored. A
+// modifier may appear more than once. Config overrides win over
+// WithProbabilities.
+func WithPipeline(steps ...PipelineStep) Option {
+	return func(e *Engine) { e.pipeline = steps }
+}
+
+// WithOrder runs the enabled modifiers named in names first, in that order,
+// and the others after them in registration order. Unlike WithPipeline it
+// keeps the enabled set passed to Obfuscate, which it has no say in, and it
+// has no effect on an engine built with WithPipeline.
+func WithOrder(names ...string) Option {
+	return func(e *Engine) { e.order = names }
+}
+
+// WithSeed seeds the engine's random choices: the same seed, options and
+// calls in the same order give the same outputs. Batch items with a zero
+// Seed draw theirs from it too, in item order. Zero, the default, seeds from
+// the clock.
+func WithSeed(seed int64) Option {
+	return func(e *Engine) { e.seed = seed }
+}
+
+// WithErrorPolicy selects how modifier errors are handled. The default is
+// BestEffort.
+func WithErrorPolicy(p ErrorPolicy) Option {
+	return func(e *Engine) { e.policy = p }
+}
+
+// WithStrict makes modifiers that return ErrNotImplemented count as errors
+// instead of being listed in ObfuscateResult.Skipped. Combine with FailFast
+// for CI runs that must not silently drop a technique.
+func WithStrict(strict bool) Option {
+	return func(e *Engine) { e.strict = strict }
+}
+
+// WithFrozenTypes freezes every token of the given types before the modifier
+// stage, so they reach the output exactly as tokenized. Use it to keep URLs or
+// paths intact; see models.Token.Frozen for per-token control.
+func WithFrozenTypes(types ...models.TokenType) Option {
+	return func(e *Engine) { e.freeze = types }
+}
+
+// WithValidators checks every rendered output with vs, in order, recording
+// their errors in ObfuscateResult.Warnings. There are none by default;
+// QuoteBalance checks that quotes are closed, and package verify has
+// validators that parse the output as a shell would.
+func WithValidators(vs ...Validator) Option {
+	return func(e *Engine) { e.validators = vs }
+}
+
+// WithLogger sends structured records of every run to l: one per modifier
+// stage and one per finished run at debug level, and a warning for each
+// modifier error. Records carry the profile and modifier names as attributes
+// and the run's context, so handlers can pick up request-scoped values. Nil,
+// the default, logs nothing; the engine never writes to stdout or stderr
+// itself.
+func WithLogger(l *slog.Logger) Option {
+	return func(e *Engine) { e.logger = l }
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"cmdFuscator/engine/modifiers"
+)
+
+// PipelineStep is one entry of the modifier list set with WithPipeline.
+type PipelineStep struct {
+	Modifier string
+	// Config is laid over the profile's config for Modifier, key by key, so
+	// a step can change just its Probability. When the profile does not
+	// configure Modifier, Config is used alone. Nil keeps the profile's.
+	Config json.RawMessage
+}
+
+// stage is a modifier about to be dispatched, with its step's Config.
+type stage struct {
+	mod      modifiers.Modifier
+	override json.RawMessage
+}
+
+// stages returns the modifiers run dispatches: the pipeline's steps in order,
+// or else every registered modifier in enabled, in the WithOrder order.
+// Pipeline steps naming unregistered modifiers are ignored, as unknown
+// modifiers in profiles are. Each stage carries its WithConfigOverrides
+// override, with its step's Config laid over it.
+func (e *Engine) stages(enabled map[string]bool) []stage {
+	var out []stage
+	if e.pipeline != nil {
+		for _, step := range e.pipeline {
+			if mod, ok := modifiers.Get(step.Modifier); ok {
+				out = append(out, stage{mod: mod, override: e.override(step.Modifier, step.Config)})
+			}
+		}
+		return out
+	}
+	for _, mod := range e.ordered() {
+		if enabled[mod.Name()] {
+			out = append(out, stage{mod: mod, override: e.override(mod.Name(), nil)})
+		}
+	}
+	return out
+}
+
+// ordered returns every registered modifier, those named with WithOrder first
+// and in its order, the rest in registration order.
+func (e *Engine) ordered() []modifiers.Modifier {
+	all := modifiers.All()
+	if len(e.order) == 0 {
+		return all
+	}
+	rank := make(map[string]int, len(e.order))
+	for i, name := range e.order {
+		if _, dup := rank[name]; !dup {
+			rank[name] = i
+		}
+	}
+	slices.SortStableFunc(all, func(a, b modifiers.Modifier) int {
+		ra, aok := rank[a.Name()]
+		rb, bok := rank[b.Name()]
+		switch {
+		case aok && bok:
+			return cmp.Compare(ra, rb)
+		case aok:
+			return -1
+		case bok:
+			return 1
+		}
+		return 0
+	})
+	return all
+}
+
+// override returns the config laid over the profile's for name: the
+// engine's override for it, with step laid over that.
+func (e *Engine) override(name string, step json.RawMessage) json.RawMessage {
+	o, ok := e.overrides[name]
+	switch {
+	case !ok:
+		return step
+	case step == nil:
+		return o
+	}
+	return overlayConfig(o, step)
+}
+
+// overlayConfig returns base with every top-level key of override set, and
+// any key spelled differently only in case removed, since modifiers decode
+// their configs case-insensitively. A base that is missing or not a JSON
+// object is replaced by override outright.
+func overlayConfig(base, override json.RawMessage) json.RawMessage {
+	var dst, src map[string]json.RawMessage
+	if err := json.Unmarshal(override, &src); err != nil || src == nil {
+		return override
+	}
+	if err := json.Unmarshal(base, &dst); err != nil || dst == nil {
+		return override
+	}
+	for key, v := range src {
+		for k := range dst {
+			if strings.EqualFold(k, key) {
+				delete(dst, k)
+			}
+		}
+		dst[key] = v
+	}
+	out, err := json.Marshal(dst)
+	if err != nil {
+		return override
+	}
+	return out
+}
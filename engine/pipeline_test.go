@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestPipeline_Order(t *testing.T) {
+	eng := New(WithTrace(true), WithPipeline(PipelineStep{Modifier: "Sed"}, PipelineStep{Modifier: "RandomCase"}))
+	// enabled is ignored: the pipeline names the modifiers.
+	res, err := eng.Obfuscate("tool -a", statsFile(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var order []string
+	for _, step := range res.Trace {
+		order = append(order, step.Modifier)
+	}
+	if want := []string{"Sed", "RandomCase"}; !slices.Equal(order, want) {
+		t.Errorf("modifiers ran in order %v, want %v", order, want)
+	}
+	if res.Output != "tool -A" {
+		t.Errorf("Output = %q, want %q", res.Output, "tool -A")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   []string
+		enabled map[string]bool
+		want    []string
+	}{
+		{"registration", nil, map[string]bool{"RandomCase": true, "Sed": true}, []string{"RandomCase", "Sed"}},
+		{"named first", []string{"Sed"}, map[string]bool{"RandomCase": true, "Sed": true}, []string{"Sed", "RandomCase"}},
+		{"all named", []string{"Sed", "RandomCase"}, map[string]bool{"RandomCase": true, "Sed": true}, []string{"Sed", "RandomCase"}},
+		{"enabled kept", []string{"Sed", "RandomCase"}, map[string]bool{"RandomCase": true}, []string{"RandomCase"}},
+		{"unknown ignored", []string{"NoSuchModifier", "Sed"}, map[string]bool{"RandomCase": true, "Sed": true}, []string{"Sed", "RandomCase"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := New(WithTrace(true), WithOrder(tt.order...)).Obfuscate("tool -a", statsFile(), tt.enabled)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var order []string
+			for _, step := range res.Trace {
+				order = append(order, step.Modifier)
+			}
+			if !slices.Equal(order, tt.want) {
+				t.Errorf("modifiers ran in order %v, want %v", order, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_Repeat(t *testing.T) {
+	// RandomCase at probability 1.0 flips every letter; twice is a no-op.
+	eng := New(WithPipeline(PipelineStep{Modifier: "RandomCase"}, PipelineStep{Modifier: "RandomCase"}))
+	res, err := eng.Obfuscate("tool -abc", statsFile(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output != "tool -abc" {
+		t.Errorf("Output = %q, want the input back", res.Output)
+	}
+	if want := []string{"RandomCase", "RandomCase"}; !slices.Equal(res.Applied, want) {
+		t.Errorf("Applied = %v, want %v", res.Applied, want)
+	}
+}
+
+func TestPipeline_Config(t *testing.T) {
+	eng := New(
+		WithProbabilities(map[string]float64{"RandomCase": 1}),
+		WithPipeline(
+			// Overrides win over WithProbabilities.
+			PipelineStep{Modifier: "RandomCase", Config: json.RawMessage(`{"probability":"0"}`)},
+			// The profile configures no Characters; the step supplies them.
+			PipelineStep{Modifier: "CharacterInsertion", Config: json.RawMessage(`{"Characters":["\u200d"],"Offset":"2"}`)},
+			PipelineStep{Modifier: "NoSuchModifier"},
+		),
+	)
+	res, err := eng.Obfuscate("tool -a", policyFile(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("Errors = %v", res.Errors)
+	}
+	if res.Output != "tool -a\u200d" {
+		t.Errorf("Output = %q, want one ZWJ inserted and the case unchanged", res.Output)
+	}
+}
+
+func TestPipeline_ConfigOnly(t *testing.T) {
+	// statsFile does not configure CharacterInsertion at all.
+	step := PipelineStep{Modifier: "CharacterInsertion",
+		Config: json.RawMessage(`{"AppliesTo":["argument"],"Probability":"1.0","Characters":["\u00ad"],"Offset":"1"}`)}
+	res, err := New(WithPipeline(step)).Obfuscate("tool -a", statsFile(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output != "tool -\u00ada" {
+		t.Errorf("Output = %q, want a soft hyphen inserted", res.Output)
+	}
+}
+
+func TestWithConfigOverrides(t *testing.T) {
+	overrides := map[string]json.RawMessage{
+		// Overrides win over WithProbabilities.
+		"RandomCase":         json.RawMessage(`{"Probability":"0"}`),
+		"CharacterInsertion": json.RawMessage(`{"Characters":["\u200d"],"Offset":"2"}`),
+	}
+	eng := New(WithProbabilities(map[string]float64{"RandomCase": 1}), WithConfigOverrides(overrides))
+	res, err := eng.Obfuscate("tool -a", policyFile(), map[string]bool{"RandomCase": true, "CharacterInsertion": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("Errors = %v", res.Errors)
+	}
+	if res.Output != "tool -a\u200d" {
+		t.Errorf("Output = %q, want one ZWJ inserted and the case unchanged", res.Output)
+	}
+
+	// A pipeline step's Config wins over the override.
+	eng = New(WithConfigOverrides(overrides), WithPipeline(PipelineStep{Modifier: "RandomCase", Config: json.RawMessage(`{"Probability":"1.0"}`)}))
+	if res, err := eng.Obfuscate("tool -a", policyFile(), nil); err != nil || res.Output != "tool -A" {
+		t.Errorf("with a pipeline: Output = %q, %v; want %q", res.Output, err, "tool -A")
+	}
+}
+
+func TestOverlayConfig(t *testing.T) {
+	tests := []struct {
+		base, override, want string
+	}{
+		{`{"AppliesTo":["argument"],"Probability":"1.0"}`, `{"Probability":"0.5"}`, `{"AppliesTo":["argument"],"Probability":"0.5"}`},
+		{`{"AppliesTo":["argument"],"Probability":"1.0"}`, `{"probability":0.5}`, `{"AppliesTo":["argument"],"probability":0.5}`},
+		{`{"AppliesTo":["argument"]}`, `{"Characters":["x"]}`, `{"AppliesTo":["argument"],"Characters":["x"]}`},
+		{``, `{"Probability":"0.5"}`, `{"Probability":"0.5"}`},
+		{`[1]`, `{"Probability":"0.5"}`, `{"Probability":"0.5"}`},
+		{`{"Probability":"1.0"}`, `[2]`, `[2]`},
+	}
+	for _, tt := range tests {
+		if got := string(overlayConfig(json.RawMessage(tt.base), json.RawMessage(tt.override))); got != tt.want {
+			t.Errorf("overlayConfig(%s, %s) = %s, want %s", tt.base, tt.override, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"cmdFuscator/models"
+)
+
+// ─── Render targets ───────────────────────────────────────────────────────────
+
+// RenderTarget selects the shell whose quoting and escaping rules Render
+// applies when joining tokens back into a command line.
+type RenderTarget int
+
+const (
+	// TargetAuto lets the engine pick a target from the profile's platform
+	// (see TargetFor). Passed directly to RenderFor it behaves like TargetNone.
+	TargetAuto RenderTarget = iota
+	// TargetNone joins token values verbatim with single spaces.
+	TargetNone
+	// TargetCmd renders for cmd.exe: caret-escapes & | < > and quotes whitespace.
+	TargetCmd
+	// TargetPowerShell renders for PowerShell: backtick-escapes metacharacters.
+	TargetPowerShell
+	// TargetBash renders for bash and other POSIX shells: backslash escapes.
+	TargetBash
+)
+
+var targetNames = map[RenderTarget]string{
+	TargetAuto:       "auto",
+	TargetNone:       "none",
+	TargetCmd:        "cmd",
+	TargetPowerShell: "powershell",
+	TargetBash:       "bash",
+}
+
+// String returns the canonical lowercase name of the target.
+func (t RenderTarget) String() string {
+	if name, ok := targetNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("RenderTarget(%d)", int(t))
+}
+
+// ParseRenderTarget converts a user-supplied name (e.g. from a CLI flag) into a
+// RenderTarget. Common aliases such as "cmd.exe", "pwsh" and "sh" are accepted.
+func ParseRenderTarget(s string) (RenderTarget, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "auto":
+		return TargetAuto, nil
+	case "none", "raw":
+		return TargetNone, nil
+	case "cmd", "cmd.exe":
+		return TargetCmd, nil
+	case "powershell", "pwsh", "ps":
+		return TargetPowerShell, nil
+	case "bash", "sh", "posix", "zsh":
+		return TargetBash, nil
+	}
+	return TargetAuto, fmt.Errorf("unknown render target %q", s)
+}
+
+// TargetFor infers the most likely shell for a profile from its platform:
+// cmd.exe on Windows, bash on Linux and macOS, TargetNone otherwise.
+func TargetFor(profile models.Profile) RenderTarget {
+	switch strings.ToLower(profile.Platform) {
+	case "windows":
+		return TargetCmd
+	case "linux", "macos":
+		return TargetBash
+	}
+	return TargetNone
+}
+
+// ─── Rendering ────────────────────────────────────────────────────────────────
+
+// Render joins a token slice back into a command string without applying any
+// shell-specific escaping. It is the inverse of Tokenize for single-spaced
+// input. Use RenderFor when the output is destined for a particular shell.
+func Render(tokens []models.Token) string {
+	return RenderFor(tokens, TargetNone)
+}
+
+// RenderFor joins tokens into a command line that target will parse back
+// into the same words.
+//
+// Token values are treated as shell text: balanced quotes and existing escape
+// sequences are left untouched, so techniques that deliberately insert quotes
+// keep working. Outside quoted spans, whitespace, the target's control
+// metacharacters, and stray unmatched quotes are escaped. Empty values are
+// rendered as "" so they keep their argument position. TokenTypeExpansion
+// tokens are shell syntax by definition and are always written verbatim.
+func RenderFor(tokens []models.Token, target RenderTarget) string {
+	return strings.Join(renderParts(tokens, target), " ")
+}
+
+// renderParts returns the word RenderFor writes for each token.
+func renderParts(tokens []models.Token, target RenderTarget) []string {
+	d, ok := dialects[target]
+	parts := make([]string, len(tokens))
+	for i, t := range tokens {
+		if !ok || t.Type == models.TokenTypeExpansion {
+			parts[i] = t.Value
+			continue
+		}
+		parts[i] = d.escape(t.Value)
+	}
+	return parts
+}
+
+// ─── Dialects ─────────────────────────────────────────────────────────────────
+
+// dialect describes the subset of a shell's lexical rules that matters for
+// rendering a single word.
+type dialect struct {
+	quotes    string // runes that open and close a quoted span
+	esc       rune   // escape rune outside quotes
+	quotedEsc string // quote runes inside which esc also escapes (e.g. bash "\"")
+	special   string // metacharacters that must be escaped outside quotes
+	quoteWS   bool   // whitespace cannot be escaped; wrap it in "" instead
+	escQuotes bool   // whether an unmatched quote can be escaped with esc
+}
+
+var dialects = map[RenderTarget]dialect{
+	TargetCmd: {
+		quotes:  `"`,
+		esc:     '^',
+		special: "&|<>",
+		quoteWS: true,
+	},
+	TargetPowerShell: {
+		quotes:    `"'`,
+		esc:       '`',
+		quotedEsc: `"`,
+		special:   "&|;<>",
+		escQuotes: true,
+	},
+	TargetBash: {
+		quotes:    `"'`,
+		esc:       '\\',
+		quotedEsc: `"`,
+		special:   "&|;<>()",
+		escQuotes: true,
+	},
+}
+
+// escape rewrites word so the dialect's shell reads it as a single word with
+// the same meaning the token author intended.
+func (d dialect) escape(word string) string {
+	if word == "" {
+		return `""`
+	}
+
+	runes := []rune(word)
+	var b strings.Builder
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == d.esc:
+			// Existing escape sequence: keep it and the rune it escapes.
+			b.WriteRune(r)
+			if i+1 < len(runes) {
+				i++
+				b.WriteRune(runes[i])
+			}
+
+		case strings.ContainsRune(d.quotes, r):
+			if end := d.closingQuote(runes, i); end >= 0 {
+				b.WriteString(string(runes[i : end+1]))
+				i = end
+			} else if d.escQuotes {
+				b.WriteRune(d.esc)
+				b.WriteRune(r)
+			} else {
+				b.WriteRune(r)
+			}
+
+		case unicode.IsSpace(r):
+			if d.quoteWS {
+				b.WriteRune('"')
+				b.WriteRune(r)
+				b.WriteRune('"')
+			} else {
+				b.WriteRune(d.esc)
+				b.WriteRune(r)
+			}
+
+		case strings.ContainsRune(d.special, r):
+			b.WriteRune(d.esc)
+			b.WriteRune(r)
+
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// closingQuote returns the index of the rune closing the quoted span opened at
+// runes[open], or -1 when the quote is never closed.
+func (d dialect) closingQuote(runes []rune, open int) int {
+	q := runes[open]
+	escapes := strings.ContainsRune(d.quotedEsc, q)
+	for j := open + 1; j < len(runes); j++ {
+		switch {
+		case escapes && runes[j] == d.esc:
+			j++ // skip the escaped rune
+		case runes[j] == q:
+			return j
+		}
+	}
+	return -1
+}
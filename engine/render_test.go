@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+// ─── per-target escaping ──────────────────────────────────────────────────────
+
+func TestRenderFor_Escaping(t *testing.T) {
+	cases := []struct {
+		name   string
+		target RenderTarget
+		value  string
+		want   string
+	}{
+		// TargetNone is a verbatim join.
+		{"none leaves metachars", TargetNone, "a&b c", "a&b c"},
+		{"auto behaves like none", TargetAuto, "a&b", "a&b"},
+
+		// cmd.exe
+		{"cmd carets", TargetCmd, "https://x/?a=1&b=2|c", "https://x/?a=1^&b=2^|c"},
+		{"cmd redirects", TargetCmd, "a<b>c", "a^<b^>c"},
+		{"cmd whitespace quoted", TargetCmd, "a b", `a" "b`},
+		{"cmd quoted span verbatim", TargetCmd, `"a & b"`, `"a & b"`},
+		{"cmd existing caret kept", TargetCmd, "a^&b", "a^&b"},
+		{"cmd inserted quotes kept", TargetCmd, `-url""cache`, `-url""cache`},
+
+		// PowerShell
+		{"ps backtick metachars", TargetPowerShell, "a;b&c", "a`;b`&c"},
+		{"ps whitespace", TargetPowerShell, "a b", "a` b"},
+		{"ps single quotes verbatim", TargetPowerShell, "'a;b'", "'a;b'"},
+		{"ps escaped quote inside double quotes", TargetPowerShell, "\"a`\"b\"", "\"a`\"b\""},
+		{"ps unmatched quote", TargetPowerShell, "it's", "it`'s"},
+		{"ps variable untouched", TargetPowerShell, "$env:TEMP", "$env:TEMP"},
+
+		// bash
+		{"bash metachars", TargetBash, "a;b|c(d)", `a\;b\|c\(d\)`},
+		{"bash whitespace", TargetBash, "a b", `a\ b`},
+		{"bash single quotes verbatim", TargetBash, "'$(id); x'", "'$(id); x'"},
+		{"bash unmatched single quote", TargetBash, "O'Brien", `O\'Brien`},
+		{"bash existing backslash kept", TargetBash, `a\ b`, `a\ b`},
+		{"bash escaped quote inside double quotes", TargetBash, `"a\"b"`, `"a\"b"`},
+
+		// empty values keep their position
+		{"empty cmd", TargetCmd, "", `""`},
+		{"empty bash", TargetBash, "", `""`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := RenderFor([]models.Token{tok(models.TokenTypeValue, tc.value)}, tc.target)
+			if got != tc.want {
+				t.Errorf("RenderFor(%q, %v) = %q, want %q", tc.value, tc.target, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenderFor_ExpansionVerbatim(t *testing.T) {
+	toks := []models.Token{
+		tok(models.TokenTypeCommand, "echo"),
+		tok(models.TokenTypeExpansion, "$(id | tr a b)"),
+		tok(models.TokenTypeValue, "(x)"),
+	}
+	if got, want := RenderFor(toks, TargetBash), `echo $(id | tr a b) \(x\)`; got != want {
+		t.Errorf("RenderFor = %q, want %q", got, want)
+	}
+}
+
+// Input that is already valid for a target must survive a round trip through
+// Tokenize and RenderFor unchanged.
+func TestRenderFor_RoundTripValidInput(t *testing.T) {
+	cases := []struct {
+		target RenderTarget
+		input  string
+	}{
+		{TargetCmd, `certutil.exe -urlcache -f "https://x/?a=1&b=2" out.bin`},
+		{TargetCmd, `certutil.exe -urlcache -f https://x/?a=1^&b=2 out.bin`},
+		{TargetPowerShell, `powershell -c "Get-Process; Get-Date"`},
+		{TargetBash, `bash -c 'id; uname -a'`},
+		{TargetBash, `curl https://x/?a=1\&b=2`},
+	}
+	for _, tc := range cases {
+		toks, err := Tokenize(tc.input, bashProfile)
+		if err != nil {
+			t.Fatalf("Tokenize: %v", err)
+		}
+		if got := RenderFor(toks, tc.target); got != tc.input {
+			t.Errorf("%v round trip:\n got  %q\n want %q", tc.target, got, tc.input)
+		}
+	}
+}
+
+// ─── target selection ─────────────────────────────────────────────────────────
+
+func TestParseRenderTarget(t *testing.T) {
+	cases := map[string]RenderTarget{
+		"":           TargetAuto,
+		"auto":       TargetAuto,
+		"none":       TargetNone,
+		"cmd.exe":    TargetCmd,
+		"CMD":        TargetCmd,
+		"pwsh":       TargetPowerShell,
+		"powershell": TargetPowerShell,
+		"sh":         TargetBash,
+		"bash":       TargetBash,
+	}
+	for in, want := range cases {
+		got, err := ParseRenderTarget(in)
+		if err != nil || got != want {
+			t.Errorf("ParseRenderTarget(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseRenderTarget("fish"); err == nil {
+		t.Error("ParseRenderTarget(fish) should fail")
+	}
+}
+
+func TestRenderTarget_StringRoundTrip(t *testing.T) {
+	for _, target := range []RenderTarget{TargetAuto, TargetNone, TargetCmd, TargetPowerShell, TargetBash} {
+		got, err := ParseRenderTarget(target.String())
+		if err != nil || got != target {
+			t.Errorf("ParseRenderTarget(%q) = %v, %v; want %v", target.String(), got, err, target)
+		}
+	}
+}
+
+func TestTargetFor(t *testing.T) {
+	cases := map[string]RenderTarget{
+		"windows": TargetCmd,
+		"Linux":   TargetBash,
+		"macos":   TargetBash,
+		"":        TargetNone,
+	}
+	for plat, want := range cases {
+		if got := TargetFor(models.Profile{Platform: plat}); got != want {
+			t.Errorf("TargetFor(%q) = %v, want %v", plat, got, want)
+		}
+	}
+}
+
+// ─── engine integration ───────────────────────────────────────────────────────
+
+func TestObfuscate_UsesProfileTarget(t *testing.T) {
+	pf := &models.ProfileFile{Name: "certutil", Profiles: []models.Profile{certutilProfile}}
+
+	res, err := New().Obfuscate("certutil -urlcache -f https://x/?a=1&b=2 out", pf, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Target != TargetCmd {
+		t.Errorf("Target = %v, want %v", res.Target, TargetCmd)
+	}
+	if want := "certutil -urlcache -f https://x/?a=1^&b=2 out"; res.Output != want {
+		t.Errorf("Output = %q, want %q", res.Output, want)
+	}
+
+	res, err = New(WithRenderTarget(TargetNone)).Obfuscate("certutil https://x/?a&b", pf, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "certutil https://x/?a&b"; res.Output != want {
+		t.Errorf("WithRenderTarget(TargetNone) Output = %q, want %q", res.Output, want)
+	}
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"math"
+	"unicode"
+
+	"cmdFuscator/models"
+)
+
+// Score is a rough measure of how far an obfuscated command has moved away
+// from its input. Value combines the individual ratios into a single 0–100
+// figure meant for comparing variants of the same command, not for absolute
+// claims about detectability.
+type Score struct {
+	Value int // weighted overall score, 0–100
+
+	EditDistance  int     // Levenshtein distance between input and output, in runes
+	EditRatio     float64 // EditDistance relative to the input length, capped at 1
+	NonASCIIRatio float64 // share of output runes outside ASCII
+	CaseFlipRatio float64 // share of input letters whose case changed in the output
+	Structural    float64 // share of token positions added, removed or retyped
+}
+
+// Score weights; they sum to 1 so Value stays within 0–100.
+const (
+	weightEdit       = 0.40
+	weightNonASCII   = 0.20
+	weightCaseFlip   = 0.25
+	weightStructural = 0.15
+)
+
+// ComputeScore scores output against input. before and after are the token
+// slices on either side of the modifier stage and feed the Structural ratio;
+// either may be nil.
+func ComputeScore(input, output string, before, after []models.Token) Score {
+	in, out := []rune(input), []rune(output)
+
+	s := Score{
+		EditDistance:  levenshtein(in, out),
+		NonASCIIRatio: nonASCIIRatio(out),
+		CaseFlipRatio: caseFlipRatio(in, out),
+		Structural:    structuralRatio(before, after),
+	}
+	if len(in) > 0 {
+		s.EditRatio = math.Min(1, float64(s.EditDistance)/float64(len(in)))
+	} else if len(out) > 0 {
+		s.EditRatio = 1
+	}
+
+	v := weightEdit*s.EditRatio +
+		weightNonASCII*s.NonASCIIRatio +
+		weightCaseFlip*s.CaseFlipRatio +
+		weightStructural*s.Structural
+	s.Value = int(math.Round(100 * math.Min(1, v)))
+	return s
+}
+
+// levenshtein returns the edit distance between a and b using two rows.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func nonASCIIRatio(rs []rune) float64 {
+	if len(rs) == 0 {
+		return 0
+	}
+	n := 0
+	for _, r := range rs {
+		if r > unicode.MaxASCII {
+			n++
+		}
+	}
+	return float64(n) / float64(len(rs))
+}
+
+// caseFlipRatio walks the output once, matching each rune to the next input
+// rune that is equal ignoring case and treating anything else as an
+// insertion. That greedy alignment is exact for case changes and inserted
+// characters, which covers the modifiers that change letter case.
+func caseFlipRatio(in, out []rune) float64 {
+	letters, flipped := 0, 0
+	for _, r := range in {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters == 0 {
+		return 0
+	}
+
+	i := 0
+	for _, r := range out {
+		if i >= len(in) {
+			break
+		}
+		if unicode.ToLower(r) != unicode.ToLower(in[i]) {
+			continue
+		}
+		if r != in[i] && unicode.IsLetter(r) {
+			flipped++
+		}
+		i++
+	}
+	return float64(flipped) / float64(letters)
+}
+
+// structuralRatio is the share of token positions whose type differs between
+// before and after, counting added or removed tokens as changed.
+func structuralRatio(before, after []models.Token) float64 {
+	total := max(len(before), len(after))
+	if total == 0 {
+		return 0
+	}
+	n := min(len(before), len(after))
+	changed := total - n
+	for i := range n {
+		if before[i].Type != after[i].Type {
+			changed++
+		}
+	}
+	return float64(changed) / float64(total)
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func TestComputeScore_Unchanged(t *testing.T) {
+	toks := []models.Token{tok(models.TokenTypeCommand, "tool"), tok(models.TokenTypeArgument, "-a")}
+	s := ComputeScore("tool -a", "tool -a", toks, toks)
+	if s != (Score{}) {
+		t.Errorf("identical input/output: got %+v, want zero Score", s)
+	}
+}
+
+func TestComputeScore_Components(t *testing.T) {
+	cases := []struct {
+		name              string
+		in, out           string
+		edit              int
+		caseFlip, nonASCI float64
+	}{
+		{"case flips", "tool -ab", "TOOL -AB", 6, 1, 0},
+		{"insertion", "tool", "to^ol", 1, 0, 0},
+		{"insertion and flip", "tool", "T^ool", 2, 0.25, 0},
+		{"non-ascii", "ab", "a­b", 1, 0, 1.0 / 3},
+	}
+	for _, tc := range cases {
+		s := ComputeScore(tc.in, tc.out, nil, nil)
+		if s.EditDistance != tc.edit {
+			t.Errorf("%s: EditDistance = %d, want %d", tc.name, s.EditDistance, tc.edit)
+		}
+		if s.CaseFlipRatio != tc.caseFlip {
+			t.Errorf("%s: CaseFlipRatio = %v, want %v", tc.name, s.CaseFlipRatio, tc.caseFlip)
+		}
+		if s.NonASCIIRatio != tc.nonASCI {
+			t.Errorf("%s: NonASCIIRatio = %v, want %v", tc.name, s.NonASCIIRatio, tc.nonASCI)
+		}
+		if s.Value <= 0 || s.Value > 100 {
+			t.Errorf("%s: Value = %d, want within (0, 100]", tc.name, s.Value)
+		}
+	}
+}
+
+func TestComputeScore_Structural(t *testing.T) {
+	before := []models.Token{tok(models.TokenTypeCommand, "tool"), tok(models.TokenTypeArgument, "-a")}
+	after := append(append([]models.Token{}, before...), tok(models.TokenTypeValue, "x"))
+	if got := ComputeScore("", "", before, after).Structural; got != 1.0/3 {
+		t.Errorf("Structural = %v, want 1/3", got)
+	}
+}
+
+func TestComputeScore_StrongerScoresHigher(t *testing.T) {
+	light := ComputeScore("certutil -urlcache", "certutil -urlcAche", nil, nil)
+	heavy := ComputeScore("certutil -urlcache", "CeRtUtIl -URLC^A^CHE", nil, nil)
+	if heavy.Value <= light.Value {
+		t.Errorf("heavy %d should outscore light %d", heavy.Value, light.Value)
+	}
+}
+
+func TestObfuscate_SetsScore(t *testing.T) {
+	res, err := New().Obfuscate("tool -a -b", statsFile(), map[string]bool{"RandomCase": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Score.Value == 0 || res.Score.CaseFlipRatio == 0 {
+		t.Errorf("Score = %+v, want non-zero for case-flipped output", res.Score)
+	}
+}
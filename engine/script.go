@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cmdFuscator/models"
+)
+
+// ScriptResult is the outcome of ObfuscateScript.
+type ScriptResult struct {
+	// Output is the reassembled script: every recognised command replaced by
+	// its obfuscated form, separators and surrounding whitespace untouched.
+	Output string
+
+	// Commands has one entry per non-blank command in the script, in order.
+	Commands []ScriptCommand
+}
+
+// ScriptCommand describes how a single command inside a script was handled.
+type ScriptCommand struct {
+	Input   string              // the command as written, trimmed
+	Profile *models.ProfileFile // matched profile; nil when none matched
+	Result  ObfuscateResult     // zero when Profile is nil or Err is set
+	Err     error               // pipeline error; the command is left as written
+}
+
+// ObfuscateScript obfuscates a script containing several commands separated by
+// newlines, ";", "&&", "||" or "|". Each command's executable is matched to a
+// profile with DetectProfile and obfuscated independently; commands with no
+// matching profile, or whose pipeline fails, are copied through unchanged.
+//
+// enabled applies to every command. A nil map enables each matched profile's
+// own modifiers (see DefaultEnabled).
+func (e *Engine) ObfuscateScript(script string, profiles []*models.ProfileFile, enabled map[string]bool) (ScriptResult, error) {
+	return e.ObfuscateScriptContext(context.Background(), script, profiles, enabled)
+}
+
+// ObfuscateScriptContext is ObfuscateScript with a context. Once ctx is done
+// the whole script fails with an error wrapping ctx.Err(), rather than being
+// returned with its remaining commands copied through unobfuscated.
+func (e *Engine) ObfuscateScriptContext(ctx context.Context, script string, profiles []*models.ProfileFile, enabled map[string]bool) (ScriptResult, error) {
+	if strings.TrimSpace(script) == "" {
+		return ScriptResult{}, errors.New("engine: empty script")
+	}
+
+	var (
+		out strings.Builder
+		res ScriptResult
+	)
+
+	for _, part := range splitScript(script) {
+		body := strings.TrimSpace(part.text)
+		if body == "" {
+			out.WriteString(part.text)
+			out.WriteString(part.sep)
+			continue
+		}
+
+		lead := part.text[:strings.Index(part.text, body)]
+		trail := part.text[len(lead)+len(body):]
+		cmd := ScriptCommand{Input: body}
+		rendered := body
+
+		if pf, ok := DetectProfile(body, profiles); ok {
+			cmd.Profile = pf
+			en := enabled
+			if en == nil {
+				en = DefaultEnabled(pf)
+			}
+			cmd.Result, cmd.Err = e.run(ctx, e.modContext(), body, nil, pf, en)
+			if err := ctx.Err(); err != nil {
+				return ScriptResult{}, fmt.Errorf("engine: script stopped at %q: %w", body, err)
+			}
+			if cmd.Err == nil {
+				rendered = cmd.Result.Output
+			}
+		}
+
+		res.Commands = append(res.Commands, cmd)
+		out.WriteString(lead)
+		out.WriteString(rendered)
+		out.WriteString(trail)
+		out.WriteString(part.sep)
+	}
+
+	res.Output = out.String()
+	return res, nil
+}
+
+// ─── Script splitting ─────────────────────────────────────────────────────────
+
+// scriptPart is a command's raw text followed by the separator that ended it
+// ("" for the final command).
+type scriptPart struct {
+	text string
+	sep  string
+}
+
+// scriptSeps lists command separators, longest first so "&&" wins over "&".
+var scriptSeps = []string{"\r\n", "\n", "&&", "||", ";", "|"}
+
+// splitScript splits s into commands on unquoted separators. A separator
+// preceded by ^ (cmd.exe) or \ (POSIX) is escaped and stays in the command, as
+// does a backslash-newline line continuation.
+func splitScript(s string) []scriptPart {
+	var (
+		parts []scriptPart
+		cur   strings.Builder
+		quote rune
+	)
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if quote != 0 {
+			cur.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case r == '"' || r == '\'':
+			quote = r
+			cur.WriteRune(r)
+			continue
+		case (r == '^' || r == '\\') && i+1 < len(runes) && isSepRune(runes[i+1]):
+			cur.WriteRune(r)
+			cur.WriteRune(runes[i+1])
+			i++
+			continue
+		}
+
+		if sep := sepAt(runes, i); sep != "" {
+			parts = append(parts, scriptPart{text: cur.String(), sep: sep})
+			cur.Reset()
+			i += len([]rune(sep)) - 1
+			continue
+		}
+		cur.WriteRune(r)
+	}
+
+	return append(parts, scriptPart{text: cur.String()})
+}
+
+func isSepRune(r rune) bool {
+	return r == ';' || r == '&' || r == '|' || r == '\n' || r == '\r'
+}
+
+// sepAt returns the separator starting at runes[i], or "".
+func sepAt(runes []rune, i int) string {
+	for _, sep := range scriptSeps {
+		sr := []rune(sep)
+		if i+len(sr) > len(runes) {
+			continue
+		}
+		if string(runes[i:i+len(sr)]) == sep {
+			return sep
+		}
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func scriptProfiles() []*models.ProfileFile {
+	upper := json.RawMessage(`{"AppliesTo":["argument"],"Probability":"1.0"}`)
+	return []*models.ProfileFile{
+		{Name: "certutil", Profiles: []models.Profile{{
+			Platform:   "windows",
+			Parameters: models.ProfileParameters{Modifiers: map[string]json.RawMessage{"RandomCase": upper}},
+		}}},
+		{Name: "bash", Profiles: []models.Profile{{
+			Platform:   "linux",
+			Alias:      []string{"sh", "certutil-alias"},
+			Parameters: models.ProfileParameters{Modifiers: map[string]json.RawMessage{"RandomCase": upper}},
+		}}},
+	}
+}
+
+// ─── profile detection ────────────────────────────────────────────────────────
+
+func TestDetectProfile(t *testing.T) {
+	profiles := scriptProfiles()
+	cases := map[string]string{
+		"certutil -urlcache":                   "certutil",
+		"CertUtil.EXE -urlcache":               "certutil",
+		`C:\Windows\System32\certutil.exe -f`:  "certutil",
+		`"C:\Program Files\x\certutil.exe" -f`: "certutil",
+		"/usr/bin/bash -c id":                  "bash",
+		"sh -c id":                             "bash",
+		"/bin/SH -c id":                        "bash",
+		"certutil-alias -x":                    "bash",
+		"curl https://x":                       "",
+		"":                                     "",
+	}
+	for cmd, want := range cases {
+		pf, ok := DetectProfile(cmd, profiles)
+		got := ""
+		if ok {
+			got = pf.Name
+		}
+		if got != want {
+			t.Errorf("DetectProfile(%q) = %q, want %q", cmd, got, want)
+		}
+	}
+}
+
+func TestExecutableName(t *testing.T) {
+	cases := map[string]string{
+		"certutil.exe":      "certutil",
+		`C:\x\RunDLL32.EXE`: "rundll32",
+		"./script.sh":       "script",
+		".exe":              ".exe",
+		"bash":              "bash",
+	}
+	for in, want := range cases {
+		if got := ExecutableName(in); got != want {
+			t.Errorf("ExecutableName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// ─── splitting ────────────────────────────────────────────────────────────────
+
+func TestSplitScript(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []scriptPart
+	}{
+		{"a\nb", []scriptPart{{"a", "\n"}, {"b", ""}}},
+		{"a && b || c", []scriptPart{{"a ", "&&"}, {" b ", "||"}, {" c", ""}}},
+		{"a; b | c", []scriptPart{{"a", ";"}, {" b ", "|"}, {" c", ""}}},
+		{`echo "a; b" 'c && d'`, []scriptPart{{`echo "a; b" 'c && d'`, ""}}},
+		{`find . -exec rm {} \; && x`, []scriptPart{{`find . -exec rm {} \; `, "&&"}, {" x", ""}}},
+		{"echo a^&b", []scriptPart{{"echo a^&b", ""}}},
+		{"a\r\nb", []scriptPart{{"a", "\r\n"}, {"b", ""}}},
+	}
+	for _, tc := range cases {
+		got := splitScript(tc.input)
+		if len(got) != len(tc.want) {
+			t.Errorf("splitScript(%q) = %q, want %q", tc.input, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("splitScript(%q)[%d] = %q, want %q", tc.input, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+// ─── end to end ───────────────────────────────────────────────────────────────
+
+func TestObfuscateScript(t *testing.T) {
+	script := "certutil -urlcache -f https://x/a out.bin\n  curl -o x https://y ;bash -c id\n"
+
+	res, err := New().ObfuscateScript(script, scriptProfiles(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "certutil -URLCACHE -F https://x/a out.bin\n  curl -o x https://y ;bash -C id\n"
+	if res.Output != want {
+		t.Errorf("Output:\n got  %q\n want %q", res.Output, want)
+	}
+
+	if len(res.Commands) != 3 {
+		t.Fatalf("got %d commands, want 3", len(res.Commands))
+	}
+	if res.Commands[0].Profile == nil || res.Commands[0].Profile.Name != "certutil" {
+		t.Errorf("command 0 should match certutil, got %+v", res.Commands[0].Profile)
+	}
+	if res.Commands[1].Profile != nil {
+		t.Errorf("curl should not match any profile")
+	}
+	if !strings.HasPrefix(res.Commands[2].Input, "bash") {
+		t.Errorf("command 2 Input = %q", res.Commands[2].Input)
+	}
+}
+
+func TestObfuscateScript_RespectsEnabled(t *testing.T) {
+	res, err := New().ObfuscateScript("certutil -f", scriptProfiles(), map[string]bool{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output != "certutil -f" {
+		t.Errorf("no modifiers enabled: Output = %q", res.Output)
+	}
+}
+
+func TestObfuscateScript_Empty(t *testing.T) {
+	if _, err := New().ObfuscateScript(" \n ", scriptProfiles(), nil); err == nil {
+		t.Error("empty script should return an error")
+	}
+}
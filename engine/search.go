@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+
+	"cmdFuscator/detect"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// ─── Evasion search ───────────────────────────────────────────────────────────
+
+// DefaultSearchAttempts is the attempt budget of a SearchBudget that sets
+// none.
+const DefaultSearchAttempts = 200
+
+// searchChunk is how many attempts SearchEvading hands ObfuscateBatch at a
+// time; small enough not to overshoot much past the winner.
+const searchChunk = 32
+
+// SearchBudget bounds a SearchEvading run.
+type SearchBudget struct {
+	// Attempts is how many variants to try at most; below 1 means
+	// DefaultSearchAttempts.
+	Attempts int
+	// Seed seeds the search's choices of seeds and modifier subsets, so the
+	// same seed makes the same attempts. Zero picks a fresh one.
+	Seed int64
+}
+
+// SearchResult is the outcome of SearchEvading.
+type SearchResult struct {
+	// Found reports whether Result evades every detector.
+	Found bool
+	// Result is the first variant that evades, or when none did, the first
+	// of those firing the fewest detectors.
+	Result ObfuscateResult
+	// Hits lists the detectors Result still fires; nil when Found.
+	Hits []detect.Detector
+	// Seed and Enabled reproduce Result as a BatchItem.
+	Seed    int64
+	Enabled map[string]bool
+	Stats   SearchStats
+}
+
+// SearchStats describes a SearchEvading run.
+type SearchStats struct {
+	Attempts int           // variants tried, up to the winner when one was found
+	Distinct int           // distinct outputs among them
+	Duration time.Duration // wall time of the whole search
+}
+
+// SearchEvading obfuscates command with pf until a variant fires none of the
+// detectors in ruleset, judged on the process-creation event detect.EventFor
+// builds, or until budget runs out. The first attempt enables every
+// modifier pf configures; later ones draw a fresh seed and a random subset
+// of them, since fewer techniques sometimes slip past rules that look for
+// traces of a specific one. Engines built with WithPipeline ignore the
+// subsets and vary only the seed.
+//
+// The error is non-nil only when no attempt could be made at all, a run
+// failed under FailFast, or ctx is done; missing the budget is reported by
+// Found. A search stopped by ctx still returns the best variant it had found,
+// with an error wrapping ctx.Err(), so a deadline bounds a search by time as
+// budget bounds it by attempts.
+func (e *Engine) SearchEvading(ctx context.Context, command string, pf *models.ProfileFile, ruleset []detect.Detector, budget SearchBudget) (SearchResult, error) {
+	if pf == nil || len(pf.Profiles) == 0 {
+		return SearchResult{}, errors.New("engine: no profiles available")
+	}
+	attempts := budget.Attempts
+	if attempts < 1 {
+		attempts = DefaultSearchAttempts
+	}
+	seed := budget.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	pool := searchPool(pickProfile(pf))
+
+	start := time.Now()
+	var best SearchResult
+	bestHits := -1
+	seen := make(map[string]bool)
+	for tried := 0; tried < attempts; {
+		items := make([]BatchItem, min(searchChunk, attempts-tried))
+		for i := range items {
+			enabled := searchSubset(nil, pool)
+			if tried+i > 0 {
+				enabled = searchSubset(rng, pool)
+			}
+			items[i] = BatchItem{Command: command, Profile: pf, Enabled: enabled, Seed: searchSeed(rng)}
+		}
+		tried += len(items)
+		for _, r := range e.ObfuscateBatch(ctx, items) {
+			if r.Err != nil {
+				if err := ctx.Err(); err != nil {
+					best.Stats.Distinct = len(seen)
+					best.Stats.Duration = time.Since(start)
+					return best, fmt.Errorf("engine: search stopped after %d attempts: %w", best.Stats.Attempts, err)
+				}
+				return SearchResult{}, r.Err
+			}
+			best.Stats.Attempts++
+			if seen[r.Result.Output] {
+				continue
+			}
+			seen[r.Result.Output] = true
+			hits := detect.Hits(ruleset, detect.EventFor(pf, r.Result.Output))
+			if bestHits >= 0 && len(hits) >= bestHits {
+				continue
+			}
+			bestHits = len(hits)
+			best.Result, best.Hits = r.Result, hits
+			best.Seed, best.Enabled = r.Seed, items[r.Index].Enabled
+			if len(hits) == 0 {
+				best.Found = true
+				break
+			}
+		}
+		if best.Found {
+			break
+		}
+	}
+	best.Stats.Distinct = len(seen)
+	best.Stats.Duration = time.Since(start)
+	return best, nil
+}
+
+// searchPool returns the modifiers p configures, in registry order so that a
+// seeded search draws the same subsets every time.
+func searchPool(p models.Profile) []string {
+	var pool []string
+	for _, mod := range modifiers.All() {
+		if _, ok := ConfigFor(p, mod.Name()); ok {
+			pool = append(pool, mod.Name())
+		}
+	}
+	return pool
+}
+
+// searchSubset draws a non-empty random subset of pool, or with a nil rng
+// returns all of it.
+func searchSubset(rng *rand.Rand, pool []string) map[string]bool {
+	picked := pool
+	if rng != nil && len(pool) > 0 {
+		idx := rng.Perm(len(pool))[:1+rng.Intn(len(pool))]
+		slices.Sort(idx)
+		picked = make([]string, len(idx))
+		for i, j := range idx {
+			picked[i] = pool[j]
+		}
+	}
+	enabled := make(map[string]bool, len(picked))
+	for _, name := range picked {
+		enabled[name] = true
+	}
+	return enabled
+}
+
+// searchSeed returns the engine seed of the next attempt; never zero, which
+// would ask ObfuscateBatch to draw its own.
+func searchSeed(rng *rand.Rand) int64 {
+	if s := rng.Int63(); s != 0 {
+		return s
+	}
+	return 1
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"cmdFuscator/detect"
+	"cmdFuscator/models"
+)
+
+// signature fires on events whose command line contains it, case and all.
+type signature string
+
+func (s signature) Name() string { return string(s) }
+
+func (s signature) Match(ev detect.Event) bool {
+	cmd, _ := ev.Get("CommandLine")
+	return strings.Contains(cmd, string(s))
+}
+
+func searchFile() *models.ProfileFile {
+	return &models.ProfileFile{
+		Name: "tool",
+		Profiles: []models.Profile{{
+			Platform: "linux",
+			Parameters: models.ProfileParameters{
+				Modifiers: map[string]json.RawMessage{
+					"RandomCase":         json.RawMessage(`{"AppliesTo":["argument"],"Probability":"0.3"}`),
+					"CharacterInsertion": json.RawMessage(`{"AppliesTo":["argument"],"Probability":"0.3","Characters":["\u200d"],"Offset":"1"}`),
+				},
+			},
+		}},
+	}
+}
+
+func TestSearchEvading(t *testing.T) {
+	ruleset := []detect.Detector{signature("--verbose"), signature("-\u200d-verbose")}
+	e := New(WithRenderTarget(TargetNone))
+	res, err := e.SearchEvading(context.Background(), "tool --verbose", searchFile(), ruleset, SearchBudget{Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Found || res.Hits != nil || res.Stats.Attempts < 1 || res.Stats.Distinct > res.Stats.Attempts {
+		t.Fatalf("SearchEvading = %+v, want an evading variant", res)
+	}
+	for _, d := range ruleset {
+		if d.Match(detect.Event{"CommandLine": res.Result.Output}) {
+			t.Errorf("%q still fires %s", res.Result.Output, d.Name())
+		}
+	}
+
+	again := e.ObfuscateBatch(context.Background(), []BatchItem{{Command: "tool --verbose", Profile: searchFile(), Enabled: res.Enabled, Seed: res.Seed}})
+	if again[0].Result.Output != res.Result.Output {
+		t.Errorf("Seed and Enabled give %q, want %q", again[0].Result.Output, res.Result.Output)
+	}
+	same, _ := e.SearchEvading(context.Background(), "tool --verbose", searchFile(), ruleset, SearchBudget{Seed: 7})
+	if same.Result.Output != res.Result.Output || same.Stats.Attempts != res.Stats.Attempts {
+		t.Errorf("the same budget seed searched differently: %q after %d, then %q after %d",
+			res.Result.Output, res.Stats.Attempts, same.Result.Output, same.Stats.Attempts)
+	}
+}
+
+func TestSearchEvadingExhausted(t *testing.T) {
+	ruleset := []detect.Detector{signature("tool"), signature("--verbose")}
+	res, err := New().SearchEvading(context.Background(), "tool --verbose", searchFile(), ruleset, SearchBudget{Attempts: 40, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Found || res.Stats.Attempts != 40 {
+		t.Fatalf("Found = %v after %d attempts, want a miss after 40", res.Found, res.Stats.Attempts)
+	}
+	// Variants changing --verbose fire only the other signature.
+	if len(res.Hits) != 1 || res.Hits[0].Name() != "tool" {
+		t.Errorf("Hits = %v, want only the unchangeable tool", res.Hits)
+	}
+
+	if _, err := New().SearchEvading(context.Background(), "tool", &models.ProfileFile{Name: "tool"}, ruleset, SearchBudget{}); err == nil {
+		t.Error("no profiles: want an error")
+	}
+}
+
+func TestSearchEvadingCancelled(t *testing.T) {
+	ruleset := []detect.Detector{signature("tool")}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := New().SearchEvading(ctx, "tool --verbose", searchFile(), ruleset, SearchBudget{Attempts: 40, Seed: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if res.Found || res.Stats.Attempts != 0 {
+		t.Errorf("Found = %v after %d attempts, want the search stopped at once", res.Found, res.Stats.Attempts)
+	}
+}
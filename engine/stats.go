@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"time"
+
+	"cmdFuscator/models"
+)
+
+// Stats records where time went during one Obfuscate run. It is only
+// collected when the engine is built with WithStats(true).
+type Stats struct {
+	Total    time.Duration // wall-clock time for the whole pipeline
+	Tokenize time.Duration
+	Render   time.Duration
+	Tokens   int // number of tokens the command parsed into
+
+	// Modifiers has one entry per modifier that was dispatched, in pipeline
+	// order, including modifiers that were skipped or failed.
+	Modifiers []ModifierStats
+}
+
+// ModifierStats is the per-modifier part of Stats.
+type ModifierStats struct {
+	Name          string
+	Duration      time.Duration
+	TokensTouched int // tokens whose type or value differs after the modifier ran
+}
+
+// SumStats aggregates the per-item Stats of a batch run into a single Stats
+// value: durations and token counts are summed, and per-modifier figures are
+// accumulated by modifier name in order of first appearance. Items without
+// Stats (collection disabled, or failed before running) are ignored.
+func SumStats(results []BatchResult) *Stats {
+	sum := &Stats{}
+	index := make(map[string]int)
+
+	for _, r := range results {
+		s := r.Result.Stats
+		if s == nil {
+			continue
+		}
+		sum.Total += s.Total
+		sum.Tokenize += s.Tokenize
+		sum.Render += s.Render
+		sum.Tokens += s.Tokens
+
+		for _, ms := range s.Modifiers {
+			i, ok := index[ms.Name]
+			if !ok {
+				i = len(sum.Modifiers)
+				index[ms.Name] = i
+				sum.Modifiers = append(sum.Modifiers, ModifierStats{Name: ms.Name})
+			}
+			sum.Modifiers[i].Duration += ms.Duration
+			sum.Modifiers[i].TokensTouched += ms.TokensTouched
+		}
+	}
+
+	return sum
+}
+
+// tokensTouched counts positions whose token differs between before and
+// after; tokens added or removed count as touched.
+func tokensTouched(before, after []models.Token) int {
+	n := min(len(before), len(after))
+	touched := max(len(before), len(after)) - n
+	for i := range n {
+		if before[i] != after[i] {
+			touched++
+		}
+	}
+	return touched
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func statsFile() *models.ProfileFile {
+	return &models.ProfileFile{
+		Name: "tool",
+		Profiles: []models.Profile{{
+			Platform: "linux",
+			Parameters: models.ProfileParameters{
+				Modifiers: map[string]json.RawMessage{
+					"RandomCase": json.RawMessage(`{"AppliesTo":["argument"],"Probability":"1.0"}`),
+					"Sed":        json.RawMessage(`{"AppliesTo":["argument"],"Probability":"1.0"}`),
+				},
+			},
+		}},
+	}
+}
+
+func TestStats_DisabledByDefault(t *testing.T) {
+	res, err := New().Obfuscate("tool -a -b", statsFile(), map[string]bool{"RandomCase": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Stats != nil {
+		t.Errorf("Stats = %+v, want nil without WithStats", res.Stats)
+	}
+}
+
+func TestStats_Collected(t *testing.T) {
+	enabled := map[string]bool{"RandomCase": true, "Sed": true}
+	res, err := New(WithStats(true)).Obfuscate("tool -a -b value", statsFile(), enabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := res.Stats
+	if s == nil {
+		t.Fatal("Stats is nil with WithStats(true)")
+	}
+	if s.Tokens != 4 {
+		t.Errorf("Tokens = %d, want 4", s.Tokens)
+	}
+	if s.Total <= 0 || s.Total < s.Tokenize+s.Render {
+		t.Errorf("Total %v inconsistent with Tokenize %v / Render %v", s.Total, s.Tokenize, s.Render)
+	}
+
+	byName := map[string]ModifierStats{}
+	for _, ms := range s.Modifiers {
+		byName[ms.Name] = ms
+	}
+	if got := byName["RandomCase"].TokensTouched; got != 2 {
+		t.Errorf("RandomCase TokensTouched = %d, want 2", got)
+	}
+	// Modifiers that did not run to completion are timed but touch nothing.
+	if ms, ok := byName["Sed"]; !ok || ms.TokensTouched != 0 {
+		t.Errorf("Sed stats = %+v, %v", ms, ok)
+	}
+}
+
+func TestSumStats(t *testing.T) {
+	pf := statsFile()
+	items := []BatchItem{
+		{Command: "tool -a", Profile: pf, Enabled: map[string]bool{"RandomCase": true}},
+		{Command: "tool -a -b", Profile: pf, Enabled: map[string]bool{"RandomCase": true}},
+		{Command: "tool", Profile: nil},
+	}
+	sum := SumStats(New(WithStats(true)).ObfuscateBatch(context.Background(), items))
+
+	if sum.Tokens != 5 {
+		t.Errorf("Tokens = %d, want 5", sum.Tokens)
+	}
+	if len(sum.Modifiers) != 1 || sum.Modifiers[0].Name != "RandomCase" {
+		t.Fatalf("Modifiers = %+v", sum.Modifiers)
+	}
+	if sum.Modifiers[0].TokensTouched != 3 {
+		t.Errorf("TokensTouched = %d, want 3", sum.Modifiers[0].TokensTouched)
+	}
+}
+
+func TestTokensTouched(t *testing.T) {
+	a := []models.Token{tok(models.TokenTypeArgument, "-a"), tok(models.TokenTypeValue, "x")}
+	cases := []struct {
+		name  string
+		after []models.Token
+		want  int
+	}{
+		{"identical", a, 0},
+		{"value changed", []models.Token{tok(models.TokenTypeArgument, "-A"), a[1]}, 1},
+		{"token added", append(append([]models.Token{}, a...), tok(models.TokenTypeValue, "y")), 1},
+		{"token removed", a[:1], 1},
+	}
+	for _, tc := range cases {
+		if got := tokensTouched(a, tc.after); got != tc.want {
+			t.Errorf("%s: tokensTouched = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"cmdFuscator/models"
+)
+
+// Tokenize parses a raw command string into a slice of typed tokens.
+//
+// Words are separated by unquoted whitespace; single- and double-quoted spans
+// are kept inside the word they belong to, quotes included, so that
+// Render(Tokenize(cmd)) reproduces cmd for single-spaced input.
+//
+// Classification rules, applied in order:
+//   - The first word is always TokenTypeCommand.
+//   - Words following a known flag with ValueCount N are TokenTypeValue.
+//   - Words matching a flag in profile.Parameters.Arguments → TokenTypeArgument.
+//   - Words starting with a URL scheme (http://, https://, …) → TokenTypeURL.
+//   - Words that look like a switch (-x, --long, /x on Windows) → TokenTypeArgument.
+//   - Words containing / or \ → TokenTypePath.
+//   - Everything else → TokenTypeValue.
+//
+// Finally, any word other than a flag that contains a command substitution
+// ($(...) or `...`) becomes TokenTypeExpansion, and a word that is entirely an
+// environment variable reference (%VAR%, $VAR, ${VAR}, $env:VAR) becomes
+// TokenTypeEnvVar. Values owed to a flag are still counted against it.
+func Tokenize(command string, profile models.Profile) ([]models.Token, error) {
+	spans := splitWordSpans(command)
+	if len(spans) == 0 {
+		return nil, errors.New("tokenize: empty command")
+	}
+
+	windows := strings.EqualFold(profile.Platform, "windows")
+	flags := knownFlags(profile.Parameters.Arguments)
+
+	tokens := make([]models.Token, len(spans))
+	tokens[0] = models.Token{Type: models.TokenTypeCommand, Value: spans[0].text, Start: spans[0].start, End: spans[0].end}
+
+	pending := 0 // values still owed to the previous flag
+	for i, sp := range spans[1:] {
+		w := sp.text
+		typ := models.TokenTypeValue
+		bare := unquote(w)
+
+		switch n, known := lookupFlag(flags, bare, windows); {
+		case pending > 0:
+			pending--
+		case known:
+			typ = models.TokenTypeArgument
+			pending = n
+		case isURL(bare):
+			typ = models.TokenTypeURL
+		case isSwitch(bare, windows):
+			typ = models.TokenTypeArgument
+		case strings.ContainsAny(bare, `/\`):
+			typ = models.TokenTypePath
+		}
+
+		if typ != models.TokenTypeArgument {
+			if st, ok := substitutionType(w); ok {
+				typ = st
+			}
+		}
+
+		tokens[i+1] = models.Token{Type: typ, Value: w, Start: sp.start, End: sp.end}
+	}
+
+	return tokens, nil
+}
+
+// splitWords splits s on unquoted whitespace. Quote characters are retained in
+// the returned words; an unterminated quote extends to the end of the input.
+// Whitespace inside a $(...) substitution does not split the word either.
+func splitWords(s string) []string {
+	spans := splitWordSpans(s)
+	words := make([]string, len(spans))
+	for i, sp := range spans {
+		words[i] = sp.text
+	}
+	return words
+}
+
+// wordSpan is one word found by splitWordSpans and its byte range in the input.
+type wordSpan struct {
+	text       string
+	start, end int
+}
+
+// splitWordSpans is splitWords with byte offsets.
+func splitWordSpans(s string) []wordSpan {
+	var (
+		spans []wordSpan
+		quote rune // the quote rune currently open, or 0
+		depth int  // nesting depth of open $( substitutions
+		prev  rune
+		start = -1 // byte offset of the current word, or -1 between words
+	)
+
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(' && (prev == '$' || depth > 0):
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case unicode.IsSpace(r) && depth == 0:
+			if start >= 0 {
+				spans = append(spans, wordSpan{text: s[start:i], start: start, end: i})
+				start = -1
+			}
+			prev = r
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		prev = r
+	}
+	if start >= 0 {
+		spans = append(spans, wordSpan{text: s[start:], start: start, end: len(s)})
+	}
+
+	return spans
+}
+
+// unquote strips every quote character from s. It is only used for
+// classification, never to alter token values.
+func unquote(s string) string {
+	return strings.NewReplacer(`"`, "", `'`, "").Replace(s)
+}
+
+// knownFlags maps every flag spelling from the profile's argument definitions
+// to the number of values it consumes.
+func knownFlags(defs []models.ArgumentDefinition) map[string]int {
+	flags := make(map[string]int)
+	for _, def := range defs {
+		for _, f := range def.Flags {
+			flags[f] = def.ValueCount
+		}
+	}
+	return flags
+}
+
+// lookupFlag reports whether word is a known flag and how many values it
+// takes. Windows executables match flags case-insensitively.
+func lookupFlag(flags map[string]int, word string, windows bool) (int, bool) {
+	if n, ok := flags[word]; ok {
+		return n, true
+	}
+	if windows {
+		for f, n := range flags {
+			if strings.EqualFold(f, word) {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// urlSchemes lists the prefixes that mark a word as a URL.
+var urlSchemes = []string{"http://", "https://", "ftp://", "ftps://", "file://", "smb://"}
+
+func isURL(word string) bool {
+	lower := strings.ToLower(word)
+	for _, scheme := range urlSchemes {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSwitch reports whether word looks like an option that is not listed in the
+// profile: "-x" / "--long" anywhere, "/x" on Windows as long as the rest of the
+// word contains no further separators (which would make it a path).
+func isSwitch(word string, windows bool) bool {
+	switch {
+	case len(word) > 1 && word[0] == '-':
+		return true
+	case windows && len(word) > 1 && word[0] == '/':
+		return !strings.ContainsAny(word[1:], `/\`)
+	}
+	return false
+}
+
+// substitutionType classifies words the shell expands before the program sees
+// them. Single-quoted words are literal in POSIX shells and PowerShell, so
+// only unquoted or double-quoted references count.
+func substitutionType(word string) (models.TokenType, bool) {
+	if strings.HasPrefix(word, "'") {
+		return "", false
+	}
+	bare := strings.Trim(word, `"`)
+	if strings.Contains(bare, "$(") || (len(bare) > 2 && bare[0] == '`' && bare[len(bare)-1] == '`') {
+		return models.TokenTypeExpansion, true
+	}
+	if isEnvVar(bare) {
+		return models.TokenTypeEnvVar, true
+	}
+	return "", false
+}
+
+// isEnvVar reports whether word is exactly one environment variable reference.
+func isEnvVar(word string) bool {
+	switch {
+	case len(word) > 2 && word[0] == '%' && word[len(word)-1] == '%':
+		return isVarName(word[1:len(word)-1], "()")
+	case len(word) > 3 && strings.HasPrefix(word, "${") && word[len(word)-1] == '}':
+		return isVarName(word[2:len(word)-1], "")
+	case len(word) > 5 && strings.HasPrefix(strings.ToLower(word), "$env:"):
+		return isVarName(word[5:], "")
+	case len(word) > 1 && word[0] == '$':
+		return isVarName(word[1:], "")
+	}
+	return false
+}
+
+// isVarName reports whether s is a non-empty variable name: letters, digits
+// and underscores, plus any runes in extra (cmd.exe allows %ProgramFiles(x86)%).
+func isVarName(s, extra string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(extra, r) {
+			return false
+		}
+	}
+	return true
+}
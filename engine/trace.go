@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"errors"
+
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/models"
+)
+
+// TraceStep records what one modifier did during an Obfuscate run. Steps are
+// only recorded when the engine is built with WithTrace(true).
+type TraceStep struct {
+	Modifier string
+	// Before and After are the full token stream, frozen tokens included,
+	// going into and coming out of the modifier. After equals Before when
+	// the modifier was skipped or failed.
+	Before []models.Token
+	After  []models.Token
+	Err    error // nil if the modifier ran; see Skipped
+}
+
+// Skipped reports whether the modifier returned ErrNotImplemented.
+func (s TraceStep) Skipped() bool {
+	return errors.Is(s.Err, modifiers.ErrNotImplemented)
+}
+
+// Changed reports whether the modifier changed any token.
+func (s TraceStep) Changed() bool {
+	return tokensTouched(s.Before, s.After) > 0
+}
+
+// Segment is the stretch of ObfuscateResult.Output one final token was
+// rendered to, with the input token it descends from, so callers can show
+// which characters the modifiers changed. Segments are only recorded when the
+// engine is built with WithTrace(true).
+type Segment struct {
+	Start, End int          // Output[Start:End]
+	Token      models.Token // the final token
+	// Original is the input token with the same span, rendered for the same
+	// target, or "" when a modifier inserted the token.
+	Original string
+}
+
+// Inserted reports whether a modifier added the token rather than changing
+// or moving an input token.
+func (s Segment) Inserted() bool {
+	return !s.Token.HasSpan()
+}
+
+// segments lays parts, the rendered words of tokens, out along the output
+// and pairs each with its input token in original, matched by span.
+func segments(parts []string, tokens, original []models.Token, target RenderTarget) []Segment {
+	type span struct{ start, end int }
+	sources := make(map[span]models.Token, len(original))
+	for _, t := range original {
+		if t.HasSpan() {
+			sources[span{t.Start, t.End}] = t
+		}
+	}
+	out := make([]Segment, len(parts))
+	pos := 0
+	for i, part := range parts {
+		seg := Segment{Start: pos, End: pos + len(part), Token: tokens[i]}
+		if src, ok := sources[span{tokens[i].Start, tokens[i].End}]; ok && tokens[i].HasSpan() {
+			seg.Original = renderParts([]models.Token{src}, target)[0]
+		}
+		out[i] = seg
+		pos = seg.End + 1
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func TestTrace_DisabledByDefault(t *testing.T) {
+	res, err := New().Obfuscate("tool -a -b", statsFile(), map[string]bool{"RandomCase": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Trace != nil {
+		t.Errorf("Trace = %+v, want nil without WithTrace", res.Trace)
+	}
+}
+
+func TestTrace_Recorded(t *testing.T) {
+	enabled := map[string]bool{"RandomCase": true, "Sed": true}
+	res, err := New(WithTrace(true), WithFrozenTypes(models.TokenTypeValue)).Obfuscate("tool -a -b value", statsFile(), enabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trace) != 2 {
+		t.Fatalf("Trace has %d steps, want 2: %+v", len(res.Trace), res.Trace)
+	}
+
+	rc := res.Trace[0]
+	if rc.Modifier != "RandomCase" || rc.Err != nil {
+		t.Fatalf("step 0 = %s (err %v), want RandomCase without error", rc.Modifier, rc.Err)
+	}
+	if !rc.Changed() {
+		t.Error("RandomCase step reports no change at probability 1.0")
+	}
+	if len(rc.Before) != 4 || len(rc.After) != 4 {
+		t.Errorf("RandomCase step has %d → %d tokens, want 4 → 4 with the frozen value", len(rc.Before), len(rc.After))
+	}
+	if got := Render(rc.Before); got != "tool -a -b value" {
+		t.Errorf("Before = %q, want the tokenized input", got)
+	}
+	if got, want := Render(rc.After), res.Output; got != want {
+		t.Errorf("After = %q, want the output %q (Sed is a stub)", got, want)
+	}
+
+	sed := res.Trace[1]
+	if sed.Modifier != "Sed" || !sed.Skipped() || sed.Changed() {
+		t.Errorf("step 1 = %s (err %v, changed %v), want Sed skipped and unchanged", sed.Modifier, sed.Err, sed.Changed())
+	}
+}
+
+func TestTrace_Segments(t *testing.T) {
+	pf := statsFile()
+	pf.Profiles[0].Platform = "windows"
+	enabled := map[string]bool{"RandomCase": true}
+	res, err := New(WithTrace(true), WithFrozenTypes(models.TokenTypeValue)).Obfuscate("tool -a a&b", pf, enabled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output != "tool -A a^&b" {
+		t.Fatalf("Output = %q", res.Output)
+	}
+	want := []struct{ out, original string }{{"tool", "tool"}, {"-A", "-a"}, {"a^&b", "a^&b"}}
+	if len(res.Segments) != len(want) {
+		t.Fatalf("Segments = %+v, want %d", res.Segments, len(want))
+	}
+	for i, seg := range res.Segments {
+		if got := res.Output[seg.Start:seg.End]; got != want[i].out || seg.Original != want[i].original || seg.Inserted() {
+			t.Errorf("segment %d = %q from %q (inserted %v), want %q from %q", i, got, seg.Original, seg.Inserted(), want[i].out, want[i].original)
+		}
+	}
+
+	if res, _ := New().Obfuscate("tool -a", pf, enabled); res.Segments != nil {
+		t.Errorf("Segments = %+v, want nil without WithTrace", res.Segments)
+	}
+}
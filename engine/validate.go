@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ─── Validation ───────────────────────────────────────────────────────────────
+
+// Validator checks a rendered command line, typically by parsing it as the
+// shell it was rendered for would. Validators run after rendering, once per
+// Obfuscate; an error does not fail the run but is recorded in
+// ObfuscateResult.Warnings. Validators should return nil for targets they do
+// not know and must be safe for concurrent use, as ObfuscateBatch calls them
+// from several goroutines.
+type Validator interface {
+	Validate(output string, target RenderTarget) error
+}
+
+// ValidatorFunc adapts a function to the Validator interface.
+type ValidatorFunc func(output string, target RenderTarget) error
+
+// Validate calls f(output, target).
+func (f ValidatorFunc) Validate(output string, target RenderTarget) error {
+	return f(output, target)
+}
+
+// validate runs the engine's validators over result's output.
+func (e *Engine) validate(result *ObfuscateResult) {
+	for _, v := range e.validators {
+		if err := v.Validate(result.Output, result.Target); err != nil {
+			result.Warnings = append(result.Warnings, err)
+		}
+	}
+}
+
+// QuoteBalance reports output that leaves a quote open under the quoting
+// rules RenderFor escapes by. The target shell would join every word after
+// such a quote into one argument, or wait for the rest of the line. It
+// returns nil for targets without quoting rules, TargetAuto and TargetNone.
+var QuoteBalance Validator = ValidatorFunc(func(output string, target RenderTarget) error {
+	d, ok := dialects[target]
+	if !ok {
+		return nil
+	}
+	runes := []rune(output)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == d.esc:
+			i++ // skip the escaped rune
+		case strings.ContainsRune(d.quotes, r):
+			end := d.closingQuote(runes, i)
+			if end < 0 {
+				return fmt.Errorf("engine: unbalanced %c quote at column %d", r, i+1)
+			}
+			i = end
+		}
+	}
+	return nil
+})
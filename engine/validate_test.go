@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithValidators(t *testing.T) {
+	var calls atomic.Int32
+	reject := ValidatorFunc(func(output string, target RenderTarget) error {
+		calls.Add(1)
+		if target != TargetBash {
+			t.Errorf("target = %v, want bash", target)
+		}
+		return errors.New("rejected " + output)
+	})
+	accept := ValidatorFunc(func(string, RenderTarget) error {
+		calls.Add(1)
+		return nil
+	})
+
+	res, err := New(WithValidators(accept, reject)).Obfuscate("tool -a", policyFile(), map[string]bool{"RandomCase": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Warnings) != 1 || res.Warnings[0].Error() != "rejected tool -A" {
+		t.Errorf("Warnings = %v, want the rejection of the output", res.Warnings)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("validators ran %d times, want 2", calls.Load())
+	}
+
+	res, _ = New().Obfuscate("tool -a", policyFile(), map[string]bool{"RandomCase": true})
+	if res.Warnings != nil {
+		t.Errorf("Warnings = %v without validators", res.Warnings)
+	}
+}
+
+func TestQuoteBalance(t *testing.T) {
+	tests := []struct {
+		output string
+		target RenderTarget
+		want   string // "" for balanced
+	}{
+		{`tool "a b" 'c'`, TargetBash, ""},
+		{`tool "a \" b"`, TargetBash, ""},
+		{`tool \"a`, TargetBash, ""},
+		{`tool 'a \' b`, TargetBash, ""},
+		{`tool it\'s 'x`, TargetBash, "unbalanced ' quote at column 12"},
+		{`tool "a`, TargetBash, `unbalanced " quote at column 6`},
+		{`tool "a" ^"b`, TargetCmd, ""},
+		{`tool "a^" b`, TargetCmd, ""},
+		{`tool "a" "b`, TargetCmd, `unbalanced " quote at column 10`},
+		{"tool \"a`\" b\" 'c'", TargetPowerShell, ""},
+		{"tool 'a", TargetPowerShell, "unbalanced ' quote at column 6"},
+		{`tool "a`, TargetNone, ""},
+		{`tool "a`, TargetAuto, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output+"/"+tt.target.String(), func(t *testing.T) {
+			err := QuoteBalance.Validate(tt.output, tt.target)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("err = %v, want nil", err)
+			case tt.want != "" && (err == nil || !strings.HasSuffix(err.Error(), tt.want)):
+				t.Errorf("err = %v, want one ending %q", err, tt.want)
+			}
+		})
+	}
+}
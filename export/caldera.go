@@ -0,0 +1,163 @@
+// Package export turns obfuscated command lines into files other tools take
+// as input: MITRE Caldera abilities, and scripts that run the commands.
+package export
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/models"
+)
+
+// ─── Caldera ──────────────────────────────────────────────────────────────────
+
+// Ability is a Caldera ability, laid out as the stockpile plugin's
+// data/abilities YAML files are.
+type Ability struct {
+	ID          string    `yaml:"id"`
+	Name        string    `yaml:"name"`
+	Description string    `yaml:"description"`
+	Tactic      string    `yaml:"tactic"`
+	Technique   Technique `yaml:"technique"`
+
+	// Platforms maps a Caldera platform (windows, linux, darwin) to its
+	// executors (cmd, psh, sh) and what each runs.
+	Platforms map[string]map[string]Executor `yaml:"platforms"`
+}
+
+// Technique is an ability's ATT&CK technique.
+type Technique struct {
+	AttackID string `yaml:"attack_id"`
+	Name     string `yaml:"name"`
+}
+
+// Executor is the command one executor runs.
+type Executor struct {
+	Command string `yaml:"command"`
+}
+
+// Variant is one obfuscated command to export, with what produced it.
+type Variant struct {
+	Profile   *models.ProfileFile
+	Original  string              // the command before obfuscation
+	Command   string              // the obfuscated command, as rendered for Target
+	Target    engine.RenderTarget // the shell Command was rendered for
+	Modifiers []string            // modifiers applied, for the description
+}
+
+// techniques names common ATT&CK techniques, the bundled profiles' among
+// them, and the tactic Caldera files each under. Others are exported under
+// their ID and the "execution" tactic; edit the YAML to refine them.
+var techniques = map[string]struct{ name, tactic string }{
+	"T1059":     {"Command and Scripting Interpreter", "execution"},
+	"T1059.001": {"Command and Scripting Interpreter: PowerShell", "execution"},
+	"T1059.003": {"Command and Scripting Interpreter: Windows Command Shell", "execution"},
+	"T1059.004": {"Command and Scripting Interpreter: Unix Shell", "execution"},
+	"T1105":     {"Ingress Tool Transfer", "command-and-control"},
+	"T1140":     {"Deobfuscate/Decode Files or Information", "defense-evasion"},
+	"T1218":     {"System Binary Proxy Execution", "defense-evasion"},
+	"T1197":     {"BITS Jobs", "defense-evasion"},
+}
+
+// calderaPlatforms maps profile platforms to Caldera's names.
+var calderaPlatforms = map[string]string{
+	"windows": "windows",
+	"linux":   "linux",
+	"macos":   "darwin",
+}
+
+// executors maps render targets to the Caldera executor that runs their
+// output.
+var executors = map[engine.RenderTarget]string{
+	engine.TargetCmd:        "cmd",
+	engine.TargetPowerShell: "psh",
+	engine.TargetBash:       "sh",
+}
+
+// CalderaAbility returns the ability running v, called name. Its ID is
+// derived from the executor and command, so exporting the same variant again
+// yields the same ability rather than a duplicate.
+func CalderaAbility(name string, v Variant) (Ability, error) {
+	var p models.Profile
+	if len(v.Profile.Profiles) > 0 {
+		p = v.Profile.Profiles[0]
+	}
+	platform, ok := calderaPlatforms[strings.ToLower(p.Platform)]
+	if !ok {
+		return Ability{}, fmt.Errorf("export: %s: Caldera has no platform for %q", v.Profile.Name, p.Platform)
+	}
+	target := v.Target
+	if target == engine.TargetAuto || target == engine.TargetNone {
+		target = engine.TargetFor(p)
+	}
+	executor, ok := executors[target]
+	if !ok {
+		return Ability{}, fmt.Errorf("export: %s: no Caldera executor for target %s", v.Profile.Name, target)
+	}
+
+	a := Ability{
+		ID:        abilityID(executor, v.Command),
+		Name:      name,
+		Tactic:    "execution",
+		Platforms: map[string]map[string]Executor{platform: {executor: {Command: v.Command}}},
+	}
+	ids := v.Profile.Techniques()
+	if len(ids) > 0 {
+		a.Technique = Technique{AttackID: ids[0], Name: ids[0]}
+		if t, ok := techniques[ids[0]]; ok {
+			a.Technique.Name, a.Tactic = t.name, t.tactic
+		}
+	}
+
+	var desc strings.Builder
+	if d := v.Profile.Description(); d != "" {
+		desc.WriteString(strings.TrimSuffix(d, ".") + ". ")
+	}
+	desc.WriteString("Obfuscated by cmdFuscator")
+	if len(v.Modifiers) > 0 {
+		desc.WriteString(" with " + strings.Join(v.Modifiers, ", "))
+	}
+	desc.WriteString(".")
+	if len(ids) > 1 {
+		desc.WriteString(" Also exercises " + strings.Join(ids[1:], ", ") + ".")
+	}
+	desc.WriteString(" Original command: " + v.Original)
+	a.Description = desc.String()
+	return a, nil
+}
+
+// WriteCaldera writes abilities to w as one YAML list, the form stockpile
+// keeps in each data/abilities file.
+func WriteCaldera(w io.Writer, abilities []Ability) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(abilities); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	return nil
+}
+
+// abilityID returns a name-based (version 5) UUID for executor running
+// command.
+func abilityID(executor, command string) string {
+	h := sha1.New()
+	h.Write(calderaNamespace[:])
+	h.Write([]byte(executor + "\x00" + command))
+	var u [16]byte
+	copy(u[:], h.Sum(nil))
+	u[6] = u[6]&0x0f | 0x50
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// calderaNamespace is the UUID namespace of ability IDs,
+// 6f1c8a3e-2b4d-4e6f-9a1b-3c5d7e9f0a2b.
+var calderaNamespace = [16]byte{0x6f, 0x1c, 0x8a, 0x3e, 0x2b, 0x4d, 0x4e, 0x6f, 0x9a, 0x1b, 0x3c, 0x5d, 0x7e, 0x9f, 0x0a, 0x2b}
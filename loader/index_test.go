@@ -0,0 +1,83 @@
+package loader
+
+import (
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func TestIndexKey(t *testing.T) {
+	cases := map[string]string{
+		"certutil":                               "certutil",
+		"CertUtil.EXE":                           "certutil",
+		`C:\Windows\System32\certutil.exe`:       "certutil",
+		`"C:\Program Files\PowerShell\pwsh.exe"`: "pwsh",
+		"/usr/bin/curl":                          "curl",
+		"script.ps1":                             "script.ps1",
+		".exe":                                   ".exe",
+		"":                                       "",
+	}
+	for in, want := range cases {
+		if got := IndexKey(in); got != want {
+			t.Errorf("IndexKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildIndex(t *testing.T) {
+	file := func(name string, aliases ...string) *models.ProfileFile {
+		return &models.ProfileFile{Name: name, Profiles: []models.Profile{{Alias: aliases}}}
+	}
+	pwsh := file("powershell", "pwsh", "PowerShell.exe")
+	core := file("pwsh-core", "PWSH")
+	bash := file("bash")
+	fake := file("fakebash", "bash.exe")
+	dup := file("BASH")
+
+	ix, conflicts := BuildIndex([]*models.ProfileFile{pwsh, core, bash, fake, dup})
+
+	lookups := map[string]*models.ProfileFile{
+		"powershell.exe":  pwsh,
+		"pwsh":            pwsh,
+		"pwsh.exe":        pwsh,
+		"pwsh-core":       core,
+		`C:\bin\bash.EXE`: bash,
+		"fakebash":        fake,
+	}
+	for name, want := range lookups {
+		if got, ok := ix.Lookup(name); !ok || got != want {
+			t.Errorf("Lookup(%q) = %v, want %s", name, got, want.Name)
+		}
+	}
+	if _, ok := ix.Lookup("zsh"); ok {
+		t.Error("unknown name found")
+	}
+
+	want := []Conflict{
+		{Key: "bash", Kind: ConflictName, Kept: bash, Dropped: dup},
+		{Key: "bash", Kind: ConflictShadowed, Kept: bash, Dropped: fake},
+		{Key: "pwsh", Kind: ConflictAlias, Kept: pwsh, Dropped: core},
+	}
+	if len(conflicts) != len(want) {
+		t.Fatalf("conflicts = %v", conflicts)
+	}
+	for i, w := range want {
+		if conflicts[i] != w {
+			t.Errorf("conflict %d = %s, want %s", i, conflicts[i], w)
+		}
+	}
+}
+
+func TestBuildIndex_Embedded(t *testing.T) {
+	profiles, err := LoadFS(embedded(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix, conflicts := BuildIndex(profiles)
+	if len(conflicts) != 0 {
+		t.Errorf("bundled profiles conflict: %v", conflicts)
+	}
+	if pf, ok := ix.Lookup(`C:\Windows\System32\certutil.exe`); !ok || pf.Name != "certutil" {
+		t.Errorf("Lookup(certutil path) = %v", pf)
+	}
+}
@@ -0,0 +1,212 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cmdFuscator/models"
+)
+
+// ─── Lazy loading ─────────────────────────────────────────────────────────────
+
+// Catalog indexes a profile tree without fully decoding it. Each file's
+// header — versions and each profile's platform, aliases, description and
+// ATT&CK tags — is read up front; the parameters, with their potentially huge
+// Characters pools, are decoded the first time Full asks for the file.
+//
+// A Catalog is safe for concurrent use.
+type Catalog struct {
+	entries []*catalogEntry
+	byStub  map[*models.ProfileFile]*catalogEntry
+}
+
+type catalogEntry struct {
+	fsys fs.FS
+	path string
+	stub *models.ProfileFile
+
+	once sync.Once
+	full *models.ProfileFile
+	err  error
+}
+
+// header is the part of a profile file the Catalog decodes eagerly. Fields
+// absent here, notably parameters, are skipped by encoding/json without being
+// allocated.
+type header struct {
+	Versions models.Versions `json:"versions"`
+	Profiles []struct {
+		ExecutableVersion      string   `json:"executableVersion"`
+		Platform               string   `json:"platform"`
+		OperatingSystem        string   `json:"operatingSystem"`
+		OperatingSystemVersion string   `json:"operatingSystemVersion"`
+		Alias                  []string `json:"alias"`
+		Description            string   `json:"description"`
+		Attack                 []string `json:"attack"`
+	} `json:"profiles"`
+}
+
+// LoadLazy indexes every profile file in fsys, as LoadFS would find them.
+// Files whose header does not parse are reported as skipped; errors in the
+// rest of a file surface from Full.
+func LoadLazy(fsys fs.FS) (*Catalog, *LoadReport, error) {
+	rep := &LoadReport{}
+	paths, err := profilePaths(fsys)
+	if err != nil {
+		return nil, rep, fmt.Errorf("loader: walk: %w", err)
+	}
+
+	c := &Catalog{byStub: make(map[*models.ProfileFile]*catalogEntry, len(paths))}
+	for _, p := range paths {
+		stub, warn, err := readHeader(fsys, p)
+		if err != nil {
+			rep.Skipped = append(rep.Skipped, FileError{File: p, Err: err})
+			continue
+		}
+		if warn != nil {
+			rep.Warnings = append(rep.Warnings, FileError{File: p, Err: warn})
+		}
+		rep.Loaded = append(rep.Loaded, p)
+		c.add(&catalogEntry{fsys: fsys, path: p, stub: stub})
+	}
+	if len(c.entries) == 0 && len(rep.Skipped) > 0 {
+		return nil, rep, fmt.Errorf("loader: all files failed: %w", rep.Skipped[0])
+	}
+	return c, rep, nil
+}
+
+// LoadLazyWithOverlay is LoadLazy with the precedence rules of
+// LoadWithOverlay: files in each of userDirs replace same-named ones loaded
+// before them or are appended.
+func LoadLazyWithOverlay(embedded fs.FS, userDirs ...string) (*Catalog, *LoadReport, error) {
+	return LoadLazyWithOverlayVerified(embedded, nil, userDirs...)
+}
+
+// LoadLazyWithOverlayVerified is LoadLazyWithOverlay with each of userDirs
+// verified as LoadWithOverlayVerified does.
+func LoadLazyWithOverlayVerified(embedded fs.FS, v *Verifier, userDirs ...string) (*Catalog, *LoadReport, error) {
+	c, rep, err := LoadLazy(embedded)
+	if err != nil {
+		return c, rep, err
+	}
+	for _, dir := range userDirs {
+		if ok, err := overlayDir(dir); err != nil {
+			return nil, rep, err
+		} else if !ok {
+			continue
+		}
+
+		fsys, err := overlayFS(dir, v)
+		if err != nil {
+			return nil, rep, fmt.Errorf("loader: overlay %s: %w", dir, err)
+		}
+		user, userRep, err := LoadLazy(fsys)
+		rep.merge(userRep, dir+string(filepath.Separator))
+		if err != nil {
+			return nil, rep, fmt.Errorf("loader: overlay %s: %w", dir, err)
+		}
+		c.overlay(user)
+	}
+	return c, rep, nil
+}
+
+// overlay replaces c's entries with same-named ones from user and appends the
+// rest.
+func (c *Catalog) overlay(user *Catalog) {
+	pos := make(map[string]int, len(c.entries))
+	for i, e := range c.entries {
+		pos[strings.ToLower(e.stub.Name)] = i
+	}
+	for _, e := range user.entries {
+		key := strings.ToLower(e.stub.Name)
+		if i, ok := pos[key]; ok {
+			delete(c.byStub, c.entries[i].stub)
+			c.entries[i] = e
+			c.byStub[e.stub] = e
+			continue
+		}
+		pos[key] = len(c.entries)
+		c.add(e)
+	}
+}
+
+func (c *Catalog) add(e *catalogEntry) {
+	c.entries = append(c.entries, e)
+	c.byStub[e.stub] = e
+}
+
+// Profiles returns a stub ProfileFile per indexed file, in load order. Stubs
+// carry Name, Versions and each profile's header fields, which is enough for
+// listing, filtering and IndexByName, but their Parameters are empty: pass a
+// stub to Full before obfuscating with it.
+func (c *Catalog) Profiles() []*models.ProfileFile {
+	out := make([]*models.ProfileFile, len(c.entries))
+	for i, e := range c.entries {
+		out[i] = e.stub
+	}
+	return out
+}
+
+// Full returns the fully decoded ProfileFile for a stub from Profiles,
+// decoding it on first use and caching the result. A ProfileFile that did not
+// come from this Catalog is returned as is.
+func (c *Catalog) Full(pf *models.ProfileFile) (*models.ProfileFile, error) {
+	e, ok := c.byStub[pf]
+	if !ok {
+		return pf, nil
+	}
+	e.once.Do(func() {
+		e.full, _, e.err = loadFile(e.fsys, e.path)
+		if e.err != nil {
+			e.err = fmt.Errorf("loader: %s: %w", e.path, e.err)
+		}
+	})
+	return e.full, e.err
+}
+
+// readHeader decodes the header of one profile file into a stub. Files in
+// an older format are decoded in full and upgraded first; they are rare.
+func readHeader(fsys fs.FS, name string) (stub *models.ProfileFile, warn, err error) {
+	data, err := readProfile(fsys, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	var h header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, nil, fmt.Errorf("parse: %w", err)
+	}
+	major, warn, err := negotiate(h.Versions.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+	if major < formatMajor {
+		pf, warn, err := decodeProfile(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		stub = &models.ProfileFile{Name: profileName(name), Versions: pf.Versions}
+		for _, p := range pf.Profiles {
+			p.Parameters = models.ProfileParameters{}
+			stub.Profiles = append(stub.Profiles, p)
+		}
+		return stub, warn, nil
+	}
+
+	stub = &models.ProfileFile{Name: profileName(name), Versions: h.Versions}
+	for _, p := range h.Profiles {
+		stub.Profiles = append(stub.Profiles, models.Profile{
+			ExecutableVersion:      p.ExecutableVersion,
+			Platform:               p.Platform,
+			OperatingSystem:        p.OperatingSystem,
+			OperatingSystemVersion: p.OperatingSystemVersion,
+			Alias:                  p.Alias,
+			Description:            p.Description,
+			Attack:                 p.Attack,
+		})
+	}
+	return stub, warn, nil
+}
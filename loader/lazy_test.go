@@ -0,0 +1,87 @@
+package loader
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadLazy(t *testing.T) {
+	fsys := treeFS()
+	fsys["broken-body.json"] = &fstest.MapFile{Data: []byte(`{"versions":{"format":"2.0"},"profiles":[{"platform":"linux","parameters":7}]}`)}
+	fsys["broken.json"] = &fstest.MapFile{Data: []byte(`{`)}
+
+	cat, rep, err := LoadLazy(fsys)
+	if err != nil {
+		t.Fatalf("LoadLazy: %v", err)
+	}
+	if len(rep.Skipped) != 1 || rep.Skipped[0].File != "broken.json" {
+		t.Errorf("Skipped = %v", rep.Skipped)
+	}
+
+	stubs := cat.Profiles()
+	idx := IndexByName(stubs)
+	curl := idx["curl"]
+	if curl == nil || curl.Profiles[0].Platform != "linux" || curl.Profiles[0].Parameters.Modifiers != nil {
+		t.Fatalf("curl stub = %+v, want header only", curl)
+	}
+
+	full, err := cat.Full(curl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full.Profiles[0].Parameters.Modifiers == nil {
+		t.Error("Full did not decode parameters")
+	}
+	if again, _ := cat.Full(curl); again != full {
+		t.Error("Full should cache the decoded file")
+	}
+	if _, err := cat.Full(idx["broken-body"]); err == nil {
+		t.Error("a parameters error should surface from Full")
+	}
+}
+
+func TestLoadLazy_Embedded(t *testing.T) {
+	cat, _, err := LoadLazy(embedded(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eager, err := LoadFS(embedded(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stubs := cat.Profiles()
+	if len(stubs) != len(eager) {
+		t.Fatalf("%d stubs, %d eager profiles", len(stubs), len(eager))
+	}
+	for i, stub := range stubs {
+		full, err := cat.Full(stub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := fmt.Sprint(full.Profiles), fmt.Sprint(eager[i].Profiles); got != want {
+			t.Errorf("%s: lazy and eager loads differ", stub.Name)
+		}
+		if stub.Description() != eager[i].Description() || fmt.Sprint(stub.Aliases()) != fmt.Sprint(eager[i].Aliases()) {
+			t.Errorf("%s: stub header differs from the full file", stub.Name)
+		}
+	}
+}
+
+func TestLoadLazyWithOverlay(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile(t, dir, "certutil.json", "macos")
+	writeProfile(t, dir, "mytool.json", "linux")
+
+	cat, _, err := LoadLazyWithOverlay(embedded(t), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := IndexByName(cat.Profiles())
+	if idx["certutil"].Profiles[0].Platform != "macos" || idx["mytool"] == nil {
+		t.Error("overlay precedence not applied")
+	}
+	if full, err := cat.Full(idx["certutil"]); err != nil || full.Profiles[0].Platform != "macos" {
+		t.Errorf("Full(certutil) = %v, %v; want the overlay file", full, err)
+	}
+}
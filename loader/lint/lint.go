@@ -0,0 +1,291 @@
+// Package lint checks loaded profiles for mistakes the JSON schema cannot
+// catch because they depend on the rest of the program: modifier names the
+// registry does not know, AppliesTo entries that are not token types,
+// probabilities the engine would reject, and flags claimed by more than one
+// argument definition. Modifiers that implement modifiers.Linter check their
+// own settings too.
+//
+// Every finding is a Diagnostic that says where the problem is and, where
+// there is an obvious fix, how to make it; Diagnostic.String formats one per
+// line for command-line tools.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"cmdFuscator/engine/modifiers"
+	_ "cmdFuscator/engine/modifiers/all"
+	"cmdFuscator/models"
+)
+
+// ─── Diagnostics ──────────────────────────────────────────────────────────────
+
+// Severity ranks a Diagnostic.
+type Severity int
+
+const (
+	// Warning: the profile works, but probably not as intended.
+	Warning Severity = iota
+	// Error: the engine will fail or silently do nothing with this setting.
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Diagnostic is one finding about a profile.
+type Diagnostic struct {
+	// File is the ProfileFile's Name, e.g. "certutil".
+	File string
+	// Profile is the index of the offending entry in the file's profiles.
+	Profile int
+	// Path locates the setting within that profile, e.g.
+	// "parameters.modifiers.RandomCase.Probability".
+	Path     string
+	Severity Severity
+	Message  string
+	// Hint suggests a fix; it may be empty.
+	Hint string
+}
+
+// String formats d as "file: profiles[n].path: severity: message (hint)".
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s: profiles[%d]", d.File, d.Profile)
+	if d.Path != "" {
+		s += "." + d.Path
+	}
+	s += fmt.Sprintf(": %s: %s", d.Severity, d.Message)
+	if d.Hint != "" {
+		s += " (" + d.Hint + ")"
+	}
+	return s
+}
+
+// HasErrors reports whether any diagnostic is an Error.
+func HasErrors(diags []Diagnostic) bool {
+	return slices.ContainsFunc(diags, func(d Diagnostic) bool { return d.Severity == Error })
+}
+
+// ─── Lint ─────────────────────────────────────────────────────────────────────
+
+// Lint checks every profile in profiles and returns the diagnostics in file,
+// profile and modifier order. A nil result means nothing was found.
+func Lint(profiles []*models.ProfileFile) []Diagnostic {
+	var out []Diagnostic
+	for _, pf := range profiles {
+		for i := range pf.Profiles {
+			l := linter{file: pf.Name, index: i}
+			l.profile(&pf.Profiles[i])
+			out = append(out, l.diags...)
+		}
+	}
+	return out
+}
+
+// linter collects the diagnostics for one profile.
+type linter struct {
+	file  string
+	index int
+	diags []Diagnostic
+}
+
+func (l *linter) report(sev Severity, path, hint, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{
+		File:     l.file,
+		Profile:  l.index,
+		Path:     path,
+		Severity: sev,
+		Message:  fmt.Sprintf(format, args...),
+		Hint:     hint,
+	})
+}
+
+func (l *linter) profile(p *models.Profile) {
+	for _, name := range p.Parameters.ModifierNames() {
+		l.modifier(name, p.Parameters.Modifiers[name], p)
+	}
+	l.arguments(p.Parameters.Arguments, strings.EqualFold(p.Platform, "windows"))
+}
+
+// ─── Modifiers ────────────────────────────────────────────────────────────────
+
+func (l *linter) modifier(name string, raw json.RawMessage, p *models.Profile) {
+	at := "parameters.modifiers." + name
+	m, ok := modifiers.Get(name)
+	if !ok {
+		l.report(Error, at, suggest(name, registered()), "unknown modifier %q", name)
+	}
+
+	base, err := modifiers.ParseConfig(raw)
+	if err != nil {
+		l.report(Error, at, "", "config does not parse: %v", err)
+		return
+	}
+	l.appliesTo(at+".AppliesTo", base.AppliesTo)
+	l.probability(at+".Probability", base.Probability)
+
+	if lm, ok := m.(modifiers.Linter); ok {
+		for _, f := range lm.Lint(raw, l.file, p) {
+			path, sev := at, Warning
+			if f.Path != "" {
+				path += "." + f.Path
+			}
+			if f.Error {
+				sev = Error
+			}
+			l.report(sev, path, f.Hint, "%s", f.Message)
+		}
+	}
+}
+
+// tokenTypes are the values AppliesTo may contain.
+var tokenTypes = []string{
+	string(models.TokenTypeCommand),
+	string(models.TokenTypeArgument),
+	string(models.TokenTypeValue),
+	string(models.TokenTypePath),
+	string(models.TokenTypeURL),
+	string(models.TokenTypeEnvVar),
+	string(models.TokenTypeExpansion),
+}
+
+func (l *linter) appliesTo(at string, types []string) {
+	if len(types) == 0 {
+		l.report(Warning, at, "list the token types it should rewrite, e.g. [\"argument\"]",
+			"applies to no token types, so the modifier never fires")
+		return
+	}
+	seen := make(map[string]bool, len(types))
+	for _, t := range types {
+		switch {
+		case seen[t]:
+			l.report(Warning, at, "", "%q is listed more than once", t)
+		case !slices.Contains(tokenTypes, t):
+			l.report(Error, at, suggest(t, tokenTypes), "%q is not a token type", t)
+		}
+		seen[t] = true
+	}
+}
+
+func (l *linter) probability(at string, v models.ProbabilityValue) {
+	if strings.TrimSpace(string(v)) == "" {
+		l.report(Error, at, `set it to a number between 0 and 1, e.g. "0.5"`, "missing probability")
+		return
+	}
+	p, err := v.Float()
+	switch {
+	case err != nil || math.IsNaN(p) || math.IsInf(p, 0):
+		l.report(Error, at, `set it to a number between 0 and 1, e.g. "0.5"`, "probability %q is not a number", v)
+	case p < 0 || p > 1:
+		hint := "probabilities are fractions between 0 and 1"
+		if p > 1 && p <= 100 {
+			hint = fmt.Sprintf("for %g%% write %g", p, p/100)
+		}
+		l.report(Error, at, hint, "probability %s is outside [0, 1]", v)
+	case p == 0:
+		l.report(Warning, at, "remove the modifier or raise the probability", "probability 0 means the modifier never fires")
+	}
+}
+
+// ─── Arguments ────────────────────────────────────────────────────────────────
+
+// arguments reports flags that more than one ArgumentDefinition claims. The
+// tokenizer keeps the last definition's ValueCount, so a disagreement changes
+// how the following words are classified. Windows flags match
+// case-insensitively, so "/F" and "/f" collide there.
+func (l *linter) arguments(defs []models.ArgumentDefinition, windows bool) {
+	type claim struct {
+		def   int
+		flag  string
+		count int
+	}
+	first := make(map[string]claim)
+	for i, def := range defs {
+		for _, f := range def.Flags {
+			key := f
+			if windows {
+				key = strings.ToLower(f)
+			}
+			prev, ok := first[key]
+			if !ok {
+				first[key] = claim{def: i, flag: f, count: def.ValueCount}
+				continue
+			}
+			at := fmt.Sprintf("parameters.arguments[%d].flags", i)
+			switch {
+			case prev.def == i:
+				l.report(Warning, at, "remove the repeated spelling", "flag %q is listed twice", f)
+			case prev.count != def.ValueCount:
+				l.report(Error, at, fmt.Sprintf("merge it into arguments[%d] or remove it", prev.def),
+					"flag %q is also defined by arguments[%d] (%q) with valueCount %d, not %d",
+					f, prev.def, prev.flag, prev.count, def.ValueCount)
+			default:
+				l.report(Warning, at, fmt.Sprintf("merge it into arguments[%d] or remove it", prev.def),
+					"flag %q is also defined by arguments[%d] (%q)", f, prev.def, prev.flag)
+			}
+		}
+	}
+}
+
+// ─── Suggestions ──────────────────────────────────────────────────────────────
+
+func registered() []string {
+	all := modifiers.All()
+	names := make([]string, len(all))
+	for i, m := range all {
+		names[i] = m.Name()
+	}
+	sort.Strings(names)
+	return names
+}
+
+// suggest returns a "did you mean" hint for word among known, or a list of
+// the valid values when nothing is close.
+func suggest(word string, known []string) string {
+	best, bestDist := "", math.MaxInt
+	for _, k := range known {
+		if strings.EqualFold(k, word) {
+			return fmt.Sprintf("did you mean %q?", k)
+		}
+		if d := distance(strings.ToLower(word), strings.ToLower(k)); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	if best != "" && bestDist <= max(2, len(word)/4) {
+		return fmt.Sprintf("did you mean %q?", best)
+	}
+	return "expected one of " + strings.Join(known, ", ")
+}
+
+// distance is the Levenshtein distance between a and b, by bytes.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,269 @@
+package lint
+
+import (
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"cmdFuscator/data"
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+// profile builds a single-profile ProfileFile named "tool" from the JSON of
+// its parameters.
+func profile(t *testing.T, platform, params string) *models.ProfileFile {
+	t.Helper()
+	var pf models.ProfileFile
+	doc := `{"versions":{"format":"2.0"},"profiles":[{"platform":"` + platform + `","parameters":` + params + `}]}`
+	if err := json.Unmarshal([]byte(doc), &pf); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	pf.Name = "tool"
+	return &pf
+}
+
+func TestLintEmbeddedProfilesClean(t *testing.T) {
+	sub, err := fs.Sub(data.ModelFS, "models")
+	if err != nil {
+		t.Fatalf("fs.Sub: %v", err)
+	}
+	profiles, err := loader.LoadFS(sub)
+	if err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	for _, d := range Lint(profiles) {
+		t.Errorf("embedded profile: %s", d)
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform string
+		params   string
+		// want is one "severity path: message substring | hint substring"
+		// per expected diagnostic, in order.
+		want []string
+	}{
+		{
+			name:   "clean",
+			params: `{"modifiers":{"RandomCase":{"AppliesTo":["argument"],"Probability":"0.5"}}}`,
+		},
+		{
+			name:   "unknown modifier with suggestion",
+			params: `{"modifiers":{"RandomCsae":{"AppliesTo":["argument"],"Probability":"0.5"}}}`,
+			want:   []string{`error parameters.modifiers.RandomCsae: unknown modifier | did you mean "RandomCase"?`},
+		},
+		{
+			name:   "unknown modifier far from any",
+			params: `{"modifiers":{"Teleport":{"AppliesTo":["argument"],"Probability":"0.5"}}}`,
+			want:   []string{`error parameters.modifiers.Teleport: unknown modifier | expected one of BidiInsertion, CharacterInsertion,`},
+		},
+		{
+			name:   "bad token types",
+			params: `{"modifiers":{"RandomCase":{"AppliesTo":["argumnet","Value","argumnet"],"Probability":1}}}`,
+			want: []string{
+				`error parameters.modifiers.RandomCase.AppliesTo: "argumnet" is not a token type | did you mean "argument"?`,
+				`error parameters.modifiers.RandomCase.AppliesTo: "Value" is not a token type | did you mean "value"?`,
+				`warning parameters.modifiers.RandomCase.AppliesTo: listed more than once |`,
+			},
+		},
+		{
+			name:   "no token types",
+			params: `{"modifiers":{"RandomCase":{"AppliesTo":[],"Probability":"0.5"}}}`,
+			want:   []string{`warning parameters.modifiers.RandomCase.AppliesTo: never fires | list the token types`},
+		},
+		{
+			name:   "percentage probability",
+			params: `{"modifiers":{"RandomCase":{"AppliesTo":["argument"],"Probability":"50"}}}`,
+			want:   []string{`error parameters.modifiers.RandomCase.Probability: outside [0, 1] | for 50% write 0.5`},
+		},
+		{
+			name:   "negative probability",
+			params: `{"modifiers":{"RandomCase":{"AppliesTo":["argument"],"Probability":-0.1}}}`,
+			want:   []string{`error parameters.modifiers.RandomCase.Probability: outside [0, 1] | fractions between 0 and 1`},
+		},
+		{
+			name:   "non-numeric probability",
+			params: `{"modifiers":{"RandomCase":{"AppliesTo":["argument"],"Probability":"often"}}}`,
+			want:   []string{`error parameters.modifiers.RandomCase.Probability: "often" is not a number | e.g. "0.5"`},
+		},
+		{
+			name:   "missing probability",
+			params: `{"modifiers":{"RandomCase":{"AppliesTo":["argument"]}}}`,
+			want:   []string{`error parameters.modifiers.RandomCase.Probability: missing probability |`},
+		},
+		{
+			name:   "zero probability",
+			params: `{"modifiers":{"RandomCase":{"AppliesTo":["argument"],"Probability":"0"}}}`,
+			want:   []string{`warning parameters.modifiers.RandomCase.Probability: never fires | remove the modifier`},
+		},
+		{
+			name:   "empty characters pool",
+			params: `{"modifiers":{"CharacterInsertion":{"AppliesTo":["argument"],"Probability":"0.5","Characters":[],"Offset":"1"}}}`,
+			want:   []string{`error parameters.modifiers.CharacterInsertion.Characters: pool is empty | add at least one character`},
+		},
+		{
+			name:   "empty option chars and blank entry",
+			params: `{"modifiers":{"OptionCharSubstitution":{"AppliesTo":["argument"],"Probability":"0.5","OutputOptionChars":["/",""]}}}`,
+			want:   []string{`warning parameters.modifiers.OptionCharSubstitution.OutputOptionChars[1]: empty string | remove the entry`},
+		},
+		{
+			name:   "bidi character not a control",
+			params: `{"modifiers":{"BidiInsertion":{"AppliesTo":["path"],"Probability":"0.5","Characters":["\u202e","\u200b"]}}}`,
+			want:   []string{`error parameters.modifiers.BidiInsertion.Characters[1]: "\u200b" is not a bidi control character`},
+		},
+		{
+			name:   "diacritic not a combining mark",
+			params: `{"modifiers":{"DiacriticInsertion":{"AppliesTo":["argument"],"Probability":"0.5","Characters":["\u0307","e"]}}}`,
+			want:   []string{`error parameters.modifiers.DiacriticInsertion.Characters[1]: "e" is not a combining mark`},
+		},
+		{
+			name:   "nothing to duplicate",
+			params: `{"arguments":[{"flags":["-f"]}],"modifiers":{"DuplicateFlags":{"AppliesTo":["argument"],"Probability":"0.5"}}}`,
+			want:   []string{`warning parameters.modifiers.DuplicateFlags: no argument is repeatable | "repeatable": true`},
+		},
+		{
+			name:   "no number forms",
+			params: `{"arguments":[{"flags":["-p"],"valueCount":1}],"modifiers":{"NumericMangling":{"AppliesTo":["value"],"Probability":"0.5"}}}`,
+			want:   []string{`warning parameters.modifiers.NumericMangling: no argument has number forms | "numberForms"`},
+		},
+		{
+			name:   "concatenation outside PowerShell",
+			params: `{"modifiers":{"PowerShellConcat":{"AppliesTo":["value"],"Probability":"0.5"}}}`,
+			want:   []string{`warning parameters.modifiers.PowerShellConcat: "tool" is not PowerShell | powershell or pwsh profile`},
+		},
+		{
+			name:   "substring expansion outside Windows",
+			params: `{"modifiers":{"SubstringExpansion":{"AppliesTo":["command"],"Probability":"0.5","Table":{"e":["COMSPEC:-1"]}}}}`,
+			want:   []string{`warning parameters.modifiers.SubstringExpansion: "linux" is not cmd.exe's platform | Windows profile`},
+		},
+		{
+			name:     "substring table with a metacharacter",
+			platform: "windows",
+			params:   `{"modifiers":{"SubstringExpansion":{"AppliesTo":["command"],"Probability":"0.5","Table":{"&":["COMSPEC:-1"]}}}}`,
+			want:     []string{`error parameters.modifiers.SubstringExpansion: table: "&" cannot be replaced`},
+		},
+		{
+			name:     "single quotes on windows",
+			platform: "windows",
+			params:   `{"modifiers":{"QuoteSplitting":{"AppliesTo":["value"],"Probability":"0.5","Quotes":["\"","'"]}}}`,
+			want:     []string{`warning parameters.modifiers.QuoteSplitting.Quotes: cmd.exe passes ' through | use only "\""`},
+		},
+		{
+			name:   "single quotes on linux",
+			params: `{"modifiers":{"QuoteSplitting":{"AppliesTo":["value"],"Probability":"0.5","Quotes":["'"]}}}`,
+		},
+		{
+			name:     "absolute path without paths",
+			platform: "windows",
+			params:   `{"modifiers":{"ExecutableForm":{"AppliesTo":["command"],"Probability":"0.5","AbsolutePath":"0.5"}}}`,
+			want:     []string{`warning parameters.modifiers.ExecutableForm.AbsolutePath: no path in the profile names "tool" | list where tool is installed in "paths"`},
+		},
+		{
+			name:   "extension outside Windows",
+			params: `{"modifiers":{"ExecutableForm":{"AppliesTo":["command"],"Probability":"0.5","Extension":"0.5"}}}`,
+			want:   []string{`warning parameters.modifiers.ExecutableForm.Extension: "linux" executables have no .exe |`},
+		},
+		{
+			name:     "dot segment without paths",
+			platform: "windows",
+			params:   `{"modifiers":{"ExecutableForm":{"AppliesTo":["command"],"Probability":"0.5","DotPrefix":"0.5"}}}`,
+			want:     []string{`warning parameters.modifiers.ExecutableForm.DotPrefix: only fires on commands naming a directory | list where tool is installed`},
+		},
+		{
+			name:   "script does not compile",
+			params: `{"modifiers":{"Script":{"AppliesTo":["argument"],"Probability":"0.5","Script":"tokens = ["}}}`,
+			want:   []string{`error parameters.modifiers.Script.Script: script: compile:`},
+		},
+		{
+			name:   "script missing",
+			params: `{"modifiers":{"Script":{"AppliesTo":["argument"],"Probability":"0.5"}}}`,
+			want:   []string{`error parameters.modifiers.Script.Script: script: no Script in config`},
+		},
+		{
+			name:   "config not an object",
+			params: `{"modifiers":{"RandomCase":"yes"}}`,
+			want:   []string{`error parameters.modifiers.RandomCase: does not parse |`},
+		},
+		{
+			name:   "duplicate flag with different value count",
+			params: `{"arguments":[{"flags":["-f","--file"],"valueCount":1},{"flags":["-f"],"valueCount":0}]}`,
+			want:   []string{`error parameters.arguments[1].flags: also defined by arguments[0] ("-f") with valueCount 1, not 0 | merge it into arguments[0]`},
+		},
+		{
+			name:   "duplicate flag with same value count",
+			params: `{"arguments":[{"flags":["-v"],"valueCount":0},{"flags":["-v","--verbose"],"valueCount":0}]}`,
+			want:   []string{`warning parameters.arguments[1].flags: also defined by arguments[0] | merge it into arguments[0]`},
+		},
+		{
+			name:   "flag repeated within a definition",
+			params: `{"arguments":[{"flags":["-v","-v"],"valueCount":0}]}`,
+			want:   []string{`warning parameters.arguments[0].flags: listed twice | remove the repeated spelling`},
+		},
+		{
+			name:   "case differs on linux",
+			params: `{"arguments":[{"flags":["-f"],"valueCount":1},{"flags":["-F"],"valueCount":0}]}`,
+		},
+		{
+			name:     "case differs on windows",
+			platform: "windows",
+			params:   `{"arguments":[{"flags":["/f"],"valueCount":1},{"flags":["/F"],"valueCount":0}]}`,
+			want:     []string{`error parameters.arguments[1].flags: "/F" is also defined by arguments[0] ("/f") | merge it`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platform := tt.platform
+			if platform == "" {
+				platform = "linux"
+			}
+			got := Lint([]*models.ProfileFile{profile(t, platform, tt.params)})
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d diagnostics, want %d:\n%s", len(got), len(tt.want), join(got))
+			}
+			for i, w := range tt.want {
+				head, hint, _ := strings.Cut(w, "|")
+				head, hint = strings.TrimSpace(head), strings.TrimSpace(hint)
+				sev, rest, _ := strings.Cut(head, " ")
+				at, msg, _ := strings.Cut(rest, ": ")
+				d := got[i]
+				if d.File != "tool" || d.Profile != 0 {
+					t.Errorf("[%d] location = %s/%d, want tool/0", i, d.File, d.Profile)
+				}
+				if d.Severity.String() != sev || d.Path != at || !strings.Contains(d.Message, msg) || !strings.Contains(d.Hint, hint) {
+					t.Errorf("[%d] got  %s\nwant %s", i, d, w)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{File: "certutil", Profile: 1, Path: "parameters.modifiers.Sedd", Severity: Error,
+		Message: `unknown modifier "Sedd"`, Hint: `did you mean "Sed"?`}
+	want := `certutil: profiles[1].parameters.modifiers.Sedd: error: unknown modifier "Sedd" (did you mean "Sed"?)`
+	if got := d.String(); got != want {
+		t.Errorf("String() = %s\nwant       %s", got, want)
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	if HasErrors([]Diagnostic{{Severity: Warning}}) {
+		t.Error("HasErrors(warnings only) = true")
+	}
+	if !HasErrors([]Diagnostic{{Severity: Warning}, {Severity: Error}}) {
+		t.Error("HasErrors(with error) = false")
+	}
+}
+
+func join(ds []Diagnostic) string {
+	var b strings.Builder
+	for _, d := range ds {
+		b.WriteString(d.String() + "\n")
+	}
+	return b.String()
+}
@@ -7,71 +7,286 @@
 package loader
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
+	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"cmdFuscator/models"
 )
 
-// LoadFS reads every *.json file from the provided fs.FS and returns a slice of
-// parsed ProfileFiles. The Name field of each ProfileFile is set to the base
-// filename without the .json extension (e.g. "certutil").
+// LoadFS reads every profile file from the provided fs.FS, including those in
+// subdirectories (e.g. windows/certutil.json), and returns a slice of parsed
+// ProfileFiles. Profile files are *.json, or *.yaml and *.yml with the same
+// structure. The Name field of each ProfileFile is set to the base filename
+// without its extension (e.g. "certutil"). Directories whose names start with
+// "." are not entered.
 //
-// Files that fail to parse are skipped and their errors are collected; a non-nil
-// error is returned only when no files could be loaded at all.
+// Files that fail to parse are skipped; a non-nil error is returned only when
+// no files could be loaded at all. Use LoadFSReport to find out which files
+// were skipped.
 func LoadFS(fsys fs.FS) ([]*models.ProfileFile, error) {
-	entries, err := fs.Glob(fsys, "*.json")
-	if err != nil {
-		return nil, fmt.Errorf("loader: glob: %w", err)
+	profiles, _, err := LoadFSReport(fsys)
+	return profiles, err
+}
+
+// LoadFSReport is LoadFS, also returning a LoadReport naming every file that
+// was loaded, skipped or loaded with a format-version warning. The report is
+// non-nil even when the error is.
+func LoadFSReport(fsys fs.FS) ([]*models.ProfileFile, *LoadReport, error) {
+	return LoadFiltered(fsys, LoadOptions{})
+}
+
+// LoadOptions selects which profiles LoadFiltered loads from a large tree.
+// The zero value loads everything.
+type LoadOptions struct {
+	// Platforms keeps only profiles for these platforms ("windows", "linux",
+	// "macos"), compared case-insensitively. A file's profiles for other
+	// platforms are dropped, and files left with none are not returned.
+	Platforms []string
+
+	// NameGlob keeps only files whose name (without extension) matches this
+	// path.Match pattern, case-insensitively, e.g. "cert*". Non-matching
+	// files are not read at all.
+	NameGlob string
+}
+
+// LoadFiltered is LoadFSReport restricted by opts. Files excluded by a filter
+// appear in neither Loaded nor Skipped.
+func LoadFiltered(fsys fs.FS, opts LoadOptions) ([]*models.ProfileFile, *LoadReport, error) {
+	rep := &LoadReport{}
+	glob := strings.ToLower(opts.NameGlob)
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, rep, fmt.Errorf("loader: name glob %q: %w", opts.NameGlob, err)
 	}
 
-	var (
-		profiles []*models.ProfileFile
-		errs     []string
-	)
+	entries, err := profilePaths(fsys)
+	if err != nil {
+		return nil, rep, fmt.Errorf("loader: walk: %w", err)
+	}
 
+	var profiles []*models.ProfileFile
 	for _, entry := range entries {
-		pf, err := loadFile(fsys, entry)
+		if glob != "" {
+			if ok, _ := path.Match(glob, strings.ToLower(profileName(entry))); !ok {
+				continue
+			}
+		}
+		pf, warn, err := loadFile(fsys, entry)
 		if err != nil {
-			errs = append(errs, fmt.Sprintf("%s: %v", entry, err))
+			rep.Skipped = append(rep.Skipped, FileError{File: entry, Err: err})
 			continue
 		}
+		if len(opts.Platforms) > 0 {
+			if pf = filterPlatforms(pf, opts.Platforms); pf == nil {
+				continue
+			}
+		}
+		if warn != nil {
+			rep.Warnings = append(rep.Warnings, FileError{File: entry, Err: warn})
+		}
+		rep.Loaded = append(rep.Loaded, entry)
 		profiles = append(profiles, pf)
 	}
 
-	if len(profiles) == 0 && len(errs) > 0 {
-		return nil, fmt.Errorf("loader: all files failed:\n%s", strings.Join(errs, "\n"))
+	if len(profiles) == 0 && len(rep.Skipped) > 0 {
+		errs := make([]string, len(rep.Skipped))
+		for i, e := range rep.Skipped {
+			errs[i] = e.Error()
+		}
+		return nil, rep, fmt.Errorf("loader: all files failed:\n%s", strings.Join(errs, "\n"))
 	}
 
-	return profiles, nil
+	return profiles, rep, nil
 }
 
-// loadFile reads and parses a single JSON profile file from fsys.
-func loadFile(fsys fs.FS, name string) (*models.ProfileFile, error) {
-	data, err := fs.ReadFile(fsys, name)
+// profilePaths returns the profile files in fsys, recursively, in lexical
+// order, skipping hidden directories.
+func profilePaths(fsys fs.FS) ([]string, error) {
+	var out []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if isProfileFile(p) {
+			out = append(out, p)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// filterPlatforms returns pf with only the profiles for platforms, or nil if
+// none match. pf is not modified.
+func filterPlatforms(pf *models.ProfileFile, platforms []string) *models.ProfileFile {
+	var keep []models.Profile
+	for _, p := range pf.Profiles {
+		if slices.ContainsFunc(platforms, func(want string) bool { return strings.EqualFold(want, p.Platform) }) {
+			keep = append(keep, p)
+		}
+	}
+	if len(keep) == 0 {
+		return nil
+	}
+	out := *pf
+	out.Profiles = keep
+	return &out
+}
+
+// LoadWithOverlay loads the profiles in embedded and then overlays the
+// profile files found in each of userDirs, in order, on top of them.
+//
+// Precedence is by file name, compared case-insensitively: a user file named
+// like an embedded one (e.g. certutil.json) replaces the embedded ProfileFile
+// in its position, and user files with new names are appended after the
+// embedded set. Replacement is whole-file; copy the embedded file and edit it
+// to change a single probability. A later directory wins over an earlier one
+// by the same rules.
+//
+// Empty entries of userDirs, and directories that do not exist, are ignored.
+// Parse failures follow the LoadFS rules for each source separately.
+func LoadWithOverlay(embedded fs.FS, userDirs ...string) ([]*models.ProfileFile, error) {
+	profiles, _, err := LoadWithOverlayReport(embedded, userDirs...)
+	return profiles, err
+}
+
+// LoadWithOverlayReport is LoadWithOverlay, also returning a LoadReport for
+// every source. Overlay file names in the report are joined with their
+// directory so users can tell them from embedded ones.
+func LoadWithOverlayReport(embedded fs.FS, userDirs ...string) ([]*models.ProfileFile, *LoadReport, error) {
+	return LoadWithOverlayVerified(embedded, nil, userDirs...)
+}
+
+// LoadWithOverlayVerified is LoadWithOverlayReport with each of userDirs
+// passed through Verified with v, so its own manifest must list its files.
+// A directory whose manifest is missing or fails v is an error. A nil v
+// verifies nothing.
+func LoadWithOverlayVerified(embedded fs.FS, v *Verifier, userDirs ...string) ([]*models.ProfileFile, *LoadReport, error) {
+	profiles, rep, err := LoadFSReport(embedded)
 	if err != nil {
-		return nil, fmt.Errorf("read: %w", err)
+		return nil, rep, err
 	}
+	for _, dir := range userDirs {
+		if ok, err := overlayDir(dir); err != nil {
+			return nil, rep, err
+		} else if !ok {
+			continue
+		}
 
-	var pf models.ProfileFile
-	if err := json.Unmarshal(data, &pf); err != nil {
-		return nil, fmt.Errorf("parse: %w", err)
+		fsys, err := overlayFS(dir, v)
+		if err != nil {
+			return nil, rep, fmt.Errorf("loader: overlay %s: %w", dir, err)
+		}
+		user, userRep, err := LoadFSReport(fsys)
+		rep.merge(userRep, dir+string(filepath.Separator))
+		if err != nil {
+			return nil, rep, fmt.Errorf("loader: overlay %s: %w", dir, err)
+		}
+
+		// MergeReplace cannot fail.
+		profiles, _ = Merge(profiles, user, MergeReplace)
+	}
+	return profiles, rep, nil
+}
+
+// overlayDir reports whether dir should be overlaid: false for "" and for a
+// directory that does not exist, an error when dir is not a directory.
+func overlayDir(dir string) (bool, error) {
+	if dir == "" {
+		return false, nil
+	}
+	info, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("loader: overlay: %w", err)
+	case !info.IsDir():
+		return false, fmt.Errorf("loader: overlay: %s is not a directory", dir)
+	}
+	return true, nil
+}
+
+// overlayFS returns the files of the overlay directory dir, verified with v
+// unless v is nil.
+func overlayFS(dir string, v *Verifier) (fs.FS, error) {
+	fsys := os.DirFS(dir)
+	if v == nil {
+		return fsys, nil
+	}
+	return Verified(fsys, *v)
+}
+
+// DefaultUserDir returns the conventional overlay directory,
+// <user config dir>/cmdfuscator/models (e.g. ~/.config/cmdfuscator/models on
+// Linux), or "" when the platform has no user config directory.
+func DefaultUserDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cmdfuscator", "models")
+}
+
+// loadFile reads and parses a single JSON or YAML profile file from fsys,
+// upgrading older format versions. warn is non-nil when the file loaded but
+// is not exactly models.FormatVersion.
+func loadFile(fsys fs.FS, name string) (pf *models.ProfileFile, warn, err error) {
+	data, err := readProfile(fsys, name)
+	if err != nil {
+		return nil, nil, err
 	}
+	if pf, warn, err = decodeProfile(data); err != nil {
+		return nil, nil, err
+	}
+	pf.Name = profileName(name)
+	return pf, warn, nil
+}
 
-	// Derive the executable name from the filename (strip directory + extension).
-	base := filepath.Base(name)
-	pf.Name = strings.TrimSuffix(base, filepath.Ext(base))
+// readProfile reads a profile file as JSON, converting YAML files.
+func readProfile(fsys fs.FS, name string) ([]byte, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	if isYAML(name) {
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("parse: %w", err)
+		}
+	}
+	return data, nil
+}
 
-	return &pf, nil
+// profileName derives the executable name from a profile's path by stripping
+// the directory and extension: "windows/certutil.json" → "certutil".
+func profileName(name string) string {
+	base := path.Base(name)
+	return strings.TrimSuffix(base, path.Ext(base))
 }
 
 // IndexByName returns a map from executable name (lowercase) to its ProfileFile.
-// When multiple profiles share the same name the last one wins.
+// Each profile's Alias entries are indexed too, so "pwsh" finds powershell.
+// When multiple profiles share the same name the last one wins; a real name
+// always takes precedence over another file's alias.
+// BuildIndex additionally matches basenames such as "certutil.exe" and
+// reports conflicts instead of resolving them silently.
 func IndexByName(profiles []*models.ProfileFile) map[string]*models.ProfileFile {
 	idx := make(map[string]*models.ProfileFile, len(profiles))
+	for _, pf := range profiles {
+		for _, alias := range pf.Aliases() {
+			idx[strings.ToLower(alias)] = pf
+		}
+	}
 	for _, pf := range profiles {
 		idx[strings.ToLower(pf.Name)] = pf
 	}
@@ -101,3 +316,17 @@ func GroupByPlatform(profiles []*models.ProfileFile) map[string][]*models.Profil
 	}
 	return groups
 }
+
+// GroupByTechnique maps each MITRE ATT&CK technique ID to the ProfileFiles
+// tagged with it, for detection-coverage reporting. Files appear once per
+// technique, in input order; untagged files are omitted. Sub-techniques are
+// kept as written ("T1059.001" and "T1059" are separate keys).
+func GroupByTechnique(profiles []*models.ProfileFile) map[string][]*models.ProfileFile {
+	groups := make(map[string][]*models.ProfileFile)
+	for _, pf := range profiles {
+		for _, id := range pf.Techniques() {
+			groups[id] = append(groups[id], pf)
+		}
+	}
+	return groups
+}
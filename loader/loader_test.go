@@ -0,0 +1,348 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"cmdFuscator/data"
+	"cmdFuscator/models"
+)
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func embedded(t *testing.T) fs.FS {
+	t.Helper()
+	sub, err := fs.Sub(data.ModelFS, "models")
+	if err != nil {
+		t.Fatalf("fs.Sub: %v", err)
+	}
+	return sub
+}
+
+// ─── LoadFS ───────────────────────────────────────────────────────────────────
+
+// Every bundled profile must parse; this catches JSON schema drift early.
+func TestLoadFS_Embedded(t *testing.T) {
+	profiles, err := LoadFS(embedded(t))
+	if err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	if len(profiles) == 0 {
+		t.Fatal("no embedded profiles loaded")
+	}
+	for _, pf := range profiles {
+		if pf.Name == "" {
+			t.Error("profile file with empty Name")
+		}
+		if len(pf.Profiles) == 0 {
+			t.Errorf("%s: no profiles", pf.Name)
+		}
+	}
+}
+
+// ─── IndexByName ──────────────────────────────────────────────────────────────
+
+func TestIndexByName_Aliases(t *testing.T) {
+	pwsh := &models.ProfileFile{Name: "powershell", Profiles: []models.Profile{{Alias: []string{"PWSH", "bash"}}}}
+	bash := &models.ProfileFile{Name: "bash", Profiles: []models.Profile{{}}}
+
+	idx := IndexByName([]*models.ProfileFile{pwsh, bash})
+
+	if idx["powershell"] != pwsh {
+		t.Error("name lookup failed")
+	}
+	if idx["pwsh"] != pwsh {
+		t.Error("alias lookup should be case-insensitive")
+	}
+	if idx["bash"] != bash {
+		t.Error("a real name must take precedence over another file's alias")
+	}
+}
+
+func TestIndexByName_EmbeddedAlias(t *testing.T) {
+	profiles, err := LoadFS(embedded(t))
+	if err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	pf, ok := IndexByName(profiles)["pwsh"]
+	if !ok || pf.Name != "powershell" {
+		t.Errorf(`IndexByName()["pwsh"] = %v, want the powershell profile`, pf)
+	}
+}
+
+// ─── GroupByTechnique ─────────────────────────────────────────────────────────
+
+func TestGroupByTechnique(t *testing.T) {
+	a := &models.ProfileFile{Name: "a", Profiles: []models.Profile{{Attack: []string{"T1105", "t1140"}}, {Attack: []string{"T1105"}}}}
+	b := &models.ProfileFile{Name: "b", Profiles: []models.Profile{{Attack: []string{"T1105"}}}}
+	c := &models.ProfileFile{Name: "c", Profiles: []models.Profile{{}}}
+
+	groups := GroupByTechnique([]*models.ProfileFile{a, b, c})
+	if len(groups) != 2 {
+		t.Fatalf("got %d techniques, want 2: %v", len(groups), groups)
+	}
+	if got := groups["T1105"]; len(got) != 2 || got[0] != a || got[1] != b {
+		t.Errorf("T1105 = %v, want [a b]", got)
+	}
+	if got := groups["T1140"]; len(got) != 1 || got[0] != a {
+		t.Errorf("T1140 = %v, want [a]", got)
+	}
+}
+
+func TestGroupByTechnique_Embedded(t *testing.T) {
+	profiles, err := LoadFS(embedded(t))
+	if err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	if got := GroupByTechnique(profiles)["T1105"]; len(got) != 1 || got[0].Name != "certutil" {
+		t.Errorf("T1105 = %v, want certutil", got)
+	}
+}
+
+// ─── LoadWithOverlay ──────────────────────────────────────────────────────────
+
+const overlayProfile = `{"versions":{"argfuscator":"2.0","format":"2.0"},"profiles":[{"platform":"%s","parameters":{"modifiers":{}}}]}`
+
+func writeProfile(t *testing.T, dir, name, platform string) {
+	t.Helper()
+	body := fmt.Sprintf(overlayProfile, platform)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadWithOverlay(t *testing.T) {
+	base, err := LoadFS(embedded(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeProfile(t, dir, "CertUtil.json", "macos") // overrides, case-insensitively
+	writeProfile(t, dir, "mytool.json", "linux")   // new
+
+	profiles, err := LoadWithOverlay(embedded(t), dir)
+	if err != nil {
+		t.Fatalf("LoadWithOverlay: %v", err)
+	}
+	if len(profiles) != len(base)+1 {
+		t.Fatalf("got %d profiles, want %d", len(profiles), len(base)+1)
+	}
+
+	for i, pf := range base {
+		got := profiles[i]
+		if !strings.EqualFold(got.Name, pf.Name) {
+			t.Errorf("position %d: got %s, want %s", i, got.Name, pf.Name)
+		}
+		if strings.EqualFold(pf.Name, "certutil") && got.Profiles[0].Platform != "macos" {
+			t.Error("user certutil.json did not replace the embedded one")
+		}
+	}
+	if last := profiles[len(profiles)-1]; last.Name != "mytool" {
+		t.Errorf("last profile = %s, want mytool", last.Name)
+	}
+}
+
+func TestLoadWithOverlay_SeveralDirs(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writeProfile(t, first, "mytool.json", "linux")
+	writeProfile(t, first, "other.json", "linux")
+	writeProfile(t, second, "MyTool.json", "macos") // later directory wins
+
+	profiles, err := LoadWithOverlay(embedded(t), first, "", second)
+	if err != nil {
+		t.Fatalf("LoadWithOverlay: %v", err)
+	}
+	byName := make(map[string]string)
+	for _, pf := range profiles {
+		byName[strings.ToLower(pf.Name)] = pf.Profiles[0].Platform
+	}
+	if got := byName["mytool"]; got != "macos" {
+		t.Errorf("mytool platform = %q, want macos from the second directory", got)
+	}
+	if _, ok := byName["other"]; !ok {
+		t.Error("profile only in the first directory is missing")
+	}
+}
+
+func TestLoadWithOverlay_MissingDir(t *testing.T) {
+	for _, dir := range []string{"", filepath.Join(t.TempDir(), "absent")} {
+		profiles, err := LoadWithOverlay(embedded(t), dir)
+		if err != nil || len(profiles) == 0 {
+			t.Errorf("dir %q: got %d profiles, err %v; want embedded set", dir, len(profiles), err)
+		}
+	}
+}
+
+func TestLoadWithOverlay_NotADir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWithOverlay(embedded(t), file); err == nil {
+		t.Error("a file as overlay dir should be an error")
+	}
+}
+
+// ─── LoadReport ───────────────────────────────────────────────────────────────
+
+func TestLoadFSReport(t *testing.T) {
+	fsys := fstest.MapFS{
+		"good.json":   {Data: []byte(fmt.Sprintf(overlayProfile, "linux"))},
+		"old.json":    {Data: []byte(`{"versions":{"format":"1.0"},"profiles":[{"platform":"linux"}]}`)},
+		"broken.json": {Data: []byte(`{`)},
+		"notes.txt":   {Data: []byte(`ignored`)},
+	}
+
+	profiles, rep, err := LoadFSReport(fsys)
+	if err != nil {
+		t.Fatalf("LoadFSReport: %v", err)
+	}
+	if len(profiles) != 2 || strings.Join(rep.Loaded, ",") != "good.json,old.json" {
+		t.Errorf("Loaded = %v (%d profiles)", rep.Loaded, len(profiles))
+	}
+	if len(rep.Skipped) != 1 || rep.Skipped[0].File != "broken.json" {
+		t.Errorf("Skipped = %v", rep.Skipped)
+	}
+	if len(rep.Warnings) != 1 || rep.Warnings[0].File != "old.json" {
+		t.Errorf("Warnings = %v", rep.Warnings)
+	}
+	if rep.OK() {
+		t.Error("report with problems should not be OK")
+	}
+	if got := rep.Summary(); !strings.Contains(got, "1 rejected") || !strings.Contains(got, "broken.json") {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+func TestLoadFSReport_AllFailed(t *testing.T) {
+	_, rep, err := LoadFSReport(fstest.MapFS{"broken.json": {Data: []byte(`{`)}})
+	if err == nil {
+		t.Fatal("expected an error when no file loads")
+	}
+	if rep == nil || len(rep.Skipped) != 1 {
+		t.Errorf("report = %+v, want the failed file listed", rep)
+	}
+}
+
+func TestLoadWithOverlayReport(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile(t, dir, "mytool.json", "linux")
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, rep, err := LoadWithOverlayReport(embedded(t), dir)
+	if err != nil {
+		t.Fatalf("LoadWithOverlayReport: %v", err)
+	}
+	if len(rep.Skipped) != 1 || rep.Skipped[0].File != filepath.Join(dir, "bad.json") {
+		t.Errorf("Skipped = %v, want the overlay path", rep.Skipped)
+	}
+	if len(rep.Warnings) != 0 {
+		t.Errorf("embedded profiles raised warnings: %v", rep.Warnings)
+	}
+}
+
+func TestLoadWithOverlayVerified(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile(t, dir, "mytool.json", "linux")
+	v := &Verifier{}
+	if _, _, err := LoadWithOverlayVerified(embedded(t), v, dir); err == nil {
+		t.Error("an overlay without a manifest should be an error")
+	}
+
+	manifest, err := Manifest(os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, DefaultManifest), manifest, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeProfile(t, dir, "unlisted.json", "linux")
+	profiles, rep, err := LoadWithOverlayVerified(embedded(t), v, dir)
+	if err != nil {
+		t.Fatalf("LoadWithOverlayVerified: %v", err)
+	}
+	if last := profiles[len(profiles)-1]; last.Name != "mytool" {
+		t.Errorf("last profile = %s, want mytool", last.Name)
+	}
+	if len(rep.Skipped) != 1 || !errors.Is(rep.Skipped[0].Err, ErrNotInManifest) {
+		t.Errorf("Skipped = %v, want unlisted.json as not in the manifest", rep.Skipped)
+	}
+}
+
+// ─── LoadFiltered ─────────────────────────────────────────────────────────────
+
+func treeFS() fstest.MapFS {
+	multi := `{"versions":{"format":"2.0"},"profiles":[` +
+		`{"platform":"windows","parameters":{"modifiers":{}}},` +
+		`{"platform":"linux","parameters":{"modifiers":{}}}]}`
+	return fstest.MapFS{
+		"windows/certutil.json":  {Data: []byte(fmt.Sprintf(overlayProfile, "windows"))},
+		"windows/bitsadmin.json": {Data: []byte(fmt.Sprintf(overlayProfile, "windows"))},
+		"linux/curl.json":        {Data: []byte(fmt.Sprintf(overlayProfile, "linux"))},
+		"python.json":            {Data: []byte(multi)},
+		".cache/stale.json":      {Data: []byte(fmt.Sprintf(overlayProfile, "linux"))},
+	}
+}
+
+func TestLoadFS_Recursive(t *testing.T) {
+	profiles, rep, err := LoadFSReport(treeFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, pf := range profiles {
+		got = append(got, pf.Name)
+	}
+	if strings.Join(got, ",") != "curl,python,bitsadmin,certutil" {
+		t.Errorf("names = %v", got)
+	}
+	if rep.Loaded[0] != "linux/curl.json" {
+		t.Errorf("report should use full paths, got %v", rep.Loaded)
+	}
+}
+
+func TestLoadFiltered(t *testing.T) {
+	cases := []struct {
+		name string
+		opts LoadOptions
+		want string
+	}{
+		{"platform", LoadOptions{Platforms: []string{"Linux"}}, "curl,python"},
+		{"glob", LoadOptions{NameGlob: "C*"}, "curl,certutil"},
+		{"both", LoadOptions{Platforms: []string{"windows"}, NameGlob: "*t*"}, "python,bitsadmin,certutil"},
+		{"none", LoadOptions{NameGlob: "zzz"}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			profiles, _, err := LoadFiltered(treeFS(), tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, pf := range profiles {
+				got = append(got, pf.Name)
+				for _, p := range pf.Profiles {
+					if len(tc.opts.Platforms) > 0 && !strings.EqualFold(p.Platform, tc.opts.Platforms[0]) {
+						t.Errorf("%s kept a %s profile", pf.Name, p.Platform)
+					}
+				}
+			}
+			if strings.Join(got, ",") != tc.want {
+				t.Errorf("got %v, want %s", got, tc.want)
+			}
+		})
+	}
+
+	if _, _, err := LoadFiltered(treeFS(), LoadOptions{NameGlob: "["}); err == nil {
+		t.Error("a malformed glob should be an error")
+	}
+}
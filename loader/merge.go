@@ -0,0 +1,198 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cmdFuscator/models"
+)
+
+// ─── Merging sources ──────────────────────────────────────────────────────────
+
+// MergePolicy decides how Merge combines two ProfileFiles with the same name.
+type MergePolicy int
+
+const (
+	// MergeReplace keeps the src file and drops the dst one. This is what
+	// LoadWithOverlay does.
+	MergeReplace MergePolicy = iota
+
+	// MergeAppendProfiles keeps the dst file and appends src's profiles to
+	// it, e.g. to add a macOS profile to a file that only has Windows.
+	MergeAppendProfiles
+
+	// MergeModifiers merges each src profile into the first dst profile for
+	// the same platform, appending it when there is none. Modifier configs
+	// are merged field by field (nested objects recursively), so a src
+	// profile that only sets {"RandomCase": {"Probability": "0.9"}} changes
+	// that one value. Other non-empty src fields — header strings, alias and
+	// attack lists, the command template and arguments — replace dst's.
+	MergeModifiers
+)
+
+func (p MergePolicy) String() string {
+	switch p {
+	case MergeReplace:
+		return "replace"
+	case MergeAppendProfiles:
+		return "append-profiles"
+	case MergeModifiers:
+		return "merge-modifiers"
+	}
+	return fmt.Sprintf("MergePolicy(%d)", int(p))
+}
+
+// Merge combines two profile sets, such as embedded and remote, or remote and
+// a user overlay, where src takes precedence. Files are matched by Name,
+// case-insensitively: a matched file keeps dst's position and is combined
+// according to policy; unmatched src files are appended in src order. The
+// result is deterministic for given inputs, and neither input is modified.
+//
+// The error reports a modifier config that is not a JSON object and so
+// cannot be merged under MergeModifiers.
+func Merge(dst, src []*models.ProfileFile, policy MergePolicy) ([]*models.ProfileFile, error) {
+	out := append([]*models.ProfileFile(nil), dst...)
+	pos := make(map[string]int, len(out))
+	for i, pf := range out {
+		pos[strings.ToLower(pf.Name)] = i
+	}
+
+	for _, pf := range src {
+		key := strings.ToLower(pf.Name)
+		i, ok := pos[key]
+		if !ok {
+			pos[key] = len(out)
+			out = append(out, pf)
+			continue
+		}
+		merged, err := mergeFile(out[i], pf, policy)
+		if err != nil {
+			return nil, fmt.Errorf("loader: merge %s: %w", pf.Name, err)
+		}
+		out[i] = merged
+	}
+	return out, nil
+}
+
+func mergeFile(dst, src *models.ProfileFile, policy MergePolicy) (*models.ProfileFile, error) {
+	switch policy {
+	case MergeReplace:
+		return src, nil
+	case MergeAppendProfiles:
+		out := *dst
+		out.Profiles = append(append([]models.Profile(nil), dst.Profiles...), src.Profiles...)
+		return &out, nil
+	case MergeModifiers:
+		out := *dst
+		out.Profiles = append([]models.Profile(nil), dst.Profiles...)
+		for _, sp := range src.Profiles {
+			i := profileFor(out.Profiles, sp.Platform)
+			if i < 0 {
+				out.Profiles = append(out.Profiles, sp)
+				continue
+			}
+			merged, err := mergeProfile(out.Profiles[i], sp)
+			if err != nil {
+				return nil, err
+			}
+			out.Profiles[i] = merged
+		}
+		return &out, nil
+	}
+	return nil, fmt.Errorf("unknown merge policy %v", policy)
+}
+
+func profileFor(profiles []models.Profile, platform string) int {
+	for i, p := range profiles {
+		if strings.EqualFold(p.Platform, platform) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeProfile overlays src onto dst as described for MergeModifiers.
+func mergeProfile(dst, src models.Profile) (models.Profile, error) {
+	override := func(d *string, s string) {
+		if s != "" {
+			*d = s
+		}
+	}
+	out := dst
+	override(&out.ExecutableVersion, src.ExecutableVersion)
+	override(&out.OperatingSystem, src.OperatingSystem)
+	override(&out.OperatingSystemVersion, src.OperatingSystemVersion)
+	override(&out.Description, src.Description)
+	if len(src.Alias) > 0 {
+		out.Alias = src.Alias
+	}
+	if len(src.Attack) > 0 {
+		out.Attack = src.Attack
+	}
+
+	// Rebuild the parameters so dst's modifier map and order stay untouched.
+	dp, sp := dst.Parameters, src.Parameters
+	params := models.ProfileParameters{Command: dp.Command, Arguments: dp.Arguments}
+	if len(sp.Command) > 0 {
+		params.Command = sp.Command
+	}
+	if len(sp.Arguments) > 0 {
+		params.Arguments = sp.Arguments
+	}
+	for _, name := range dp.ModifierNames() {
+		params.SetModifier(name, dp.Modifiers[name])
+	}
+	for _, name := range sp.ModifierNames() {
+		cfg := sp.Modifiers[name]
+		if base, ok := dp.Modifiers[name]; ok {
+			var err error
+			if cfg, err = mergeConfig(base, cfg); err != nil {
+				return out, fmt.Errorf("modifier %s: %w", name, err)
+			}
+		}
+		params.SetModifier(name, cfg)
+	}
+	out.Parameters = params
+	return out, nil
+}
+
+// mergeConfig merges two JSON objects, keeping dst's key order and appending
+// keys only src has.
+func mergeConfig(dst, src json.RawMessage) (json.RawMessage, error) {
+	d, err := parseTree(dst)
+	if err != nil {
+		return nil, err
+	}
+	s, err := parseTree(src)
+	if err != nil {
+		return nil, err
+	}
+	if d.kind != kindObject || s.kind != kindObject {
+		return nil, fmt.Errorf("config is not a JSON object")
+	}
+	var buf bytes.Buffer
+	mergeNodes(d, s).appendJSON(&buf)
+	return buf.Bytes(), nil
+}
+
+func mergeNodes(d, s *node) *node {
+	if d.kind != kindObject || s.kind != kindObject {
+		return s
+	}
+	out := &node{kind: kindObject, fields: make(map[string]*node, len(d.keys)+len(s.keys))}
+	for _, k := range d.keys {
+		out.keys = append(out.keys, k)
+		out.fields[k] = d.fields[k]
+	}
+	for _, k := range s.keys {
+		if prev, ok := out.fields[k]; ok {
+			out.fields[k] = mergeNodes(prev, s.fields[k])
+			continue
+		}
+		out.keys = append(out.keys, k)
+		out.fields[k] = s.fields[k]
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package loader
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func mergeFixture() (dst, src []*models.ProfileFile) {
+	var win, lin, winOverride models.Profile
+	win.Platform, win.Description = "windows", "base"
+	win.Parameters.SetModifier("RandomCase", json.RawMessage(`{"AppliesTo":["argument"],"Probability":"0.5"}`))
+	win.Parameters.SetModifier("Sed", json.RawMessage(`{"AppliesTo":["value"],"Probability":"0.5","SedStatements":"s/a/b/"}`))
+	lin.Platform = "linux"
+	winOverride.Platform = "Windows"
+	winOverride.Parameters.SetModifier("RandomCase", json.RawMessage(`{"Probability":"0.9","Extra":{"x":1}}`))
+	winOverride.Parameters.SetModifier("QuoteInsertion", json.RawMessage(`{"AppliesTo":["path"],"Probability":"1"}`))
+
+	dst = []*models.ProfileFile{
+		{Name: "certutil", Profiles: []models.Profile{win}},
+		{Name: "bash", Profiles: []models.Profile{lin}},
+	}
+	src = []*models.ProfileFile{
+		{Name: "CertUtil", Profiles: []models.Profile{winOverride, {Platform: "macos"}}},
+		{Name: "mytool", Profiles: []models.Profile{lin}},
+	}
+	return dst, src
+}
+
+func names(pfs []*models.ProfileFile) []string {
+	var out []string
+	for _, pf := range pfs {
+		out = append(out, pf.Name)
+	}
+	return out
+}
+
+func TestMerge_Replace(t *testing.T) {
+	dst, src := mergeFixture()
+	out, err := Merge(dst, src, MergeReplace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(out); !slices.Equal(got, []string{"CertUtil", "bash", "mytool"}) {
+		t.Errorf("names = %v", got)
+	}
+	if out[0] != src[0] || dst[0].Name != "certutil" {
+		t.Error("replace should take src and leave dst alone")
+	}
+}
+
+func TestMerge_AppendProfiles(t *testing.T) {
+	dst, src := mergeFixture()
+	out, err := Merge(dst, src, MergeAppendProfiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(out[0].Profiles); n != 3 || out[0].Name != "certutil" {
+		t.Errorf("certutil has %d profiles (name %q), want 3", n, out[0].Name)
+	}
+	if len(dst[0].Profiles) != 1 {
+		t.Error("dst was modified")
+	}
+}
+
+func TestMerge_Modifiers(t *testing.T) {
+	dst, src := mergeFixture()
+	out, err := Merge(dst, src, MergeModifiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cu := out[0]
+	if len(cu.Profiles) != 2 || cu.Profiles[1].Platform != "macos" {
+		t.Fatalf("profiles = %+v, want windows merged and macos appended", cu.Profiles)
+	}
+	win := cu.Profiles[0]
+	if win.Description != "base" {
+		t.Error("empty src fields must not clear dst ones")
+	}
+	params := win.Parameters
+	if got := params.ModifierNames(); !slices.Equal(got, []string{"RandomCase", "Sed", "QuoteInsertion"}) {
+		t.Errorf("modifier order = %v", got)
+	}
+	if got := string(params.Modifiers["RandomCase"]); got != `{"AppliesTo":["argument"],"Probability":"0.9","Extra":{"x":1}}` {
+		t.Errorf("RandomCase = %s", got)
+	}
+	if got := dst[0].Profiles[0].Parameters; len(got.Modifiers) != 2 || string(got.Modifiers["RandomCase"]) != `{"AppliesTo":["argument"],"Probability":"0.5"}` {
+		t.Errorf("dst was modified: %v", got.Modifiers)
+	}
+
+	// Deterministic: the same inputs give byte-identical configs.
+	again, _ := Merge(dst, src, MergeModifiers)
+	if string(again[0].Profiles[0].Parameters.Modifiers["RandomCase"]) != string(params.Modifiers["RandomCase"]) {
+		t.Error("merge is not deterministic")
+	}
+}
+
+func TestMerge_ModifiersNotObject(t *testing.T) {
+	dst, src := mergeFixture()
+	src[0].Profiles[0].Parameters.SetModifier("Sed", json.RawMessage(`true`))
+	if _, err := Merge(dst, src, MergeModifiers); err == nil {
+		t.Error("merging a non-object config should fail")
+	}
+}
@@ -0,0 +1,505 @@
+// Package remote keeps a local copy of the upstream ArgFuscator.net profile
+// set so the binary does not go stale as new executables are added upstream.
+//
+// Profiles are listed through the GitHub contents API, downloaded over HTTPS
+// and cached on disk. The cache is reused for a TTL; after that the listing is
+// revalidated with its ETag and only files whose git SHA changed are fetched
+// again. When the network is unavailable the stale cache is used, and when
+// there is no cache either the caller's fallback (normally the embedded set)
+// is loaded instead.
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cmdFuscator/loader"
+	"cmdFuscator/models"
+)
+
+// Defaults for the upstream source and cache policy.
+const (
+	DefaultAPIBase = "https://api.github.com"
+	DefaultRepo    = "wietze/ArgFuscator.net"
+	DefaultPath    = "models"
+	DefaultRef     = "main"
+	DefaultTTL     = 24 * time.Hour
+)
+
+// maxFileSize bounds a single downloaded profile; upstream files are a few KB.
+const maxFileSize = 4 << 20
+
+// metaFile is the cache bookkeeping file, kept next to the cached profiles.
+// Its name does not end in .json so loader.LoadFS never mistakes it for one.
+const metaFile = ".remote-meta"
+
+// ─── Fetcher ──────────────────────────────────────────────────────────────────
+
+// Fetcher downloads and caches upstream profiles. Create one with New; it is
+// safe to reuse but not for concurrent Load calls on the same cache directory.
+type Fetcher struct {
+	client   *http.Client
+	apiBase  string
+	repo     string
+	path     string
+	ref      string
+	cacheDir string
+	ttl      time.Duration
+	fallback fs.FS
+	verifier *loader.Verifier
+	now      func() time.Time
+}
+
+// Option configures a Fetcher.
+type Option func(*Fetcher)
+
+// WithClient sets the HTTP client; the default is a client with a 30s timeout.
+func WithClient(c *http.Client) Option {
+	return func(f *Fetcher) { f.client = c }
+}
+
+// WithSource points the fetcher at a different GitHub API host, repository
+// ("owner/name"), directory and git ref. Empty arguments keep the default.
+func WithSource(apiBase, repo, dir, ref string) Option {
+	return func(f *Fetcher) {
+		if apiBase != "" {
+			f.apiBase = strings.TrimRight(apiBase, "/")
+		}
+		if repo != "" {
+			f.repo = repo
+		}
+		if dir != "" {
+			f.path = strings.Trim(dir, "/")
+		}
+		if ref != "" {
+			f.ref = ref
+		}
+	}
+}
+
+// WithTTL sets how long a cache is used before it is revalidated. Zero
+// revalidates on every Load.
+func WithTTL(ttl time.Duration) Option {
+	return func(f *Fetcher) { f.ttl = ttl }
+}
+
+// WithFallback sets the profiles loaded when the network and cache both fail,
+// typically the embedded set (fs.Sub(data.ModelFS, "models")).
+func WithFallback(fsys fs.FS) Option {
+	return func(f *Fetcher) { f.fallback = fsys }
+}
+
+// WithVerifier requires the cached profiles to pass v (see loader.Verified)
+// before Load returns them. The manifest, and its .minisig signature when
+// v.PublicKey is set, are synced from the same upstream directory, and its
+// entries name files by their path under that directory. A cache
+// that fails verification, even for one file, is not used: Load falls back as
+// if upstream were unreachable.
+func WithVerifier(v loader.Verifier) Option {
+	return func(f *Fetcher) { f.verifier = &v }
+}
+
+// New returns a Fetcher caching into cacheDir (see DefaultCacheDir).
+func New(cacheDir string, opts ...Option) *Fetcher {
+	f := &Fetcher{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		apiBase:  DefaultAPIBase,
+		repo:     DefaultRepo,
+		path:     DefaultPath,
+		ref:      DefaultRef,
+		cacheDir: cacheDir,
+		ttl:      DefaultTTL,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// DefaultCacheDir returns <user cache dir>/cmdfuscator/remote-models, or ""
+// when the platform has no user cache directory.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cmdfuscator", "remote-models")
+}
+
+// ─── Loading ──────────────────────────────────────────────────────────────────
+
+// Source says where the profiles returned by Load came from.
+type Source int
+
+const (
+	SourceCache    Source = iota // fresh cache, no network access needed
+	SourceRemote                 // cache refreshed from upstream during this Load
+	SourceStale                  // upstream unreachable; cache older than the TTL used
+	SourceFallback               // upstream unreachable and no cache; fallback used
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceCache:
+		return "cache"
+	case SourceRemote:
+		return "remote"
+	case SourceStale:
+		return "stale cache"
+	case SourceFallback:
+		return "fallback"
+	}
+	return fmt.Sprintf("Source(%d)", int(s))
+}
+
+// Report describes a Load.
+type Report struct {
+	Source  Source
+	Fetched time.Time // when upstream was last checked successfully
+	SyncErr error     // why refreshing failed, for SourceStale and SourceFallback
+}
+
+// Load returns the upstream profiles, refreshing the cache first when it is
+// older than the TTL. Network problems are not fatal: they are recorded in
+// Report.SyncErr and the stale cache or fallback is returned. An error is only
+// returned when no profiles could be loaded from any source.
+func (f *Fetcher) Load(ctx context.Context) ([]*models.ProfileFile, Report, error) {
+	_, profiles, rep, err := f.load(ctx)
+	return profiles, rep, err
+}
+
+// FS is Load for callers that read the profiles themselves, to load them
+// lazily or lay overlays over them (see loader.LoadLazyWithOverlay): it
+// returns the file system Load would have loaded them from, the cache,
+// verified when a verifier is configured, or the fallback.
+func (f *Fetcher) FS(ctx context.Context) (fs.FS, Report, error) {
+	fsys, _, rep, err := f.load(ctx)
+	return fsys, rep, err
+}
+
+// load is Load, also returning the file system the profiles came from.
+func (f *Fetcher) load(ctx context.Context) (fs.FS, []*models.ProfileFile, Report, error) {
+	meta := f.readMeta()
+	rep := Report{Source: SourceCache, Fetched: meta.Fetched}
+
+	if !f.fresh(meta) {
+		if err := f.sync(ctx, &meta); err != nil {
+			rep.SyncErr = err
+			rep.Source = SourceStale
+		} else {
+			rep.Source = SourceRemote
+			rep.Fetched = meta.Fetched
+		}
+	}
+
+	if len(meta.Files) > 0 {
+		fsys, profiles, err := f.loadCache()
+		if err == nil && len(profiles) > 0 {
+			return fsys, profiles, rep, nil
+		}
+		if rep.SyncErr == nil {
+			rep.SyncErr = errors.New("remote: cached profiles missing or unreadable")
+			if err != nil {
+				rep.SyncErr = fmt.Errorf("remote: cache: %w", err)
+			}
+		}
+	}
+
+	if f.fallback == nil {
+		if rep.SyncErr == nil {
+			return nil, nil, rep, errors.New("remote: no profiles available upstream")
+		}
+		return nil, nil, rep, fmt.Errorf("remote: no profiles available: %w", rep.SyncErr)
+	}
+	rep.Source = SourceFallback
+	profiles, err := loader.LoadFS(f.fallback)
+	if err != nil {
+		return nil, nil, rep, fmt.Errorf("remote: fallback: %w", err)
+	}
+	return f.fallback, profiles, rep, nil
+}
+
+// loadCache loads the cached profiles, verifying them first when a verifier
+// is configured, and returns them with the file system they came from.
+func (f *Fetcher) loadCache() (fs.FS, []*models.ProfileFile, error) {
+	fsys := os.DirFS(f.cacheDir)
+	if f.verifier == nil {
+		profiles, err := loader.LoadFS(fsys)
+		return fsys, profiles, err
+	}
+	vfs, err := loader.Verified(fsys, *f.verifier)
+	if err != nil {
+		return nil, nil, err
+	}
+	profiles, lrep, err := loader.LoadFSReport(vfs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(lrep.Skipped) > 0 {
+		return nil, nil, fmt.Errorf("verification: %w", lrep.Skipped[0])
+	}
+	return vfs, profiles, nil
+}
+
+// Sync refreshes the cache from upstream regardless of the TTL.
+func (f *Fetcher) Sync(ctx context.Context) error {
+	meta := f.readMeta()
+	return f.sync(ctx, &meta)
+}
+
+func (f *Fetcher) fresh(m cacheMeta) bool {
+	return len(m.Files) > 0 && f.ttl > 0 && f.now().Sub(m.Fetched) < f.ttl
+}
+
+// ─── Sync ─────────────────────────────────────────────────────────────────────
+
+// cacheMeta is persisted in metaFile.
+type cacheMeta struct {
+	Fetched time.Time         `json:"fetched"`
+	ETag    string            `json:"etag"`  // of the directory listing
+	Files   map[string]string `json:"files"` // path under the cache → git blob SHA
+}
+
+// entry is the subset of a GitHub contents API item that is used.
+type entry struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Type        string `json:"type"` // "file" or "dir"
+	SHA         string `json:"sha"`
+	DownloadURL string `json:"download_url"`
+}
+
+func (f *Fetcher) sync(ctx context.Context, meta *cacheMeta) error {
+	if f.cacheDir == "" {
+		return errors.New("remote: no cache directory")
+	}
+	if err := os.MkdirAll(f.cacheDir, 0o755); err != nil {
+		return fmt.Errorf("remote: %w", err)
+	}
+
+	entries, etag, err := f.list(ctx, f.path, meta.ETag)
+	if err != nil {
+		return err
+	}
+	if entries == nil { // 304: listing unchanged
+		meta.Fetched = f.now()
+		return f.writeMeta(*meta)
+	}
+
+	files := make(map[string]string)
+	for _, e := range entries {
+		name, ok := f.relative(e.Path)
+		if e.Type != "file" || !ok || !f.wanted(name) {
+			continue
+		}
+		if sha, ok := meta.Files[name]; ok && sha == e.SHA && f.cached(name) {
+			files[name] = sha
+			continue
+		}
+		if err := f.download(ctx, e.DownloadURL, name); err != nil {
+			return err
+		}
+		files[name] = e.SHA
+	}
+
+	// Drop files that disappeared upstream.
+	for name := range meta.Files {
+		if _, ok := files[name]; !ok && fs.ValidPath(name) {
+			os.Remove(filepath.Join(f.cacheDir, filepath.FromSlash(name)))
+		}
+	}
+
+	*meta = cacheMeta{Fetched: f.now(), ETag: etag, Files: files}
+	return f.writeMeta(*meta)
+}
+
+// relative returns p, a path in the upstream repository, relative to the
+// fetcher's directory, which is where it goes in the cache. Paths outside
+// the directory, or that would escape the cache, are not ok.
+func (f *Fetcher) relative(p string) (string, bool) {
+	name := p
+	if f.path != "" {
+		var ok bool
+		if name, ok = strings.CutPrefix(p, f.path+"/"); !ok {
+			return "", false
+		}
+	}
+	if !fs.ValidPath(name) || name == "." || name == metaFile {
+		return "", false
+	}
+	return name, true
+}
+
+// wanted reports whether an upstream file, by its path under the cache,
+// belongs in the cache: profiles, and the manifest and signature when
+// verifying.
+func (f *Fetcher) wanted(name string) bool {
+	if strings.HasSuffix(name, ".json") {
+		return true
+	}
+	if f.verifier == nil {
+		return false
+	}
+	manifest := f.verifier.Manifest
+	if manifest == "" {
+		manifest = loader.DefaultManifest
+	}
+	return name == manifest || name == manifest+".minisig"
+}
+
+// list returns the .json files under dir, descending into subdirectories. For
+// the top-level listing a matching etag yields (nil, etag, nil).
+func (f *Fetcher) list(ctx context.Context, dir, etag string) ([]entry, string, error) {
+	url := fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s", f.apiBase, f.repo, dir, f.ref)
+	resp, err := f.get(ctx, url, etag, "application/vnd.github+json")
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, nil
+	}
+	var items []entry
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxFileSize)).Decode(&items); err != nil {
+		return nil, "", fmt.Errorf("remote: listing %s: %w", dir, err)
+	}
+
+	var out []entry
+	for _, it := range items {
+		if it.Type == "dir" {
+			sub, _, err := f.list(ctx, it.Path, "")
+			if err != nil {
+				return nil, "", err
+			}
+			out = append(out, sub...)
+			continue
+		}
+		out = append(out, it)
+	}
+	if out == nil {
+		out = []entry{}
+	}
+	return out, resp.Header.Get("ETag"), nil
+}
+
+// download fetches url, checks that it parses if it is a profile, and writes
+// it to the cache as name, a slash-separated path under it.
+func (f *Fetcher) download(ctx context.Context, url, name string) error {
+	resp, err := f.get(ctx, url, "", "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize+1))
+	if err != nil {
+		return fmt.Errorf("remote: %s: %w", name, err)
+	}
+	if len(body) > maxFileSize {
+		return fmt.Errorf("remote: %s: larger than %d bytes", name, maxFileSize)
+	}
+	if strings.HasSuffix(name, ".json") {
+		var pf models.ProfileFile
+		if err := json.Unmarshal(body, &pf); err != nil {
+			return fmt.Errorf("remote: %s: %w", name, err)
+		}
+	}
+	dst := filepath.Join(f.cacheDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("remote: %w", err)
+	}
+	return writeAtomic(dst, body)
+}
+
+// get performs a GET and returns the response for 200 and 304; any other
+// status is an error, and so is a URL that is not https://, whether it is
+// the API base or a download_url from a listing.
+func (f *Fetcher) get(ctx context.Context, url, etag, accept string) (*http.Response, error) {
+	if !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("remote: GET %s: not an https:// URL", url)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+	req.Header.Set("User-Agent", "cmdFuscator")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		resp.Body.Close()
+		return nil, fmt.Errorf("remote: GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// ─── Cache files ──────────────────────────────────────────────────────────────
+
+func (f *Fetcher) cached(name string) bool {
+	_, err := os.Stat(filepath.Join(f.cacheDir, filepath.FromSlash(name)))
+	return err == nil
+}
+
+func (f *Fetcher) readMeta() cacheMeta {
+	var m cacheMeta
+	if f.cacheDir == "" {
+		return m
+	}
+	b, err := os.ReadFile(filepath.Join(f.cacheDir, metaFile))
+	if err != nil {
+		return m
+	}
+	if json.Unmarshal(b, &m) != nil {
+		return cacheMeta{}
+	}
+	return m
+}
+
+func (f *Fetcher) writeMeta(m cacheMeta) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("remote: %w", err)
+	}
+	return writeAtomic(filepath.Join(f.cacheDir, metaFile), b)
+}
+
+// writeAtomic writes data to a temporary file and renames it over name, so a
+// crash never leaves a half-written profile in the cache.
+func writeAtomic(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("remote: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("remote: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("remote: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("remote: %w", err)
+	}
+	return nil
+}
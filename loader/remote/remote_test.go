@@ -0,0 +1,305 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cmdFuscator/data"
+	"cmdFuscator/loader"
+)
+
+const profileJSON = `{"versions":{"argfuscator":"2.0","format":"2.0"},"profiles":[{"platform":"%s","parameters":{"modifiers":{}}}]}`
+
+// upstream is a minimal GitHub contents API: a models/ directory with one
+// nested directory, served over HTTPS with an ETag.
+type upstream struct {
+	mu       sync.Mutex
+	files    map[string]string // path under models/ → body
+	etag     string
+	requests map[string]int
+	down     bool
+	rawBase  string // where download_url points; the server itself when empty
+}
+
+func newUpstream() *upstream {
+	return &upstream{
+		files: map[string]string{
+			"certutil.json":  fmt.Sprintf(profileJSON, "windows"),
+			"linux/cur.json": fmt.Sprintf(profileJSON, "linux"),
+			"README.md":      "not a profile",
+		},
+		etag:     `"v1"`,
+		requests: map[string]int{},
+	}
+}
+
+func (u *upstream) serve(t *testing.T) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		u.requests[r.URL.Path]++
+		if u.down {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+
+		if raw, ok := strings.CutPrefix(r.URL.Path, "/raw/"); ok {
+			body, ok := u.files[raw]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, body)
+			return
+		}
+
+		dir, ok := strings.CutPrefix(r.URL.Path, "/repos/o/r/contents/models")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		dir = strings.TrimPrefix(dir, "/")
+		if dir == "" && r.Header.Get("If-None-Match") == u.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		var items []entry
+		seenDirs := map[string]bool{}
+		names := make([]string, 0, len(u.files))
+		for p := range u.files {
+			names = append(names, p)
+		}
+		sort.Strings(names)
+		for _, p := range names {
+			parent, base := filepath.Split(p)
+			parent = strings.TrimSuffix(parent, "/")
+			switch {
+			case parent == dir:
+				raw := u.rawBase
+				if raw == "" {
+					raw = srv.URL
+				}
+				items = append(items, entry{Name: base, Path: "models/" + p, Type: "file",
+					SHA: fmt.Sprintf("%x", len(u.files[p])) + u.etag, DownloadURL: raw + "/raw/" + p})
+			case dir == "" && parent != "" && !seenDirs[parent]:
+				seenDirs[parent] = true
+				items = append(items, entry{Name: parent, Path: "models/" + parent, Type: "dir"})
+			}
+		}
+		if dir == "" {
+			w.Header().Set("ETag", u.etag)
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func fetcher(srv *httptest.Server, dir string, opts ...Option) *Fetcher {
+	opts = append([]Option{WithSource(srv.URL, "o/r", "models", "main"), WithClient(srv.Client())}, opts...)
+	return New(dir, opts...)
+}
+
+func names(t *testing.T, f *Fetcher) ([]string, Report) {
+	t.Helper()
+	profiles, rep, err := f.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var out []string
+	for _, pf := range profiles {
+		out = append(out, pf.Name)
+	}
+	sort.Strings(out)
+	return out, rep
+}
+
+func TestLoad_DownloadsAndCaches(t *testing.T) {
+	u := newUpstream()
+	srv := u.serve(t)
+	dir := t.TempDir()
+
+	got, rep := names(t, fetcher(srv, dir))
+	if rep.Source != SourceRemote || rep.SyncErr != nil {
+		t.Errorf("first load: %+v", rep)
+	}
+	if strings.Join(got, ",") != "certutil,cur" {
+		t.Errorf("profiles = %v", got)
+	}
+
+	// Within the TTL nothing is requested.
+	before := u.requests["/repos/o/r/contents/models"]
+	if _, rep := names(t, fetcher(srv, dir)); rep.Source != SourceCache {
+		t.Errorf("second load source = %v, want cache", rep.Source)
+	}
+	if u.requests["/repos/o/r/contents/models"] != before {
+		t.Error("fresh cache should not hit the network")
+	}
+}
+
+func TestLoad_RevalidatesWithETag(t *testing.T) {
+	u := newUpstream()
+	srv := u.serve(t)
+	dir := t.TempDir()
+	names(t, fetcher(srv, dir))
+
+	if _, rep := names(t, fetcher(srv, dir, WithTTL(0))); rep.Source != SourceRemote {
+		t.Errorf("revalidation source = %v", rep.Source)
+	}
+	if n := u.requests["/raw/certutil.json"]; n != 1 {
+		t.Errorf("unchanged file downloaded %d times, want 1", n)
+	}
+
+	// Upstream changes: a file is removed and the ETag moves.
+	u.mu.Lock()
+	delete(u.files, "linux/cur.json")
+	u.etag = `"v2"`
+	u.mu.Unlock()
+	if got, _ := names(t, fetcher(srv, dir, WithTTL(0))); strings.Join(got, ",") != "certutil" {
+		t.Errorf("after upstream removal: %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "linux", "cur.json")); !os.IsNotExist(err) {
+		t.Error("removed upstream file should be deleted from the cache")
+	}
+}
+
+func TestLoad_Offline(t *testing.T) {
+	u := newUpstream()
+	srv := u.serve(t)
+	dir := t.TempDir()
+	names(t, fetcher(srv, dir))
+
+	u.mu.Lock()
+	u.down = true
+	u.mu.Unlock()
+
+	got, rep := names(t, fetcher(srv, dir, WithTTL(0)))
+	if rep.Source != SourceStale || rep.SyncErr == nil {
+		t.Errorf("offline with cache: %+v", rep)
+	}
+	if len(got) != 2 {
+		t.Errorf("stale cache profiles = %v", got)
+	}
+
+	embedded, err := fs.Sub(data.ModelFS, "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, rep = names(t, fetcher(srv, t.TempDir(), WithFallback(embedded)))
+	if rep.Source != SourceFallback || len(got) == 0 {
+		t.Errorf("offline without cache: %+v, %v", rep, got)
+	}
+
+	if _, _, err := fetcher(srv, t.TempDir()).Load(context.Background()); err == nil {
+		t.Error("offline with no cache and no fallback should fail")
+	}
+}
+
+func TestLoad_RejectsInvalidDownload(t *testing.T) {
+	u := newUpstream()
+	u.files["broken.json"] = "{"
+	srv := u.serve(t)
+
+	f := fetcher(srv, t.TempDir())
+	if err := f.Sync(context.Background()); err == nil {
+		t.Error("Sync should fail on an unparseable profile")
+	}
+}
+
+func TestLoad_KeepsDirectories(t *testing.T) {
+	u := newUpstream()
+	u.files["macos/cur.json"] = fmt.Sprintf(profileJSON, "macos")
+	srv := u.serve(t)
+	dir := t.TempDir()
+
+	profiles, _, err := fetcher(srv, dir).Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var platforms []string
+	for _, pf := range profiles {
+		if pf.Name == "cur" {
+			platforms = append(platforms, pf.Profiles[0].Platform)
+		}
+	}
+	sort.Strings(platforms)
+	if strings.Join(platforms, ",") != "linux,macos" {
+		t.Errorf("cur profiles for %v, want both directories' copies", platforms)
+	}
+	for _, p := range []string{"linux/cur.json", "macos/cur.json"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(p))); err != nil {
+			t.Errorf("%s not cached: %v", p, err)
+		}
+	}
+}
+
+func TestLoad_RejectsPlainHTTP(t *testing.T) {
+	u := newUpstream()
+	u.rawBase = "http://example.invalid"
+	srv := u.serve(t)
+
+	err := fetcher(srv, t.TempDir()).Sync(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "not an https:// URL") {
+		t.Errorf("Sync = %v, want an http:// download_url rejected", err)
+	}
+
+	plain := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(plain.Close)
+	if err := fetcher(plain, t.TempDir()).Sync(context.Background()); err == nil || !strings.Contains(err.Error(), "not an https:// URL") {
+		t.Errorf("Sync = %v, want an http:// API base rejected", err)
+	}
+}
+
+func TestFresh(t *testing.T) {
+	now := time.Unix(1000, 0)
+	f := New(t.TempDir(), WithTTL(time.Hour))
+	f.now = func() time.Time { return now }
+	files := map[string]string{"a.json": "x"}
+
+	if !f.fresh(cacheMeta{Fetched: now.Add(-time.Minute), Files: files}) {
+		t.Error("recent cache should be fresh")
+	}
+	if f.fresh(cacheMeta{Fetched: now.Add(-2 * time.Hour), Files: files}) {
+		t.Error("old cache should not be fresh")
+	}
+	if f.fresh(cacheMeta{Fetched: now}) {
+		t.Error("empty cache should never be fresh")
+	}
+}
+
+func TestLoad_Verifier(t *testing.T) {
+	u := newUpstream()
+	delete(u.files, "linux/cur.json")
+	sum := sha256.Sum256([]byte(u.files["certutil.json"]))
+	u.files["SHA256SUMS"] = fmt.Sprintf("%x  certutil.json\n", sum)
+	srv := u.serve(t)
+	dir := t.TempDir()
+
+	f := fetcher(srv, dir, WithVerifier(loader.Verifier{}), WithTTL(0))
+	if got, rep := names(t, f); rep.Source != SourceRemote || strings.Join(got, ",") != "certutil" {
+		t.Fatalf("verified load: %v, %+v", got, rep)
+	}
+
+	// Upstream content changes without the manifest following.
+	u.mu.Lock()
+	u.files["certutil.json"] = fmt.Sprintf(profileJSON, "macos")
+	u.etag = `"v2"`
+	u.mu.Unlock()
+	if _, _, err := f.Load(context.Background()); err == nil {
+		t.Error("a cache failing verification should not be used")
+	}
+}
@@ -0,0 +1,66 @@
+package loader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ─── Load report ──────────────────────────────────────────────────────────────
+
+// LoadReport records what happened to each file during a load, so callers can
+// tell users which profiles were rejected and why instead of silently showing
+// a shorter list.
+type LoadReport struct {
+	// Loaded lists the files that parsed, in load order.
+	Loaded []string
+	// Skipped lists the files that were rejected, with the reason.
+	Skipped []FileError
+	// Warnings lists files that loaded but declare an unexpected format
+	// version: a 2.x minor this build may not fully understand, a missing
+	// version, or a 1.x file that was upgraded.
+	Warnings []FileError
+}
+
+// FileError pairs a profile file with the error or warning raised for it.
+type FileError struct {
+	File string
+	Err  error
+}
+
+func (e FileError) Error() string { return e.File + ": " + e.Err.Error() }
+func (e FileError) Unwrap() error { return e.Err }
+
+// OK reports whether every file loaded without errors or warnings.
+func (r *LoadReport) OK() bool {
+	return len(r.Skipped) == 0 && len(r.Warnings) == 0
+}
+
+// Summary is a one-line description of the problems in r, suitable for a
+// status bar, quoting the first one; it is "" when r is OK.
+func (r *LoadReport) Summary() string {
+	var parts []string
+	if n := len(r.Skipped); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d rejected", n))
+	}
+	if n := len(r.Warnings); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d with warnings", n))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	first := append(append([]FileError(nil), r.Skipped...), r.Warnings...)[0]
+	return fmt.Sprintf("profiles: %s (%s)", strings.Join(parts, ", "), first)
+}
+
+// merge appends other to r, prefixing its file names with prefix.
+func (r *LoadReport) merge(other *LoadReport, prefix string) {
+	for _, f := range other.Loaded {
+		r.Loaded = append(r.Loaded, prefix+f)
+	}
+	for _, e := range other.Skipped {
+		r.Skipped = append(r.Skipped, FileError{File: prefix + e.File, Err: e.Err})
+	}
+	for _, e := range other.Warnings {
+		r.Warnings = append(r.Warnings, FileError{File: prefix + e.File, Err: e.Err})
+	}
+}
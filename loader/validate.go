@@ -0,0 +1,473 @@
+package loader
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ─── Schema validation ────────────────────────────────────────────────────────
+
+//go:embed profile.schema.json
+var schemaJSON []byte
+
+// Schema returns the JSON Schema (draft 2020-12) for format 2.0 profile
+// files that Validate checks against, for use in editors.
+func Schema() []byte { return bytes.Clone(schemaJSON) }
+
+// Issue is one problem found by Validate. Line and Col are 1-based and point
+// at the offending value (or key, for unknown names); Path is the JSON Pointer
+// of that value, e.g. "/profiles/0/parameters/modifiers/RandomCase".
+type Issue struct {
+	File    string
+	Line    int
+	Col     int
+	Path    string
+	Message string
+}
+
+func (i Issue) String() string {
+	loc := fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Col)
+	if i.Path == "" {
+		return loc + ": " + i.Message
+	}
+	return loc + ": " + i.Path + ": " + i.Message
+}
+
+// Validate checks every profile file in fsys, recursively as for LoadFS,
+// against the format 2.0 schema (see Schema) and returns the issues found, in
+// file then document order. It reports what LoadFS would silently accept or reject: unknown modifier
+// names, malformed Probability values, missing required fields and type
+// mismatches. A nil slice means every file is valid; the error is non-nil
+// only when fsys cannot be read.
+func Validate(fsys fs.FS) ([]Issue, error) {
+	entries, err := profilePaths(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("loader: walk: %w", err)
+	}
+	var issues []Issue
+	for _, entry := range entries {
+		data, err := fs.ReadFile(fsys, entry)
+		if err != nil {
+			return issues, fmt.Errorf("loader: %s: %w", entry, err)
+		}
+		issues = append(issues, ValidateFile(entry, data)...)
+	}
+	return issues, nil
+}
+
+// ValidateFile is Validate for a single file's contents. name labels the
+// issues and picks the format: YAML for .yaml and .yml, JSON otherwise.
+func ValidateFile(name string, data []byte) []Issue {
+	root, err := loadSchema()
+	if err != nil {
+		// The schema is embedded, so this is a build defect, not user error.
+		panic(err)
+	}
+
+	doc, err := parseProfileTree(name, data)
+	if err != nil {
+		line, col := 1, 1
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			line, col = position(data, int(se.Offset))
+		} else if l := yamlErrorLine(err); l > 0 {
+			line = l
+		}
+		return []Issue{{File: name, Line: line, Col: col, Message: err.Error()}}
+	}
+
+	v := validator{root: root}
+	v.check(root, doc, "")
+	sort.SliceStable(v.found, func(i, j int) bool { return v.found[i].off < v.found[j].off })
+
+	issues := make([]Issue, len(v.found))
+	for i, f := range v.found {
+		line, col := position(data, f.off)
+		issues[i] = Issue{File: name, Line: line, Col: col, Path: f.path, Message: f.msg}
+	}
+	return issues
+}
+
+// position converts a byte offset in data to a 1-based line and column.
+func position(data []byte, off int) (line, col int) {
+	off = min(off, len(data))
+	before := data[:off]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = off - (bytes.LastIndexByte(before, '\n') + 1) + 1
+	return line, col
+}
+
+// ─── Schema subset ────────────────────────────────────────────────────────────
+
+// schema is the subset of JSON Schema that profile.schema.json uses. Besides
+// the standard keywords it honours "errorMessage" (as in ajv-errors), which
+// replaces the generic message for failures of the schema's own keywords.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Defs                 map[string]*schema `json:"$defs"`
+	Type                 typeList           `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	PropertyNames        *schema            `json:"propertyNames"`
+	MinProperties        *int               `json:"minProperties"`
+	MaxProperties        *int               `json:"maxProperties"`
+	Items                *schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	Enum                 []any              `json:"enum"`
+	Pattern              string             `json:"pattern"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	ErrorMessage         string             `json:"errorMessage"`
+
+	never bool           // the boolean schema false
+	re    *regexp.Regexp // compiled Pattern
+}
+
+func (s *schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = schema{}
+		return nil
+	case "false":
+		*s = schema{never: true}
+		return nil
+	}
+	type plain schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// typeList is the "type" keyword, which may be a string or an array.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = typeList{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+var loadSchema = sync.OnceValues(func() (*schema, error) {
+	var s schema
+	if err := json.Unmarshal(schemaJSON, &s); err != nil {
+		return nil, fmt.Errorf("loader: schema: %w", err)
+	}
+	if err := compile(&s); err != nil {
+		return nil, fmt.Errorf("loader: schema: %w", err)
+	}
+	return &s, nil
+})
+
+// compile compiles every pattern in s.
+func compile(s *schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.re = re
+	}
+	for _, sub := range []*schema{s.AdditionalProperties, s.PropertyNames, s.Items} {
+		if err := compile(sub); err != nil {
+			return err
+		}
+	}
+	for _, m := range []map[string]*schema{s.Defs, s.Properties} {
+		for _, sub := range m {
+			if err := compile(sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ─── Validator ────────────────────────────────────────────────────────────────
+
+type finding struct {
+	off  int
+	path string
+	msg  string
+}
+
+type validator struct {
+	root  *schema
+	found []finding
+}
+
+func (v *validator) report(n *node, off int, path, generic, custom string) {
+	msg := generic
+	if custom != "" {
+		msg = custom
+	}
+	if off < 0 {
+		off = n.off
+	}
+	v.found = append(v.found, finding{off: off, path: path, msg: msg})
+}
+
+func (v *validator) resolve(ref string) *schema {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil
+	}
+	return v.root.Defs[name]
+}
+
+// check validates n against s, recording findings under path.
+func (v *validator) check(s *schema, n *node, path string) {
+	if s == nil {
+		return
+	}
+	if s.never {
+		v.report(n, -1, path, "not allowed here", "")
+		return
+	}
+	if s.Ref != "" {
+		v.check(v.resolve(s.Ref), n, path)
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, n.is) {
+		v.report(n, -1, path, fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, " or "), n.kindName()), "")
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, n.equals) {
+		v.report(n, -1, path, fmt.Sprintf("%s is not one of %s", n.literal(), enumList(s.Enum)), s.ErrorMessage)
+	}
+
+	switch n.kind {
+	case kindObject:
+		v.checkObject(s, n, path)
+	case kindArray:
+		if s.MinItems != nil && len(n.items) < *s.MinItems {
+			v.report(n, -1, path, fmt.Sprintf("needs at least %d item(s)", *s.MinItems), s.ErrorMessage)
+		}
+		for i, item := range n.items {
+			v.check(s.Items, item, path+"/"+strconv.Itoa(i))
+		}
+	case kindString:
+		if s.re != nil && !s.re.MatchString(n.str) {
+			v.report(n, -1, path, fmt.Sprintf("%q does not match %s", n.str, s.Pattern), s.ErrorMessage)
+		}
+	case kindNumber:
+		if s.Minimum != nil && n.num < *s.Minimum || s.Maximum != nil && n.num > *s.Maximum {
+			v.report(n, -1, path, fmt.Sprintf("%s is out of range", n.literal()), s.ErrorMessage)
+		}
+	}
+}
+
+func (v *validator) checkObject(s *schema, n *node, path string) {
+	for _, req := range s.Required {
+		if _, ok := n.fields[req]; !ok {
+			v.report(n, -1, path, fmt.Sprintf("missing required field %q", req), "")
+		}
+	}
+	if s.MinProperties != nil && len(n.keys) < *s.MinProperties ||
+		s.MaxProperties != nil && len(n.keys) > *s.MaxProperties {
+		v.report(n, -1, path, fmt.Sprintf("has %d field(s)", len(n.keys)), s.ErrorMessage)
+	}
+
+	for i, key := range n.keys {
+		child, childPath := n.fields[key], path+"/"+escapePointer(key)
+		if pn := s.PropertyNames; pn != nil && len(pn.Enum) > 0 && !slices.Contains(pn.Enum, any(key)) {
+			msg := "unknown property"
+			if pn.ErrorMessage != "" {
+				msg = pn.ErrorMessage
+			}
+			v.report(n, n.keyOffs[i], childPath, fmt.Sprintf("%s %q", msg, key), "")
+			continue
+		}
+		if sub, ok := s.Properties[key]; ok {
+			v.check(sub, child, childPath)
+		} else if ap := s.AdditionalProperties; ap != nil {
+			if ap.never {
+				v.report(n, n.keyOffs[i], childPath, fmt.Sprintf("unknown field %q", key), s.ErrorMessage)
+				continue
+			}
+			v.check(ap, child, childPath)
+		}
+	}
+}
+
+func enumList(values []any) string {
+	parts := make([]string, len(values))
+	for i, e := range values {
+		b, _ := json.Marshal(e)
+		parts[i] = string(b)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// escapePointer escapes a key for use in a JSON Pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// ─── Positioned JSON tree ─────────────────────────────────────────────────────
+
+type nodeKind int
+
+const (
+	kindNull nodeKind = iota
+	kindBool
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+// node is a decoded JSON value that remembers where it started in the source,
+// which encoding/json's generic decoding throws away.
+type node struct {
+	kind    nodeKind
+	off     int
+	str     string
+	num     float64
+	raw     string // number literal
+	b       bool
+	items   []*node
+	keys    []string // in document order
+	keyOffs []int
+	fields  map[string]*node
+}
+
+func (n *node) kindName() string {
+	return [...]string{"null", "boolean", "number", "string", "array", "object"}[n.kind]
+}
+
+// is reports whether n matches the JSON Schema type name t.
+func (n *node) is(t string) bool {
+	switch t {
+	case "integer":
+		return n.kind == kindNumber && n.num == math.Trunc(n.num)
+	case "number":
+		return n.kind == kindNumber
+	default:
+		return n.kindName() == t
+	}
+}
+
+func (n *node) equals(e any) bool {
+	switch e := e.(type) {
+	case string:
+		return n.kind == kindString && n.str == e
+	case float64:
+		return n.kind == kindNumber && n.num == e
+	case bool:
+		return n.kind == kindBool && n.b == e
+	case nil:
+		return n.kind == kindNull
+	}
+	return false
+}
+
+func (n *node) literal() string {
+	switch n.kind {
+	case kindString:
+		return strconv.Quote(n.str)
+	case kindNumber:
+		return n.raw
+	case kindBool:
+		return strconv.FormatBool(n.b)
+	default:
+		return n.kindName()
+	}
+}
+
+// parseTree decodes data into a node tree, rejecting trailing content.
+func parseTree(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := parseNode(dec, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &json.SyntaxError{Offset: dec.InputOffset()}
+	}
+	return root, nil
+}
+
+func parseNode(dec *json.Decoder, data []byte) (*node, error) {
+	n := &node{off: skipSeparators(data, int(dec.InputOffset()))}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			n.kind = kindArray
+			for dec.More() {
+				item, err := parseNode(dec, data)
+				if err != nil {
+					return nil, err
+				}
+				n.items = append(n.items, item)
+			}
+		} else {
+			n.kind, n.fields = kindObject, map[string]*node{}
+			for dec.More() {
+				keyOff := skipSeparators(data, int(dec.InputOffset()))
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := kt.(string)
+				child, err := parseNode(dec, data)
+				if err != nil {
+					return nil, err
+				}
+				if _, dup := n.fields[key]; !dup {
+					n.keys = append(n.keys, key)
+					n.keyOffs = append(n.keyOffs, keyOff)
+				}
+				n.fields[key] = child
+			}
+		}
+		if _, err := dec.Token(); err != nil { // closing delimiter
+			return nil, err
+		}
+	case string:
+		n.kind, n.str = kindString, t
+	case json.Number:
+		n.kind, n.raw = kindNumber, t.String()
+		n.num, _ = t.Float64()
+	case bool:
+		n.kind, n.b = kindBool, t
+	case nil:
+		n.kind = kindNull
+	}
+	return n, nil
+}
+
+// skipSeparators advances off past whitespace, ':' and ',' to the start of
+// the next token; Decoder.InputOffset points just after the previous one.
+func skipSeparators(data []byte, off int) int {
+	for off < len(data) {
+		switch data[off] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+			off++
+		default:
+			return off
+		}
+	}
+	return off
+}
@@ -0,0 +1,97 @@
+package loader
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidate_Embedded(t *testing.T) {
+	issues, err := Validate(embedded(t))
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, is := range issues {
+		t.Errorf("bundled profile is invalid: %s", is)
+	}
+}
+
+const invalidProfile = `{
+  "versions": { "format": "2.0" },
+  "profiles": [
+    {
+      "platform": "beos",
+      "parameters": {
+        "command": [{ "command": "x", "path": "y" }],
+        "arguments": [{ "flags": ["-a"], "valueCount": "1" }],
+        "modifiers": {
+          "RandomCase": { "AppliesTo": ["argument"], "Probability": "0,5" },
+          "Sed": { "AppliesTo": ["argument"], "Probability": 1.5 },
+          "QuoteInsertion": { "Probability": "0.5" },
+          "Spaghetti": {}
+        }
+      }
+    }
+  ]
+}`
+
+func TestValidateFile(t *testing.T) {
+	want := []struct {
+		line    int
+		path    string
+		message string
+	}{
+		{5, "/profiles/0/platform", `"beos" is not one of`},
+		{7, "/profiles/0/parameters/command/0", "exactly one of"},
+		{8, "/profiles/0/parameters/arguments/0/valueCount", "expected integer, got string"},
+		{10, "/profiles/0/parameters/modifiers/RandomCase/Probability", "between 0 and 1"},
+		{11, "/profiles/0/parameters/modifiers/Sed/Probability", "between 0 and 1"},
+		{12, "/profiles/0/parameters/modifiers/QuoteInsertion", `missing required field "AppliesTo"`},
+		{13, "/profiles/0/parameters/modifiers/Spaghetti", `unknown modifier "Spaghetti"`},
+	}
+
+	issues := ValidateFile("bad.json", []byte(invalidProfile))
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d:\n%v", len(issues), len(want), issues)
+	}
+	for i, w := range want {
+		got := issues[i]
+		if got.File != "bad.json" || got.Line != w.line || got.Path != w.path || !strings.Contains(got.Message, w.message) {
+			t.Errorf("issue %d = %s\nwant line %d, %s: …%s…", i, got, w.line, w.path, w.message)
+		}
+	}
+	if col := issues[6].Col; col != 11 {
+		t.Errorf("unknown modifier column = %d, want 11 (the key)", col)
+	}
+}
+
+func TestValidateFile_Syntax(t *testing.T) {
+	issues := ValidateFile("broken.json", []byte("{\n  \"versions\": {,\n}"))
+	if len(issues) != 1 || issues[0].Line != 2 {
+		t.Errorf("issues = %v, want one on line 2", issues)
+	}
+}
+
+func TestValidate_Files(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.json":   {Data: []byte(invalidProfile)},
+		"b.json":   {Data: []byte(`{"versions":{"format":"2.0"},"profiles":[{"platform":"linux","parameters":{"command":[],"modifiers":{}}}]}`)},
+		"skip.txt": {Data: []byte("{")},
+	}
+	issues, err := Validate(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, is := range issues {
+		if is.File != "a.json" {
+			t.Errorf("unexpected issue %s", is)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	if !json.Valid(Schema()) {
+		t.Error("Schema() is not valid JSON")
+	}
+}
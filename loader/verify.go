@@ -0,0 +1,250 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ─── Integrity verification ───────────────────────────────────────────────────
+
+// DefaultManifest is the manifest file name Verifier uses when none is set.
+const DefaultManifest = "SHA256SUMS"
+
+// ErrNotInManifest is returned when reading a profile file a manifest does
+// not list.
+var ErrNotInManifest = errors.New("not listed in manifest")
+
+// ErrChecksum is returned when a file's contents do not match its manifest
+// entry.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// Verifier describes how to check profiles from disk or the network before
+// they are accepted: every file must match a SHA-256 manifest, and the
+// manifest itself may be required to carry a minisign signature.
+type Verifier struct {
+	// Manifest is the path within the FS of a manifest in sha256sum format
+	// ("<hex digest>  <path>" per line). Empty means DefaultManifest.
+	Manifest string
+
+	// PublicKey, when non-nil, requires Manifest+".minisig" to be a valid
+	// minisign signature of the manifest by this key. Without it the
+	// manifest only detects corruption, not tampering.
+	PublicKey *PublicKey
+}
+
+// Verified checks v's manifest (and its signature, if required) in fsys and
+// returns an FS that serves fsys's files only when they match the manifest.
+// Reading an unlisted profile file fails with ErrNotInManifest and a
+// modified one with ErrChecksum, so LoadFS and friends report them as
+// skipped instead of accepting them. Contents are hashed as they are read,
+// so a file changed after verification is caught too.
+func Verified(fsys fs.FS, v Verifier) (fs.FS, error) {
+	name := v.Manifest
+	if name == "" {
+		name = DefaultManifest
+	}
+	manifest, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("loader: manifest: %w", err)
+	}
+	if v.PublicKey != nil {
+		sig, err := fs.ReadFile(fsys, name+".minisig")
+		if err != nil {
+			return nil, fmt.Errorf("loader: manifest signature: %w", err)
+		}
+		if err := v.PublicKey.Verify(manifest, sig); err != nil {
+			return nil, fmt.Errorf("loader: manifest %s: %w", name, err)
+		}
+	}
+	sums, err := parseManifest(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("loader: manifest %s: %w", name, err)
+	}
+	return &verifiedFS{fsys: fsys, sums: sums}, nil
+}
+
+// Manifest returns a sha256sum-format manifest for the profile files in fsys,
+// ready to be written to DefaultManifest and optionally signed with minisign.
+func Manifest(fsys fs.FS) ([]byte, error) {
+	paths, err := profilePaths(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("loader: walk: %w", err)
+	}
+	var buf bytes.Buffer
+	for _, p := range paths {
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("loader: %s: %w", p, err)
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(&buf, "%x  %s\n", sum, p)
+	}
+	return buf.Bytes(), nil
+}
+
+func parseManifest(data []byte) (map[string][sha256.Size]byte, error) {
+	sums := make(map[string][sha256.Size]byte)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		digest, name, ok := strings.Cut(text, " ")
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*") // "*" marks binary mode
+		raw, err := hex.DecodeString(digest)
+		if !ok || err != nil || len(raw) != sha256.Size || name == "" {
+			return nil, fmt.Errorf("line %d: want \"<sha256 hex>  <path>\"", line)
+		}
+		var sum [sha256.Size]byte
+		copy(sum[:], raw)
+		sums[path.Clean(strings.TrimPrefix(name, "./"))] = sum
+	}
+	return sums, sc.Err()
+}
+
+// verifiedFS wraps an FS so profile files are only served when they match
+// the manifest. Directories and non-profile files pass through.
+type verifiedFS struct {
+	fsys fs.FS
+	sums map[string][sha256.Size]byte
+}
+
+func (v *verifiedFS) ReadFile(name string) ([]byte, error) {
+	data, err := fs.ReadFile(v.fsys, name)
+	if err != nil || !isProfileFile(name) {
+		return data, err
+	}
+	want, ok := v.sums[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "verify", Path: name, Err: ErrNotInManifest}
+	}
+	if got := sha256.Sum256(data); subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+		return nil, &fs.PathError{Op: "verify", Path: name, Err: ErrChecksum}
+	}
+	return data, nil
+}
+
+func (v *verifiedFS) Open(name string) (fs.File, error) {
+	if !isProfileFile(name) {
+		return v.fsys.Open(name)
+	}
+	f, err := v.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return v.fsys.Open(name)
+	}
+	data, err := v.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{Reader: bytes.NewReader(data), info: info}, nil
+}
+
+// memFile serves already verified contents, so what is read is exactly what
+// was hashed.
+type memFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// ─── minisign ─────────────────────────────────────────────────────────────────
+
+// PublicKey is a minisign (https://jedisct1.github.io/minisign/) Ed25519
+// public key.
+type PublicKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a minisign public key, either the base64 line alone
+// or the whole .pub file with its untrusted comment.
+func ParsePublicKey(text string) (*PublicKey, error) {
+	line := lastLine(text)
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return nil, errors.New("loader: not a minisign Ed25519 public key")
+	}
+	pk := &PublicKey{key: ed25519.PublicKey(raw[10:])}
+	copy(pk.id[:], raw[2:10])
+	return pk, nil
+}
+
+// Verify checks a minisign signature file (sig) over message, including its
+// trusted comment. Both legacy ("Ed") and prehashed ("ED") signatures are
+// accepted.
+func (pk *PublicKey) Verify(message, sig []byte) error {
+	lines := strings.Split(strings.TrimRight(string(sig), "\r\n"), "\n")
+	if len(lines) < 4 {
+		return errors.New("malformed minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return errors.New("malformed minisign signature")
+	}
+	alg, id, signature := string(raw[:2]), raw[2:10], raw[10:]
+	if subtle.ConstantTimeCompare(id, pk.id[:]) != 1 {
+		return fmt.Errorf("signed by key %X, want %X", reverse(id), reverse(pk.id[:]))
+	}
+
+	switch alg {
+	case "Ed":
+	case "ED":
+		sum := blake2b.Sum512(message)
+		message = sum[:]
+	default:
+		return fmt.Errorf("unsupported minisign algorithm %q", alg)
+	}
+	if !ed25519.Verify(pk.key, message, signature) {
+		return errors.New("invalid signature")
+	}
+
+	trusted, ok := strings.CutPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	if !ok {
+		return errors.New("malformed minisign trusted comment")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(pk.key, append(bytes.Clone(signature), trusted...), global) {
+		return errors.New("invalid trusted comment signature")
+	}
+	return nil
+}
+
+// reverse returns b reversed; minisign prints key IDs little-endian.
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i, c := range b {
+		out[len(b)-1-i] = c
+	}
+	return out
+}
+
+func lastLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		text = text[i+1:]
+	}
+	return strings.TrimSpace(text)
+}
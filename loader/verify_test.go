@@ -0,0 +1,145 @@
+package loader
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignKey generates a key pair and returns the public key file and a
+// signer producing minisign signature files.
+func minisignKey(t *testing.T) (pub string, sign func(msg []byte, prehash bool) []byte) {
+	t.Helper()
+	pk, sk, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	pub = "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id...), pk...)) + "\n"
+
+	sign = func(msg []byte, prehash bool) []byte {
+		alg := "Ed"
+		if prehash {
+			alg = "ED"
+			sum := blake2b.Sum512(msg)
+			msg = sum[:]
+		}
+		sig := ed25519.Sign(sk, msg)
+		trusted := "timestamp:0\tfile:SHA256SUMS"
+		global := ed25519.Sign(sk, append(append([]byte(nil), sig...), trusted...))
+		return []byte(fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+			base64.StdEncoding.EncodeToString(append(append([]byte(alg), id...), sig...)),
+			trusted, base64.StdEncoding.EncodeToString(global)))
+	}
+	return pub, sign
+}
+
+func signedFS(t *testing.T, sign func([]byte, bool) []byte) fstest.MapFS {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"certutil.json":   {Data: []byte(fmt.Sprintf(overlayProfile, "windows"))},
+		"linux/curl.yaml": {Data: []byte("versions: {format: \"2.0\"}\nprofiles: [{platform: linux, parameters: {modifiers: {}}}]\n")},
+	}
+	manifest, err := Manifest(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys[DefaultManifest] = &fstest.MapFile{Data: manifest}
+	if sign != nil {
+		fsys[DefaultManifest+".minisig"] = &fstest.MapFile{Data: sign(manifest, true)}
+	}
+	return fsys
+}
+
+func TestVerified(t *testing.T) {
+	pubText, sign := minisignKey(t)
+	pub, err := ParsePublicKey(pubText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := signedFS(t, sign)
+
+	vfs, err := Verified(fsys, Verifier{PublicKey: pub})
+	if err != nil {
+		t.Fatalf("Verified: %v", err)
+	}
+	profiles, rep, err := LoadFSReport(vfs)
+	if err != nil || len(profiles) != 2 || len(rep.Skipped) != 0 {
+		t.Fatalf("load verified: %d profiles, %v, %v", len(profiles), rep.Skipped, err)
+	}
+
+	// Tampering after verification is still caught when the file is read.
+	fsys["certutil.json"].Data = []byte(fmt.Sprintf(overlayProfile, "macos"))
+	fsys["extra.json"] = &fstest.MapFile{Data: []byte(fmt.Sprintf(overlayProfile, "linux"))}
+	_, rep, _ = LoadFSReport(vfs)
+	reasons := map[string]error{}
+	for _, s := range rep.Skipped {
+		reasons[s.File] = s.Err
+	}
+	if !errors.Is(reasons["certutil.json"], ErrChecksum) {
+		t.Errorf("tampered file: %v", reasons["certutil.json"])
+	}
+	if !errors.Is(reasons["extra.json"], ErrNotInManifest) {
+		t.Errorf("unlisted file: %v", reasons["extra.json"])
+	}
+}
+
+func TestVerified_Signature(t *testing.T) {
+	pubText, sign := minisignKey(t)
+	pub, _ := ParsePublicKey(pubText)
+	otherText, otherSign := minisignKey(t)
+	other, _ := ParsePublicKey(otherText)
+
+	if _, err := Verified(signedFS(t, nil), Verifier{}); err != nil {
+		t.Errorf("unsigned manifest without a key: %v", err)
+	}
+	if _, err := Verified(signedFS(t, nil), Verifier{PublicKey: pub}); err == nil {
+		t.Error("a missing signature should fail when a key is required")
+	}
+	if _, err := Verified(signedFS(t, otherSign), Verifier{PublicKey: pub}); err == nil {
+		t.Error("a signature by another key with the same ID should fail")
+	}
+	if _, err := Verified(signedFS(t, sign), Verifier{PublicKey: other}); err == nil {
+		t.Error("verifying with the wrong key should fail")
+	}
+
+	fsys := signedFS(t, sign)
+	fsys[DefaultManifest].Data = append(fsys[DefaultManifest].Data, "# edited\n"...)
+	if _, err := Verified(fsys, Verifier{PublicKey: pub}); err == nil {
+		t.Error("an edited manifest should fail verification")
+	}
+
+	// Legacy (non-prehashed) signatures verify too.
+	msg := []byte("hello")
+	if err := pub.Verify(msg, sign(msg, false)); err != nil {
+		t.Errorf("legacy signature: %v", err)
+	}
+	sig := strings.Replace(string(sign(msg, true)), "timestamp:0", "timestamp:1", 1)
+	if err := pub.Verify(msg, []byte(sig)); err == nil {
+		t.Error("a forged trusted comment should fail")
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	sums, err := parseManifest([]byte("# comment\n" + sum + "  ./a.json\n" + sum + " *b/c.json\n"))
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("parseManifest: %v, %v", sums, err)
+	}
+	if _, ok := sums["b/c.json"]; !ok {
+		t.Error("binary-mode entry not parsed")
+	}
+	if _, err := parseManifest([]byte("xyz a.json\n")); err == nil {
+		t.Error("a malformed line should fail")
+	}
+	if _, err := ParsePublicKey("not a key"); err == nil {
+		t.Error("ParsePublicKey accepted garbage")
+	}
+}
@@ -0,0 +1,96 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cmdFuscator/models"
+)
+
+// ─── Format versions ──────────────────────────────────────────────────────────
+
+// ErrUnsupportedVersion is returned for a profile file whose format major
+// version this build cannot read.
+var ErrUnsupportedVersion = errors.New("unsupported format version")
+
+// formatMajor is the major version of models.FormatVersion.
+const formatMajor = 2
+
+// negotiate checks a file's declared format version. It returns the major
+// version to decode as, a warning when the file is readable but not exactly
+// models.FormatVersion, and an error wrapping ErrUnsupportedVersion for
+// unknown major versions. A missing version is read as the current one.
+func negotiate(format string) (major int, warn, err error) {
+	format = strings.TrimSpace(format)
+	if format == "" {
+		return formatMajor, fmt.Errorf("no format version, assuming %s", models.FormatVersion), nil
+	}
+	head, _, _ := strings.Cut(format, ".")
+	major, convErr := strconv.Atoi(head)
+	switch {
+	case convErr != nil || major < 1 || major > formatMajor:
+		return 0, nil, fmt.Errorf("%w %q: this build reads 1.x and 2.x", ErrUnsupportedVersion, format)
+	case major == 1:
+		return 1, fmt.Errorf("format version %s upgraded to %s", format, models.FormatVersion), nil
+	case format != models.FormatVersion:
+		return major, fmt.Errorf("format version %s, this build reads %s", format, models.FormatVersion), nil
+	}
+	return major, nil, nil
+}
+
+// decodeProfile decodes a JSON profile document of any supported format
+// version into the 2.0 model structures. warn is as for negotiate.
+func decodeProfile(data []byte) (pf *models.ProfileFile, warn, err error) {
+	var head struct {
+		Versions models.Versions `json:"versions"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, nil, fmt.Errorf("parse: %w", err)
+	}
+	major, warn, err := negotiate(head.Versions.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pf = &models.ProfileFile{}
+	if major == 1 {
+		err = upgradeV1(data, pf)
+	} else {
+		err = json.Unmarshal(data, pf)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse: %w", err)
+	}
+	return pf, warn, nil
+}
+
+// upgradeV1 decodes an ArgFuscator format 1.x file into pf. A 1.x file
+// describes a single profile at the top level: the header fields (platform,
+// operatingSystem, …) sit beside command, arguments and modifiers instead of
+// under profiles[n] and profiles[n].parameters. Modifier configs carry over
+// unchanged. A 1.x file that already uses the profiles array is decoded as is.
+func upgradeV1(data []byte, pf *models.ProfileFile) error {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+	if _, ok := probe["profiles"]; ok {
+		if err := json.Unmarshal(data, pf); err != nil {
+			return err
+		}
+	} else {
+		var p models.Profile
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &p.Parameters); err != nil {
+			return err
+		}
+		pf.Profiles = []models.Profile{p}
+	}
+	pf.Versions.Format = models.FormatVersion
+	return nil
+}
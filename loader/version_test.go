@@ -0,0 +1,90 @@
+package loader
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"cmdFuscator/models"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		format string
+		major  int
+		warn   bool
+		err    bool
+	}{
+		{"2.0", 2, false, false},
+		{"2.1", 2, true, false},
+		{"", 2, true, false},
+		{"1.0", 1, true, false},
+		{"1", 1, true, false},
+		{"3.0", 0, false, true},
+		{"0.9", 0, false, true},
+		{"v2", 0, false, true},
+	}
+	for _, tc := range cases {
+		major, warn, err := negotiate(tc.format)
+		if major != tc.major || (warn != nil) != tc.warn || (err != nil) != tc.err {
+			t.Errorf("negotiate(%q) = %d, %v, %v", tc.format, major, warn, err)
+		}
+		if err != nil && !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("negotiate(%q) error %v does not wrap ErrUnsupportedVersion", tc.format, err)
+		}
+	}
+}
+
+const v1Profile = `{
+  "versions": { "argfuscator": "1.2", "format": "1.0" },
+  "platform": "windows",
+  "operatingSystem": "Windows",
+  "command": [{ "command": "certutil.exe" }, { "argument": "-f" }],
+  "arguments": [{ "flags": ["-f"], "valueCount": 0 }],
+  "modifiers": {
+    "Sed": { "AppliesTo": ["argument"], "Probability": "0.5" },
+    "RandomCase": { "AppliesTo": ["argument"], "Probability": "0.5" }
+  }
+}`
+
+func TestLoadFS_V1Upgrade(t *testing.T) {
+	fsys := fstest.MapFS{
+		"certutil.json": {Data: []byte(v1Profile)},
+		"future.json":   {Data: []byte(`{"versions":{"format":"3.0"},"profiles":[]}`)},
+	}
+	profiles, rep, err := LoadFSReport(fsys)
+	if err != nil || len(profiles) != 1 {
+		t.Fatalf("LoadFSReport: %v, %d profiles", err, len(profiles))
+	}
+
+	pf := profiles[0]
+	if pf.Versions.Format != models.FormatVersion || len(pf.Profiles) != 1 {
+		t.Fatalf("upgraded file = %+v", pf)
+	}
+	p := pf.Profiles[0]
+	if p.Platform != "windows" || len(p.Parameters.Command) != 2 || len(p.Parameters.Arguments) != 1 {
+		t.Errorf("upgraded profile = %+v", p)
+	}
+	if got := p.Parameters.ModifierNames(); !slices.Equal(got, []string{"Sed", "RandomCase"}) {
+		t.Errorf("modifier order = %v", got)
+	}
+
+	if len(rep.Warnings) != 1 || !strings.Contains(rep.Warnings[0].Error(), "upgraded") {
+		t.Errorf("Warnings = %v", rep.Warnings)
+	}
+	if len(rep.Skipped) != 1 || !errors.Is(rep.Skipped[0].Err, ErrUnsupportedVersion) {
+		t.Errorf("Skipped = %v, want future.json rejected", rep.Skipped)
+	}
+
+	// The lazy catalog upgrades the same way.
+	cat, _, err := LoadLazy(fsys)
+	if err != nil || len(cat.Profiles()) != 1 || cat.Profiles()[0].Profiles[0].Platform != "windows" {
+		t.Fatalf("LoadLazy: %v", err)
+	}
+	full, err := cat.Full(cat.Profiles()[0])
+	if err != nil || len(full.Profiles[0].Parameters.Modifiers) != 2 {
+		t.Errorf("lazy Full = %+v, %v", full, err)
+	}
+}
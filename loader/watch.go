@@ -0,0 +1,193 @@
+package loader
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"cmdFuscator/models"
+)
+
+// ─── Hot reload ───────────────────────────────────────────────────────────────
+
+// debounce is how long Watcher waits after the last change before reloading,
+// so an editor's write-rename-chmod sequence triggers one reload, not three.
+const debounce = 150 * time.Millisecond
+
+// Reload is what a Watcher sends after the watched directory changes: the
+// freshly overlaid profile set and its report, or the error that stopped the
+// reload (in which case subscribers should keep their current profiles).
+type Reload struct {
+	Profiles []*models.ProfileFile
+	Report   *LoadReport
+	Err      error
+}
+
+// Watcher reloads profiles when profile files under its directories change
+// and notifies subscribers. Create one with Watch and stop it with Close.
+type Watcher struct {
+	embedded fs.FS
+	dirs     []string
+	verifier *Verifier
+	w        *fsnotify.Watcher
+
+	mu   sync.Mutex
+	subs []chan Reload
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Watch watches dirs, including subdirectories created later, and on every
+// change reloads them on top of embedded as LoadWithOverlayReport does. Every
+// directory must exist.
+func Watch(embedded fs.FS, dirs ...string) (*Watcher, error) {
+	return WatchVerified(embedded, nil, dirs...)
+}
+
+// WatchVerified is Watch reloading as LoadWithOverlayVerified does, and also
+// on changes to the manifests and their signatures. A nil v verifies nothing.
+func WatchVerified(embedded fs.FS, v *Verifier, dirs ...string) (*Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("loader: watch: %w", err)
+	}
+	w := &Watcher{embedded: embedded, dirs: dirs, verifier: v, w: fw, done: make(chan struct{})}
+	for _, dir := range dirs {
+		if err := w.addTree(dir); err != nil {
+			fw.Close()
+			return nil, fmt.Errorf("loader: watch %s: %w", dir, err)
+		}
+	}
+	w.wg.Add(1)
+	go w.loop()
+	return w, nil
+}
+
+// Subscribe returns a channel that receives a Reload after each change. The
+// channel holds one pending Reload; a slow reader sees only the latest. It is
+// closed by Close.
+func (w *Watcher) Subscribe() <-chan Reload {
+	ch := make(chan Reload, 1)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.done:
+		close(ch)
+	default:
+		w.subs = append(w.subs, ch)
+	}
+	return ch
+}
+
+// Close stops watching and closes every subscriber channel.
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	select {
+	case <-w.done:
+		w.mu.Unlock()
+		return nil
+	default:
+	}
+	close(w.done)
+	w.mu.Unlock()
+
+	err := w.w.Close()
+	w.wg.Wait()
+
+	w.mu.Lock()
+	for _, ch := range w.subs {
+		close(ch)
+	}
+	w.subs = nil
+	w.mu.Unlock()
+	return err
+}
+
+// addTree watches dir and every non-hidden directory below it; fsnotify
+// itself is not recursive.
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.w.Add(p)
+	})
+}
+
+func (w *Watcher) loop() {
+	defer w.wg.Done()
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case ev, ok := <-w.w.Events:
+			if !ok {
+				return
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					// Best effort: a directory that vanishes again is harmless.
+					_ = w.addTree(ev.Name)
+					timer.Reset(debounce)
+					continue
+				}
+			}
+			if isProfileFile(ev.Name) || w.isManifest(ev.Name) || ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+				timer.Reset(debounce)
+			}
+		case err, ok := <-w.w.Errors:
+			if !ok {
+				return
+			}
+			w.publish(Reload{Err: fmt.Errorf("loader: watch: %w", err)})
+		case <-timer.C:
+			profiles, rep, err := LoadWithOverlayVerified(w.embedded, w.verifier, w.dirs...)
+			w.publish(Reload{Profiles: profiles, Report: rep, Err: err})
+		}
+	}
+}
+
+// isManifest reports whether name is the verifier's manifest or its
+// signature, in any of the directories.
+func (w *Watcher) isManifest(name string) bool {
+	if w.verifier == nil {
+		return false
+	}
+	manifest := w.verifier.Manifest
+	if manifest == "" {
+		manifest = DefaultManifest
+	}
+	base, manifest := filepath.Base(name), path.Base(manifest)
+	return base == manifest || base == manifest+".minisig"
+}
+
+// publish hands r to every subscriber, replacing any Reload still unread.
+func (w *Watcher) publish(r Reload) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, ch := range w.subs {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- r
+	}
+}
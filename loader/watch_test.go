@@ -0,0 +1,85 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func nextReload(t *testing.T, ch <-chan Reload) Reload {
+	t.Helper()
+	select {
+	case r, ok := <-ch:
+		if !ok {
+			t.Fatal("subscriber channel closed")
+		}
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload within 5s")
+	}
+	return Reload{}
+}
+
+func hasProfile(r Reload, name string) bool {
+	for _, pf := range r.Profiles {
+		if pf.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Watch(embedded(t), dir)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	ch := w.Subscribe()
+
+	writeProfile(t, dir, "mytool.json", "linux")
+	r := nextReload(t, ch)
+	if r.Err != nil || !hasProfile(r, "mytool") || !hasProfile(r, "certutil") {
+		t.Fatalf("after create: err %v, %d profiles", r.Err, len(r.Profiles))
+	}
+
+	// Directories created after Watch are watched too.
+	sub := filepath.Join(dir, "windows")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	nextReload(t, ch)
+	writeProfile(t, sub, "nested.json", "windows")
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if r = nextReload(t, ch); hasProfile(r, "nested") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("nested profile never appeared")
+		}
+	}
+
+	if err := os.Remove(filepath.Join(dir, "mytool.json")); err != nil {
+		t.Fatal(err)
+	}
+	if r = nextReload(t, ch); hasProfile(r, "mytool") {
+		t.Error("removed profile still loaded")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, ok := <-ch; ok {
+		t.Error("channel should be closed after Close")
+	}
+	if _, ok := <-w.Subscribe(); ok {
+		t.Error("Subscribe after Close should return a closed channel")
+	}
+}
+
+func TestWatch_MissingDir(t *testing.T) {
+	if _, err := Watch(embedded(t), filepath.Join(t.TempDir(), "absent")); err == nil {
+		t.Error("watching a missing directory should fail")
+	}
+}
@@ -0,0 +1,224 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ─── YAML profiles ────────────────────────────────────────────────────────────
+
+// isProfileFile reports whether name has a profile file extension: .json,
+// .yaml or .yml.
+func isProfileFile(name string) bool {
+	switch path.Ext(name) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+func isYAML(name string) bool {
+	ext := path.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// yamlToJSON converts a YAML profile to the equivalent JSON document, keeping
+// mapping key order so modifier order survives as it does for JSON files.
+func yamlToJSON(data []byte) ([]byte, error) {
+	root, err := parseYAMLTree(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	root.appendJSON(&buf)
+	return buf.Bytes(), nil
+}
+
+// parseYAMLTree decodes a single-document YAML file into the same positioned
+// tree parseTree builds for JSON, so Validate reports YAML issues at the
+// right line and column. Anchors and aliases are expanded; "<<" merge keys
+// are not supported.
+func parseYAMLTree(data []byte) (*node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		return nil, fmt.Errorf("empty YAML document")
+	}
+	b := yamlBuilder{lines: lineStarts(data), data: data}
+	return b.build(&doc, 0)
+}
+
+type yamlBuilder struct {
+	data  []byte
+	lines []int // byte offset at which each line starts
+}
+
+// maxAliasDepth stops alias expansion from looping on self-referential
+// documents.
+const maxAliasDepth = 64
+
+func (b yamlBuilder) build(y *yaml.Node, depth int) (*node, error) {
+	if depth > maxAliasDepth {
+		return nil, fmt.Errorf("yaml: line %d: nesting too deep", y.Line)
+	}
+	n := &node{off: b.offset(y.Line, y.Column)}
+	switch y.Kind {
+	case yaml.DocumentNode:
+		if len(y.Content) != 1 {
+			return nil, fmt.Errorf("yaml: expected a single document")
+		}
+		return b.build(y.Content[0], depth)
+	case yaml.AliasNode:
+		return b.build(y.Alias, depth+1)
+	case yaml.SequenceNode:
+		n.kind = kindArray
+		for _, c := range y.Content {
+			item, err := b.build(c, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		}
+	case yaml.MappingNode:
+		n.kind, n.fields = kindObject, map[string]*node{}
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			k, v := y.Content[i], y.Content[i+1]
+			if k.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("yaml: line %d: mapping keys must be scalars", k.Line)
+			}
+			child, err := b.build(v, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := n.fields[k.Value]; !dup {
+				n.keys = append(n.keys, k.Value)
+				n.keyOffs = append(n.keyOffs, b.offset(k.Line, k.Column))
+			}
+			n.fields[k.Value] = child
+		}
+	case yaml.ScalarNode:
+		if err := scalar(n, y); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// scalar fills n from a resolved YAML scalar. Anything YAML does not type as
+// a number, boolean or null is a string, so quoting "0.5" is optional.
+func scalar(n *node, y *yaml.Node) error {
+	switch y.ShortTag() {
+	case "!!null":
+		n.kind = kindNull
+	case "!!bool":
+		var v bool
+		if err := y.Decode(&v); err != nil {
+			return err
+		}
+		n.kind, n.b = kindBool, v
+	case "!!int", "!!float":
+		var v float64
+		if err := y.Decode(&v); err != nil {
+			return err
+		}
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return fmt.Errorf("yaml: line %d: %s has no JSON equivalent", y.Line, y.Value)
+		}
+		n.kind, n.num = kindNumber, v
+		n.raw = strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		n.kind, n.str = kindString, y.Value
+	}
+	return nil
+}
+
+// offset converts yaml.v3's 1-based line and column (in characters) to a
+// byte offset in the source.
+func (b yamlBuilder) offset(line, col int) int {
+	if line < 1 || line > len(b.lines) {
+		return 0
+	}
+	off := b.lines[line-1]
+	for i := 1; i < col && off < len(b.data) && b.data[off] != '\n'; i++ {
+		_, size := utf8.DecodeRune(b.data[off:])
+		off += size
+	}
+	return off
+}
+
+func lineStarts(data []byte) []int {
+	starts := []int{0}
+	for i, c := range data {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// appendJSON writes n as JSON, keeping key order.
+func (n *node) appendJSON(buf *bytes.Buffer) {
+	switch n.kind {
+	case kindNull:
+		buf.WriteString("null")
+	case kindBool:
+		buf.WriteString(strconv.FormatBool(n.b))
+	case kindNumber:
+		buf.WriteString(n.raw)
+	case kindString:
+		s, _ := json.Marshal(n.str)
+		buf.Write(s)
+	case kindArray:
+		buf.WriteByte('[')
+		for i, item := range n.items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			item.appendJSON(buf)
+		}
+		buf.WriteByte(']')
+	case kindObject:
+		buf.WriteByte('{')
+		for i, k := range n.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(k)
+			buf.Write(key)
+			buf.WriteByte(':')
+			n.fields[k].appendJSON(buf)
+		}
+		buf.WriteByte('}')
+	}
+}
+
+var yamlErrLine = regexp.MustCompile(`line (\d+)`)
+
+// yamlErrorLine extracts the line number yaml.v3 puts in its error messages,
+// or 0.
+func yamlErrorLine(err error) int {
+	m := yamlErrLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line
+}
+
+// parseProfileTree parses a profile file of either format by its extension.
+func parseProfileTree(name string, data []byte) (*node, error) {
+	if isYAML(name) {
+		return parseYAMLTree(data)
+	}
+	return parseTree(data)
+}
@@ -0,0 +1,95 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"cmdFuscator/models"
+)
+
+const yamlProfile = `versions: { argfuscator: "2.0", format: "2.0" }
+profiles:
+  - platform: windows
+    alias: [ct]
+    parameters:
+      command:
+        - command: certutil.exe
+        - argument: -urlcache
+      arguments: []
+      modifiers:
+        Sed:
+          AppliesTo: [argument]
+          Probability: 0.5
+          SedStatements: |
+            s/a/ᵃ/i
+            s/b/ᵇ/i
+        RandomCase:
+          AppliesTo: &types [argument, path]
+          Probability: "0.5"
+        QuoteInsertion:
+          AppliesTo: *types
+          Probability: 1
+`
+
+func TestLoadFS_YAML(t *testing.T) {
+	profiles, rep, err := LoadFSReport(fstest.MapFS{"certutil.yaml": {Data: []byte(yamlProfile)}})
+	if err != nil || len(profiles) != 1 {
+		t.Fatalf("LoadFSReport: %v (%d profiles, %v)", err, len(profiles), rep.Skipped)
+	}
+	pf := profiles[0]
+	if pf.Name != "certutil" || pf.Profiles[0].Alias[0] != "ct" {
+		t.Errorf("unexpected profile %+v", pf)
+	}
+
+	params := pf.Profiles[0].Parameters
+	if got := params.ModifierNames(); !slices.Equal(got, []string{"Sed", "RandomCase", "QuoteInsertion"}) {
+		t.Errorf("modifier order = %v", got)
+	}
+	var sed struct {
+		models.BaseModifierConfig
+		SedStatements string
+	}
+	if err := json.Unmarshal(params.Modifiers["Sed"], &sed); err != nil {
+		t.Fatal(err)
+	}
+	if sed.Probability != "0.5" || sed.SedStatements != "s/a/ᵃ/i\ns/b/ᵇ/i\n" {
+		t.Errorf("Sed config = %+v", sed)
+	}
+	var quote models.BaseModifierConfig
+	if err := json.Unmarshal(params.Modifiers["QuoteInsertion"], &quote); err != nil || !slices.Equal(quote.AppliesTo, []string{"argument", "path"}) {
+		t.Errorf("alias not expanded: %+v, %v", quote, err)
+	}
+
+	// Encoding a YAML-loaded profile yields the ordinary JSON format.
+	var buf bytes.Buffer
+	if err := pf.Encode(&buf); err != nil || !json.Valid(buf.Bytes()) {
+		t.Errorf("Encode: %v", err)
+	}
+}
+
+func TestValidateFile_YAML(t *testing.T) {
+	bad := strings.Replace(yamlProfile, `Probability: "0.5"`, `Probability: "half"`, 1)
+	bad = strings.Replace(bad, "QuoteInsertion:", "QuoteInsert:", 1)
+
+	issues := ValidateFile("certutil.yml", []byte(bad))
+	if len(issues) != 2 {
+		t.Fatalf("issues = %v", issues)
+	}
+	if is := issues[0]; is.Line != 19 || !strings.Contains(is.Message, "between 0 and 1") {
+		t.Errorf("issue 0 = %s", is)
+	}
+	if is := issues[1]; is.Line != 20 || is.Col != 9 || !strings.Contains(is.Message, `unknown modifier "QuoteInsert"`) {
+		t.Errorf("issue 1 = %s", is)
+	}
+
+	if issues := ValidateFile("certutil.yaml", []byte(yamlProfile)); len(issues) != 0 {
+		t.Errorf("valid YAML reported %v", issues)
+	}
+	if issues := ValidateFile("x.yaml", []byte("a: [\n")); len(issues) != 1 || issues[0].Line == 0 {
+		t.Errorf("syntax error issues = %v", issues)
+	}
+}
@@ -0,0 +1,233 @@
+// Package lolbas reads metadata from the LOLBAS project
+// (https://lolbas-project.github.io): for each Windows binary that can be
+// abused to download, execute or hide things, what it can do, the ATT&CK
+// techniques those uses map to and the published detections for them.
+//
+// Entries are keyed by executable name, so a profile's entry is found from
+// its name or aliases. A snapshot of the entries for commonly obfuscated
+// binaries is embedded; the full list is fetched from the project's API, or
+// read from a saved copy of it, with Open.
+package lolbas
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"cmdFuscator/models"
+)
+
+// DefaultURL is the LOLBAS project's API listing every entry.
+const DefaultURL = "https://lolbas-project.github.io/api/lolbas.json"
+
+// maxSize bounds a fetched listing; the full API is a few MB.
+const maxSize = 32 << 20
+
+//go:embed lolbas.json
+var snapshot []byte
+
+// ─── Entries ──────────────────────────────────────────────────────────────────
+
+// Entry is one binary's page, in the layout of the LOLBAS API.
+type Entry struct {
+	Name        string      `json:"Name"` // file name, such as "Certutil.exe"
+	Description string      `json:"Description"`
+	Commands    []Command   `json:"Commands"`
+	FullPath    []Path      `json:"Full_Path"`
+	Detection   []Detection `json:"Detection"`
+}
+
+// Command is one documented use of a binary.
+type Command struct {
+	Command         string `json:"Command"`
+	Description     string `json:"Description"`
+	Usecase         string `json:"Usecase"`
+	Category        string `json:"Category"` // the function, such as "Download" or "AWL Bypass"
+	Privileges      string `json:"Privileges"`
+	MitreID         string `json:"MitreID"`
+	OperatingSystem string `json:"OperatingSystem"`
+}
+
+// Path is a location the binary ships in.
+type Path struct {
+	Path string `json:"Path"`
+}
+
+// Detection is one published detection: a Sigma, Elastic or Splunk rule URL,
+// a block rule, or an IOC described in words. The API writes each as an
+// object with a single key naming its kind.
+type Detection struct {
+	Kind  string // "Sigma", "Elastic", "Splunk", "BlockRule", "IOC", ...
+	Value string
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Detection) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	for kind, value := range m {
+		d.Kind, d.Value = kind, value
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Detection) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{d.Kind: d.Value})
+}
+
+// Functions returns the distinct categories of e's commands, in the order
+// the entry lists them.
+func (e *Entry) Functions() []string {
+	var out []string
+	for _, c := range e.Commands {
+		if c.Category != "" && !slices.Contains(out, c.Category) {
+			out = append(out, c.Category)
+		}
+	}
+	return out
+}
+
+// Techniques returns the distinct ATT&CK technique IDs of e's commands, in
+// the order the entry lists them.
+func (e *Entry) Techniques() []string {
+	var out []string
+	for _, c := range e.Commands {
+		if c.MitreID != "" && !slices.Contains(out, c.MitreID) {
+			out = append(out, c.MitreID)
+		}
+	}
+	return out
+}
+
+// ─── Catalog ──────────────────────────────────────────────────────────────────
+
+// Catalog is a set of entries, looked up by executable name.
+type Catalog struct {
+	entries []*Entry
+	byName  map[string]*Entry
+}
+
+// Parse reads a catalog in the layout of the LOLBAS API: a JSON array of
+// entries.
+func Parse(data []byte) (*Catalog, error) {
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("lolbas: %w", err)
+	}
+	c := &Catalog{byName: make(map[string]*Entry, 2*len(entries))}
+	for _, e := range entries {
+		if e == nil || e.Name == "" {
+			continue
+		}
+		c.entries = append(c.entries, e)
+		name := strings.ToLower(e.Name)
+		c.byName[name] = e
+		if base, ok := strings.CutSuffix(name, ".exe"); ok {
+			if _, taken := c.byName[base]; !taken {
+				c.byName[base] = e
+			}
+		}
+	}
+	return c, nil
+}
+
+// Embedded returns the catalog of the embedded snapshot.
+func Embedded() *Catalog {
+	c, err := Parse(snapshot)
+	if err != nil {
+		panic(err) // the snapshot is checked by the tests
+	}
+	return c
+}
+
+// Len returns how many entries c holds.
+func (c *Catalog) Len() int {
+	return len(c.entries)
+}
+
+// Lookup returns the entry of the binary called name, ignoring case; ".exe"
+// may be left off.
+func (c *Catalog) Lookup(name string) (*Entry, bool) {
+	e, ok := c.byName[strings.ToLower(name)]
+	return e, ok
+}
+
+// ForProfile returns the entry of pf's executable, looked up by its name and
+// then its aliases. LOLBAS covers Windows binaries only, so profiles without
+// a Windows platform have none: the bash profile is not WSL's bash.exe.
+func (c *Catalog) ForProfile(pf *models.ProfileFile) (*Entry, bool) {
+	if !slices.ContainsFunc(pf.Profiles, func(p models.Profile) bool { return strings.EqualFold(p.Platform, "windows") }) {
+		return nil, false
+	}
+	for _, name := range append([]string{pf.Name}, pf.Aliases()...) {
+		if e, ok := c.Lookup(name); ok {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// ─── Sources ──────────────────────────────────────────────────────────────────
+
+// Open returns the catalog source names: "" or "embedded" for the embedded
+// snapshot, "remote" for DefaultURL, an http or https URL, or the path of a
+// saved API listing.
+func Open(ctx context.Context, source string) (*Catalog, error) {
+	switch {
+	case source == "" || source == "embedded":
+		return Embedded(), nil
+	case source == "remote":
+		return Fetch(ctx, nil, DefaultURL)
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		return Fetch(ctx, nil, source)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("lolbas: %w", err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return c, nil
+}
+
+// Fetch downloads the listing at url with client, or a client with a 30s
+// timeout when client is nil.
+func Fetch(ctx context.Context, client *http.Client, url string) (*Catalog, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("lolbas: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("lolbas: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lolbas: %s: %s", url, resp.Status)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(resp.Body, maxSize+1)); err != nil {
+		return nil, fmt.Errorf("lolbas: %s: %w", url, err)
+	}
+	if buf.Len() > maxSize {
+		return nil, fmt.Errorf("lolbas: %s: listing larger than %d bytes", url, maxSize)
+	}
+	return Parse(buf.Bytes())
+}
@@ -0,0 +1,117 @@
+package lolbas
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func TestEmbedded(t *testing.T) {
+	c := Embedded()
+	if c.Len() == 0 {
+		t.Fatal("embedded snapshot is empty")
+	}
+	for _, name := range []string{"certutil", "CertUtil.exe", "certutil.exe"} {
+		e, ok := c.Lookup(name)
+		if !ok || e.Name != "Certutil.exe" {
+			t.Fatalf("Lookup(%q) = %v, %v; want Certutil.exe", name, e, ok)
+		}
+	}
+	e, _ := c.Lookup("certutil")
+	if got, want := e.Functions(), []string{"Download", "ADS", "Encode", "Decode"}; !slices.Equal(got, want) {
+		t.Errorf("Functions() = %q, want %q", got, want)
+	}
+	if got, want := e.Techniques(), []string{"T1105", "T1564.004", "T1027.013", "T1140"}; !slices.Equal(got, want) {
+		t.Errorf("Techniques() = %q, want %q", got, want)
+	}
+	if len(e.Detection) == 0 || e.Detection[0].Kind != "Sigma" || !strings.HasPrefix(e.Detection[0].Value, "https://") {
+		t.Errorf("Detection = %+v, want a Sigma rule URL first", e.Detection)
+	}
+	if _, ok := c.Lookup("notepad"); ok {
+		t.Error("Lookup(notepad): want no entry")
+	}
+}
+
+func TestForProfile(t *testing.T) {
+	c := Embedded()
+	tests := []struct {
+		name string
+		pf   *models.ProfileFile
+		want string
+	}{
+		{"by name", &models.ProfileFile{Name: "certutil", Profiles: []models.Profile{{Platform: "windows"}}}, "Certutil.exe"},
+		{"by alias", &models.ProfileFile{Name: "mytool", Profiles: []models.Profile{{Platform: "windows", Alias: []string{"mshta"}}}}, "Mshta.exe"},
+		{"not windows", &models.ProfileFile{Name: "bash", Profiles: []models.Profile{{Platform: "linux"}}}, ""},
+		{"unknown", &models.ProfileFile{Name: "curl", Profiles: []models.Profile{{Platform: "windows"}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if e, ok := c.ForProfile(tt.pf); ok {
+				got = e.Name
+			}
+			if got != tt.want {
+				t.Errorf("ForProfile = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectionJSON(t *testing.T) {
+	var d Detection
+	if err := json.Unmarshal([]byte(`{"IOC": "child process"}`), &d); err != nil || d != (Detection{"IOC", "child process"}) {
+		t.Fatalf("Unmarshal = %+v, %v", d, err)
+	}
+	data, err := json.Marshal(d)
+	if err != nil || string(data) != `{"IOC":"child process"}` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	listing := `[{"Name": "Foo.exe", "Commands": [{"Category": "Execute", "MitreID": "T1218"}]}]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/lolbas.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(listing))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "lolbas.json")
+	if err := os.WriteFile(path, []byte(listing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{srv.URL + "/api/lolbas.json", path} {
+		c, err := Open(context.Background(), source)
+		if err != nil {
+			t.Fatalf("Open(%s): %v", source, err)
+		}
+		if e, ok := c.Lookup("foo"); !ok || c.Len() != 1 || !slices.Equal(e.Techniques(), []string{"T1218"}) {
+			t.Errorf("Open(%s) = %d entries, foo %+v", source, c.Len(), e)
+		}
+	}
+	if c, err := Open(context.Background(), ""); err != nil || c.Len() != Embedded().Len() {
+		t.Errorf(`Open("") = %v, %v; want the embedded snapshot`, c, err)
+	}
+
+	for _, source := range []string{srv.URL + "/missing", filepath.Join(t.TempDir(), "nope.json")} {
+		if _, err := Open(context.Background(), source); err == nil {
+			t.Errorf("Open(%s): want an error", source)
+		}
+	}
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(bad, []byte("{"), 0o644)
+	if _, err := Open(context.Background(), bad); err == nil || !strings.Contains(err.Error(), "bad.json: lolbas: ") {
+		t.Errorf("Open(bad.json) = %v, want a parse error naming the file", err)
+	}
+}
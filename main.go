@@ -7,6 +7,14 @@
 //   - [cmdFuscator/loader]  – Parse ArgFuscator JSON profile files
 //   - [cmdFuscator/engine]  – Orchestrate the obfuscation pipeline
 //   - [cmdFuscator/engine/modifiers] – Modifier interface and registry
+//   - [cmdFuscator/deobfuscate] – Normalize obfuscated command lines
+//
+// Programs that just want obfuscated strings can use [Obfuscate] and
+// [Variants] from this package instead, which load the bundled profiles and
+// wire up the engine themselves:
+//
+//	out, err := cmdfuscator.Obfuscate(ctx, "certutil -urlcache -f https://x",
+//		cmdfuscator.WithSeed(1), cmdfuscator.WithModifiers("RandomCase"))
 //
 // The TUI application lives in cmd/cmdfuscator/ and is not part of the
 // public module API.
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ─── Profile builder ──────────────────────────────────────────────────────────
+
+// FormatVersion is the ArgFuscator profile format version this package reads
+// and writes.
+const FormatVersion = "2.0"
+
+// ProfileBuilder assembles a ProfileFile in code, for custom profiles of
+// in-house tools that would otherwise be hand-written JSON. Methods configure
+// the current profile and return the builder so calls can be chained; errors
+// are kept and reported by Build.
+//
+//	pf, err := models.NewProfileBuilder().
+//	    Name("mytool").
+//	    Platform("windows").
+//	    AddArgument(1, "-o", "/out").
+//	    AddModifier("RandomCase", randomcase.Config{...}).
+//	    Build()
+type ProfileBuilder struct {
+	pf  ProfileFile
+	cur int // index of the profile being configured
+	err error
+}
+
+// NewProfileBuilder starts a ProfileFile with one empty profile ready to
+// configure.
+func NewProfileBuilder() *ProfileBuilder {
+	return &ProfileBuilder{pf: ProfileFile{
+		Versions: Versions{ArgFuscator: FormatVersion, Format: FormatVersion},
+		Profiles: []Profile{{}},
+	}}
+}
+
+// Name sets the executable name the file describes (ProfileFile.Name).
+func (b *ProfileBuilder) Name(name string) *ProfileBuilder {
+	b.pf.Name = name
+	return b
+}
+
+// NextProfile starts another profile in the same file, e.g. for a second
+// platform. Later calls configure the new profile.
+func (b *ProfileBuilder) NextProfile() *ProfileBuilder {
+	b.pf.Profiles = append(b.pf.Profiles, Profile{})
+	b.cur = len(b.pf.Profiles) - 1
+	return b
+}
+
+func (b *ProfileBuilder) profile() *Profile { return &b.pf.Profiles[b.cur] }
+
+// Platform sets the platform: "windows", "linux" or "macos".
+func (b *ProfileBuilder) Platform(platform string) *ProfileBuilder {
+	b.profile().Platform = platform
+	return b
+}
+
+// OperatingSystem sets the operating system name and version shown to users.
+func (b *ProfileBuilder) OperatingSystem(name, version string) *ProfileBuilder {
+	p := b.profile()
+	p.OperatingSystem, p.OperatingSystemVersion = name, version
+	return b
+}
+
+// ExecutableVersion records which version of the executable was profiled.
+func (b *ProfileBuilder) ExecutableVersion(v string) *ProfileBuilder {
+	b.profile().ExecutableVersion = v
+	return b
+}
+
+// Alias adds alternative executable names, e.g. "pwsh" for powershell.
+func (b *ProfileBuilder) Alias(names ...string) *ProfileBuilder {
+	p := b.profile()
+	p.Alias = append(p.Alias, names...)
+	return b
+}
+
+// Paths adds the absolute paths the executable is installed at, e.g.
+// `C:\Windows\System32\certutil.exe`.
+func (b *ProfileBuilder) Paths(paths ...string) *ProfileBuilder {
+	p := b.profile()
+	p.Paths = append(p.Paths, paths...)
+	return b
+}
+
+// Description sets the profile's free-text description.
+func (b *ProfileBuilder) Description(text string) *ProfileBuilder {
+	b.profile().Description = text
+	return b
+}
+
+// Attack tags the profile with MITRE ATT&CK technique IDs, e.g. "T1105".
+func (b *ProfileBuilder) Attack(ids ...string) *ProfileBuilder {
+	p := b.profile()
+	p.Attack = append(p.Attack, ids...)
+	return b
+}
+
+// Command appends elements to the example command template.
+func (b *ProfileBuilder) Command(elems ...CommandElement) *ProfileBuilder {
+	p := b.profile()
+	p.Parameters.Command = append(p.Parameters.Command, elems...)
+	return b
+}
+
+// AddArgument declares a flag, with all its equivalent spellings, that consumes
+// valueCount following values.
+func (b *ProfileBuilder) AddArgument(valueCount int, flags ...string) *ProfileBuilder {
+	switch {
+	case len(flags) == 0:
+		b.fail(errors.New("AddArgument: no flags given"))
+	case valueCount < 0:
+		b.fail(fmt.Errorf("AddArgument %s: negative value count", flags[0]))
+	default:
+		p := b.profile()
+		p.Parameters.Arguments = append(p.Parameters.Arguments, ArgumentDefinition{Flags: flags, ValueCount: valueCount})
+	}
+	return b
+}
+
+// AddModifier enables a modifier with the given config. cfg may be a
+// json.RawMessage, or any value that marshals to the modifier's config object
+// (typically the modifier package's Config struct). Adding the same name again
+// replaces its config but keeps its position.
+func (b *ProfileBuilder) AddModifier(name string, cfg any) *ProfileBuilder {
+	raw, ok := cfg.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(cfg); err != nil {
+			b.fail(fmt.Errorf("AddModifier %s: %w", name, err))
+			return b
+		}
+	}
+	if !json.Valid(raw) {
+		b.fail(fmt.Errorf("AddModifier %s: config is not valid JSON", name))
+		return b
+	}
+	b.profile().Parameters.SetModifier(name, raw)
+	return b
+}
+
+// Build returns the assembled ProfileFile, or the first error recorded while
+// building. Every profile must have a known platform and at least one
+// modifier.
+func (b *ProfileBuilder) Build() (*ProfileFile, error) {
+	if b.err != nil {
+		return nil, fmt.Errorf("profile builder: %w", b.err)
+	}
+	if strings.TrimSpace(b.pf.Name) == "" {
+		return nil, errors.New("profile builder: empty executable name")
+	}
+	for i, p := range b.pf.Profiles {
+		switch p.Platform {
+		case "windows", "linux", "macos":
+		default:
+			return nil, fmt.Errorf("profile builder: profile %d: unknown platform %q", i, p.Platform)
+		}
+		if len(p.Parameters.Modifiers) == 0 {
+			return nil, fmt.Errorf("profile builder: profile %d: no modifiers", i)
+		}
+	}
+
+	pf := b.pf
+	pf.Profiles = append([]Profile(nil), b.pf.Profiles...)
+	return &pf, nil
+}
+
+func (b *ProfileBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProfileBuilder_Build(t *testing.T) {
+	pf, err := NewProfileBuilder().
+		Name("mytool").
+		Platform("windows").
+		OperatingSystem("Windows", "11").
+		Alias("mytool64").
+		Paths(`C:\Tools\mytool.exe`).
+		Command(CommandElement{Command: "mytool"}, CommandElement{Argument: "/out"}, CommandElement{Path: `C:\x`}).
+		AddArgument(1, "/out", "-o").
+		AddModifier("RandomCase", BaseModifierConfig{AppliesTo: []string{"argument"}, Probability: "0.5"}).
+		AddModifier("Sed", json.RawMessage(`{"AppliesTo":["argument"],"Probability":"0.5"}`)).
+		NextProfile().
+		Platform("linux").
+		AddModifier("RandomCase", json.RawMessage(`{"AppliesTo":["value"],"Probability":1}`)).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if pf.Name != "mytool" || pf.Versions.Format != FormatVersion || len(pf.Profiles) != 2 {
+		t.Fatalf("unexpected file: %+v", pf)
+	}
+	win := pf.Profiles[0]
+	if win.Platform != "windows" || !slices.Equal(win.Alias, []string{"mytool64"}) || !slices.Equal(win.Paths, []string{`C:\Tools\mytool.exe`}) {
+		t.Errorf("windows profile = %+v", win)
+	}
+	if got := win.Parameters.ModifierNames(); !slices.Equal(got, []string{"RandomCase", "Sed"}) {
+		t.Errorf("ModifierNames() = %v", got)
+	}
+	if n := win.Parameters.Arguments[0].ValueCount; n != 1 {
+		t.Errorf("ValueCount = %d", n)
+	}
+
+	// The result must survive an encode/decode round trip.
+	var buf bytes.Buffer
+	if err := pf.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var back ProfileFile
+	if err := json.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatalf("decode built profile: %v", err)
+	}
+	var cfg BaseModifierConfig
+	if err := json.Unmarshal(back.Profiles[0].Parameters.Modifiers["RandomCase"], &cfg); err != nil || cfg.Probability != "0.5" {
+		t.Errorf("RandomCase config = %+v, %v", cfg, err)
+	}
+}
+
+func TestProfileBuilder_Errors(t *testing.T) {
+	mod := json.RawMessage(`{}`)
+	cases := map[string]*ProfileBuilder{
+		"no name":        NewProfileBuilder().Platform("linux").AddModifier("X", mod),
+		"bad platform":   NewProfileBuilder().Name("x").Platform("beos").AddModifier("X", mod),
+		"no modifiers":   NewProfileBuilder().Name("x").Platform("linux"),
+		"no flags":       NewProfileBuilder().Name("x").Platform("linux").AddModifier("X", mod).AddArgument(1),
+		"bad value cnt":  NewProfileBuilder().Name("x").Platform("linux").AddModifier("X", mod).AddArgument(-1, "-a"),
+		"invalid json":   NewProfileBuilder().Name("x").Platform("linux").AddModifier("X", json.RawMessage(`{`)),
+		"unmarshallable": NewProfileBuilder().Name("x").Platform("linux").AddModifier("X", func() {}),
+	}
+	for name, b := range cases {
+		if _, err := b.Build(); err == nil {
+			t.Errorf("%s: Build should fail", name)
+		} else if !strings.HasPrefix(err.Error(), "profile builder:") {
+			t.Errorf("%s: error %q lacks prefix", name, err)
+		}
+	}
+}
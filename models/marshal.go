@@ -0,0 +1,169 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+)
+
+// ─── Round-trip serialization ─────────────────────────────────────────────────
+
+// encodeJSON marshals v without HTML escaping, so "&" and "<" in commands and
+// URLs are written as-is, the way hand-edited profiles contain them.
+func encodeJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// Encode writes pf to w in the ArgFuscator 2.0 layout: two-space indentation,
+// fields in format order, empty aliases omitted, and modifiers in the order
+// they were read or added. Decoding the output yields an equal ProfileFile.
+func (pf *ProfileFile) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(pf)
+}
+
+// ModifierNames returns the keys of Modifiers in profile order: the order they
+// appeared in the source JSON or were added with SetModifier, followed by any
+// keys only present in the map, sorted.
+func (pp ProfileParameters) ModifierNames() []string {
+	names := make([]string, 0, len(pp.Modifiers))
+	seen := make(map[string]bool, len(pp.Modifiers))
+	for _, name := range pp.order {
+		if _, ok := pp.Modifiers[name]; ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	var rest []string
+	for name := range pp.Modifiers {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// SetModifier sets the raw config for a modifier, appending it to the profile
+// order if it is new.
+func (pp *ProfileParameters) SetModifier(name string, cfg json.RawMessage) {
+	if pp.Modifiers == nil {
+		pp.Modifiers = make(map[string]json.RawMessage)
+	}
+	if _, ok := pp.Modifiers[name]; !ok && !slices.Contains(pp.order, name) {
+		pp.order = append(pp.order, name)
+	}
+	pp.Modifiers[name] = cfg
+}
+
+// UnmarshalJSON decodes the parameters and remembers the order of the
+// modifiers object's keys, which a Go map would otherwise lose.
+func (pp *ProfileParameters) UnmarshalJSON(data []byte) error {
+	type plain ProfileParameters
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	var raw struct {
+		Modifiers json.RawMessage `json:"modifiers"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	order, err := objectKeys(raw.Modifiers)
+	if err != nil {
+		return fmt.Errorf("modifiers: %w", err)
+	}
+
+	*pp = ProfileParameters(p)
+	pp.order = order
+	return nil
+}
+
+// MarshalJSON encodes the parameters with modifiers in ModifierNames order.
+func (pp ProfileParameters) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	command, err := encodeJSON(nonNil(pp.Command))
+	if err != nil {
+		return nil, err
+	}
+	arguments, err := encodeJSON(nonNil(pp.Arguments))
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString(`{"command":`)
+	buf.Write(command)
+	buf.WriteString(`,"arguments":`)
+	buf.Write(arguments)
+	buf.WriteString(`,"modifiers":{`)
+
+	for i, name := range pp.ModifierNames() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := encodeJSON(name)
+		if err != nil {
+			return nil, err
+		}
+		cfg := pp.Modifiers[name]
+		if len(cfg) == 0 {
+			cfg = json.RawMessage("{}")
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(cfg)
+	}
+	buf.WriteString("}}")
+
+	return buf.Bytes(), nil
+}
+
+// nonNil returns s, or an empty slice when s is nil, so empty lists encode as
+// [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// objectKeys returns the keys of the JSON object in data, in order. Empty
+// input and null yield no keys.
+func objectKeys(data json.RawMessage) ([]string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected object, got %v", tok)
+	}
+
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
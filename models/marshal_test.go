@@ -0,0 +1,91 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func compact(t *testing.T, b []byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	return buf.String()
+}
+
+// Every bundled profile must survive decode → Encode byte-for-byte, modulo
+// whitespace.
+func TestProfileFile_RoundTripBundled(t *testing.T) {
+	files, err := filepath.Glob("../data/models/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no bundled profiles found: %v", err)
+	}
+	for _, f := range files {
+		src, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pf ProfileFile
+		if err := json.Unmarshal(src, &pf); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+		var out bytes.Buffer
+		if err := pf.Encode(&out); err != nil {
+			t.Fatalf("%s: encode: %v", f, err)
+		}
+		if got, want := compact(t, out.Bytes()), compact(t, src); got != want {
+			t.Errorf("%s: round trip differs\n got  %s\n want %s", f, got, want)
+		}
+	}
+}
+
+func TestProfileParameters_ModifierOrder(t *testing.T) {
+	src := `{"command":[],"arguments":[],"modifiers":{"Zed":{},"Alpha":{"A":1},"Mid":{}}}`
+	var pp ProfileParameters
+	if err := json.Unmarshal([]byte(src), &pp); err != nil {
+		t.Fatal(err)
+	}
+	if got := pp.ModifierNames(); !slices.Equal(got, []string{"Zed", "Alpha", "Mid"}) {
+		t.Errorf("ModifierNames() = %v", got)
+	}
+
+	pp.SetModifier("New", json.RawMessage(`{}`))
+	pp.SetModifier("Zed", json.RawMessage(`{"Z":true}`))
+	pp.Modifiers["Direct"] = json.RawMessage(`{}`) // bypasses SetModifier; sorts last
+	b, err := json.Marshal(pp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"command":[],"arguments":[],"modifiers":{"Zed":{"Z":true},"Alpha":{"A":1},"Mid":{},"New":{},"Direct":{}}}`
+	if string(b) != want {
+		t.Errorf("Marshal =\n %s\nwant\n %s", b, want)
+	}
+}
+
+func TestProfile_OmitsEmptyAlias(t *testing.T) {
+	b, err := json.Marshal(Profile{Platform: "linux"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte(`"alias"`)) {
+		t.Errorf("empty alias should be omitted: %s", b)
+	}
+}
+
+func TestEncode_NoHTMLEscaping(t *testing.T) {
+	pf := ProfileFile{Profiles: []Profile{{Parameters: ProfileParameters{
+		Command: []CommandElement{{URL: "https://x/?a=1&b=<2>"}},
+	}}}}
+	var out bytes.Buffer
+	if err := pf.Encode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte(`"https://x/?a=1&b=<2>"`)) {
+		t.Errorf("URL was escaped:\n%s", out.String())
+	}
+}
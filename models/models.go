@@ -1,9 +1,14 @@
 // Package models defines the data structures that map to the ArgFuscator JSON
 // profile format (format version 2.0). These types are shared across the loader,
-// engine, and any future de-obfuscator packages.
+// engine, and the deobfuscate package.
 package models
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // ─── Token ───────────────────────────────────────────────────────────────────
 
@@ -17,6 +22,9 @@ const (
 	TokenTypeValue    TokenType = "value"    // a plain value for a preceding argument
 	TokenTypePath     TokenType = "path"     // a file-system path
 	TokenTypeURL      TokenType = "url"      // a URL
+
+	TokenTypeEnvVar    TokenType = "envvar"    // an environment variable, e.g. %TEMP% or $HOME
+	TokenTypeExpansion TokenType = "expansion" // a command substitution, e.g. $(whoami) or `id`
 )
 
 // Token is the unit that the engine and modifiers operate on.
@@ -25,8 +33,22 @@ const (
 type Token struct {
 	Type  TokenType
 	Value string
+
+	// Frozen marks a token that must reach the output unchanged, e.g. a URL
+	// whose payload still has to download. The engine withholds frozen tokens
+	// from every modifier and splices them back in afterwards.
+	Frozen bool
+
+	// Start and End are the byte offsets of the token's source text in the
+	// original command, as set by the tokenizer: command[Start:End]. Modifiers
+	// keep them when rewriting a token's Value; tokens without a source, such
+	// as ones a modifier inserts, have End == 0.
+	Start, End int
 }
 
+// HasSpan reports whether t carries source offsets from the tokenizer.
+func (t Token) HasSpan() bool { return t.End > 0 }
+
 // ─── Profile file ─────────────────────────────────────────────────────────────
 
 // ProfileFile is the root of a single JSON model file.
@@ -38,6 +60,52 @@ type ProfileFile struct {
 	Profiles []Profile `json:"profiles"`
 }
 
+// Aliases returns the distinct alternative executable names declared across all
+// profiles in the file (e.g. "pwsh" for powershell), in declaration order.
+func (pf *ProfileFile) Aliases() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, p := range pf.Profiles {
+		for _, a := range p.Alias {
+			key := strings.ToLower(a)
+			if a == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Techniques returns the distinct MITRE ATT&CK technique IDs tagged across all
+// profiles in the file, uppercased, in declaration order.
+func (pf *ProfileFile) Techniques() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, p := range pf.Profiles {
+		for _, id := range p.Attack {
+			id = strings.ToUpper(strings.TrimSpace(id))
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// Description returns the first non-empty profile description in the file.
+func (pf *ProfileFile) Description() string {
+	for _, p := range pf.Profiles {
+		if p.Description != "" {
+			return p.Description
+		}
+	}
+	return ""
+}
+
 // Versions holds format metadata from the JSON file header.
 type Versions struct {
 	ArgFuscator string `json:"argfuscator"`
@@ -49,12 +117,15 @@ type Versions struct {
 // Profile is a single OS/version-specific configuration for one executable.
 // A ProfileFile may contain multiple Profiles (one per OS or version).
 type Profile struct {
-	ExecutableVersion     string            `json:"executableVersion"`
-	Platform              string            `json:"platform"`              // "windows" | "linux" | "macos"
-	OperatingSystem       string            `json:"operatingSystem"`       // "Windows" | "Ubuntu" | "macOS"
-	OperatingSystemVersion string           `json:"operatingSystemVersion"`
-	Alias                 []string          `json:"alias"`
-	Parameters            ProfileParameters `json:"parameters"`
+	ExecutableVersion      string            `json:"executableVersion"`
+	Platform               string            `json:"platform"`        // "windows" | "linux" | "macos"
+	OperatingSystem        string            `json:"operatingSystem"` // "Windows" | "Ubuntu" | "macOS"
+	OperatingSystemVersion string            `json:"operatingSystemVersion"`
+	Alias                  []string          `json:"alias,omitempty"`
+	Paths                  []string          `json:"paths,omitempty"`       // absolute install paths, e.g. `C:\Windows\System32\certutil.exe`
+	Description            string            `json:"description,omitempty"` // what the executable is abused for
+	Attack                 []string          `json:"attack,omitempty"`      // MITRE ATT&CK technique IDs, e.g. "T1105"
+	Parameters             ProfileParameters `json:"parameters"`
 }
 
 // ProfileParameters bundles the command template, known arguments, and modifier
@@ -71,6 +142,10 @@ type ProfileParameters struct {
 	// Using json.RawMessage lets each modifier unmarshal its own extra fields
 	// without requiring a union type here.
 	Modifiers map[string]json.RawMessage `json:"modifiers"`
+
+	// order records the modifiers' key order for round-trip encoding; see
+	// ModifierNames and marshal.go.
+	order []string
 }
 
 // ─── Command element ──────────────────────────────────────────────────────────
@@ -131,6 +206,14 @@ func (c CommandElement) ToToken() Token {
 type ArgumentDefinition struct {
 	Flags      []string `json:"flags"`
 	ValueCount int      `json:"valueCount"`
+	// Repeatable marks a flag the executable accepts more than once, with
+	// the same effect as once; DuplicateFlags only repeats these.
+	Repeatable bool `json:"repeatable,omitempty"`
+	// NumberForms lists the spellings besides plain decimal the executable
+	// accepts for this argument's numeric values: "zeros" (leading zeros,
+	// 0080), "hex" (0x50) and "octal" (0120). NumericMangling picks from
+	// these.
+	NumberForms []string `json:"numberForms,omitempty"`
 }
 
 // ─── Base modifier config ─────────────────────────────────────────────────────
@@ -148,10 +231,41 @@ type ArgumentDefinition struct {
 //	json.Unmarshal(rawMsg, &cfg)
 type BaseModifierConfig struct {
 	// AppliesTo is the set of TokenTypes this modifier should act on.
-	// Values match the TokenType constants: "command", "argument", "value", "path", "url".
+	// Values match the TokenType constants: "command", "argument", "value", "path",
+	// "url", "envvar", "expansion".
 	AppliesTo []string `json:"AppliesTo"`
 
-	// Probability is a string in [0.0, 1.0] controlling how often the modifier
-	// fires on each eligible token. Parse with strconv.ParseFloat.
-	Probability string `json:"Probability"`
+	// Probability is a value in [0.0, 1.0] controlling how often the modifier
+	// fires on each eligible token. Profiles may write it as a string or a
+	// number; read it with Probability.Float.
+	Probability ProbabilityValue `json:"Probability"`
+}
+
+// ProbabilityValue is the Probability field of a modifier config. Upstream
+// profiles encode it as a JSON string ("0.5") while hand-written configs often
+// use a number (0.5); both unmarshal into the same textual form, and it is
+// marshalled back as a string to stay compatible with the ArgFuscator format.
+type ProbabilityValue string
+
+// UnmarshalJSON accepts a JSON string, a JSON number, or null (empty).
+func (p *ProbabilityValue) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*p = ""
+		if s != nil {
+			*p = ProbabilityValue(*s)
+		}
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("probability must be a string or a number, got %s", data)
+	}
+	*p = ProbabilityValue(n)
+	return nil
+}
+
+// Float parses the probability. It does not check the [0, 1] range.
+func (p ProbabilityValue) Float() (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(string(p)), 64)
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProbabilityValue_Unmarshal(t *testing.T) {
+	cases := []struct {
+		json string
+		want float64
+	}{
+		{`{"Probability":"0.5"}`, 0.5},
+		{`{"Probability":0.25}`, 0.25},
+		{`{"Probability":1}`, 1},
+		{`{"Probability":" 0.75 "}`, 0.75},
+	}
+	for _, tc := range cases {
+		var c BaseModifierConfig
+		if err := json.Unmarshal([]byte(tc.json), &c); err != nil {
+			t.Errorf("%s: unmarshal: %v", tc.json, err)
+			continue
+		}
+		got, err := c.Probability.Float()
+		if err != nil || got != tc.want {
+			t.Errorf("%s: Float() = %v, %v; want %v", tc.json, got, err, tc.want)
+		}
+	}
+}
+
+func TestProbabilityValue_Invalid(t *testing.T) {
+	var c BaseModifierConfig
+	if err := json.Unmarshal([]byte(`{"Probability":true}`), &c); err == nil {
+		t.Error("boolean probability should fail to unmarshal")
+	}
+
+	for _, raw := range []string{`{"Probability":"often"}`, `{"Probability":null}`, `{}`} {
+		c = BaseModifierConfig{}
+		if err := json.Unmarshal([]byte(raw), &c); err != nil {
+			t.Errorf("%s: unmarshal: %v", raw, err)
+			continue
+		}
+		if _, err := c.Probability.Float(); err == nil {
+			t.Errorf("%s: Float() should fail", raw)
+		}
+	}
+}
+
+func TestProbabilityValue_MarshalsAsString(t *testing.T) {
+	var c BaseModifierConfig
+	if err := json.Unmarshal([]byte(`{"AppliesTo":null,"Probability":0.5}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `{"AppliesTo":null,"Probability":"0.5"}`; got != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
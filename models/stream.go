@@ -0,0 +1,106 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// ─── Token stream ─────────────────────────────────────────────────────────────
+
+// TokenStream is a token slice with the structural helpers modifiers keep
+// needing: finding arguments, pairing flags with their values, and editing
+// the slice without clobbering the caller's copy. Convert freely between
+// TokenStream and []Token.
+type TokenStream []Token
+
+// ArgGroup is a contiguous run of tokens that belongs together: a flag and the
+// values it consumes, or a single token that is not a flag.
+type ArgGroup struct {
+	Start int                 // index of the group's first token
+	End   int                 // index one past the group's last token
+	Def   *ArgumentDefinition // definition matched by the flag; nil if unknown or not a flag
+}
+
+// Len returns the number of tokens in the group.
+func (g ArgGroup) Len() int { return g.End - g.Start }
+
+// Arguments returns the indices of all TokenTypeArgument tokens, in order.
+func (s TokenStream) Arguments() []int {
+	var out []int
+	for i, t := range s {
+		if t.Type == TokenTypeArgument {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// PairsWithValues splits every token after the command into ArgGroups that
+// together cover s[1:] in order. An argument token matching one of defs takes
+// up to the definition's ValueCount following tokens, stopping early at the
+// next argument. Unknown flags and every other token form one-token groups.
+//
+// Flags are matched exactly first and then case-insensitively, so Windows
+// spellings such as "/URLCache" still find "/urlcache".
+func (s TokenStream) PairsWithValues(defs []ArgumentDefinition) []ArgGroup {
+	var groups []ArgGroup
+	for i := 1; i < len(s); {
+		g := ArgGroup{Start: i, End: i + 1}
+		if s[i].Type == TokenTypeArgument {
+			g.Def = findDefinition(defs, s[i].Value)
+		}
+		if g.Def != nil {
+			for n := 0; n < g.Def.ValueCount && g.End < len(s) && s[g.End].Type != TokenTypeArgument; n++ {
+				g.End++
+			}
+		}
+		groups = append(groups, g)
+		i = g.End
+	}
+	return groups
+}
+
+// findDefinition returns the definition listing flag, preferring an exact
+// match over a case-insensitive one.
+func findDefinition(defs []ArgumentDefinition, flag string) *ArgumentDefinition {
+	var fold *ArgumentDefinition
+	for i := range defs {
+		for _, f := range defs[i].Flags {
+			if f == flag {
+				return &defs[i]
+			}
+			if fold == nil && strings.EqualFold(f, flag) {
+				fold = &defs[i]
+			}
+		}
+	}
+	return fold
+}
+
+// ReplaceAt returns a new stream with the token at i replaced by toks, which
+// may be empty to delete it or several tokens to split it. s is not modified.
+func (s TokenStream) ReplaceAt(i int, toks ...Token) TokenStream {
+	out := make(TokenStream, 0, len(s)-1+len(toks))
+	out = append(out, s[:i]...)
+	out = append(out, toks...)
+	return append(out, s[i+1:]...)
+}
+
+// InsertAfter returns a new stream with toks inserted after index i; pass -1
+// to insert at the front. s is not modified.
+func (s TokenStream) InsertAfter(i int, toks ...Token) TokenStream {
+	return slices.Insert(slices.Clone(s), i+1, toks...)
+}
+
+// Flatten reassembles groups, in the given order, into a new stream that
+// starts with s[0]. Use it after reordering the result of PairsWithValues.
+func (s TokenStream) Flatten(groups []ArgGroup) TokenStream {
+	if len(s) == 0 {
+		return TokenStream{}
+	}
+	out := TokenStream{s[0]}
+	for _, g := range groups {
+		out = append(out, s[g.Start:g.End]...)
+	}
+	return out
+}
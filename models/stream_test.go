@@ -0,0 +1,90 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func tok(typ TokenType, v string) Token { return Token{Type: typ, Value: v} }
+
+func values(s TokenStream) []string {
+	out := make([]string, len(s))
+	for i, t := range s {
+		out[i] = t.Value
+	}
+	return out
+}
+
+var streamDefs = []ArgumentDefinition{
+	{Flags: []string{"-f", "--file"}, ValueCount: 1},
+	{Flags: []string{"/urlcache"}, ValueCount: 0},
+	{Flags: []string{"-o"}, ValueCount: 2},
+}
+
+func TestTokenStream_Arguments(t *testing.T) {
+	s := TokenStream{tok(TokenTypeCommand, "x"), tok(TokenTypeArgument, "-a"), tok(TokenTypeValue, "v"), tok(TokenTypeArgument, "-b")}
+	if got := s.Arguments(); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("Arguments() = %v, want [1 3]", got)
+	}
+}
+
+func TestTokenStream_PairsWithValues(t *testing.T) {
+	s := TokenStream{
+		tok(TokenTypeCommand, "tool"),
+		tok(TokenTypeArgument, "-f"), tok(TokenTypeValue, "a.txt"), // 1–2
+		tok(TokenTypeArgument, "/URLCache"),                    // 3, case-insensitive match, no values
+		tok(TokenTypeValue, "pos"),                             // 4, positional
+		tok(TokenTypeArgument, "-o"), tok(TokenTypeValue, "1"), // 5–6, short one value
+		tok(TokenTypeArgument, "-x"), // 7, unknown flag
+	}
+	got := s.PairsWithValues(streamDefs)
+
+	want := []struct {
+		start, end int
+		flag       string
+	}{
+		{1, 3, "-f"},
+		{3, 4, "/urlcache"},
+		{4, 5, ""},
+		{5, 7, "-o"},
+		{7, 8, ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d groups %+v, want %d", len(got), got, len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		flag := ""
+		if g.Def != nil {
+			flag = g.Def.Flags[0]
+		}
+		if g.Start != w.start || g.End != w.end || flag != w.flag {
+			t.Errorf("group %d = {%d %d %q}, want {%d %d %q}", i, g.Start, g.End, flag, w.start, w.end, w.flag)
+		}
+	}
+
+	slices.Reverse(got)
+	if v := values(s.Flatten(got)); !slices.Equal(v, []string{"tool", "-x", "-o", "1", "pos", "/URLCache", "-f", "a.txt"}) {
+		t.Errorf("Flatten(reversed) = %v", v)
+	}
+}
+
+func TestTokenStream_Edits(t *testing.T) {
+	s := TokenStream{tok(TokenTypeCommand, "a"), tok(TokenTypeValue, "b"), tok(TokenTypeValue, "c")}
+
+	if v := values(s.ReplaceAt(1, tok(TokenTypeValue, "x"), tok(TokenTypeValue, "y"))); !slices.Equal(v, []string{"a", "x", "y", "c"}) {
+		t.Errorf("ReplaceAt split = %v", v)
+	}
+	if v := values(s.ReplaceAt(2)); !slices.Equal(v, []string{"a", "b"}) {
+		t.Errorf("ReplaceAt delete = %v", v)
+	}
+	if v := values(s.InsertAfter(0, tok(TokenTypeValue, "x"))); !slices.Equal(v, []string{"a", "x", "b", "c"}) {
+		t.Errorf("InsertAfter(0) = %v", v)
+	}
+	if v := values(s.InsertAfter(-1, tok(TokenTypeValue, "x"))); !slices.Equal(v, []string{"x", "a", "b", "c"}) {
+		t.Errorf("InsertAfter(-1) = %v", v)
+	}
+	if v := values(s); !slices.Equal(v, []string{"a", "b", "c"}) {
+		t.Errorf("edits modified the receiver: %v", v)
+	}
+}
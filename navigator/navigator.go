@@ -0,0 +1,244 @@
+// Package navigator summarizes obfuscation runs as MITRE ATT&CK Navigator
+// layers, for reporting which techniques a test campaign exercised.
+//
+// A Coverage collects the variants generated per profile; each is credited to
+// the ATT&CK techniques its profile is tagged with (the profile JSON's
+// "attack" field). Layer turns the tally into a layer file that the Navigator
+// (https://mitre-attack.github.io/attack-navigator/) opens as is: every
+// exercised technique is scored by its variant count and annotated with the
+// executables and modifiers behind it.
+package navigator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"cmdFuscator/models"
+)
+
+// ─── Coverage ─────────────────────────────────────────────────────────────────
+
+// Coverage tallies the variants generated per executable, for Layer. The zero
+// value is ready to use; it is not safe for concurrent use.
+type Coverage struct {
+	exes map[string]*exeCoverage
+}
+
+type exeCoverage struct {
+	techniques []string
+	platforms  []string
+	variants   int
+	modifiers  map[string]int // variants each modifier took part in
+}
+
+// Record credits one variant of a command for pf's executable, produced by
+// the modifiers in applied (engine.ObfuscateResult.Applied).
+func (c *Coverage) Record(pf *models.ProfileFile, applied []string) {
+	if c.exes == nil {
+		c.exes = make(map[string]*exeCoverage)
+	}
+	e := c.exes[pf.Name]
+	if e == nil {
+		e = &exeCoverage{techniques: pf.Techniques(), modifiers: make(map[string]int)}
+		for _, p := range pf.Profiles {
+			if p.Platform != "" && !slices.Contains(e.platforms, p.Platform) {
+				e.platforms = append(e.platforms, p.Platform)
+			}
+		}
+		c.exes[pf.Name] = e
+	}
+	e.variants++
+	for _, name := range applied {
+		e.modifiers[name]++
+	}
+}
+
+// Variants returns the number of variants recorded.
+func (c *Coverage) Variants() int {
+	n := 0
+	for _, e := range c.exes {
+		n += e.variants
+	}
+	return n
+}
+
+// Untagged returns, sorted, the executables with recorded variants whose
+// profiles name no ATT&CK technique: they are missing from the layer.
+func (c *Coverage) Untagged() []string {
+	var out []string
+	for name, e := range c.exes {
+		if len(e.techniques) == 0 {
+			out = append(out, name)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// ─── Layers ───────────────────────────────────────────────────────────────────
+
+// LayerVersion is the Navigator layer format version Layer writes.
+const LayerVersion = "4.5"
+
+// Layer is an ATT&CK Navigator layer file. Only the fields this package sets
+// are declared; the Navigator fills in defaults for the rest.
+type Layer struct {
+	Name        string      `json:"name"`
+	Versions    Versions    `json:"versions"`
+	Domain      string      `json:"domain"`
+	Description string      `json:"description,omitempty"`
+	Filters     *Filters    `json:"filters,omitempty"`
+	Techniques  []Technique `json:"techniques"`
+	Gradient    Gradient    `json:"gradient"`
+	Metadata    []Metadata  `json:"metadata,omitempty"`
+}
+
+// Versions records the layer format version.
+type Versions struct {
+	Layer string `json:"layer"`
+}
+
+// Filters limits the matrix the Navigator shows to the platforms covered.
+type Filters struct {
+	Platforms []string `json:"platforms"`
+}
+
+// Technique annotates one technique or sub-technique of the matrix.
+type Technique struct {
+	TechniqueID string     `json:"techniqueID"`
+	Score       *int       `json:"score,omitempty"`
+	Comment     string     `json:"comment,omitempty"`
+	Enabled     bool       `json:"enabled"`
+	Metadata    []Metadata `json:"metadata,omitempty"`
+
+	// ShowSubtechniques expands a parent technique in the matrix, so the
+	// scored sub-techniques under it are visible when the layer opens.
+	ShowSubtechniques bool `json:"showSubtechniques,omitempty"`
+}
+
+// Gradient maps scores to cell colours.
+type Gradient struct {
+	Colors   []string `json:"colors"`
+	MinValue int      `json:"minValue"`
+	MaxValue int      `json:"maxValue"`
+}
+
+// Metadata is a name/value annotation shown in a cell's tooltip.
+type Metadata struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// navigatorPlatforms maps profile platforms to the Navigator's names.
+var navigatorPlatforms = map[string]string{
+	"windows": "Windows",
+	"linux":   "Linux",
+	"macos":   "macOS",
+	"darwin":  "macOS",
+}
+
+// Layer returns the layer for the variants recorded so far, named name.
+// Techniques are listed by ID. A sub-technique's parent is expanded, and
+// listed unscored when no profile is tagged with it, so that the Navigator
+// shows the sub-technique when the layer opens.
+func (c *Coverage) Layer(name string) *Layer {
+	type tally struct {
+		variants int
+		exes     []string
+	}
+	tallies := make(map[string]*tally)
+	var platforms []string
+	for _, exe := range slices.Sorted(maps.Keys(c.exes)) {
+		e := c.exes[exe]
+		for _, id := range e.techniques {
+			t := tallies[id]
+			if t == nil {
+				t = &tally{}
+				tallies[id] = t
+			}
+			t.variants += e.variants
+			t.exes = append(t.exes, exe)
+		}
+		if len(e.techniques) == 0 {
+			continue
+		}
+		for _, p := range e.platforms {
+			if np, ok := navigatorPlatforms[strings.ToLower(p)]; ok && !slices.Contains(platforms, np) {
+				platforms = append(platforms, np)
+			}
+		}
+	}
+
+	l := &Layer{
+		Name:     name,
+		Versions: Versions{Layer: LayerVersion},
+		Domain:   "enterprise-attack",
+		Description: fmt.Sprintf("%s generated by cmdFuscator for %s.",
+			plural(c.Variants(), "variant"), plural(len(c.exes), "executable")),
+		Techniques: []Technique{},
+		Gradient:   Gradient{Colors: []string{"#ffe766", "#8ec843"}, MinValue: 0, MaxValue: 1},
+	}
+	if len(platforms) > 0 {
+		slices.Sort(platforms)
+		l.Filters = &Filters{Platforms: platforms}
+	}
+	if untagged := c.Untagged(); len(untagged) > 0 {
+		l.Metadata = append(l.Metadata, Metadata{Name: "untagged executables", Value: strings.Join(untagged, ", ")})
+	}
+
+	parents := make(map[string]bool)
+	for id := range tallies {
+		if parent, _, ok := strings.Cut(id, "."); ok {
+			parents[parent] = true
+		}
+	}
+	for id := range parents {
+		if tallies[id] == nil {
+			l.Techniques = append(l.Techniques, Technique{TechniqueID: id, Enabled: true, ShowSubtechniques: true})
+		}
+	}
+	for id, t := range tallies {
+		score := t.variants
+		l.Gradient.MaxValue = max(l.Gradient.MaxValue, score)
+		tech := Technique{
+			TechniqueID:       id,
+			Score:             &score,
+			Comment:           fmt.Sprintf("%s via %s", plural(t.variants, "variant"), strings.Join(t.exes, ", ")),
+			Enabled:           true,
+			ShowSubtechniques: parents[id],
+		}
+		for _, exe := range t.exes {
+			tech.Metadata = append(tech.Metadata, Metadata{Name: exe, Value: c.exes[exe].summary()})
+		}
+		l.Techniques = append(l.Techniques, tech)
+	}
+	slices.SortFunc(l.Techniques, func(a, b Technique) int { return strings.Compare(a.TechniqueID, b.TechniqueID) })
+	return l
+}
+
+// summary describes e's variants and the modifiers behind them, the most
+// used first.
+func (e *exeCoverage) summary() string {
+	names := slices.SortedFunc(maps.Keys(e.modifiers), func(a, b string) int {
+		if d := e.modifiers[b] - e.modifiers[a]; d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+	if len(names) == 0 {
+		return plural(e.variants, "variant") + ", no modifier applied"
+	}
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%s (%d)", name, e.modifiers[name])
+	}
+	return plural(e.variants, "variant") + ": " + strings.Join(names, ", ")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
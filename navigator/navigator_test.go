@@ -0,0 +1,110 @@
+package navigator
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"cmdFuscator/models"
+)
+
+func profile(name, platform string, attack ...string) *models.ProfileFile {
+	return &models.ProfileFile{Name: name, Profiles: []models.Profile{{Platform: platform, Attack: attack}}}
+}
+
+func TestLayer(t *testing.T) {
+	certutil := profile("certutil", "windows", "T1105", "t1140")
+	bash := profile("bash", "linux", "T1059.004")
+	sh := profile("sh", "linux", "T1059.004", "T1059")
+	notepad := profile("notepad", "windows")
+
+	var c Coverage
+	c.Record(certutil, []string{"RandomCase", "CharacterInsertion"})
+	c.Record(certutil, []string{"CharacterInsertion"})
+	c.Record(bash, []string{"RandomCase"})
+	c.Record(notepad, nil)
+
+	l := c.Layer("test")
+	if l.Name != "test" || l.Domain != "enterprise-attack" || l.Versions.Layer != LayerVersion {
+		t.Errorf("header = %q %q %q", l.Name, l.Domain, l.Versions.Layer)
+	}
+	if want := "4 variants generated by cmdFuscator for 3 executables."; l.Description != want {
+		t.Errorf("Description = %q, want %q", l.Description, want)
+	}
+	var ids []string
+	for _, tech := range l.Techniques {
+		ids = append(ids, tech.TechniqueID)
+	}
+	if want := []string{"T1059", "T1059.004", "T1105", "T1140"}; !slices.Equal(ids, want) {
+		t.Fatalf("techniques = %v, want %v", ids, want)
+	}
+	if parent := l.Techniques[0]; parent.Score != nil || !parent.ShowSubtechniques {
+		t.Errorf("parent T1059 = %+v, want unscored and expanded", parent)
+	}
+	tech := l.Techniques[2]
+	if tech.Score == nil || *tech.Score != 2 || tech.Comment != "2 variants via certutil" {
+		t.Errorf("T1105 = %+v, want 2 variants via certutil", tech)
+	}
+	if want := []Metadata{{"certutil", "2 variants: CharacterInsertion (2), RandomCase (1)"}}; !slices.Equal(tech.Metadata, want) {
+		t.Errorf("T1105 metadata = %v, want %v", tech.Metadata, want)
+	}
+	if l.Gradient.MaxValue != 2 {
+		t.Errorf("Gradient.MaxValue = %d, want 2", l.Gradient.MaxValue)
+	}
+	if l.Filters == nil || !slices.Equal(l.Filters.Platforms, []string{"Linux", "Windows"}) {
+		t.Errorf("Filters = %+v, want Linux and Windows", l.Filters)
+	}
+	if want := []Metadata{{"untagged executables", "notepad"}}; !slices.Equal(l.Metadata, want) {
+		t.Errorf("Metadata = %v, want %v", l.Metadata, want)
+	}
+
+	// A parent tagged itself is scored, and still expanded.
+	c.Record(sh, nil)
+	l = c.Layer("test")
+	for _, tech := range l.Techniques {
+		switch tech.TechniqueID {
+		case "T1059":
+			if tech.Score == nil || *tech.Score != 1 || !tech.ShowSubtechniques {
+				t.Errorf("T1059 = %+v, want scored 1 and expanded", tech)
+			}
+		case "T1059.004":
+			if *tech.Score != 2 || tech.Comment != "2 variants via bash, sh" {
+				t.Errorf("T1059.004 = %+v, want 2 variants via bash, sh", tech)
+			}
+		}
+	}
+}
+
+func TestLayerJSON(t *testing.T) {
+	var c Coverage
+	data, err := json.Marshal(c.Layer("empty"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The Navigator rejects a layer without a techniques array.
+	if !strings.Contains(string(data), `"techniques":[]`) {
+		t.Errorf("empty layer = %s, want an empty techniques array", data)
+	}
+
+	c.Record(profile("certutil", "windows", "T1105"), []string{"RandomCase"})
+	data, err = json.Marshal(c.Layer("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Techniques []map[string]any `json:"techniques"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	got := raw.Techniques[0]
+	for _, key := range []string{"techniqueID", "score", "comment", "enabled", "metadata"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("technique %v has no %q", got, key)
+		}
+	}
+	if _, ok := got["showSubtechniques"]; ok {
+		t.Errorf("technique %v should omit showSubtechniques", got)
+	}
+}
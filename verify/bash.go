@@ -0,0 +1,197 @@
+package verify
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ─── Bash ─────────────────────────────────────────────────────────────────────
+
+// Bash checks that bash runs variant as it runs original: the same simple
+// commands with the same argument vectors, joined by the same operators and
+// with the same redirections. It returns nil if so and a *Mismatch naming the
+// first difference if not, a variant bash cannot parse included. Errors of
+// any other kind mean original itself could not be parsed.
+//
+// Neither line is run. Variables expand to a placeholder naming them,
+// commands substituted with $(...) to their own parsed form, and globs are
+// left as written, so two lines differing only in how they quote or escape
+// the same words compare equal.
+func Bash(original, variant string) error {
+	want, err := ParseBash(original)
+	if err != nil {
+		return fmt.Errorf("verify: original: %w", err)
+	}
+	got, err := ParseBash(variant)
+	if err != nil {
+		return mismatch("bash cannot parse it: %v", err)
+	}
+	return compare(want, got)
+}
+
+// ParseBash parses command as bash does and returns it as a Line.
+func ParseBash(command string) (Line, error) {
+	f, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, err
+	}
+	return bashStmts(f.Stmts)
+}
+
+// bashLine builds a Line from a parsed command line.
+type bashLine struct {
+	cfg  *expand.Config
+	line Line
+}
+
+// bashStmts returns the Line of stmts. Substituted commands get a bashLine
+// of their own, as expanding them shares no state with the outer line.
+func bashStmts(stmts []*syntax.Stmt) (Line, error) {
+	b := &bashLine{cfg: &expand.Config{Env: placeholders{}, CmdSubst: cmdSubst, ProcSubst: procSubst}}
+	if err := b.stmts(stmts); err != nil {
+		return nil, err
+	}
+	return b.line, nil
+}
+
+func (b *bashLine) op(op string) {
+	b.line = append(b.line, Part{Op: op})
+}
+
+func (b *bashLine) stmts(stmts []*syntax.Stmt) error {
+	for i, s := range stmts {
+		if i > 0 && !stmts[i-1].Background {
+			b.op(";")
+		}
+		if err := b.stmt(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bashLine) stmt(s *syntax.Stmt) error {
+	if s.Negated {
+		b.op("!")
+	}
+	redirects, err := b.redirects(s.Redirs)
+	if err != nil {
+		return err
+	}
+	switch c := s.Cmd.(type) {
+	case nil:
+		// A bare redirection, such as "> file".
+		b.line = append(b.line, Part{Command: &Command{Redirects: redirects}})
+	case *syntax.CallExpr:
+		cmd := &Command{Redirects: redirects}
+		for _, a := range c.Assigns {
+			value := ""
+			if a.Value != nil {
+				if value, err = expand.Literal(b.cfg, a.Value); err != nil {
+					return err
+				}
+			}
+			cmd.Env = append(cmd.Env, a.Name.Value+"="+value)
+		}
+		if cmd.Args, err = expand.Fields(b.cfg, c.Args...); err != nil {
+			return err
+		}
+		b.line = append(b.line, Part{Command: cmd})
+	case *syntax.BinaryCmd:
+		if err := b.stmt(c.X); err != nil {
+			return err
+		}
+		b.op(c.Op.String())
+		if err := b.stmt(c.Y); err != nil {
+			return err
+		}
+		b.redirectOps(redirects)
+	case *syntax.Subshell:
+		b.op("(")
+		if err := b.stmts(c.Stmts); err != nil {
+			return err
+		}
+		b.op(")")
+		b.redirectOps(redirects)
+	case *syntax.Block:
+		b.op("{")
+		if err := b.stmts(c.Stmts); err != nil {
+			return err
+		}
+		b.op("}")
+		b.redirectOps(redirects)
+	default:
+		// Compound commands (if, for, [[ ]], functions, ...) are compared as
+		// bash would print them, quotes and all.
+		var sb strings.Builder
+		if err := syntax.NewPrinter(syntax.Minify(true)).Print(&sb, c); err != nil {
+			return err
+		}
+		b.op(sb.String())
+		b.redirectOps(redirects)
+	}
+	if s.Background {
+		b.op("&")
+	}
+	return nil
+}
+
+func (b *bashLine) redirects(rs []*syntax.Redirect) ([]string, error) {
+	var out []string
+	for _, r := range rs {
+		target, err := expand.Literal(b.cfg, r.Word)
+		if err != nil {
+			return nil, err
+		}
+		n := ""
+		if r.N != nil {
+			n = r.N.Value
+		}
+		out = append(out, n+r.Op.String()+target)
+	}
+	return out, nil
+}
+
+// redirectOps records the redirections of a compound command, which belong
+// to no simple command, as operators.
+func (b *bashLine) redirectOps(redirects []string) {
+	for _, r := range redirects {
+		b.op(r)
+	}
+}
+
+// cmdSubst expands $(...) to the substituted commands' Line.
+func cmdSubst(w io.Writer, cs *syntax.CmdSubst) error {
+	inner, err := bashStmts(cs.Stmts)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "$("+inner.String()+")")
+	return err
+}
+
+// procSubst expands <(...) and >(...) as cmdSubst does $(...).
+func procSubst(ps *syntax.ProcSubst) (string, error) {
+	inner, err := bashStmts(ps.Stmts)
+	if err != nil {
+		return "", err
+	}
+	return ps.Op.String() + inner.String() + ")", nil
+}
+
+// placeholders is an environment in which every variable but IFS is set to
+// its own name, as in "$HOME". IFS keeps bash's default.
+type placeholders struct{}
+
+func (placeholders) Get(name string) expand.Variable {
+	if name == "IFS" {
+		return expand.Variable{}
+	}
+	return expand.Variable{Set: true, Kind: expand.String, Str: "$" + name}
+}
+
+func (placeholders) Each(func(name string, vr expand.Variable) bool) {}
@@ -0,0 +1,64 @@
+package verify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBash(t *testing.T) {
+	tests := []struct {
+		name              string
+		original, variant string
+		want              string // substring of the mismatch; empty: none
+	}{
+		{"quotes", `curl -o /tmp/a https://x`, `c''u"r"l -o '/tmp/a' https://\x`, ""},
+		{"variables", `tar -czf "$HOME/a b.tgz" ~`, `t\ar -czf "$HOME"/a\ b.tgz ~`, ""},
+		{"substitution", `echo $(id -u)`, `echo $(i''d -u)`, ""},
+		{"pipeline", `ps aux | grep x && echo ok`, `ps aux|grep x&&echo ok`, ""},
+		{"case", `bash -c id`, `bash -C id`, `word 2 of command 1 is "-C", the original "-c"`},
+		{"joiner", `bash -c id`, "bash -c i\u200dd", `word 3 of command 1 is "i\u200dd"`},
+		{"escaped pipe", `ps aux | grep x`, `ps aux \| grep x`, "runs 1 command, the original 2"},
+		{"operator", `a && b`, `a || b`, `joins its commands with "||", the original with "&&"`},
+		{"split", `echo "a b"`, `echo a b`, "command 1 has 3 words, the original 2"},
+		{"unquoted variable", `ls "$DIR"`, `ls $DIR`, ""},
+		{"redirect", `id >/tmp/a`, `id '>/tmp/a'`, "command 1 has 2 words"},
+		{"inner command", `echo "$(id -u)"`, `echo "$(id -U)"`, `word 2 of command 1 is "$(\"id\" \"-U\")"`},
+		{"assignment", `A=1 env`, `'A=1' env`, "command 1 has 0 assignments, the original 1"},
+		{"unparsable", `echo 'a'`, `echo 'a`, "bash cannot parse it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Bash(tt.original, tt.variant)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Bash = %v, want nil", err)
+				}
+				return
+			}
+			var m *Mismatch
+			if !errors.As(err, &m) || !strings.Contains(m.Reason, tt.want) {
+				t.Errorf("Bash = %v, want a mismatch containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBashOriginal(t *testing.T) {
+	err := Bash(`echo "a`, `echo a`)
+	var m *Mismatch
+	if err == nil || errors.As(err, &m) {
+		t.Errorf("Bash = %v, want an error about the original", err)
+	}
+}
+
+func TestParseBash(t *testing.T) {
+	line, err := ParseBash(`A=1 c''url -o "/tmp/a b" x 2>&1 | (cd /tmp; ls) &`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"A=1" "curl" "-o" "/tmp/a b" "x" "2>&1" | ( "cd" "/tmp" ; "ls" ) &`
+	if got := line.String(); got != want {
+		t.Errorf("ParseBash = %s\nwant         %s", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ─── cmd.exe ──────────────────────────────────────────────────────────────────
+
+// ErrNoCmd is returned by Cmd off Windows, where there is no cmd.exe to ask.
+var ErrNoCmd = errors.New("verify: cmd.exe checks need Windows")
+
+// Cmd checks that cmd.exe hands the program variant starts the argument
+// vector it hands original's. It has cmd.exe echo each line, which applies
+// the caret escapes and %VARIABLE% expansions the real run would, then splits
+// the echoed line into arguments as the Microsoft C runtime does. Only echo
+// is ever run, never the program the line names. Arguments are compared
+// ignoring case, as Windows programs read their switches.
+//
+// Cmd returns nil if the vectors match and a *Mismatch naming the first
+// difference if not, including a variant cmd.exe would split into more than
+// one command. It returns ErrNoCmd off Windows, and other errors when
+// original cannot be checked or cmd.exe cannot be run.
+func Cmd(original, variant string) error {
+	if !haveCmd {
+		return ErrNoCmd
+	}
+	if op, ok := cmdOperator(original); ok {
+		return fmt.Errorf("verify: original: %q starts another command, which echo cannot check", op)
+	}
+	if op, ok := cmdOperator(variant); ok {
+		return mismatch("cmd.exe reads an unescaped %q in it as starting another command", op)
+	}
+	want, err := cmdEcho(original)
+	if err != nil {
+		return err
+	}
+	got, err := cmdEcho(variant)
+	if err != nil {
+		return err
+	}
+	return compare(argvLine(want), argvLine(got))
+}
+
+// argvLine returns the single command of an echoed command line, its
+// arguments lowercased for comparison.
+func argvLine(echoed string) Line {
+	args := SplitArgs(echoed)
+	for i, a := range args {
+		args[i] = strings.ToLower(a)
+	}
+	return Line{{Command: &Command{Args: args}}}
+}
+
+// cmdOperator returns the first character of line that cmd.exe would read as
+// an operator: &, |, < or > outside double quotes and not escaped with a
+// caret, or a line break. Running such a line through echo would run what
+// follows it too.
+func cmdOperator(line string) (string, bool) {
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\r' || c == '\n':
+			return string(c), true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '^':
+			i++
+		case strings.IndexByte("&|<>", c) >= 0:
+			return string(c), true
+		}
+	}
+	return "", false
+}
+
+// SplitArgs splits a Windows command line into arguments as the Microsoft C
+// runtime (and CommandLineToArgvW) does for a program's argv: arguments are
+// separated by spaces and tabs outside double quotes, quotes are removed,
+// "" inside quotes is a literal quote, and backslashes are literal unless
+// they precede a quote, when each pair yields one backslash and an odd one
+// out escapes the quote.
+func SplitArgs(line string) []string {
+	var (
+		args    []string
+		b       strings.Builder
+		inArg   bool
+		quoted  bool
+		slashes int
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			slashes++
+			inArg = true
+			continue
+		case c == '"':
+			b.WriteString(strings.Repeat(`\`, slashes/2))
+			inArg = true
+			if slashes%2 == 1 {
+				b.WriteByte('"')
+			} else if quoted && i+1 < len(line) && line[i+1] == '"' {
+				b.WriteByte('"')
+				i++
+			} else {
+				quoted = !quoted
+			}
+		case (c == ' ' || c == '\t') && !quoted:
+			b.WriteString(strings.Repeat(`\`, slashes))
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+		default:
+			b.WriteString(strings.Repeat(`\`, slashes))
+			b.WriteByte(c)
+			inArg = true
+		}
+		slashes = 0
+	}
+	b.WriteString(strings.Repeat(`\`, slashes))
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args
+}
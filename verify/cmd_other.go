@@ -0,0 +1,9 @@
+//go:build !windows
+
+package verify
+
+const haveCmd = false
+
+func cmdEcho(string) (string, error) {
+	return "", ErrNoCmd
+}
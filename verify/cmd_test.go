@@ -0,0 +1,87 @@
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"runtime"
+	"slices"
+	"testing"
+
+	"cmdFuscator/engine"
+	"cmdFuscator/engine/modifiers"
+	"cmdFuscator/engine/modifiers/quotesplit"
+	"cmdFuscator/models"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`certutil  -f	x`, []string{"certutil", "-f", "x"}},
+		{`a "b c" d`, []string{"a", "b c", "d"}},
+		{`ce"r"tutil -url""cache`, []string{"certutil", "-urlcache"}},
+		{`a "b ""c"" d"`, []string{"a", `b "c" d`}},
+		{`a \"b\" c`, []string{"a", `"b"`, "c"}},
+		{`C:\dir\ "C:\dir\\" \\\"x`, []string{`C:\dir\`, `C:\dir\`, `\"x`}},
+		{`a ""`, []string{"a", ""}},
+		{`  `, nil},
+	}
+	for _, tt := range tests {
+		if got := SplitArgs(tt.line); !slices.Equal(got, tt.want) {
+			t.Errorf("SplitArgs(%s) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+// TestSplitArgs_QuoteSplitting checks that QuoteSplitting's chunks, rendered
+// for cmd.exe, reach the program as the words they were split from.
+func TestSplitArgs_QuoteSplitting(t *testing.T) {
+	tokens := []models.Token{
+		{Type: models.TokenTypeCommand, Value: "certutil"},
+		{Type: models.TokenTypeArgument, Value: "-urlcache"},
+		{Type: models.TokenTypeValue, Value: "hello big world"},
+		{Type: models.TokenTypeURL, Value: "https://example.com/a?b=1"},
+	}
+	want := []string{"certutil", "-urlcache", "hello big world", "https://example.com/a?b=1"}
+	cfg := json.RawMessage(`{"AppliesTo":["argument","value","url"],"Probability":"1.0","Chunks":"3"}`)
+	for seed := range int64(50) {
+		out, err := (&quotesplit.QuoteSplitting{}).ApplyContext(modifiers.Context{Rand: rand.New(rand.NewSource(seed))}, tokens, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		line := engine.RenderFor(out, engine.TargetCmd)
+		if got := SplitArgs(line); !slices.Equal(got, want) {
+			t.Errorf("seed %d: %s splits into %q, want %q", seed, line, got, want)
+		}
+	}
+}
+
+func TestCmdOperator(t *testing.T) {
+	tests := []struct {
+		line string
+		want string // the operator found; empty: none
+	}{
+		{`certutil -f https://x/?a=1^&b=2 a`, ""},
+		{`echo "a & b" ^| c^>d`, ""},
+		{`certutil -f https://x/?a=1&b=2 a`, "&"},
+		{`a "b" | c`, "|"},
+		{`a "b ^" > c`, ">"},
+		{"a\nb", "\n"},
+	}
+	for _, tt := range tests {
+		if op, ok := cmdOperator(tt.line); op != tt.want || ok != (tt.want != "") {
+			t.Errorf("cmdOperator(%q) = %q, %v; want %q", tt.line, op, ok, tt.want)
+		}
+	}
+}
+
+func TestCmdUnsupported(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("cmd.exe is available")
+	}
+	if err := Cmd("certutil -f x", "c^ertutil -f x"); !errors.Is(err, ErrNoCmd) {
+		t.Errorf("Cmd = %v, want ErrNoCmd", err)
+	}
+}
@@ -0,0 +1,45 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+)
+
+const haveCmd = true
+
+// cmdEcho returns what cmd.exe echoes for line. The command line is passed
+// verbatim, as Go's argument quoting would change what cmd.exe parses; /s
+// strips only the outer quotes, /d skips AutoRun commands and /u makes echo
+// write UTF-16, so inserted Unicode survives the pipe.
+func cmdEcho(line string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	comspec := os.Getenv("ComSpec")
+	if comspec == "" {
+		comspec = `C:\Windows\System32\cmd.exe`
+	}
+	cmd := exec.CommandContext(ctx, comspec)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine:    `cmd.exe /d /u /v:off /s /c "echo ` + line + `"`,
+		HideWindow: true,
+	}
+	cmd.Dir = os.TempDir()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("verify: cmd.exe: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	out := stdout.Bytes()
+	units := make([]uint16, len(out)/2)
+	for i := range units {
+		units[i] = uint16(out[2*i]) | uint16(out[2*i+1])<<8
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\r\n"), nil
+}
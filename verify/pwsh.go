@@ -0,0 +1,112 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"cmdFuscator/engine"
+)
+
+// ─── PowerShell ───────────────────────────────────────────────────────────────
+
+// ErrNoPwsh is returned by LookPwsh when no PowerShell is installed.
+var ErrNoPwsh = errors.New("verify: pwsh not found")
+
+// DefaultTimeout bounds each parse of a Pwsh with no Timeout of its own.
+const DefaultTimeout = 10 * time.Second
+
+// Pwsh is an engine.Validator that has PowerShell's own parser,
+// System.Management.Automation.Language.Parser, check commands rendered for
+// PowerShell. The command is only parsed, never run: it reaches pwsh
+// base64-encoded inside a fixed -Command script, which starts without
+// profiles or a console. Results are cached per command, so a batch that
+// keeps producing the same variant starts pwsh once for it.
+type Pwsh struct {
+	Path    string        // the PowerShell executable
+	Timeout time.Duration // per parse; zero means DefaultTimeout
+
+	mu     sync.Mutex
+	parsed map[string]error
+}
+
+// LookPwsh returns a Pwsh running the pwsh on $PATH, or on Windows the
+// built-in powershell.exe when PowerShell 7 is missing. It returns ErrNoPwsh
+// when there is neither.
+func LookPwsh() (*Pwsh, error) {
+	names := []string{"pwsh"}
+	if runtime.GOOS == "windows" {
+		names = append(names, "powershell")
+	}
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			return &Pwsh{Path: path}, nil
+		}
+	}
+	return nil, ErrNoPwsh
+}
+
+// Validate checks that PowerShell parses output without syntax errors when
+// it was rendered for PowerShell, and returns nil for other targets. It
+// returns a *Mismatch listing the errors pwsh reports, or another error when
+// pwsh itself could not be run.
+func (p *Pwsh) Validate(output string, target engine.RenderTarget) error {
+	if target != engine.TargetPowerShell {
+		return nil
+	}
+	p.mu.Lock()
+	err, ok := p.parsed[output]
+	p.mu.Unlock()
+	if ok {
+		return err
+	}
+	err = p.parse(output)
+	p.mu.Lock()
+	if p.parsed == nil {
+		p.parsed = make(map[string]error)
+	}
+	p.parsed[output] = err
+	p.mu.Unlock()
+	return err
+}
+
+// parseScript has PowerShell parse a base64-encoded UTF-8 command and print
+// one line per syntax error.
+const parseScript = `$src = [Text.Encoding]::UTF8.GetString([Convert]::FromBase64String('%s'))
+$tokens = $null; $errs = $null
+[void][System.Management.Automation.Language.Parser]::ParseInput($src, [ref]$tokens, [ref]$errs)
+foreach ($e in $errs) { '{0}:{1}: {2}' -f $e.Extent.StartLineNumber, $e.Extent.StartColumnNumber, $e.Message }`
+
+func (p *Pwsh) parse(command string) error {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	script := fmt.Sprintf(parseScript, base64.StdEncoding.EncodeToString([]byte(command)))
+	cmd := exec.CommandContext(ctx, p.Path, "-NoProfile", "-NonInteractive", "-NoLogo", "-Command", script)
+	cmd.Dir = os.TempDir()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("verify: pwsh: %w: %s", err, msg)
+		}
+		return fmt.Errorf("verify: pwsh: %w", err)
+	}
+	out := strings.TrimSpace(strings.ReplaceAll(stdout.String(), "\r\n", "\n"))
+	if out == "" {
+		return nil
+	}
+	return mismatch("PowerShell cannot parse it: %s", strings.ReplaceAll(out, "\n", "; "))
+}
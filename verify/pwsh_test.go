@@ -0,0 +1,100 @@
+package verify
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"cmdFuscator/engine"
+)
+
+// fakePwsh writes a shell script standing in for pwsh that logs its
+// arguments to a file, then runs body. It returns the Pwsh and the log.
+func fakePwsh(t *testing.T, body string) (*Pwsh, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake pwsh is a shell script")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" >> " + log + "\n" + body + "\n"
+	path := filepath.Join(dir, "pwsh")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return &Pwsh{Path: path}, log
+}
+
+func TestPwsh(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string // substring of the error; empty: none
+		fail bool   // whether the error is a failure to run pwsh, not a *Mismatch
+	}{
+		{"parses", "exit 0", "", false},
+		{"syntax errors", `printf '1:13: Missing closing '"'}'"' in statement block.\r\n1:1: Unexpected token.\r\n'`,
+			"PowerShell cannot parse it: 1:13: Missing closing '}' in statement block.; 1:1: Unexpected token.", false},
+		{"crash", "echo boom >&2; exit 3", "verify: pwsh: exit status 3: boom", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := fakePwsh(t, tt.body)
+			err := p.Validate("Get-Process | Where-Object {", engine.TargetPowerShell)
+			var m *Mismatch
+			switch {
+			case tt.want == "":
+				if err != nil {
+					t.Errorf("Validate = %v, want nil", err)
+				}
+			case errors.As(err, &m) == tt.fail || !strings.Contains(err.Error(), tt.want):
+				t.Errorf("Validate = %v (mismatch: %v), want %q", err, m != nil, tt.want)
+			}
+		})
+	}
+}
+
+func TestPwshInvocation(t *testing.T) {
+	p, log := fakePwsh(t, "exit 0")
+	command := "gEt-pRoCeSs -Name 'a b'; Remove-Item x"
+	for range 2 {
+		if err := p.Validate(command, engine.TargetPowerShell); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Validate("rm -rf /", engine.TargetBash); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := string(data)
+	if n := strings.Count(args, "-Command\n"); n != 1 {
+		t.Errorf("pwsh ran %d times, want once for the PowerShell command:\n%s", n, args)
+	}
+	if !strings.HasPrefix(args, "-NoProfile\n-NonInteractive\n") {
+		t.Errorf("pwsh args:\n%s", args)
+	}
+	if strings.Contains(args, "Remove-Item") || !strings.Contains(args, base64.StdEncoding.EncodeToString([]byte(command))) {
+		t.Errorf("the command should reach pwsh base64-encoded only:\n%s", args)
+	}
+}
+
+func TestPwshReal(t *testing.T) {
+	p, err := LookPwsh()
+	if err != nil {
+		t.Skip(err)
+	}
+	if err := p.Validate("g`e`t-process -Name 'a b' | Where-Object { $_.Id -gt 4 }", engine.TargetPowerShell); err != nil {
+		t.Errorf("valid command: %v", err)
+	}
+	var m *Mismatch
+	if err := p.Validate("Get-Process | Where-Object {", engine.TargetPowerShell); !errors.As(err, &m) {
+		t.Errorf("unclosed block: Validate = %v, want a mismatch", err)
+	}
+}
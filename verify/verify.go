@@ -0,0 +1,147 @@
+// Package verify checks that obfuscated command lines still do what the
+// original did. Bash parses both lines as bash would before running them and
+// compares the resulting argument vectors, operators and redirections word by
+// word. Cmd, on Windows, has cmd.exe echo both lines and compares the
+// argument vectors the echoed lines split into. Pwsh, whose parser is
+// PowerShell's own, checks that a variant still parses at all.
+package verify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ─── Lines ────────────────────────────────────────────────────────────────────
+
+// Line is a command line as a shell runs it: its simple commands and the
+// operators around them, in source order.
+type Line []Part
+
+// Part is one element of a Line: either a simple command or an operator
+// (such as |, && or a subshell's parenthesis) joining commands.
+type Part struct {
+	Op      string   // the operator; empty for a command
+	Command *Command // the command; nil for an operator
+}
+
+// Command is a simple command after expansion, word splitting and quote
+// removal.
+type Command struct {
+	Env       []string // NAME=value assignments prefixed to the command
+	Args      []string // the argument vector; Args[0] names the program
+	Redirects []string // each redirection's operator and target, e.g. ">/tmp/a"
+}
+
+// String returns l with every word quoted, the form a mismatch shows it in.
+func (l Line) String() string {
+	parts := make([]string, len(l))
+	for i, p := range l {
+		if p.Command == nil {
+			parts[i] = p.Op
+			continue
+		}
+		var words []string
+		for _, group := range [][]string{p.Command.Env, p.Command.Args, p.Command.Redirects} {
+			for _, w := range group {
+				words = append(words, strconv.Quote(w))
+			}
+		}
+		parts[i] = strings.Join(words, " ")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (l Line) commands() int {
+	n := 0
+	for _, p := range l {
+		if p.Command != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// ─── Mismatches ───────────────────────────────────────────────────────────────
+
+// Mismatch is the error a check returns for a variant that does not do what
+// the original did, or does not parse at all. Reason names the first
+// difference or the syntax errors found.
+type Mismatch struct {
+	Reason string
+}
+
+func (m *Mismatch) Error() string {
+	return "verify: " + m.Reason
+}
+
+func mismatch(format string, args ...any) *Mismatch {
+	return &Mismatch{Reason: fmt.Sprintf(format, args...)}
+}
+
+// compare returns a *Mismatch naming the first difference between want, the
+// original line, and got, the variant's, or nil if they are the same.
+// Differences in shape (how many commands, which operators) are reported
+// before differences in the words of one command.
+func compare(want, got Line) error {
+	if w, g := want.commands(), got.commands(); w != g {
+		return mismatch("runs %s, the original %d", count(g, "command"), w)
+	}
+	if w, g := operators(want), operators(got); w != g {
+		return mismatch("joins its commands with %s, the original with %s", g, w)
+	}
+	n := 0
+	for i, p := range want {
+		if p.Command == nil {
+			continue
+		}
+		n++
+		if err := compareCommand(n, p.Command, got[i].Command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compareCommand(n int, want, got *Command) error {
+	for _, f := range []struct {
+		what      string
+		want, got []string
+	}{
+		{"assignment", want.Env, got.Env},
+		{"word", want.Args, got.Args},
+		{"redirection", want.Redirects, got.Redirects},
+	} {
+		if len(f.want) != len(f.got) {
+			return mismatch("command %d has %s, the original %d", n, count(len(f.got), f.what), len(f.want))
+		}
+		for i := range f.want {
+			if f.want[i] != f.got[i] {
+				return mismatch("%s %d of command %d is %s, the original %s",
+					f.what, i+1, n, strconv.QuoteToASCII(f.got[i]), strconv.QuoteToASCII(f.want[i]))
+			}
+		}
+	}
+	return nil
+}
+
+// operators lists l's operators, for a message; "none" if it has none.
+func operators(l Line) string {
+	var ops []string
+	for _, p := range l {
+		if p.Command == nil {
+			ops = append(ops, strconv.Quote(p.Op))
+		}
+	}
+	if len(ops) == 0 {
+		return "none"
+	}
+	return strings.Join(ops, " ")
+}
+
+func count(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}